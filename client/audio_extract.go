@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+// DownloadOptions.ExtractAudio values.
+const (
+	// AudioFormatBest keeps the source codec: opus sources become .opus,
+	// everything else .m4a.
+	AudioFormatBest = "best"
+	AudioFormatOpus = "opus"
+	AudioFormatM4A  = "m4a"
+)
+
+func validateAudioFormat(format string) error {
+	switch format {
+	case "", AudioFormatBest, AudioFormatOpus, AudioFormatM4A:
+		return nil
+	}
+	return fmt.Errorf("unsupported audio format %q (want best, opus or m4a)", format)
+}
+
+// audioExtractionTarget returns the output extension for format and whether
+// the source codec has to be re-encoded to fit it.
+func audioExtractionTarget(format, sourceCodec string) (string, bool) {
+	isOpus := sourceCodec == "opus"
+	isAAC := strings.HasPrefix(sourceCodec, "mp4a")
+	switch format {
+	case AudioFormatOpus:
+		return AudioFormatOpus, !isOpus
+	case AudioFormatM4A:
+		return AudioFormatM4A, !isAAC
+	}
+	if isOpus {
+		return AudioFormatOpus, false
+	}
+	return AudioFormatM4A, !isAAC
+}
+
+// sourceAudioCodec returns the first audio codec named in the mime types of
+// the fetched formats (e.g. "opus", "mp4a.40.2"), or "" when none is known.
+func sourceAudioCodec(formats []types.FormatInfo) string {
+	for _, f := range formats {
+		if !f.HasAudio {
+			continue
+		}
+		for _, codec := range parseMimeCodecs(f.MimeType) {
+			if codec == "opus" || codec == "vorbis" || strings.HasPrefix(codec, "mp4a") || strings.HasPrefix(codec, "ac-3") || strings.HasPrefix(codec, "ec-3") {
+				return codec
+			}
+		}
+	}
+	return ""
+}
+
+func parseMimeCodecs(mimeType string) []string {
+	_, params, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return nil
+	}
+	var codecs []string
+	for _, c := range strings.Split(params["codecs"], ",") {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			codecs = append(codecs, c)
+		}
+	}
+	return codecs
+}
+
+// extractAudio replaces res.OutputPath with an audio-only, tagged copy.
+func (c *Client) extractAudio(ctx context.Context, res *DownloadResult, format string, meta types.Metadata) (*DownloadResult, error) {
+	extractor, ok := c.config.Muxer.(AudioExtractor)
+	if !ok || !c.config.Muxer.Available() {
+		c.warnf("audio extraction needs ffmpeg; keeping %s as downloaded", res.OutputPath)
+		res.FallbackReason = "muxer_unavailable"
+		return res, nil
+	}
+	ext, transcode := audioExtractionTarget(format, sourceAudioCodec(res.SelectedFormats))
+	base := strings.TrimSuffix(res.OutputPath, filepath.Ext(res.OutputPath))
+	outputPath := base + "." + ext
+	// Extract next to the source when the names collide; the muxer picks the
+	// container from the extension, so keep it last.
+	tmpPath := outputPath
+	if outputPath == res.OutputPath {
+		tmpPath = base + ".extract." + ext
+	}
+
+	forgetOutputDigest(ctx, outputPath)
+	c.emitDownloadEvent("extract_audio", "start", res.VideoID, outputPath, fmt.Sprintf("format=%s transcode=%t", ext, transcode))
+	if err := extractor.ExtractAudio(ctx, res.OutputPath, tmpPath, transcode, meta); err != nil {
+		_ = os.Remove(tmpPath)
+		c.emitDownloadEvent("extract_audio", "failure", res.VideoID, outputPath, err.Error())
+		return nil, err
+	}
+	if tmpPath != outputPath {
+		if err := os.Rename(tmpPath, outputPath); err != nil {
+			return nil, err
+		}
+	} else {
+		_ = os.Remove(res.OutputPath)
+	}
+	if st, err := os.Stat(outputPath); err == nil {
+		res.Bytes = st.Size()
+	}
+	res.OutputPath = outputPath
+	c.emitDownloadEvent("extract_audio", "success", res.VideoID, outputPath, "")
+	return res, nil
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+type testAudioExtractor struct {
+	testMuxer
+	transcode bool
+	meta      types.Metadata
+}
+
+func (m *testAudioExtractor) ExtractAudio(ctx context.Context, inputPath, outputPath string, transcode bool, meta types.Metadata) error {
+	m.transcode = transcode
+	m.meta = meta
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, append([]byte("tagged:"), data...), 0o644)
+}
+
+func TestAudioExtractionTarget(t *testing.T) {
+	cases := []struct {
+		format, codec string
+		wantExt       string
+		wantTranscode bool
+	}{
+		{AudioFormatBest, "opus", "opus", false},
+		{AudioFormatBest, "mp4a.40.2", "m4a", false},
+		{AudioFormatBest, "vorbis", "m4a", true},
+		{AudioFormatOpus, "mp4a.40.2", "opus", true},
+		{AudioFormatM4A, "opus", "m4a", true},
+		{AudioFormatM4A, "mp4a.40.5", "m4a", false},
+	}
+	for _, tc := range cases {
+		ext, transcode := audioExtractionTarget(tc.format, tc.codec)
+		if ext != tc.wantExt || transcode != tc.wantTranscode {
+			t.Errorf("audioExtractionTarget(%q, %q) = (%q, %v), want (%q, %v)", tc.format, tc.codec, ext, transcode, tc.wantExt, tc.wantTranscode)
+		}
+	}
+}
+
+func TestDownload_ExtractAudioTagsAndRenames(t *testing.T) {
+	mediaBase := "https://media.example"
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Episode 1","author":"Show"},
+					"streamingData":{"adaptiveFormats":[
+						{"itag":251,"url":"` + mediaBase + `/a.webm","mimeType":"audio/webm; codecs=\"opus\"","bitrate":160000,"audioSampleRate":"48000"}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.Path == "/watch":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`<html><script src="/s/player/test/base.js"></script></html>`)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/s/player/"):
+				return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(bytes.NewBufferString(`var cfg={signatureTimestamp:20494};`))}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/a.webm":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("audio")), Header: make(http.Header)}, nil
+			default:
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+			}
+		}),
+	}
+	extractor := &testAudioExtractor{}
+	c := New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}, Muxer: extractor})
+
+	dir := t.TempDir()
+	res, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{
+		Mode:         SelectionModeAudioOnly,
+		OutputPath:   filepath.Join(dir, "%(uploader)s", "%(title)s.%(ext)s"),
+		ExtractAudio: AudioFormatBest,
+	})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	want := filepath.Join(dir, "Show", "Episode 1.opus")
+	if res.OutputPath != want {
+		t.Fatalf("OutputPath = %q, want %q", res.OutputPath, want)
+	}
+	if extractor.transcode || extractor.meta.Title != "Episode 1" || extractor.meta.Artist != "Show" {
+		t.Fatalf("extractor got transcode=%v meta=%+v", extractor.transcode, extractor.meta)
+	}
+	if data, err := os.ReadFile(want); err != nil || string(data) != "tagged:audio" {
+		t.Fatalf("ReadFile(output) = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Show", "Episode 1.webm")); !os.IsNotExist(err) {
+		t.Fatalf("source file should be removed, Stat err = %v", err)
+	}
+}
+
+func TestDownload_RejectsUnknownAudioFormat(t *testing.T) {
+	c := New(Config{})
+	if _, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{ExtractAudio: "flac"}); err == nil || !strings.Contains(err.Error(), "unsupported audio format") {
+		t.Fatalf("Download() error = %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// BandwidthUsage is the client's HTTP traffic so far: every response body
+// read through Config.HTTPClient, media and metadata alike. Transfers made
+// by an ExternalDownloader are not seen and not counted.
+type BandwidthUsage struct {
+	Bytes int64
+	// Limit is Config.MaxTotalBytes, 0 when unlimited.
+	Limit int64
+}
+
+// bandwidthMeter tallies response bytes and enforces Config.MaxTotalBytes.
+type bandwidthMeter struct {
+	limit int64
+	bytes atomic.Int64
+}
+
+// exceeded reports whether the tally has passed the limit.
+func (m *bandwidthMeter) exceeded() bool {
+	return m.limit > 0 && m.bytes.Load() > m.limit
+}
+
+func (m *bandwidthMeter) err() error {
+	return &MaxTotalBytesError{Limit: m.limit, Bytes: m.bytes.Load()}
+}
+
+// bandwidthTransport counts the response bodies of the client's HTTP
+// traffic and refuses requests once the budget is spent.
+type bandwidthTransport struct {
+	base  http.RoundTripper
+	meter *bandwidthMeter
+}
+
+func (t *bandwidthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.meter.exceeded() {
+		return nil, t.meter.err()
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.Body == nil {
+		return resp, err
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body, meter: t.meter}
+	return resp, nil
+}
+
+// countingBody adds what is read from a response body to its meter and
+// fails the read that crosses the budget.
+type countingBody struct {
+	io.ReadCloser
+	meter *bandwidthMeter
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.meter.bytes.Add(int64(n))
+	if b.meter.exceeded() {
+		return n, b.meter.err()
+	}
+	return n, err
+}
+
+// withBandwidthTransport returns a copy of hc whose responses are counted by
+// meter.
+func withBandwidthTransport(hc *http.Client, meter *bandwidthMeter) *http.Client {
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *hc
+	wrapped.Transport = &bandwidthTransport{base: base, meter: meter}
+	return &wrapped
+}
+
+// BandwidthUsage reports the bytes received so far against
+// Config.MaxTotalBytes.
+func (c *Client) BandwidthUsage() BandwidthUsage {
+	if c.bandwidth == nil {
+		return BandwidthUsage{Limit: c.config.MaxTotalBytes}
+	}
+	return BandwidthUsage{Bytes: c.bandwidth.bytes.Load(), Limit: c.bandwidth.limit}
+}
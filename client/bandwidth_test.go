@@ -0,0 +1,58 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBandwidthTransport_CountsAndEnforcesBudget(t *testing.T) {
+	var requests int
+	c := New(Config{
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			requests++
+			return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(strings.Repeat("x", 60))), Request: req}, nil
+		})},
+		MaxTotalBytes: 100,
+	})
+
+	get := func() ([]byte, error) {
+		resp, err := c.config.HTTPClient.Get("https://www.youtube.com/")
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		return io.ReadAll(resp.Body)
+	}
+	if _, err := get(); err != nil {
+		t.Fatalf("first request error = %v", err)
+	}
+	if usage := c.BandwidthUsage(); usage.Bytes != 60 || usage.Limit != 100 {
+		t.Fatalf("usage = %+v, want 60 of 100", usage)
+	}
+	_, err := get()
+	var budgetErr *MaxTotalBytesError
+	if !errors.As(err, &budgetErr) || budgetErr.Bytes != 120 || ClassifyError(err) != ErrorCategoryMaxTotalBytesExceeded {
+		t.Fatalf("second request error = %v, want MaxTotalBytesError at 120 bytes", err)
+	}
+	if _, err := get(); !errors.Is(err, ErrMaxTotalBytesExceeded) || requests != 2 {
+		t.Fatalf("request after budget: err=%v requests=%d, want refused without a round trip", err, requests)
+	}
+}
+
+func TestBandwidthUsage_UnlimitedStillCounts(t *testing.T) {
+	c := New(Config{HTTPClient: &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader("abc")), Request: req}, nil
+	})}})
+	resp, err := c.config.HTTPClient.Get("https://www.youtube.com/")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if usage := c.BandwidthUsage(); usage.Bytes != 3 || usage.Limit != 0 {
+		t.Fatalf("usage = %+v, want 3 bytes unlimited", usage)
+	}
+}
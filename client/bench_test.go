@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/famomatic/ytv1/internal/innertube"
+)
+
+// Allocation budgets for the client hot paths, about 25% above the measured
+// baseline in docs/benchmarks/baseline.txt.
+const (
+	primeChallengesAllocBudget = 19000
+	chunkScheduleAllocBudget   = 40
+)
+
+// benchChallengeResponse returns a response with n ciphered formats, each
+// carrying its own n and s challenge.
+func benchChallengeResponse(n int) *innertube.PlayerResponse {
+	resp := &innertube.PlayerResponse{VideoDetails: innertube.VideoDetails{VideoID: "jNQXAC9IVRw"}}
+	for i := 0; i < n; i++ {
+		resp.StreamingData.AdaptiveFormats = append(resp.StreamingData.AdaptiveFormats, innertube.Format{
+			Itag: 133 + i,
+			SignatureCipher: buildCipher(fmt.Sprintf("https://example.com/v?itag=%d&n=nchal%03d", 133+i, i), map[string]string{
+				"s":  fmt.Sprintf("schal%03d", i),
+				"sp": "sig",
+			}),
+		})
+	}
+	return resp
+}
+
+// primeOnce primes every challenge of resp from a cold challenge cache; the
+// player JS itself stays cached in the resolver, as within one session.
+func primeOnce(c *Client, resp *innertube.PlayerResponse) {
+	c.challenges = map[string]challengeSolutions{}
+	c.primeChallengeSolutions(context.Background(), "/s/player/test/base.js", resp, "", "")
+}
+
+func benchPrimeClient() *Client {
+	return &Client{
+		config:           Config{HTTPClient: http.DefaultClient},
+		playerJSResolver: playerResolverStub{js: testPlayerJS()},
+		sessions:         map[string]videoSession{},
+		challenges:       map[string]challengeSolutions{},
+	}
+}
+
+func BenchmarkPrimeChallengeSolutions(b *testing.B) {
+	c := benchPrimeClient()
+	resp := benchChallengeResponse(50)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		primeOnce(c, resp)
+	}
+}
+
+// scheduleChunks drains a scheduler for a total-byte download with workers
+// goroutines over sources, the way downloadURLChunked does.
+func scheduleChunks(total, chunkSize int64, workers, sources int) {
+	chunks := buildChunks(total, chunkSize)
+	s := newChunkScheduler(len(chunks), sources)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for {
+				if _, _, ok := s.next(context.Background(), worker); !ok {
+					return
+				}
+				s.done()
+			}
+		}(w)
+	}
+	wg.Wait()
+}
+
+func BenchmarkChunkSchedule(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		// 1 GiB in 1 MiB chunks over 8 workers and 3 mirrors.
+		scheduleChunks(1<<30, 1<<20, 8, 3)
+	}
+}
+
+func TestPrimeChallengeSolutionsAllocBudget(t *testing.T) {
+	c := benchPrimeClient()
+	resp := benchChallengeResponse(50)
+	primeOnce(c, resp)
+	if len(c.challenges) == 0 {
+		t.Fatal("priming solved nothing; the benchmark would measure an early return")
+	}
+	allocs := testing.AllocsPerRun(10, func() { primeOnce(c, resp) })
+	if allocs > primeChallengesAllocBudget {
+		t.Errorf("primeChallengeSolutions() allocs = %v, budget %v", allocs, primeChallengesAllocBudget)
+	}
+}
+
+func TestChunkScheduleAllocBudget(t *testing.T) {
+	allocs := testing.AllocsPerRun(10, func() { scheduleChunks(1<<30, 1<<20, 8, 3) })
+	if allocs > chunkScheduleAllocBudget {
+		t.Errorf("chunk scheduling allocs = %v, budget %v", allocs, chunkScheduleAllocBudget)
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"github.com/famomatic/ytv1/internal/playerjs"
+)
+
+// Cache stores values the client would otherwise fetch again: player scripts,
+// and playlist listings when PlaylistCacheTTL is set. Keys are prefixed with
+// their kind ("player_js:", "playlist:"), so one Cache can back several
+// clients. Implementations must be safe for concurrent use; Set may drop
+// values, as every entry can be fetched again.
+type Cache interface {
+	Get(key string) (string, bool)
+	Set(key, value string)
+}
+
+// NewMemoryCache returns an unbounded in-memory Cache, for sharing player
+// scripts between clients without touching disk.
+func NewMemoryCache() Cache {
+	return playerjs.NewMemoryCache()
+}
+
+const (
+	playerJSCachePrefix = "player_js:"
+	playlistCachePrefix = "playlist:"
+)
+
+// prefixedCache scopes a Cache to the keys of one kind.
+type prefixedCache struct {
+	cache  Cache
+	prefix string
+}
+
+func (p prefixedCache) Get(key string) (string, bool) {
+	return p.cache.Get(p.prefix + key)
+}
+
+func (p prefixedCache) Set(key, value string) {
+	p.cache.Set(p.prefix+key, value)
+}
+
+// playerJSCache returns the resolver cache for player scripts: Config.Cache
+// when set, otherwise a per-client memory cache.
+func playerJSCache(config Config) playerjs.Cache {
+	if config.Cache == nil {
+		return playerjs.NewMemoryCache()
+	}
+	return prefixedCache{cache: config.Cache, prefix: playerJSCachePrefix}
+}
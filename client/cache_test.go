@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestGetPlaylist_UsesConfigCache(t *testing.T) {
+	var pages, browses int
+	var ifNoneMatch string
+	cache := NewMemoryCache()
+	c := &Client{config: Config{
+		HTTPClient:       playlistCacheTestClient(t, &pages, &browses, &ifNoneMatch),
+		CacheDir:         t.TempDir(),
+		Cache:            cache,
+		PlaylistCacheTTL: time.Hour,
+	}}
+
+	if _, err := c.GetPlaylist(context.Background(), "PL1234567890"); err != nil {
+		t.Fatalf("GetPlaylist() error = %v", err)
+	}
+	if _, ok := cache.Get(playlistCachePrefix + "PL1234567890"); !ok {
+		t.Fatal("playlist listing not stored in Config.Cache")
+	}
+	if entries, _ := os.ReadDir(c.config.CacheDir); len(entries) != 0 {
+		t.Fatalf("CacheDir written despite Config.Cache: %v", entries)
+	}
+	second, err := c.GetPlaylist(context.Background(), "PL1234567890")
+	if err != nil || !second.FromCache || pages != 1 {
+		t.Fatalf("cached fetch: fromCache=%v pages=%d err=%v", second != nil && second.FromCache, pages, err)
+	}
+}
+
+func TestNoDisk_SkipsDiskCachesAndRefusesDownloads(t *testing.T) {
+	var pages, browses int
+	var ifNoneMatch string
+	cacheDir, captureDir := t.TempDir(), t.TempDir()
+	c := New(Config{
+		HTTPClient:       playlistCacheTestClient(t, &pages, &browses, &ifNoneMatch),
+		CacheDir:         cacheDir,
+		CaptureDir:       captureDir,
+		PlaylistCacheTTL: time.Hour,
+		NoDisk:           true,
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetPlaylist(context.Background(), "PL1234567890"); err != nil {
+			t.Fatalf("GetPlaylist() error = %v", err)
+		}
+	}
+	if pages != 2 {
+		t.Fatalf("pages = %d, want every call to refetch without a cache", pages)
+	}
+	for _, dir := range []string{cacheDir, captureDir} {
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Fatalf("%s written with NoDisk: %v", dir, entries)
+		}
+	}
+	if c.capture != nil {
+		t.Fatal("fixture capture enabled with NoDisk")
+	}
+
+	_, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{OutputPath: t.TempDir() + "/out.mp4"})
+	if !errors.Is(err, ErrDiskDisabled) {
+		t.Fatalf("Download() error = %v, want ErrDiskDisabled", err)
+	}
+	if caps := c.Capabilities(); !caps.NoDisk {
+		t.Fatal("Capabilities().NoDisk = false")
+	}
+}
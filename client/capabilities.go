@@ -0,0 +1,121 @@
+package client
+
+import (
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// modulePath is this module's import path, used to find its version in the
+// embedding binary's build info.
+const modulePath = "github.com/famomatic/ytv1"
+
+// Capabilities describes what a Client can do with its current Config, so
+// orchestration layers can gate features instead of probing for failures.
+type Capabilities struct {
+	// Version is the ytv1 module version from the binary's build info, or
+	// "(devel)" when unknown.
+	Version string `json:"version"`
+	// Protocols maps each streaming protocol to whether Download supports it.
+	// "sabr" is listed as unsupported: formats only served over SABR carry the
+	// "sabr_only" hint and cannot be downloaded.
+	Protocols map[string]bool `json:"protocols"`
+	// Clients are the Innertube client names accepted by ClientOverrides and
+	// ClientSkip, sorted. ClientOverrides, if set, is the effective order.
+	Clients         []string `json:"clients"`
+	ClientOverrides []string `json:"client_overrides,omitempty"`
+	ClientSkip      []string `json:"client_skip,omitempty"`
+	// Extractors are the registered non-YouTube extractors in match order.
+	Extractors []string `json:"extractors,omitempty"`
+	// Muxer reports whether a Muxer is configured and available; the other
+	// fields report its optional extensions.
+	Muxer          bool `json:"muxer"`
+	MultiTrackMux  bool `json:"multi_track_mux"`
+	AudioExtractor bool `json:"audio_extractor"`
+	FrameExtractor bool `json:"frame_extractor"`
+	OutputProber   bool `json:"output_prober"`
+	// MP3Transcoder reports whether SelectionModeMP3 can run.
+	MP3Transcoder bool `json:"mp3_transcoder"`
+	// PoTokenProvider reports whether PO tokens are injected.
+	PoTokenProvider bool `json:"po_token_provider"`
+	// JSEngine is the JavaScript runtime solving signature and n challenges.
+	JSEngine string `json:"js_engine"`
+	// Caches lists the caches the client keeps.
+	Caches []CacheCapability `json:"caches"`
+	// NoDisk reports Config.NoDisk: no disk caches, captures or downloads.
+	NoDisk bool `json:"no_disk"`
+}
+
+// CacheCapability describes one cache. Backend is "memory", "disk" or
+// "custom" (Config.Cache); Path is set for enabled disk caches.
+type CacheCapability struct {
+	Name    string `json:"name"`
+	Backend string `json:"backend"`
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path,omitempty"`
+}
+
+// Capabilities reports the features available with the client's Config.
+// Muxer availability is checked on every call, which for the ffmpeg muxer
+// means a PATH lookup.
+func (c *Client) Capabilities() Capabilities {
+	caps := Capabilities{
+		Version: moduleVersion(),
+		Protocols: map[string]bool{
+			"https": true,
+			"dash":  true,
+			"hls":   true,
+			"sabr":  false,
+		},
+		Clients:         InnertubeClientNames(),
+		ClientOverrides: append([]string(nil), c.config.ClientOverrides...),
+		ClientSkip:      append([]string(nil), c.config.ClientSkip...),
+		Extractors:      c.extractors().Names(),
+		MP3Transcoder:   c.config.MP3Transcoder != nil,
+		PoTokenProvider: c.config.PoTokenProvider != nil,
+		JSEngine:        "goja",
+	}
+	if m := c.config.Muxer; m != nil && m.Available() {
+		caps.Muxer = true
+		_, caps.MultiTrackMux = m.(MultiTrackMuxer)
+		_, caps.AudioExtractor = m.(AudioExtractor)
+		_, caps.FrameExtractor = m.(FrameExtractor)
+		_, caps.OutputProber = m.(OutputProber)
+	}
+
+	playerJSCache := CacheCapability{Name: "player_js", Backend: "memory", Enabled: true}
+	playlistCache := CacheCapability{Name: "playlist", Backend: "disk"}
+	if c.config.Cache != nil {
+		playerJSCache.Backend = "custom"
+		playlistCache.Backend = "custom"
+		playlistCache.Enabled = c.config.PlaylistCacheTTL > 0
+	} else if dir := strings.TrimSpace(c.config.CacheDir); dir != "" && !c.config.NoDisk && c.config.PlaylistCacheTTL > 0 {
+		playlistCache.Enabled = true
+		playlistCache.Path = filepath.Join(dir, "playlists")
+	}
+	caps.NoDisk = c.config.NoDisk
+	caps.Caches = []CacheCapability{
+		{Name: "session", Backend: "memory", Enabled: true},
+		playerJSCache,
+		{Name: "challenge", Backend: "memory", Enabled: true},
+		{Name: "po_token", Backend: "memory", Enabled: caps.PoTokenProvider},
+		playlistCache,
+	}
+	return caps
+}
+
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath && dep.Version != "" {
+			return dep.Version
+		}
+	}
+	return "(devel)"
+}
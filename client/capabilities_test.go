@@ -0,0 +1,73 @@
+package client
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type unavailableMuxer struct{ testMuxer }
+
+func (unavailableMuxer) Available() bool { return false }
+
+func TestCapabilities_Defaults(t *testing.T) {
+	caps := New(Config{Extractors: &ExtractorRegistry{}}).Capabilities()
+	if caps.Version == "" || caps.JSEngine != "goja" {
+		t.Fatalf("Version=%q JSEngine=%q", caps.Version, caps.JSEngine)
+	}
+	for _, p := range []string{"https", "dash", "hls"} {
+		if !caps.Protocols[p] {
+			t.Fatalf("protocol %s not supported: %v", p, caps.Protocols)
+		}
+	}
+	if supported, listed := caps.Protocols["sabr"]; !listed || supported {
+		t.Fatalf("sabr=%v listed=%v, want listed as unsupported", supported, listed)
+	}
+	if len(caps.Clients) == 0 || caps.Muxer || caps.MP3Transcoder || caps.PoTokenProvider {
+		t.Fatalf("caps=%+v", caps)
+	}
+	for _, cache := range caps.Caches {
+		if (cache.Name == "playlist" || cache.Name == "po_token") && cache.Enabled {
+			t.Fatalf("cache %s enabled without configuration", cache.Name)
+		}
+	}
+}
+
+func TestCapabilities_ReflectsConfig(t *testing.T) {
+	extractors := &ExtractorRegistry{}
+	if err := extractors.Register("portal", &portalExtractor{}); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	caps := New(Config{
+		Muxer:            &testMultiTrackMuxer{},
+		MP3Transcoder:    &mp3TranscoderStub{},
+		PoTokenProvider:  &tokenProviderStub{token: "pot"},
+		ClientOverrides:  []string{"ios", "web"},
+		Extractors:       extractors,
+		CacheDir:         dir,
+		PlaylistCacheTTL: time.Hour,
+	}).Capabilities()
+	if !caps.Muxer || !caps.MultiTrackMux || caps.AudioExtractor || !caps.MP3Transcoder || !caps.PoTokenProvider {
+		t.Fatalf("caps=%+v", caps)
+	}
+	if len(caps.ClientOverrides) != 2 || caps.ClientOverrides[0] != "ios" {
+		t.Fatalf("ClientOverrides=%v", caps.ClientOverrides)
+	}
+	if len(caps.Extractors) != 1 || caps.Extractors[0] != "portal" {
+		t.Fatalf("Extractors=%v", caps.Extractors)
+	}
+	var playlist CacheCapability
+	for _, cache := range caps.Caches {
+		if cache.Name == "playlist" {
+			playlist = cache
+		}
+	}
+	if !playlist.Enabled || playlist.Backend != "disk" || playlist.Path != filepath.Join(dir, "playlists") {
+		t.Fatalf("playlist cache=%+v", playlist)
+	}
+
+	if caps := New(Config{Muxer: unavailableMuxer{}}).Capabilities(); caps.Muxer {
+		t.Fatalf("unavailable muxer reported as available")
+	}
+}
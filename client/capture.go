@@ -0,0 +1,149 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/innertube"
+)
+
+// CaptureIndexFile is the NDJSON index written next to captured fixtures.
+const CaptureIndexFile = "captures.ndjson"
+
+// redactedVideoID replaces video IDs when CaptureRedactVideoID is set. It keeps
+// the 11-character shape so ID-format parsers still accept the fixture.
+const redactedVideoID = "XXXXXXXXXXX"
+
+// CaptureRecord is one line of CaptureIndexFile.
+type CaptureRecord struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"` // "player_response" or "player_js"
+	Reason  string    `json:"reason"`
+	Client  string    `json:"client,omitempty"`
+	VideoID string    `json:"video_id,omitempty"`
+	Player  string    `json:"player,omitempty"`
+	File    string    `json:"file"`
+}
+
+var (
+	captureVisitorDataPattern = regexp.MustCompile(`"visitorData"\s*:\s*"[^"]*"`)
+	// Stream URL parameters tied to the requesting client or session,
+	// plain or JSON-escaped (\u0026ip=) and percent-encoded inside signatureCipher.
+	captureQueryParamPattern   = regexp.MustCompile(`([?&]|\\u0026)(ip|ei|pot|cpn)=[^&"\\]*`)
+	captureEncodedParamPattern = regexp.MustCompile(`(%3F|%26)(ip|ei|pot|cpn)%3D[^%&"\\]*`)
+)
+
+// fixtureCapture writes anonymized copies of anomalous player responses and
+// player JS that failed deciphering under Config.CaptureDir.
+type fixtureCapture struct {
+	dir           string
+	redactVideoID bool
+	now           func() time.Time
+	logger        Logger
+
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+func newFixtureCapture(cfg Config, logger Logger) *fixtureCapture {
+	dir := strings.TrimSpace(cfg.CaptureDir)
+	if dir == "" || cfg.NoDisk {
+		return nil
+	}
+	return &fixtureCapture{
+		dir:           dir,
+		redactVideoID: cfg.CaptureRedactVideoID,
+		now:           time.Now,
+		logger:        logger,
+		seen:          make(map[string]struct{}),
+	}
+}
+
+func (fc *fixtureCapture) playerResponse(a innertube.ResponseAnomaly) {
+	if fc == nil {
+		return
+	}
+	body := anonymizePlayerResponse(a.Body)
+	videoID := a.VideoID
+	if fc.redactVideoID && videoID != "" {
+		body = []byte(strings.ReplaceAll(string(body), videoID, redactedVideoID))
+		videoID = redactedVideoID
+	}
+	fc.write(CaptureRecord{Kind: "player_response", Reason: a.Reason, Client: a.Client, VideoID: videoID}, "json", body)
+}
+
+// playerJS captures a player script once per player and failure reason.
+func (fc *fixtureCapture) playerJS(playerURL, reason string, body string) {
+	if fc == nil || body == "" {
+		return
+	}
+	key := playerURL + "\x00" + reason
+	fc.mu.Lock()
+	_, dup := fc.seen[key]
+	fc.seen[key] = struct{}{}
+	fc.mu.Unlock()
+	if dup {
+		return
+	}
+	fc.write(CaptureRecord{Kind: "player_js", Reason: reason, Player: playerURL}, "js", []byte(body))
+}
+
+func (fc *fixtureCapture) write(rec CaptureRecord, ext string, body []byte) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	rec.Time = fc.now().UTC()
+	label := rec.VideoID
+	if rec.Kind == "player_js" {
+		label = playerIDFromURL(rec.Player)
+	}
+	name := fmt.Sprintf("%s-%s", rec.Time.Format("20060102T150405.000"), rec.Kind)
+	if label != "" {
+		name += "-" + sanitizeOutputToken(label)
+	}
+	rec.File = name + "." + ext
+	if err := os.MkdirAll(fc.dir, 0o755); err != nil {
+		fc.logger.Warnf("fixture capture: %v", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(fc.dir, rec.File), body, 0o644); err != nil {
+		fc.logger.Warnf("fixture capture: %v", err)
+		return
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(fc.dir, CaptureIndexFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		fc.logger.Warnf("fixture capture: %v", err)
+		return
+	}
+	defer f.Close()
+	_, _ = f.Write(append(line, '\n'))
+}
+
+// anonymizePlayerResponse strips visitor identity and client-bound stream URL
+// parameters while keeping challenge inputs (n, s, sig) intact.
+func anonymizePlayerResponse(body []byte) []byte {
+	out := captureVisitorDataPattern.ReplaceAll(body, []byte(`"visitorData":"REDACTED"`))
+	out = captureQueryParamPattern.ReplaceAll(out, []byte("${1}${2}=REDACTED"))
+	out = captureEncodedParamPattern.ReplaceAll(out, []byte("${1}${2}%3DREDACTED"))
+	return out
+}
+
+// playerIDFromURL returns the player hash segment of /s/player/<id>/... URLs.
+func playerIDFromURL(playerURL string) string {
+	parts := strings.Split(playerURL, "/")
+	for i, p := range parts {
+		if p == "player" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
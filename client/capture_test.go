@@ -0,0 +1,96 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCaptureDir_WritesAnonymizedResponseWithoutFormats(t *testing.T) {
+	dir := t.TempDir()
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player") {
+				body := `{"responseContext":{"visitorData":"CgtWaXNpdG9y"},"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},"playbackTracking":{"videostatsPlaybackUrl":{"baseUrl":"https://s.youtube.com/api/stats/playback?docid=jNQXAC9IVRw&ei=abc&cpn=xyz"}}}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			}
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+		}),
+	}
+	c := New(Config{
+		HTTPClient:           httpClient,
+		ClientOverrides:      []string{"mweb"},
+		CaptureDir:           dir,
+		CaptureRedactVideoID: true,
+	})
+	_, _ = c.GetVideo(context.Background(), "jNQXAC9IVRw")
+
+	f, err := os.Open(filepath.Join(dir, CaptureIndexFile))
+	if err != nil {
+		t.Fatalf("open capture index: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	if !scanner.Scan() {
+		t.Fatal("capture index is empty")
+	}
+	var rec CaptureRecord
+	if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+		t.Fatalf("json.Unmarshal(index) error = %v", err)
+	}
+	if rec.Kind != "player_response" || rec.Reason != "player_response_no_formats" || rec.Client != "mweb" || rec.VideoID != redactedVideoID {
+		t.Fatalf("capture record = %+v", rec)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, rec.File))
+	if err != nil {
+		t.Fatalf("read capture: %v", err)
+	}
+	got := string(data)
+	for _, leaked := range []string{"jNQXAC9IVRw", "CgtWaXNpdG9y", "ei=abc", "cpn=xyz"} {
+		if strings.Contains(got, leaked) {
+			t.Fatalf("capture still contains %q: %s", leaked, got)
+		}
+	}
+	if !strings.Contains(got, `"status":"OK"`) {
+		t.Fatalf("capture lost response content: %s", got)
+	}
+}
+
+func TestAnonymizePlayerResponse_SignatureCipher(t *testing.T) {
+	in := `{"signatureCipher":"s=AOq0&sp=sig&url=https%3A%2F%2Frr1.googlevideo.com%2Fvideoplayback%3Fip%3D203.0.113.7%26n%3DabcN%26pot%3Dtok"}`
+	got := string(anonymizePlayerResponse([]byte(in)))
+	if strings.Contains(got, "203.0.113.7") || strings.Contains(got, "pot%3Dtok") {
+		t.Fatalf("client-bound params not redacted: %s", got)
+	}
+	if !strings.Contains(got, "n%3DabcN") || !strings.Contains(got, "s=AOq0") {
+		t.Fatalf("challenge inputs should be kept: %s", got)
+	}
+}
+
+func TestFixtureCapture_PlayerJSOncePerReason(t *testing.T) {
+	dir := t.TempDir()
+	fc := newFixtureCapture(Config{CaptureDir: dir}, nopLogger{})
+	playerURL := "/s/player/abcd1234/player_ias.vflset/en_US/base.js"
+	fc.playerJS(playerURL, "n_decipher: boom", "var a=1;")
+	fc.playerJS(playerURL, "n_decipher: boom", "var a=1;")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	var scripts int
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), "-player_js-abcd1234.js") {
+			scripts++
+		}
+	}
+	if scripts != 1 {
+		t.Fatalf("captured player scripts = %d, want 1 (entries=%v)", scripts, entries)
+	}
+}
@@ -109,6 +109,55 @@ func (c *Client) primeChallengeSolutions(
 	c.emitExtractionEvent("challenge", "success", "web", "n="+itoa(len(nChallenges))+",sig="+itoa(len(sigChallenges)))
 }
 
+// primeFormatChallenges solves the challenges of the selected formats in one
+// batch, so resolving them one by one afterwards hits the cache.
+func (c *Client) primeFormatChallenges(ctx context.Context, videoID string, selected []FormatInfo) {
+	session, ok := c.getSession(videoID)
+	if !ok || session.Response == nil || session.Extractor != "" {
+		return
+	}
+	itags := make(map[int]bool, len(selected))
+	for _, f := range selected {
+		itags[f.Itag] = true
+	}
+	subset := &innertube.PlayerResponse{}
+	for _, raw := range session.Response.StreamingData.Formats {
+		if itags[raw.Itag] {
+			subset.StreamingData.Formats = append(subset.StreamingData.Formats, raw)
+		}
+	}
+	for _, raw := range session.Response.StreamingData.AdaptiveFormats {
+		if itags[raw.Itag] {
+			subset.StreamingData.AdaptiveFormats = append(subset.StreamingData.AdaptiveFormats, raw)
+		}
+	}
+	nChallenges, sigChallenges := collectStreamChallenges(subset, "", "")
+	if len(nChallenges) == 0 && len(sigChallenges) == 0 {
+		return
+	}
+	session, err := c.ensureSessionPlayerURL(ctx, videoID, session)
+	if err != nil || c.challengesCached(session.PlayerURL, nChallenges, sigChallenges) {
+		return
+	}
+	c.primeChallengeSolutions(ctx, session.PlayerURL, subset, "", "")
+}
+
+// challengesCached reports whether every challenge already has a cached
+// solution for playerURL.
+func (c *Client) challengesCached(playerURL string, nChallenges, sigChallenges map[string]struct{}) bool {
+	for challenge := range nChallenges {
+		if _, ok := c.getChallengeN(playerURL, challenge); !ok {
+			return false
+		}
+	}
+	for challenge := range sigChallenges {
+		if _, ok := c.getChallengeSig(playerURL, challenge); !ok {
+			return false
+		}
+	}
+	return true
+}
+
 type challengeProviderFunc func(ctx context.Context, playerURL string) (challenge.Decipherer, error)
 
 func (f challengeProviderFunc) Load(ctx context.Context, playerURL string) (challenge.Decipherer, error) {
@@ -125,6 +174,7 @@ func (c *Client) decodeNWithCache(ctx context.Context, playerURL, challenge stri
 	}
 	decoded, err := decipherer.DecipherN(challenge)
 	if err != nil {
+		c.capturePlayerJS(ctx, playerURL, "n_decipher: "+err.Error())
 		return "", err
 	}
 	c.setChallengeN(playerURL, challenge, decoded)
@@ -141,6 +191,7 @@ func (c *Client) decodeSignatureWithCache(ctx context.Context, playerURL, challe
 	}
 	decoded, err := decipherer.DecipherSignature(challenge)
 	if err != nil {
+		c.capturePlayerJS(ctx, playerURL, "sig_decipher: "+err.Error())
 		return "", err
 	}
 	c.setChallengeSig(playerURL, challenge, decoded)
@@ -158,6 +209,19 @@ func (c *Client) loadDecipherer(ctx context.Context, playerURL string) (*playerj
 	return playerjs.NewDecipherer(jsBody), nil
 }
 
+// capturePlayerJS stores the player script behind a decipher failure when
+// Config.CaptureDir is set. The script is already in the resolver cache.
+func (c *Client) capturePlayerJS(ctx context.Context, playerURL, reason string) {
+	if c.capture == nil {
+		return
+	}
+	jsBody, err := c.playerJSResolver.GetPlayerJS(ctx, playerURL)
+	if err != nil {
+		return
+	}
+	c.capture.playerJS(playerURL, reason, jsBody)
+}
+
 func (c *Client) getChallengeN(playerURL, challenge string) (string, bool) {
 	key := canonicalPlayerCacheKey(playerURL)
 	c.challengesMu.RLock()
@@ -197,6 +261,15 @@ func (c *Client) setChallengeN(playerURL, challenge, decoded string) {
 	c.challenges[key] = s
 }
 
+func (c *Client) forgetChallengeN(playerURL, challenge string) {
+	key := canonicalPlayerCacheKey(playerURL)
+	c.challengesMu.Lock()
+	defer c.challengesMu.Unlock()
+	if s, ok := c.challenges[key]; ok && s.n != nil {
+		delete(s.n, challenge)
+	}
+}
+
 func (c *Client) setChallengeSig(playerURL, challenge, decoded string) {
 	key := canonicalPlayerCacheKey(playerURL)
 	c.challengesMu.Lock()
@@ -3,6 +3,7 @@ package client
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
@@ -11,7 +12,79 @@ import (
 
 func TestResolveStreamURL_DegradesWhenNChallengeDecodeFails(t *testing.T) {
 	var events []ExtractionEvent
-	httpClient := &http.Client{
+	httpClient := brokenPlayerJSHTTPClient(t)
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+		OnExtractionEvent: func(evt ExtractionEvent) {
+			events = append(events, evt)
+		},
+	})
+
+	streamURL, err := c.ResolveStreamURL(context.Background(), "jNQXAC9IVRw", 18)
+	if err != nil {
+		t.Fatalf("ResolveStreamURL() error = %v", err)
+	}
+	if streamURL != "https://media.local/v18.mp4?n=abcd" {
+		t.Fatalf("streamURL=%q, want unchanged n url", streamURL)
+	}
+
+	foundPartial := false
+	for _, evt := range events {
+		if evt.Stage == "challenge" && evt.Phase == "partial" {
+			foundPartial = strings.Contains(evt.Detail, "n=1")
+		}
+	}
+	if !foundPartial {
+		t.Fatalf("expected challenge partial event with n failure detail, events=%v", events)
+	}
+}
+
+func TestResolveStreamURL_StrictChallengesFailsWhenNChallengeDecodeFails(t *testing.T) {
+	c := New(Config{
+		HTTPClient:       brokenPlayerJSHTTPClient(t),
+		ClientOverrides:  []string{"mweb"},
+		StrictChallenges: true,
+	})
+
+	_, err := c.ResolveStreamURL(context.Background(), "jNQXAC9IVRw", 18)
+	if !errors.Is(err, ErrChallengeNotSolved) {
+		t.Fatalf("ResolveStreamURL() error = %v, want ErrChallengeNotSolved", err)
+	}
+	attempts, ok := AttemptDetails(err)
+	if !ok || len(attempts) != 1 {
+		t.Fatalf("AttemptDetails()=%v ok=%v, want one challenge attempt", attempts, ok)
+	}
+	got := attempts[0]
+	if got.Stage != "challenge" || got.Itag != 18 || !got.URLHasN || got.URLHost != "media.local" || got.Client != "mweb" {
+		t.Fatalf("unexpected attempt detail: %+v", got)
+	}
+	if ClassifyError(err) != ErrorCategoryChallengeNotSolved {
+		t.Fatalf("ClassifyError()=%q", ClassifyError(err))
+	}
+}
+
+func TestDownload_StrictChallengesSkipsProgressiveFallback(t *testing.T) {
+	c := New(Config{
+		HTTPClient:       brokenPlayerJSHTTPClient(t),
+		ClientOverrides:  []string{"mweb"},
+		StrictChallenges: true,
+	})
+
+	_, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{
+		OutputPath: t.TempDir() + "/out.mp4",
+	})
+	var detail *ChallengeNotSolvedDetailError
+	if !errors.As(err, &detail) || detail.Challenge != "n" {
+		t.Fatalf("Download() error = %v, want *ChallengeNotSolvedDetailError", err)
+	}
+}
+
+// brokenPlayerJSHTTPClient serves one progressive format with an n parameter
+// and a player JS that cannot be parsed, so n decoding always fails.
+func brokenPlayerJSHTTPClient(t *testing.T) *http.Client {
+	t.Helper()
+	return &http.Client{
 		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
 			switch {
 			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
@@ -43,29 +116,4 @@ func TestResolveStreamURL_DegradesWhenNChallengeDecodeFails(t *testing.T) {
 			}
 		}),
 	}
-	c := New(Config{
-		HTTPClient:      httpClient,
-		ClientOverrides: []string{"mweb"},
-		OnExtractionEvent: func(evt ExtractionEvent) {
-			events = append(events, evt)
-		},
-	})
-
-	streamURL, err := c.ResolveStreamURL(context.Background(), "jNQXAC9IVRw", 18)
-	if err != nil {
-		t.Fatalf("ResolveStreamURL() error = %v", err)
-	}
-	if streamURL != "https://media.local/v18.mp4?n=abcd" {
-		t.Fatalf("streamURL=%q, want unchanged n url", streamURL)
-	}
-
-	foundPartial := false
-	for _, evt := range events {
-		if evt.Stage == "challenge" && evt.Phase == "partial" {
-			foundPartial = strings.Contains(evt.Detail, "n=1")
-		}
-	}
-	if !foundPartial {
-		t.Fatalf("expected challenge partial event with n failure detail, events=%v", events)
-	}
 }
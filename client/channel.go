@@ -0,0 +1,161 @@
+package client
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/httpx"
+)
+
+var channelPageIDPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`"externalId":"(UC[0-9A-Za-z_-]{22})"`),
+	regexp.MustCompile(`<link rel="canonical" href="https://www\.youtube\.com/channel/(UC[0-9A-Za-z_-]{22})"`),
+}
+
+// ChannelFeedEntry is one upload listed in a channel's RSS feed.
+type ChannelFeedEntry struct {
+	VideoID   string
+	Title     string
+	Published time.Time
+}
+
+// ChannelFeed is a channel's latest uploads as published by its RSS feed
+// (YouTube lists roughly the newest 15), newest first. Playlist feeds use
+// the same shape with PlaylistID set.
+type ChannelFeed struct {
+	ChannelID  string
+	PlaylistID string
+	Title      string
+	Entries    []ChannelFeedEntry
+}
+
+// ResolveChannelID returns the UC... channel ID for input. Raw IDs and
+// /channel/ URLs resolve locally; handles ("@name", "youtube.com/@name") and
+// /c/ or /user/ URLs are looked up on the channel page.
+func (c *Client) ResolveChannelID(ctx context.Context, input string) (string, error) {
+	if id, err := ExtractChannelID(input); err == nil {
+		return id, nil
+	}
+	s := strings.TrimSpace(input)
+	pageURL := ""
+	switch {
+	case strings.HasPrefix(s, "@"):
+		pageURL = "https://www.youtube.com/" + url.PathEscape(s)
+	default:
+		parsed, ok := tryParseURL(s)
+		if !ok || !isYouTubeHost(parsed.Hostname()) {
+			return "", invalidInput(input, "unsupported_input_shape")
+		}
+		parsed.Scheme = "https"
+		parsed.RawQuery = ""
+		pageURL = parsed.String()
+	}
+
+	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+	body, err := c.fetchChannelResource(ctx, pageURL)
+	if err != nil {
+		return "", err
+	}
+	for _, pattern := range channelPageIDPatterns {
+		if m := pattern.FindSubmatch(body); len(m) == 2 {
+			return string(m[1]), nil
+		}
+	}
+	return "", invalidInput(input, "channel_id_not_found")
+}
+
+// GetChannelFeed fetches the channel's uploads RSS feed.
+func (c *Client) GetChannelFeed(ctx context.Context, input string) (*ChannelFeed, error) {
+	channelID, err := c.ResolveChannelID(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := c.GetFeed(ctx, "https://www.youtube.com/feeds/videos.xml?channel_id="+url.QueryEscape(channelID))
+	if err != nil {
+		return nil, err
+	}
+	if feed.ChannelID == "" {
+		feed.ChannelID = channelID
+	}
+	return feed, nil
+}
+
+// GetFeed fetches and parses a YouTube RSS/Atom feed URL
+// (feeds/videos.xml?channel_id=..., ?playlist_id=... or ?user=...).
+func (c *Client) GetFeed(ctx context.Context, feedURL string) (*ChannelFeed, error) {
+	if !IsFeedURL(feedURL) {
+		return nil, invalidInput(feedURL, "unsupported_feed_url")
+	}
+	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+	body, err := c.fetchChannelResource(ctx, strings.TrimSpace(feedURL))
+	if err != nil {
+		return nil, err
+	}
+	return parseChannelFeed(body)
+}
+
+func (c *Client) fetchChannelResource(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.pageUserAgent())
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	httpx.AcceptCompressed(req)
+	applyRequestHeaders(req, c.config.RequestHeaders)
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUnavailable
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("channel fetch failed: status=%d", resp.StatusCode)
+	}
+	return httpx.ReadBody(resp)
+}
+
+func parseChannelFeed(raw []byte) (*ChannelFeed, error) {
+	var doc struct {
+		ChannelID  string `xml:"channelId"`
+		PlaylistID string `xml:"playlistId"`
+		Title      string `xml:"title"`
+		Entries    []struct {
+			VideoID   string `xml:"videoId"`
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("channel feed parse failed: %w", err)
+	}
+	feed := &ChannelFeed{
+		ChannelID:  strings.TrimSpace(doc.ChannelID),
+		PlaylistID: strings.TrimSpace(doc.PlaylistID),
+		Title:      strings.TrimSpace(doc.Title),
+		Entries:    make([]ChannelFeedEntry, 0, len(doc.Entries)),
+	}
+	for _, e := range doc.Entries {
+		videoID := strings.TrimSpace(e.VideoID)
+		if !youtubeIDPattern.MatchString(videoID) {
+			continue
+		}
+		published, _ := time.Parse(time.RFC3339, strings.TrimSpace(e.Published))
+		feed.Entries = append(feed.Entries, ChannelFeedEntry{
+			VideoID:   videoID,
+			Title:     strings.TrimSpace(e.Title),
+			Published: published,
+		})
+	}
+	return feed, nil
+}
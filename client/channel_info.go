@@ -0,0 +1,383 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ChannelLink is one link from a channel's about section.
+type ChannelLink struct {
+	Title string
+	URL   string
+}
+
+// ChannelInfo is a channel's about section.
+type ChannelInfo struct {
+	ID          string
+	Title       string
+	Handle      string // "@name", when the channel has one
+	Description string
+	Country     string
+	// SubscriberCount is approximate: YouTube rounds it ("1.2M subscribers").
+	SubscriberCount int64
+	ViewCount       int64
+	VideoCount      int64
+	JoinedDate      string // as displayed, e.g. "Jan 1, 2010"
+	Links           []ChannelLink
+}
+
+// ChannelPlaylist is one playlist listed on a channel's playlists tab.
+type ChannelPlaylist struct {
+	ID         string
+	Title      string
+	VideoCount int64 // 0 when not shown
+}
+
+// ChannelPlaylists is the playlists tab of a channel.
+type ChannelPlaylists struct {
+	ChannelID            string
+	Playlists            []ChannelPlaylist
+	ContinuationWarnings []PlaylistContinuationWarning
+	ContinuationStats    PlaylistContinuationStats
+}
+
+// GetChannelInfo fetches the about section of a channel: description,
+// country, links and subscriber, view and video counts. input is anything
+// ResolveChannelID accepts.
+func (c *Client) GetChannelInfo(ctx context.Context, input string) (*ChannelInfo, error) {
+	channelID, err := c.ResolveChannelID(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+
+	root, err := c.fetchChannelTab(ctx, channelID, "about")
+	if err != nil {
+		return nil, err
+	}
+	info := &ChannelInfo{ID: channelID}
+	walkAny(root, func(m map[string]any) {
+		if md, ok := m["channelMetadataRenderer"].(map[string]any); ok {
+			info.Title = getStringFromMap(md, "title")
+			info.Description = getStringFromMap(md, "description")
+			info.Handle = channelHandle(getStringFromMap(md, "vanityChannelUrl"))
+		}
+	})
+
+	about := findAboutChannelViewModel(root)
+	if about == nil {
+		// The about section is loaded lazily from a continuation in the
+		// page's about panel.
+		var token string
+		walkAny(root, func(m map[string]any) {
+			if token != "" {
+				return
+			}
+			if panel, ok := m["aboutChannelRenderer"]; ok {
+				if tokens := findContinuationTokens(panel); len(tokens) > 0 {
+					token = tokens[0]
+				}
+			}
+		})
+		if token != "" {
+			body, err := c.browseRaw(ctx, token, findVisitorData(root))
+			if err != nil {
+				return nil, err
+			}
+			var continued any
+			if err := json.Unmarshal(body, &continued); err != nil {
+				return nil, err
+			}
+			about = findAboutChannelViewModel(continued)
+		}
+	}
+	if about != nil {
+		applyAboutChannelViewModel(info, about)
+	}
+	return info, nil
+}
+
+// GetChannelPlaylists lists the playlists on a channel's playlists tab,
+// following continuations up to Config.PlaylistContinuationMaxRequests.
+func (c *Client) GetChannelPlaylists(ctx context.Context, input string) (*ChannelPlaylists, error) {
+	channelID, err := c.ResolveChannelID(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+
+	root, err := c.fetchChannelTab(ctx, channelID, "playlists")
+	if err != nil {
+		return nil, err
+	}
+	out := &ChannelPlaylists{ChannelID: channelID}
+	seenPlaylists := make(map[string]struct{})
+	appendPlaylists := func(page any) {
+		for _, p := range findChannelPlaylists(page) {
+			if _, seen := seenPlaylists[p.ID]; seen {
+				continue
+			}
+			seenPlaylists[p.ID] = struct{}{}
+			out.Playlists = append(out.Playlists, p)
+		}
+	}
+	appendPlaylists(root)
+
+	pending := findContinuationItemTokens(root)
+	visitorData := findVisitorData(root)
+	seenContinuations := make(map[string]struct{}, len(pending))
+	maxRequests := c.config.PlaylistContinuationMaxRequests
+	if maxRequests <= 0 {
+		maxRequests = defaultPlaylistContinuationMaxRequests
+	}
+	for len(pending) > 0 {
+		if out.ContinuationStats.Requested >= maxRequests {
+			out.ContinuationStats.StoppedByLimit = true
+			out.ContinuationWarnings = append(out.ContinuationWarnings, PlaylistContinuationWarning{
+				Token:  pending[0],
+				Reason: "max_requests_reached",
+			})
+			break
+		}
+		continuation := pending[0]
+		pending = pending[1:]
+		if _, seen := seenContinuations[continuation]; seen {
+			out.ContinuationStats.SkippedDuplicate++
+			continue
+		}
+		seenContinuations[continuation] = struct{}{}
+		out.ContinuationStats.Requested++
+
+		c.emitExtractionEvent("channel_continuation", "start", "web", fmt.Sprintf("request=%d", out.ContinuationStats.Requested))
+		body, err := c.browseRaw(ctx, continuation, visitorData)
+		var page any
+		if err == nil {
+			err = json.Unmarshal(body, &page)
+		}
+		if err != nil {
+			out.ContinuationStats.Failed++
+			out.ContinuationWarnings = append(out.ContinuationWarnings, continuationWarningFromError(continuation, err))
+			c.warnf("failed to fetch channel playlists continuation: %v", err)
+			c.emitExtractionEvent("channel_continuation", "failure", "web", err.Error())
+			continue
+		}
+		out.ContinuationStats.Succeeded++
+		before := len(out.Playlists)
+		appendPlaylists(page)
+		c.emitExtractionEvent("channel_continuation", "success", "web", fmt.Sprintf("playlists=%d total=%d", len(out.Playlists)-before, len(out.Playlists)))
+		for _, token := range findContinuationItemTokens(page) {
+			if _, seen := seenContinuations[token]; !seen {
+				pending = append(pending, token)
+			}
+		}
+	}
+	return out, nil
+}
+
+// fetchChannelTab returns the parsed ytInitialData of a channel tab page.
+func (c *Client) fetchChannelTab(ctx context.Context, channelID, tab string) (any, error) {
+	c.emitExtractionEvent("channel_page", "start", "web", "id="+channelID+" tab="+tab)
+	root, err := c.fetchChannelTabData(ctx, channelID, tab)
+	if err != nil {
+		c.emitExtractionEvent("channel_page", "failure", "web", err.Error())
+		return nil, err
+	}
+	c.emitExtractionEvent("channel_page", "success", "web", "id="+channelID+" tab="+tab)
+	return root, nil
+}
+
+func (c *Client) fetchChannelTabData(ctx context.Context, channelID, tab string) (any, error) {
+	body, err := c.fetchChannelResource(ctx, "https://www.youtube.com/channel/"+url.PathEscape(channelID)+"/"+tab+"?hl=en")
+	if err != nil {
+		return nil, err
+	}
+	initial, err := extractYTInitialData(body)
+	if err != nil {
+		return nil, err
+	}
+	var root any
+	if err := json.Unmarshal(initial, &root); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+func findAboutChannelViewModel(root any) map[string]any {
+	var about map[string]any
+	walkAny(root, func(m map[string]any) {
+		if about != nil {
+			return
+		}
+		if vm, ok := m["aboutChannelViewModel"].(map[string]any); ok {
+			about = vm
+		}
+	})
+	return about
+}
+
+func applyAboutChannelViewModel(info *ChannelInfo, about map[string]any) {
+	if id := getStringFromMap(about, "channelId"); id != "" {
+		info.ID = id
+	}
+	if desc := displayText(about["description"]); desc != "" {
+		info.Description = desc
+	}
+	if handle := channelHandle(getStringFromMap(about, "canonicalChannelUrl")); handle != "" {
+		info.Handle = handle
+	}
+	info.Country = displayText(about["country"])
+	info.SubscriberCount = parseCountText(displayText(about["subscriberCountText"]))
+	info.ViewCount = parseCountText(displayText(about["viewCountText"]))
+	info.VideoCount = parseCountText(displayText(about["videoCountText"]))
+	info.JoinedDate = strings.TrimSpace(strings.TrimPrefix(displayText(about["joinedDateText"]), "Joined"))
+
+	links, _ := about["links"].([]any)
+	for _, raw := range links {
+		entry, _ := raw.(map[string]any)
+		vm, _ := entry["channelExternalLinkViewModel"].(map[string]any)
+		if vm == nil {
+			continue
+		}
+		link := ChannelLink{Title: displayText(vm["title"])}
+		walkAny(vm["link"], func(m map[string]any) {
+			if link.URL != "" {
+				return
+			}
+			if ep, ok := m["urlEndpoint"].(map[string]any); ok {
+				link.URL = unwrapRedirectURL(getStringFromMap(ep, "url"))
+			}
+		})
+		if link.URL == "" {
+			if shown := displayText(vm["link"]); shown != "" {
+				link.URL = "https://" + shown
+			}
+		}
+		if link.URL != "" {
+			info.Links = append(info.Links, link)
+		}
+	}
+}
+
+// findChannelPlaylists reads playlist tiles from a playlists tab page or
+// continuation: lockupViewModel on current layouts, gridPlaylistRenderer on
+// older ones.
+func findChannelPlaylists(root any) []ChannelPlaylist {
+	var out []ChannelPlaylist
+	walkAny(root, func(m map[string]any) {
+		if lockup, ok := m["lockupViewModel"].(map[string]any); ok {
+			if getStringFromMap(lockup, "contentType") != "LOCKUP_CONTENT_TYPE_PLAYLIST" {
+				return
+			}
+			p := ChannelPlaylist{ID: getStringFromMap(lockup, "contentId")}
+			if md, ok := lockup["metadata"].(map[string]any); ok {
+				if lmd, ok := md["lockupMetadataViewModel"].(map[string]any); ok {
+					p.Title = displayText(lmd["title"])
+				}
+			}
+			walkAny(lockup["contentImage"], func(mm map[string]any) {
+				if badge, ok := mm["thumbnailBadgeViewModel"].(map[string]any); ok && p.VideoCount == 0 {
+					if text := getStringFromMap(badge, "text"); strings.Contains(text, "video") {
+						p.VideoCount = parseCountText(text)
+					}
+				}
+			})
+			if p.ID != "" {
+				out = append(out, p)
+			}
+			return
+		}
+		if grid, ok := m["gridPlaylistRenderer"].(map[string]any); ok {
+			p := ChannelPlaylist{
+				ID:         getStringFromMap(grid, "playlistId"),
+				Title:      getTextField(grid["title"]),
+				VideoCount: parseCountText(getTextField(grid["videoCountText"])),
+			}
+			if p.ID != "" {
+				out = append(out, p)
+			}
+		}
+	})
+	return out
+}
+
+// findContinuationItemTokens returns the tokens of continuationItemRenderer
+// entries, the "load more" markers at the end of a list.
+func findContinuationItemTokens(root any) []string {
+	var tokens []string
+	walkAny(root, func(m map[string]any) {
+		if item, ok := m["continuationItemRenderer"]; ok {
+			tokens = append(tokens, findContinuationTokens(item)...)
+		}
+	})
+	return tokens
+}
+
+// displayText reads a text field in any of its renderings: a plain string,
+// {"content": ...} (view models) or {"simpleText"/"runs": ...} (renderers).
+func displayText(v any) string {
+	switch x := v.(type) {
+	case string:
+		return strings.TrimSpace(x)
+	case map[string]any:
+		if s := getStringFromMap(x, "content"); s != "" {
+			return strings.TrimSpace(s)
+		}
+		return strings.TrimSpace(getTextField(x))
+	}
+	return ""
+}
+
+// channelHandle returns the "@name" part of a channel URL.
+func channelHandle(rawURL string) string {
+	if i := strings.Index(rawURL, "/@"); i >= 0 {
+		return strings.TrimRight(rawURL[i+1:], "/")
+	}
+	return ""
+}
+
+// unwrapRedirectURL returns the target of a youtube.com/redirect link.
+func unwrapRedirectURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	if u.Path == "/redirect" && isYouTubeHost(u.Hostname()) {
+		if q := u.Query().Get("q"); q != "" {
+			return q
+		}
+	}
+	return raw
+}
+
+// parseCountText parses display counts such as "12,345 views",
+// "1.2M subscribers", "3.4K videos" or "No views".
+func parseCountText(text string) int64 {
+	fields := strings.Fields(strings.TrimSpace(text))
+	if len(fields) == 0 {
+		return 0
+	}
+	s := strings.ReplaceAll(fields[0], ",", "")
+	mult := float64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		mult = 1e3
+	case strings.HasSuffix(s, "M"):
+		mult = 1e6
+	case strings.HasSuffix(s, "B"):
+		mult = 1e9
+	}
+	if mult > 1 {
+		s = s[:len(s)-1]
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return int64(v*mult + 0.5)
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testChannelID = "UCabcdefghijklmnopqrstuv"
+
+func channelPageResponse(initialData string) *http.Response {
+	body := `<html><script>var ytInitialData = ` + initialData + `;</script></html>`
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}
+}
+
+func TestGetChannelInfo_FollowsAboutContinuation(t *testing.T) {
+	aboutPage := `{"responseContext":{"visitorData":"vd"},
+		"metadata":{"channelMetadataRenderer":{"title":"Example","description":"short","vanityChannelUrl":"http://www.youtube.com/@example"}},
+		"onResponseReceivedEndpoints":[{"showEngagementPanelEndpoint":{"engagementPanel":{"engagementPanelSectionListRenderer":{"content":{"sectionListRenderer":{"contents":[{"itemSectionRenderer":{"contents":[{"aboutChannelRenderer":{"metadata":{"continuationItemRenderer":{"continuationEndpoint":{"continuationCommand":{"token":"about-token"}}}}}}]}}]}}}}}}]}`
+	aboutContinuation := `{"onResponseReceivedEndpoints":[{"appendContinuationItemsAction":{"continuationItems":[{"aboutChannelRenderer":{"metadata":{"aboutChannelViewModel":{
+		"channelId":"` + testChannelID + `",
+		"description":"Full description",
+		"country":"South Korea",
+		"subscriberCountText":"1.23M subscribers",
+		"viewCountText":"123,456,789 views",
+		"videoCountText":"1,024 videos",
+		"joinedDateText":{"content":"Joined Jan 2, 2010"},
+		"canonicalChannelUrl":"http://www.youtube.com/@example",
+		"links":[{"channelExternalLinkViewModel":{"title":{"content":"Site"},"link":{"content":"example.com","commandRuns":[{"onTap":{"innertubeCommand":{"urlEndpoint":{"url":"https://www.youtube.com/redirect?event=channel_description&q=https%3A%2F%2Fexample.com%2F"}}}}]}}},
+			{"channelExternalLinkViewModel":{"title":{"content":"Shop"},"link":{"content":"shop.example.com"}}}]
+	}}}}]}}]}`
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		switch {
+		case r.URL.Path == "/channel/"+testChannelID+"/about":
+			return channelPageResponse(aboutPage), nil
+		case r.URL.Path == "/youtubei/v1/browse":
+			raw, _ := io.ReadAll(r.Body)
+			if !strings.Contains(string(raw), `"continuation":"about-token"`) {
+				t.Fatalf("browse body = %s", raw)
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(aboutContinuation)), Header: make(http.Header)}, nil
+		}
+		t.Fatalf("unexpected request: %s", r.URL)
+		return nil, nil
+	})}
+	c := New(Config{HTTPClient: httpClient})
+
+	info, err := c.GetChannelInfo(context.Background(), testChannelID)
+	if err != nil {
+		t.Fatalf("GetChannelInfo() error = %v", err)
+	}
+	if info.Title != "Example" || info.Handle != "@example" || info.Description != "Full description" || info.Country != "South Korea" {
+		t.Fatalf("info = %+v", info)
+	}
+	if info.SubscriberCount != 1230000 || info.ViewCount != 123456789 || info.VideoCount != 1024 || info.JoinedDate != "Jan 2, 2010" {
+		t.Fatalf("counts = %d/%d/%d joined=%q", info.SubscriberCount, info.ViewCount, info.VideoCount, info.JoinedDate)
+	}
+	want := []ChannelLink{{Title: "Site", URL: "https://example.com/"}, {Title: "Shop", URL: "https://shop.example.com"}}
+	if len(info.Links) != len(want) || info.Links[0] != want[0] || info.Links[1] != want[1] {
+		t.Fatalf("links = %+v, want %+v", info.Links, want)
+	}
+}
+
+func TestGetChannelPlaylists_FollowsContinuations(t *testing.T) {
+	playlistsPage := `{"contents":{"twoColumnBrowseResultsRenderer":{"tabs":[{"tabRenderer":{"content":{"richGridRenderer":{"contents":[
+		{"richItemRenderer":{"content":{"lockupViewModel":{"contentId":"PLfirst","contentType":"LOCKUP_CONTENT_TYPE_PLAYLIST",
+			"contentImage":{"collectionThumbnailViewModel":{"primaryThumbnail":{"thumbnailViewModel":{"overlays":[{"thumbnailOverlayBadgeViewModel":{"thumbnailBadges":[{"thumbnailBadgeViewModel":{"text":"12 videos"}}]}}]}}}},
+			"metadata":{"lockupMetadataViewModel":{"title":{"content":"First"}}}}}}},
+		{"richItemRenderer":{"content":{"lockupViewModel":{"contentId":"abcdefghijk","contentType":"LOCKUP_CONTENT_TYPE_VIDEO"}}}},
+		{"continuationItemRenderer":{"continuationEndpoint":{"continuationCommand":{"token":"page-2"}}}}
+	]}}}}]}}}`
+	page2 := `{"onResponseReceivedActions":[{"appendContinuationItemsAction":{"continuationItems":[
+		{"gridPlaylistRenderer":{"playlistId":"PLsecond","title":{"runs":[{"text":"Second"}]},"videoCountText":{"runs":[{"text":"3"},{"text":" videos"}]}}},
+		{"richItemRenderer":{"content":{"lockupViewModel":{"contentId":"PLfirst","contentType":"LOCKUP_CONTENT_TYPE_PLAYLIST"}}}}
+	]}}]}`
+	var browses int
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		switch {
+		case r.URL.Path == "/channel/"+testChannelID+"/playlists":
+			return channelPageResponse(playlistsPage), nil
+		case r.URL.Path == "/youtubei/v1/browse":
+			browses++
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(page2)), Header: make(http.Header)}, nil
+		}
+		t.Fatalf("unexpected request: %s", r.URL)
+		return nil, nil
+	})}
+	c := New(Config{HTTPClient: httpClient})
+
+	got, err := c.GetChannelPlaylists(context.Background(), "https://www.youtube.com/channel/"+testChannelID)
+	if err != nil {
+		t.Fatalf("GetChannelPlaylists() error = %v", err)
+	}
+	want := []ChannelPlaylist{{ID: "PLfirst", Title: "First", VideoCount: 12}, {ID: "PLsecond", Title: "Second", VideoCount: 3}}
+	if len(got.Playlists) != len(want) || got.Playlists[0] != want[0] || got.Playlists[1] != want[1] {
+		t.Fatalf("playlists = %+v, want %+v", got.Playlists, want)
+	}
+	if browses != 1 || got.ContinuationStats.Succeeded != 1 || got.ChannelID != testChannelID {
+		t.Fatalf("browses=%d stats=%+v", browses, got.ContinuationStats)
+	}
+}
+
+func TestParseCountText(t *testing.T) {
+	for text, want := range map[string]int64{
+		"12,345 views":     12345,
+		"1.2M subscribers": 1200000,
+		"3.4K videos":      3400,
+		"2B views":         2000000000,
+		"No views":         0,
+		"1 video":          1,
+		"":                 0,
+	} {
+		if got := parseCountText(text); got != want {
+			t.Errorf("parseCountText(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const channelFeedXML = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns:media="http://search.yahoo.com/mrss/" xmlns="http://www.w3.org/2005/Atom">
+ <yt:channelId>UCabcdefghijklmnopqrstuv</yt:channelId>
+ <title>Example Channel</title>
+ <entry>
+  <yt:videoId>bbbbbbbbbbb</yt:videoId>
+  <title>Newer</title>
+  <published>2026-10-14T12:00:00+00:00</published>
+  <media:group><media:title>Newer</media:title></media:group>
+ </entry>
+ <entry>
+  <yt:videoId>aaaaaaaaaaa</yt:videoId>
+  <title>Older</title>
+  <published>2026-10-01T08:30:00+00:00</published>
+ </entry>
+</feed>`
+
+func TestGetChannelFeed_ResolvesHandleAndParsesEntries(t *testing.T) {
+	var paths []string
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			paths = append(paths, r.URL.Path)
+			switch {
+			case r.URL.Path == "/@example":
+				body := `<html><script>var ytInitialData = {"metadata":{"channelMetadataRenderer":{"externalId":"UCabcdefghijklmnopqrstuv"}}};</script></html>`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.URL.Path == "/feeds/videos.xml" && r.URL.Query().Get("channel_id") == "UCabcdefghijklmnopqrstuv":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(channelFeedXML)), Header: make(http.Header)}, nil
+			}
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+		}),
+	}
+	c := &Client{config: Config{HTTPClient: httpClient}}
+
+	feed, err := c.GetChannelFeed(context.Background(), "@example")
+	if err != nil {
+		t.Fatalf("GetChannelFeed() error = %v", err)
+	}
+	if feed.ChannelID != "UCabcdefghijklmnopqrstuv" || feed.Title != "Example Channel" {
+		t.Fatalf("feed = %+v", feed)
+	}
+	if len(feed.Entries) != 2 || feed.Entries[0].VideoID != "bbbbbbbbbbb" || feed.Entries[0].Title != "Newer" {
+		t.Fatalf("entries = %+v", feed.Entries)
+	}
+	if feed.Entries[1].Published.Day() != 1 {
+		t.Fatalf("published = %v", feed.Entries[1].Published)
+	}
+	if len(paths) != 2 {
+		t.Fatalf("requests = %v", paths)
+	}
+
+	paths = nil
+	if _, err := c.GetChannelFeed(context.Background(), "https://www.youtube.com/channel/UCabcdefghijklmnopqrstuv"); err != nil {
+		t.Fatalf("GetChannelFeed(channel URL) error = %v", err)
+	}
+	if len(paths) != 1 {
+		t.Fatalf("channel URL should not need a page lookup, requests = %v", paths)
+	}
+}
+
+func TestGetFeed_PlaylistFeed(t *testing.T) {
+	feedXML := strings.Replace(channelFeedXML, "<yt:channelId>", "<yt:playlistId>PLabc</yt:playlistId>\n <yt:channelId>", 1)
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.URL.Path == "/feeds/videos.xml" && r.URL.Query().Get("playlist_id") == "PLabc" {
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(feedXML)), Header: make(http.Header)}, nil
+			}
+			t.Fatalf("unexpected request: %s", r.URL)
+			return nil, nil
+		}),
+	}
+	c := &Client{config: Config{HTTPClient: httpClient}}
+
+	feed, err := c.GetFeed(context.Background(), "https://www.youtube.com/feeds/videos.xml?playlist_id=PLabc")
+	if err != nil {
+		t.Fatalf("GetFeed() error = %v", err)
+	}
+	if feed.PlaylistID != "PLabc" || len(feed.Entries) != 2 {
+		t.Fatalf("feed = %+v", feed)
+	}
+	if _, err := c.GetFeed(context.Background(), "https://example.com/feeds/videos.xml?playlist_id=PLabc"); err == nil {
+		t.Fatal("expected non-YouTube feed URL to be rejected")
+	}
+}
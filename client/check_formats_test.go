@@ -0,0 +1,141 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestResolveDownloadURLs_CheckFormatsFallsBackOnForbidden(t *testing.T) {
+	var probed []string
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body: io.NopCloser(bytes.NewBufferString(`{
+						"playabilityStatus":{"status":"OK"},
+						"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo","author":"jawed"},
+						"streamingData":{"formats":[
+							{"itag":22,"url":"https://stream.local/v22.mp4","mimeType":"video/mp4","bitrate":2000,"width":1280,"height":720},
+							{"itag":18,"url":"https://stream.local/v18.mp4","mimeType":"video/mp4","bitrate":1000,"width":640,"height":360}
+						]}
+					}`)),
+				}, nil
+			case r.Method == http.MethodGet && r.URL.Path == "/watch":
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(bytes.NewBufferString(`<html><script src="/s/player/test/base.js"></script></html>`)),
+				}, nil
+			case r.Method == http.MethodGet && r.URL.Host == "stream.local":
+				probed = append(probed, r.URL.Path)
+				if r.Header.Get("Range") != "bytes=0-0" {
+					t.Fatalf("probe Range=%q, want bytes=0-0", r.Header.Get("Range"))
+				}
+				status := http.StatusPartialContent
+				if r.URL.Path == "/v22.mp4" {
+					status = http.StatusForbidden
+				}
+				return &http.Response{StatusCode: status, Header: make(http.Header), Body: io.NopCloser(bytes.NewBufferString("x"))}, nil
+			default:
+				t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+				return nil, nil
+			}
+		}),
+	}
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+	})
+
+	streams, err := c.ResolveDownloadURLs(context.Background(), "jNQXAC9IVRw", DownloadOptions{
+		FormatSelector: "best",
+		CheckFormats:   true,
+	})
+	if err != nil {
+		t.Fatalf("ResolveDownloadURLs() error = %v", err)
+	}
+	if len(streams) != 1 || streams[0].Format.Itag != 18 {
+		t.Fatalf("streams=%+v, want itag 18 after 22 failed check", streams)
+	}
+	if strings.Join(probed, ",") != "/v22.mp4,/v18.mp4" {
+		t.Fatalf("probed=%v", probed)
+	}
+}
+
+func TestResolveDownloadURLs_CheckFormatsReusesSessionProbes(t *testing.T) {
+	var probed []string
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body: io.NopCloser(bytes.NewBufferString(`{
+						"playabilityStatus":{"status":"OK"},
+						"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo","author":"jawed"},
+						"streamingData":{"formats":[
+							{"itag":22,"url":"https://stream.local/v22.mp4","mimeType":"video/mp4","bitrate":2000,"width":1280,"height":720},
+							{"itag":18,"url":"https://stream.local/v18.mp4","mimeType":"video/mp4","bitrate":1000,"width":640,"height":360}
+						]}
+					}`)),
+				}, nil
+			case r.Method == http.MethodGet && r.URL.Path == "/watch":
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(bytes.NewBufferString(`<html><script src="/s/player/test/base.js"></script></html>`)),
+				}, nil
+			case r.Method == http.MethodGet && r.URL.Host == "stream.local":
+				probed = append(probed, r.URL.Path)
+				status := http.StatusPartialContent
+				if r.URL.Path == "/v22.mp4" {
+					status = http.StatusForbidden
+				}
+				header := make(http.Header)
+				header.Set("Content-Range", "bytes 0-0/4096")
+				return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(bytes.NewBufferString("x"))}, nil
+			default:
+				t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+				return nil, nil
+			}
+		}),
+	}
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+	})
+
+	for i := 0; i < 2; i++ {
+		streams, err := c.ResolveDownloadURLs(context.Background(), "jNQXAC9IVRw", DownloadOptions{
+			FormatSelector: "best",
+			CheckFormats:   true,
+		})
+		if err != nil {
+			t.Fatalf("ResolveDownloadURLs() #%d error = %v", i+1, err)
+		}
+		if len(streams) != 1 || streams[0].Format.Itag != 18 {
+			t.Fatalf("streams=%+v, want itag 18", streams)
+		}
+	}
+	if strings.Join(probed, ",") != "/v22.mp4,/v18.mp4" {
+		t.Fatalf("probed=%v, want each URL probed once per session", probed)
+	}
+
+	// The chunked downloader's content-length probe reuses the check too.
+	ctx := withURLProbeCache(context.Background(), c.urlProbes("jNQXAC9IVRw"))
+	total, err := probeContentLengthWithRange(ctx, httpClient, "https://stream.local/v18.mp4", "jNQXAC9IVRw", nil)
+	if err != nil || total != 4096 {
+		t.Fatalf("probeContentLengthWithRange() = %d, %v; want 4096", total, err)
+	}
+	if len(probed) != 2 {
+		t.Fatalf("probed=%v, want no new probe", probed)
+	}
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ChecksumSuffix is appended to the output path for the sidecar written
+// under DownloadOptions.WriteChecksum.
+const ChecksumSuffix = ".sha256"
+
+// outputDigestsKey carries an *outputDigests to the transfer functions.
+type outputDigestsKey struct{}
+
+// outputDigests hashes output files while they are written sequentially
+// from their first byte, so the final checksum usually needs no read pass.
+// Files filled any other way (resumed, chunked, external downloader, muxer)
+// have no digest and are read once instead.
+type outputDigests struct {
+	mu    sync.Mutex
+	files map[string]*outputDigest
+}
+
+type outputDigest struct {
+	h hash.Hash
+	n int64
+}
+
+func withOutputDigests(ctx context.Context) (context.Context, *outputDigests) {
+	d := &outputDigests{files: map[string]*outputDigest{}}
+	return context.WithValue(ctx, outputDigestsKey{}, d), d
+}
+
+// trackOutputDigest wraps w, a sequential writer filling path from offset,
+// so its bytes are hashed. A write starting past the first byte cannot be
+// hashed and drops path's digest.
+func trackOutputDigest(ctx context.Context, w io.Writer, path string, offset int64) io.Writer {
+	d, ok := ctx.Value(outputDigestsKey{}).(*outputDigests)
+	if !ok {
+		return w
+	}
+	if offset != 0 {
+		d.forget(path)
+		return w
+	}
+	digest := &outputDigest{h: sha256.New()}
+	d.mu.Lock()
+	d.files[path] = digest
+	d.mu.Unlock()
+	return &digestWriter{w: w, digest: digest}
+}
+
+// forgetOutputDigest drops path's digest before it is rewritten by a
+// writer trackOutputDigest does not see.
+func forgetOutputDigest(ctx context.Context, path string) {
+	if d, ok := ctx.Value(outputDigestsKey{}).(*outputDigests); ok {
+		d.forget(path)
+	}
+}
+
+func (d *outputDigests) forget(path string) {
+	d.mu.Lock()
+	delete(d.files, path)
+	d.mu.Unlock()
+}
+
+// sum returns the hex SHA-256 of path, from the digest recorded while it
+// was written when that covers the whole file, otherwise by reading it.
+func (d *outputDigests) sum(path string) (string, error) {
+	d.mu.Lock()
+	digest := d.files[path]
+	d.mu.Unlock()
+	pipe := isPipeOutput(path)
+	if digest != nil {
+		// A pipe reports no size, and its bytes are gone.
+		if st, err := os.Stat(path); err == nil && (pipe || st.Size() == digest.n) {
+			return hex.EncodeToString(digest.h.Sum(nil)), nil
+		}
+	}
+	if pipe {
+		return "", fmt.Errorf("%s is a pipe and was not hashed while written", path)
+	}
+	return fileSHA256(path)
+}
+
+type digestWriter struct {
+	w      io.Writer
+	digest *outputDigest
+}
+
+func (w *digestWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.digest.h.Write(p[:n])
+	w.digest.n += int64(n)
+	return n, err
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeChecksumSidecar writes path+ChecksumSuffix in sha256sum format.
+func writeChecksumSidecar(path, sum string) error {
+	return os.WriteFile(path+ChecksumSuffix, []byte(checksumLine(sum, filepath.Base(path))), 0o644)
+}
+
+// checksumLine is the sha256sum line for the file called name.
+func checksumLine(sum, name string) string {
+	return fmt.Sprintf("%s  %s\n", sum, name)
+}
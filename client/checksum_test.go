@@ -0,0 +1,88 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestOutputDigests_HashesSequentialWrites(t *testing.T) {
+	ctx, digests := withOutputDigests(context.Background())
+	dir := t.TempDir()
+
+	// The recorded digest is used without reading the file back: the file
+	// holds different bytes of the same size.
+	streamed := filepath.Join(dir, "streamed.bin")
+	var buf bytes.Buffer
+	if _, err := io.WriteString(trackOutputDigest(ctx, &buf, streamed, 0), "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(streamed, []byte("xyz"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := digests.sum(streamed); err != nil || got != sha256Hex("abc") {
+		t.Fatalf("sum(streamed) = %q, %v; want the digest recorded while writing", got, err)
+	}
+
+	// A resumed file is read once instead.
+	resumed := filepath.Join(dir, "resumed.bin")
+	trackOutputDigest(ctx, &buf, resumed, 0)
+	if err := os.WriteFile(resumed, []byte("abcdef"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	trackOutputDigest(ctx, &buf, resumed, 3)
+	if got, err := digests.sum(resumed); err != nil || got != sha256Hex("abcdef") {
+		t.Fatalf("sum(resumed) = %q, %v; want the file's digest", got, err)
+	}
+}
+
+func TestDownload_WriteChecksum(t *testing.T) {
+	const payload = "media-payload"
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+					"streamingData":{"formats":[
+						{"itag":18,"url":"https://media.example/v18.mp4","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.Host == "media.example":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(payload)), Header: make(http.Header)}, nil
+			}
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+		}),
+	}
+	c := New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}})
+	out := filepath.Join(t.TempDir(), "out.mp4")
+
+	res, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{OutputPath: out, WriteChecksum: true})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if res.SHA256 != sha256Hex(payload) {
+		t.Fatalf("SHA256 = %q, want %q", res.SHA256, sha256Hex(payload))
+	}
+	sidecar, err := os.ReadFile(out + ChecksumSuffix)
+	if err != nil {
+		t.Fatalf("read sidecar: %v", err)
+	}
+	if want := sha256Hex(payload) + "  out.mp4\n"; string(sidecar) != want {
+		t.Fatalf("sidecar = %q, want %q", sidecar, want)
+	}
+}
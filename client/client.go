@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/famomatic/ytv1/internal/challenge"
@@ -24,20 +25,51 @@ import (
 type Client struct {
 	config           Config
 	engine           *orchestrator.Engine
+	quota            *innertube.QuotaGuard
+	cooldown         *rateLimitCooldown
+	bandwidth        *bandwidthMeter
+	capture          *fixtureCapture
 	playerJSResolver playerjs.Resolver
 	logger           Logger
 	sessionsMu       sync.RWMutex
 	sessions         map[string]videoSession
+	sessionEvictions int64 // guarded by sessionsMu, like responsesDropped
+	responsesDropped int64
 	challengesMu     sync.RWMutex
 	challenges       map[string]challengeSolutions
+	// lastBytesPerSecond is the speed of the last completed download, for
+	// estimating how long the next one takes.
+	lastBytesPerSecond atomic.Int64
 }
 
 type videoSession struct {
-	Response   *innertube.PlayerResponse
-	PlayerURL  string
-	Info       *VideoInfo
+	Response  *innertube.PlayerResponse
+	PlayerURL string
+	Info      *VideoInfo
+	// DuplicateFormats are copies of Info.Formats streams from other
+	// clients or URLs, dropped by format deduplication.
+	DuplicateFormats []FormatInfo
+	// Extractor names the Extractor that produced Info from Input; Response
+	// is nil then.
+	Extractor  string
+	Input      string
 	CachedAt   time.Time
 	LastAccess time.Time
+	// Probes caches range-probe results for this session's stream URLs.
+	Probes *urlProbeCache
+	// Transcripts caches parsed caption tracks by track URL.
+	Transcripts *transcriptCache
+	// Bytes is the session's approximate size; see approxSessionBytes.
+	Bytes int64
+	// MetadataOnly marks a session from GetVideoMetadata, extracted without
+	// the player's signature timestamp.
+	MetadataOnly bool
+}
+
+// InnertubeClientNames lists the names accepted by Config.ClientOverrides and
+// Config.ClientSkip, sorted.
+func InnertubeClientNames() []string {
+	return innertube.NewRegistry().Names()
 }
 
 // New creates a new YouTube client.
@@ -45,8 +77,14 @@ func New(config Config) *Client {
 	return NewClient(config)
 }
 
-// NewClient creates a new YouTube client.
+// NewClient creates a new YouTube client. Config.Validate errors are logged
+// as warnings; use NewStrict to refuse them.
 func NewClient(config Config) *Client {
+	// Validate before defaults fill in fields such as HTTPClient.
+	configErrs := config.Validate()
+	if forceNoDisk {
+		config.NoDisk = true
+	}
 	if config.HTTPClient == nil {
 		config.HTTPClient = defaultHTTPClient(config.ProxyURL)
 	}
@@ -57,8 +95,28 @@ func NewClient(config Config) *Client {
 		config.PoTokenProvider = challenge.NewCachedPoTokenProvider(config.PoTokenProvider)
 	}
 
+	config.HTTPClient = withConsentTransport(config.HTTPClient, config)
+	config.HTTPClient = withInterceptTransport(config.HTTPClient, config)
+	cooldown := newRateLimitCooldown(config.RateLimitCooldown, config.OnRateLimitState)
+	config.HTTPClient = withCooldownTransport(config.HTTPClient, cooldown)
+	bandwidth := &bandwidthMeter{limit: config.MaxTotalBytes}
+	config.HTTPClient = withBandwidthTransport(config.HTTPClient, bandwidth)
+
 	registry := innertube.NewRegistry()
 	innerCfg := config.ToInnerTubeConfig()
+	innerCfg.QuotaGuard = innertube.NewQuotaGuard(innertube.QuotaConfig(config.InnertubeQuota))
+	logger := config.Logger
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	for _, err := range configErrs {
+		logger.Warnf("config: %v", err)
+	}
+	innerCfg.OnSchemaDrift = newSchemaDriftReporter(config.OnSchemaDrift, logger).report
+	capture := newFixtureCapture(config, logger)
+	if capture != nil {
+		innerCfg.OnResponseAnomaly = capture.playerResponse
+	}
 	preferAuthDefaults := config.CookieJar != nil || (config.HTTPClient != nil && config.HTTPClient.Jar != nil)
 	selector := policy.NewSelector(registry, innerCfg.ClientOverrides, innerCfg.ClientSkip, preferAuthDefaults)
 	engine := orchestrator.NewEngine(selector, innerCfg)
@@ -69,22 +127,22 @@ func NewClient(config Config) *Client {
 	mergeHeaders(playerHeaders, innerCfg.PlayerJSHeaders)
 	jsResolver := playerjs.NewResolver(
 		config.HTTPClient,
-		playerjs.NewMemoryCache(),
+		playerJSCache(config),
 		playerjs.ResolverConfig{
 			BaseURL:         innerCfg.PlayerJSBaseURL,
 			UserAgent:       innerCfg.PlayerJSUserAgent,
+			PageUserAgent:   innerCfg.WatchPageUserAgent,
 			Headers:         playerHeaders,
 			PreferredLocale: innerCfg.PlayerJSPreferredLocale,
 		},
 	)
-	logger := config.Logger
-	if logger == nil {
-		logger = nopLogger{}
-	}
-
 	return &Client{
 		config:           config,
 		engine:           engine,
+		quota:            innerCfg.QuotaGuard,
+		cooldown:         cooldown,
+		bandwidth:        bandwidth,
+		capture:          capture,
 		playerJSResolver: jsResolver,
 		logger:           logger,
 		sessions:         make(map[string]videoSession),
@@ -93,10 +151,19 @@ func NewClient(config Config) *Client {
 }
 
 // GetVideo fetches video metadata and normalized formats for the input ID/URL.
+// During a RateLimitCooldown it first waits for the cool-down to end, outside
+// the request timeout.
 func (c *Client) GetVideo(ctx context.Context, input string) (*VideoInfo, error) {
+	if err := c.cooldown.wait(ctx); err != nil {
+		return nil, err
+	}
 	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
 	defer cancel()
+	ctx = c.withRetryBudget(ctx)
 
+	if entry, ok := c.extractors().match(strings.TrimSpace(input)); ok {
+		return c.extractWith(ctx, entry, input)
+	}
 	videoID, err := normalizeVideoID(input)
 	if err != nil {
 		return nil, err
@@ -131,15 +198,23 @@ func (c *Client) GetVideo(ctx context.Context, input string) (*VideoInfo, error)
 		DashManifestURL: resp.StreamingData.DashManifestURL,
 		HLSManifestURL:  resp.StreamingData.HlsManifestURL,
 	}
-
-	playerURL := ""
-	nChallenges, sigChallenges := collectStreamChallenges(resp, info.DashManifestURL, info.HLSManifestURL)
-	if len(nChallenges) > 0 || len(sigChallenges) > 0 {
-		fetched, fetchErr := c.fetchPlayerURL(ctx, videoID)
-		if fetchErr == nil {
-			playerURL = fetched
-			c.primeChallengeSolutions(ctx, playerURL, resp, info.DashManifestURL, info.HLSManifestURL)
+	applyScheduleInfo(info, resp)
+	applyLiveStreamInfo(info, resp)
+	applyLocalizedInfo(info, resp, parsedFormats, c.config.MetadataLanguage)
+
+	// Format challenges are solved when a format is resolved
+	// (FormatInfo.Resolve, downloads), not here: listing formats costs no
+	// player JS work. Manifests are fetched right below, so an n on their
+	// URLs is solved now. A response scraped from the watch page already
+	// names its player.
+	playerURL := resp.PlayerURL
+	if hasQueryParam(info.DashManifestURL, "n") || hasQueryParam(info.HLSManifestURL, "n") {
+		if playerURL == "" {
+			if fetched, fetchErr := c.fetchPlayerURL(ctx, videoID); fetchErr == nil {
+				playerURL = fetched
+			}
 		}
+		c.primeChallengeSolutions(ctx, playerURL, &innertube.PlayerResponse{}, info.DashManifestURL, info.HLSManifestURL)
 	}
 	info.DashManifestURL = c.resolveManifestURL(ctx, info.DashManifestURL, playerURL, resp.SourceClient, innertube.StreamingProtocolDASH)
 	info.HLSManifestURL = c.resolveManifestURL(ctx, info.HLSManifestURL, playerURL, resp.SourceClient, innertube.StreamingProtocolHLS)
@@ -148,15 +223,39 @@ func (c *Client) GetVideo(ctx context.Context, input string) (*VideoInfo, error)
 	if len(manifestFormats) > 0 {
 		info.Formats = appendUniqueFormats(info.Formats, manifestFormats)
 	}
+	var duplicates []FormatInfo
+	if !c.config.DisableFormatDedup {
+		info.Formats, duplicates = dedupeFormats(info.Formats, c.config)
+	}
+	c.attachResolvers(videoID, info.Formats)
+	c.attachResolvers(videoID, duplicates)
+	if c.config.ProbeThumbnails {
+		if thumb, err := c.ResolveBestThumbnail(ctx, videoID); err == nil {
+			info.BestThumbnailURL = thumb
+		} else {
+			c.warnf("thumbnail probe failed: %v", err)
+		}
+	}
 	c.putSession(videoID, videoSession{
-		Response:  resp,
-		PlayerURL: playerURL,
-		Info:      cloneVideoInfo(info),
+		Response:         resp,
+		PlayerURL:        playerURL,
+		Info:             cloneVideoInfo(info),
+		DuplicateFormats: duplicates,
+		MetadataOnly:     types.MetadataOnlyFromContext(ctx),
 	})
 
 	return info, nil
 }
 
+// GetVideoMetadata is GetVideo for callers that only read metadata, such
+// as info scrapers: the player JS is not fetched for the signature
+// timestamp, which only ciphered stream URLs need. Format URLs still
+// resolve on demand; a ciphered format or a Download re-extracts the video
+// first.
+func (c *Client) GetVideoMetadata(ctx context.Context, input string) (*VideoInfo, error) {
+	return c.GetVideo(types.WithMetadataOnly(ctx), input)
+}
+
 // GetFormats returns normalized formats only.
 func (c *Client) GetFormats(ctx context.Context, input string) ([]FormatInfo, error) {
 	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
@@ -191,7 +290,7 @@ func (c *Client) FetchDASHManifest(ctx context.Context, input string) (string, e
 	if manifestURL == "" {
 		return "", fmt.Errorf("%w: dash manifest unavailable for video=%s", ErrNoPlayableFormats, videoID)
 	}
-	manifest, err := formats.FetchDASHManifest(ctx, c.config.HTTPClient, manifestURL)
+	manifest, err := formats.FetchDASHManifest(ctx, c.config.HTTPClient, manifestURL, buildMediaRequestHeaders(c.mediaHeaders(manifestURL), videoID))
 	if err != nil {
 		return "", err
 	}
@@ -217,7 +316,7 @@ func (c *Client) FetchHLSManifest(ctx context.Context, input string) (string, er
 	if manifestURL == "" {
 		return "", fmt.Errorf("%w: hls manifest unavailable for video=%s", ErrNoPlayableFormats, videoID)
 	}
-	manifest, err := formats.FetchHLSManifest(ctx, c.config.HTTPClient, manifestURL)
+	manifest, err := formats.FetchHLSManifest(ctx, c.config.HTTPClient, manifestURL, buildMediaRequestHeaders(c.mediaHeaders(manifestURL), videoID))
 	if err != nil {
 		return "", err
 	}
@@ -229,13 +328,25 @@ func (c *Client) ResolveStreamURL(ctx context.Context, videoID string, itag int)
 	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
 	defer cancel()
 
+	if session, ok := c.getSession(videoID); ok && session.Extractor != "" {
+		for _, f := range session.Info.Formats {
+			if f.Itag == itag {
+				return f.URL, nil
+			}
+		}
+		return "", fmt.Errorf("%w: itag=%d", ErrNoPlayableFormats, itag)
+	}
 	videoID, err := normalizeVideoID(videoID)
 	if err != nil {
 		return "", err
 	}
 
 	session, ok := c.getSession(videoID)
-	if !ok {
+	if !ok || session.Response == nil || session.MetadataOnly && isCipheredItag(session.Response, itag) {
+		// A session whose response was dropped (Config.DropSessionResponses)
+		// is re-extracted for its ciphers, as is a metadata-only one whose
+		// player request lacked the signature timestamp.
+		ctx = types.WithoutMetadataOnly(ctx)
 		if _, err := c.GetVideo(ctx, videoID); err != nil {
 			return "", err
 		}
@@ -249,6 +360,12 @@ func (c *Client) ResolveStreamURL(ctx context.Context, videoID string, itag int)
 	if !found {
 		return "", fmt.Errorf("%w: itag=%d", ErrNoPlayableFormats, itag)
 	}
+	// The batch solver reports unsolved challenges as a challenge "partial"
+	// event; the decodes below then hit its cache.
+	c.primeFormatChallenges(ctx, videoID, []FormatInfo{{Itag: itag}})
+	if updated, ok := c.getSession(videoID); ok && updated.Response != nil {
+		session = updated
+	}
 
 	if raw.URL != "" {
 		if hasQueryParam(raw.URL, "n") && strings.TrimSpace(session.PlayerURL) == "" {
@@ -266,7 +383,7 @@ func (c *Client) ResolveStreamURL(ctx context.Context, videoID string, itag int)
 			protocolFromRawFormat(raw),
 		)
 		if err != nil {
-			return "", err
+			return "", withChallengeItag(err, itag)
 		}
 		return rewritten, nil
 	}
@@ -317,6 +434,9 @@ func (c *Client) ResolveStreamURL(ctx context.Context, videoID string, itag int)
 	if n := q.Get("n"); n != "" {
 		decN, err := c.decodeNWithCache(ctx, session.PlayerURL, n)
 		if err != nil {
+			if c.config.StrictChallenges {
+				return "", withChallengeItag(challengeNotSolvedError("n", u.String(), session.Response.SourceClient, protocolFromRawFormat(raw), err), itag)
+			}
 			c.warnf("n challenge decode failed for video=%s itag=%d; using original n value: %v", videoID, itag, err)
 		} else {
 			q.Set("n", decN)
@@ -332,6 +452,10 @@ func (c *Client) ResolveStreamURL(ctx context.Context, videoID string, itag int)
 }
 
 func (c *Client) resolveSelectedFormatURL(ctx context.Context, videoID string, f FormatInfo) (string, error) {
+	if c.isExtractedVideo(videoID) {
+		// Extractor formats are used as given.
+		return f.URL, nil
+	}
 	videoID, err := normalizeVideoID(videoID)
 	if err != nil {
 		return "", err
@@ -355,32 +479,52 @@ func (c *Client) resolveSelectedFormatURL(ctx context.Context, videoID string, f
 			}
 			session = updated
 		}
-		return c.resolveDirectURL(ctx, f.URL, session.PlayerURL, f.SourceClient, protocolFromFormat(f))
+		rewritten, err := c.resolveDirectURL(ctx, f.URL, session.PlayerURL, f.SourceClient, protocolFromFormat(f))
+		if err != nil {
+			return "", withChallengeItag(err, f.Itag)
+		}
+		return rewritten, nil
 	}
 
 	return c.ResolveStreamURL(ctx, videoID, f.Itag)
 }
 
+// attachResolvers makes each format resolve its URL through the video's
+// session when FormatInfo.Resolve is called.
+func (c *Client) attachResolvers(videoID string, list []FormatInfo) {
+	for i := range list {
+		f := list[i]
+		list[i] = f.WithResolver(func(ctx context.Context) (string, error) {
+			return c.resolveSelectedFormatURL(ctx, videoID, f)
+		})
+	}
+}
+
 func toFormatInfo(f formats.Format) FormatInfo {
 	hasVideo := f.HasVideo
 	hasAudio := f.HasAudio
 	return FormatInfo{
-		Itag:         f.Itag,
-		URL:          f.URL,
-		MimeType:     f.MimeType,
-		Protocol:     f.Protocol,
-		HasAudio:     hasAudio,
-		HasVideo:     hasVideo,
-		Bitrate:      f.Bitrate,
-		Width:        f.Width,
-		Height:       f.Height,
-		FPS:          f.FPS,
-		Ciphered:     f.Ciphered,
-		IsDRM:        f.IsDRM,
-		IsDamaged:    f.IsDamaged,
-		Quality:      f.Quality,
-		QualityLabel: f.QualityLabel,
-		SourceClient: f.SourceClient,
+		Itag:           f.Itag,
+		URL:            f.URL,
+		MimeType:       f.MimeType,
+		Protocol:       f.Protocol,
+		HasAudio:       hasAudio,
+		HasVideo:       hasVideo,
+		Bitrate:        f.Bitrate,
+		Width:          f.Width,
+		Height:         f.Height,
+		FPS:            f.FPS,
+		Ciphered:       f.Ciphered,
+		IsDRM:          f.IsDRM,
+		IsDamaged:      f.IsDamaged,
+		Quality:        f.Quality,
+		QualityLabel:   f.QualityLabel,
+		SourceClient:   f.SourceClient,
+		ContentLength:  f.ContentLength,
+		ExpiresAt:      formats.Expiry(f),
+		Language:       f.Language,
+		AudioTrackName: f.AudioTrackName,
+		Hints:          formats.Hints(f),
 	}
 }
 
@@ -424,8 +568,12 @@ func mapError(err error) error {
 		attempts := make([]AttemptDetail, 0, len(allFailedErr.Attempts))
 		hasUnavailable := false
 		hasLoginRequired := false
+		allQuota := len(allFailedErr.Attempts) > 0
 		for _, attempt := range allFailedErr.Attempts {
 			attempts = append(attempts, attemptDetailFromSingle(attempt.Client, attempt.Err))
+			if !errors.Is(attempt.Err, ErrQuotaExceeded) {
+				allQuota = false
+			}
 			if !errors.As(attempt.Err, &playabilityErr) {
 				continue
 			}
@@ -442,6 +590,9 @@ func mapError(err error) error {
 		if hasUnavailable {
 			return &UnavailableDetailError{Attempts: attempts}
 		}
+		if allQuota {
+			return fmt.Errorf("%w: %w", ErrQuotaExceeded, &AllClientsFailedDetailError{Attempts: attempts})
+		}
 		return &AllClientsFailedDetailError{Attempts: attempts}
 	}
 
@@ -495,6 +646,12 @@ func attemptDetailFromSingle(client string, err error) AttemptDetail {
 		return d
 	}
 
+	var timeoutErr *orchestrator.ClientTimeoutError
+	if errors.As(err, &timeoutErr) {
+		d.Stage = "request"
+		return d
+	}
+
 	var poTokenErr *orchestrator.PoTokenRequiredError
 	if errors.As(err, &poTokenErr) {
 		d.Stage = "pot"
@@ -537,6 +694,15 @@ func (c *Client) putSession(videoID string, session videoSession) {
 		session.CachedAt = now
 	}
 	session.LastAccess = now
+	if session.Probes == nil {
+		session.Probes = newURLProbeCache()
+	}
+	if session.Transcripts == nil {
+		session.Transcripts = newTranscriptCache()
+	}
+	if session.Bytes == 0 {
+		session.Bytes = approxSessionBytes(session)
+	}
 
 	c.sessionsMu.Lock()
 	defer c.sessionsMu.Unlock()
@@ -549,6 +715,12 @@ func (c *Client) putSession(videoID string, session videoSession) {
 	c.evictLRULocked()
 }
 
+func (c *Client) dropSession(videoID string) {
+	c.sessionsMu.Lock()
+	defer c.sessionsMu.Unlock()
+	delete(c.sessions, videoID)
+}
+
 func (c *Client) evictExpiredLocked(now time.Time) {
 	ttl := c.config.SessionCacheTTL
 	if ttl <= 0 {
@@ -564,12 +736,20 @@ func (c *Client) evictExpiredLocked(now time.Time) {
 	}
 }
 
+// evictLRULocked evicts least recently used sessions until the cache fits
+// SessionCacheMaxEntries and SessionCacheMaxBytes. The newest session is
+// kept even when it alone exceeds the byte budget.
 func (c *Client) evictLRULocked() {
 	maxEntries := c.config.SessionCacheMaxEntries
-	if maxEntries <= 0 {
+	maxBytes := c.config.SessionCacheMaxBytes
+	if maxEntries <= 0 && maxBytes <= 0 {
 		return
 	}
-	for len(c.sessions) > maxEntries {
+	var total int64
+	for _, session := range c.sessions {
+		total += session.Bytes
+	}
+	for (maxEntries > 0 && len(c.sessions) > maxEntries) || (maxBytes > 0 && total > maxBytes && len(c.sessions) > 1) {
 		var oldestID string
 		var oldest time.Time
 		first := true
@@ -587,7 +767,9 @@ func (c *Client) evictLRULocked() {
 		if oldestID == "" {
 			return
 		}
+		total -= c.sessions[oldestID].Bytes
 		delete(c.sessions, oldestID)
+		c.sessionEvictions++
 	}
 }
 
@@ -597,7 +779,7 @@ func (c *Client) ensureSession(ctx context.Context, input string) (videoSession,
 		return videoSession{}, "", err
 	}
 	session, ok := c.getSession(videoID)
-	if ok {
+	if ok && session.Response != nil {
 		return session, videoID, nil
 	}
 	if _, err := c.GetVideo(ctx, videoID); err != nil {
@@ -610,6 +792,12 @@ func (c *Client) ensureSession(ctx context.Context, input string) (videoSession,
 	return session, videoID, nil
 }
 
+// isCipheredItag reports whether itag's URL needs its signature deciphered.
+func isCipheredItag(resp *innertube.PlayerResponse, itag int) bool {
+	raw, ok := findRawFormat(resp, itag)
+	return ok && raw.URL == "" && (raw.SignatureCipher != "" || raw.Cipher != "")
+}
+
 func findRawFormat(resp *innertube.PlayerResponse, itag int) (innertube.Format, bool) {
 	if resp == nil {
 		return innertube.Format{}, false
@@ -731,7 +919,7 @@ func (c *Client) loadManifestFormats(ctx context.Context, dashURL, hlsURL string
 	out := make([]FormatInfo, 0, 16)
 	if dashURL != "" {
 		c.emitExtractionEvent("manifest", "start", "dash", dashURL)
-		if dash, err := formats.FetchDASHManifest(ctx, c.httpClient(), dashURL); err == nil {
+		if dash, err := formats.FetchDASHManifest(ctx, c.httpClient(), dashURL, buildMediaRequestHeaders(c.mediaHeaders(dashURL), "")); err == nil {
 			c.emitExtractionEvent("manifest", "success", "dash", dashURL)
 			for _, f := range dash.Formats {
 				out = append(out, toFormatInfo(f))
@@ -742,7 +930,7 @@ func (c *Client) loadManifestFormats(ctx context.Context, dashURL, hlsURL string
 	}
 	if hlsURL != "" {
 		c.emitExtractionEvent("manifest", "start", "hls", hlsURL)
-		if hls, err := formats.FetchHLSManifest(ctx, c.httpClient(), hlsURL); err == nil {
+		if hls, err := formats.FetchHLSManifest(ctx, c.httpClient(), hlsURL, buildMediaRequestHeaders(c.mediaHeaders(hlsURL), "")); err == nil {
 			c.emitExtractionEvent("manifest", "success", "hls", hlsURL)
 			for _, f := range hls.Formats {
 				out = append(out, toFormatInfo(f))
@@ -802,6 +990,10 @@ func (c *Client) resolveDirectURL(
 			return c.decodeNWithCache(ctx, playerURL, value)
 		})
 		if err != nil {
+			c.emitExtractionEvent("challenge", "failure", "web", "n decode failed for direct url: "+err.Error())
+			if c.config.StrictChallenges {
+				return "", challengeNotSolvedError("n", rawURL, sourceClient, protocol, err)
+			}
 			c.warnf("n challenge decode failed for direct url; using original url: %v", err)
 		} else {
 			rewritten = nRewritten
@@ -815,6 +1007,40 @@ func (c *Client) resolveDirectURL(
 	return potRewritten, nil
 }
 
+// challengeNotSolvedError builds the strict-mode error for a failed challenge
+// decode on rawURL, carrying the same URL diagnostics as download attempts.
+func challengeNotSolvedError(
+	challenge string,
+	rawURL string,
+	sourceClient string,
+	protocol innertube.VideoStreamingProtocol,
+	err error,
+) *ChallengeNotSolvedDetailError {
+	attempt := downloadAttemptFromFormatAndURL(types.FormatInfo{
+		SourceClient: sourceClient,
+		Protocol:     string(protocol),
+	}, rawURL, fmt.Errorf("%s decode failed: %w", challenge, err))
+	attempt.Stage = "challenge"
+	return &ChallengeNotSolvedDetailError{
+		Challenge: challenge,
+		Attempts:  []AttemptDetail{attempt},
+	}
+}
+
+// withChallengeItag records itag on strict-mode challenge attempts, which are
+// built where only the URL is known.
+func withChallengeItag(err error, itag int) error {
+	var detail *ChallengeNotSolvedDetailError
+	if errors.As(err, &detail) {
+		for i := range detail.Attempts {
+			if detail.Attempts[i].Itag == 0 {
+				detail.Attempts[i].Itag = itag
+			}
+		}
+	}
+	return err
+}
+
 func (c *Client) warnf(format string, args ...any) {
 	if c == nil || c.logger == nil {
 		return
@@ -839,6 +1065,124 @@ func parseInt64String(raw string) int64 {
 	return v
 }
 
+// applyLocalizedInfo fills the language fields of info. When lang is set, the
+// localized title and description replace the original ones.
+func applyLocalizedInfo(info *VideoInfo, resp *innertube.PlayerResponse, parsed []formats.Format, lang string) {
+	mf := resp.Microformat.PlayerMicroformatRenderer
+	info.LocalizedTitle = mf.Title.SimpleText
+	info.LocalizedDescription = mf.Description.SimpleText
+	info.DefaultLanguage = mf.DefaultLanguage
+	if info.DefaultLanguage == "" {
+		// The original audio track is in the upload's own language.
+		for _, f := range parsed {
+			if f.AudioIsDefault && f.Language != "" {
+				info.DefaultLanguage = f.Language
+				break
+			}
+		}
+	}
+	info.Language = info.DefaultLanguage
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		return
+	}
+	translated := false
+	if info.LocalizedTitle != "" && info.LocalizedTitle != info.Title {
+		info.Title, translated = info.LocalizedTitle, true
+	}
+	if info.LocalizedDescription != "" && info.LocalizedDescription != info.Description {
+		info.Description, translated = info.LocalizedDescription, true
+	}
+	if translated {
+		info.Language = lang
+	}
+}
+
+// applyScheduleInfo fills the upcoming/premiere fields of info.
+func applyScheduleInfo(info *VideoInfo, resp *innertube.PlayerResponse) {
+	broadcast := resp.Microformat.PlayerMicroformatRenderer.LiveBroadcastDetails
+	info.IsUpcoming = resp.VideoDetails.IsUpcoming || resp.PlayabilityStatus.IsUpcoming()
+	info.IsPremiere = (info.IsUpcoming || broadcast != nil) && !resp.VideoDetails.IsLiveContent
+	info.TrailerVideoID = resp.PlayabilityStatus.TrailerVideoID()
+	info.LiveReplay = liveReplayStatus(resp)
+	if !info.IsUpcoming {
+		return
+	}
+	if ls := resp.PlayabilityStatus.LiveStreamability; ls != nil {
+		if slate := ls.LiveStreamabilityRenderer.OfflineSlate; slate != nil && slate.LiveStreamOfflineSlateRenderer != nil {
+			if sec := parseInt64String(slate.LiveStreamOfflineSlateRenderer.ScheduledStartTime); sec > 0 {
+				info.ScheduledStartTime = time.Unix(sec, 0).UTC()
+				return
+			}
+		}
+	}
+	if broadcast != nil {
+		if t, err := time.Parse(time.RFC3339, broadcast.StartTimestamp); err == nil {
+			info.ScheduledStartTime = t.UTC()
+		}
+	}
+}
+
+// applyLiveStreamInfo fills the live segmentation fields of info.
+func applyLiveStreamInfo(info *VideoInfo, resp *innertube.PlayerResponse) {
+	for _, list := range [][]innertube.Format{resp.StreamingData.Formats, resp.StreamingData.AdaptiveFormats} {
+		for _, f := range list {
+			if d := time.Duration(f.TargetDurationSec) * time.Second; d > info.LiveTargetDuration {
+				info.LiveTargetDuration = d
+			}
+			if d := time.Duration(f.MaxDvrDurationSec) * time.Second; d > info.LiveDVRWindow {
+				info.LiveDVRWindow = d
+			}
+		}
+	}
+	info.LatencyClass = parseLatencyClass(resp.VideoDetails)
+}
+
+// parseLatencyClass maps videoDetails.latencyClass
+// (MDE_STREAM_OPTIMIZATIONS_RENDERER_LATENCY_*) to a LatencyClass.
+func parseLatencyClass(details innertube.VideoDetails) LatencyClass {
+	class := strings.ToUpper(details.LatencyClass)
+	switch {
+	case strings.HasSuffix(class, "_ULTRA_LOW"):
+		return LatencyUltraLow
+	case strings.HasSuffix(class, "_LOW"):
+		return LatencyLow
+	case strings.HasSuffix(class, "_NORMAL"):
+		return LatencyNormal
+	case details.IsLowLatencyLiveStream:
+		return LatencyLow
+	}
+	return ""
+}
+
+// liveReplayStatus classifies the replay of an ended live stream. Besides
+// isPostLiveDvr, a replay whose adaptive formats carry no content length is
+// still served from the live DVR and so is treated as processing.
+func liveReplayStatus(resp *innertube.PlayerResponse) LiveReplayStatus {
+	if !resp.VideoDetails.IsLiveContent || resp.VideoDetails.IsUpcoming || resp.PlayabilityStatus.IsUpcoming() {
+		return ""
+	}
+	if broadcast := resp.Microformat.PlayerMicroformatRenderer.LiveBroadcastDetails; broadcast != nil && broadcast.IsLiveNow {
+		return ""
+	}
+	if resp.VideoDetails.IsPostLiveDvr {
+		return LiveReplayProcessing
+	}
+	if resp.PlayabilityStatus.IsLive() {
+		return ""
+	}
+	adaptive := resp.StreamingData.AdaptiveFormats
+	if len(adaptive) == 0 {
+		return LiveReplayReady
+	}
+	for _, f := range adaptive {
+		if f.ContentLength != "" {
+			return LiveReplayReady
+		}
+	}
+	return LiveReplayProcessing
+}
+
 func cloneVideoInfo(v *VideoInfo) *VideoInfo {
 	if v == nil {
 		return nil
@@ -23,6 +23,17 @@ type Config struct {
 	// Applied to HTTPClient if non-nil.
 	CookieJar http.CookieJar
 
+	// MediaCookies controls forwarding of CookieJar session cookies to media hosts.
+	MediaCookies MediaCookieConfig
+
+	// MediaURLParams adds the playback parameters web players send on
+	// googlevideo media requests. All are off by default.
+	MediaURLParams MediaURLParamsConfig
+
+	// EmulatePlayback makes downloads resemble a player session to reduce
+	// throttling on long transfers. Off by default.
+	EmulatePlayback PlaybackEmulationConfig
+
 	// PoTokenProvider is the provider for PO Tokens.
 	// If nil, PO Tokens will not be injected, which may cause throttling or errors.
 	PoTokenProvider innertube.PoTokenProvider
@@ -35,6 +46,18 @@ type Config struct {
 	// Use this to persist sessions or emulate a specific user context.
 	VisitorData string
 
+	// InnertubeHost routes the Innertube API requests (/youtubei/v1/player,
+	// browse) of every client to another host, such as
+	// "youtubei.googleapis.com", a regional endpoint or an enterprise proxy
+	// given as a base URL ("http://proxy.internal:8080"). Watch pages,
+	// cookies and Origin headers stay on the client's YouTube host. Empty
+	// keeps each client's own host.
+	InnertubeHost string
+
+	// InnertubeHosts sets InnertubeHost per client ID (e.g. "ios"), taking
+	// precedence over InnertubeHost.
+	InnertubeHosts map[string]string
+
 	// PlayerJSBaseURL overrides player JS fetch host (default: https://www.youtube.com).
 	PlayerJSBaseURL string
 
@@ -45,10 +68,27 @@ type Config struct {
 	// PlayerJSHeaders are additional headers for player JS fetches.
 	PlayerJSHeaders http.Header
 
+	// UserAgents sets the User-Agent per request stage. Empty fields keep
+	// the defaults: each Innertube client's own User-Agent for metadata and
+	// a desktop Chrome User-Agent elsewhere.
+	UserAgents UserAgentConfig
+
 	// PlayerJSPreferredLocale controls canonical locale for player JS fetch path.
 	// Default is "en_US". Fetch falls back to the original watch-page locale path.
 	PlayerJSPreferredLocale string
 
+	// MetadataLanguage requests title and description localized to this
+	// language (e.g. "ko", "pt-BR") and uses them for VideoInfo.Title,
+	// VideoInfo.Description and embedded file metadata. Videos without a
+	// translation keep their original text. Empty keeps the original
+	// language metadata.
+	MetadataLanguage string
+
+	// TextNormalization cleans up the title, uploader and description used
+	// for Download's output file names and embedded metadata. VideoInfo
+	// keeps the text as YouTube returned it.
+	TextNormalization TextNormalization
+
 	// ClientOverrides sets Innertube client trial order (e.g. "web", "ios", "android").
 	// If empty, package defaults are used.
 	ClientOverrides []string
@@ -58,8 +98,15 @@ type Config struct {
 	// Default is false: explicit override mode disables auto fallback append.
 	AppendFallbackOnClientOverrides bool
 
-	// DisableDynamicAPIKeyResolution disables watch-page ytcfg API key extraction.
-	// Default is false (dynamic resolution enabled).
+	// DisableConsentBypass turns off automatic handling of the cookie-consent
+	// interstitial: by default a youtube.com page request redirected to
+	// consent.youtube.com is retried with SOCS/CONSENT cookies that decline
+	// the wall, and later page requests send them up front.
+	DisableConsentBypass bool
+
+	// DisableDynamicAPIKeyResolution disables ytcfg extraction from the page
+	// of each client (watch, /embed or /tv): API key, client version, visitor
+	// data and STS. Default is false (dynamic resolution enabled).
 	DisableDynamicAPIKeyResolution bool
 
 	// UseAdPlaybackContext enables `playbackContext.adPlaybackContext.pyv=true`
@@ -77,15 +124,78 @@ type Config struct {
 	// Zero means no additional timeout.
 	RequestTimeout time.Duration
 
+	// ClientTimeout bounds each Innertube client's player request, retries
+	// included, so one slow client cannot hold up the others. A timed-out
+	// client fails its attempt like any other error. Zero means no limit
+	// beyond RequestTimeout.
+	ClientTimeout time.Duration
+
+	// ClientCircuitBreaker takes clients whose requests keep running into
+	// ClientTimeout out of rotation for a while. The zero value disables it.
+	ClientCircuitBreaker ClientCircuitBreakerConfig
+
+	// ClassifyProbe sends one player request with the first client before
+	// racing the rest and lets its playability status pick what follows.
+	// An age-gated video skips straight to the embedded and TV clients (plus
+	// cookie-capable clients when a cookie jar is set). A deleted or
+	// region-blocked video fails at once. Anything else continues with the
+	// remaining clients. It trades latency on playable videos for fewer
+	// requests on gated ones.
+	ClassifyProbe bool
+
 	// ClientSkip excludes specific Innertube clients from selection.
 	ClientSkip []string
 
 	// DisableFallbackClients disables automatic fallback-client append behavior.
 	DisableFallbackClients bool
 
+	// DisableWatchPageFallback disables scraping ytInitialPlayerResponse from
+	// the watch page when every client's player request fails at the HTTP
+	// level. ClientOverrides without AppendFallbackOnClientOverrides also
+	// disable it.
+	DisableWatchPageFallback bool
+
 	// MetadataTransport configures retry/backoff for Innertube metadata requests.
 	MetadataTransport MetadataTransportConfig
 
+	// InnertubeQuota caps Innertube API requests per client (requests/hour),
+	// queueing requests over budget. The zero value sets no ceiling.
+	InnertubeQuota InnertubeQuotaConfig
+
+	// RateLimitCooldown pauses new extractions client-wide after consecutive
+	// HTTP 403/429 responses. The zero value disables it.
+	RateLimitCooldown RateLimitCooldownConfig
+
+	// OnRateLimitState receives cool-down state changes (optional): entering
+	// a cool-down and recovering from one with a successful response.
+	OnRateLimitState func(RateLimitState)
+
+	// RequestInterceptor is called with every Innertube API request
+	// (/youtubei/ endpoints: player, browse, ...) just before it is sent, for
+	// custom signing, logging or header changes. It receives a copy it may
+	// modify.
+	RequestInterceptor func(*http.Request)
+
+	// ResponseInterceptor is called with the response of every request
+	// RequestInterceptor sees, before the client reads it.
+	ResponseInterceptor func(*http.Response)
+
+	// InterceptMediaRequests also applies the interceptors to media
+	// (googlevideo.com) downloads.
+	InterceptMediaRequests bool
+
+	// DisableFormatDedup lists every copy of a stream in VideoInfo.Formats.
+	// By default copies with the same itag, protocol and audio track are
+	// collapsed into the healthiest one (non-ciphered, PO token requirement
+	// satisfiable); the others stay available to Download as fallback and
+	// multi-source mirrors.
+	DisableFormatDedup bool
+
+	// ProbeThumbnails makes GetVideo fill VideoInfo.BestThumbnailURL by
+	// probing the thumbnail ladder (see ResolveBestThumbnail), at the cost
+	// of up to four HEAD requests per video.
+	ProbeThumbnails bool
+
 	// MP3Transcoder handles optional stream->mp3 conversion in Download(mode=mp3).
 	// If nil, mp3 mode returns ErrMP3TranscoderNotConfigured.
 	MP3Transcoder MP3Transcoder
@@ -93,10 +203,55 @@ type Config struct {
 	// DownloadTransport configures retry/backoff behavior for stream downloads.
 	DownloadTransport DownloadTransportConfig
 
+	// RetryBudget caps retries across all stages of one GetVideo or Download
+	// call, on top of the per-stage MaxRetries. Independently of it, backoff
+	// waits are trimmed to fit the context deadline and retries stop once
+	// the deadline leaves no time.
+	RetryBudget RetryBudgetConfig
+
+	// ThrottleDetection configures slow-transfer detection for direct downloads.
+	ThrottleDetection ThrottleDetectionConfig
+
+	// SlowDownloadAbort sets a speed floor for every transfer; see
+	// SlowDownloadAbortConfig.
+	SlowDownloadAbort SlowDownloadAbortConfig
+
+	// MaxTotalBytes caps the bytes the client receives over its lifetime,
+	// media and metadata together. The read that crosses it and every later
+	// request fail with ErrMaxTotalBytesExceeded. Zero means unlimited; see
+	// Client.BandwidthUsage for the tally either way.
+	MaxTotalBytes int64
+
+	// DownloadRetryClients lists Innertube clients used to re-extract a format
+	// when its direct media download is still rejected with HTTP 403 after
+	// transport retries. The client that produced the failing URL is skipped.
+	// If empty, "ios", "android_vr" and "tv" are tried in order.
+	DownloadRetryClients []string
+
+	// DisableDownloadClientRetry disables 403 re-extraction via alternate clients.
+	DisableDownloadClientRetry bool
+
+	// StrictChallenges makes n-challenge decode failures on direct stream URLs
+	// return ErrChallengeNotSolved (as *ChallengeNotSolvedDetailError) instead
+	// of warning and using the original, usually throttled, URL. It also
+	// disables Download's progressive-format fallback on unsolved challenges.
+	StrictChallenges bool
+
+	// ExternalDownloader, when set, performs direct media transfers instead
+	// of the built-in HTTP downloader. HLS/DASH manifests and mp3 transcoding
+	// keep the built-in path, and DownloadTransport retries and chunking do
+	// not apply; throttle detection and MaxFileSize work from its progress
+	// reports.
+	ExternalDownloader ExternalDownloader
+
 	// Muxer handles optional video+audio merging in Download(options.Merge=true).
 	// If nil, merge operations will warn and fallback to pre-muxed formats.
 	Muxer Muxer
 
+	// Extractors handles non-YouTube inputs in GetVideo, Download and
+	// ResolveDownloadURLs. If nil, DefaultExtractors is used.
+	Extractors *ExtractorRegistry
+
 	// Logger receives non-fatal package warnings (optional).
 	// If nil, warnings are suppressed.
 	Logger Logger
@@ -105,6 +260,11 @@ type Config struct {
 	// If nil, extraction events are suppressed.
 	OnExtractionEvent func(ExtractionEvent)
 
+	// OnSchemaDrift receives unrecognized player response statuses, fields
+	// and renderer types (optional), once per distinct finding. Drift is also
+	// logged as a warning.
+	OnSchemaDrift func(SchemaDrift)
+
 	// OnDownloadEvent receives download lifecycle events (optional).
 	// If nil, download events are suppressed.
 	OnDownloadEvent func(DownloadEvent)
@@ -121,12 +281,50 @@ type Config struct {
 	// Zero or negative means unbounded.
 	SessionCacheMaxEntries int
 
+	// SessionCacheMaxBytes bounds the approximate memory of in-memory video
+	// sessions (LRU eviction); see Client.SessionCacheUsage. Zero means
+	// unbounded.
+	SessionCacheMaxBytes int64
+
+	// DropSessionResponses releases a video's player response once Download,
+	// ResolveDownloadURLs or OpenStream has resolved its URLs, keeping only
+	// formats and the player URL. Long-running processes trade a
+	// re-extraction on later ciphered resolution for memory.
+	DropSessionResponses bool
+
 	// SubtitlePolicy controls default subtitle track selection behavior.
 	SubtitlePolicy SubtitlePolicy
 
 	// PlaylistContinuationMaxRequests bounds continuation browse requests in GetPlaylist.
 	// Zero or negative uses package default.
 	PlaylistContinuationMaxRequests int
+
+	// CacheDir is the root directory for on-disk caches. Empty disables them.
+	CacheDir string
+
+	// Cache, when set, holds player scripts and cached playlist listings
+	// instead of the per-client memory cache and CacheDir.
+	Cache Cache
+
+	// NoDisk guarantees the client never reads or writes the filesystem, for
+	// read-only containers and serverless environments. CacheDir and
+	// CaptureDir are ignored, and Download and frame extraction through the
+	// Muxer fail with ErrDiskDisabled. GetVideo, OpenStream, GetTranscript,
+	// GetPlaylist and storyboard frames work as usual. Always set on js/wasm.
+	NoDisk bool
+
+	// CaptureDir, when set, receives anonymized copies of player responses that
+	// failed parsing or listed no formats, and of player JS that failed
+	// deciphering, plus a captures.ndjson index. Empty disables capture.
+	CaptureDir string
+
+	// CaptureRedactVideoID also replaces the video ID inside captured responses.
+	CaptureRedactVideoID bool
+
+	// PlaylistCacheTTL reuses a cached GetPlaylist item list younger than this
+	// without any request. Older entries are revalidated with If-None-Match when
+	// the playlist page sent an ETag. Zero disables playlist caching.
+	PlaylistCacheTTL time.Duration
 }
 
 // SubtitlePolicy controls subtitle selection when language is not explicitly specified.
@@ -142,6 +340,45 @@ type Muxer interface {
 	Merge(ctx context.Context, videoPath, audioPath, outputPath string, meta types.Metadata) error
 }
 
+// MultiTrackMuxer is an optional Muxer extension used when a selection merges
+// more than one video and one audio stream (e.g. "bv+ba[lang=en]+ba[lang=es]").
+// Muxers that do not implement it only receive the first video and audio track.
+type MultiTrackMuxer interface {
+	MergeTracks(ctx context.Context, tracks []types.MuxTrack, outputPath string, meta types.Metadata) error
+}
+
+// AudioExtractor is an optional Muxer extension used by
+// DownloadOptions.ExtractAudio. It writes the audio of inputPath to
+// outputPath, in the container implied by outputPath's extension, tagged with
+// meta. transcode re-encodes to that container's codec instead of copying the
+// stream. inputPath is left in place.
+type AudioExtractor interface {
+	ExtractAudio(ctx context.Context, inputPath, outputPath string, transcode bool, meta types.Metadata) error
+}
+
+// FrameExtractor is an optional Muxer extension used by GetFrameWithOptions's
+// accurate path. It decodes the video frame at at from inputURL, sending
+// headers with every request and fetching only what it needs to seek there,
+// and writes it to outputPath as JPEG.
+type FrameExtractor interface {
+	ExtractFrame(ctx context.Context, inputURL string, headers http.Header, at time.Duration, outputPath string) error
+}
+
+// OutputProber is an optional Muxer extension used by
+// DownloadOptions.ValidateOutput. It reports the duration, stream counts and
+// video frame count of a finished media file.
+type OutputProber interface {
+	ProbeOutput(ctx context.Context, path string) (types.MediaProbe, error)
+}
+
+// ExternalDownloader transfers one resolved media URL to req.OutputPath with
+// an external program such as aria2c or curl. progress, when called, receives
+// cumulative byte counts; each sample is reported as a "download"/"progress"
+// event.
+type ExternalDownloader interface {
+	Download(ctx context.Context, req ExternalDownload, progress func(DownloadProgress)) error
+}
+
 // DownloadTransportConfig controls retry/backoff behavior for direct stream downloads.
 type DownloadTransportConfig struct {
 	MaxRetries               int
@@ -153,6 +390,149 @@ type DownloadTransportConfig struct {
 	MaxConcurrency           int
 	SkipUnavailableFragments bool
 	MaxSkippedFragments      int
+	// MultiSource spreads the chunks of a chunked direct download across
+	// every source serving the same bytes: other formats with the same MIME
+	// type and content length, and the alternate CDN nodes listed in the
+	// URL's mn parameter. Sources whose size probe disagrees are ignored, and
+	// chunks move to the remaining sources when one fails.
+	MultiSource bool
+}
+
+// ThrottleDetectionConfig controls detection of the throttled-URL signature
+// (sustained ~50-100 KB/s on a large stream, typically caused by an unsolved
+// or stale n parameter). When detected, the transfer is aborted, the format
+// URL is re-extracted with a fresh n solve, and the download resumes from
+// the partial file on the new URL.
+type ThrottleDetectionConfig struct {
+	// Disable turns detection off.
+	Disable bool
+	// MinBytesPerSecond is the speed below which a transfer counts as throttled.
+	// Zero uses 100 KiB/s.
+	MinBytesPerSecond int64
+	// Window is how long speed must stay below MinBytesPerSecond. Zero uses 15s.
+	Window time.Duration
+	// MinContentLength skips detection for streams known to be smaller and
+	// for streams of unknown size. Zero uses 10 MiB.
+	MinContentLength int64
+	// MaxRefreshes bounds URL refreshes per stream; after that the transfer
+	// continues at whatever speed it gets. Zero uses 1.
+	MaxRefreshes int
+}
+
+// SlowDownloadAbortConfig aborts transfers whose average speed stays below
+// a floor, on any protocol and stream size. A slow direct stream first gets
+// its URL refreshed like a throttled one (sharing
+// ThrottleDetectionConfig.MaxRefreshes); after that the stream fails with
+// ErrDownloadTooSlow and the download moves on to its next fallback format.
+type SlowDownloadAbortConfig struct {
+	// MinBytesPerSecond is the speed floor. Zero disables the abort.
+	MinBytesPerSecond int64
+	// Window is how long the average speed must stay below the floor.
+	// Zero uses 30s.
+	Window time.Duration
+}
+
+// MediaCookieConfig controls cookie forwarding on media download requests.
+// Session cookies live on youtube.com, so the jar never attaches them to
+// googlevideo.com stream requests; some age-gated and members-only streams
+// reject the transfer without them.
+type MediaCookieConfig struct {
+	// Enable forwards youtube.com cookies from the configured jar as a Cookie
+	// header on media requests whose host is allowlisted.
+	Enable bool
+	// Hosts is the host allowlist; an entry matches the host itself and any
+	// subdomain. If empty, "googlevideo.com" and "youtube.com" are used.
+	Hosts []string
+}
+
+// MediaURLParamsConfig selects query parameters added to each direct media
+// request of a download. Parameters already on the stream URL (pot, ctt,
+// sparams and the signed ones) are kept as they are.
+type MediaURLParamsConfig struct {
+	// CPN adds a client playback nonce, one per download.
+	CPN bool
+	// Range mirrors each bounded Range header as range=<start>-<end>.
+	Range bool
+	// RequestNumber adds rn, numbering the download's media requests from
+	// 1, and rbuf=0 (nothing buffered ahead).
+	RequestNumber bool
+}
+
+// PlaybackEmulationConfig makes a download look like a player watching the
+// video.
+type PlaybackEmulationConfig struct {
+	// Enable gives each download a stable client playback nonce (cpn),
+	// sent on all of its googlevideo requests as MediaURLParams.CPN does.
+	Enable bool
+	// StatsPings additionally reports the download to the video's playback
+	// stats URLs with that nonce: a playback ping when the transfer starts
+	// and a watchtime ping every PingInterval, advancing at real-time speed.
+	// Ping failures are ignored.
+	StatsPings bool
+	// PingInterval defaults to 40s.
+	PingInterval time.Duration
+}
+
+// InnertubeQuotaConfig bounds Innertube API request volume per client with
+// token buckets, for long-running deployments.
+type InnertubeQuotaConfig struct {
+	// RequestsPerHour is the default per-client ceiling. Zero means unlimited.
+	RequestsPerHour int
+	// PerClient overrides RequestsPerHour by client ID (e.g. "web", "ios").
+	PerClient map[string]int
+	// Burst is how many requests may go out back-to-back. Zero uses min(10, limit).
+	Burst int
+	// MaxWait fails requests with ErrQuotaExceeded rather than queueing longer.
+	// Zero queues until the request context is done.
+	MaxWait time.Duration
+}
+
+// RateLimitCooldownConfig is the client-wide cool-down curve for soft bans:
+// after Threshold consecutive 403/429 responses the client waits Initial, and
+// each further cool-down before a successful response waits Multiplier times
+// longer, up to Max. A 429 Retry-After longer than the curve is honored.
+type RateLimitCooldownConfig struct {
+	// Threshold is the number of consecutive 403/429 responses that starts a
+	// cool-down. Zero disables cool-downs.
+	Threshold int
+	// Initial is the first cool-down. Zero uses 30s.
+	Initial time.Duration
+	// Multiplier grows successive cool-downs. Values below 1 use 2.
+	Multiplier float64
+	// Max caps a single cool-down. Zero uses 10m.
+	Max time.Duration
+}
+
+// ClientCircuitBreakerConfig opens a client's circuit after Threshold
+// consecutive ClientTimeout timeouts: GetVideo skips the client for
+// Cooldown, then lets one request probe it. A probe that answers closes the
+// circuit, one that times out reopens it. When every candidate client is
+// open they are all tried anyway.
+type ClientCircuitBreakerConfig struct {
+	// Threshold is the number of consecutive timeouts that opens the
+	// circuit. Zero disables the breaker.
+	Threshold int
+	// Cooldown is how long an open circuit skips the client. Zero uses 5m.
+	Cooldown time.Duration
+}
+
+// UserAgentConfig is the per-stage User-Agent policy.
+type UserAgentConfig struct {
+	// Metadata replaces the User-Agent of every Innertube client profile on
+	// API requests and the client-config page fetched for it.
+	Metadata string
+	// MetadataByClient sets the metadata User-Agent per client ID (e.g.
+	// "ios"), taking precedence over Metadata.
+	MetadataByClient map[string]string
+	// WatchPage is used for YouTube web pages: the watch page (player URL
+	// and scraping fallback), playlist and channel pages, and captions.
+	WatchPage string
+	// PlayerJS is used for player JS fetches; it takes precedence over
+	// PlayerJSUserAgent.
+	PlayerJS string
+	// Media is used for media downloads, DASH/HLS manifests and storyboard
+	// images, taking precedence over a User-Agent in RequestHeaders.
+	Media string
 }
 
 // MetadataTransportConfig controls retry/backoff for Innertube player metadata requests.
@@ -163,11 +543,23 @@ type MetadataTransportConfig struct {
 	RetryStatusCodes []int
 }
 
+// RetryBudgetConfig limits the retries shared by the metadata, player JS
+// and download stages of one call. Zero fields are unlimited.
+type RetryBudgetConfig struct {
+	// MaxRetries is the total number of retries across stages.
+	MaxRetries int
+	// MaxDelay is the total backoff time across stages; once spent,
+	// remaining retries run without waiting.
+	MaxDelay time.Duration
+}
+
 // ToInnerTubeConfig converts package-level Config into innertube.Config.
 func (c Config) ToInnerTubeConfig() innertube.Config {
 	disableFallback := c.DisableFallbackClients
-	if !disableFallback && len(c.ClientOverrides) > 0 && !c.AppendFallbackOnClientOverrides {
+	disableWatchPage := c.DisableWatchPageFallback
+	if len(c.ClientOverrides) > 0 && !c.AppendFallbackOnClientOverrides {
 		disableFallback = true
+		disableWatchPage = true
 	}
 
 	var extractionHandler innertube.ExtractionEventHandler
@@ -189,18 +581,28 @@ func (c Config) ToInnerTubeConfig() innertube.Config {
 		PoTokenFetchPolicy:            c.PoTokenFetchPolicy,
 		VisitorData:                   c.VisitorData,
 		PlayerJSBaseURL:               c.PlayerJSBaseURL,
-		PlayerJSUserAgent:             c.PlayerJSUserAgent,
+		PlayerJSUserAgent:             firstNonEmptyString(c.UserAgents.PlayerJS, c.PlayerJSUserAgent),
+		WatchPageUserAgent:            c.UserAgents.WatchPage,
+		MetadataUserAgent:             c.UserAgents.Metadata,
+		MetadataUserAgents:            c.UserAgents.MetadataByClient,
+		APIHost:                       c.InnertubeHost,
+		APIHosts:                      c.InnertubeHosts,
 		PlayerJSHeaders:               c.PlayerJSHeaders,
 		PlayerJSPreferredLocale:       c.PlayerJSPreferredLocale,
+		MetadataLanguage:              c.MetadataLanguage,
 		ClientOverrides:               c.ClientOverrides,
 		ClientSkip:                    c.ClientSkip,
 		RequestHeaders:                c.RequestHeaders,
 		RequestTimeout:                c.RequestTimeout,
 		DisableFallbackClients:        disableFallback,
+		DisableWatchPageFallback:      disableWatchPage,
 		MetadataTransport:             innertube.MetadataTransportConfig(c.MetadataTransport),
 		EnableDynamicAPIKeyResolution: !c.DisableDynamicAPIKeyResolution,
 		UseAdPlaybackContext:          c.UseAdPlaybackContext,
 		ClientHedgeDelay:              c.ClientHedgeDelay,
+		ClientTimeout:                 c.ClientTimeout,
+		ClientCircuitBreaker:          innertube.CircuitBreakerConfig(c.ClientCircuitBreaker),
+		ClassifyProbe:                 c.ClassifyProbe,
 		OnExtractionEvent:             extractionHandler,
 	}
 }
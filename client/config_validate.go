@@ -0,0 +1,209 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/famomatic/ytv1/internal/innertube"
+)
+
+// ErrInvalidConfig matches every error returned by Config.Validate.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// ConfigError is one inconsistency found by Config.Validate. Field is the
+// Config field path (e.g. "ClientOverrides[1]"), Problem what is wrong with
+// it and Fix what to change.
+type ConfigError struct {
+	Field   string
+	Problem string
+	Fix     string
+}
+
+// Error returns "Field: Problem (Fix)".
+func (e *ConfigError) Error() string {
+	if e.Fix == "" {
+		return e.Field + ": " + e.Problem
+	}
+	return fmt.Sprintf("%s: %s (%s)", e.Field, e.Problem, e.Fix)
+}
+
+// Is reports sentinel compatibility with ErrInvalidConfig.
+func (e *ConfigError) Is(target error) bool {
+	return target == ErrInvalidConfig
+}
+
+// Validate reports settings that New would accept but that cannot work as
+// written: unknown client names, PO token policies with no provider to
+// satisfy them, negative limits, settings that another field turns off.
+// Every returned error is a *ConfigError. New logs them as warnings;
+// NewStrict refuses to build a client with any.
+func (c Config) Validate() []error {
+	var errs []error
+	add := func(field, problem, fix string) {
+		errs = append(errs, &ConfigError{Field: field, Problem: problem, Fix: fix})
+	}
+
+	if c.ProxyURL != "" {
+		if c.HTTPClient != nil {
+			add("ProxyURL", "ignored because HTTPClient is set", "configure the proxy on HTTPClient's transport instead")
+		} else if u, err := url.Parse(c.ProxyURL); err != nil || u.Scheme == "" || u.Host == "" {
+			add("ProxyURL", fmt.Sprintf("%q is not an absolute URL", c.ProxyURL), `use a form like "http://host:port" or "socks5://host:port"`)
+		}
+	}
+	if c.PlayerJSBaseURL != "" {
+		if u, err := url.Parse(c.PlayerJSBaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			add("PlayerJSBaseURL", fmt.Sprintf("%q is not an absolute URL", c.PlayerJSBaseURL), `use a form like "https://www.youtube.com"`)
+		}
+	}
+
+	protocols := make([]string, 0, len(c.PoTokenFetchPolicy))
+	for protocol := range c.PoTokenFetchPolicy {
+		protocols = append(protocols, string(protocol))
+	}
+	sort.Strings(protocols)
+	for _, name := range protocols {
+		protocol := innertube.VideoStreamingProtocol(name)
+		field := fmt.Sprintf("PoTokenFetchPolicy[%s]", name)
+		switch protocol {
+		case innertube.StreamingProtocolHTTPS, innertube.StreamingProtocolDASH, innertube.StreamingProtocolHLS:
+		default:
+			add(field, fmt.Sprintf("unknown streaming protocol %q", name), "use https, dash or hls")
+		}
+		policy := c.PoTokenFetchPolicy[protocol]
+		switch innertube.PoTokenFetchPolicy(strings.ToLower(strings.TrimSpace(string(policy)))) {
+		case innertube.PoTokenFetchPolicyRequired:
+			if c.PoTokenProvider == nil {
+				add(field, "required, but no PoTokenProvider is set, so every "+name+" format is dropped", "set PoTokenProvider or relax the policy to recommended")
+			}
+		case innertube.PoTokenFetchPolicyRecommended, innertube.PoTokenFetchPolicyNever:
+		default:
+			add(field, fmt.Sprintf("unknown policy %q is treated as recommended", policy), "use required, recommended or never")
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, name := range InnertubeClientNames() {
+		known[name] = true
+	}
+	checkClients := func(field string, names []string) {
+		for i, name := range names {
+			normalized := strings.ToLower(strings.TrimSpace(name))
+			if normalized != "" && !known[normalized] {
+				add(fmt.Sprintf("%s[%d]", field, i), fmt.Sprintf("unknown Innertube client %q", name), "use one of "+strings.Join(InnertubeClientNames(), ", "))
+			}
+		}
+	}
+	checkClients("ClientOverrides", c.ClientOverrides)
+	checkClients("ClientSkip", c.ClientSkip)
+	checkClients("DownloadRetryClients", c.DownloadRetryClients)
+	checkClients("InnertubeQuota.PerClient", sortedKeys(c.InnertubeQuota.PerClient))
+	checkClients("UserAgents.MetadataByClient", sortedKeys(c.UserAgents.MetadataByClient))
+	checkClients("InnertubeHosts", sortedKeys(c.InnertubeHosts))
+	if c.InnertubeHost != "" && !validInnertubeHost(c.InnertubeHost) {
+		add("InnertubeHost", fmt.Sprintf("%q is neither a host nor a base URL", c.InnertubeHost), `use a form like "youtubei.googleapis.com" or "http://proxy:8080"`)
+	}
+	for _, id := range sortedKeys(c.InnertubeHosts) {
+		if host := c.InnertubeHosts[id]; !validInnertubeHost(host) {
+			add("InnertubeHosts["+id+"]", fmt.Sprintf("%q is neither a host nor a base URL", host), `use a form like "youtubei.googleapis.com" or "http://proxy:8080"`)
+		}
+	}
+	if len(c.ClientOverrides) > 0 {
+		skipped := make(map[string]bool, len(c.ClientSkip))
+		for _, name := range c.ClientSkip {
+			skipped[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+		usable := false
+		for _, name := range c.ClientOverrides {
+			normalized := strings.ToLower(strings.TrimSpace(name))
+			if known[normalized] && !skipped[normalized] {
+				usable = true
+				break
+			}
+		}
+		if !usable {
+			add("ClientOverrides", "no listed client is known and not in ClientSkip, so the default clients are used", "list at least one usable client")
+		}
+	}
+
+	if c.MediaCookies.Enable && c.CookieJar == nil && (c.HTTPClient == nil || c.HTTPClient.Jar == nil) {
+		add("MediaCookies.Enable", "no cookie jar to forward cookies from", "set CookieJar")
+	}
+	if c.EmulatePlayback.StatsPings && !c.EmulatePlayback.Enable {
+		add("EmulatePlayback.StatsPings", "has no effect without EmulatePlayback.Enable", "set EmulatePlayback.Enable")
+	}
+	if c.CaptureRedactVideoID && c.CaptureDir == "" {
+		add("CaptureRedactVideoID", "has no effect without CaptureDir", "set CaptureDir")
+	}
+
+	for _, d := range []struct {
+		field string
+		value int64
+	}{
+		{"RequestTimeout", int64(c.RequestTimeout)},
+		{"ClientHedgeDelay", int64(c.ClientHedgeDelay)},
+		{"ClientTimeout", int64(c.ClientTimeout)},
+		{"ClientCircuitBreaker.Threshold", int64(c.ClientCircuitBreaker.Threshold)},
+		{"SessionCacheTTL", int64(c.SessionCacheTTL)},
+		{"SessionCacheMaxBytes", c.SessionCacheMaxBytes},
+		{"PlaylistCacheTTL", int64(c.PlaylistCacheTTL)},
+		{"MaxTotalBytes", c.MaxTotalBytes},
+		{"MetadataTransport.MaxRetries", int64(c.MetadataTransport.MaxRetries)},
+		{"DownloadTransport.MaxRetries", int64(c.DownloadTransport.MaxRetries)},
+		{"DownloadTransport.ChunkSize", c.DownloadTransport.ChunkSize},
+		{"DownloadTransport.MaxConcurrency", int64(c.DownloadTransport.MaxConcurrency)},
+		{"SlowDownloadAbort.MinBytesPerSecond", c.SlowDownloadAbort.MinBytesPerSecond},
+		{"InnertubeQuota.RequestsPerHour", int64(c.InnertubeQuota.RequestsPerHour)},
+		{"RateLimitCooldown.Threshold", int64(c.RateLimitCooldown.Threshold)},
+		{"EmulatePlayback.PingInterval", int64(c.EmulatePlayback.PingInterval)},
+	} {
+		if d.value < 0 {
+			add(d.field, "must not be negative", "use 0 for the default")
+		}
+	}
+	if c.ClientCircuitBreaker.Threshold > 0 && c.ClientTimeout <= 0 {
+		add("ClientCircuitBreaker.Threshold", "has no effect without ClientTimeout, as only client timeouts trip the breaker", "set ClientTimeout")
+	}
+	if c.MetadataTransport.MaxBackoff > 0 && c.MetadataTransport.InitialBackoff > c.MetadataTransport.MaxBackoff {
+		add("MetadataTransport.InitialBackoff", "exceeds MaxBackoff", "raise MaxBackoff or lower InitialBackoff")
+	}
+	if c.DownloadTransport.MaxBackoff > 0 && c.DownloadTransport.InitialBackoff > c.DownloadTransport.MaxBackoff {
+		add("DownloadTransport.InitialBackoff", "exceeds MaxBackoff", "raise MaxBackoff or lower InitialBackoff")
+	}
+	return errs
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// NewStrict is New for callers that want configuration mistakes to fail
+// loudly: it returns the Config.Validate errors, joined, instead of a client
+// when there are any.
+func NewStrict(config Config) (*Client, error) {
+	if errs := config.Validate(); len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return New(config), nil
+}
+
+// validInnertubeHost reports whether s is a bare host[:port] or an
+// http(s) URL with nothing after its host.
+func validInnertubeHost(s string) bool {
+	s = strings.TrimSpace(s)
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	return (u.Path == "" || u.Path == "/") && u.RawQuery == "" && u.Fragment == "" && u.User == nil
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/famomatic/ytv1/internal/innertube"
+)
+
+func TestConfigValidate_ReportsActionableErrors(t *testing.T) {
+	cfg := Config{
+		HTTPClient: &http.Client{},
+		ProxyURL:   "http://proxy:8080",
+		PoTokenFetchPolicy: map[innertube.VideoStreamingProtocol]innertube.PoTokenFetchPolicy{
+			innertube.StreamingProtocolHTTPS: innertube.PoTokenFetchPolicyRequired,
+			innertube.StreamingProtocolHLS:   "always",
+		},
+		ClientOverrides: []string{"web", "iphone"},
+		ClientSkip:      []string{"web"},
+		MediaCookies:    MediaCookieConfig{Enable: true},
+		MaxTotalBytes:   -1,
+		EmulatePlayback: PlaybackEmulationConfig{StatsPings: true},
+		InnertubeHosts:  map[string]string{"ios": "https://proxy/youtubei"},
+	}
+	errs := cfg.Validate()
+	got := make(map[string]*ConfigError)
+	for _, err := range errs {
+		var cfgErr *ConfigError
+		if !errors.As(err, &cfgErr) || !errors.Is(err, ErrInvalidConfig) {
+			t.Fatalf("error %v is not a *ConfigError", err)
+		}
+		got[cfgErr.Field] = cfgErr
+	}
+	for _, field := range []string{
+		"ProxyURL",
+		"PoTokenFetchPolicy[https]",
+		"PoTokenFetchPolicy[hls]",
+		"ClientOverrides[1]",
+		"ClientOverrides",
+		"MediaCookies.Enable",
+		"MaxTotalBytes",
+		"EmulatePlayback.StatsPings",
+		"InnertubeHosts[ios]",
+	} {
+		if got[field] == nil {
+			t.Errorf("no error for %s in %v", field, errs)
+		}
+	}
+	if len(got) != 9 {
+		t.Errorf("got %d errors, want 9: %v", len(errs), errs)
+	}
+	if e := got["PoTokenFetchPolicy[https]"]; e != nil && !strings.Contains(e.Error(), "set PoTokenProvider") {
+		t.Errorf("POT error lacks a fix: %v", e)
+	}
+	if e := got["ClientOverrides[1]"]; e != nil && !strings.Contains(e.Error(), `"iphone"`) {
+		t.Errorf("unknown client error = %v", e)
+	}
+}
+
+func TestConfigValidate_ZeroConfigIsClean(t *testing.T) {
+	if errs := (Config{}).Validate(); len(errs) != 0 {
+		t.Fatalf("Validate() = %v, want none", errs)
+	}
+}
+
+func TestNewStrict_RefusesInvalidConfig(t *testing.T) {
+	if _, err := NewStrict(Config{ClientSkip: []string{"bogus"}}); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("NewStrict() error = %v, want ErrInvalidConfig", err)
+	}
+	c, err := NewStrict(Config{ClientSkip: []string{"ios"}})
+	if err != nil || c == nil {
+		t.Fatalf("NewStrict() = %v, %v", c, err)
+	}
+
+	logger := &testLogger{}
+	New(Config{ClientSkip: []string{"bogus"}, Logger: logger})
+	if len(logger.warnings) != 1 || !strings.Contains(logger.warnings[0], `ClientSkip[0]: unknown Innertube client "bogus"`) {
+		t.Fatalf("warnings = %v", logger.warnings)
+	}
+}
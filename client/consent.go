@@ -0,0 +1,91 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+)
+
+// consentCookies decline the cookie wall: SOCS is the current consent
+// cookie, CONSENT the legacy one some frontends still check.
+const consentCookies = "SOCS=CAI; CONSENT=YES+cb"
+
+// consentTransport answers YouTube's cookie-consent interstitial. EU
+// requests for watch, channel and playlist pages may be redirected to
+// consent.youtube.com instead of getting the page; such a request is retried
+// once with the consent cookies, and every later youtube.com request carries
+// them up front.
+type consentTransport struct {
+	base      http.RoundTripper
+	consented atomic.Bool
+}
+
+func (t *consentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isYouTubePageRequest(req) {
+		return t.base.RoundTrip(req)
+	}
+	if t.consented.Load() {
+		return t.base.RoundTrip(withConsentCookies(req))
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !isConsentRedirect(resp) {
+		return resp, err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	t.consented.Store(true)
+	return t.base.RoundTrip(withConsentCookies(req))
+}
+
+// withConsentCookies returns a copy of req with the consent cookies added.
+func withConsentCookies(req *http.Request) *http.Request {
+	// A RoundTripper must not modify its request.
+	req = req.Clone(req.Context())
+	if existing := req.Header.Get("Cookie"); existing != "" {
+		req.Header.Set("Cookie", existing+"; "+consentCookies)
+	} else {
+		req.Header.Set("Cookie", consentCookies)
+	}
+	return req
+}
+
+// isYouTubePageRequest reports whether req is a GET of a youtube.com page;
+// Innertube API calls never get the interstitial.
+func isYouTubePageRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet || isInnertubeAPIRequest(req) {
+		return false
+	}
+	host := strings.ToLower(req.URL.Hostname())
+	return host == "youtube.com" || strings.HasSuffix(host, ".youtube.com")
+}
+
+// isConsentRedirect reports whether resp sends the client to a consent
+// interstitial (consent.youtube.com or consent.google.com).
+func isConsentRedirect(resp *http.Response) bool {
+	if resp.StatusCode < 300 || resp.StatusCode > 399 {
+		return false
+	}
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(loc.Hostname())
+	return strings.HasPrefix(host, "consent.youtube.") || strings.HasPrefix(host, "consent.google.")
+}
+
+// withConsentTransport returns a copy of hc that handles consent
+// interstitials, or hc itself when Config.DisableConsentBypass is set.
+func withConsentTransport(hc *http.Client, config Config) *http.Client {
+	if config.DisableConsentBypass {
+		return hc
+	}
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *hc
+	wrapped.Transport = &consentTransport{base: base}
+	return &wrapped
+}
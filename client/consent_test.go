@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func consentWallTransport(requests *[]string) roundTripFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		*requests = append(*requests, r.URL.Host+r.URL.Path+" cookie="+r.Header.Get("Cookie"))
+		if r.URL.Host == "consent.youtube.com" {
+			body := `<html><form action="https://consent.youtube.com/save"></form></html>`
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+		}
+		if !strings.Contains(r.Header.Get("Cookie"), "SOCS=") {
+			header := make(http.Header)
+			header.Set("Location", "https://consent.youtube.com/m?continue="+r.URL.String())
+			return &http.Response{StatusCode: http.StatusFound, Body: io.NopCloser(strings.NewReader("")), Header: header, Request: r}, nil
+		}
+		body := `<html><script>var ytInitialData = {"metadata":{"channelMetadataRenderer":{"externalId":"UCabcdefghijklmnopqrstuv"}}};</script></html>`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	}
+}
+
+func TestConsentTransport_RetriesWithConsentCookies(t *testing.T) {
+	var requests []string
+	c := New(Config{HTTPClient: &http.Client{Transport: consentWallTransport(&requests)}})
+
+	id, err := c.ResolveChannelID(context.Background(), "@example")
+	if err != nil {
+		t.Fatalf("ResolveChannelID() error = %v", err)
+	}
+	if id != "UCabcdefghijklmnopqrstuv" || len(requests) != 2 {
+		t.Fatalf("id = %q, requests = %v", id, requests)
+	}
+
+	// Once consent was needed, later page requests send the cookies up front.
+	requests = nil
+	if _, err := c.ResolveChannelID(context.Background(), "@other"); err != nil {
+		t.Fatalf("second ResolveChannelID() error = %v", err)
+	}
+	if len(requests) != 1 || !strings.Contains(requests[0], "SOCS=CAI") {
+		t.Fatalf("requests = %v, want one request carrying SOCS", requests)
+	}
+}
+
+func TestConsentTransport_OptOut(t *testing.T) {
+	var requests []string
+	c := New(Config{
+		HTTPClient:           &http.Client{Transport: consentWallTransport(&requests)},
+		DisableConsentBypass: true,
+	})
+	if _, err := c.ResolveChannelID(context.Background(), "@example"); err == nil {
+		t.Fatal("expected the consent page to break channel resolution")
+	}
+	for _, r := range requests {
+		if strings.Contains(r, "SOCS") {
+			t.Fatalf("consent cookies sent with DisableConsentBypass: %v", requests)
+		}
+	}
+}
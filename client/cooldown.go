@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitState is the client-wide cool-down state reported through
+// Config.OnRateLimitState and Client.RateLimitState.
+type RateLimitState struct {
+	// CoolingDown is set while new extractions are paused, until Until.
+	CoolingDown bool
+	Until       time.Time
+	// Level counts cool-downs entered since the last successful response.
+	Level int
+	// Consecutive is the current run of 403/429 responses.
+	Consecutive int
+	// LastStatus is the HTTP status that last counted toward a cool-down.
+	LastStatus int
+}
+
+// rateLimitCooldown tracks consecutive 403/429 responses for
+// RateLimitCooldownConfig. A nil *rateLimitCooldown never cools down.
+type rateLimitCooldown struct {
+	cfg      RateLimitCooldownConfig
+	onChange func(RateLimitState)
+	now      func() time.Time
+
+	mu    sync.Mutex
+	state RateLimitState
+}
+
+func newRateLimitCooldown(cfg RateLimitCooldownConfig, onChange func(RateLimitState)) *rateLimitCooldown {
+	if cfg.Threshold <= 0 {
+		return nil
+	}
+	if cfg.Initial <= 0 {
+		cfg.Initial = 30 * time.Second
+	}
+	if cfg.Multiplier < 1 {
+		cfg.Multiplier = 2
+	}
+	if cfg.Max <= 0 {
+		cfg.Max = 10 * time.Minute
+	}
+	return &rateLimitCooldown{cfg: cfg, onChange: onChange, now: time.Now}
+}
+
+// observe updates the state from one HTTP response.
+func (r *rateLimitCooldown) observe(resp *http.Response) {
+	if r == nil {
+		return
+	}
+	var notify *RateLimitState
+	r.mu.Lock()
+	now := r.now()
+	switch {
+	case now.Before(r.state.Until):
+		// Responses to requests sent before the cool-down neither extend it
+		// nor count toward the next one.
+	case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+		r.state.Consecutive++
+		r.state.LastStatus = resp.StatusCode
+		if r.state.Consecutive >= r.cfg.Threshold {
+			r.state.Level++
+			r.state.Consecutive = 0
+			r.state.CoolingDown = true
+			r.state.Until = now.Add(r.delay(r.state.Level, resp))
+			state := r.state
+			notify = &state
+		}
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		r.state.Consecutive = 0
+		if r.state.Level > 0 {
+			r.state = RateLimitState{}
+			state := r.state
+			notify = &state
+		}
+	}
+	r.mu.Unlock()
+	if notify != nil && r.onChange != nil {
+		r.onChange(*notify)
+	}
+}
+
+func (r *rateLimitCooldown) delay(level int, resp *http.Response) time.Duration {
+	d := r.cfg.Initial
+	for i := 1; i < level && d < r.cfg.Max; i++ {
+		d = time.Duration(float64(d) * r.cfg.Multiplier)
+	}
+	if d > r.cfg.Max {
+		d = r.cfg.Max
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && time.Duration(secs)*time.Second > d {
+		d = time.Duration(secs) * time.Second
+	}
+	return d
+}
+
+// wait blocks while a cool-down is active, returning ctx.Err() if ctx ends
+// first.
+func (r *rateLimitCooldown) wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+	remaining := r.snapshot().Until.Sub(r.now())
+	if remaining <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(remaining)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *rateLimitCooldown) snapshot() RateLimitState {
+	if r == nil {
+		return RateLimitState{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state := r.state
+	state.CoolingDown = r.now().Before(state.Until)
+	return state
+}
+
+// cooldownTransport feeds every response of the client's HTTP traffic to a
+// rateLimitCooldown.
+type cooldownTransport struct {
+	base     http.RoundTripper
+	cooldown *rateLimitCooldown
+}
+
+func (t *cooldownTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.cooldown.observe(resp)
+	}
+	return resp, err
+}
+
+// withCooldownTransport returns a copy of hc whose responses feed cooldown.
+func withCooldownTransport(hc *http.Client, cooldown *rateLimitCooldown) *http.Client {
+	if cooldown == nil {
+		return hc
+	}
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *hc
+	wrapped.Transport = &cooldownTransport{base: base, cooldown: cooldown}
+	return &wrapped
+}
+
+// RateLimitState reports the client-wide cool-down state. It is the zero
+// value when Config.RateLimitCooldown is disabled.
+func (c *Client) RateLimitState() RateLimitState {
+	return c.cooldown.snapshot()
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func cooldownResponse(status int, retryAfter string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: make(http.Header)}
+	if retryAfter != "" {
+		resp.Header.Set("Retry-After", retryAfter)
+	}
+	return resp
+}
+
+func TestRateLimitCooldown_Curve(t *testing.T) {
+	var states []RateLimitState
+	r := newRateLimitCooldown(RateLimitCooldownConfig{Threshold: 2, Initial: time.Minute, Multiplier: 3, Max: 5 * time.Minute}, func(s RateLimitState) {
+		states = append(states, s)
+	})
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	r.observe(cooldownResponse(http.StatusForbidden, ""))
+	r.observe(cooldownResponse(http.StatusOK, ""))
+	r.observe(cooldownResponse(http.StatusTooManyRequests, ""))
+	if len(states) != 0 || r.snapshot().CoolingDown {
+		t.Fatalf("success did not reset the run: %+v", states)
+	}
+	r.observe(cooldownResponse(http.StatusTooManyRequests, ""))
+	if len(states) != 1 || !states[0].CoolingDown || !states[0].Until.Equal(now.Add(time.Minute)) || states[0].LastStatus != http.StatusTooManyRequests {
+		t.Fatalf("first cool-down = %+v", states)
+	}
+
+	// Responses of requests already in flight do not extend the cool-down.
+	r.observe(cooldownResponse(http.StatusForbidden, ""))
+	r.observe(cooldownResponse(http.StatusForbidden, ""))
+	if len(states) != 1 {
+		t.Fatalf("cool-down re-entered while active: %+v", states)
+	}
+
+	now = now.Add(time.Minute)
+	r.observe(cooldownResponse(http.StatusForbidden, ""))
+	r.observe(cooldownResponse(http.StatusForbidden, ""))
+	if len(states) != 2 || states[1].Level != 2 || !states[1].Until.Equal(now.Add(3*time.Minute)) {
+		t.Fatalf("second cool-down = %+v", states[len(states)-1])
+	}
+	now = now.Add(3 * time.Minute)
+	r.observe(cooldownResponse(http.StatusForbidden, ""))
+	r.observe(cooldownResponse(http.StatusTooManyRequests, "900"))
+	if got := states[len(states)-1].Until.Sub(now); got != 15*time.Minute {
+		t.Fatalf("third cool-down = %v, want Retry-After 15m over the 5m cap", got)
+	}
+
+	now = now.Add(15 * time.Minute)
+	r.observe(cooldownResponse(http.StatusOK, ""))
+	if last := states[len(states)-1]; last.CoolingDown || last.Level != 0 {
+		t.Fatalf("recovery state = %+v", last)
+	}
+}
+
+func TestGetVideo_WaitsForCooldown(t *testing.T) {
+	var states []RateLimitState
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(""))}, nil
+	})}
+	c := New(Config{
+		HTTPClient:        httpClient,
+		ClientOverrides:   []string{"web"},
+		RateLimitCooldown: RateLimitCooldownConfig{Threshold: 1, Initial: time.Hour},
+		OnRateLimitState:  func(s RateLimitState) { states = append(states, s) },
+		MetadataTransport: MetadataTransportConfig{MaxRetries: 0},
+	})
+
+	if _, err := c.GetVideo(context.Background(), "jNQXAC9IVRw"); err == nil {
+		t.Fatalf("GetVideo() succeeded against 429s")
+	}
+	if len(states) == 0 || !c.RateLimitState().CoolingDown {
+		t.Fatalf("no cool-down after 429: states=%+v", states)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetVideo(ctx, "jNQXAC9IVRw"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetVideo() during cool-down error = %v, want deadline exceeded", err)
+	}
+	if New(Config{HTTPClient: httpClient}).RateLimitState().CoolingDown {
+		t.Fatalf("cool-down state without RateLimitCooldown")
+	}
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// DebugReport is a per-video snapshot of how extraction and format selection
+// went, for attaching to bug reports. Stream URLs themselves are not included;
+// FinalURLs only records which parameters they carry.
+type DebugReport struct {
+	VideoID string `json:"video_id"`
+	// Clients lists the Innertube clients attempted in order; on success the
+	// last one is ChosenClient, whose response was used.
+	Clients      []DebugClientAttempt `json:"clients"`
+	ChosenClient string               `json:"chosen_client,omitempty"`
+	// PlayerURL and PlayerVersion identify the player script used for
+	// challenges; both are empty when no challenge required it.
+	PlayerURL          string `json:"player_url,omitempty"`
+	PlayerVersion      string `json:"player_version,omitempty"`
+	SignatureTimestamp int    `json:"signature_timestamp,omitempty"`
+	// Challenges counts the distinct n and signature challenges in the
+	// response and how many of them have a cached solution.
+	Challenges DebugChallenges `json:"challenges"`
+	// FormatsBeforePOFilter and FormatsAfterPOFilter are itags before and after
+	// dropping formats the PO token policy cannot play; POFilterSkips says why.
+	FormatsBeforePOFilter []int             `json:"formats_before_po_filter"`
+	FormatsAfterPOFilter  []int             `json:"formats_after_po_filter"`
+	POFilterSkips         []DebugFormatSkip `json:"po_filter_skips,omitempty"`
+	// Selector is the expression Download evaluated and SelectorTrace the
+	// fallback groups tried; both are empty for an explicit itag.
+	Selector      string               `json:"selector,omitempty"`
+	SelectorTrace []SelectorGroupTrace `json:"selector_trace,omitempty"`
+	Selected      []int                `json:"selected,omitempty"`
+	FinalURLs     []DebugURL           `json:"final_urls,omitempty"`
+	// Error is the first failure that cut the report short.
+	Error string `json:"error,omitempty"`
+}
+
+// DebugClientAttempt is one Innertube client tried for the player response.
+type DebugClientAttempt struct {
+	Client string `json:"client"`
+	Error  string `json:"error,omitempty"`
+}
+
+// DebugChallenges counts n and signature challenges.
+type DebugChallenges struct {
+	N         int `json:"n"`
+	NSolved   int `json:"n_solved"`
+	Sig       int `json:"sig"`
+	SigSolved int `json:"sig_solved"`
+}
+
+// DebugFormatSkip is a format dropped by the PO token policy.
+type DebugFormatSkip struct {
+	Itag     int    `json:"itag"`
+	Protocol string `json:"protocol"`
+	Reason   string `json:"reason"`
+}
+
+// DebugURL records which query parameters a resolved stream URL carries.
+type DebugURL struct {
+	Itag         int    `json:"itag"`
+	Host         string `json:"host,omitempty"`
+	HasN         bool   `json:"has_n"`
+	HasPOT       bool   `json:"has_pot"`
+	HasSignature bool   `json:"has_signature"`
+	Error        string `json:"error,omitempty"`
+}
+
+// DebugReport runs extraction (reusing a cached GetVideo result) and the
+// selection Download would make for options, resolving the selected stream
+// URLs without downloading them. It never fails: errors end the report early
+// and are recorded in Error, with the client attempts of extraction failures.
+func (c *Client) DebugReport(ctx context.Context, input string, options DownloadOptions) *DebugReport {
+	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+
+	r := &DebugReport{VideoID: strings.TrimSpace(input)}
+	videoID, err := c.resolveVideoInput(ctx, input)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	r.VideoID = videoID
+
+	if _, ok := c.getSession(videoID); !ok {
+		if _, err := c.GetVideo(ctx, videoID); err != nil {
+			r.Error = err.Error()
+			if attempts, ok := AttemptDetails(err); ok {
+				for _, a := range attempts {
+					r.Clients = append(r.Clients, DebugClientAttempt{Client: a.Client, Error: a.Reason})
+				}
+			}
+			return r
+		}
+	}
+	session, ok := c.getSession(videoID)
+	if !ok || session.Info == nil {
+		r.Error = ErrNoPlayableFormats.Error()
+		return r
+	}
+
+	for _, f := range session.Info.Formats {
+		r.FormatsBeforePOFilter = append(r.FormatsBeforePOFilter, f.Itag)
+	}
+	kept, skips := filterFormatsByPoTokenPolicy(session.Info.Formats, c.config)
+	for _, f := range kept {
+		r.FormatsAfterPOFilter = append(r.FormatsAfterPOFilter, f.Itag)
+	}
+	for _, s := range skips {
+		r.POFilterSkips = append(r.POFilterSkips, DebugFormatSkip{Itag: s.Itag, Protocol: s.Protocol, Reason: s.Reason})
+	}
+
+	_, candidates, selected, selErr := c.selectDownloadFormats(ctx, videoID, options)
+	if selErr != nil {
+		candidates = kept
+	}
+	r.Selector, r.SelectorTrace = explainDownloadSelector(candidates, options)
+	if selErr != nil {
+		r.Error = selErr.Error()
+	}
+	for _, f := range selected {
+		r.Selected = append(r.Selected, f.Itag)
+		u := DebugURL{Itag: f.Itag}
+		resolved, err := c.resolveSelectedFormatURL(ctx, videoID, f)
+		if err != nil {
+			u.Error = err.Error()
+		} else if parsed, err := url.Parse(resolved); err == nil {
+			q := parsed.Query()
+			u.Host = parsed.Host
+			u.HasN = q.Get("n") != ""
+			u.HasPOT = q.Get("pot") != "" || strings.Contains(parsed.Path, "/pot/")
+			u.HasSignature = q.Get("sig") != "" || q.Get("signature") != "" || q.Get("lsig") != ""
+		}
+		r.FinalURLs = append(r.FinalURLs, u)
+	}
+
+	// URL resolution may have fetched the player, so read the session last.
+	if latest, ok := c.getSession(videoID); ok {
+		session = latest
+	}
+	c.fillDebugResponse(r, session)
+	return r
+}
+
+// fillDebugResponse records the client attempts, player and challenge state
+// of a YouTube session. Extractor sessions have no response to report.
+func (c *Client) fillDebugResponse(r *DebugReport, session videoSession) {
+	resp := session.Response
+	if resp == nil {
+		return
+	}
+	for _, a := range resp.FailedAttempts {
+		r.Clients = append(r.Clients, DebugClientAttempt{Client: a.Client, Error: a.Error})
+	}
+	r.ChosenClient = resp.SourceClient
+	r.Clients = append(r.Clients, DebugClientAttempt{Client: resp.SourceClient})
+	r.PlayerURL = session.PlayerURL
+	r.PlayerVersion = playerIDFromURL(session.PlayerURL)
+	r.SignatureTimestamp = resp.SignatureTimestamp
+
+	nChallenges, sigChallenges := collectStreamChallenges(resp, resp.StreamingData.DashManifestURL, resp.StreamingData.HlsManifestURL)
+	r.Challenges.N = len(nChallenges)
+	r.Challenges.Sig = len(sigChallenges)
+	if session.PlayerURL == "" {
+		return
+	}
+	for ch := range nChallenges {
+		if _, ok := c.getChallengeN(session.PlayerURL, ch); ok {
+			r.Challenges.NSolved++
+		}
+	}
+	for ch := range sigChallenges {
+		if _, ok := c.getChallengeSig(session.PlayerURL, ch); ok {
+			r.Challenges.SigSolved++
+		}
+	}
+}
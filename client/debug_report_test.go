@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDebugReport_RecordsSelectionAndURLs(t *testing.T) {
+	c := newMockClientForPlayerJSON(t, `{
+		"playabilityStatus":{"status":"OK"},
+		"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo"},
+		"streamingData":{
+			"formats":[{"itag":18,"url":"https://rr1.googlevideo.com/videoplayback?itag=18","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","bitrate":1000,"width":640,"height":360}],
+			"adaptiveFormats":[{"itag":140,"url":"https://rr2.googlevideo.com/videoplayback?itag=140&sig=abc","mimeType":"audio/mp4; codecs=\"mp4a\"","bitrate":128000}]
+		}
+	}`)
+
+	r := c.DebugReport(context.Background(), "https://www.youtube.com/watch?v=jNQXAC9IVRw", DownloadOptions{Mode: SelectionModeAudioOnly})
+	if r.Error != "" {
+		t.Fatalf("Error = %q", r.Error)
+	}
+	if r.VideoID != "jNQXAC9IVRw" || r.ChosenClient != "mweb" {
+		t.Fatalf("VideoID=%q ChosenClient=%q", r.VideoID, r.ChosenClient)
+	}
+	if len(r.Clients) != 1 || r.Clients[0].Client != "mweb" || r.Clients[0].Error != "" {
+		t.Fatalf("Clients = %+v", r.Clients)
+	}
+	if len(r.FormatsBeforePOFilter) != 2 || len(r.FormatsAfterPOFilter) != 2 {
+		t.Fatalf("formats before=%v after=%v", r.FormatsBeforePOFilter, r.FormatsAfterPOFilter)
+	}
+	if r.Selector != "bestaudio" || len(r.SelectorTrace) != 1 || !r.SelectorTrace[0].Matched {
+		t.Fatalf("Selector=%q trace=%+v", r.Selector, r.SelectorTrace)
+	}
+	if len(r.Selected) != 1 || r.Selected[0] != 140 {
+		t.Fatalf("Selected = %v, want [140]", r.Selected)
+	}
+	if len(r.FinalURLs) != 1 {
+		t.Fatalf("FinalURLs = %+v", r.FinalURLs)
+	}
+	u := r.FinalURLs[0]
+	if u.Itag != 140 || u.Host != "rr2.googlevideo.com" || !u.HasSignature || u.HasN || u.HasPOT || u.Error != "" {
+		t.Fatalf("FinalURLs[0] = %+v", u)
+	}
+}
+
+func TestDebugReport_RecordsExtractionFailure(t *testing.T) {
+	c := newMockClientForPlayerJSON(t, `{"playabilityStatus":{"status":"ERROR","reason":"Video unavailable"}}`)
+
+	r := c.DebugReport(context.Background(), "jNQXAC9IVRw", DownloadOptions{})
+	if r.Error == "" || r.VideoID != "jNQXAC9IVRw" {
+		t.Fatalf("report = %+v, want extraction error", r)
+	}
+	if len(r.Clients) == 0 || r.Clients[0].Client == "" || r.Clients[0].Error == "" {
+		t.Fatalf("Clients = %+v, want failed attempts", r.Clients)
+	}
+	if len(r.Selected) != 0 || len(r.FinalURLs) != 0 {
+		t.Fatalf("report = %+v, want no selection", r)
+	}
+}
+
+func TestExplainSelection_TracesFallback(t *testing.T) {
+	c := newMockClientForPlayerJSON(t, `{
+		"playabilityStatus":{"status":"OK"},
+		"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo"},
+		"streamingData":{"formats":[{"itag":18,"url":"https://rr1.googlevideo.com/videoplayback?itag=18","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","bitrate":1000,"width":640,"height":360}]}
+	}`)
+
+	expr, trace, err := c.ExplainSelection(context.Background(), "jNQXAC9IVRw", DownloadOptions{})
+	if err != nil {
+		t.Fatalf("ExplainSelection() error = %v", err)
+	}
+	if expr != "bestvideo+bestaudio/best" || len(trace) != 2 {
+		t.Fatalf("expr=%q trace=%+v", expr, trace)
+	}
+	if trace[0].Matched || !trace[1].Matched || trace[1].Specs[0].Picked[0] != 18 {
+		t.Fatalf("trace = %+v", trace)
+	}
+	if r := trace[0].Specs[0].Rejected; len(r) != 1 || r[0].Reason != "fails bestvideo" {
+		t.Fatalf("bestvideo rejected = %+v", r)
+	}
+
+	_, trace, err = c.ExplainSelection(context.Background(), "jNQXAC9IVRw", DownloadOptions{FormatSelector: "bestaudio"})
+	if err == nil || len(trace) != 1 || trace[0].Matched {
+		t.Fatalf("err=%v trace=%+v, want unmatched trace with error", err, trace)
+	}
+}
@@ -13,9 +13,11 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/famomatic/ytv1/internal/downloader"
+	"github.com/famomatic/ytv1/internal/httpx"
 	"github.com/famomatic/ytv1/internal/selector"
 	"github.com/famomatic/ytv1/internal/types"
 )
@@ -29,60 +31,342 @@ type DownloadOptions struct {
 	Resume                bool
 	MergeOutput           bool
 	KeepIntermediateFiles bool
+	// CheckFormats probes selected stream URLs before committing to them and
+	// re-runs selection without candidates that fail (e.g. 403 from missing POT).
+	CheckFormats bool
+	// MaxFileSize skips formats whose known size exceeds it and aborts a stream
+	// transfer once its output grows past it, counting bytes resumed from a
+	// partial file but not bytes fetched again after a retry. Zero means no
+	// limit.
+	MaxFileSize int64
+	// MaxHeight caps video height (e.g. 1080). It is added to the selector as
+	// [height<=N] and also drops taller candidates, so explicit itags obey it.
+	MaxHeight int
+	// Simulate runs extraction, selection and output-path templating, then
+	// returns the planned result without resolving stream URLs or touching
+	// the filesystem.
+	Simulate bool
+	// ExtractAudio rewrites the downloaded file as audio-only with metadata
+	// tags: AudioFormatOpus, AudioFormatM4A, or AudioFormatBest to keep the
+	// source codec. It needs a Muxer implementing AudioExtractor; without one
+	// the original file is kept and FallbackReason is "muxer_unavailable".
+	ExtractAudio string
+	// Trailer makes Download and ResolveDownloadURLs use
+	// VideoInfo.TrailerVideoID instead of failing with UpcomingVideoError
+	// when the video has not started yet. DownloadResult.VideoID is then the
+	// trailer's.
+	Trailer bool
+	// LiveOffset starts the capture of a live HLS stream this far behind the
+	// live edge, within the stream's DVR window, instead of at the oldest
+	// segment still listed. Zero keeps that default; DASH ignores it.
+	LiveOffset time.Duration
+	// ValidateOutput probes the finished file (after merging and audio
+	// extraction) with a Muxer implementing OutputProber, and fails with an
+	// OutputValidationError when it lacks a selected stream or video frames,
+	// or when its duration differs from VideoInfo.DurationSec by more than
+	// OutputDurationTolerance. Live streams skip the duration check. Without
+	// a prober the check is skipped with a warning.
+	ValidateOutput bool
+	// OutputDurationTolerance is the allowed duration difference for
+	// ValidateOutput. Zero uses 2s.
+	OutputDurationTolerance time.Duration
+	// WriteChecksum computes the SHA-256 of the finished file into
+	// DownloadResult.SHA256 and writes it next to the file as
+	// <output>.sha256 in sha256sum format. Sequentially written outputs are
+	// hashed as they are written; merged, resumed, chunked and externally
+	// downloaded ones are read once more.
+	WriteChecksum bool
+	// SetMtime sets the finished file's modification time to the video's
+	// publish date (upload date when unknown), so file browsers sort
+	// downloads chronologically. Failing to set it only logs a warning.
+	SetMtime bool
+	// Sink uploads the finished file, and its WriteChecksum sidecar, to
+	// remote storage (see Client.OpenOutputSink) instead of keeping it;
+	// OutputPath is then the key template below the sink root. A single
+	// direct stream without ExtractAudio or ValidateOutput is streamed into
+	// the sink with no local copy. Merged, manifest and transcoded outputs
+	// are built in a temporary directory, uploaded and removed; they are
+	// not resumed.
+	Sink OutputSink
 }
 
 // DownloadResult describes a completed file download.
 type DownloadResult struct {
-	VideoID    string
-	Itag       int
+	VideoID string
+	Itag    int
+	// OutputPath is the written file, or its key in DownloadOptions.Sink.
 	OutputPath string
 	Bytes      int64
+	// SelectedFormats lists the formats actually fetched, in download order.
+	SelectedFormats []FormatInfo
+	// Streams reports per-stream transfer details, aligned with SelectedFormats.
+	Streams []DownloadStreamResult
+	// FallbackReason is non-empty when the requested selection was not fetched
+	// as-is: "muxer_unavailable", "multi_track_unsupported",
+	// "selector_fallback" (a later "/" alternative after the selection failed
+	// to resolve or download), "duplicate_source" (the same stream from
+	// another client or URL), or "challenge_not_solved" (single-file retry
+	// after a partial challenge solve).
+	FallbackReason string
+	// Simulated is set when DownloadOptions.Simulate skipped the transfer;
+	// OutputPath and Streams[].Path are the paths that would be written.
+	Simulated bool
+	// Elapsed and BytesPerSecond total the stream transfers. Merging and
+	// audio extraction are not included.
+	Elapsed        time.Duration
+	BytesPerSecond int64
+	// ExpiresAt is the earliest expiry of the selected formats' signed URLs
+	// (zero if unknown); the result's URLs cannot be reused past it.
+	ExpiresAt time.Time
+	// SHA256 is the hex digest of the file at OutputPath, set under
+	// DownloadOptions.WriteChecksum.
+	SHA256 string
+}
+
+// DownloadStreamResult describes one fetched stream of a download.
+type DownloadStreamResult struct {
+	Itag     int
+	Protocol string
+	URLHost  string
+	Path     string // final output, or the intermediate file for merges
+	Bytes    int64
+	Retries  int
+	// Client is the Innertube client whose URL served the stream. It differs
+	// from the selected format's SourceClient after a 403 client switch.
+	Client string
+	// Discontinuities lists HLS/DASH boundaries in the stream output where
+	// timestamps or encoding parameters may restart.
+	Discontinuities []DownloadDiscontinuity
+	// Transferred counts bytes fetched in this call across all attempts. It
+	// is below Bytes when a partial file was resumed.
+	Transferred int64
+	// Elapsed is the wall time spent transferring, including retries and
+	// throttle refreshes; BytesPerSecond is Transferred over Elapsed.
+	Elapsed        time.Duration
+	BytesPerSecond int64
+}
+
+// DownloadDiscontinuity marks a byte offset in a stream's output where a new
+// DASH Period or an HLS EXT-X-DISCONTINUITY begins, or where an LL-HLS
+// segment lost its remaining parts. Concatenated media is only
+// continuous between boundaries; split or re-time the output there before
+// relying on its timestamps.
+type DownloadDiscontinuity struct {
+	Offset   int64
+	Sequence int64
+	Period   string // DASH Period id; empty for HLS
+	Reason   string // "period", "discontinuity" or "incomplete_segment"
 }
 
 // Download resolves the selected stream URL and writes it to a local file.
 // If options.Itag is 0, format selection follows options.Mode (default: best).
 // If options.OutputPath is empty, "<videoID>-<itag><ext>" is used.
 func (c *Client) Download(ctx context.Context, input string, options DownloadOptions) (*DownloadResult, error) {
+	if c.config.NoDisk {
+		return nil, fmt.Errorf("%w: Download writes its output to a file", ErrDiskDisabled)
+	}
 	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
 	defer cancel()
+	ctx = c.withRetryBudget(ctx)
+	ctx = c.withMediaRequestState(ctx)
 
-	videoID, err := normalizeVideoID(input)
+	videoID, err := c.resolveVideoInput(ctx, input)
 	if err != nil {
 		return nil, err
 	}
+	if err := validateAudioFormat(options.ExtractAudio); err != nil {
+		return nil, err
+	}
+	if options.ExtractAudio != "" && isPipeOutput(options.OutputPath) {
+		return nil, fmt.Errorf("audio extraction rewrites the output file, but %s is a pipe", options.OutputPath)
+	}
 
-	// filters ...
-
-	var info *VideoInfo
-	if session, ok := c.getSession(videoID); ok && session.Info != nil {
-		info = cloneVideoInfo(session.Info)
+	releaseExtraction, err := acquireExtraction(ctx)
+	if err != nil {
+		return nil, err
 	}
-	if info == nil {
-		info, err = c.GetVideo(ctx, videoID)
-		if err != nil {
-			return nil, err
-		}
+	videoID, info, formats, selected, err := c.selectDownloadTarget(ctx, videoID, options)
+	releaseExtraction()
+	if err != nil {
+		return nil, err
+	}
+	defer c.releaseSessionResponse(videoID)
+	if options.MaxFileSize > 0 {
+		ctx = context.WithValue(ctx, maxFileSizeKey{}, options.MaxFileSize)
+	}
+	if options.LiveOffset > 0 {
+		ctx = context.WithValue(ctx, liveOffsetKey{}, options.LiveOffset)
+	}
+	if hints := liveHints(info); hints != (downloader.LiveHints{}) {
+		ctx = context.WithValue(ctx, liveHintsKey{}, hints)
 	}
-	formats := info.Formats
 
+	text := c.config.TextNormalization
 	meta := types.Metadata{
-		Title:       info.Title,
-		Artist:      info.Author,
-		Description: info.Description,
+		Title:       text.Apply(info.Title),
+		Artist:      text.Apply(info.Author),
+		Description: text.Apply(info.Description),
 		Date:        info.PublishDate,
 		Duration:    int(info.DurationSec),
+		Language:    info.Language,
 	}
 	if meta.Date == "" {
 		meta.Date = info.UploadDate
 	}
 
+	if !options.Simulate {
+		c.warnShortURLLifetime(videoID, info, selected, time.Now())
+		defer c.startPlaybackPings(ctx, videoID, info, selected)()
+	}
+	if options.Sink != nil && !options.Simulate {
+		return c.downloadToSink(ctx, videoID, info, formats, selected, options, meta)
+	}
+	return c.downloadLocal(ctx, videoID, info, formats, selected, options, meta)
+}
+
+// downloadLocal fetches the selection to a local file and runs the
+// requested post-processing on it.
+func (c *Client) downloadLocal(ctx context.Context, videoID string, info *VideoInfo, formats, selected []types.FormatInfo, options DownloadOptions, meta types.Metadata) (*DownloadResult, error) {
+	var digests *outputDigests
+	if options.WriteChecksum {
+		ctx, digests = withOutputDigests(ctx)
+	}
+	releaseStream := func() {}
+	if !options.Simulate {
+		var err error
+		if releaseStream, err = acquireStream(ctx); err != nil {
+			return nil, err
+		}
+	}
+	res, err := c.downloadSelected(ctx, videoID, info, formats, selected, options, meta)
+	releaseStream()
+	if res != nil {
+		res.ExpiresAt = earliestExpiry(res.SelectedFormats)
+	}
+	if res != nil && !res.Simulated {
+		res.totalStreamTransfers()
+		if err == nil && res.BytesPerSecond > 0 {
+			c.lastBytesPerSecond.Store(res.BytesPerSecond)
+		}
+	}
+	if err != nil || res == nil || res.Simulated {
+		return res, err
+	}
+	if options.ExtractAudio != "" {
+		if res, err = c.extractAudio(ctx, res, options.ExtractAudio, meta); err != nil {
+			return res, err
+		}
+	}
+	if options.ValidateOutput {
+		if err := c.validateOutput(ctx, res, info, options); err != nil {
+			return res, err
+		}
+	}
+	if digests != nil {
+		if err := c.writeChecksum(res, digests); err != nil {
+			return res, err
+		}
+	}
+	if options.SetMtime {
+		c.applyUploadMtime(res.OutputPath, info)
+	}
+	return res, nil
+}
+
+// writeChecksum implements DownloadOptions.WriteChecksum.
+func (c *Client) writeChecksum(res *DownloadResult, digests *outputDigests) error {
+	sum, err := digests.sum(res.OutputPath)
+	if err != nil {
+		return fmt.Errorf("checksum %s: %w", res.OutputPath, err)
+	}
+	if err := writeChecksumSidecar(res.OutputPath, sum); err != nil {
+		return err
+	}
+	res.SHA256 = sum
+	c.emitDownloadEvent("checksum", "complete", res.VideoID, res.OutputPath, "sha256="+sum)
+	return nil
+}
+
+// applyUploadMtime implements DownloadOptions.SetMtime.
+func (c *Client) applyUploadMtime(path string, info *VideoInfo) {
+	published, ok := videoPublishTime(info)
+	if !ok || isPipeOutput(path) {
+		return
+	}
+	if err := os.Chtimes(path, time.Now(), published); err != nil {
+		c.warnf("cannot set modification time of %s: %v", path, err)
+	}
+}
+
+// videoPublishTime parses the video's publish date, falling back to its
+// upload date. Both are RFC 3339 timestamps or bare YYYY-MM-DD dates.
+func videoPublishTime(info *VideoInfo) (time.Time, bool) {
+	for _, raw := range []string{info.PublishDate, info.UploadDate} {
+		raw = strings.TrimSpace(raw)
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, true
+		}
+		if t, err := time.Parse("2006-01-02", raw); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// downloadSelected fetches the selected formats, merging them when more than
+// one was selected, and walks the download fallback plan when that fails.
+func (c *Client) downloadSelected(ctx context.Context, videoID string, info *VideoInfo, formats, selected []types.FormatInfo, options DownloadOptions, meta types.Metadata) (*DownloadResult, error) {
+	plan, err := c.downloadPlan(videoID, formats, selected, options)
+	if err != nil {
+		return nil, err
+	}
+	return c.runDownloadPlan(ctx, videoID, info, plan, options, meta)
+}
+
+// selectDownloadFormats loads video info and runs Download format selection.
+// It returns the policy-filtered candidate set alongside the selected formats.
+// selectDownloadTarget is selectDownloadFormats, switching to the trailer of
+// an upcoming video when options.Trailer is set. It returns the video ID
+// actually selected from.
+func (c *Client) selectDownloadTarget(ctx context.Context, videoID string, options DownloadOptions) (string, *VideoInfo, []types.FormatInfo, []types.FormatInfo, error) {
+	info, formats, selected, err := c.selectDownloadFormats(ctx, videoID, options)
+	var upcoming *UpcomingVideoError
+	if options.Trailer && errors.As(err, &upcoming) && upcoming.TrailerVideoID != "" {
+		c.warnf("video %s has not started yet; using trailer %s", videoID, upcoming.TrailerVideoID)
+		videoID = upcoming.TrailerVideoID
+		info, formats, selected, err = c.selectDownloadFormats(ctx, videoID, options)
+	}
+	return videoID, info, formats, selected, err
+}
+
+func (c *Client) selectDownloadFormats(ctx context.Context, videoID string, options DownloadOptions) (*VideoInfo, []types.FormatInfo, []types.FormatInfo, error) {
+	var info *VideoInfo
+	if session, ok := c.getSession(videoID); ok && session.Info != nil && !session.MetadataOnly {
+		info = cloneVideoInfo(session.Info)
+	}
+	if info == nil {
+		var err error
+		info, err = c.GetVideo(types.WithoutMetadataOnly(ctx), videoID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	formats := info.Formats
+	if len(formats) == 0 && info.IsUpcoming {
+		return nil, nil, nil, &UpcomingVideoError{
+			VideoID:            info.ID,
+			ScheduledStartTime: info.ScheduledStartTime,
+			TrailerVideoID:     info.TrailerVideoID,
+		}
+	}
+
 	// Filter unplayable formats (e.g. requiring PO Token)
 	filteredFormats, skipReasons := filterFormatsByPoTokenPolicy(formats, c.config)
 	if len(filteredFormats) == 0 && len(skipReasons) > 0 {
 		for _, skip := range skipReasons {
 			c.warnf("format skipped by po token policy: itag=%d protocol=%s reason=%s", skip.Itag, skip.Protocol, skip.Reason)
 		}
-		return nil, &NoPlayableFormatsDetailError{
+		return nil, nil, nil, &NoPlayableFormatsDetailError{
 			Mode:  options.Mode, // Approximate
 			Skips: skipReasons,
 		}
@@ -91,33 +375,23 @@ func (c *Client) Download(ctx context.Context, input string, options DownloadOpt
 		formats = filteredFormats
 	}
 	if len(formats) == 0 {
-		return nil, ErrNoPlayableFormats
+		return nil, nil, nil, ErrNoPlayableFormats
 	}
-
-	// 1. Determine Selector
-	selStr := options.FormatSelector
-	if selStr == "" {
-		if options.Itag > 0 {
-			// Explicit Itag: No selector needed, handled in selection
-		} else {
-			// Map Mode to selector
-			switch options.Mode {
-			case SelectionModeBest, "":
-				selStr = "bestvideo+bestaudio/best"
-			case SelectionModeMP4AV:
-				selStr = "bestvideo[ext=mp4]+bestaudio[ext=m4a]/best[ext=mp4]/best"
-			case SelectionModeMP4VideoOnly:
-				selStr = "bestvideo[ext=mp4]"
-			case SelectionModeVideoOnly:
-				selStr = "bestvideo"
-			case SelectionModeAudioOnly, SelectionModeMP3:
-				selStr = "bestaudio"
-			default:
-				selStr = "best"
+	if limited, skips := filterFormatsByLimits(formats, options); len(skips) > 0 {
+		if len(limited) == 0 {
+			return nil, nil, nil, &NoPlayableFormatsDetailError{
+				Mode:           normalizeSelectionMode(options.Mode),
+				Selector:       options.FormatSelector,
+				SelectionError: "all formats exceed max filesize/height limits",
+				Skips:          skips,
 			}
 		}
+		formats = limited
 	}
 
+	// 1. Determine Selector
+	selStr := downloadSelectorString(options)
+
 	// 2. Select Formats
 	var selected []types.FormatInfo
 	var parsedSelector *selector.Selector
@@ -129,12 +403,12 @@ func (c *Client) Download(ctx context.Context, input string, options DownloadOpt
 			}
 		}
 		if len(selected) == 0 {
-			return nil, fmt.Errorf("requested itag %d not found", options.Itag)
+			return nil, nil, nil, fmt.Errorf("requested itag %d not found", options.Itag)
 		}
 	} else {
 		sel, err := selector.Parse(selStr)
 		if err != nil {
-			return nil, &NoPlayableFormatsDetailError{
+			return nil, nil, nil, &NoPlayableFormatsDetailError{
 				Mode:           normalizeSelectionMode(options.Mode),
 				Selector:       selStr,
 				SelectionError: "selector parse failed: " + err.Error(),
@@ -143,12 +417,12 @@ func (c *Client) Download(ctx context.Context, input string, options DownloadOpt
 		parsedSelector = sel
 		selected, err = selector.Select(formats, sel)
 		if err != nil {
-			return nil, err
+			return nil, nil, nil, err
 		}
 	}
 
 	if len(selected) == 0 {
-		return nil, &NoPlayableFormatsDetailError{
+		return nil, nil, nil, &NoPlayableFormatsDetailError{
 			Mode:           normalizeSelectionMode(options.Mode),
 			Selector:       selStr,
 			SelectionError: "no formats matched selector",
@@ -173,107 +447,182 @@ func (c *Client) Download(ctx context.Context, input string, options DownloadOpt
 		}
 	}
 
-	// 3. Fallback for Merge if Muxer missing
-	if len(selected) > 1 && (c.config.Muxer == nil || !c.config.Muxer.Available()) {
-		c.logger.Warnf("Muxer unavailable, falling back to best single file")
-		sel, _ := selector.Parse("best")
-		selected, _ = selector.Select(formats, sel)
-		if len(selected) == 0 {
-			return nil, errors.New("no formats found (and muxer unavailable)")
+	if options.CheckFormats && parsedSelector != nil {
+		checked, remaining, err := c.checkSelectedFormats(ctx, videoID, formats, selected, parsedSelector)
+		if err != nil {
+			return nil, nil, nil, &NoPlayableFormatsDetailError{
+				Mode:           normalizeSelectionMode(options.Mode),
+				Selector:       selStr,
+				SelectionError: err.Error(),
+			}
 		}
+		selected, formats = checked, remaining
 	}
 
-	// 4. Download
-	if len(selected) == 1 {
-		res, err := c.downloadSingle(ctx, videoID, info.Title, info.Author, selected[0], options.OutputPath, options)
-		if err != nil && errors.Is(err, ErrChallengeNotSolved) && options.Itag == 0 {
-			c.warnf("challenge solve incomplete; retrying with fallback single-file format")
-			return c.downloadFallbackSingle(ctx, videoID, info.Title, info.Author, formats, options.OutputPath, options)
+	return info, formats, selected, nil
+}
+
+// downloadSelectorString returns the selector expression Download evaluates
+// for options: FormatSelector, or the one options.Mode maps to, constrained by
+// MaxHeight. It is empty when an explicit Itag bypasses selection.
+func downloadSelectorString(options DownloadOptions) string {
+	selStr := options.FormatSelector
+	if selStr == "" {
+		if options.Itag > 0 {
+			// Explicit Itag: No selector needed, handled in selection
+		} else {
+			// Map Mode to selector
+			switch options.Mode {
+			case SelectionModeBest, "":
+				selStr = "bestvideo+bestaudio/best"
+			case SelectionModeMP4AV:
+				selStr = "bestvideo[ext=mp4]+bestaudio[ext=m4a]/best[ext=mp4]/best"
+			case SelectionModeMP4VideoOnly:
+				selStr = "bestvideo[ext=mp4]"
+			case SelectionModeVideoOnly:
+				selStr = "bestvideo"
+			case SelectionModeAudioOnly, SelectionModeMP3:
+				selStr = "bestaudio"
+			default:
+				selStr = "best"
+			}
 		}
-		return res, err
 	}
 
-	res, err := c.downloadAndMerge(ctx, videoID, selected, options, meta)
-	if err != nil && errors.Is(err, ErrChallengeNotSolved) && options.Itag == 0 {
-		c.warnf("challenge solve incomplete during merge selection; retrying with fallback single-file format")
-		return c.downloadFallbackSingle(ctx, videoID, info.Title, info.Author, formats, options.OutputPath, options)
+	if options.MaxHeight > 0 {
+		selStr = selector.ConstrainHeight(selStr, options.MaxHeight)
 	}
-	return res, err
+	return selStr
 }
 
-func selectionHasCiphered(selected []types.FormatInfo) bool {
-	for _, f := range selected {
-		if f.Ciphered {
-			return true
+// filterFormatsByLimits drops formats taller than options.MaxHeight or with a
+// known size above options.MaxFileSize. Formats of unknown size are kept; the
+// transfer itself enforces MaxFileSize.
+func filterFormatsByLimits(formats []types.FormatInfo, options DownloadOptions) ([]types.FormatInfo, []FormatSkipReason) {
+	if options.MaxFileSize <= 0 && options.MaxHeight <= 0 {
+		return formats, nil
+	}
+	kept := make([]types.FormatInfo, 0, len(formats))
+	var skips []FormatSkipReason
+	for _, f := range formats {
+		reason := ""
+		switch {
+		case options.MaxHeight > 0 && f.Height > options.MaxHeight:
+			reason = fmt.Sprintf("height %d exceeds max %d", f.Height, options.MaxHeight)
+		case options.MaxFileSize > 0 && knownContentLength(f, f.URL) > options.MaxFileSize:
+			reason = fmt.Sprintf("filesize %d exceeds max %d", knownContentLength(f, f.URL), options.MaxFileSize)
+		}
+		if reason != "" {
+			skips = append(skips, FormatSkipReason{Itag: f.Itag, Protocol: f.Protocol, Reason: reason})
+			continue
 		}
+		kept = append(kept, f)
 	}
-	return false
+	return kept, skips
 }
 
-func (c *Client) downloadFallbackSingle(
+// checkSelectedFormats probes each selected format and, when one fails,
+// drops it from the candidate set and re-runs the selector so the next
+// alternative is tried. Formats that already passed are not probed again.
+func (c *Client) checkSelectedFormats(
 	ctx context.Context,
 	videoID string,
-	title string,
-	uploader string,
 	formats []types.FormatInfo,
-	outputPath string,
-	options DownloadOptions,
-) (*DownloadResult, error) {
-	candidates := make([]types.FormatInfo, 0, len(formats))
-	for _, f := range formats {
-		if f.HasVideo && f.HasAudio {
-			candidates = append(candidates, f)
+	selected []types.FormatInfo,
+	sel *selector.Selector,
+) ([]types.FormatInfo, []types.FormatInfo, error) {
+	passed := make(map[string]struct{}, len(selected))
+	for {
+		rejected := make(map[string]struct{})
+		for _, f := range selected {
+			key := formatCheckKey(f)
+			if _, ok := passed[key]; ok {
+				continue
+			}
+			if err := c.probeFormatAvailability(ctx, videoID, f); err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return nil, nil, ctxErr
+				}
+				c.emitDownloadEvent("check", "failure", videoID, "", fmt.Sprintf("itag=%d reason=%v", f.Itag, err))
+				rejected[key] = struct{}{}
+				continue
+			}
+			c.emitDownloadEvent("check", "success", videoID, "", fmt.Sprintf("itag=%d", f.Itag))
+			passed[key] = struct{}{}
+		}
+		if len(rejected) == 0 {
+			return selected, formats, nil
 		}
-	}
-	if len(candidates) == 0 {
-		return nil, ErrChallengeNotSolved
-	}
 
-	preferred := make([]types.FormatInfo, 0, len(candidates))
-	for _, f := range candidates {
-		if !f.Ciphered {
-			preferred = append(preferred, f)
+		remaining := make([]types.FormatInfo, 0, len(formats))
+		for _, f := range formats {
+			if _, bad := rejected[formatCheckKey(f)]; !bad {
+				remaining = append(remaining, f)
+			}
+		}
+		formats = remaining
+		if len(formats) == 0 {
+			return nil, nil, errors.New("all candidate formats failed availability check")
 		}
+		next, err := selector.Select(formats, sel)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(next) == 0 {
+			return nil, nil, errors.New("no remaining formats matched selector after availability check")
+		}
+		selected = next
 	}
-	if len(preferred) == 0 {
-		preferred = candidates
+}
+
+func formatCheckKey(f types.FormatInfo) string {
+	return fmt.Sprintf("%d|%s|%s", f.Itag, f.Protocol, f.URL)
+}
+
+// probeFormatAvailability issues a one-byte range request against the resolved
+// format URL, reusing the session's earlier probe of it. Manifest-backed
+// formats are validated during fragment transfer.
+func (c *Client) probeFormatAvailability(ctx context.Context, videoID string, f types.FormatInfo) error {
+	if f.Protocol == "hls" || f.Protocol == "dash" {
+		return nil
+	}
+	streamURL, err := c.resolveSelectedFormatURL(ctx, videoID, f)
+	if err != nil {
+		return err
+	}
+	ctx = withURLProbeCache(ctx, c.urlProbes(videoID))
+	probe, err := probeStreamURL(ctx, c.httpClient(), streamURL, videoID, c.mediaHeaders(streamURL))
+	if err != nil {
+		return err
+	}
+	if probe.StatusCode != http.StatusOK && probe.StatusCode != http.StatusPartialContent {
+		return &downloadHTTPStatusError{StatusCode: probe.StatusCode, RetryAfter: probe.RetryAfter}
 	}
+	return nil
+}
 
-	for _, f := range preferred {
-		res, err := c.downloadSingle(ctx, videoID, title, uploader, f, outputPath, options)
-		if err == nil {
-			return res, nil
-		}
-		if !errors.Is(err, ErrChallengeNotSolved) {
-			return nil, err
+func selectionHasCiphered(selected []types.FormatInfo) bool {
+	for _, f := range selected {
+		if f.Ciphered {
+			return true
 		}
 	}
-	return nil, ErrChallengeNotSolved
+	return false
 }
 
 func (c *Client) downloadSingle(ctx context.Context, videoID string, title string, uploader string, f types.FormatInfo, outputPath string, options DownloadOptions) (*DownloadResult, error) {
-	if outputPath == "" {
-		outputPath = defaultOutputPath(videoID, f.Itag, f.MimeType, options.Mode)
-	} else {
-		outputPath = renderOutputPathTemplate(outputPath, outputTemplateData{
-			VideoID:  videoID,
-			Title:    title,
-			Uploader: uploader,
-			Ext:      detectOutputExt(f.MimeType, options.Mode),
-			Itag:     strconv.Itoa(f.Itag),
-		})
-		if strings.TrimSpace(outputPath) == "" {
-			outputPath = defaultOutputPath(videoID, f.Itag, f.MimeType, options.Mode)
-		}
-	}
-	if dir := filepath.Dir(outputPath); dir != "." && dir != "" {
-		_ = os.MkdirAll(dir, 0755)
-	}
+	outputPath = singleOutputPath(videoID, title, uploader, f, outputPath, options.Mode)
 
 	// MP3 Transcode Check
 	if options.Mode == SelectionModeMP3 && c.config.MP3Transcoder == nil {
 		return nil, &MP3TranscoderError{Mode: options.Mode}
 	}
+	if options.Simulate {
+		return simulatedDownloadResult(videoID, outputPath, []mergePart{{Format: f}}, func(mergePart) string { return outputPath }), nil
+	}
+	if dir := filepath.Dir(outputPath); dir != "." && dir != "" {
+		_ = os.MkdirAll(dir, 0755)
+	}
 
 	streamURL, err := c.resolveSelectedFormatURL(ctx, videoID, f)
 	if err != nil {
@@ -281,138 +630,322 @@ func (c *Client) downloadSingle(ctx context.Context, videoID string, title strin
 	}
 	c.emitDownloadEvent("download", "destination", videoID, outputPath, fmt.Sprintf("itag=%d", f.Itag))
 
-	// If MP3, we might need to download to temp then transcode, or stream transcode.
-	// Previous logic: transcodeURLToMP3 handles download.
 	if options.Mode == SelectionModeMP3 {
-		c.emitDownloadEvent("download", "start", videoID, outputPath, "transcode=mp3")
-		out, err := os.Create(outputPath)
-		if err != nil {
-			c.emitDownloadEvent("download", "failure", videoID, outputPath, err.Error())
-			return nil, err
-		}
-		defer out.Close()
-
-		bytes, err := transcodeURLToMP3(ctx, c.config.HTTPClient, c.config.MP3Transcoder, streamURL, MP3TranscodeMetadata{
-			VideoID: videoID, SourceItag: f.Itag, SourceMimeType: f.MimeType,
-		}, out, c.config.RequestHeaders)
-		if err != nil {
-			c.emitDownloadEvent("download", "failure", videoID, outputPath, err.Error())
-			return nil, err
-		}
-		c.emitDownloadEvent("download", "complete", videoID, outputPath, fmt.Sprintf("bytes=%d", bytes))
-
-		return &DownloadResult{VideoID: videoID, Itag: f.Itag, OutputPath: outputPath, Bytes: bytes}, nil
+		return c.downloadMP3(ctx, videoID, streamURL, outputPath, f, options)
 	}
 
 	c.emitDownloadEvent("download", "start", videoID, outputPath, fmt.Sprintf("itag=%d", f.Itag))
-	if err := c.downloadStream(ctx, videoID, streamURL, outputPath, f, options.Resume); err != nil {
-		attempt := downloadAttemptFromFormatAndURL(f, streamURL, err)
-		c.emitDownloadEvent("download", "failure", videoID, outputPath, formatDownloadFailureDetail(attempt))
-		return nil, wrapDownloadFailure(err, attempt)
+	stream, attempts, err := c.fetchStream(ctx, videoID, streamURL, outputPath, f, options.Resume)
+	if err != nil {
+		c.emitDownloadEvent("download", "failure", videoID, outputPath, formatDownloadFailureDetail(attempts[len(attempts)-1]))
+		return nil, wrapDownloadFailure(err, attempts...)
 	}
-	c.emitDownloadEvent("download", "complete", videoID, outputPath, fmt.Sprintf("bytes=%d", getFileSize(outputPath)))
+	c.emitDownloadEvent("download", "complete", videoID, outputPath, fmt.Sprintf("bytes=%d", stream.Bytes))
 
 	return &DownloadResult{
+		VideoID:         videoID,
+		Itag:            f.Itag,
+		OutputPath:      outputPath,
+		Bytes:           stream.Bytes,
+		SelectedFormats: []types.FormatInfo{f},
+		Streams:         []DownloadStreamResult{stream},
+	}, nil
+}
+
+// singleOutputPath renders the output template for a single-format
+// download, falling back to the default name.
+func singleOutputPath(videoID, title, uploader string, f types.FormatInfo, template string, mode SelectionMode) string {
+	if template == "" {
+		return defaultOutputPath(videoID, f.Itag, f.MimeType, mode)
+	}
+	outputPath := renderOutputPathTemplate(template, outputTemplateData{
+		VideoID:  videoID,
+		Title:    title,
+		Uploader: uploader,
+		Ext:      detectOutputExt(f.MimeType, mode),
+		Itag:     strconv.Itoa(f.Itag),
+	})
+	if strings.TrimSpace(outputPath) == "" {
+		return defaultOutputPath(videoID, f.Itag, f.MimeType, mode)
+	}
+	return outputPath
+}
+
+func newDownloadStreamResult(f types.FormatInfo, streamURL, path string, bytes int64, retries int) DownloadStreamResult {
+	stream := DownloadStreamResult{
+		Itag:     f.Itag,
+		Protocol: strings.TrimSpace(f.Protocol),
+		Path:     path,
+		Bytes:    bytes,
+		Retries:  retries,
+		Client:   f.SourceClient,
+	}
+	if stream.Protocol == "" {
+		stream.Protocol = "unknown"
+	}
+	if u, err := url.Parse(streamURL); err == nil {
+		stream.URLHost = u.Host
+	}
+	return stream
+}
+
+// setTransfer records bytes fetched over elapsed and derives the speed.
+func (s *DownloadStreamResult) setTransfer(transferred int64, elapsed time.Duration) {
+	s.Transferred = transferred
+	s.Elapsed = elapsed
+	s.BytesPerSecond = bytesPerSecond(transferred, elapsed)
+}
+
+// addPriorAttempt folds the counters of an earlier failed attempt at the same
+// stream into s.
+func (s *DownloadStreamResult) addPriorAttempt(prior DownloadStreamResult) {
+	s.Retries += prior.Retries
+	s.setTransfer(s.Transferred+prior.Transferred, s.Elapsed+prior.Elapsed)
+}
+
+// totalStreamTransfers sums the stream transfer times and speeds into r.
+// Streams are fetched one after another, so the times add up.
+func (r *DownloadResult) totalStreamTransfers() {
+	var transferred int64
+	var elapsed time.Duration
+	for _, s := range r.Streams {
+		transferred += s.Transferred
+		elapsed += s.Elapsed
+	}
+	r.Elapsed = elapsed
+	r.BytesPerSecond = bytesPerSecond(transferred, elapsed)
+}
+
+func bytesPerSecond(n int64, d time.Duration) int64 {
+	if n <= 0 || d <= 0 {
+		return 0
+	}
+	return int64(float64(n) / d.Seconds())
+}
+
+// simulatedDownloadResult describes the files a download would produce.
+// Stream URLs are not resolved, so URLHost is empty and Bytes are zero.
+func simulatedDownloadResult(videoID, outputPath string, parts []mergePart, streamPath func(mergePart) string) *DownloadResult {
+	res := &DownloadResult{
 		VideoID:    videoID,
-		Itag:       f.Itag,
+		Itag:       parts[0].Format.Itag,
 		OutputPath: outputPath,
-		Bytes:      getFileSize(outputPath),
-	}, nil
+		Simulated:  true,
+	}
+	for _, p := range parts {
+		res.SelectedFormats = append(res.SelectedFormats, p.Format)
+		res.Streams = append(res.Streams, newDownloadStreamResult(p.Format, "", streamPath(p), 0, 0))
+	}
+	return res
 }
 
-func (c *Client) downloadAndMerge(ctx context.Context, videoID string, formats []types.FormatInfo, options DownloadOptions, meta types.Metadata) (*DownloadResult, error) {
-	// Identify Video and Audio
-	var vidF, audF types.FormatInfo
-	foundV, foundA := false, false
+// mergePart is one stream of a merge selection and its intermediate file role.
+type mergePart struct {
+	Format types.FormatInfo
+	Kind   string // "video" or "audio"
+}
 
+func (c *Client) downloadAndMerge(ctx context.Context, videoID string, formats []types.FormatInfo, options DownloadOptions, meta types.Metadata) (*DownloadResult, error) {
+	// Identify Video and Audio parts
+	var parts []mergePart
+	videoCount, audioCount := 0, 0
 	for _, f := range formats {
-		if f.HasVideo && !foundV {
-			vidF = f
-			foundV = true
-		} else if f.HasAudio && !foundA {
-			audF = f
-			foundA = true
+		switch {
+		case f.HasVideo:
+			parts = append(parts, mergePart{Format: f, Kind: "video"})
+			videoCount++
+		case f.HasAudio:
+			parts = append(parts, mergePart{Format: f, Kind: "audio"})
+			audioCount++
 		}
 	}
 
-	if !foundV || !foundA {
+	if (videoCount == 0 && audioCount < 2) || (videoCount > 0 && audioCount == 0) {
 		// Should not happen if selector logic works for +
 		return c.downloadSingle(ctx, videoID, meta.Title, meta.Artist, formats[0], options.OutputPath, options)
 	}
 
+	multiMuxer, _ := c.config.Muxer.(MultiTrackMuxer)
+	multiTrack := videoCount != 1 || audioCount != 1
+	fallbackReason := ""
+	if multiTrack && multiMuxer == nil {
+		c.warnf("muxer does not support multi-track output; merging first video and audio stream only")
+		parts = firstVideoAndAudio(parts)
+		if len(parts) < 2 {
+			res, err := c.downloadSingle(ctx, videoID, meta.Title, meta.Artist, parts[0].Format, options.OutputPath, options)
+			if res != nil {
+				res.FallbackReason = "multi_track_unsupported"
+			}
+			return res, err
+		}
+		multiTrack = false
+		fallbackReason = "multi_track_unsupported"
+	}
+
+	ext := "mp4"
+	if multiTrack {
+		// MP4 players handle extra audio tracks poorly; Matroska keeps
+		// per-track language/title metadata intact.
+		ext = "mkv"
+		if videoCount == 0 {
+			ext = "mka"
+		}
+	}
+	itags := make([]string, 0, len(parts))
+	for _, p := range parts {
+		itags = append(itags, strconv.Itoa(p.Format.Itag))
+	}
+	itagLabel := strings.Join(itags, "+")
+
 	basePath := options.OutputPath
 	if basePath == "" {
-		basePath = fmt.Sprintf("%s-%d+%d.mp4", videoID, vidF.Itag, audF.Itag)
+		basePath = fmt.Sprintf("%s-%s.%s", videoID, itagLabel, ext)
 	} else {
 		basePath = renderOutputPathTemplate(basePath, outputTemplateData{
 			VideoID:  videoID,
 			Title:    meta.Title,
 			Uploader: meta.Artist,
-			Ext:      "mp4",
-			Itag:     fmt.Sprintf("%d+%d", vidF.Itag, audF.Itag),
+			Ext:      ext,
+			Itag:     itagLabel,
 		})
 		if strings.TrimSpace(basePath) == "" {
-			basePath = fmt.Sprintf("%s-%d+%d.mp4", videoID, vidF.Itag, audF.Itag)
+			basePath = fmt.Sprintf("%s-%s.%s", videoID, itagLabel, ext)
 		}
 	}
 	if filepath.Ext(basePath) == "" {
-		basePath += ".mp4"
+		basePath += "." + ext
+	}
+	if options.Simulate {
+		res := simulatedDownloadResult(videoID, basePath, parts, func(p mergePart) string {
+			return intermediatePartPath(basePath, p.Format, p.Kind, "")
+		})
+		res.FallbackReason = fallbackReason
+		return res, nil
 	}
 
 	if dir := filepath.Dir(basePath); dir != "." && dir != "" {
 		_ = os.MkdirAll(dir, 0755)
 	}
 
-	videoPath := basePath + ".f" + strconv.Itoa(vidF.Itag) + ".video"
-	audioPath := basePath + ".f" + strconv.Itoa(audF.Itag) + ".audio"
-	keepIntermediates := options.KeepIntermediateFiles || c.config.KeepIntermediateFiles
-
-	// Video
-	vURL, err := c.resolveSelectedFormatURL(ctx, videoID, vidF)
-	if err != nil {
-		return nil, err
-	}
-	c.emitDownloadEvent("download", "destination", videoID, videoPath, fmt.Sprintf("itag=%d", vidF.Itag))
-	c.emitDownloadEvent("download", "start", videoID, videoPath, fmt.Sprintf("itag=%d", vidF.Itag))
-	if err := c.downloadStream(ctx, videoID, vURL, videoPath, vidF, options.Resume); err != nil {
-		attempt := downloadAttemptFromFormatAndURL(vidF, vURL, err)
-		c.emitDownloadEvent("download", "failure", videoID, videoPath, formatDownloadFailureDetail(attempt))
-		return nil, wrapDownloadFailure(err, attempt)
+	// Another run of the same output owns the deterministic intermediate
+	// names; use run-scoped names so neither corrupts the other's streams.
+	runToken := ""
+	resume := options.Resume
+	if lock := acquireIntermediateLock(basePath); lock != nil {
+		defer lock.release()
+	} else {
+		runToken = newIntermediateRunToken()
+		resume = false
+		c.warnf("intermediate files for %s are in use by another download; using run-scoped names", basePath)
 	}
-	c.emitDownloadEvent("download", "complete", videoID, videoPath, fmt.Sprintf("bytes=%d", getFileSize(videoPath)))
-	defer c.cleanupIntermediateFile(videoID, videoPath, keepIntermediates)
 
-	// Audio
-	aURL, err := c.resolveSelectedFormatURL(ctx, videoID, audF)
-	if err != nil {
-		return nil, err
-	}
-	c.emitDownloadEvent("download", "destination", videoID, audioPath, fmt.Sprintf("itag=%d", audF.Itag))
-	c.emitDownloadEvent("download", "start", videoID, audioPath, fmt.Sprintf("itag=%d", audF.Itag))
-	if err := c.downloadStream(ctx, videoID, aURL, audioPath, audF, options.Resume); err != nil {
-		attempt := downloadAttemptFromFormatAndURL(audF, aURL, err)
-		c.emitDownloadEvent("download", "failure", videoID, audioPath, formatDownloadFailureDetail(attempt))
-		return nil, wrapDownloadFailure(err, attempt)
+	keepIntermediates := options.KeepIntermediateFiles || c.config.KeepIntermediateFiles
+	tracks := make([]types.MuxTrack, 0, len(parts))
+	selectedFormats := make([]types.FormatInfo, 0, len(parts))
+	streams := make([]DownloadStreamResult, 0, len(parts))
+	partFormats := make([]types.FormatInfo, 0, len(parts))
+	for _, p := range parts {
+		partFormats = append(partFormats, p.Format)
+	}
+	c.primeFormatChallenges(ctx, videoID, partFormats)
+	for _, p := range parts {
+		f := p.Format
+		partPath := intermediatePartPath(basePath, f, p.Kind, runToken)
+		streamURL, err := c.resolveSelectedFormatURL(ctx, videoID, f)
+		if err != nil {
+			return nil, err
+		}
+		c.emitDownloadEvent("download", "destination", videoID, partPath, fmt.Sprintf("itag=%d", f.Itag))
+		c.emitDownloadEvent("download", "start", videoID, partPath, fmt.Sprintf("itag=%d", f.Itag))
+		stream, attempts, err := c.fetchStream(ctx, videoID, streamURL, partPath, f, resume)
+		if err != nil {
+			c.emitDownloadEvent("download", "failure", videoID, partPath, formatDownloadFailureDetail(attempts[len(attempts)-1]))
+			return nil, wrapDownloadFailure(err, attempts...)
+		}
+		c.emitDownloadEvent("download", "complete", videoID, partPath, fmt.Sprintf("bytes=%d", stream.Bytes))
+		defer func(partPath string) {
+			// A cancelled run (e.g. Job.Pause) keeps finished parts for the
+			// resumed run to pick up.
+			c.cleanupIntermediateFile(videoID, partPath, keepIntermediates || ctx.Err() != nil)
+		}(partPath)
+		selectedFormats = append(selectedFormats, f)
+		streams = append(streams, stream)
+
+		tracks = append(tracks, types.MuxTrack{
+			Path:     partPath,
+			Kind:     p.Kind,
+			Language: f.Language,
+			Title:    f.AudioTrackName,
+		})
 	}
-	c.emitDownloadEvent("download", "complete", videoID, audioPath, fmt.Sprintf("bytes=%d", getFileSize(audioPath)))
-	defer c.cleanupIntermediateFile(videoID, audioPath, keepIntermediates)
 
 	// Merge
-	c.emitDownloadEvent("merge", "start", videoID, basePath, fmt.Sprintf("video_itag=%d,audio_itag=%d", vidF.Itag, audF.Itag))
-	if err := c.config.Muxer.Merge(ctx, videoPath, audioPath, basePath, meta); err != nil {
-		c.emitDownloadEvent("merge", "failure", videoID, basePath, err.Error())
-		return nil, err
+	forgetOutputDigest(ctx, basePath)
+	if multiTrack {
+		c.emitDownloadEvent("merge", "start", videoID, basePath, "itags="+itagLabel)
+		if err := multiMuxer.MergeTracks(ctx, tracks, basePath, meta); err != nil {
+			c.emitDownloadEvent("merge", "failure", videoID, basePath, err.Error())
+			return nil, err
+		}
+	} else {
+		vi, ai := 0, 1
+		if parts[0].Kind != "video" {
+			vi, ai = 1, 0
+		}
+		c.emitDownloadEvent("merge", "start", videoID, basePath, fmt.Sprintf("video_itag=%d,audio_itag=%d", parts[vi].Format.Itag, parts[ai].Format.Itag))
+		if err := c.config.Muxer.Merge(ctx, tracks[vi].Path, tracks[ai].Path, basePath, meta); err != nil {
+			c.emitDownloadEvent("merge", "failure", videoID, basePath, err.Error())
+			return nil, err
+		}
 	}
 	c.emitDownloadEvent("merge", "complete", videoID, basePath, fmt.Sprintf("bytes=%d", getFileSize(basePath)))
 
 	return &DownloadResult{
-		VideoID:    videoID,
-		Itag:       vidF.Itag,
-		OutputPath: basePath,
-		Bytes:      getFileSize(basePath),
+		VideoID:         videoID,
+		Itag:            primaryMergeItag(parts),
+		OutputPath:      basePath,
+		Bytes:           getFileSize(basePath),
+		SelectedFormats: selectedFormats,
+		Streams:         streams,
+		FallbackReason:  fallbackReason,
 	}, nil
 }
 
+// firstVideoAndAudio reduces a merge selection to the classic video+audio pair.
+func firstVideoAndAudio(parts []mergePart) []mergePart {
+	var out []mergePart
+	foundV, foundA := false, false
+	for _, p := range parts {
+		if p.Kind == "video" && !foundV {
+			out = append(out, p)
+			foundV = true
+		} else if p.Kind == "audio" && !foundA {
+			out = append(out, p)
+			foundA = true
+		}
+	}
+	return out
+}
+
+// mergePartID names an intermediate file. Dubbed audio tracks share an itag,
+// so the track language keeps their paths distinct.
+func mergePartID(f types.FormatInfo) string {
+	id := strconv.Itoa(f.Itag)
+	if lang := sanitizeOutputToken(f.Language); f.Language != "" {
+		id += "-" + lang
+	}
+	return id
+}
+
+func primaryMergeItag(parts []mergePart) int {
+	for _, p := range parts {
+		if p.Kind == "video" {
+			return p.Format.Itag
+		}
+	}
+	return parts[0].Format.Itag
+}
+
 func (c *Client) downloadStream(ctx context.Context, videoID, streamURL, outputPath string, f types.FormatInfo, resume bool) error {
 	if f.Protocol == "hls" || strings.HasSuffix(streamURL, ".m3u8") {
 		_, err := c.downloadHLS(ctx, videoID, streamURL, outputPath, f)
@@ -422,6 +955,16 @@ func (c *Client) downloadStream(ctx context.Context, videoID, streamURL, outputP
 		_, err := c.downloadDASH(ctx, videoID, streamURL, outputPath, f)
 		return err
 	}
+	// External downloaders seek and preallocate; pipes stay in-process.
+	if c.config.ExternalDownloader != nil && !isPipeOutput(outputPath) {
+		return c.downloadExternal(ctx, videoID, streamURL, outputPath, resume)
+	}
+	ctx = withURLProbeCache(ctx, c.urlProbes(videoID))
+	if c.config.DownloadTransport.MultiSource {
+		if mirrors := c.mirrorStreamURLs(ctx, videoID, f, streamURL); len(mirrors) > 0 {
+			ctx = context.WithValue(ctx, mirrorURLsKey{}, mirrors)
+		}
+	}
 	_, err := downloadURLToPathWithHeaders(
 		ctx,
 		c.config.HTTPClient,
@@ -430,37 +973,11 @@ func (c *Client) downloadStream(ctx context.Context, videoID, streamURL, outputP
 		resume,
 		c.config.DownloadTransport,
 		videoID,
-		c.config.RequestHeaders,
+		c.mediaHeaders(streamURL),
 	)
 	return err
 }
 
-func transcodeURLToMP3(
-	ctx context.Context,
-	httpClient *http.Client,
-	transcoder MP3Transcoder,
-	streamURL string,
-	meta MP3TranscodeMetadata,
-	dst io.Writer,
-	requestHeaders http.Header,
-) (int64, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
-	if err != nil {
-		return 0, err
-	}
-	applyMediaRequestHeaders(req, requestHeaders, meta.VideoID)
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("download failed: status=%d", resp.StatusCode)
-	}
-	return transcoder.TranscodeToMP3(ctx, resp.Body, dst, meta)
-}
-
 func downloadURLToWriter(ctx context.Context, httpClient *http.Client, streamURL string, w io.Writer) (int64, error) {
 	return downloadURLToWriterWithConfigAndHeaders(ctx, httpClient, streamURL, w, DownloadTransportConfig{}, "", nil)
 }
@@ -495,7 +1012,12 @@ func downloadURLToWriterWithConfigAndHeaders(
 		if !isRetryableError(err, effectiveCfg) || attempt == effectiveCfg.MaxRetries {
 			return 0, err
 		}
-		if err := waitBackoff(ctx, effectiveCfg.backoffFor(attempt)); err != nil {
+		wait, ok := httpx.AllowRetry(ctx, effectiveCfg.retryWait(attempt, err))
+		if !ok {
+			return 0, err
+		}
+		noteDownloadRetry(ctx)
+		if err := waitBackoff(ctx, wait); err != nil {
 			return 0, err
 		}
 	}
@@ -525,9 +1047,9 @@ func downloadURLToWriterOnce(
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, &downloadHTTPStatusError{StatusCode: resp.StatusCode}
+		return 0, &downloadHTTPStatusError{StatusCode: resp.StatusCode, RetryAfter: httpx.ParseRetryAfter(resp.Header.Get("Retry-After"))}
 	}
-	return io.Copy(w, resp.Body)
+	return io.Copy(w, countDownloadBytes(ctx, resp.Body))
 }
 
 func downloadURLToPath(
@@ -552,6 +1074,9 @@ func downloadURLToPathWithHeaders(
 	requestHeaders http.Header,
 ) (int64, error) {
 	effectiveCfg := normalizeDownloadTransportConfig(cfg)
+	if isPipeOutput(outputPath) {
+		return downloadURLToPipe(ctx, httpClient, streamURL, outputPath, effectiveCfg, videoID, requestHeaders)
+	}
 	startOffset := int64(0)
 	if resume {
 		if st, err := os.Stat(outputPath); err == nil {
@@ -609,10 +1134,11 @@ func downloadURLRangeAppend(
 		return 0, err
 	}
 	defer file.Close()
+	w := trackOutputSize(ctx, trackSourceWatermark(ctx, trackOutputDigest(ctx, file, outputPath, startOffset), startOffset), startOffset)
 
 	var lastErr error
 	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
-		n, err := downloadRangeOnce(ctx, httpClient, streamURL, startOffset, file, videoID, requestHeaders)
+		n, err := downloadRangeOnce(ctx, httpClient, streamURL, startOffset, w, videoID, requestHeaders)
 		if err == nil {
 			return n, nil
 		}
@@ -623,7 +1149,12 @@ func downloadURLRangeAppend(
 		if !isRetryableError(err, cfg) || attempt == cfg.MaxRetries {
 			return 0, err
 		}
-		if err := waitBackoff(ctx, cfg.backoffFor(attempt)); err != nil {
+		wait, ok := httpx.AllowRetry(ctx, cfg.retryWait(attempt, err))
+		if !ok {
+			return 0, err
+		}
+		noteDownloadRetry(ctx)
+		if err := waitBackoff(ctx, wait); err != nil {
 			return 0, err
 		}
 	}
@@ -646,8 +1177,8 @@ func downloadRangeOnce(
 	if err != nil {
 		return 0, err
 	}
-	applyMediaRequestHeaders(req, requestHeaders, videoID)
 	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", startOffset))
+	applyMediaRequestHeaders(req, requestHeaders, videoID)
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -657,13 +1188,103 @@ func downloadRangeOnce(
 
 	switch resp.StatusCode {
 	case http.StatusPartialContent:
-		return io.Copy(w, resp.Body)
+		return io.Copy(w, countDownloadBytes(ctx, resp.Body))
 	case http.StatusRequestedRangeNotSatisfiable:
 		return 0, errRangeNotSatisfiable
 	case http.StatusOK:
 		return 0, errRangeNotSupported
 	default:
-		return 0, &downloadHTTPStatusError{StatusCode: resp.StatusCode}
+		return 0, &downloadHTTPStatusError{StatusCode: resp.StatusCode, RetryAfter: httpx.ParseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+}
+
+// resumingReader reads a direct media URL strictly in order, for outputs
+// that cannot be rewritten (sinks, pipes). A read that fails with a
+// retryable error reopens the URL from the current offset with a Range
+// request.
+type resumingReader struct {
+	ctx     context.Context
+	client  *http.Client
+	url     string
+	videoID string
+	headers http.Header
+	cfg     effectiveDownloadTransportConfig
+
+	body   io.ReadCloser
+	offset int64
+}
+
+func (s *resumingReader) Read(p []byte) (int, error) {
+	var lastErr error
+	for attempt := 0; attempt <= s.cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			wait, ok := httpx.AllowRetry(s.ctx, s.cfg.retryWait(attempt-1, lastErr))
+			if !ok {
+				return 0, lastErr
+			}
+			noteDownloadRetry(s.ctx)
+			if err := waitBackoff(s.ctx, wait); err != nil {
+				return 0, err
+			}
+		}
+		if s.body == nil {
+			if lastErr = s.open(); lastErr != nil {
+				if errors.Is(lastErr, errRangeNotSupported) || !isRetryableError(lastErr, s.cfg) {
+					return 0, lastErr
+				}
+				continue
+			}
+		}
+		n, err := s.body.Read(p)
+		s.offset += int64(n)
+		switch {
+		case err == nil || errors.Is(err, io.EOF):
+			return n, err
+		case n > 0:
+			// Hand over what arrived; the next Read reopens.
+			s.drop()
+			return n, nil
+		}
+		s.drop()
+		lastErr = err
+		if !isRetryableError(err, s.cfg) {
+			return 0, err
+		}
+	}
+	return 0, lastErr
+}
+
+func (s *resumingReader) open() error {
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return err
+	}
+	if s.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", s.offset))
+	}
+	applyMediaRequestHeaders(req, s.headers, s.videoID)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	switch {
+	case s.offset == 0 && resp.StatusCode == http.StatusOK,
+		s.offset > 0 && resp.StatusCode == http.StatusPartialContent:
+		s.body = resp.Body
+		return nil
+	case s.offset > 0 && resp.StatusCode == http.StatusOK:
+		resp.Body.Close()
+		return errRangeNotSupported
+	default:
+		resp.Body.Close()
+		return &downloadHTTPStatusError{StatusCode: resp.StatusCode, RetryAfter: httpx.ParseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+}
+
+func (s *resumingReader) drop() {
+	if s.body != nil {
+		s.body.Close()
+		s.body = nil
 	}
 }
 
@@ -676,12 +1297,13 @@ func downloadURLFullRewrite(
 	videoID string,
 	requestHeaders http.Header,
 ) (int64, error) {
+	publishSourceWatermark(ctx, 0)
 	file, err := os.Create(outputPath)
 	if err != nil {
 		return 0, err
 	}
 	defer file.Close()
-	return downloadURLToWriterWithConfigAndHeaders(ctx, httpClient, streamURL, file, DownloadTransportConfig{
+	return downloadURLToWriterWithConfigAndHeaders(ctx, httpClient, streamURL, trackOutputSize(ctx, trackSourceWatermark(ctx, trackOutputDigest(ctx, file, outputPath, 0), 0), 0), DownloadTransportConfig{
 		MaxRetries:       cfg.MaxRetries,
 		InitialBackoff:   cfg.InitialBackoff,
 		MaxBackoff:       cfg.MaxBackoff,
@@ -697,6 +1319,7 @@ type effectiveDownloadTransportConfig struct {
 	EnableChunked    bool
 	ChunkSize        int64
 	MaxConcurrency   int
+	MultiSource      bool
 }
 
 func normalizeDownloadTransportConfig(cfg DownloadTransportConfig) effectiveDownloadTransportConfig {
@@ -748,28 +1371,230 @@ func normalizeDownloadTransportConfig(cfg DownloadTransportConfig) effectiveDown
 		EnableChunked:    enableChunked,
 		ChunkSize:        chunkSize,
 		MaxConcurrency:   maxConcurrency,
+		MultiSource:      cfg.MultiSource,
 	}
 }
 
-func (c effectiveDownloadTransportConfig) backoffFor(attempt int) time.Duration {
-	backoff := c.InitialBackoff
-	for i := 0; i < attempt; i++ {
-		backoff *= 2
-		if backoff > c.MaxBackoff {
-			return c.MaxBackoff
-		}
+// retryWait returns the jittered backoff before retry attempt, or the
+// server's Retry-After when err carries a longer one.
+func (c effectiveDownloadTransportConfig) retryWait(attempt int, err error) time.Duration {
+	wait := httpx.Backoff(c.InitialBackoff, c.MaxBackoff, attempt)
+	var statusErr *downloadHTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > wait {
+		wait = statusErr.RetryAfter
 	}
-	return backoff
+	return wait
 }
 
 type downloadHTTPStatusError struct {
 	StatusCode int
+	RetryAfter time.Duration
 }
 
 func (e *downloadHTTPStatusError) Error() string {
 	return fmt.Sprintf("download failed: status=%d", e.StatusCode)
 }
 
+// downloadStats collects per-stream transfer counters. It is carried on the
+// context so the transfer loops below can update it without extra plumbing.
+type downloadStats struct {
+	retries atomic.Int64
+	// bytes counts bytes transferred, including bytes fetched again after
+	// a retry.
+	bytes atomic.Int64
+	// output is the end offset of the output written so far, resumed bytes
+	// included.
+	output atomic.Int64
+	// maxBytes aborts the transfer once output exceeds it; zero means no limit.
+	maxBytes int64
+
+	mu              sync.Mutex
+	discontinuities []DownloadDiscontinuity
+}
+
+type downloadStatsKey struct{}
+
+// maxFileSizeKey carries DownloadOptions.MaxFileSize to the transfer stats.
+type maxFileSizeKey struct{}
+
+// liveHintsKey carries the live segmentation of the video (see liveHints)
+// to HLS and DASH downloads.
+type liveHintsKey struct{}
+
+// liveHints turns the live stream settings of info into manifest refresh
+// fallbacks. Without a target duration the latency class sets the pace.
+func liveHints(info *VideoInfo) downloader.LiveHints {
+	hints := downloader.LiveHints{TargetDuration: info.LiveTargetDuration, Window: info.LiveDVRWindow}
+	if hints.TargetDuration == 0 {
+		switch info.LatencyClass {
+		case LatencyUltraLow:
+			hints.TargetDuration = time.Second
+		case LatencyLow:
+			hints.TargetDuration = 2 * time.Second
+		}
+	}
+	return hints
+}
+
+// liveOffsetKey carries DownloadOptions.LiveOffset to HLS downloads.
+type liveOffsetKey struct{}
+
+// mirrorURLsKey carries alternate sources of a direct stream to chunked
+// downloads (DownloadTransportConfig.MultiSource).
+type mirrorURLsKey struct{}
+
+func withDownloadStats(ctx context.Context) (context.Context, *downloadStats) {
+	stats := new(downloadStats)
+	stats.maxBytes, _ = ctx.Value(maxFileSizeKey{}).(int64)
+	return context.WithValue(ctx, downloadStatsKey{}, stats), stats
+}
+
+func noteDownloadRetry(ctx context.Context) {
+	if stats, ok := ctx.Value(downloadStatsKey{}).(*downloadStats); ok {
+		stats.retries.Add(1)
+	}
+}
+
+// noteDownloadDiscontinuities records manifest stream boundaries in the
+// context stats and reports each as a download event.
+func (c *Client) noteDownloadDiscontinuities(ctx context.Context, videoID, outputPath string, found []downloader.Discontinuity) {
+	if len(found) == 0 {
+		return
+	}
+	stats, _ := ctx.Value(downloadStatsKey{}).(*downloadStats)
+	for _, d := range found {
+		detail := fmt.Sprintf("reason=%s seq=%d offset=%d", d.Reason, d.Sequence, d.Offset)
+		if d.Period != "" {
+			detail += " period=" + d.Period
+		}
+		c.emitDownloadEvent("download", "discontinuity", videoID, outputPath, detail)
+		if stats != nil {
+			stats.mu.Lock()
+			stats.discontinuities = append(stats.discontinuities, DownloadDiscontinuity(d))
+			stats.mu.Unlock()
+		}
+	}
+}
+
+func (s *downloadStats) discontinuityList() []DownloadDiscontinuity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.discontinuities) == 0 {
+		return nil
+	}
+	return append([]DownloadDiscontinuity(nil), s.discontinuities...)
+}
+
+// countDownloadBytes wraps r so bytes read are added to the context stats
+// and held to the scheduler's bandwidth.
+func countDownloadBytes(ctx context.Context, r io.Reader) io.Reader {
+	r = limitDownloadRate(ctx, r)
+	stats, ok := ctx.Value(downloadStatsKey{}).(*downloadStats)
+	if !ok {
+		return r
+	}
+	return &countingReader{r: r, stats: stats}
+}
+
+// countDownloadWrites is countDownloadBytes for downloaders that only expose
+// the output writer (HLS/DASH), whose writes are both transfer and output.
+func countDownloadWrites(ctx context.Context, w io.Writer) io.Writer {
+	w = limitDownloadWriteRate(ctx, w)
+	stats, ok := ctx.Value(downloadStatsKey{}).(*downloadStats)
+	if !ok {
+		return w
+	}
+	return &countingWriter{w: w, stats: stats}
+}
+
+// trackOutputSize wraps w, which writes the output from offset on, so the
+// output end is tracked against the size limit.
+func trackOutputSize(ctx context.Context, w io.Writer, offset int64) io.Writer {
+	stats, ok := ctx.Value(downloadStatsKey{}).(*downloadStats)
+	if !ok {
+		return w
+	}
+	if err := stats.reach(offset); err != nil {
+		return errWriter{err}
+	}
+	return &outputSizeWriter{w: w, stats: stats, offset: offset}
+}
+
+// noteOutputEnd records an out-of-order output write ending at end.
+func noteOutputEnd(ctx context.Context, end int64) error {
+	if stats, ok := ctx.Value(downloadStatsKey{}).(*downloadStats); ok {
+		return stats.reach(end)
+	}
+	return nil
+}
+
+// add counts n transferred bytes.
+func (s *downloadStats) add(n int) {
+	s.bytes.Add(int64(n))
+}
+
+// reach records that the output extends to end and reports when that
+// passes the size limit.
+func (s *downloadStats) reach(end int64) error {
+	for {
+		cur := s.output.Load()
+		if end <= cur || s.output.CompareAndSwap(cur, end) {
+			break
+		}
+	}
+	if s.maxBytes > 0 && end > s.maxBytes {
+		return &MaxFileSizeError{Limit: s.maxBytes}
+	}
+	return nil
+}
+
+type countingReader struct {
+	r     io.Reader
+	stats *downloadStats
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.stats.add(n)
+	return n, err
+}
+
+type countingWriter struct {
+	w      io.Writer
+	stats  *downloadStats
+	offset int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.stats.add(n)
+	c.offset += int64(n)
+	if limitErr := c.stats.reach(c.offset); limitErr != nil {
+		return n, limitErr
+	}
+	return n, err
+}
+
+type outputSizeWriter struct {
+	w      io.Writer
+	stats  *downloadStats
+	offset int64
+}
+
+func (o *outputSizeWriter) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	o.offset += int64(n)
+	if limitErr := o.stats.reach(o.offset); limitErr != nil {
+		return n, limitErr
+	}
+	return n, err
+}
+
+// errWriter fails every write with err.
+type errWriter struct{ err error }
+
+func (e errWriter) Write([]byte) (int, error) { return 0, e.err }
+
 func waitBackoff(ctx context.Context, d time.Duration) error {
 	timer := time.NewTimer(d)
 	defer timer.Stop()
@@ -785,7 +1610,7 @@ func isRetryableError(err error, cfg effectiveDownloadTransportConfig) bool {
 	if err == nil {
 		return false
 	}
-	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrMaxFileSizeExceeded) || httpx.IsPermanent(err) {
 		return false
 	}
 	var statusErr *downloadHTTPStatusError
@@ -817,6 +1642,8 @@ func downloadURLChunked(
 		return 0, errChunkProbeFailed
 	}
 
+	publishSourceWatermark(ctx, 0)
+	forgetOutputDigest(ctx, outputPath)
 	file, err := os.Create(outputPath)
 	if err != nil {
 		return 0, err
@@ -826,46 +1653,96 @@ func downloadURLChunked(
 		return 0, err
 	}
 
+	sources := []string{streamURL}
+	if cfg.MultiSource {
+		mirrors, _ := ctx.Value(mirrorURLsKey{}).([]string)
+		for _, mirror := range mirrors {
+			if n, err := probeContentLengthWithRange(ctx, httpClient, mirror, videoID, requestHeaders); err == nil && n == total {
+				sources = append(sources, mirror)
+			}
+		}
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	chunks := buildChunks(total, cfg.ChunkSize)
-	sem := make(chan struct{}, cfg.MaxConcurrency)
+	completed := make([]bool, len(chunks))
+	var completedMu sync.Mutex
+	sched := newChunkScheduler(len(chunks), len(sources))
 	errCh := make(chan error, 1)
 	var wg sync.WaitGroup
 
-	for _, chunk := range chunks {
-		if ctx.Err() != nil {
-			break
-		}
-		chunk := chunk
+	workers := cfg.MaxConcurrency
+	if workers > len(chunks) {
+		workers = len(chunks)
+	}
+	for w := 0; w < workers; w++ {
+		w := w
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			select {
-			case sem <- struct{}{}:
-			case <-ctx.Done():
-				return
-			}
-			defer func() { <-sem }()
-
-			if err := downloadChunkWithRetry(ctx, httpClient, streamURL, file, chunk[0], chunk[1], cfg, videoID, requestHeaders); err != nil {
+			for {
+				i, src, ok := sched.next(ctx, w)
+				if !ok {
+					return
+				}
+				chunk := chunks[i]
+				err := downloadChunkWithRetry(ctx, httpClient, sources[src], file, chunk[0], chunk[1], cfg, videoID, requestHeaders)
+				if err == nil {
+					completedMu.Lock()
+					completed[i] = true
+					prefix := completedChunkPrefix(chunks, completed)
+					completedMu.Unlock()
+					publishSourceWatermark(ctx, prefix)
+					sched.done()
+					continue
+				}
+				if ctx.Err() == nil && sched.fail(i, src) {
+					// Another source takes the chunk over.
+					noteDownloadRetry(ctx)
+					continue
+				}
 				select {
 				case errCh <- err:
 				default:
 				}
 				cancel()
+				return
 			}
 		}()
 	}
 
 	wg.Wait()
+	var chunkErr error
 	select {
-	case err := <-errCh:
-		return 0, err
+	case chunkErr = <-errCh:
 	default:
-		return total, nil
 	}
+	if prefix := completedChunkPrefix(chunks, completed); prefix < total {
+		// Keep only the contiguous completed bytes so a resumed download
+		// (DownloadOptions.Resume) appends after them instead of treating the
+		// pre-sized file as complete.
+		_ = file.Truncate(prefix)
+		if chunkErr == nil {
+			chunkErr = context.Cause(ctx)
+		}
+		return 0, chunkErr
+	}
+	return total, nil
+}
+
+// completedChunkPrefix returns the byte length of the leading run of
+// completed chunks.
+func completedChunkPrefix(chunks [][2]int64, completed []bool) int64 {
+	var prefix int64
+	for i, chunk := range chunks {
+		if !completed[i] {
+			break
+		}
+		prefix = chunk[1] + 1
+	}
+	return prefix
 }
 
 func probeContentLengthWithRange(
@@ -875,34 +1752,17 @@ func probeContentLengthWithRange(
 	videoID string,
 	requestHeaders http.Header,
 ) (int64, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	probe, err := probeStreamURL(ctx, httpClient, streamURL, videoID, requestHeaders)
 	if err != nil {
 		return 0, err
 	}
-	applyMediaRequestHeaders(req, requestHeaders, videoID)
-	req.Header.Set("Range", "bytes=0-0")
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-	_, _ = io.Copy(io.Discard, resp.Body)
-
-	if resp.StatusCode != http.StatusPartialContent {
+	if !probe.AcceptsRanges {
 		return 0, errRangeNotSupported
 	}
-	cr := strings.TrimSpace(resp.Header.Get("Content-Range"))
-	// expected form: bytes 0-0/12345
-	slash := strings.LastIndex(cr, "/")
-	if slash < 0 || slash == len(cr)-1 {
-		return 0, errChunkProbeFailed
-	}
-	var total int64
-	if _, err := fmt.Sscanf(cr[slash+1:], "%d", &total); err != nil || total <= 0 {
+	if probe.ContentLength <= 0 {
 		return 0, errChunkProbeFailed
 	}
-	return total, nil
+	return probe.ContentLength, nil
 }
 
 func buildChunks(total, chunkSize int64) [][2]int64 {
@@ -941,7 +1801,12 @@ func downloadChunkWithRetry(
 		if !isRetryableError(err, cfg) || attempt == cfg.MaxRetries {
 			return err
 		}
-		if err := waitBackoff(ctx, cfg.backoffFor(attempt)); err != nil {
+		wait, ok := httpx.AllowRetry(ctx, cfg.retryWait(attempt, err))
+		if !ok {
+			return err
+		}
+		noteDownloadRetry(ctx)
+		if err := waitBackoff(ctx, wait); err != nil {
 			return err
 		}
 	}
@@ -962,8 +1827,8 @@ func downloadChunkOnce(
 	if err != nil {
 		return err
 	}
-	applyMediaRequestHeaders(req, requestHeaders, videoID)
 	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	applyMediaRequestHeaders(req, requestHeaders, videoID)
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -975,18 +1840,22 @@ func downloadChunkOnce(
 		if resp.StatusCode == http.StatusOK {
 			return errRangeNotSupported
 		}
-		return &downloadHTTPStatusError{StatusCode: resp.StatusCode}
+		return &downloadHTTPStatusError{StatusCode: resp.StatusCode, RetryAfter: httpx.ParseRetryAfter(resp.Header.Get("Retry-After"))}
 	}
 
+	body := countDownloadBytes(ctx, resp.Body)
 	buf := make([]byte, 32*1024)
 	offset := start
 	for {
-		n, readErr := resp.Body.Read(buf)
+		n, readErr := body.Read(buf)
 		if n > 0 {
 			if _, writeErr := file.WriteAt(buf[:n], offset); writeErr != nil {
 				return writeErr
 			}
 			offset += int64(n)
+			if err := noteOutputEnd(ctx, offset); err != nil {
+				return err
+			}
 		}
 		if readErr == io.EOF {
 			break
@@ -1041,29 +1910,7 @@ func renderOutputPathTemplate(template string, data outputTemplateData) string {
 }
 
 func sanitizeOutputToken(v string) string {
-	v = strings.TrimSpace(v)
-	if v == "" {
-		return "unknown"
-	}
-	var b strings.Builder
-	b.Grow(len(v))
-	for _, r := range v {
-		switch r {
-		case '<', '>', ':', '"', '/', '\\', '|', '?', '*':
-			b.WriteRune('_')
-		default:
-			if r < 32 {
-				b.WriteRune('_')
-				continue
-			}
-			b.WriteRune(r)
-		}
-	}
-	out := strings.TrimSpace(b.String())
-	if out == "" {
-		return "unknown"
-	}
-	return out
+	return SanitizeFileName(v, TextNormalization{})
 }
 
 func detectOutputExt(mimeType string, mode SelectionMode) string {
@@ -1082,7 +1929,7 @@ func detectOutputExt(mimeType string, mode SelectionMode) string {
 }
 
 func (c *Client) downloadHLS(ctx context.Context, videoID, streamURL, outputPath string, format FormatInfo) (*DownloadResult, error) {
-	headers := buildMediaRequestHeaders(c.config.RequestHeaders, videoID)
+	headers := buildMediaRequestHeaders(c.mediaHeaders(streamURL), videoID)
 	transport := downloader.TransportConfig{
 		MaxRetries:               c.config.DownloadTransport.MaxRetries,
 		InitialBackoff:           c.config.DownloadTransport.InitialBackoff,
@@ -1091,18 +1938,27 @@ func (c *Client) downloadHLS(ctx context.Context, videoID, streamURL, outputPath
 		MaxConcurrency:           c.config.DownloadTransport.MaxConcurrency,
 		SkipUnavailableFragments: c.config.DownloadTransport.SkipUnavailableFragments,
 		MaxSkippedFragments:      c.config.DownloadTransport.MaxSkippedFragments,
+		OnRetry:                  func() { noteDownloadRetry(ctx) },
 	}
 	dl := downloader.NewHLSDownloader(c.config.HTTPClient, streamURL).
 		WithRequestHeaders(headers).
 		WithTransportConfig(transport)
+	if offset, ok := ctx.Value(liveOffsetKey{}).(time.Duration); ok {
+		dl = dl.WithLiveOffset(offset)
+	}
+	if hints, ok := ctx.Value(liveHintsKey{}).(downloader.LiveHints); ok {
+		dl = dl.WithLiveHints(hints)
+	}
 
-	f, err := os.Create(outputPath)
+	f, err := createOutput(outputPath)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	if err := dl.Download(ctx, f); err != nil {
+	err = dl.Download(ctx, countDownloadWrites(ctx, trackOutputDigest(ctx, f, outputPath, 0)))
+	c.noteDownloadDiscontinuities(ctx, videoID, outputPath, dl.Discontinuities)
+	if err != nil {
 		return nil, err
 	}
 
@@ -1124,7 +1980,7 @@ func (c *Client) downloadHLS(ctx context.Context, videoID, streamURL, outputPath
 
 func (c *Client) downloadDASH(ctx context.Context, videoID, streamURL, outputPath string, format FormatInfo) (*DownloadResult, error) {
 	repID := fmt.Sprintf("%d", format.Itag)
-	headers := buildMediaRequestHeaders(c.config.RequestHeaders, videoID)
+	headers := buildMediaRequestHeaders(c.mediaHeaders(streamURL), videoID)
 	transport := downloader.TransportConfig{
 		MaxRetries:               c.config.DownloadTransport.MaxRetries,
 		InitialBackoff:           c.config.DownloadTransport.InitialBackoff,
@@ -1133,18 +1989,24 @@ func (c *Client) downloadDASH(ctx context.Context, videoID, streamURL, outputPat
 		MaxConcurrency:           c.config.DownloadTransport.MaxConcurrency,
 		SkipUnavailableFragments: c.config.DownloadTransport.SkipUnavailableFragments,
 		MaxSkippedFragments:      c.config.DownloadTransport.MaxSkippedFragments,
+		OnRetry:                  func() { noteDownloadRetry(ctx) },
 	}
 	dl := downloader.NewDASHDownloader(c.config.HTTPClient, streamURL, repID).
 		WithRequestHeaders(headers).
 		WithTransportConfig(transport)
+	if hints, ok := ctx.Value(liveHintsKey{}).(downloader.LiveHints); ok {
+		dl = dl.WithLiveHints(hints)
+	}
 
-	f, err := os.Create(outputPath)
+	f, err := createOutput(outputPath)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	if err := dl.Download(ctx, f); err != nil {
+	err = dl.Download(ctx, countDownloadWrites(ctx, trackOutputDigest(ctx, f, outputPath, 0)))
+	c.noteDownloadDiscontinuities(ctx, videoID, outputPath, dl.Discontinuities)
+	if err != nil {
 		return nil, err
 	}
 
@@ -1201,12 +2063,12 @@ func (c *Client) emitDownloadEvent(stage, phase, videoID, path, detail string) {
 	})
 }
 
-func wrapDownloadFailure(err error, attempt AttemptDetail) error {
+func wrapDownloadFailure(err error, attempts ...AttemptDetail) error {
 	if err == nil {
 		return nil
 	}
 	return errors.Join(err, &DownloadFailureDetailError{
-		Attempts: []AttemptDetail{attempt},
+		Attempts: append([]AttemptDetail(nil), attempts...),
 	})
 }
 
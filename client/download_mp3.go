@@ -0,0 +1,196 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+// downloadMP3 fetches the source stream through the regular transfer path
+// (retries, resume, chunking, throttle refresh, progress events) into an
+// intermediate file, and feeds the transcoder from that file while it is
+// still being written. Only the bytes in flight are held in memory.
+func (c *Client) downloadMP3(ctx context.Context, videoID, streamURL, outputPath string, f types.FormatInfo, options DownloadOptions) (*DownloadResult, error) {
+	sourcePath := intermediatePartPath(outputPath, f, "audio", "")
+	c.emitDownloadEvent("download", "start", videoID, outputPath, fmt.Sprintf("itag=%d transcode=mp3", f.Itag))
+	out, err := createOutput(outputPath)
+	if err != nil {
+		c.emitDownloadEvent("download", "failure", videoID, outputPath, err.Error())
+		return nil, err
+	}
+	defer out.Close()
+
+	mark := &sourceWatermark{}
+	mark.cond = sync.NewCond(&mark.mu)
+	fetchCtx, cancel := context.WithCancel(context.WithValue(ctx, sourceWatermarkKey{}, mark))
+	defer cancel()
+	var (
+		stream   DownloadStreamResult
+		attempts []AttemptDetail
+		fetchErr error
+	)
+	fetched := make(chan struct{})
+	go func() {
+		defer close(fetched)
+		stream, attempts, fetchErr = c.fetchStream(fetchCtx, videoID, streamURL, sourcePath, f, options.Resume)
+		mark.finish(getFileSize(sourcePath), fetchErr)
+	}()
+
+	src := &sourceTail{path: sourcePath, mark: mark}
+	n, transcodeErr := c.config.MP3Transcoder.TranscodeToMP3(ctx, src, trackOutputDigest(ctx, out, outputPath, 0), MP3TranscodeMetadata{
+		VideoID: videoID, SourceItag: f.Itag, SourceMimeType: f.MimeType,
+	})
+	src.close()
+	if transcodeErr != nil {
+		// The transfer is of no use once the transcoder gave up.
+		cancel()
+	}
+	<-fetched
+
+	// A transcoder failing on the source read error is a download failure.
+	if fetchErr != nil && (transcodeErr == nil || errors.Is(transcodeErr, fetchErr)) {
+		c.emitDownloadEvent("download", "failure", videoID, outputPath, formatDownloadFailureDetail(attempts[len(attempts)-1]))
+		return nil, wrapDownloadFailure(fetchErr, attempts...)
+	}
+	if transcodeErr != nil {
+		c.emitDownloadEvent("download", "failure", videoID, outputPath, transcodeErr.Error())
+		return nil, transcodeErr
+	}
+	c.emitDownloadEvent("download", "complete", videoID, outputPath, fmt.Sprintf("bytes=%d", n))
+	c.cleanupIntermediateFile(videoID, sourcePath, options.KeepIntermediateFiles || c.config.KeepIntermediateFiles)
+
+	// Transferred and speed describe the source; Bytes is the MP3 output.
+	stream.Path = outputPath
+	stream.Bytes = n
+	return &DownloadResult{
+		VideoID:         videoID,
+		Itag:            f.Itag,
+		OutputPath:      outputPath,
+		Bytes:           n,
+		SelectedFormats: []types.FormatInfo{f},
+		Streams:         []DownloadStreamResult{stream},
+	}, nil
+}
+
+// sourceWatermarkKey carries a *sourceWatermark to the transfer functions
+// writing a stream that is read while it downloads.
+type sourceWatermarkKey struct{}
+
+// sourceWatermark publishes how many leading bytes of a download's output
+// file are final. Sequential writers advance it as they write, chunked
+// downloads as their leading chunks complete; a transfer that restarts from
+// scratch lowers it again. Downloaders that publish nothing (HLS, DASH,
+// external) release the whole file at once when the transfer finishes.
+type sourceWatermark struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	n    int64
+	done bool
+	err  error
+}
+
+func (w *sourceWatermark) set(n int64) {
+	w.mu.Lock()
+	w.n = n
+	w.mu.Unlock()
+	w.cond.Broadcast()
+}
+
+// finish marks the transfer as over; on success size is the final length.
+func (w *sourceWatermark) finish(size int64, err error) {
+	w.mu.Lock()
+	if err == nil {
+		w.n = size
+	}
+	w.done = true
+	w.err = err
+	w.mu.Unlock()
+	w.cond.Broadcast()
+}
+
+// wait blocks until more than pos bytes are final or the transfer is over.
+func (w *sourceWatermark) wait(pos int64) (int64, bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for w.n <= pos && !w.done {
+		w.cond.Wait()
+	}
+	return w.n, w.done, w.err
+}
+
+// publishSourceWatermark sets the watermark carried by ctx, if any.
+func publishSourceWatermark(ctx context.Context, n int64) {
+	if mark, ok := ctx.Value(sourceWatermarkKey{}).(*sourceWatermark); ok {
+		mark.set(n)
+	}
+}
+
+// trackSourceWatermark wraps a sequential writer starting at offset so each
+// write advances the watermark carried by ctx.
+func trackSourceWatermark(ctx context.Context, w io.Writer, offset int64) io.Writer {
+	mark, ok := ctx.Value(sourceWatermarkKey{}).(*sourceWatermark)
+	if !ok {
+		return w
+	}
+	mark.set(offset)
+	return &watermarkWriter{w: w, mark: mark, offset: offset}
+}
+
+type watermarkWriter struct {
+	w      io.Writer
+	mark   *sourceWatermark
+	offset int64
+}
+
+func (w *watermarkWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.offset += int64(n)
+	w.mark.set(w.offset)
+	return n, err
+}
+
+// sourceTail reads a file that is being downloaded, up to its watermark,
+// waiting for more bytes until the transfer finishes. A failed transfer
+// surfaces as the read error.
+type sourceTail struct {
+	path string
+	mark *sourceWatermark
+	file *os.File
+	pos  int64
+}
+
+func (t *sourceTail) Read(p []byte) (int, error) {
+	avail, done, err := t.mark.wait(t.pos)
+	if t.pos >= avail {
+		if done && err != nil {
+			return 0, err
+		}
+		return 0, io.EOF
+	}
+	if t.file == nil {
+		if t.file, err = os.Open(t.path); err != nil {
+			return 0, err
+		}
+	}
+	p = p[:min(int64(len(p)), avail-t.pos)]
+	n, err := t.file.ReadAt(p, t.pos)
+	t.pos += int64(n)
+	if n > 0 {
+		return n, nil
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return 0, err
+}
+
+func (t *sourceTail) close() {
+	if t.file != nil {
+		t.file.Close()
+	}
+}
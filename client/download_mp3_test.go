@@ -6,9 +6,12 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type mp3TranscoderStub struct {
@@ -106,3 +109,99 @@ func TestDownload_MP3ModeWithTranscoder(t *testing.T) {
 		t.Fatalf("result bytes=%d, want %d", result.Bytes, len("mp3:source-audio"))
 	}
 }
+
+// gatedBody yields first, then waits for release before yielding rest.
+type gatedBody struct {
+	first, rest string
+	release     <-chan struct{}
+	step        int
+}
+
+func (b *gatedBody) Read(p []byte) (int, error) {
+	switch b.step {
+	case 0:
+		b.step++
+		return copy(p, b.first), nil
+	case 1:
+		select {
+		case <-b.release:
+		case <-time.After(2 * time.Second):
+			return 0, errors.New("transcoder did not read before the transfer finished")
+		}
+		b.step++
+		return copy(p, b.rest), nil
+	}
+	return 0, io.EOF
+}
+
+func (b *gatedBody) Close() error { return nil }
+
+func TestDownload_MP3TranscodesWhileDownloading(t *testing.T) {
+	released := make(chan struct{})
+	var mediaGets atomic.Int32
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		switch {
+		case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+			json := `{
+				"playabilityStatus":{"status":"OK"},
+				"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo","author":"jawed"},
+				"streamingData":{"adaptiveFormats":[{"itag":140,"url":"https://media.local/audio","mimeType":"audio/mp4","bitrate":128000}]}
+			}`
+			return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(bytes.NewBufferString(json))}, nil
+		case r.URL.Host == "media.local" && r.Header.Get("Range") != "":
+			return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(bytes.NewBufferString("x"))}, nil
+		case r.URL.Host == "media.local":
+			if mediaGets.Add(1) == 1 {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Header: make(http.Header), Body: io.NopCloser(bytes.NewBufferString(""))}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: &gatedBody{first: "head-", rest: "tail", release: released}}, nil
+		default:
+			return &http.Response{StatusCode: http.StatusNotFound, Header: make(http.Header), Body: io.NopCloser(bytes.NewBufferString(""))}, nil
+		}
+	})}
+
+	transcoder := mp3TranscoderFunc(func(ctx context.Context, src io.Reader, dst io.Writer, meta MP3TranscodeMetadata) (int64, error) {
+		head := make([]byte, len("head-"))
+		if _, err := io.ReadFull(src, head); err != nil {
+			return 0, err
+		}
+		close(released)
+		rest, err := io.ReadAll(src)
+		if err != nil {
+			return 0, err
+		}
+		n, err := dst.Write([]byte("mp3:" + string(head) + string(rest)))
+		return int64(n), err
+	})
+	c := New(Config{
+		HTTPClient:        httpClient,
+		ClientOverrides:   []string{"mweb"},
+		MP3Transcoder:     transcoder,
+		DownloadTransport: DownloadTransportConfig{MaxRetries: 1, InitialBackoff: time.Millisecond},
+	})
+
+	outPath := filepath.Join(t.TempDir(), "out.mp3")
+	result, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{Mode: SelectionModeMP3, OutputPath: outPath})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	data, _ := os.ReadFile(outPath)
+	if string(data) != "mp3:head-tail" || result.Bytes != int64(len(data)) {
+		t.Fatalf("output=%q bytes=%d", data, result.Bytes)
+	}
+	if got := mediaGets.Load(); got != 2 {
+		t.Fatalf("media requests=%d, want 2 (503 then retry)", got)
+	}
+	if result.Streams[0].Retries != 1 || result.Streams[0].Transferred != int64(len("head-tail")) {
+		t.Fatalf("stream=%+v", result.Streams[0])
+	}
+	if _, err := os.Stat(outPath + ".f140.audio"); !os.IsNotExist(err) {
+		t.Fatalf("source intermediate not removed: %v", err)
+	}
+}
+
+type mp3TranscoderFunc func(ctx context.Context, src io.Reader, dst io.Writer, meta MP3TranscodeMetadata) (int64, error)
+
+func (f mp3TranscoderFunc) TranscodeToMP3(ctx context.Context, src io.Reader, dst io.Writer, meta MP3TranscodeMetadata) (int64, error) {
+	return f(ctx, src, dst, meta)
+}
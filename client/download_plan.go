@@ -0,0 +1,209 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/famomatic/ytv1/internal/selector"
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+// downloadPlanStep is one selection Download may fetch. Steps after the
+// first are only tried when an earlier one failed with an error After
+// accepts.
+type downloadPlanStep struct {
+	Formats []types.FormatInfo
+	// Reason is reported as DownloadResult.FallbackReason when the step
+	// succeeds; empty for the requested selection.
+	Reason string
+	After  func(error) bool
+}
+
+func (s downloadPlanStep) itagLabel() string {
+	itags := make([]string, 0, len(s.Formats))
+	for _, f := range s.Formats {
+		itags = append(itags, strconv.Itoa(f.Itag))
+	}
+	return strings.Join(itags, "+")
+}
+
+// downloadPlan builds the ordered selections Download walks:
+//  1. the selected formats (or the best single file when they need a muxer
+//     that is unavailable),
+//  2. the selection of every other matching "/" alternative of the selector,
+//     tried after URL-resolution or HTTP failures,
+//  3. progressive (video+audio) formats, non-ciphered first, tried after an
+//     unsolved challenge unless StrictChallenges is set.
+//
+// Steps 1 and 2 are each followed by their variants with one format swapped
+// for a deduplicated copy of the same stream ("duplicate_source"). An
+// explicit Itag gets no fallbacks beyond those copies. Selections needing a
+// muxer are left out when none is available, and duplicate selections
+// appear once.
+func (c *Client) downloadPlan(videoID string, formats, selected []types.FormatInfo, options DownloadOptions) ([]downloadPlanStep, error) {
+	canMerge := c.config.Muxer != nil && c.config.Muxer.Available()
+	var steps []downloadPlanStep
+	seen := make(map[string]struct{})
+	add := func(step downloadPlanStep) {
+		if len(step.Formats) == 0 || (len(step.Formats) > 1 && !canMerge) {
+			return
+		}
+		key := selectionPlanKey(step.Formats)
+		if _, dup := seen[key]; dup {
+			return
+		}
+		seen[key] = struct{}{}
+		steps = append(steps, step)
+	}
+	addWithDuplicates := func(step downloadPlanStep) {
+		add(step)
+		for i, f := range step.Formats {
+			for _, dup := range c.formatDuplicates(videoID, f) {
+				swapped := append([]types.FormatInfo(nil), step.Formats...)
+				swapped[i] = dup
+				add(downloadPlanStep{Formats: swapped, Reason: "duplicate_source", After: c.isSelectionFailure})
+			}
+		}
+	}
+
+	if len(selected) > 1 && !canMerge {
+		c.logger.Warnf("Muxer unavailable, falling back to best single file")
+		best := selector.SelectBest(formats)
+		if len(best) == 0 {
+			return nil, errors.New("no formats found (and muxer unavailable)")
+		}
+		addWithDuplicates(downloadPlanStep{Formats: best, Reason: "muxer_unavailable"})
+	} else {
+		addWithDuplicates(downloadPlanStep{Formats: selected})
+	}
+	if options.Itag > 0 {
+		return steps, nil
+	}
+
+	if sel, err := selector.Parse(downloadSelectorString(options)); err == nil {
+		for _, alt := range selector.Plan(formats, sel) {
+			addWithDuplicates(downloadPlanStep{Formats: alt.Formats, Reason: "selector_fallback", After: c.isSelectionFailure})
+		}
+	}
+
+	if !c.config.StrictChallenges {
+		var progressive, plain []types.FormatInfo
+		for _, f := range formats {
+			if f.HasVideo && f.HasAudio {
+				progressive = append(progressive, f)
+				if !f.Ciphered {
+					plain = append(plain, f)
+				}
+			}
+		}
+		if len(plain) > 0 {
+			progressive = plain
+		}
+		for _, f := range progressive {
+			add(downloadPlanStep{
+				Formats: []types.FormatInfo{f},
+				Reason:  "challenge_not_solved",
+				After:   func(err error) bool { return errors.Is(err, ErrChallengeNotSolved) },
+			})
+		}
+	}
+	return steps, nil
+}
+
+// isSelectionFailure reports whether err means a selection could not be
+// resolved or fetched, so a different selection may still succeed.
+func (c *Client) isSelectionFailure(err error) bool {
+	if errors.Is(err, ErrChallengeNotSolved) {
+		return !c.config.StrictChallenges
+	}
+	if errors.Is(err, ErrDownloadTooSlow) {
+		return true
+	}
+	var statusErr *downloadHTTPStatusError
+	return errors.As(err, &statusErr)
+}
+
+// runDownloadPlan fetches the first plan step and, when it fails, the next
+// step that accepts the failure and uses none of the formats blamed so far.
+func (c *Client) runDownloadPlan(ctx context.Context, videoID string, info *VideoInfo, plan []downloadPlanStep, options DownloadOptions, meta types.Metadata) (*DownloadResult, error) {
+	failed := make(map[string]struct{})
+	var lastErr error
+	for i, step := range plan {
+		if i > 0 {
+			if step.After == nil || !step.After(lastErr) || usesFailedFormat(step, failed) {
+				continue
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			c.warnf("selection failed (%v); falling back to itags=%s reason=%s", lastErr, step.itagLabel(), step.Reason)
+			c.emitDownloadEvent("download", "fallback", videoID, "", fmt.Sprintf("itags=%s reason=%s", step.itagLabel(), step.Reason))
+		}
+
+		var res *DownloadResult
+		var err error
+		if len(step.Formats) == 1 {
+			res, err = c.downloadSingle(ctx, videoID, meta.Title, meta.Artist, step.Formats[0], options.OutputPath, options)
+		} else {
+			res, err = c.downloadAndMerge(ctx, videoID, step.Formats, options, meta)
+		}
+		if err == nil {
+			if res != nil && res.FallbackReason == "" {
+				res.FallbackReason = step.Reason
+			}
+			return res, nil
+		}
+		lastErr = err
+		for _, f := range blamedFormats(step, err) {
+			failed[formatCheckKey(f)] = struct{}{}
+		}
+	}
+	return nil, lastErr
+}
+
+// blamedFormats returns the formats of step that err names by itag in its
+// attempt details, or every format of step when it names none.
+func blamedFormats(step downloadPlanStep, err error) []types.FormatInfo {
+	var attempts []AttemptDetail
+	var challenge *ChallengeNotSolvedDetailError
+	if errors.As(err, &challenge) {
+		attempts = append(attempts, challenge.Attempts...)
+	}
+	var failure *DownloadFailureDetailError
+	if errors.As(err, &failure) {
+		attempts = append(attempts, failure.Attempts...)
+	}
+	var blamed []types.FormatInfo
+	for _, f := range step.Formats {
+		for _, a := range attempts {
+			if a.Itag == f.Itag {
+				blamed = append(blamed, f)
+				break
+			}
+		}
+	}
+	if len(blamed) == 0 {
+		return step.Formats
+	}
+	return blamed
+}
+
+func usesFailedFormat(step downloadPlanStep, failed map[string]struct{}) bool {
+	for _, f := range step.Formats {
+		if _, ok := failed[formatCheckKey(f)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func selectionPlanKey(formats []types.FormatInfo) string {
+	keys := make([]string, 0, len(formats))
+	for _, f := range formats {
+		keys = append(keys, formatCheckKey(f))
+	}
+	return strings.Join(keys, "+")
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+func planItags(plan []downloadPlanStep) []string {
+	var out []string
+	for _, step := range plan {
+		out = append(out, step.itagLabel()+":"+step.Reason)
+	}
+	return out
+}
+
+func TestDownloadPlan_OrdersSelectorAndChallengeFallbacks(t *testing.T) {
+	formats := []types.FormatInfo{
+		{Itag: 137, HasVideo: true, Height: 1080, Bitrate: 4000, MimeType: "video/mp4", URL: "v137"},
+		{Itag: 140, HasAudio: true, Bitrate: 128, MimeType: "audio/mp4", URL: "a140"},
+		{Itag: 22, HasVideo: true, HasAudio: true, Height: 720, Bitrate: 2000, MimeType: "video/mp4", URL: "p22", Ciphered: true},
+		{Itag: 18, HasVideo: true, HasAudio: true, Height: 360, Bitrate: 500, MimeType: "video/mp4", URL: "p18"},
+	}
+	selected := formats[:2]
+
+	c := New(Config{Muxer: testMuxer{}})
+	plan, err := c.downloadPlan("jNQXAC9IVRw", formats, selected, DownloadOptions{FormatSelector: "bv+ba/best[height<=720]/best"})
+	if err != nil {
+		t.Fatalf("downloadPlan() error = %v", err)
+	}
+	want := []string{"137+140:", "22:selector_fallback", "18:challenge_not_solved"}
+	if got := planItags(plan); !reflect.DeepEqual(got, want) {
+		t.Fatalf("plan = %q, want %q", got, want)
+	}
+	if plan[1].After(errors.New("disk full")) || !plan[1].After(&downloadHTTPStatusError{StatusCode: http.StatusForbidden}) {
+		t.Fatal("selector fallback should follow HTTP failures only")
+	}
+
+	strict := New(Config{Muxer: testMuxer{}, StrictChallenges: true})
+	plan, _ = strict.downloadPlan("jNQXAC9IVRw", formats, selected, DownloadOptions{FormatSelector: "bv+ba/best[height<=720]/best"})
+	if got := planItags(plan); !reflect.DeepEqual(got, []string{"137+140:", "22:selector_fallback"}) {
+		t.Fatalf("strict plan = %q", got)
+	}
+	if plan[1].After(ErrChallengeNotSolved) {
+		t.Fatal("strict challenges should not fall back on unsolved challenges")
+	}
+
+	plan, _ = c.downloadPlan("jNQXAC9IVRw", formats, formats[3:], DownloadOptions{Itag: 18})
+	if got := planItags(plan); !reflect.DeepEqual(got, []string{"18:"}) {
+		t.Fatalf("explicit itag plan = %q", got)
+	}
+}
+
+func TestDownload_FallsBackToNextSelectorAlternativeOnForbidden(t *testing.T) {
+	var events []DownloadEvent
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		switch {
+		case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+			body := `{
+				"playabilityStatus":{"status":"OK"},
+				"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+				"streamingData":{
+					"formats":[{"itag":18,"url":"https://media.example/p18.mp4","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}],
+					"adaptiveFormats":[
+						{"itag":137,"url":"https://media.example/v137.mp4","mimeType":"video/mp4; codecs=\"avc1\"","width":1920,"height":1080,"bitrate":4000000},
+						{"itag":140,"url":"https://media.example/a140.m4a","mimeType":"audio/mp4; codecs=\"mp4a\"","bitrate":128000}
+					]
+				}
+			}`
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+		case r.URL.Path == "/v137.mp4":
+			return &http.Response{StatusCode: http.StatusForbidden, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+		case r.URL.Host == "media.example":
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("media")), Header: make(http.Header)}, nil
+		default:
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+		}
+	})}
+	c := New(Config{
+		HTTPClient:                 httpClient,
+		ClientOverrides:            []string{"mweb"},
+		Muxer:                      testMuxer{},
+		DisableDownloadClientRetry: true,
+		OnDownloadEvent:            func(evt DownloadEvent) { events = append(events, evt) },
+	})
+
+	res, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{
+		FormatSelector: "bv+ba/best",
+		OutputPath:     filepath.Join(t.TempDir(), "out.mp4"),
+	})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if res.Itag != 18 || res.FallbackReason != "selector_fallback" {
+		t.Fatalf("result itag=%d reason=%q, want 18 selector_fallback", res.Itag, res.FallbackReason)
+	}
+	var fallback bool
+	for _, evt := range events {
+		if evt.Phase == "fallback" && evt.Detail == "itags=18 reason=selector_fallback" {
+			fallback = true
+		}
+	}
+	if !fallback {
+		t.Fatalf("no fallback event in %+v", events)
+	}
+}
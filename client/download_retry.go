@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/famomatic/ytv1/internal/formats"
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+var defaultDownloadRetryClients = []string{"ios", "android_vr", "tv"}
+
+// fetchStream downloads one resolved stream and reports what was fetched.
+// When a direct download is rejected with 403, the same itag is re-extracted
+// through alternate Innertube clients before giving up. The returned attempts
+// describe every failed try (one per client), oldest first.
+func (c *Client) fetchStream(
+	ctx context.Context,
+	videoID string,
+	streamURL string,
+	outputPath string,
+	f types.FormatInfo,
+	resume bool,
+) (DownloadStreamResult, []AttemptDetail, error) {
+	stream, err := c.fetchStreamWithThrottleRefresh(ctx, videoID, streamURL, outputPath, f, resume)
+	if err == nil {
+		return stream, nil, nil
+	}
+	attempts := []AttemptDetail{downloadAttemptFromFormatAndURL(f, streamURL, err)}
+	if !c.shouldRetryDownloadWithClient(f, err) || c.isExtractedVideo(videoID) {
+		return stream, attempts, err
+	}
+	if stream.Transferred > 0 && isPipeOutput(outputPath) {
+		// Another client's stream would start over in a pipe that already
+		// holds the first bytes.
+		return stream, attempts, err
+	}
+
+	failedClient := f.SourceClient
+	for _, clientName := range c.downloadRetryClients(f.SourceClient) {
+		alt, altURL, resolveErr := c.reextractFormatURL(ctx, videoID, f, clientName)
+		if resolveErr != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return stream, attempts, errors.Join(err, ctxErr)
+			}
+			c.warnf("download client retry skipped: itag=%d client=%s reason=%v", f.Itag, clientName, resolveErr)
+			continue
+		}
+		c.warnf("download returned 403; retrying itag=%d via client=%s", f.Itag, clientName)
+		c.emitDownloadEvent("download", "retry", videoID, outputPath, fmt.Sprintf("itag=%d client=%s previous_client=%s", f.Itag, clientName, failedClient))
+
+		altStream, altErr := c.fetchStreamWithThrottleRefresh(ctx, videoID, altURL, outputPath, alt, false)
+		altStream.addPriorAttempt(stream)
+		if altErr == nil {
+			return altStream, attempts, nil
+		}
+		attempt := downloadAttemptFromFormatAndURL(alt, altURL, altErr)
+		attempt.SwitchedFromClient = failedClient
+		attempts = append(attempts, attempt)
+		stream, err = altStream, altErr
+		failedClient = alt.SourceClient
+		if !isDownloadForbidden(altErr) {
+			break
+		}
+	}
+	return stream, attempts, err
+}
+
+func (c *Client) shouldRetryDownloadWithClient(f types.FormatInfo, err error) bool {
+	if c.config.DisableDownloadClientRetry || !isDownloadForbidden(err) {
+		return false
+	}
+	// Manifest-backed formats fail per fragment; only direct URLs are re-extracted.
+	return f.Protocol != "hls" && f.Protocol != "dash"
+}
+
+func isDownloadForbidden(err error) bool {
+	var statusErr *downloadHTTPStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusForbidden
+}
+
+// downloadRetryClients returns the configured retry clients minus the client
+// that produced the failing URL and any skipped clients.
+func (c *Client) downloadRetryClients(sourceClient string) []string {
+	candidates := c.config.DownloadRetryClients
+	if len(candidates) == 0 {
+		candidates = defaultDownloadRetryClients
+	}
+	skip := map[string]struct{}{strings.ToLower(strings.TrimSpace(sourceClient)): {}}
+	for _, name := range c.config.ClientSkip {
+		skip[strings.ToLower(strings.TrimSpace(name))] = struct{}{}
+	}
+	out := make([]string, 0, len(candidates))
+	for _, name := range candidates {
+		normalized := strings.ToLower(strings.TrimSpace(name))
+		if normalized == "" {
+			continue
+		}
+		if _, skipped := skip[normalized]; skipped {
+			continue
+		}
+		skip[normalized] = struct{}{}
+		out = append(out, normalized)
+	}
+	return out
+}
+
+// reextractFormatURL fetches a fresh player response from clientName and
+// resolves the direct URL for the same itag (and audio track language).
+func (c *Client) reextractFormatURL(ctx context.Context, videoID string, f types.FormatInfo, clientName string) (types.FormatInfo, string, error) {
+	resp, err := c.engine.GetVideoInfoWithClients(ctx, videoID, []string{clientName})
+	if err != nil {
+		return types.FormatInfo{}, "", mapError(err)
+	}
+	for _, parsed := range formats.Parse(resp) {
+		alt := toFormatInfo(parsed)
+		if alt.Itag != f.Itag || alt.Language != f.Language {
+			continue
+		}
+		if strings.TrimSpace(alt.URL) == "" {
+			return types.FormatInfo{}, "", fmt.Errorf("itag %d is ciphered for client %s", f.Itag, clientName)
+		}
+		playerURL := ""
+		if session, ok := c.getSession(videoID); ok {
+			if hasQueryParam(alt.URL, "n") {
+				if updated, fetchErr := c.ensureSessionPlayerURL(ctx, videoID, session); fetchErr == nil {
+					session = updated
+				}
+			}
+			playerURL = session.PlayerURL
+		}
+		altURL, err := c.resolveDirectURL(ctx, alt.URL, playerURL, alt.SourceClient, protocolFromFormat(alt))
+		if err != nil {
+			return types.FormatInfo{}, "", err
+		}
+		return alt, altURL, nil
+	}
+	return types.FormatInfo{}, "", fmt.Errorf("itag %d not offered by client %s", f.Itag, clientName)
+}
@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownload_ForbiddenRetriesViaAlternateClient(t *testing.T) {
+	videoID := "jNQXAC9IVRw"
+	mediaBase := "https://media.example"
+	var events []DownloadEvent
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				raw, _ := io.ReadAll(r.Body)
+				path := "/mweb.mp4"
+				if strings.Contains(string(raw), `"IOS"`) {
+					path = "/ios.mp4"
+				}
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+					"streamingData":{"formats":[
+						{"itag":18,"url":"` + mediaBase + path + `","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.Path == "/watch":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`<html><script src="/s/player/test/base.js"></script></html>`)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/mweb.mp4":
+				return &http.Response{StatusCode: http.StatusForbidden, Body: io.NopCloser(strings.NewReader("denied")), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/ios.mp4":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("payload")), Header: make(http.Header)}, nil
+			default:
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+			}
+		}),
+	}
+
+	c := New(Config{
+		HTTPClient:           httpClient,
+		ClientOverrides:      []string{"mweb"},
+		DownloadRetryClients: []string{"mweb", "ios"},
+		OnDownloadEvent:      func(evt DownloadEvent) { events = append(events, evt) },
+	})
+	out := filepath.Join(t.TempDir(), "out.mp4")
+	res, err := c.Download(context.Background(), videoID, DownloadOptions{Itag: 18, OutputPath: out})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil || string(data) != "payload" {
+		t.Fatalf("output=%q err=%v", data, err)
+	}
+	if len(res.Streams) != 1 || res.Streams[0].Client != "ios" {
+		t.Fatalf("streams=%+v, want client ios", res.Streams)
+	}
+	var sawRetry bool
+	for _, evt := range events {
+		if evt.Stage == "download" && evt.Phase == "retry" && strings.Contains(evt.Detail, "client=ios") {
+			sawRetry = true
+		}
+	}
+	if !sawRetry {
+		t.Fatalf("expected download retry event, got=%v", events)
+	}
+}
+
+func TestDownload_ForbiddenRecordsClientSwitchAttempts(t *testing.T) {
+	videoID := "jNQXAC9IVRw"
+	mediaBase := "https://media.example"
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+					"streamingData":{"formats":[
+						{"itag":18,"url":"` + mediaBase + `/blocked.mp4","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.Path == "/watch":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`<html><script src="/s/player/test/base.js"></script></html>`)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/blocked.mp4":
+				return &http.Response{StatusCode: http.StatusForbidden, Body: io.NopCloser(strings.NewReader("denied")), Header: make(http.Header)}, nil
+			default:
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+			}
+		}),
+	}
+
+	c := New(Config{
+		HTTPClient:           httpClient,
+		ClientOverrides:      []string{"mweb"},
+		DownloadRetryClients: []string{"ios"},
+	})
+	_, err := c.Download(context.Background(), videoID, DownloadOptions{Itag: 18, OutputPath: filepath.Join(t.TempDir(), "out.mp4")})
+	if err == nil {
+		t.Fatal("expected download failure")
+	}
+	var detail *DownloadFailureDetailError
+	if !errors.As(err, &detail) {
+		t.Fatalf("expected DownloadFailureDetailError, got %v", err)
+	}
+	if len(detail.Attempts) != 2 {
+		t.Fatalf("attempts=%+v, want 2", detail.Attempts)
+	}
+	if detail.Attempts[0].Client != "mweb" || detail.Attempts[0].HTTPStatus != http.StatusForbidden {
+		t.Fatalf("first attempt=%+v", detail.Attempts[0])
+	}
+	if detail.Attempts[1].Client != "ios" || detail.Attempts[1].SwitchedFromClient != "mweb" {
+		t.Fatalf("second attempt=%+v", detail.Attempts[1])
+	}
+}
+
+func TestDownloadRetryWait_HonorsRetryAfter(t *testing.T) {
+	cfg := normalizeDownloadTransportConfig(DownloadTransportConfig{InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond})
+	if got := cfg.retryWait(0, &downloadHTTPStatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 5 * time.Second}); got != 5*time.Second {
+		t.Fatalf("retryWait() = %v, want Retry-After 5s", got)
+	}
+	if got := cfg.retryWait(3, errors.New("reset")); got > 8*time.Millisecond {
+		t.Fatalf("retryWait() = %v, want jittered backoff up to 8ms", got)
+	}
+}
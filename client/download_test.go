@@ -3,6 +3,7 @@ package client
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -15,7 +16,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/famomatic/ytv1/internal/downloader"
+	"github.com/famomatic/ytv1/internal/innertube"
 	"github.com/famomatic/ytv1/internal/types"
+	"github.com/famomatic/ytv1/mediatest"
 )
 
 func TestDownloadURLToWriter_OK(t *testing.T) {
@@ -82,14 +86,7 @@ func TestDownloadURLToWriter_RetryOnTransientStatus(t *testing.T) {
 }
 
 func TestDownloadURLToPath_ResumeAppend(t *testing.T) {
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if got := r.Header.Get("Range"); got != "bytes=3-" {
-			t.Fatalf("range header=%q, want %q", got, "bytes=3-")
-		}
-		w.Header().Set("Content-Range", "bytes 3-5/6")
-		w.WriteHeader(http.StatusPartialContent)
-		_, _ = io.WriteString(w, "def")
-	}))
+	srv := mediatest.NewServer(mediatest.Options{Payload: []byte("abcdef")})
 	defer srv.Close()
 
 	out := filepath.Join(t.TempDir(), "resume.bin")
@@ -97,7 +94,7 @@ func TestDownloadURLToPath_ResumeAppend(t *testing.T) {
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	n, err := downloadURLToPath(context.Background(), srv.Client(), srv.URL, out, true, DownloadTransportConfig{
+	n, err := downloadURLToPath(context.Background(), srv.Client(), srv.MediaURL(), out, true, DownloadTransportConfig{
 		MaxRetries:     0,
 		InitialBackoff: time.Millisecond,
 		MaxBackoff:     time.Millisecond,
@@ -105,6 +102,9 @@ func TestDownloadURLToPath_ResumeAppend(t *testing.T) {
 	if err != nil {
 		t.Fatalf("downloadURLToPath() error = %v", err)
 	}
+	if got := strings.Join(srv.Ranges(), ","); got != "bytes=3-" {
+		t.Fatalf("range headers=%q, want %q", got, "bytes=3-")
+	}
 	if n != 6 {
 		t.Fatalf("downloadURLToPath() bytes=%d, want 6", n)
 	}
@@ -118,15 +118,7 @@ func TestDownloadURLToPath_ResumeAppend(t *testing.T) {
 }
 
 func TestDownloadURLToPath_ResumeFallbackToFull(t *testing.T) {
-	var sawRange bool
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if strings.TrimSpace(r.Header.Get("Range")) != "" {
-			sawRange = true
-			_, _ = io.WriteString(w, "full-data")
-			return
-		}
-		_, _ = io.WriteString(w, "full-data")
-	}))
+	srv := mediatest.NewServer(mediatest.Options{Payload: []byte("full-data"), IgnoreRange: true})
 	defer srv.Close()
 
 	out := filepath.Join(t.TempDir(), "resume-fallback.bin")
@@ -134,7 +126,7 @@ func TestDownloadURLToPath_ResumeFallbackToFull(t *testing.T) {
 		t.Fatalf("WriteFile() error = %v", err)
 	}
 
-	n, err := downloadURLToPath(context.Background(), srv.Client(), srv.URL, out, true, DownloadTransportConfig{
+	n, err := downloadURLToPath(context.Background(), srv.Client(), srv.MediaURL(), out, true, DownloadTransportConfig{
 		MaxRetries:     0,
 		InitialBackoff: time.Millisecond,
 		MaxBackoff:     time.Millisecond,
@@ -142,7 +134,7 @@ func TestDownloadURLToPath_ResumeFallbackToFull(t *testing.T) {
 	if err != nil {
 		t.Fatalf("downloadURLToPath() error = %v", err)
 	}
-	if !sawRange {
+	if ranges := srv.Ranges(); len(ranges) == 0 || ranges[0] == "" {
 		t.Fatal("expected initial resume range attempt")
 	}
 	if n != int64(len("full-data")) {
@@ -159,32 +151,11 @@ func TestDownloadURLToPath_ResumeFallbackToFull(t *testing.T) {
 
 func TestDownloadURLToPath_Chunked(t *testing.T) {
 	payload := []byte(strings.Repeat("chunk-data-", 512))
-	var rangeCalls int32
-
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rangeHeader := r.Header.Get("Range")
-		if rangeHeader == "" {
-			http.Error(w, "range required", http.StatusBadRequest)
-			return
-		}
-		var start, end int
-		if _, err := fmt.Sscanf(rangeHeader, "bytes=%d-%d", &start, &end); err != nil {
-			http.Error(w, "bad range", http.StatusBadRequest)
-			return
-		}
-		if start < 0 || end < start || end >= len(payload) {
-			http.Error(w, "invalid range", http.StatusRequestedRangeNotSatisfiable)
-			return
-		}
-		atomic.AddInt32(&rangeCalls, 1)
-		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(payload)))
-		w.WriteHeader(http.StatusPartialContent)
-		_, _ = w.Write(payload[start : end+1])
-	}))
+	srv := mediatest.NewServer(mediatest.Options{Payload: payload, RequireRange: true})
 	defer srv.Close()
 
 	out := filepath.Join(t.TempDir(), "chunked.bin")
-	n, err := downloadURLToPath(context.Background(), srv.Client(), srv.URL, out, false, DownloadTransportConfig{
+	n, err := downloadURLToPath(context.Background(), srv.Client(), srv.MediaURL(), out, false, DownloadTransportConfig{
 		EnableChunked:  true,
 		ChunkSize:      1024,
 		MaxConcurrency: 4,
@@ -205,36 +176,21 @@ func TestDownloadURLToPath_Chunked(t *testing.T) {
 	if !bytes.Equal(body, payload) {
 		t.Fatal("chunked output mismatch")
 	}
-	if atomic.LoadInt32(&rangeCalls) <= 1 {
-		t.Fatalf("expected multiple range calls, got %d", atomic.LoadInt32(&rangeCalls))
+	if calls := len(srv.Requests()); calls <= 2 {
+		t.Fatalf("expected multiple range calls after the probe, got %d", calls)
 	}
 }
 
 func TestDownloadURLToPath_ChunkedCancel(t *testing.T) {
 	payload := []byte(strings.Repeat("x", 1024*64))
-	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rangeHeader := r.Header.Get("Range")
-		if rangeHeader == "" {
-			http.Error(w, "range required", http.StatusBadRequest)
-			return
-		}
-		var start, end int
-		if _, err := fmt.Sscanf(rangeHeader, "bytes=%d-%d", &start, &end); err != nil {
-			http.Error(w, "bad range", http.StatusBadRequest)
-			return
-		}
-		time.Sleep(50 * time.Millisecond)
-		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(payload)))
-		w.WriteHeader(http.StatusPartialContent)
-		_, _ = w.Write(payload[start : end+1])
-	}))
+	srv := mediatest.NewServer(mediatest.Options{Payload: payload, RequireRange: true, Latency: 50 * time.Millisecond})
 	defer srv.Close()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
 	defer cancel()
 
 	out := filepath.Join(t.TempDir(), "chunked-cancel.bin")
-	_, err := downloadURLToPath(ctx, srv.Client(), srv.URL, out, false, DownloadTransportConfig{
+	_, err := downloadURLToPath(ctx, srv.Client(), srv.MediaURL(), out, false, DownloadTransportConfig{
 		EnableChunked:  true,
 		ChunkSize:      1024,
 		MaxConcurrency: 4,
@@ -246,6 +202,10 @@ func TestDownloadURLToPath_ChunkedCancel(t *testing.T) {
 	if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
 		t.Fatalf("expected context cancellation error, got %v", err)
 	}
+	// Only whole leading chunks are kept, so a resume appends correctly.
+	if st, err := os.Stat(out); err == nil && (st.Size() >= int64(len(payload)) || st.Size()%1024 != 0) {
+		t.Fatalf("cancelled chunked output is %d bytes, want a chunk-aligned prefix", st.Size())
+	}
 }
 
 func TestDownloadURLToPathWithHeaders_AppliesMediaHeaders(t *testing.T) {
@@ -474,12 +434,14 @@ func TestDownloadFailureProvidesAttemptDetails(t *testing.T) {
 	}
 
 	c := New(Config{
-		HTTPClient:      httpClient,
-		ClientOverrides: []string{"mweb"},
+		HTTPClient:                 httpClient,
+		ClientOverrides:            []string{"mweb"},
+		DisableDownloadClientRetry: true,
 	})
 
 	_, err := c.Download(context.Background(), videoID, DownloadOptions{
-		Itag: 18,
+		Itag:       18,
+		OutputPath: filepath.Join(t.TempDir(), "out.mp4"),
 	})
 	if err == nil {
 		t.Fatal("expected download failure error, got nil")
@@ -654,3 +616,557 @@ func TestDownloadFallsBackToSingleWhenMergeChallengeUnsolved(t *testing.T) {
 		t.Fatalf("expected fallback muxed itag=18, got %d", res.Itag)
 	}
 }
+
+type testMultiTrackMuxer struct {
+	testMuxer
+	tracks []MuxTrack
+}
+
+func (m *testMultiTrackMuxer) MergeTracks(ctx context.Context, tracks []MuxTrack, outputPath string, meta types.Metadata) error {
+	m.tracks = append([]MuxTrack(nil), tracks...)
+	var out []byte
+	for _, t := range tracks {
+		b, err := os.ReadFile(t.Path)
+		if err != nil {
+			return err
+		}
+		out = append(out, b...)
+	}
+	return os.WriteFile(outputPath, out, 0o644)
+}
+
+func TestDownloadAndMerge_MultiAudioUsesMultiTrackMuxer(t *testing.T) {
+	videoID := "jNQXAC9IVRw"
+	mediaBase := "https://media.example"
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+					"streamingData":{"adaptiveFormats":[
+						{"itag":248,"url":"` + mediaBase + `/v.webm","mimeType":"video/webm","bitrate":1000},
+						{"itag":251,"url":"` + mediaBase + `/en.webm","mimeType":"audio/webm","bitrate":1000,"audioTrack":{"displayName":"English","id":"en.4","audioIsDefault":true}},
+						{"itag":251,"url":"` + mediaBase + `/es.webm","mimeType":"audio/webm","bitrate":1000,"audioTrack":{"displayName":"Spanish","id":"es.3"}}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.Path == "/watch":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`<html><script src="/s/player/test/base.js"></script></html>`)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && strings.HasPrefix(r.URL.String(), mediaBase+"/"):
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ".webm"))), Header: make(http.Header)}, nil
+			default:
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+			}
+		}),
+	}
+
+	muxer := &testMultiTrackMuxer{}
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+		Muxer:           muxer,
+	})
+	dir := t.TempDir()
+	res, err := c.Download(context.Background(), videoID, DownloadOptions{
+		FormatSelector: "bv+ba[lang=en]+ba[lang=es]",
+		OutputPath:     filepath.Join(dir, "%(id)s.%(ext)s"),
+	})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if want := filepath.Join(dir, videoID+".mkv"); res.OutputPath != want {
+		t.Fatalf("output path=%q want=%q", res.OutputPath, want)
+	}
+	if res.Itag != 248 {
+		t.Fatalf("result itag=%d want=248", res.Itag)
+	}
+	if len(muxer.tracks) != 3 {
+		t.Fatalf("tracks=%d want=3", len(muxer.tracks))
+	}
+	if muxer.tracks[0].Kind != "video" || muxer.tracks[1].Language != "en" || muxer.tracks[2].Language != "es" || muxer.tracks[2].Title != "Spanish" {
+		t.Fatalf("unexpected tracks: %+v", muxer.tracks)
+	}
+	if muxer.tracks[1].Path == muxer.tracks[2].Path {
+		t.Fatalf("audio tracks share intermediate path %q", muxer.tracks[1].Path)
+	}
+	data, err := os.ReadFile(res.OutputPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if string(data) != "venes" {
+		t.Fatalf("merged payload=%q", data)
+	}
+}
+
+func TestDownload_ResultReportsStreamsRetriesAndFallback(t *testing.T) {
+	videoID := "jNQXAC9IVRw"
+	mediaBase := "https://media.example"
+	videoHits := 0
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+					"streamingData":{
+						"formats":[{"itag":18,"url":"` + mediaBase + `/av.mp4","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}],
+						"adaptiveFormats":[
+							{"itag":248,"url":"` + mediaBase + `/v.webm","mimeType":"video/webm","width":1920,"height":1080,"bitrate":1000},
+							{"itag":251,"url":"` + mediaBase + `/a.webm","mimeType":"audio/webm","bitrate":1000}
+						]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.Path == "/watch":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`<html><script src="/s/player/test/base.js"></script></html>`)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/v.webm":
+				videoHits++
+				if videoHits == 1 {
+					return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("busy")), Header: make(http.Header)}, nil
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("video")), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/a.webm":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("audio")), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/av.mp4":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("muxed")), Header: make(http.Header)}, nil
+			default:
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+			}
+		}),
+	}
+	transport := DownloadTransportConfig{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxConcurrency: 1}
+
+	c := New(Config{
+		HTTPClient:        httpClient,
+		ClientOverrides:   []string{"mweb"},
+		Muxer:             testMuxer{},
+		DownloadTransport: transport,
+	})
+	res, err := c.Download(context.Background(), videoID, DownloadOptions{
+		FormatSelector: "bv+ba",
+		OutputPath:     filepath.Join(t.TempDir(), "merged.webm"),
+	})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if len(res.SelectedFormats) != 2 || res.SelectedFormats[0].Itag != 248 || res.SelectedFormats[1].Itag != 251 {
+		t.Fatalf("selected formats=%+v", res.SelectedFormats)
+	}
+	if len(res.Streams) != 2 {
+		t.Fatalf("streams=%d want=2", len(res.Streams))
+	}
+	if s := res.Streams[0]; s.Itag != 248 || s.URLHost != "media.example" || s.Bytes != int64(len("video")) || s.Retries != 1 {
+		t.Fatalf("video stream=%+v", s)
+	}
+	if s := res.Streams[1]; s.Itag != 251 || s.Retries != 0 || s.Bytes != int64(len("audio")) {
+		t.Fatalf("audio stream=%+v", s)
+	}
+	if res.FallbackReason != "" {
+		t.Fatalf("unexpected fallback reason %q", res.FallbackReason)
+	}
+	for _, s := range res.Streams {
+		if s.Transferred != s.Bytes || s.Elapsed <= 0 {
+			t.Fatalf("stream transfer stats=%+v", s)
+		}
+	}
+	if res.Elapsed != res.Streams[0].Elapsed+res.Streams[1].Elapsed {
+		t.Fatalf("result elapsed=%v, want sum of streams", res.Elapsed)
+	}
+
+	noMuxer := New(Config{
+		HTTPClient:        httpClient,
+		ClientOverrides:   []string{"mweb"},
+		DownloadTransport: transport,
+	})
+	res, err = noMuxer.Download(context.Background(), videoID, DownloadOptions{
+		FormatSelector: "bv+ba",
+		OutputPath:     filepath.Join(t.TempDir(), "single.mp4"),
+	})
+	if err != nil {
+		t.Fatalf("Download() without muxer error = %v", err)
+	}
+	if res.FallbackReason != "muxer_unavailable" || res.Itag != 18 || len(res.Streams) != 1 {
+		t.Fatalf("fallback result=%+v", res)
+	}
+}
+
+func TestDownloadStreamResult_AddPriorAttemptAccumulatesTransfer(t *testing.T) {
+	prior := DownloadStreamResult{Retries: 2}
+	prior.setTransfer(1000, time.Second)
+	stream := DownloadStreamResult{Retries: 1}
+	stream.setTransfer(3000, time.Second)
+	stream.addPriorAttempt(prior)
+	if stream.Retries != 3 || stream.Transferred != 4000 || stream.Elapsed != 2*time.Second || stream.BytesPerSecond != 2000 {
+		t.Fatalf("stream=%+v", stream)
+	}
+
+	res := &DownloadResult{Streams: []DownloadStreamResult{stream, {Transferred: 2000, Elapsed: time.Second}}}
+	res.totalStreamTransfers()
+	if res.Elapsed != 3*time.Second || res.BytesPerSecond != 2000 {
+		t.Fatalf("result elapsed=%v speed=%d", res.Elapsed, res.BytesPerSecond)
+	}
+	if got := bytesPerSecond(100, 0); got != 0 {
+		t.Fatalf("bytesPerSecond with zero elapsed = %d", got)
+	}
+}
+
+func TestNoteDownloadDiscontinuities_RecordsStatsAndEvents(t *testing.T) {
+	var events []DownloadEvent
+	c := New(Config{OnDownloadEvent: func(evt DownloadEvent) { events = append(events, evt) }})
+	ctx, stats := withDownloadStats(context.Background())
+
+	c.noteDownloadDiscontinuities(ctx, "jNQXAC9IVRw", "out.mp4", []downloader.Discontinuity{
+		{Offset: 2048, Sequence: 7, Period: "ad", Reason: "period"},
+	})
+
+	got := stats.discontinuityList()
+	want := DownloadDiscontinuity{Offset: 2048, Sequence: 7, Period: "ad", Reason: "period"}
+	if len(got) != 1 || got[0] != want {
+		t.Fatalf("discontinuities=%+v, want [%+v]", got, want)
+	}
+	if len(events) != 1 || events[0].Phase != "discontinuity" || events[0].Detail != "reason=period seq=7 offset=2048 period=ad" {
+		t.Fatalf("events=%+v", events)
+	}
+}
+
+func TestDownload_MaxFileSizeSkipsLargeFormatsAndAbortsTransfer(t *testing.T) {
+	mediaBase := "https://media.example"
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+					"streamingData":{"formats":[
+						{"itag":22,"url":"` + mediaBase + `/v22.mp4","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":1280,"height":720,"bitrate":2000,"contentLength":"4096"},
+						{"itag":18,"url":"` + mediaBase + `/v18.mp4","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/v18.mp4":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(strings.Repeat("x", 2048))), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/v22.mp4":
+				t.Fatalf("format above max filesize was fetched")
+				return nil, nil
+			default:
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+			}
+		}),
+	}
+	c := New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}})
+	out := filepath.Join(t.TempDir(), "out.mp4")
+
+	_, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{OutputPath: out, MaxFileSize: 1024})
+	if !errors.Is(err, ErrMaxFileSizeExceeded) {
+		t.Fatalf("Download() error = %v, want ErrMaxFileSizeExceeded", err)
+	}
+	if ClassifyError(err) != ErrorCategoryMaxFileSizeExceeded {
+		t.Fatalf("ClassifyError()=%q", ClassifyError(err))
+	}
+	if _, statErr := os.Stat(out); !os.IsNotExist(statErr) {
+		t.Fatalf("expected truncated output to be removed, stat err=%v", statErr)
+	}
+}
+
+// cutBody yields n bytes of data and then fails like a dropped connection.
+type cutBody struct {
+	data []byte
+	n    int
+}
+
+func (b *cutBody) Read(p []byte) (int, error) {
+	if b.n == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	k := copy(p, b.data[:min(b.n, len(b.data))])
+	b.data, b.n = b.data[k:], b.n-k
+	return k, nil
+}
+
+func (b *cutBody) Close() error { return nil }
+
+func TestDownloadURLToPath_MaxFileSizeCountsOutputNotTransfer(t *testing.T) {
+	payload := []byte(strings.Repeat("x", 2048))
+	var cut atomic.Bool
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var start, end int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil {
+			return &http.Response{StatusCode: http.StatusBadRequest, Body: http.NoBody, Header: make(http.Header)}, nil
+		}
+		end = min(end, len(payload)-1)
+		header := make(http.Header)
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(payload)))
+		var body io.ReadCloser = io.NopCloser(bytes.NewReader(payload[start : end+1]))
+		if start == 1024 && cut.CompareAndSwap(false, true) {
+			// The second chunk drops halfway once; its retry re-reads it.
+			body = &cutBody{data: payload[start : end+1], n: 512}
+		}
+		return &http.Response{StatusCode: http.StatusPartialContent, Body: body, Header: header}, nil
+	})}
+	ctx, stats := withDownloadStats(context.WithValue(context.Background(), maxFileSizeKey{}, int64(len(payload))))
+	out := filepath.Join(t.TempDir(), "chunked.bin")
+	_, err := downloadURLToPath(ctx, httpClient, "https://media.example/v.mp4", out, false, DownloadTransportConfig{
+		EnableChunked:  true,
+		ChunkSize:      1024,
+		MaxConcurrency: 1,
+		MaxRetries:     1,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("downloadURLToPath() error = %v, want the retried chunk not to count twice", err)
+	}
+	if got := stats.bytes.Load(); got <= int64(len(payload)) {
+		t.Fatalf("transferred=%d, want the re-read bytes counted", got)
+	}
+
+	// Bytes already on disk count towards the limit when resuming.
+	srv := mediatest.NewServer(mediatest.Options{Payload: []byte("abcdef")})
+	defer srv.Close()
+	resumed := filepath.Join(t.TempDir(), "resume.bin")
+	if err := os.WriteFile(resumed, []byte("abc"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	ctx, _ = withDownloadStats(context.WithValue(context.Background(), maxFileSizeKey{}, int64(4)))
+	_, err = downloadURLToPath(ctx, srv.Client(), srv.MediaURL(), resumed, true, DownloadTransportConfig{})
+	if !errors.Is(err, ErrMaxFileSizeExceeded) {
+		t.Fatalf("resumed downloadURLToPath() error = %v, want ErrMaxFileSizeExceeded", err)
+	}
+}
+
+func TestFilterFormatsByLimits(t *testing.T) {
+	formats := []types.FormatInfo{
+		{Itag: 137, HasVideo: true, Height: 1080},
+		{Itag: 136, HasVideo: true, Height: 720, ContentLength: 10 << 20},
+		{Itag: 135, HasVideo: true, Height: 480},
+		{Itag: 140, HasAudio: true},
+	}
+	kept, skips := filterFormatsByLimits(formats, DownloadOptions{MaxHeight: 720, MaxFileSize: 1 << 20})
+	if len(kept) != 2 || kept[0].Itag != 135 || kept[1].Itag != 140 {
+		t.Fatalf("kept=%+v", kept)
+	}
+	if len(skips) != 2 || skips[0].Itag != 137 || skips[1].Itag != 136 {
+		t.Fatalf("skips=%+v", skips)
+	}
+}
+
+func TestDownload_SimulateTemplatesPathsWithoutWriting(t *testing.T) {
+	mediaBase := "https://media.example"
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Clip","author":"y"},
+					"streamingData":{"adaptiveFormats":[
+						{"itag":248,"url":"` + mediaBase + `/v.webm","mimeType":"video/webm","bitrate":1000},
+						{"itag":251,"url":"` + mediaBase + `/a.webm","mimeType":"audio/webm","bitrate":1000}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case strings.HasPrefix(r.URL.String(), mediaBase):
+				t.Fatalf("simulate fetched media %s", r.URL)
+			}
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+		}),
+	}
+	c := New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}, Muxer: testMuxer{}})
+	dir := filepath.Join(t.TempDir(), "out")
+
+	res, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{
+		Mode:       SelectionModeBest,
+		OutputPath: filepath.Join(dir, "%(title)s.%(ext)s"),
+		Simulate:   true,
+	})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if !res.Simulated || res.OutputPath != filepath.Join(dir, "Clip.mp4") {
+		t.Fatalf("result = %+v", res)
+	}
+	if len(res.Streams) != 2 || res.Streams[0].Path != res.OutputPath+".f248.video" || res.Streams[1].Itag != 251 {
+		t.Fatalf("streams = %+v", res.Streams)
+	}
+
+	res, err = c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{
+		Itag:       251,
+		OutputPath: filepath.Join(dir, "%(id)s.%(ext)s"),
+		Simulate:   true,
+	})
+	if err != nil {
+		t.Fatalf("Download(itag) error = %v", err)
+	}
+	if !res.Simulated || res.Itag != 251 || res.OutputPath != filepath.Join(dir, "jNQXAC9IVRw.webm") {
+		t.Fatalf("single result = %+v", res)
+	}
+	if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("simulate created output dir, stat err=%v", err)
+	}
+}
+
+func TestDownload_UpcomingPremiereUsesTrailerWhenRequested(t *testing.T) {
+	const premiereID, trailerID = "prem1ereVID", "trailerVID1"
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Method != http.MethodPost || !strings.Contains(r.URL.Path, "/youtubei/v1/player") {
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+			}
+			reqBody, _ := io.ReadAll(r.Body)
+			body := `{
+				"playabilityStatus":{"status":"LIVE_STREAM_OFFLINE","reason":"Premieres in 2 days",
+					"liveStreamability":{"liveStreamabilityRenderer":{"videoId":"` + premiereID + `",
+						"offlineSlate":{"liveStreamOfflineSlateRenderer":{"scheduledStartTime":"1792051200"}}}},
+					"errorScreen":{"ypcTrailerRenderer":{"trailerVideoId":"` + trailerID + `"}}},
+				"videoDetails":{"videoId":"` + premiereID + `","title":"Premiere","author":"y","isUpcoming":true},
+				"microformat":{"playerMicroformatRenderer":{"liveBroadcastDetails":{"isLiveNow":false,"startTimestamp":"2026-10-14T00:00:00+00:00"}}}
+			}`
+			if strings.Contains(string(reqBody), trailerID) {
+				body = `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"` + trailerID + `","title":"Trailer","author":"y"},
+					"streamingData":{"formats":[{"itag":18,"url":"https://media.example/t.mp4","mimeType":"video/mp4","bitrate":1000}]}
+				}`
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+		}),
+	}
+	c := New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}})
+
+	info, err := c.GetVideo(context.Background(), premiereID)
+	if err != nil {
+		t.Fatalf("GetVideo() error = %v", err)
+	}
+	wantStart := time.Unix(1792051200, 0).UTC()
+	if !info.IsUpcoming || !info.IsPremiere || !info.ScheduledStartTime.Equal(wantStart) || info.TrailerVideoID != trailerID {
+		t.Fatalf("info upcoming=%v premiere=%v start=%v trailer=%q", info.IsUpcoming, info.IsPremiere, info.ScheduledStartTime, info.TrailerVideoID)
+	}
+
+	_, err = c.Download(context.Background(), premiereID, DownloadOptions{Simulate: true})
+	var upcoming *UpcomingVideoError
+	if !errors.As(err, &upcoming) || !errors.Is(err, ErrNoPlayableFormats) || upcoming.TrailerVideoID != trailerID {
+		t.Fatalf("Download() error = %v, want UpcomingVideoError", err)
+	}
+
+	dir := t.TempDir()
+	res, err := c.Download(context.Background(), premiereID, DownloadOptions{
+		OutputPath: filepath.Join(dir, "%(title)s [%(id)s].%(ext)s"),
+		Simulate:   true,
+		Trailer:    true,
+	})
+	if err != nil {
+		t.Fatalf("Download(trailer) error = %v", err)
+	}
+	if res.VideoID != trailerID || res.OutputPath != filepath.Join(dir, "Trailer ["+trailerID+"].mp4") {
+		t.Fatalf("result = %+v", res)
+	}
+}
+
+func TestLiveReplayStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		resp string
+		want LiveReplayStatus
+	}{
+		{"upload", `{"videoDetails":{},"streamingData":{"adaptiveFormats":[{"itag":137}]}}`, ""},
+		{"live now", `{"videoDetails":{"isLiveContent":true},"playabilityStatus":{"liveStreamability":{}},
+			"microformat":{"playerMicroformatRenderer":{"liveBroadcastDetails":{"isLiveNow":true}}}}`, ""},
+		{"post live dvr", `{"videoDetails":{"isLiveContent":true,"isPostLiveDvr":true},"playabilityStatus":{"liveStreamability":{}}}`, LiveReplayProcessing},
+		{"dvr formats only", `{"videoDetails":{"isLiveContent":true},"streamingData":{"adaptiveFormats":[{"itag":136},{"itag":140}]}}`, LiveReplayProcessing},
+		{"processed", `{"videoDetails":{"isLiveContent":true},"streamingData":{"adaptiveFormats":[{"itag":137,"contentLength":"1048576"}]}}`, LiveReplayReady},
+	}
+	for _, tt := range tests {
+		var resp innertube.PlayerResponse
+		if err := json.Unmarshal([]byte(tt.resp), &resp); err != nil {
+			t.Fatalf("%s: unmarshal: %v", tt.name, err)
+		}
+		if got := liveReplayStatus(&resp); got != tt.want {
+			t.Errorf("%s: liveReplayStatus() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyLiveStreamInfo(t *testing.T) {
+	tests := []struct {
+		name   string
+		resp   string
+		target time.Duration
+		window time.Duration
+		class  LatencyClass
+		hints  downloader.LiveHints
+	}{
+		{"upload", `{"videoDetails":{},"streamingData":{"adaptiveFormats":[{"itag":137}]}}`, 0, 0, "", downloader.LiveHints{}},
+		{"low latency", `{"videoDetails":{"latencyClass":"MDE_STREAM_OPTIMIZATIONS_RENDERER_LATENCY_LOW"},
+			"streamingData":{"adaptiveFormats":[{"itag":136,"targetDurationSec":2,"maxDvrDurationSec":43200},{"itag":140,"targetDurationSec":2}]}}`,
+			2 * time.Second, 12 * time.Hour, LatencyLow, downloader.LiveHints{TargetDuration: 2 * time.Second, Window: 12 * time.Hour}},
+		{"ultra low without target", `{"videoDetails":{"latencyClass":"MDE_STREAM_OPTIMIZATIONS_RENDERER_LATENCY_ULTRA_LOW"}}`,
+			0, 0, LatencyUltraLow, downloader.LiveHints{TargetDuration: time.Second}},
+		{"normal", `{"videoDetails":{"latencyClass":"MDE_STREAM_OPTIMIZATIONS_RENDERER_LATENCY_NORMAL"},
+			"streamingData":{"formats":[{"itag":18,"targetDurationSec":5}]}}`,
+			5 * time.Second, 0, LatencyNormal, downloader.LiveHints{TargetDuration: 5 * time.Second}},
+	}
+	for _, tt := range tests {
+		var resp innertube.PlayerResponse
+		if err := json.Unmarshal([]byte(tt.resp), &resp); err != nil {
+			t.Fatalf("%s: unmarshal: %v", tt.name, err)
+		}
+		info := &VideoInfo{}
+		applyLiveStreamInfo(info, &resp)
+		if info.LiveTargetDuration != tt.target || info.LiveDVRWindow != tt.window || info.LatencyClass != tt.class {
+			t.Errorf("%s: target=%v window=%v class=%q, want %v %v %q", tt.name, info.LiveTargetDuration, info.LiveDVRWindow, info.LatencyClass, tt.target, tt.window, tt.class)
+		}
+		if got := liveHints(info); got != tt.hints {
+			t.Errorf("%s: liveHints() = %+v, want %+v", tt.name, got, tt.hints)
+		}
+	}
+}
+
+func TestApplyUploadMtime(t *testing.T) {
+	c := &Client{}
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c.applyUploadMtime(path, &VideoInfo{PublishDate: "2005-04-23T20:31:52-07:00", UploadDate: "2005-04-24"})
+	st, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2005, 4, 24, 3, 31, 52, 0, time.UTC); !st.ModTime().Equal(want) {
+		t.Fatalf("mtime = %v, want %v", st.ModTime().UTC(), want)
+	}
+
+	c.applyUploadMtime(path, &VideoInfo{UploadDate: "2010-01-02"})
+	if st, _ = os.Stat(path); !st.ModTime().Equal(time.Date(2010, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("mtime = %v, want the upload date", st.ModTime().UTC())
+	}
+
+	before := st.ModTime()
+	c.applyUploadMtime(path, &VideoInfo{})
+	if st, _ = os.Stat(path); !st.ModTime().Equal(before) {
+		t.Fatalf("mtime changed to %v without a date", st.ModTime())
+	}
+}
+
+func TestDownloadURLToPath_ChunkedForbiddenMidStream(t *testing.T) {
+	payload := []byte(strings.Repeat("y", 16*1024))
+	srv := mediatest.NewServer(mediatest.Options{Payload: payload, ForbidAfterBytes: 5 * 1024})
+	defer srv.Close()
+
+	out := filepath.Join(t.TempDir(), "forbidden.bin")
+	_, err := downloadURLToPath(context.Background(), srv.Client(), srv.MediaURL(), out, false, DownloadTransportConfig{
+		EnableChunked:  true,
+		ChunkSize:      4 * 1024,
+		MaxConcurrency: 1,
+		MaxRetries:     2,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond,
+	})
+	var statusErr *downloadHTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("downloadURLToPath() error = %v, want HTTP 403", err)
+	}
+}
@@ -1,6 +1,13 @@
 package client
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/innertube"
+)
 
 var (
 	// ErrInvalidInput indicates malformed input (not a video ID/url).
@@ -19,6 +26,32 @@ var (
 	ErrMP3TranscoderNotConfigured = errors.New("mp3 transcoder not configured")
 	// ErrTranscriptParse indicates transcript payload could not be parsed.
 	ErrTranscriptParse = errors.New("transcript parse failed")
+	// ErrMaxFileSizeExceeded indicates a transfer passed DownloadOptions.MaxFileSize.
+	ErrMaxFileSizeExceeded = errors.New("max filesize exceeded")
+	// ErrMaxTotalBytesExceeded indicates the client received more than
+	// Config.MaxTotalBytes.
+	ErrMaxTotalBytesExceeded = errors.New("max total bytes exceeded")
+	// ErrDownloadTooSlow indicates a transfer stayed below
+	// Config.SlowDownloadAbort's speed floor.
+	ErrDownloadTooSlow = errors.New("download too slow")
+	// ErrOutputInvalid indicates DownloadOptions.ValidateOutput found the
+	// finished file incomplete or corrupt.
+	ErrOutputInvalid = errors.New("output validation failed")
+	// ErrWorkspaceLocked indicates another run holds a workspace lock.
+	ErrWorkspaceLocked = errors.New("workspace locked by another run")
+	// ErrDiskDisabled indicates an operation that needs the filesystem on a
+	// client configured with Config.NoDisk.
+	ErrDiskDisabled = errors.New("disk access disabled")
+	// ErrFrameUnavailable indicates GetFrame found neither a storyboard nor a
+	// FrameExtractor to produce the frame with.
+	ErrFrameUnavailable = errors.New("frame unavailable")
+	// ErrJobCanceled is returned by Job.Wait after Job.Cancel.
+	ErrJobCanceled = errors.New("download job canceled")
+	// ErrJobFinished indicates Pause or Resume on a Job that already ended.
+	ErrJobFinished = errors.New("download job finished")
+	// ErrQuotaExceeded indicates an Innertube request would wait longer than
+	// InnertubeQuotaConfig.MaxWait for its client's request budget.
+	ErrQuotaExceeded = innertube.ErrQuotaExceeded
 )
 
 // ErrorCategory is a stable machine-readable error class.
@@ -35,6 +68,10 @@ const (
 	ErrorCategoryMP3TranscoderNotConfigured ErrorCategory = "mp3_transcoder_not_configured"
 	ErrorCategoryTranscriptParse            ErrorCategory = "transcript_parse_failed"
 	ErrorCategoryDownloadFailed             ErrorCategory = "download_failed"
+	ErrorCategoryMaxFileSizeExceeded        ErrorCategory = "max_filesize_exceeded"
+	ErrorCategoryMaxTotalBytesExceeded      ErrorCategory = "max_total_bytes_exceeded"
+	ErrorCategoryDownloadTooSlow            ErrorCategory = "download_too_slow"
+	ErrorCategoryOutputInvalid              ErrorCategory = "output_invalid"
 )
 
 // InvalidInputDetailError preserves ErrInvalidInput while exposing parsing reason/context.
@@ -68,6 +105,99 @@ func (e *MP3TranscoderError) Is(target error) bool {
 	return target == ErrMP3TranscoderNotConfigured
 }
 
+// MaxFileSizeError preserves ErrMaxFileSizeExceeded while exposing the limit.
+type MaxFileSizeError struct {
+	Limit int64
+}
+
+// Error returns a human-readable max filesize error.
+func (e *MaxFileSizeError) Error() string {
+	return fmt.Sprintf("max filesize exceeded: limit=%d bytes", e.Limit)
+}
+
+// Is reports sentinel compatibility with ErrMaxFileSizeExceeded.
+func (e *MaxFileSizeError) Is(target error) bool {
+	return target == ErrMaxFileSizeExceeded
+}
+
+// MaxTotalBytesError preserves ErrMaxTotalBytesExceeded while exposing the
+// budget and the bytes received.
+type MaxTotalBytesError struct {
+	Limit int64
+	Bytes int64
+}
+
+// Error returns a human-readable max total bytes error.
+func (e *MaxTotalBytesError) Error() string {
+	return fmt.Sprintf("max total bytes exceeded: received %d of %d bytes", e.Bytes, e.Limit)
+}
+
+// Retryable reports false: retrying cannot succeed once the budget is spent.
+func (e *MaxTotalBytesError) Retryable() bool {
+	return false
+}
+
+// Is reports sentinel compatibility with ErrMaxTotalBytesExceeded.
+func (e *MaxTotalBytesError) Is(target error) bool {
+	return target == ErrMaxTotalBytesExceeded
+}
+
+// SlowDownloadError preserves ErrDownloadTooSlow while exposing the
+// measured and required speeds.
+type SlowDownloadError struct {
+	BytesPerSecond    int64
+	MinBytesPerSecond int64
+	Window            time.Duration
+}
+
+// Error returns a human-readable slow download error.
+func (e *SlowDownloadError) Error() string {
+	return fmt.Sprintf("download too slow: %d B/s over %s, minimum %d B/s", e.BytesPerSecond, e.Window, e.MinBytesPerSecond)
+}
+
+// Is reports sentinel compatibility with ErrDownloadTooSlow.
+func (e *SlowDownloadError) Is(target error) bool {
+	return target == ErrDownloadTooSlow
+}
+
+// OutputValidationError preserves ErrOutputInvalid while listing what the
+// probe of the finished file found wrong.
+type OutputValidationError struct {
+	Path     string
+	Problems []string
+	Probe    MediaProbe
+}
+
+// Error returns a human-readable validation failure.
+func (e *OutputValidationError) Error() string {
+	return "output validation failed for " + e.Path + ": " + strings.Join(e.Problems, "; ")
+}
+
+// Is reports sentinel compatibility with ErrOutputInvalid.
+func (e *OutputValidationError) Is(target error) bool {
+	return target == ErrOutputInvalid
+}
+
+// WorkspaceLockedError preserves ErrWorkspaceLocked while naming the lock
+// file and, when recorded, the process holding it.
+type WorkspaceLockedError struct {
+	Path string
+	PID  int
+}
+
+// Error returns a human-readable lock conflict.
+func (e *WorkspaceLockedError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("workspace locked by another run: %s (pid %d)", e.Path, e.PID)
+	}
+	return "workspace locked by another run: " + e.Path
+}
+
+// Is reports sentinel compatibility with ErrWorkspaceLocked.
+func (e *WorkspaceLockedError) Is(target error) bool {
+	return target == ErrWorkspaceLocked
+}
+
 // FormatSkipReason captures why a candidate format was dropped.
 type FormatSkipReason struct {
 	Itag     int
@@ -100,6 +230,31 @@ func (e *NoPlayableFormatsDetailError) Is(target error) bool {
 	return target == ErrNoPlayableFormats
 }
 
+// UpcomingVideoError preserves ErrNoPlayableFormats for a scheduled stream or
+// premiere that has not started yet.
+type UpcomingVideoError struct {
+	VideoID            string
+	ScheduledStartTime time.Time // zero when unknown
+	TrailerVideoID     string
+}
+
+// Error returns a human-readable upcoming video error.
+func (e *UpcomingVideoError) Error() string {
+	msg := "video " + e.VideoID + " has not started yet"
+	if !e.ScheduledStartTime.IsZero() {
+		msg += " (scheduled for " + e.ScheduledStartTime.Format(time.RFC3339) + ")"
+	}
+	if e.TrailerVideoID != "" {
+		msg += "; trailer " + e.TrailerVideoID + " is available"
+	}
+	return msg
+}
+
+// Is reports sentinel compatibility with ErrNoPlayableFormats.
+func (e *UpcomingVideoError) Is(target error) bool {
+	return target == ErrNoPlayableFormats
+}
+
 // AttemptDetail captures a single client attempt in the fallback matrix.
 type AttemptDetail struct {
 	Client               string
@@ -125,6 +280,9 @@ type AttemptDetail struct {
 	Unavailable          bool
 	DRMProtected         bool
 	AvailableCountries   []string
+	// SwitchedFromClient is set on download attempts that re-extracted the
+	// format through Client after SwitchedFromClient's URL returned 403.
+	SwitchedFromClient string
 }
 
 // DownloadFailureDetailError preserves download failure context while exposing attempt-style diagnostics.
@@ -137,6 +295,27 @@ func (e *DownloadFailureDetailError) Error() string {
 	return "download failed with detailed attempts"
 }
 
+// ChallengeNotSolvedDetailError preserves ErrChallengeNotSolved while exposing
+// which challenge failed and for which URL. It is returned in strict challenge mode.
+type ChallengeNotSolvedDetailError struct {
+	Challenge string
+	Attempts  []AttemptDetail
+}
+
+// Error returns a summary of the unsolved challenge.
+func (e *ChallengeNotSolvedDetailError) Error() string {
+	msg := "challenge not solved: " + e.Challenge
+	if len(e.Attempts) > 0 && e.Attempts[0].Reason != "" {
+		msg += ": " + e.Attempts[0].Reason
+	}
+	return msg
+}
+
+// Is reports sentinel compatibility with ErrChallengeNotSolved.
+func (e *ChallengeNotSolvedDetailError) Is(target error) bool {
+	return target == ErrChallengeNotSolved
+}
+
 // AllClientsFailedDetailError preserves ErrAllClientsFailed while exposing attempt details.
 type AllClientsFailedDetailError struct {
 	Attempts []AttemptDetail
@@ -216,6 +395,28 @@ func (e *TranscriptParseDetailError) Is(target error) bool {
 	return target == ErrTranscriptParse
 }
 
+// TranscriptBatchError reports the languages GetTranscripts could not
+// fetch, in request order.
+type TranscriptBatchError struct {
+	VideoID       string
+	LanguageCodes []string
+	Errors        []error
+}
+
+// Error returns "transcripts failed: <lang>(<error>); ...".
+func (e *TranscriptBatchError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		parts[i] = fmt.Sprintf("%s(%v)", e.LanguageCodes[i], err)
+	}
+	return "transcripts failed: " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns the per-language errors.
+func (e *TranscriptBatchError) Unwrap() []error {
+	return e.Errors
+}
+
 // AttemptDetails extracts attempt matrix details from typed package errors.
 func AttemptDetails(err error) ([]AttemptDetail, bool) {
 	if err == nil {
@@ -237,6 +438,10 @@ func AttemptDetails(err error) ([]AttemptDetail, bool) {
 	if errors.As(err, &downloadErr) {
 		return downloadErr.Attempts, true
 	}
+	var challengeErr *ChallengeNotSolvedDetailError
+	if errors.As(err, &challengeErr) {
+		return challengeErr.Attempts, true
+	}
 	return nil, false
 }
 
@@ -261,6 +466,14 @@ func ClassifyError(err error) ErrorCategory {
 		return ErrorCategoryMP3TranscoderNotConfigured
 	case errors.Is(err, ErrTranscriptParse):
 		return ErrorCategoryTranscriptParse
+	case errors.Is(err, ErrMaxFileSizeExceeded):
+		return ErrorCategoryMaxFileSizeExceeded
+	case errors.Is(err, ErrMaxTotalBytesExceeded):
+		return ErrorCategoryMaxTotalBytesExceeded
+	case errors.Is(err, ErrDownloadTooSlow):
+		return ErrorCategoryDownloadTooSlow
+	case errors.Is(err, ErrOutputInvalid):
+		return ErrorCategoryOutputInvalid
 	default:
 		var downloadErr *DownloadFailureDetailError
 		if errors.As(err, &downloadErr) {
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// downloadExternal transfers a direct stream URL with Config.ExternalDownloader.
+// Progress samples feed the transfer stats, so throttle detection and
+// MaxFileSize see the same counters as built-in downloads.
+func (c *Client) downloadExternal(ctx context.Context, videoID, streamURL, outputPath string, resume bool) error {
+	forgetOutputDigest(ctx, outputPath)
+	stats, _ := ctx.Value(downloadStatsKey{}).(*downloadStats)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var reported int64
+	progress := func(p DownloadProgress) {
+		if delta := p.Bytes - reported; delta > 0 {
+			reported = p.Bytes
+			if stats != nil {
+				stats.add(int(delta))
+				if err := stats.reach(p.Bytes); err != nil {
+					cancel(err)
+				}
+			}
+		}
+		detail := fmt.Sprintf("bytes=%d", p.Bytes)
+		if p.Total > 0 {
+			detail += fmt.Sprintf(" total=%d", p.Total)
+		}
+		c.emitDownloadEvent("download", "progress", videoID, outputPath, detail)
+	}
+
+	err := c.config.ExternalDownloader.Download(ctx, ExternalDownload{
+		URL:        streamURL,
+		Headers:    buildMediaRequestHeaders(c.mediaHeaders(streamURL), videoID),
+		OutputPath: outputPath,
+		Resume:     resume,
+	}, progress)
+	if err != nil {
+		if cause := context.Cause(ctx); errors.Is(cause, ErrMaxFileSizeExceeded) {
+			return cause
+		}
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type externalDownloaderFunc func(ctx context.Context, req ExternalDownload, progress func(DownloadProgress)) error
+
+func (f externalDownloaderFunc) Download(ctx context.Context, req ExternalDownload, progress func(DownloadProgress)) error {
+	return f(ctx, req, progress)
+}
+
+func TestDownloadStream_UsesExternalDownloader(t *testing.T) {
+	var events []DownloadEvent
+	var got ExternalDownload
+	c := New(Config{
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			t.Fatalf("built-in downloader fetched %s", r.URL)
+			return nil, nil
+		})},
+		UserAgents: UserAgentConfig{Media: "test-agent"},
+		ExternalDownloader: externalDownloaderFunc(func(ctx context.Context, req ExternalDownload, progress func(DownloadProgress)) error {
+			got = req
+			progress(DownloadProgress{Bytes: 512, Total: 2048})
+			progress(DownloadProgress{Bytes: 2048, Total: 2048})
+			return os.WriteFile(req.OutputPath, []byte(strings.Repeat("x", 2048)), 0o644)
+		}),
+		OnDownloadEvent: func(evt DownloadEvent) { events = append(events, evt) },
+	})
+	out := filepath.Join(t.TempDir(), "out.mp4")
+	ctx, stats := withDownloadStats(context.Background())
+
+	streamURL := "https://rr1---sn-abc.googlevideo.com/videoplayback?itag=18"
+	if err := c.downloadStream(ctx, "jNQXAC9IVRw", streamURL, out, FormatInfo{Itag: 18}, true); err != nil {
+		t.Fatalf("downloadStream() error = %v", err)
+	}
+	if got.URL != streamURL || got.OutputPath != out || !got.Resume {
+		t.Fatalf("request = %+v", got)
+	}
+	if ua := got.Headers.Get("User-Agent"); ua != "test-agent" {
+		t.Fatalf("User-Agent = %q, want test-agent", ua)
+	}
+	if ref := got.Headers.Get("Referer"); ref != "https://www.youtube.com/watch?v=jNQXAC9IVRw" {
+		t.Fatalf("Referer = %q", ref)
+	}
+	if n := stats.bytes.Load(); n != 2048 {
+		t.Fatalf("stats bytes = %d, want 2048", n)
+	}
+	if len(events) != 2 || events[0].Phase != "progress" || events[0].Detail != "bytes=512 total=2048" {
+		t.Fatalf("events = %+v", events)
+	}
+}
+
+func TestDownloadStream_ExternalDownloaderMaxFileSize(t *testing.T) {
+	c := New(Config{
+		ExternalDownloader: externalDownloaderFunc(func(ctx context.Context, req ExternalDownload, progress func(DownloadProgress)) error {
+			progress(DownloadProgress{Bytes: 4096})
+			<-ctx.Done()
+			return ctx.Err()
+		}),
+	})
+	ctx := context.WithValue(context.Background(), maxFileSizeKey{}, int64(1024))
+	ctx, _ = withDownloadStats(ctx)
+
+	err := c.downloadStream(ctx, "jNQXAC9IVRw", "https://media.example/v.mp4", filepath.Join(t.TempDir(), "out.mp4"), FormatInfo{Itag: 18}, false)
+	if !errors.Is(err, ErrMaxFileSizeExceeded) {
+		t.Fatalf("downloadStream() error = %v, want ErrMaxFileSizeExceeded", err)
+	}
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Extractor produces VideoInfo for URLs outside YouTube, such as an internal
+// video portal, so they can go through the same selection, download and merge
+// pipeline. Formats must carry a directly fetchable URL; no signature or n
+// challenge handling, client retry or throttle refresh is applied to them.
+type Extractor interface {
+	// Match reports whether the extractor handles rawURL. It must not do I/O.
+	Match(rawURL string) bool
+	// Extract returns the video behind rawURL. VideoInfo.ID must be non-empty
+	// and stable, since it keys the session cache and output templates.
+	Extract(ctx context.Context, rawURL string) (*VideoInfo, error)
+}
+
+// ExtractorRegistry is an ordered set of named extractors. The first
+// extractor whose Match accepts an input wins; inputs no extractor matches
+// are treated as YouTube.
+type ExtractorRegistry struct {
+	mu      sync.RWMutex
+	entries []namedExtractor
+}
+
+type namedExtractor struct {
+	name string
+	ext  Extractor
+}
+
+// DefaultExtractors is used when Config.Extractors is nil. Packages providing
+// private extractors typically add themselves from init, so a blank import
+// in a custom build of cmd/ytv1 is enough to enable them.
+var DefaultExtractors = &ExtractorRegistry{}
+
+// RegisterExtractor adds e to DefaultExtractors under name.
+func RegisterExtractor(name string, e Extractor) error {
+	return DefaultExtractors.Register(name, e)
+}
+
+// Register adds e under name. Names are case-insensitive and must be unique.
+func (r *ExtractorRegistry) Register(name string, e Extractor) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || strings.ContainsAny(name, " \t\r\n") {
+		return fmt.Errorf("invalid extractor name %q", name)
+	}
+	if e == nil {
+		return fmt.Errorf("extractor %q is nil", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range r.entries {
+		if entry.name == name {
+			return fmt.Errorf("extractor %q already registered", name)
+		}
+	}
+	r.entries = append(r.entries, namedExtractor{name: name, ext: e})
+	return nil
+}
+
+// Names returns the registered extractor names in match order.
+func (r *ExtractorRegistry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, len(r.entries))
+	for i, entry := range r.entries {
+		names[i] = entry.name
+	}
+	return names
+}
+
+func (r *ExtractorRegistry) match(rawURL string) (namedExtractor, bool) {
+	if r == nil {
+		return namedExtractor{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, entry := range r.entries {
+		if entry.ext.Match(rawURL) {
+			return entry, true
+		}
+	}
+	return namedExtractor{}, false
+}
+
+func (c *Client) extractors() *ExtractorRegistry {
+	if c.config.Extractors != nil {
+		return c.config.Extractors
+	}
+	return DefaultExtractors
+}
+
+// resolveVideoInput returns the session key for input: the YouTube video ID,
+// or for extractor inputs the extractor's ID after caching its VideoInfo.
+func (c *Client) resolveVideoInput(ctx context.Context, input string) (string, error) {
+	entry, ok := c.extractors().match(strings.TrimSpace(input))
+	if !ok {
+		return normalizeVideoID(input)
+	}
+	if videoID, ok := c.extractedSessionID(entry.name, input); ok {
+		return videoID, nil
+	}
+	info, err := c.extractWith(ctx, entry, input)
+	if err != nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+// extractWith runs an extractor and caches its result like a player response.
+func (c *Client) extractWith(ctx context.Context, entry namedExtractor, input string) (*VideoInfo, error) {
+	c.emitExtractionEvent("extractor", "start", entry.name, "")
+	info, err := entry.ext.Extract(ctx, strings.TrimSpace(input))
+	if err == nil {
+		err = validateExtractedVideo(info)
+	}
+	if err != nil {
+		c.emitExtractionEvent("extractor", "failure", entry.name, err.Error())
+		return nil, fmt.Errorf("extractor %s: %w", entry.name, err)
+	}
+	info = cloneVideoInfo(info)
+	info.Extractor = entry.name
+	c.emitExtractionEvent("extractor", "success", entry.name, fmt.Sprintf("id=%s formats=%d", info.ID, len(info.Formats)))
+	c.putSession(info.ID, videoSession{Info: cloneVideoInfo(info), Extractor: entry.name, Input: strings.TrimSpace(input)})
+	return info, nil
+}
+
+func validateExtractedVideo(info *VideoInfo) error {
+	if info == nil {
+		return errors.New("returned no video")
+	}
+	id := strings.TrimSpace(info.ID)
+	if id == "" || id != info.ID || strings.ContainsAny(id, " \t\r\n/\\") {
+		return fmt.Errorf("invalid video id %q", info.ID)
+	}
+	for _, f := range info.Formats {
+		if strings.TrimSpace(f.URL) == "" {
+			return fmt.Errorf("format %d has no url", f.Itag)
+		}
+	}
+	return nil
+}
+
+// extractedSessionID finds a cached extraction of input, so a Download after
+// GetVideo does not query the extractor again.
+func (c *Client) extractedSessionID(name, input string) (string, bool) {
+	input = strings.TrimSpace(input)
+	c.sessionsMu.RLock()
+	var videoID string
+	for id, s := range c.sessions {
+		if s.Extractor == name && s.Input == input {
+			videoID = id
+			break
+		}
+	}
+	c.sessionsMu.RUnlock()
+	if videoID == "" {
+		return "", false
+	}
+	// getSession applies the TTL.
+	_, ok := c.getSession(videoID)
+	return videoID, ok
+}
+
+// isExtractedVideo reports whether videoID's session came from an Extractor.
+func (c *Client) isExtractedVideo(videoID string) bool {
+	session, ok := c.getSession(videoID)
+	return ok && session.Extractor != ""
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type portalExtractor struct {
+	info  *VideoInfo
+	err   error
+	calls int
+}
+
+func (e *portalExtractor) Match(rawURL string) bool {
+	return strings.HasPrefix(rawURL, "https://videos.corp.example/")
+}
+
+func (e *portalExtractor) Extract(ctx context.Context, rawURL string) (*VideoInfo, error) {
+	e.calls++
+	return e.info, e.err
+}
+
+func TestExtractorRegistry_Register(t *testing.T) {
+	r := &ExtractorRegistry{}
+	if err := r.Register("Portal", &portalExtractor{}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Register("portal", &portalExtractor{}); err == nil {
+		t.Fatalf("expected duplicate name error")
+	}
+	if err := r.Register("two words", &portalExtractor{}); err == nil {
+		t.Fatalf("expected invalid name error")
+	}
+	if err := r.Register("nil", nil); err == nil {
+		t.Fatalf("expected nil extractor error")
+	}
+	if got := r.Names(); len(got) != 1 || got[0] != "portal" {
+		t.Fatalf("Names() = %v", got)
+	}
+}
+
+func TestDownload_UsesMatchingExtractor(t *testing.T) {
+	var mediaHits []string
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.URL.Host != "media.corp.example" {
+				t.Errorf("unexpected request to %s", r.URL)
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+			}
+			mediaHits = append(mediaHits, r.URL.Path)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("portal-" + r.URL.Path)), Header: make(http.Header)}, nil
+		}),
+	}
+	ext := &portalExtractor{info: &VideoInfo{
+		ID:    "town-hall-42",
+		Title: "Town Hall",
+		Formats: []FormatInfo{
+			// An n parameter must not trigger YouTube challenge handling.
+			{Itag: 1, URL: "https://media.corp.example/low.mp4?n=abc", MimeType: "video/mp4", HasVideo: true, HasAudio: true, Height: 360, Bitrate: 500},
+			{Itag: 2, URL: "https://media.corp.example/high.mp4", MimeType: "video/mp4", HasVideo: true, HasAudio: true, Height: 720, Bitrate: 1500},
+		},
+	}}
+	registry := &ExtractorRegistry{}
+	if err := registry.Register("portal", ext); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	c := New(Config{HTTPClient: httpClient, Extractors: registry})
+
+	info, err := c.GetVideo(context.Background(), "https://videos.corp.example/watch/42")
+	if err != nil {
+		t.Fatalf("GetVideo() error = %v", err)
+	}
+	if info.ID != "town-hall-42" || info.Extractor != "portal" {
+		t.Fatalf("info id=%q extractor=%q", info.ID, info.Extractor)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "town-hall.mp4")
+	res, err := c.Download(context.Background(), "https://videos.corp.example/watch/42", DownloadOptions{OutputPath: outputPath})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if res.VideoID != "town-hall-42" || res.Itag != 2 {
+		t.Fatalf("result video=%q itag=%d", res.VideoID, res.Itag)
+	}
+	data, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if string(data) != "portal-/high.mp4" {
+		t.Fatalf("output=%q", data)
+	}
+	for _, hit := range mediaHits {
+		if hit != "/high.mp4" {
+			t.Fatalf("media hits=%v", mediaHits)
+		}
+	}
+	if ext.calls != 1 {
+		t.Fatalf("extract calls=%d, want Download to reuse the GetVideo result", ext.calls)
+	}
+
+	url, err := c.ResolveStreamURL(context.Background(), "town-hall-42", 1)
+	if err != nil || url != "https://media.corp.example/low.mp4?n=abc" {
+		t.Fatalf("ResolveStreamURL() = %q, %v", url, err)
+	}
+}
+
+func TestGetVideo_ExtractorResultValidation(t *testing.T) {
+	cases := map[string]*portalExtractor{
+		"nil info":      {},
+		"empty id":      {info: &VideoInfo{Title: "x"}},
+		"id with slash": {info: &VideoInfo{ID: "a/b"}},
+		"format no url": {info: &VideoInfo{ID: "ok", Formats: []FormatInfo{{Itag: 1}}}},
+		"extract error": {err: errors.New("portal down")},
+	}
+	for name, ext := range cases {
+		t.Run(name, func(t *testing.T) {
+			registry := &ExtractorRegistry{}
+			_ = registry.Register("portal", ext)
+			c := New(Config{Extractors: registry})
+			_, err := c.GetVideo(context.Background(), "https://videos.corp.example/watch/1")
+			if err == nil || !strings.Contains(err.Error(), "extractor portal") {
+				t.Fatalf("GetVideo() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestGetVideo_UnmatchedInputStaysYouTube(t *testing.T) {
+	registry := &ExtractorRegistry{}
+	_ = registry.Register("portal", &portalExtractor{})
+	c := New(Config{Extractors: registry})
+	_, err := c.GetVideo(context.Background(), "https://elsewhere.example/watch/1")
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("GetVideo() error = %v, want ErrInvalidInput", err)
+	}
+}
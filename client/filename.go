@@ -0,0 +1,110 @@
+package client
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// TextNormalization cleans up titles and uploader names before they become
+// file names or embedded metadata. The zero value leaves text as is.
+type TextNormalization struct {
+	// NFC composes decomposed characters ("e" + U+0301 into "é"). macOS
+	// hands out decomposed names, so without it the same title can yield
+	// two different byte sequences and two files.
+	NFC bool
+	// StripBidi removes bidirectional control characters (U+200E, U+202E
+	// and friends), which make a name display in a different order than
+	// its characters, e.g. hiding its real extension.
+	StripBidi bool
+	// StripEmoji removes emoji and their joiners and variation selectors,
+	// for file systems or tools that handle them badly. Emoji are kept by
+	// default.
+	StripEmoji bool
+}
+
+// Apply returns s normalized as configured.
+func (n TextNormalization) Apply(s string) string {
+	if n.NFC {
+		s = norm.NFC.String(s)
+	}
+	if !n.StripBidi && !n.StripEmoji {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	dropped := false
+	for _, r := range s {
+		if (n.StripBidi && isBidiControl(r)) || (n.StripEmoji && isEmojiRune(r)) {
+			dropped = true
+			continue
+		}
+		// Do not leave a double space where an emoji between words was.
+		if r == ' ' && dropped && (b.Len() == 0 || strings.HasSuffix(b.String(), " ")) {
+			continue
+		}
+		dropped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isBidiControl(r rune) bool {
+	switch {
+	case r == 0x061C, r == 0x200E, r == 0x200F:
+		return true
+	case r >= 0x202A && r <= 0x202E:
+		return true
+	case r >= 0x2066 && r <= 0x2069:
+		return true
+	}
+	return false
+}
+
+// isEmojiRune reports emoji, pictographs and the characters that only
+// modify them (zero-width joiner, variation selector 16, keycap, tags).
+func isEmojiRune(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return true
+	case r >= 0x2600 && r <= 0x27BF:
+		return true
+	case r == 0x2B50, r == 0x2B55, r == 0x2B1B, r == 0x2B1C:
+		return true
+	case r == 0x200D, r == 0xFE0F, r == 0x20E3:
+		return true
+	case r >= 0xE0020 && r <= 0xE007F:
+		return true
+	}
+	return false
+}
+
+// SanitizeFileName makes name safe as one path element on Windows, macOS
+// and Linux after applying n: characters reserved on any of them and
+// control characters become "_", and surrounding spaces are trimmed. An
+// empty result is "unknown".
+func SanitizeFileName(name string, n TextNormalization) string {
+	v := strings.TrimSpace(n.Apply(name))
+	if v == "" {
+		return "unknown"
+	}
+	var b strings.Builder
+	b.Grow(len(v))
+	for _, r := range v {
+		switch r {
+		case '<', '>', ':', '"', '/', '\\', '|', '?', '*':
+			b.WriteRune('_')
+		default:
+			if r < 32 {
+				b.WriteRune('_')
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	out := strings.TrimSpace(b.String())
+	if out == "" {
+		return "unknown"
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	normalize := TextNormalization{NFC: true, StripBidi: true}
+	tests := []struct {
+		name string
+		in   string
+		text TextNormalization
+		want string
+	}{
+		// macOS file systems hand out decomposed (NFD) names.
+		{name: "nfd latin kept by default", in: "Cafe\u0301", want: "Cafe\u0301"},
+		{name: "nfd latin composed", in: "Cafe\u0301", text: normalize, want: "Caf\u00e9"},
+		{name: "nfd hangul composed", in: "\u1112\u1161\u11ab\u1100\u1173\u11af", text: normalize, want: "\ud55c\uae00"},
+		// Windows reserves these characters; Linux only '/'.
+		{name: "windows reserved", in: `a<b>c:d"e|f?g*h\i`, want: "a_b_c_d_e_f_g_h_i"},
+		{name: "path separator", in: "AC/DC live", want: "AC_DC live"},
+		{name: "control characters", in: "line\nbreak\ttab", want: "line_break_tab"},
+		{name: "bidi override", in: "invoice\u202egpj.exe", text: normalize, want: "invoicegpj.exe"},
+		{name: "bidi isolates", in: "\u2067عربي\u2069 title", text: normalize, want: "عربي title"},
+		{name: "emoji kept", in: "Party 🎉 Time", text: normalize, want: "Party 🎉 Time"},
+		{name: "emoji stripped", in: "Party 🎉 Time 👨\u200d👩\u200d👧 ❤\ufe0f", text: TextNormalization{StripEmoji: true}, want: "Party Time"},
+		{name: "only emoji", in: "🎉🎉", text: TextNormalization{StripEmoji: true}, want: "unknown"},
+		{name: "empty", in: "  ", want: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeFileName(tt.in, tt.text); got != tt.want {
+				t.Fatalf("SanitizeFileName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownload_TextNormalizationAppliesToFileName(t *testing.T) {
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{
+			"playabilityStatus":{"status":"OK"},
+			"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Cafe\u0301 \u202e🎉 night","author":"y"},
+			"streamingData":{"formats":[{"itag":18,"url":"https://media.example/v.mp4","mimeType":"video/mp4","bitrate":1000}]}
+		}`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})}
+	c := New(Config{
+		HTTPClient:        httpClient,
+		ClientOverrides:   []string{"mweb"},
+		TextNormalization: TextNormalization{NFC: true, StripBidi: true, StripEmoji: true},
+	})
+	res, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{Itag: 18, OutputPath: "%(title)s.%(ext)s", Simulate: true})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if got := filepath.Base(res.OutputPath); got != "Caf\u00e9 night.mp4" {
+		t.Fatalf("output = %q", got)
+	}
+}
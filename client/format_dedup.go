@@ -0,0 +1,69 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// dedupeFormats keeps one format per stream (itag, protocol, audio track and
+// xtags variant such as DRC audio), preferring the healthiest copy: a
+// non-ciphered URL first, then one from a client that needs no PO token (or with
+// a PoTokenProvider configured), then the earliest listed. Kept formats stay at the position of their
+// stream's first copy. The other copies are returned as duplicates, for use
+// as download fallbacks.
+func dedupeFormats(in []FormatInfo, cfg Config) (kept, duplicates []FormatInfo) {
+	index := make(map[string]int, len(in))
+	for _, f := range in {
+		key := formatStreamKey(f)
+		i, seen := index[key]
+		if !seen {
+			index[key] = len(kept)
+			kept = append(kept, f)
+			continue
+		}
+		if formatHealth(f, cfg) > formatHealth(kept[i], cfg) {
+			kept[i], f = f, kept[i]
+		}
+		duplicates = append(duplicates, f)
+	}
+	return kept, duplicates
+}
+
+// formatStreamKey identifies the stream a format carries, independent of the
+// client and URL that serve it.
+func formatStreamKey(f FormatInfo) string {
+	xtags := ""
+	if u, err := url.Parse(f.URL); err == nil {
+		xtags = u.Query().Get("xtags")
+	}
+	return strconv.Itoa(f.Itag) + "|" + f.Protocol + "|" + f.Language + "|" + f.AudioTrackName + "|" + xtags
+}
+
+func formatHealth(f FormatInfo, cfg Config) int {
+	health := 0
+	if !f.Ciphered {
+		health += 2
+	}
+	profile, ok := resolveSourceClientProfile(f.SourceClient)
+	if !ok || !profile.PoTokenPolicy[protocolFromFormat(f)].Required || cfg.PoTokenProvider != nil {
+		health++
+	}
+	return health
+}
+
+// formatDuplicates returns the recorded duplicates of f's stream for
+// videoID, excluding f itself.
+func (c *Client) formatDuplicates(videoID string, f FormatInfo) []FormatInfo {
+	session, ok := c.getSession(videoID)
+	if !ok {
+		return nil
+	}
+	key := formatStreamKey(f)
+	var out []FormatInfo
+	for _, d := range session.DuplicateFormats {
+		if d.URL != f.URL && formatStreamKey(d) == key {
+			out = append(out, d)
+		}
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDedupeFormats_PrefersHealthiestCopy(t *testing.T) {
+	formats := []FormatInfo{
+		{Itag: 140, Protocol: "https", URL: "https://a.example/140?s=1", Ciphered: true, SourceClient: "web"},
+		{Itag: 251, Protocol: "https", URL: "https://a.example/251?xtags=drc%3D1", SourceClient: "web"},
+		{Itag: 251, Protocol: "https", URL: "https://a.example/251", SourceClient: "web"},
+		{Itag: 140, Protocol: "https", URL: "https://b.example/140", SourceClient: "ios"},
+		{Itag: 140, Protocol: "dash", URL: "https://b.example/manifest", SourceClient: "ios"},
+		{Itag: 251, Protocol: "https", URL: "https://c.example/251-es", Language: "es", SourceClient: "web"},
+	}
+
+	kept, dups := dedupeFormats(formats, Config{})
+	var keptURLs []string
+	for _, f := range kept {
+		keptURLs = append(keptURLs, f.URL)
+	}
+	want := []string{
+		"https://b.example/140", // non-ciphered copy takes the first copy's place
+		"https://a.example/251?xtags=drc%3D1",
+		"https://a.example/251",
+		"https://b.example/manifest",
+		"https://c.example/251-es",
+	}
+	if strings.Join(keptURLs, " ") != strings.Join(want, " ") {
+		t.Fatalf("kept = %q, want %q", keptURLs, want)
+	}
+	if len(dups) != 1 || dups[0].URL != "https://a.example/140?s=1" {
+		t.Fatalf("duplicates = %+v", dups)
+	}
+
+	// Without a PoTokenProvider, a copy from a client that needs no PO token
+	// wins over the web client's; with one, listing order decides.
+	potFormats := []FormatInfo{
+		{Itag: 18, Protocol: "https", URL: "https://a.example/18", SourceClient: "web"},
+		{Itag: 18, Protocol: "https", URL: "https://b.example/18", SourceClient: "tv"},
+	}
+	if kept, _ := dedupeFormats(potFormats, Config{}); len(kept) != 1 || kept[0].SourceClient != "tv" {
+		t.Fatalf("kept = %+v, want the tv copy", kept)
+	}
+	withProvider := Config{PoTokenProvider: &tokenProviderStub{token: "pot"}}
+	if kept, _ := dedupeFormats(potFormats, withProvider); len(kept) != 1 || kept[0].SourceClient != "web" {
+		t.Fatalf("kept = %+v, want the web copy", kept)
+	}
+}
+
+func TestDownload_FallsBackToDuplicateSourceOnForbidden(t *testing.T) {
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		switch {
+		case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+			body := `{
+				"playabilityStatus":{"status":"OK"},
+				"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+				"streamingData":{"formats":[
+					{"itag":18,"url":"https://a.example/v18.mp4","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500},
+					{"itag":18,"url":"https://b.example/v18.mp4","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}
+				]}
+			}`
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+		case r.URL.Host == "a.example":
+			return &http.Response{StatusCode: http.StatusForbidden, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+		case r.URL.Host == "b.example":
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("media")), Header: make(http.Header)}, nil
+		default:
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+		}
+	})}
+	c := New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}, DisableDownloadClientRetry: true})
+
+	info, err := c.GetVideo(context.Background(), "jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("GetVideo() error = %v", err)
+	}
+	if len(info.Formats) != 1 {
+		t.Fatalf("formats = %+v, want one itag 18", info.Formats)
+	}
+	res, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{Itag: 18, OutputPath: filepath.Join(t.TempDir(), "out.mp4")})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if res.FallbackReason != "duplicate_source" || res.Streams[0].URLHost != "b.example" {
+		t.Fatalf("result reason=%q host=%q", res.FallbackReason, res.Streams[0].URLHost)
+	}
+
+	all := New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}, DisableFormatDedup: true})
+	if info, err := all.GetVideo(context.Background(), "jNQXAC9IVRw"); err != nil || len(info.Formats) != 2 {
+		t.Fatalf("DisableFormatDedup: formats=%d err=%v", len(info.Formats), err)
+	}
+}
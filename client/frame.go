@@ -0,0 +1,224 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FrameOptions tunes GetFrameWithOptions.
+type FrameOptions struct {
+	// Accurate decodes the exact frame from the video stream with the Muxer's
+	// FrameExtractor instead of cropping the nearest storyboard thumbnail.
+	Accurate bool
+}
+
+// GetFrame returns a JPEG of the video at the given timestamp. It crops the
+// nearest thumbnail from YouTube's storyboard sprites, which is fast but
+// low-resolution and only as precise as the storyboard interval (often a few
+// seconds). Without a storyboard it falls back to the accurate path of
+// GetFrameWithOptions.
+func (c *Client) GetFrame(ctx context.Context, input string, at time.Duration) ([]byte, error) {
+	return c.GetFrameWithOptions(ctx, input, at, FrameOptions{})
+}
+
+// GetFrameWithOptions is GetFrame with options. The accurate path selects the
+// best video-only stream and has the Muxer's FrameExtractor (ffmpeg) seek to
+// at, so only the bytes around the timestamp are downloaded. Timestamps past
+// the end return the last frame.
+func (c *Client) GetFrameWithOptions(ctx context.Context, input string, at time.Duration, options FrameOptions) ([]byte, error) {
+	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+
+	if at < 0 {
+		return nil, &InvalidInputDetailError{Input: at.String(), Reason: "negative frame timestamp"}
+	}
+	videoID, err := c.resolveVideoInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if !options.Accurate {
+		frame, err := c.storyboardFrame(ctx, videoID, at)
+		if err == nil || !errors.Is(err, ErrFrameUnavailable) {
+			return frame, err
+		}
+	}
+	return c.extractFrame(ctx, videoID, at)
+}
+
+// storyboardLevel is one resolution of a storyboard: Count thumbnails of
+// Width x Height, Interval apart, laid out Cols x Rows per sprite sheet.
+type storyboardLevel struct {
+	Width, Height int
+	Count         int
+	Cols, Rows    int
+	Interval      time.Duration
+	// URL is the sheet URL with "$M" standing for the sheet index.
+	URL string
+}
+
+// parseStoryboardSpec parses a playerStoryboardSpecRenderer spec into its
+// levels, lowest resolution first. duration fills in intervals the spec
+// leaves at 0; levels without a usable interval are dropped.
+func parseStoryboardSpec(spec string, duration time.Duration) []storyboardLevel {
+	parts := strings.Split(spec, "|")
+	if len(parts) < 2 {
+		return nil
+	}
+	base := parts[0]
+	var levels []storyboardLevel
+	for i, raw := range parts[1:] {
+		args := strings.Split(raw, "#")
+		if len(args) != 8 {
+			continue
+		}
+		var nums [6]int
+		valid := true
+		for j := range nums {
+			n, err := strconv.Atoi(args[j])
+			if err != nil || (j < 5 && n <= 0) {
+				valid = false
+				break
+			}
+			nums[j] = n
+		}
+		if !valid {
+			continue
+		}
+		level := storyboardLevel{
+			Width:    nums[0],
+			Height:   nums[1],
+			Count:    nums[2],
+			Cols:     nums[3],
+			Rows:     nums[4],
+			Interval: time.Duration(nums[5]) * time.Millisecond,
+		}
+		if level.Interval <= 0 {
+			level.Interval = duration / time.Duration(level.Count)
+		}
+		if level.Interval <= 0 {
+			continue
+		}
+		sheetURL := strings.NewReplacer("$L", strconv.Itoa(i), "$N", args[6]).Replace(base)
+		if sigh := args[7]; sigh != "" {
+			sep := "?"
+			if strings.Contains(sheetURL, "?") {
+				sep = "&"
+			}
+			sheetURL += sep + "sigh=" + sigh
+		}
+		level.URL = sheetURL
+		levels = append(levels, level)
+	}
+	return levels
+}
+
+// locate returns the sheet URL holding the thumbnail at and the thumbnail's
+// rectangle within the sheet.
+func (l storyboardLevel) locate(at time.Duration) (string, image.Rectangle) {
+	index := int(at / l.Interval)
+	if index >= l.Count {
+		index = l.Count - 1
+	}
+	perSheet := l.Cols * l.Rows
+	pos := index % perSheet
+	x, y := (pos%l.Cols)*l.Width, (pos/l.Cols)*l.Height
+	sheetURL := strings.ReplaceAll(l.URL, "$M", strconv.Itoa(index/perSheet))
+	return sheetURL, image.Rect(x, y, x+l.Width, y+l.Height)
+}
+
+func (c *Client) storyboardFrame(ctx context.Context, videoID string, at time.Duration) ([]byte, error) {
+	session, ok := c.getSession(videoID)
+	if !ok {
+		if _, err := c.GetVideo(ctx, videoID); err != nil {
+			return nil, err
+		}
+		session, ok = c.getSession(videoID)
+	}
+	if !ok || session.Response == nil {
+		return nil, fmt.Errorf("%w: no storyboard for video=%s", ErrFrameUnavailable, videoID)
+	}
+	var duration time.Duration
+	if session.Info != nil {
+		duration = time.Duration(session.Info.DurationSec) * time.Second
+	}
+	levels := parseStoryboardSpec(session.Response.Storyboards.PlayerStoryboardSpecRenderer.Spec, duration)
+	if len(levels) == 0 {
+		return nil, fmt.Errorf("%w: no storyboard for video=%s", ErrFrameUnavailable, videoID)
+	}
+	sheetURL, rect := levels[len(levels)-1].locate(at)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sheetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	applyMediaRequestHeaders(req, c.mediaHeaders(sheetURL), videoID)
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("storyboard fetch failed: status=%d", resp.StatusCode)
+	}
+	sheet, err := jpeg.Decode(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, fmt.Errorf("storyboard decode failed: %w", err)
+	}
+	// The last sheet of a storyboard may be only partly filled.
+	rect = rect.Intersect(sheet.Bounds())
+	if rect.Empty() {
+		return nil, fmt.Errorf("%w: storyboard sheet smaller than expected", ErrFrameUnavailable)
+	}
+	sub, ok := sheet.(interface {
+		SubImage(image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil, fmt.Errorf("storyboard decode failed: unsupported image type %T", sheet)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, sub.SubImage(rect), &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *Client) extractFrame(ctx context.Context, videoID string, at time.Duration) ([]byte, error) {
+	extractor, ok := c.config.Muxer.(FrameExtractor)
+	if !ok || !c.config.Muxer.Available() {
+		return nil, fmt.Errorf("%w: accurate frames need a Muxer implementing FrameExtractor (ffmpeg)", ErrFrameUnavailable)
+	}
+	if c.config.NoDisk {
+		return nil, fmt.Errorf("%w: accurate frames go through a temporary file", ErrDiskDisabled)
+	}
+	_, _, selected, err := c.selectDownloadFormats(ctx, videoID, DownloadOptions{FormatSelector: "bestvideo[ext=mp4]/bestvideo/best"})
+	if err != nil {
+		return nil, err
+	}
+	streamURL, err := c.resolveSelectedFormatURL(ctx, videoID, selected[0])
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "ytv1-frame-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	outputPath := filepath.Join(dir, "frame.jpg")
+	headers := buildMediaRequestHeaders(c.mediaHeaders(streamURL), videoID)
+	if err := extractor.ExtractFrame(ctx, streamURL, headers, at, outputPath); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(outputPath)
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// frameTestSheet is a 2x2 storyboard sheet of 16x16 red, green, blue and
+// white thumbnails.
+func frameTestSheet(t *testing.T) []byte {
+	t.Helper()
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 255, 255}}
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			img.Set(x, y, colors[(y/16)*2+x/16])
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newFrameTestClient(t *testing.T, storyboardSpec string, muxer Muxer, requested *[]string) *Client {
+	t.Helper()
+	playerJSON := `{
+		"playabilityStatus":{"status":"OK"},
+		"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo","lengthSeconds":"19"},
+		"streamingData":{"adaptiveFormats":[{"itag":137,"url":"https://rr1.googlevideo.com/videoplayback?itag=137","mimeType":"video/mp4; codecs=\"avc1\"","bitrate":1000,"width":1920,"height":1080}]},
+		"storyboards":{"playerStoryboardSpecRenderer":{"spec":"` + storyboardSpec + `"}}
+	}`
+	sheet := frameTestSheet(t)
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := ""
+		switch {
+		case strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+			body = playerJSON
+		case r.URL.Path == "/watch":
+			body = `<html></html>`
+		case r.URL.Host == "i.ytimg.com":
+			*requested = append(*requested, r.URL.String())
+			body = string(sheet)
+		default:
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}
+	return New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}, Muxer: muxer})
+}
+
+func TestParseStoryboardSpec(t *testing.T) {
+	levels := parseStoryboardSpec("https://i.ytimg.com/sb/ID/storyboard3_L$L/$N.jpg?sqp=q|48#27#100#10#10#0#default#rs$A|160#90#100#5#5#1000#M$M#rs$B|bad#level", 100*time.Second)
+	if len(levels) != 2 {
+		t.Fatalf("levels = %+v, want 2", levels)
+	}
+	if levels[0].Interval != time.Second || levels[0].URL != "https://i.ytimg.com/sb/ID/storyboard3_L0/default.jpg?sqp=q&sigh=rs$A" {
+		t.Fatalf("levels[0] = %+v", levels[0])
+	}
+	sheetURL, rect := levels[1].locate(27500 * time.Millisecond)
+	if sheetURL != "https://i.ytimg.com/sb/ID/storyboard3_L1/M1.jpg?sqp=q&sigh=rs$B" {
+		t.Fatalf("sheet URL = %q", sheetURL)
+	}
+	if rect != image.Rect(320, 0, 480, 90) {
+		t.Fatalf("rect = %v", rect)
+	}
+	// Past the end clamps to the last thumbnail.
+	if sheetURL, _ := levels[1].locate(time.Hour); !strings.Contains(sheetURL, "/M3.jpg") {
+		t.Fatalf("clamped sheet URL = %q", sheetURL)
+	}
+}
+
+func TestGetFrame_CropsStoryboardThumbnail(t *testing.T) {
+	var requested []string
+	c := newFrameTestClient(t, "https://i.ytimg.com/sb/jNQXAC9IVRw/storyboard3_L$L/$N.jpg?sqp=q|16#16#4#2#2#5000#M$M#sig", nil, &requested)
+
+	data, err := c.GetFrame(context.Background(), "jNQXAC9IVRw", 11*time.Second)
+	if err != nil {
+		t.Fatalf("GetFrame() error = %v", err)
+	}
+	if len(requested) != 1 || requested[0] != "https://i.ytimg.com/sb/jNQXAC9IVRw/storyboard3_L0/M0.jpg?sqp=q&sigh=sig" {
+		t.Fatalf("requested = %v", requested)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("frame is not a JPEG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 16 {
+		t.Fatalf("frame bounds = %v, want 16x16", b)
+	}
+	// 11s with a 5s interval is thumbnail 2: bottom-left, blue.
+	r, g, b, _ := img.At(img.Bounds().Min.X+8, img.Bounds().Min.Y+8).RGBA()
+	if r>>8 > 40 || g>>8 > 40 || b>>8 < 200 {
+		t.Fatalf("frame color = (%d,%d,%d), want blue", r>>8, g>>8, b>>8)
+	}
+}
+
+type frameExtractorMuxer struct {
+	testMuxer
+	url     string
+	headers http.Header
+	at      time.Duration
+}
+
+func (m *frameExtractorMuxer) ExtractFrame(ctx context.Context, inputURL string, headers http.Header, at time.Duration, outputPath string) error {
+	m.url, m.headers, m.at = inputURL, headers, at
+	return os.WriteFile(outputPath, []byte("jpeg"), 0o644)
+}
+
+func TestGetFrame_AccurateUsesFrameExtractor(t *testing.T) {
+	var requested []string
+	m := &frameExtractorMuxer{}
+	c := newFrameTestClient(t, "", m, &requested)
+
+	data, err := c.GetFrameWithOptions(context.Background(), "jNQXAC9IVRw", 3*time.Second, FrameOptions{Accurate: true})
+	if err != nil {
+		t.Fatalf("GetFrameWithOptions() error = %v", err)
+	}
+	if string(data) != "jpeg" || m.at != 3*time.Second || !strings.Contains(m.url, "itag=137") {
+		t.Fatalf("data=%q extractor=%+v", data, m)
+	}
+	if m.headers.Get("User-Agent") == "" {
+		t.Fatalf("media headers not passed: %v", m.headers)
+	}
+	if len(requested) != 0 {
+		t.Fatalf("storyboard fetched on the accurate path: %v", requested)
+	}
+
+	// Without a storyboard, GetFrame falls back to the extractor.
+	if _, err := c.GetFrame(context.Background(), "jNQXAC9IVRw", time.Second); err != nil || m.at != time.Second {
+		t.Fatalf("GetFrame() error = %v at=%v", err, m.at)
+	}
+}
+
+func TestGetFrame_UnavailableWithoutStoryboardOrExtractor(t *testing.T) {
+	var requested []string
+	c := newFrameTestClient(t, "", nil, &requested)
+
+	if _, err := c.GetFrame(context.Background(), "jNQXAC9IVRw", time.Second); !errors.Is(err, ErrFrameUnavailable) {
+		t.Fatalf("GetFrame() error = %v, want ErrFrameUnavailable", err)
+	}
+	if _, err := c.GetFrame(context.Background(), "jNQXAC9IVRw", -time.Second); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("GetFrame(negative) error = %v, want ErrInvalidInput", err)
+	}
+}
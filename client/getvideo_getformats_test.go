@@ -230,6 +230,8 @@ func TestGetVideoEmitsExtractionEventsForWebpageAndManifest(t *testing.T) {
 			hasManifestStart = true
 		}
 	}
+	// n in the manifest URL needs the player, so it is fetched before the
+	// manifest is.
 	if !hasWebpageStart || !hasWebpageSuccess {
 		t.Fatalf("expected webpage start/success events, got=%v", events)
 	}
@@ -237,3 +239,186 @@ func TestGetVideoEmitsExtractionEventsForWebpageAndManifest(t *testing.T) {
 		t.Fatalf("expected manifest start event, got=%v", events)
 	}
 }
+
+func TestGetVideoDefersChallengesToResolve(t *testing.T) {
+	cipher := buildCipher("https://media.example/a.webm?itag=251&n=abcd", map[string]string{"s": "xyz", "sp": "sig"})
+	playerJSON := `{
+		"playabilityStatus":{"status":"OK"},
+		"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+		"streamingData":{"adaptiveFormats":[{"itag":251,"signatureCipher":"` + cipher + `","mimeType":"audio/webm; codecs=\"opus\"","bitrate":1000}]}
+	}`
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body := playerJSON
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+			case r.URL.Path == "/watch":
+				body = `<html><script src="/s/player/test/player_ias.vflset/en_US/base.js"></script></html>`
+			case strings.HasPrefix(r.URL.Path, "/s/player/"):
+				body = testPlayerJS()
+			default:
+				t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(bytes.NewBufferString(body)),
+			}, nil
+		}),
+	}
+	var mu sync.Mutex
+	var challengeEvents []ExtractionEvent
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+		OnExtractionEvent: func(evt ExtractionEvent) {
+			if evt.Stage == "player_js" || evt.Stage == "challenge" {
+				mu.Lock()
+				defer mu.Unlock()
+				challengeEvents = append(challengeEvents, evt)
+			}
+		},
+	})
+
+	info, err := c.GetVideo(context.Background(), "jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("GetVideo() error = %v", err)
+	}
+	if len(challengeEvents) != 0 {
+		t.Fatalf("GetVideo did challenge work: %v", challengeEvents)
+	}
+	if len(info.Formats) != 1 || !info.Formats[0].Ciphered {
+		t.Fatalf("formats = %+v, want one ciphered format", info.Formats)
+	}
+
+	got, err := info.Formats[0].Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if !strings.Contains(got, "sig=yz") || !strings.Contains(got, "n=bcd") {
+		t.Fatalf("Resolve() = %q, want deciphered sig and n", got)
+	}
+	if len(challengeEvents) == 0 {
+		t.Fatal("Resolve reported no challenge work")
+	}
+}
+
+func TestGetVideoSolvesManifestNBeforeFetch(t *testing.T) {
+	playerJSON := `{
+		"playabilityStatus":{"status":"OK"},
+		"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+		"streamingData":{"dashManifestUrl":"https://media.example/manifest.mpd?n=abcd"}
+	}`
+	var mu sync.Mutex
+	var manifestQuery string
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body := playerJSON
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+			case r.URL.Path == "/watch":
+				body = `<html><script src="/s/player/test/player_ias.vflset/en_US/base.js"></script></html>`
+			case strings.HasPrefix(r.URL.Path, "/s/player/"):
+				body = testPlayerJS()
+			case strings.HasSuffix(r.URL.Path, ".mpd"):
+				mu.Lock()
+				manifestQuery = r.URL.RawQuery
+				mu.Unlock()
+				body = `<MPD xmlns="urn:mpeg:dash:schema:mpd:2011"></MPD>`
+			default:
+				t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(bytes.NewBufferString(body)),
+			}, nil
+		}),
+	}
+	c := New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}})
+
+	info, err := c.GetVideo(context.Background(), "jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("GetVideo() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if manifestQuery != "n=bcd" {
+		t.Fatalf("manifest fetched with query %q, want solved n", manifestQuery)
+	}
+	if info.DashManifestURL != "https://media.example/manifest.mpd?n=bcd" {
+		t.Fatalf("DashManifestURL = %q, want solved n", info.DashManifestURL)
+	}
+}
+
+func TestFormatInfoResolveWithoutResolver(t *testing.T) {
+	if got, err := (FormatInfo{URL: "https://media.example/v.mp4"}).Resolve(context.Background()); err != nil || got != "https://media.example/v.mp4" {
+		t.Fatalf("Resolve() = %q, %v; want URL as given", got, err)
+	}
+	if _, err := (FormatInfo{}).Resolve(context.Background()); err == nil {
+		t.Fatal("Resolve() on a format without URL succeeded")
+	}
+}
+
+func TestGetVideoMetadataSkipsPlayerJS(t *testing.T) {
+	cipher := buildCipher("https://media.example/a.webm?itag=251&n=abcd", map[string]string{"s": "xyz", "sp": "sig"})
+	playerJSON := `{
+		"playabilityStatus":{"status":"OK"},
+		"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+		"streamingData":{"adaptiveFormats":[{"itag":251,"signatureCipher":"` + cipher + `","mimeType":"audio/webm; codecs=\"opus\"","bitrate":1000}]}
+	}`
+	var mu sync.Mutex
+	var playerRequests, playerJSFetches int
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			body := playerJSON
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				playerRequests++
+			case r.URL.Path == "/watch":
+				// No STS in the page: the timestamp comes from the player.
+				body = `<html><script src="/s/player/test/player_ias.vflset/en_US/base.js"></script></html>`
+			case strings.HasPrefix(r.URL.Path, "/s/player/"):
+				playerJSFetches++
+				body = testPlayerJS()
+			default:
+				t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(bytes.NewBufferString(body)),
+			}, nil
+		}),
+	}
+	c := New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}})
+
+	info, err := c.GetVideoMetadata(context.Background(), "jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("GetVideoMetadata() error = %v", err)
+	}
+	if info.Title != "x" || len(info.Formats) != 1 {
+		t.Fatalf("info = %+v", info)
+	}
+	if playerJSFetches != 0 || playerRequests != 1 {
+		t.Fatalf("GetVideoMetadata made %d player requests and %d player JS fetches, want 1 and 0", playerRequests, playerJSFetches)
+	}
+
+	// The ciphered URL needs a response requested with the player's
+	// signature timestamp, so resolving it re-extracts the video.
+	got, err := info.Formats[0].Resolve(context.Background())
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if !strings.Contains(got, "sig=yz") {
+		t.Fatalf("Resolve() = %q, want a deciphered signature", got)
+	}
+	if playerRequests != 2 || playerJSFetches == 0 {
+		t.Fatalf("Resolve made %d player requests and %d player JS fetches, want a full re-extraction", playerRequests, playerJSFetches)
+	}
+	if session, ok := c.getSession("jNQXAC9IVRw"); !ok || session.MetadataOnly {
+		t.Fatal("re-extracted session is still metadata-only")
+	}
+}
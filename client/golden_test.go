@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/famomatic/ytv1/internal/vcr"
+)
+
+var recordCassettes = flag.Bool("record", false, "re-record the HTTP cassettes under testdata/cassettes against the live network and rewrite their golden files")
+
+// goldenCase is one recorded video. The cassette testdata/cassettes/<name>.json
+// holds its traffic and <name>.golden the expected extraction summary.
+type goldenCase struct {
+	name    string
+	videoID string
+	clients []string
+	// resolveItag, when set, also resolves that format's URL, exercising
+	// the player JS challenge path.
+	resolveItag int
+}
+
+var goldenCases = []goldenCase{
+	{name: "public", videoID: "DSYFmhjDbvs", clients: []string{"mweb"}},
+	{name: "age_gated", videoID: "HtVdAasjOgU", clients: []string{"android", "web_embedded"}},
+	{name: "live", videoID: "jfKfPfyJRdk", clients: []string{"mweb"}},
+	{name: "ciphered", videoID: "UxxajLWwzqY", clients: []string{"web"}, resolveItag: 251},
+}
+
+// TestGolden replays each cassette through the full extraction pipeline and
+// compares the result with its golden file. Cases without a cassette are
+// skipped; run with -record to record them from the network.
+func TestGolden(t *testing.T) {
+	for _, tc := range goldenCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cassette := filepath.Join("testdata", "cassettes", tc.name+".json")
+			golden := filepath.Join("testdata", "cassettes", tc.name+".golden")
+			if *recordCassettes {
+				rec, err := vcr.New(cassette, vcr.ModeRecord, nil)
+				if err != nil {
+					t.Fatalf("vcr.New() error = %v", err)
+				}
+				rec.SetNote(fmt.Sprintf("recorded by go test ./client -run TestGolden/%s -record", tc.name))
+				runGoldenCase(t, tc, &http.Client{Transport: rec})
+				if err := rec.Stop(); err != nil {
+					t.Fatalf("saving cassette: %v", err)
+				}
+			}
+
+			if _, err := os.Stat(cassette); errors.Is(err, os.ErrNotExist) {
+				t.Skipf("no recorded cassette; record one with: go test ./client -run TestGolden/%s -record", tc.name)
+			}
+
+			// Goldens always come from a replay, which sees the sanitized
+			// cassette rather than the raw recording.
+			rec, err := vcr.New(cassette, vcr.ModeReplay, nil)
+			if err != nil {
+				t.Fatalf("vcr.New() error = %v", err)
+			}
+			got := runGoldenCase(t, tc, &http.Client{Transport: rec})
+			if *recordCassettes {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+			if got != string(want) {
+				t.Fatalf("extraction summary differs from %s\n--- got\n%s--- want\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func runGoldenCase(t *testing.T, tc goldenCase, httpClient *http.Client) string {
+	t.Helper()
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: tc.clients,
+		NoDisk:          true,
+	})
+	ctx := context.Background()
+
+	var b strings.Builder
+	info, err := c.GetVideo(ctx, tc.videoID)
+	if err != nil {
+		fmt.Fprintf(&b, "error=%v\n", err)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "id=%s\ntitle=%s\nauthor=%s\nduration=%d\nlive=%v\n", info.ID, info.Title, info.Author, info.DurationSec, info.IsLive)
+	fmt.Fprintf(&b, "dash_manifest=%v hls_manifest=%v\n", info.DashManifestURL != "", info.HLSManifestURL != "")
+	for _, f := range info.Formats {
+		fmt.Fprintf(&b, "format itag=%d protocol=%s mime=%q video=%v audio=%v ciphered=%v client=%s\n",
+			f.Itag, f.Protocol, f.MimeType, f.HasVideo, f.HasAudio, f.Ciphered, f.SourceClient)
+	}
+	if tc.resolveItag > 0 {
+		streamURL, err := c.ResolveStreamURL(ctx, tc.videoID, tc.resolveItag)
+		if err != nil {
+			fmt.Fprintf(&b, "resolve itag=%d error=%v\n", tc.resolveItag, err)
+		} else {
+			fmt.Fprintf(&b, "resolve itag=%d url=%s\n", tc.resolveItag, streamURL)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestInnertubeHostRouting(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		host    string
+		byID    map[string]string
+		wantURL string
+	}{
+		{"global", "youtubei.googleapis.com", nil, "https://youtubei.googleapis.com/youtubei/v1/player"},
+		{"per client", "youtubei.googleapis.com", map[string]string{"mweb": "http://proxy.internal:8080/"}, "http://proxy.internal:8080/youtubei/v1/player"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var playerURL, origin string
+			httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/youtubei/v1/player") {
+					playerURL = r.URL.Scheme + "://" + r.URL.Host + r.URL.Path
+					origin = r.Header.Get("Origin")
+					body := `{"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo"}}`
+					return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(body))}, nil
+				}
+				return &http.Response{StatusCode: http.StatusNotFound, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(""))}, nil
+			})}
+			c := New(Config{
+				HTTPClient:      httpClient,
+				ClientOverrides: []string{"mweb"},
+				InnertubeHost:   tc.host,
+				InnertubeHosts:  tc.byID,
+			})
+			if _, err := c.GetVideo(context.Background(), "jNQXAC9IVRw"); err != nil {
+				t.Fatalf("GetVideo() error = %v", err)
+			}
+			if playerURL != tc.wantURL {
+				t.Fatalf("player request URL = %q, want %q", playerURL, tc.wantURL)
+			}
+			if origin != "https://www.youtube.com" {
+				t.Fatalf("Origin = %q, want the client's own host", origin)
+			}
+		})
+	}
+}
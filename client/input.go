@@ -12,6 +12,7 @@ var (
 	watchURLPattern    = regexp.MustCompile(`(?:v=|/shorts/|youtu\.be/)([0-9A-Za-z_-]{11})`)
 	playlistIDPattern  = regexp.MustCompile(`^(PL|UU|LL|RD|OLAK5uy_)[0-9A-Za-z_-]+$`)
 	playlistURLPattern = regexp.MustCompile(`(?:[?&]list=)([0-9A-Za-z_-]+)`)
+	channelIDPattern   = regexp.MustCompile(`^UC[0-9A-Za-z_-]{22}$`)
 )
 
 // ExtractVideoID accepts either a raw id or common YouTube URL shapes.
@@ -45,6 +46,9 @@ func ExtractVideoID(input string) (string, error) {
 }
 
 // ExtractPlaylistID accepts raw playlist IDs or common YouTube playlist URL shapes.
+// Account playlists are accepted as PlaylistWatchLater, PlaylistLiked and
+// PlaylistHistory, the yt-dlp shortcuts (:ytwatchlater, :ytfav, :ytlike,
+// :ythistory) and the /feed/history URL.
 func ExtractPlaylistID(input string) (string, error) {
 	s := strings.TrimSpace(input)
 	if s == "" {
@@ -53,11 +57,20 @@ func ExtractPlaylistID(input string) (string, error) {
 	if playlistIDPattern.MatchString(s) {
 		return s, nil
 	}
+	if _, ok := authPlaylists[s]; ok {
+		return s, nil
+	}
+	if id, ok := authPlaylistShortcuts[strings.ToLower(s)]; ok {
+		return id, nil
+	}
 
 	if parsed, ok := tryParseURL(s); ok {
 		if !isYouTubeHost(parsed.Hostname()) {
 			return "", invalidInput(input, "unsupported_host")
 		}
+		if strings.TrimSuffix(parsed.Path, "/") == "/feed/history" {
+			return PlaylistHistory, nil
+		}
 		if listID := strings.TrimSpace(parsed.Query().Get("list")); listID != "" {
 			return listID, nil
 		}
@@ -71,6 +84,49 @@ func ExtractPlaylistID(input string) (string, error) {
 	return "", invalidInput(input, "unsupported_input_shape")
 }
 
+// ExtractChannelID accepts raw channel IDs (UC...) and /channel/<id> URLs.
+// Handle and custom URLs (@name, /c/, /user/) need a page lookup; see
+// Client.ResolveChannelID.
+func ExtractChannelID(input string) (string, error) {
+	s := strings.TrimSpace(input)
+	if s == "" {
+		return "", invalidInput(input, "empty_input")
+	}
+	if channelIDPattern.MatchString(s) {
+		return s, nil
+	}
+
+	if parsed, ok := tryParseURL(s); ok {
+		if !isYouTubeHost(parsed.Hostname()) {
+			return "", invalidInput(input, "unsupported_host")
+		}
+		parts := strings.Split(strings.Trim(path.Clean(parsed.Path), "/"), "/")
+		if len(parts) >= 2 && parts[0] == "channel" && channelIDPattern.MatchString(parts[1]) {
+			return parts[1], nil
+		}
+		return "", invalidInput(input, "missing_channel_id")
+	}
+	return "", invalidInput(input, "unsupported_input_shape")
+}
+
+// IsFeedURL reports whether input is a YouTube RSS/Atom feed URL
+// (https://www.youtube.com/feeds/videos.xml?channel_id=... or ?playlist_id=...).
+func IsFeedURL(input string) bool {
+	s := strings.TrimSpace(input)
+	if !strings.Contains(s, "://") {
+		return false
+	}
+	parsed, ok := tryParseURL(s)
+	if !ok || !isYouTubeHost(parsed.Hostname()) {
+		return false
+	}
+	if path.Clean(parsed.Path) != "/feeds/videos.xml" {
+		return false
+	}
+	q := parsed.Query()
+	return q.Get("channel_id") != "" || q.Get("playlist_id") != "" || q.Get("user") != ""
+}
+
 func invalidInput(input, reason string) error {
 	return &InvalidInputDetailError{
 		Input:  strings.TrimSpace(input),
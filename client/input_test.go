@@ -54,6 +54,12 @@ func TestExtractPlaylistID_SupportedShapes(t *testing.T) {
 		{in: "https://www.youtube.com/playlist?list=PLabc123", want: "PLabc123"},
 		{in: "https://www.youtube.com/watch?v=jNQXAC9IVRw&list=PLabc123", want: "PLabc123"},
 		{in: "youtube.com/watch?v=jNQXAC9IVRw&list=PLabc123", want: "PLabc123"},
+		{in: "WL", want: PlaylistWatchLater},
+		{in: "https://www.youtube.com/playlist?list=LL", want: PlaylistLiked},
+		{in: ":ytwatchlater", want: PlaylistWatchLater},
+		{in: ":ytfav", want: PlaylistLiked},
+		{in: ":ythistory", want: PlaylistHistory},
+		{in: "https://www.youtube.com/feed/history", want: PlaylistHistory},
 	}
 	for _, tt := range tests {
 		got, err := ExtractPlaylistID(tt.in)
@@ -79,3 +85,37 @@ func TestExtractPlaylistID_MissingList(t *testing.T) {
 		t.Fatalf("reason=%q, want %q", detail.Reason, "missing_playlist_id")
 	}
 }
+
+func TestExtractChannelID(t *testing.T) {
+	for _, input := range []string{
+		"UCabcdefghijklmnopqrstuv",
+		"https://www.youtube.com/channel/UCabcdefghijklmnopqrstuv",
+		"youtube.com/channel/UCabcdefghijklmnopqrstuv/videos",
+	} {
+		got, err := ExtractChannelID(input)
+		if err != nil || got != "UCabcdefghijklmnopqrstuv" {
+			t.Fatalf("ExtractChannelID(%q) = %q, %v", input, got, err)
+		}
+	}
+	for _, input := range []string{"@example", "https://www.youtube.com/@example", "https://example.com/channel/UCabcdefghijklmnopqrstuv"} {
+		if _, err := ExtractChannelID(input); err == nil {
+			t.Fatalf("ExtractChannelID(%q) expected error", input)
+		}
+	}
+}
+
+func TestIsFeedURL(t *testing.T) {
+	cases := map[string]bool{
+		"https://www.youtube.com/feeds/videos.xml?channel_id=UCabcdefghijklmnopqrstuv": true,
+		"https://youtube.com/feeds/videos.xml?playlist_id=PLabc":                       true,
+		"https://www.youtube.com/feeds/videos.xml":                                     false,
+		"https://www.youtube.com/playlist?list=PLabc":                                  false,
+		"https://example.com/feeds/videos.xml?channel_id=UCabcdefghijklmnopqrstuv":     false,
+		"jNQXAC9IVRw": false,
+	}
+	for input, want := range cases {
+		if got := IsFeedURL(input); got != want {
+			t.Fatalf("IsFeedURL(%q) = %v, want %v", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+)
+
+// interceptTransport applies Config.RequestInterceptor and
+// Config.ResponseInterceptor to Innertube API calls and, with
+// InterceptMediaRequests, to media downloads.
+type interceptTransport struct {
+	base       http.RoundTripper
+	onRequest  func(*http.Request)
+	onResponse func(*http.Response)
+	media      bool
+}
+
+func (t *interceptTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.intercepts(req) {
+		return t.base.RoundTrip(req)
+	}
+	if t.onRequest != nil {
+		// A RoundTripper must not modify its request.
+		req = req.Clone(req.Context())
+		t.onRequest(req)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && t.onResponse != nil {
+		t.onResponse(resp)
+	}
+	return resp, err
+}
+
+func (t *interceptTransport) intercepts(req *http.Request) bool {
+	if isInnertubeAPIRequest(req) {
+		return true
+	}
+	return t.media && isMediaRequest(req)
+}
+
+func isInnertubeAPIRequest(req *http.Request) bool {
+	return strings.HasPrefix(req.URL.Path, "/youtubei/")
+}
+
+func isMediaRequest(req *http.Request) bool {
+	host := strings.ToLower(req.URL.Hostname())
+	return host == "googlevideo.com" || strings.HasSuffix(host, ".googlevideo.com")
+}
+
+// withInterceptTransport returns a copy of hc that applies the configured
+// interceptors, or hc itself when none are set.
+func withInterceptTransport(hc *http.Client, config Config) *http.Client {
+	if config.RequestInterceptor == nil && config.ResponseInterceptor == nil {
+		return hc
+	}
+	base := hc.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *hc
+	wrapped.Transport = &interceptTransport{
+		base:       base,
+		onRequest:  config.RequestInterceptor,
+		onResponse: config.ResponseInterceptor,
+		media:      config.InterceptMediaRequests,
+	}
+	return &wrapped
+}
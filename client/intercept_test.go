@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestInterceptors_ApplyToInnertubeRequests(t *testing.T) {
+	var signed, unsigned []string
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get("X-Signature") != "" {
+			signed = append(signed, r.URL.Path)
+		} else {
+			unsigned = append(unsigned, r.URL.Path)
+		}
+		body := `<html></html>`
+		if strings.Contains(r.URL.Path, "/youtubei/v1/player") {
+			body = `{"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo"}}`
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}
+
+	var responses int
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+		RequestInterceptor: func(r *http.Request) {
+			r.Header.Set("X-Signature", "signed")
+		},
+		ResponseInterceptor: func(resp *http.Response) {
+			responses++
+			resp.Header.Set("X-Seen", "1")
+		},
+	})
+	if _, err := c.GetVideo(context.Background(), "jNQXAC9IVRw"); err != nil {
+		t.Fatalf("GetVideo() error = %v", err)
+	}
+	if len(signed) != 1 || signed[0] != "/youtubei/v1/player" || responses != 1 {
+		t.Fatalf("signed=%v responses=%d, want only the player request", signed, responses)
+	}
+	for _, path := range unsigned {
+		if strings.HasPrefix(path, "/youtubei/") {
+			t.Fatalf("unsigned Innertube request %s", path)
+		}
+	}
+	if httpClient.Transport == c.config.HTTPClient.Transport {
+		t.Fatalf("caller's HTTP client transport was replaced instead of copied")
+	}
+}
+
+func TestInterceptors_MediaRequestsOptIn(t *testing.T) {
+	var sawHeader bool
+	base := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sawHeader = r.Header.Get("X-Signature") != ""
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+	mediaURL := "https://rr1---sn-abc.googlevideo.com/videoplayback?itag=18"
+	for _, media := range []bool{false, true} {
+		hc := withInterceptTransport(&http.Client{Transport: base}, Config{
+			RequestInterceptor:     func(r *http.Request) { r.Header.Set("X-Signature", "signed") },
+			InterceptMediaRequests: media,
+		})
+		req, _ := http.NewRequest(http.MethodGet, mediaURL, nil)
+		resp, err := hc.Do(req)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		resp.Body.Close()
+		if sawHeader != media {
+			t.Fatalf("InterceptMediaRequests=%v: intercepted=%v", media, sawHeader)
+		}
+		if req.Header.Get("X-Signature") != "" {
+			t.Fatalf("interceptor modified the caller's request")
+		}
+	}
+}
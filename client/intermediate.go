@@ -0,0 +1,186 @@
+package client
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+const (
+	// intermediateLockSuffix marks a merge output whose deterministic
+	// intermediate names are owned by a running download.
+	intermediateLockSuffix = ".ytv1-lock"
+	// intermediateLockHeartbeat is how often a held lock's mtime is refreshed.
+	intermediateLockHeartbeat = time.Minute
+	// intermediateLockStaleAfter is how long a lock may go without a heartbeat
+	// before it is considered abandoned by a crashed run.
+	intermediateLockStaleAfter = 5 * time.Minute
+)
+
+var intermediateRunSeq atomic.Int64
+
+// intermediateLock claims the deterministic intermediate file names of one
+// merge output. Deterministic names let a rerun resume; the lock keeps two
+// concurrent runs of the same video from writing into the same files.
+type intermediateLock struct {
+	path string
+	stop chan struct{}
+	done chan struct{}
+}
+
+// acquireIntermediateLock claims basePath's intermediates. It returns nil when
+// another live download holds them; locks without a recent heartbeat are taken over.
+func acquireIntermediateLock(basePath string) *intermediateLock {
+	l, _ := acquireLockFile(basePath + intermediateLockSuffix)
+	return l
+}
+
+// acquireLockFile creates the lock file at path and keeps its heartbeat
+// going until release. It fails with a *WorkspaceLockedError while another
+// process refreshes the file; a lock without a recent heartbeat is taken over.
+func acquireLockFile(path string) (*intermediateLock, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, _ = fmt.Fprintf(f, "%d\n", os.Getpid())
+			_ = f.Close()
+			l := &intermediateLock{path: path, stop: make(chan struct{}), done: make(chan struct{})}
+			go l.heartbeat()
+			return l, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		st, statErr := os.Stat(path)
+		if statErr != nil {
+			return nil, statErr
+		}
+		if time.Since(st.ModTime()) < intermediateLockStaleAfter {
+			held := &WorkspaceLockedError{Path: path}
+			if raw, err := os.ReadFile(path); err == nil {
+				held.PID, _ = strconv.Atoi(strings.TrimSpace(string(raw)))
+			}
+			return nil, held
+		}
+		_ = os.Remove(path)
+	}
+	return nil, &WorkspaceLockedError{Path: path}
+}
+
+func (l *intermediateLock) heartbeat() {
+	defer close(l.done)
+	ticker := time.NewTicker(intermediateLockHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case t := <-ticker.C:
+			_ = os.Chtimes(l.path, t, t)
+		}
+	}
+}
+
+func (l *intermediateLock) release() {
+	if l == nil {
+		return
+	}
+	close(l.stop)
+	<-l.done
+	_ = os.Remove(l.path)
+}
+
+// newIntermediateRunToken returns a per-run name component used when the
+// deterministic intermediate names are held by another download.
+func newIntermediateRunToken() string {
+	return "r" + strconv.Itoa(os.Getpid()) + "-" + strconv.FormatInt(intermediateRunSeq.Add(1), 10)
+}
+
+// intermediatePartPath names the intermediate file of one merge stream:
+// <output>.f<itag>[-<lang>][.<run token>].<video|audio>.
+func intermediatePartPath(basePath string, f types.FormatInfo, kind, runToken string) string {
+	path := basePath + ".f" + mergePartID(f)
+	if runToken != "" {
+		path += "." + runToken
+	}
+	return path + "." + kind
+}
+
+var mergeIntermediatePattern = regexp.MustCompile(`^(.*)\.f\d+(?:-[^.]+)?(?:\.r\d+-\d+)?\.(?:video|audio)$`)
+
+// OrphanedFile is a leftover intermediate or partial download file.
+type OrphanedFile struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+// FindOrphanedFiles walks dir for intermediate download files last modified
+// before cutoff: merge stream intermediates (.f<itag>.video/.audio), .part and
+// .frag-state files, and .ytv1-lock intermediate and workspace locks. Files guarded by a lock
+// that a live download still refreshes are skipped. Results are sorted by path.
+func FindOrphanedFiles(dir string, cutoff time.Time) ([]OrphanedFile, error) {
+	var out []OrphanedFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		base, ok := orphanCandidateBase(path, name)
+		if !ok {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if !info.ModTime().Before(cutoff) {
+			return nil
+		}
+		if base != "" && intermediateLockHeld(base) {
+			return nil
+		}
+		out = append(out, OrphanedFile{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// orphanCandidateBase reports whether name looks like an intermediate file and,
+// for merge intermediates and locks, the output path whose lock guards it.
+func orphanCandidateBase(path, name string) (string, bool) {
+	switch {
+	case strings.HasSuffix(name, ".part"), strings.HasSuffix(name, ".frag-state"):
+		return "", true
+	case strings.HasSuffix(name, intermediateLockSuffix):
+		return strings.TrimSuffix(path, intermediateLockSuffix), true
+	}
+	if m := mergeIntermediatePattern.FindStringSubmatch(path); m != nil {
+		return m[1], true
+	}
+	return "", false
+}
+
+func intermediateLockHeld(basePath string) bool {
+	st, err := os.Stat(basePath + intermediateLockSuffix)
+	if err != nil {
+		return false
+	}
+	return time.Since(st.ModTime()) < intermediateLockStaleAfter
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireIntermediateLock_ExclusiveUntilReleasedOrStale(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "out.mp4")
+	lock := acquireIntermediateLock(base)
+	if lock == nil {
+		t.Fatal("expected first acquire to succeed")
+	}
+	if second := acquireIntermediateLock(base); second != nil {
+		second.release()
+		t.Fatal("expected concurrent acquire to fail while lock is held")
+	}
+	lock.release()
+	if _, err := os.Stat(base + intermediateLockSuffix); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected lock file removed on release, stat err=%v", err)
+	}
+
+	// A crashed run leaves a lock without heartbeat; it is taken over.
+	if err := os.WriteFile(base+intermediateLockSuffix, []byte("1\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	old := time.Now().Add(-2 * intermediateLockStaleAfter)
+	if err := os.Chtimes(base+intermediateLockSuffix, old, old); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+	lock = acquireIntermediateLock(base)
+	if lock == nil {
+		t.Fatal("expected stale lock to be taken over")
+	}
+	lock.release()
+}
+
+func TestDownloadAndMerge_LockedOutputUsesRunScopedIntermediates(t *testing.T) {
+	mediaBase := "https://media.example"
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+					"streamingData":{"adaptiveFormats":[
+						{"itag":248,"url":"` + mediaBase + `/v.webm","mimeType":"video/webm","bitrate":1000},
+						{"itag":251,"url":"` + mediaBase + `/a.webm","mimeType":"audio/webm","bitrate":1000}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/v.webm":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("video")), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/a.webm":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("audio")), Header: make(http.Header)}, nil
+			default:
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+			}
+		}),
+	}
+	out := filepath.Join(t.TempDir(), "merged.webm")
+	// Simulate another run mid-download of the same output.
+	other := acquireIntermediateLock(out)
+	if other == nil {
+		t.Fatal("expected lock acquire to succeed")
+	}
+	defer other.release()
+	if err := os.WriteFile(out+".f248.video", []byte("other-run"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	var paths []string
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+		Muxer:           testMuxer{},
+		OnDownloadEvent: func(evt DownloadEvent) {
+			if evt.Stage == "download" && evt.Phase == "start" {
+				paths = append(paths, evt.Path)
+			}
+		},
+	})
+	if _, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{Mode: SelectionModeBest, OutputPath: out, Resume: true}); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	for _, p := range paths {
+		if p == out+".f248.video" || p == out+".f251.audio" {
+			t.Fatalf("expected run-scoped intermediate, got %q", p)
+		}
+	}
+	if got, _ := os.ReadFile(out + ".f248.video"); string(got) != "other-run" {
+		t.Fatalf("other run's intermediate was modified: %q", got)
+	}
+	if got, _ := os.ReadFile(out); string(got) != "videoaudio" {
+		t.Fatalf("merged output = %q", got)
+	}
+}
+
+func TestFindOrphanedFiles_SkipsFreshAndLockedFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	write := func(name string, stale bool) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll() error = %v", err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+		if stale {
+			if err := os.Chtimes(path, old, old); err != nil {
+				t.Fatalf("Chtimes() error = %v", err)
+			}
+		}
+		return path
+	}
+	staleVideo := write("sub/a.mp4.f137.video", true)
+	staleAudio := write("b.mkv.f251-en.r42-1.audio", true)
+	stalePart := write("c.mp4.part", true)
+	staleFrag := write("d.mp4.frag-state", true)
+	write("fresh.mp4.f137.video", false)
+	write("locked.mp4.f137.video", true)
+	write("locked.mp4"+intermediateLockSuffix, false)
+	write("notes.video", true)
+	write("yarn.lock", true)
+
+	got, err := FindOrphanedFiles(dir, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("FindOrphanedFiles() error = %v", err)
+	}
+	want := []string{staleAudio, stalePart, staleFrag, staleVideo}
+	if len(got) != len(want) {
+		t.Fatalf("FindOrphanedFiles() = %+v, want %v", got, want)
+	}
+	for i, f := range got {
+		if f.Path != want[i] {
+			t.Fatalf("FindOrphanedFiles()[%d] = %q, want %q", i, f.Path, want[i])
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+// JobState is the lifecycle state of a download Job.
+type JobState string
+
+const (
+	JobRunning   JobState = "running"
+	JobPaused    JobState = "paused"
+	JobCompleted JobState = "completed"
+	JobFailed    JobState = "failed"
+	JobCanceled  JobState = "canceled"
+)
+
+// Job is an asynchronous Download started by StartDownload, for frontends
+// that pause, resume and cancel transfers. Its methods are safe for
+// concurrent use.
+//
+// Pause stops the transfer and keeps what was written: partial output and
+// finished merge intermediates stay on disk, and Resume runs Download again
+// with DownloadOptions.Resume so it continues from them.
+type Job struct {
+	client  *Client
+	ctx     context.Context
+	input   string
+	options DownloadOptions
+
+	// ops serializes Pause, Resume and Cancel, so a run is fully stopped
+	// before the next one starts.
+	ops    sync.Mutex
+	mu     sync.Mutex
+	state  JobState
+	stop   context.CancelFunc // cancels the current run
+	ran    chan struct{}      // closed when the current run returns
+	done   chan struct{}      // closed when the job ends
+	result *DownloadResult
+	err    error
+	// unwatch stops the ctx watch that ends a paused job.
+	unwatch func() bool
+}
+
+// StartDownload starts Download(ctx, input, options) in the background and
+// returns its Job. options.Resume is forced on so Pause/Resume continue
+// partial files. Invalid inputs fail here; everything else is reported by
+// Job.Wait. Cancelling ctx cancels the job.
+func (c *Client) StartDownload(ctx context.Context, input string, options DownloadOptions) (*Job, error) {
+	if _, err := c.resolveVideoInput(ctx, input); err != nil {
+		return nil, err
+	}
+	options.Resume = true
+	j := &Job{client: c, ctx: ctx, input: input, options: options, done: make(chan struct{})}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.startLocked()
+	// A running job sees ctx through its run; a paused one has no run.
+	j.unwatch = context.AfterFunc(ctx, func() {
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		if j.state == JobPaused {
+			j.finishLocked(JobCanceled, nil, ErrJobCanceled)
+		}
+	})
+	return j, nil
+}
+
+func (j *Job) startLocked() {
+	runCtx, stop := context.WithCancel(j.ctx)
+	ran := make(chan struct{})
+	j.state, j.stop, j.ran = JobRunning, stop, ran
+	go func() {
+		res, err := j.client.Download(runCtx, j.input, j.options)
+		stop()
+		j.mu.Lock()
+		defer j.mu.Unlock()
+		close(ran)
+		switch {
+		case j.state != JobRunning:
+			// Paused or cancelled; the run's error is just the interruption.
+		case err == nil:
+			j.finishLocked(JobCompleted, res, nil)
+		case j.ctx.Err() != nil:
+			j.finishLocked(JobCanceled, nil, ErrJobCanceled)
+		default:
+			j.finishLocked(JobFailed, nil, err)
+		}
+	}()
+}
+
+func (j *Job) finishLocked(state JobState, res *DownloadResult, err error) {
+	j.state, j.result, j.err = state, res, err
+	if j.unwatch != nil {
+		j.unwatch()
+	}
+	close(j.done)
+}
+
+// interruptLocked stops the current run and waits for it to return, so its
+// files are closed before Pause or Cancel returns. j.mu is released while
+// waiting.
+func (j *Job) interruptLocked() {
+	j.stop()
+	ran := j.ran
+	j.mu.Unlock()
+	<-ran
+	j.mu.Lock()
+}
+
+// Pause stops the transfer, keeping partial files for Resume. Pausing a
+// paused job does nothing; a job that already ended returns ErrJobFinished.
+func (j *Job) Pause() error {
+	j.ops.Lock()
+	defer j.ops.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch j.state {
+	case JobPaused:
+		return nil
+	case JobRunning:
+		j.state = JobPaused
+		j.interruptLocked()
+		return nil
+	default:
+		return ErrJobFinished
+	}
+}
+
+// Resume restarts a paused job from its partial files. Resuming a running
+// job does nothing; a job that already ended returns ErrJobFinished.
+func (j *Job) Resume() error {
+	j.ops.Lock()
+	defer j.ops.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch j.state {
+	case JobRunning:
+		return nil
+	case JobPaused:
+		if j.ctx.Err() != nil {
+			j.finishLocked(JobCanceled, nil, ErrJobCanceled)
+			return ErrJobFinished
+		}
+		j.startLocked()
+		return nil
+	default:
+		return ErrJobFinished
+	}
+}
+
+// Cancel stops the job for good; Wait then returns ErrJobCanceled. Partial
+// files are left in place, so a later Download with Resume can still use
+// them. Cancelling an ended job does nothing.
+func (j *Job) Cancel() {
+	j.ops.Lock()
+	defer j.ops.Unlock()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	switch j.state {
+	case JobRunning:
+		j.state = JobCanceled
+		j.interruptLocked()
+		j.finishLocked(JobCanceled, nil, ErrJobCanceled)
+	case JobPaused:
+		j.finishLocked(JobCanceled, nil, ErrJobCanceled)
+	}
+}
+
+// State reports the job's current state.
+func (j *Job) State() JobState {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.state
+}
+
+// Done is closed when the job completes, fails or is cancelled.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Wait blocks until the job ends and returns Download's result.
+func (j *Job) Wait() (*DownloadResult, error) {
+	<-j.done
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.result, j.err
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pausableBody yields data, signals started, then blocks until the request
+// is cancelled.
+type pausableBody struct {
+	ctx     context.Context
+	data    *strings.Reader
+	started chan<- struct{}
+}
+
+func (b *pausableBody) Read(p []byte) (int, error) {
+	if b.data.Len() > 0 {
+		return b.data.Read(p)
+	}
+	select {
+	case b.started <- struct{}{}:
+	default:
+	}
+	<-b.ctx.Done()
+	return 0, b.ctx.Err()
+}
+
+func (b *pausableBody) Close() error { return nil }
+
+func newJobTestClient(t *testing.T, payload string, started chan<- struct{}, ranges *[]string) *Client {
+	t.Helper()
+	const mediaURL = "https://media.example/v18.mp4"
+	var mu sync.Mutex
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		switch {
+		case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+			body := `{
+				"playabilityStatus":{"status":"OK"},
+				"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+				"streamingData":{"formats":[{"itag":18,"url":"` + mediaURL + `","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}]}
+			}`
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+		case r.Method == http.MethodGet && r.URL.String() == mediaURL:
+			rng := r.Header.Get("Range")
+			mu.Lock()
+			*ranges = append(*ranges, rng)
+			mu.Unlock()
+			if rng == "" {
+				body := &pausableBody{ctx: r.Context(), data: strings.NewReader(payload[:len(payload)/2]), started: started}
+				return &http.Response{StatusCode: http.StatusOK, Body: body, Header: make(http.Header)}, nil
+			}
+			var start int
+			if _, err := fmt.Sscanf(rng, "bytes=%d-", &start); err != nil {
+				t.Errorf("unexpected range %q", rng)
+			}
+			header := make(http.Header)
+			header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(payload)-1, len(payload)))
+			return &http.Response{StatusCode: http.StatusPartialContent, Body: io.NopCloser(strings.NewReader(payload[start:])), Header: header}, nil
+		default:
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+		}
+	})}
+	return New(Config{
+		HTTPClient:        httpClient,
+		ClientOverrides:   []string{"mweb"},
+		DownloadTransport: DownloadTransportConfig{MaxConcurrency: 1},
+	})
+}
+
+func waitStarted(t *testing.T, started <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("transfer did not start")
+	}
+}
+
+func TestStartDownload_PauseResumeContinuesPartialFile(t *testing.T) {
+	payload := strings.Repeat("0123456789", 400)
+	started := make(chan struct{}, 1)
+	var ranges []string
+	c := newJobTestClient(t, payload, started, &ranges)
+	out := filepath.Join(t.TempDir(), "out.mp4")
+
+	job, err := c.StartDownload(context.Background(), "jNQXAC9IVRw", DownloadOptions{Itag: 18, OutputPath: out})
+	if err != nil {
+		t.Fatalf("StartDownload() error = %v", err)
+	}
+	waitStarted(t, started)
+	if err := job.Pause(); err != nil || job.State() != JobPaused {
+		t.Fatalf("Pause() error = %v state=%s", err, job.State())
+	}
+	if st, err := os.Stat(out); err != nil || st.Size() != int64(len(payload)/2) {
+		t.Fatalf("partial file after pause: %v %v", st, err)
+	}
+
+	if err := job.Resume(); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	res, err := job.Wait()
+	if err != nil || job.State() != JobCompleted {
+		t.Fatalf("Wait() error = %v state=%s", err, job.State())
+	}
+	data, _ := os.ReadFile(out)
+	if string(data) != payload || res.Bytes != int64(len(payload)) {
+		t.Fatalf("output = %d bytes (result %d), want the full payload", len(data), res.Bytes)
+	}
+	if len(ranges) != 2 || ranges[1] != fmt.Sprintf("bytes=%d-", len(payload)/2) {
+		t.Fatalf("media requests = %q, want a range resume", ranges)
+	}
+	if err := job.Pause(); !errors.Is(err, ErrJobFinished) {
+		t.Fatalf("Pause() after completion error = %v", err)
+	}
+}
+
+func TestStartDownload_Cancel(t *testing.T) {
+	started := make(chan struct{}, 1)
+	var ranges []string
+	c := newJobTestClient(t, strings.Repeat("x", 4000), started, &ranges)
+
+	if _, err := c.StartDownload(context.Background(), "not a video", DownloadOptions{}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("StartDownload(invalid) error = %v", err)
+	}
+
+	job, err := c.StartDownload(context.Background(), "jNQXAC9IVRw", DownloadOptions{Itag: 18, OutputPath: filepath.Join(t.TempDir(), "out.mp4")})
+	if err != nil {
+		t.Fatalf("StartDownload() error = %v", err)
+	}
+	waitStarted(t, started)
+	job.Cancel()
+	if _, err := job.Wait(); !errors.Is(err, ErrJobCanceled) || job.State() != JobCanceled {
+		t.Fatalf("Wait() error = %v state=%s", err, job.State())
+	}
+	if err := job.Resume(); !errors.Is(err, ErrJobFinished) {
+		t.Fatalf("Resume() after Cancel error = %v", err)
+	}
+}
+
+func TestStartDownload_ContextCanceledWhilePaused(t *testing.T) {
+	started := make(chan struct{}, 1)
+	var ranges []string
+	c := newJobTestClient(t, strings.Repeat("x", 4000), started, &ranges)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	job, err := c.StartDownload(ctx, "jNQXAC9IVRw", DownloadOptions{Itag: 18, OutputPath: filepath.Join(t.TempDir(), "out.mp4")})
+	if err != nil {
+		t.Fatalf("StartDownload() error = %v", err)
+	}
+	waitStarted(t, started)
+	if err := job.Pause(); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	cancel()
+	select {
+	case <-job.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("paused job did not end when its context was cancelled")
+	}
+	if _, err := job.Wait(); !errors.Is(err, ErrJobCanceled) || job.State() != JobCanceled {
+		t.Fatalf("Wait() error = %v state=%s", err, job.State())
+	}
+}
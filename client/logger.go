@@ -1,6 +1,9 @@
 package client
 
-// ExtractionEvent represents one extraction-stage lifecycle event.
+// ExtractionEvent represents one extraction-stage lifecycle event. Besides
+// video extraction stages (player_api_json, webpage, player_js, challenge,
+// manifest), list enumeration reports playlist_page, playlist_continuation,
+// playlist, channel_page and channel_continuation stages.
 type ExtractionEvent struct {
 	Stage  string
 	Phase  string
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// mediaURL edits the query of a media URL without re-encoding it: existing
+// parameters (sparams, sig, n, ctt, ...) keep their order and escaping,
+// which url.Values.Encode would sort and normalize.
+type mediaURL struct {
+	u      url.URL
+	params []string // raw "key=value" pairs in order
+}
+
+func parseMediaURL(raw string) (*mediaURL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, err
+	}
+	return newMediaURL(u), nil
+}
+
+func newMediaURL(u *url.URL) *mediaURL {
+	m := &mediaURL{u: *u}
+	if u.RawQuery != "" {
+		m.params = strings.Split(u.RawQuery, "&")
+	}
+	return m
+}
+
+// has reports whether key is set as a query parameter or as a path-style
+// /key/value segment, as manifest and segment URLs carry them.
+func (m *mediaURL) has(key string) bool {
+	if i := m.index(key); i >= 0 {
+		_, v, _ := strings.Cut(m.params[i], "=")
+		return strings.TrimSpace(v) != ""
+	}
+	return strings.Contains(m.u.Path, "/"+key+"/")
+}
+
+func (m *mediaURL) index(key string) int {
+	for i, p := range m.params {
+		k, _, _ := strings.Cut(p, "=")
+		if unescaped, err := url.QueryUnescape(k); err == nil && unescaped == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// set replaces the value of key where it stands, or appends it.
+func (m *mediaURL) set(key, value string) {
+	p := url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	if i := m.index(key); i >= 0 {
+		m.params[i] = p
+		return
+	}
+	m.params = append(m.params, p)
+}
+
+func (m *mediaURL) URL() *url.URL {
+	u := m.u
+	u.RawQuery = strings.Join(m.params, "&")
+	return &u
+}
+
+func (m *mediaURL) String() string {
+	return m.URL().String()
+}
+
+// mediaRequestKey carries a download's mediaRequestState.
+type mediaRequestKey struct{}
+
+// mediaRequestState is the playback identity of one download: its client
+// playback nonce and request counter.
+type mediaRequestState struct {
+	params MediaURLParamsConfig
+	cpn    string
+	rn     atomic.Int64
+}
+
+// withMediaRequestState starts a playback for Config.MediaURLParams and
+// Config.EmulatePlayback, unless ctx already belongs to one.
+func (c *Client) withMediaRequestState(ctx context.Context) context.Context {
+	params := c.config.MediaURLParams
+	if c.config.EmulatePlayback.Enable {
+		params.CPN = true
+	}
+	if params == (MediaURLParamsConfig{}) || ctx.Value(mediaRequestKey{}) != nil {
+		return ctx
+	}
+	return context.WithValue(ctx, mediaRequestKey{}, &mediaRequestState{params: params, cpn: newCPN()})
+}
+
+// composeMediaRequestURL adds the Config.MediaURLParams of req's playback
+// to a googlevideo request. It runs after the Range header is set.
+func composeMediaRequestURL(req *http.Request) {
+	state, ok := req.Context().Value(mediaRequestKey{}).(*mediaRequestState)
+	if !ok || !isGoogleVideoHost(req.URL.Hostname()) {
+		return
+	}
+	m := newMediaURL(req.URL)
+	if state.params.CPN && !m.has("cpn") {
+		m.set("cpn", state.cpn)
+	}
+	if state.params.Range {
+		if start, end, ok := boundedByteRange(req.Header.Get("Range")); ok {
+			m.set("range", strconv.FormatInt(start, 10)+"-"+strconv.FormatInt(end, 10))
+		}
+	}
+	if state.params.RequestNumber {
+		m.set("rn", strconv.FormatInt(state.rn.Add(1), 10))
+		m.set("rbuf", "0")
+	}
+	req.URL = m.URL()
+}
+
+func isGoogleVideoHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == "googlevideo.com" || strings.HasSuffix(host, ".googlevideo.com")
+}
+
+// boundedByteRange parses a "bytes=start-end" Range header; open-ended
+// ranges have no range= form.
+func boundedByteRange(header string) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok {
+		return 0, 0, false
+	}
+	from, to, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	start, err1 := strconv.ParseInt(from, 10, 64)
+	end, err2 := strconv.ParseInt(to, 10, 64)
+	if err1 != nil || err2 != nil || start < 0 || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+const cpnAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+
+// newCPN returns a client playback nonce: 16 characters of the URL-safe
+// base64 alphabet, as the web player generates.
+func newCPN() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	for i := range b {
+		b[i] = cpnAlphabet[b[i]&63]
+	}
+	return string(b[:])
+}
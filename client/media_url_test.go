@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMediaURLKeepsParameterOrder(t *testing.T) {
+	const raw = "https://rr1.googlevideo.com/videoplayback?expire=1&sparams=expire%2Cid&id=o-abc&ctt=x%2By&sig=AB%3D%3D&n=q"
+	m, err := parseMediaURL(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.has("ctt") || m.has("pot") || m.has("range") {
+		t.Fatal("has() misreported parameters")
+	}
+	m.set("n", "decoded")
+	m.set("pot", "tok/en=")
+	want := "https://rr1.googlevideo.com/videoplayback?expire=1&sparams=expire%2Cid&id=o-abc&ctt=x%2By&sig=AB%3D%3D&n=decoded&pot=tok%2Fen%3D"
+	if got := m.String(); got != want {
+		t.Fatalf("String() =\n%s\nwant\n%s", got, want)
+	}
+
+	got, err := injectPoToken(raw, " token ")
+	if err != nil || !strings.HasPrefix(got, "https://rr1.googlevideo.com/videoplayback?expire=1&sparams=expire%2Cid&") || !strings.HasSuffix(got, "&pot=token") {
+		t.Fatalf("injectPoToken() = %q, %v", got, err)
+	}
+	if !hasPoTokenInURL("https://rr1.googlevideo.com/videoplayback/id/x/pot/abc/file/seg.ts") {
+		t.Fatal("path-style pot not detected")
+	}
+}
+
+func TestComposeMediaRequestURL(t *testing.T) {
+	c := &Client{config: Config{MediaURLParams: MediaURLParamsConfig{CPN: true, Range: true, RequestNumber: true}}}
+	ctx := c.withMediaRequestState(context.Background())
+	if again := c.withMediaRequestState(ctx); again != ctx {
+		t.Fatal("nested download started a second playback")
+	}
+
+	request := func(rawURL, rng string) *http.Request {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if rng != "" {
+			req.Header.Set("Range", rng)
+		}
+		applyMediaRequestHeaders(req, nil, "jNQXAC9IVRw")
+		return req
+	}
+	first := request("https://rr1.googlevideo.com/videoplayback?itag=18&sig=x", "bytes=0-1023").URL.Query()
+	second := request("https://rr1.googlevideo.com/videoplayback?itag=18&sig=x", "bytes=1024-").URL.Query()
+	if first.Get("range") != "0-1023" || second.Has("range") {
+		t.Fatalf("range = %q then %q, want a bounded range only", first.Get("range"), second.Get("range"))
+	}
+	if first.Get("rn") != "1" || second.Get("rn") != "2" || first.Get("rbuf") != "0" {
+		t.Fatalf("rn = %q, %q rbuf = %q", first.Get("rn"), second.Get("rn"), first.Get("rbuf"))
+	}
+	if cpn := first.Get("cpn"); len(cpn) != 16 || cpn != second.Get("cpn") {
+		t.Fatalf("cpn = %q then %q, want one 16-character nonce", cpn, second.Get("cpn"))
+	}
+
+	other := request("https://i.ytimg.com/sb/x/storyboard3_L1/M0.jpg", "")
+	if other.URL.RawQuery != "" {
+		t.Fatalf("non-media host got parameters: %s", other.URL)
+	}
+	plain, _ := http.NewRequest(http.MethodGet, "https://rr1.googlevideo.com/videoplayback?itag=18", nil)
+	applyMediaRequestHeaders(plain, nil, "")
+	if plain.URL.RawQuery != "itag=18" {
+		t.Fatalf("request outside a playback got parameters: %s", plain.URL)
+	}
+}
+
+func TestNewCPN(t *testing.T) {
+	a, b := newCPN(), newCPN()
+	if len(a) != 16 || a == b || strings.Trim(a, cpnAlphabet) != "" {
+		t.Fatalf("newCPN() = %q, %q", a, b)
+	}
+}
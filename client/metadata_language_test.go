@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func metadataLanguageClient(t *testing.T, lang string, gotHL *string) *Client {
+	t.Helper()
+	return New(Config{
+		ClientOverrides:  []string{"mweb"},
+		MetadataLanguage: lang,
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			body := `<html></html>`
+			if strings.Contains(r.URL.Path, "/youtubei/v1/player") {
+				var req struct {
+					Context struct {
+						Client struct {
+							HL string `json:"hl"`
+						} `json:"client"`
+					} `json:"context"`
+				}
+				raw, _ := io.ReadAll(r.Body)
+				_ = json.Unmarshal(raw, &req)
+				*gotHL = req.Context.Client.HL
+				title := "Me at the zoo"
+				if req.Context.Client.HL == "ko" {
+					title = "동물원에서 나"
+				}
+				body = `{"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo","shortDescription":"elephants"},
+					"microformat":{"playerMicroformatRenderer":{"title":{"simpleText":"` + title + `"},"description":{"simpleText":"elephants"},"defaultLanguage":"en"}}}`
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(body))}, nil
+		})},
+	})
+}
+
+func TestGetVideo_MetadataLanguage(t *testing.T) {
+	var hl string
+	info, err := metadataLanguageClient(t, "ko", &hl).GetVideo(context.Background(), "jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("GetVideo() error = %v", err)
+	}
+	if hl != "ko" {
+		t.Fatalf("player request hl = %q, want ko", hl)
+	}
+	if info.Title != "동물원에서 나" || info.LocalizedTitle != info.Title {
+		t.Fatalf("Title = %q LocalizedTitle = %q, want the Korean title", info.Title, info.LocalizedTitle)
+	}
+	if info.Description != "elephants" || info.DefaultLanguage != "en" || info.Language != "ko" {
+		t.Fatalf("Description = %q DefaultLanguage = %q Language = %q", info.Description, info.DefaultLanguage, info.Language)
+	}
+}
+
+func TestGetVideo_MetadataLanguageUnsetKeepsOriginal(t *testing.T) {
+	var hl string
+	info, err := metadataLanguageClient(t, "", &hl).GetVideo(context.Background(), "jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("GetVideo() error = %v", err)
+	}
+	if hl != "en" || info.Title != "Me at the zoo" || info.Language != "en" {
+		t.Fatalf("hl = %q Title = %q Language = %q, want original English metadata", hl, info.Title, info.Language)
+	}
+}
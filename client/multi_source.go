@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+// mirrorStreamURLs returns other URLs serving the same bytes as f at
+// streamURL: resolved URLs of f's deduplicated copies and of session formats
+// with the same MIME type and content length, and streamURL on the alternate CDN nodes listed in its mn
+// parameter. Callers still verify each mirror's size before using it.
+func (c *Client) mirrorStreamURLs(ctx context.Context, videoID string, f types.FormatInfo, streamURL string) []string {
+	seen := map[string]bool{streamURL: true}
+	var out []string
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			out = append(out, u)
+		}
+	}
+	for _, dup := range c.formatDuplicates(videoID, f) {
+		if dup.Protocol == "hls" || dup.Protocol == "dash" {
+			continue
+		}
+		if u, err := c.resolveSelectedFormatURL(ctx, videoID, dup); err == nil {
+			add(u)
+		}
+	}
+	if f.ContentLength > 0 && !c.isExtractedVideo(videoID) {
+		if session, ok := c.getSession(videoID); ok && session.Info != nil {
+			for _, g := range session.Info.Formats {
+				if g.URL == f.URL || g.ContentLength != f.ContentLength || g.MimeType != f.MimeType {
+					continue
+				}
+				if g.Protocol == "hls" || g.Protocol == "dash" {
+					continue
+				}
+				if u, err := c.resolveSelectedFormatURL(ctx, videoID, g); err == nil {
+					add(u)
+				}
+			}
+		}
+	}
+	for _, u := range mnMirrorURLs(streamURL) {
+		add(u)
+	}
+	return out
+}
+
+// mnMirrorURLs moves a rr<N>---<node>.googlevideo.com URL onto the other
+// nodes named in its mn parameter, which serve the same stream.
+func mnMirrorURLs(streamURL string) []string {
+	u, err := url.Parse(streamURL)
+	if err != nil {
+		return nil
+	}
+	prefix, rest, ok := strings.Cut(u.Hostname(), "---")
+	if !ok || !strings.HasPrefix(prefix, "rr") {
+		return nil
+	}
+	node, domain, ok := strings.Cut(rest, ".")
+	if !ok || domain != "googlevideo.com" {
+		return nil
+	}
+	var out []string
+	for _, alt := range strings.Split(u.Query().Get("mn"), ",") {
+		alt = strings.TrimSpace(alt)
+		if alt == "" || alt == node {
+			continue
+		}
+		mirror := *u
+		mirror.Host = prefix + "---" + alt + "." + domain
+		out = append(out, mirror.String())
+	}
+	return out
+}
+
+// chunkScheduler hands pending chunks to download workers, assigning each
+// worker a live source, and moves chunks off sources that fail.
+type chunkScheduler struct {
+	queue chan int
+
+	mu        sync.Mutex
+	live      []bool
+	remaining int
+}
+
+func newChunkScheduler(chunks, sources int) *chunkScheduler {
+	s := &chunkScheduler{
+		queue:     make(chan int, chunks),
+		live:      make([]bool, sources),
+		remaining: chunks,
+	}
+	for i := 0; i < chunks; i++ {
+		s.queue <- i
+	}
+	for i := range s.live {
+		s.live[i] = true
+	}
+	if chunks == 0 {
+		close(s.queue)
+	}
+	return s
+}
+
+// next returns the next pending chunk and the source worker fetches it from.
+// ok is false once every chunk is done or ctx is canceled.
+func (s *chunkScheduler) next(ctx context.Context, worker int) (chunk, source int, ok bool) {
+	select {
+	case i, open := <-s.queue:
+		if !open {
+			return 0, 0, false
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for k := range s.live {
+			if idx := (worker + k) % len(s.live); s.live[idx] {
+				return i, idx, true
+			}
+		}
+		return 0, 0, false
+	case <-ctx.Done():
+		return 0, 0, false
+	}
+}
+
+func (s *chunkScheduler) done() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remaining--
+	if s.remaining == 0 {
+		close(s.queue)
+	}
+}
+
+// fail drops source and requeues chunk for the remaining sources. It
+// reports false when no source is left.
+func (s *chunkScheduler) fail(chunk, source int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.live[source] = false
+	for _, alive := range s.live {
+		if alive {
+			s.queue <- chunk
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMnMirrorURLs(t *testing.T) {
+	got := mnMirrorURLs("https://rr3---sn-aaa.googlevideo.com/videoplayback?itag=18&mn=sn-aaa%2Csn-bbb")
+	want := []string{"https://rr3---sn-bbb.googlevideo.com/videoplayback?itag=18&mn=sn-aaa%2Csn-bbb"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("mnMirrorURLs() = %q, want %q", got, want)
+	}
+	if got := mnMirrorURLs("https://media.example/v.mp4?mn=sn-aaa,sn-bbb"); got != nil {
+		t.Fatalf("mnMirrorURLs(non-googlevideo) = %q, want nil", got)
+	}
+}
+
+func TestDownloadURLChunked_MultiSourceMovesChunksOffFailingSource(t *testing.T) {
+	payload := "0123456789abcdef"
+	var mu sync.Mutex
+	hits := map[string]int{}
+	// The mirror holds its chunks until the primary has failed one, so both
+	// sources are in use when the primary drops out.
+	primaryFailed := make(chan struct{})
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var start, end int
+		fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end)
+		probe := end == 0
+		mu.Lock()
+		hits[r.URL.Host]++
+		mu.Unlock()
+		size := len(payload)
+		switch {
+		case r.URL.Host == "short.example":
+			size = 8
+		case r.URL.Host == "primary.example" && !probe:
+			close(primaryFailed)
+			return &http.Response{StatusCode: http.StatusForbidden, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+		case r.URL.Host == "mirror.example" && !probe:
+			<-primaryFailed
+		}
+		h := make(http.Header)
+		h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+		return &http.Response{StatusCode: http.StatusPartialContent, Body: io.NopCloser(strings.NewReader(payload[start : end+1])), Header: h}, nil
+	})}
+
+	ctx := context.WithValue(context.Background(), mirrorURLsKey{}, []string{"https://short.example/v.mp4", "https://mirror.example/v.mp4"})
+	ctx, stats := withDownloadStats(ctx)
+	cfg := normalizeDownloadTransportConfig(DownloadTransportConfig{EnableChunked: true, ChunkSize: 4, MaxConcurrency: 2, MultiSource: true})
+	out := filepath.Join(t.TempDir(), "out.mp4")
+
+	n, err := downloadURLChunked(ctx, httpClient, "https://primary.example/v.mp4", out, cfg, "", nil)
+	if err != nil {
+		t.Fatalf("downloadURLChunked() error = %v", err)
+	}
+	got, _ := os.ReadFile(out)
+	if n != int64(len(payload)) || string(got) != payload {
+		t.Fatalf("downloaded %d bytes %q, want %q", n, got, payload)
+	}
+	if hits["short.example"] != 1 {
+		t.Fatalf("short.example hits = %d, want only the size probe", hits["short.example"])
+	}
+	if hits["primary.example"] != 2 || hits["mirror.example"] != 5 || stats.retries.Load() != 1 {
+		t.Fatalf("hits = %v retries = %d", hits, stats.retries.Load())
+	}
+}
+
+func TestDownloadURLChunked_MultiSourceFailsWhenAllSourcesFail(t *testing.T) {
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get("Range") != "bytes=0-0" {
+			return &http.Response{StatusCode: http.StatusForbidden, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+		}
+		h := make(http.Header)
+		h.Set("Content-Range", "bytes 0-0/8")
+		return &http.Response{StatusCode: http.StatusPartialContent, Body: io.NopCloser(strings.NewReader("0")), Header: h}, nil
+	})}
+	ctx := context.WithValue(context.Background(), mirrorURLsKey{}, []string{"https://mirror.example/v.mp4"})
+	cfg := normalizeDownloadTransportConfig(DownloadTransportConfig{EnableChunked: true, ChunkSize: 4, MaxConcurrency: 2, MultiSource: true})
+
+	_, err := downloadURLChunked(ctx, httpClient, "https://primary.example/v.mp4", filepath.Join(t.TempDir(), "out.mp4"), cfg, "", nil)
+	if !isDownloadForbidden(err) {
+		t.Fatalf("downloadURLChunked() error = %v, want 403", err)
+	}
+}
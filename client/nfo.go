@@ -0,0 +1,131 @@
+package client
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// NFOStyle selects the Kodi/Jellyfin .nfo root element WriteNFO emits.
+type NFOStyle string
+
+// NFOStyle values.
+const (
+	// NFOEpisode writes <episodedetails>, treating the channel as a show;
+	// pair it with WriteShowNFO in the channel folder.
+	NFOEpisode NFOStyle = "episode"
+	// NFOMovie writes <movie>, treating each video as a standalone film.
+	NFOMovie NFOStyle = "movie"
+)
+
+// NFOSuffix is the extension of the files WriteNFO writes.
+const NFOSuffix = ".nfo"
+
+// ShowNFOName is the show-level .nfo media servers read from a show folder.
+const ShowNFOName = "tvshow.nfo"
+
+type nfoUniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr"`
+	Value   string `xml:",chardata"`
+}
+
+// nfoVideo holds the fields shared by the <episodedetails> and <movie>
+// documents; empty fields are omitted.
+type nfoVideo struct {
+	XMLName   xml.Name
+	Title     string      `xml:"title"`
+	ShowTitle string      `xml:"showtitle,omitempty"`
+	Plot      string      `xml:"plot,omitempty"`
+	Runtime   string      `xml:"runtime,omitempty"`
+	Thumb     string      `xml:"thumb,omitempty"`
+	UniqueID  nfoUniqueID `xml:"uniqueid"`
+	Genre     string      `xml:"genre,omitempty"`
+	Tags      []string    `xml:"tag"`
+	Premiered string      `xml:"premiered,omitempty"`
+	Aired     string      `xml:"aired,omitempty"`
+	Year      string      `xml:"year,omitempty"`
+	Studio    string      `xml:"studio,omitempty"`
+}
+
+type nfoShow struct {
+	XMLName  xml.Name    `xml:"tvshow"`
+	Title    string      `xml:"title"`
+	UniqueID nfoUniqueID `xml:"uniqueid"`
+	Studio   string      `xml:"studio"`
+}
+
+// WriteNFO writes a Kodi/Jellyfin .nfo document for info to path in the
+// given style.
+func WriteNFO(path string, info *VideoInfo, style NFOStyle) error {
+	doc := nfoVideo{
+		Title:    info.Title,
+		Plot:     info.Description,
+		Thumb:    info.BestThumbnailURL,
+		UniqueID: nfoUniqueID{Type: "youtube", Default: true, Value: info.ID},
+		Genre:    info.Category,
+		Tags:     info.Keywords,
+		Studio:   "YouTube",
+	}
+	if info.DurationSec > 0 {
+		doc.Runtime = strconv.FormatInt((info.DurationSec+59)/60, 10)
+	}
+	var date string
+	if t, ok := videoPublishTime(info); ok {
+		date = t.Format("2006-01-02")
+		doc.Year = strconv.Itoa(t.Year())
+	}
+	switch style {
+	case NFOEpisode:
+		doc.XMLName.Local = "episodedetails"
+		doc.ShowTitle = info.Author
+		doc.Aired = date
+	case NFOMovie:
+		doc.XMLName.Local = "movie"
+		doc.Premiered = date
+		if info.Author != "" {
+			doc.Studio = info.Author
+		}
+	default:
+		return fmt.Errorf("unknown nfo style %q", style)
+	}
+	return writeNFODocument(path, doc)
+}
+
+// WriteShowNFO writes dir/tvshow.nfo naming info's channel as the show,
+// unless the folder already has one. It reports whether it wrote the file.
+func WriteShowNFO(dir string, info *VideoInfo) (bool, error) {
+	path := filepath.Join(dir, ShowNFOName)
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	doc := nfoShow{
+		Title:    info.Author,
+		UniqueID: nfoUniqueID{Type: "youtube", Default: true, Value: info.ChannelID},
+		Studio:   "YouTube",
+	}
+	if err := writeNFODocument(path, doc); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func writeNFODocument(path string, doc any) error {
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	data = append([]byte(xml.Header), data...)
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
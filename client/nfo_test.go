@@ -0,0 +1,80 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteNFO_Styles(t *testing.T) {
+	info := &VideoInfo{
+		ID:          "jNQXAC9IVRw",
+		Title:       "Me at the zoo",
+		Author:      "jawed",
+		ChannelID:   "UC4QobU6STFB0P71PMvOGN5A",
+		Description: "elephants & <trunks>",
+		DurationSec: 19,
+		PublishDate: "2005-04-23T20:31:52-07:00",
+		Category:    "Film & Animation",
+		Keywords:    []string{"zoo", "elephant"},
+	}
+	dir := t.TempDir()
+
+	episode := filepath.Join(dir, "jawed", "zoo.nfo")
+	if err := WriteNFO(episode, info, NFOEpisode); err != nil {
+		t.Fatalf("WriteNFO(episode) error = %v", err)
+	}
+	data, err := os.ReadFile(episode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<episodedetails>",
+		"<showtitle>jawed</showtitle>",
+		"<plot>elephants &amp; &lt;trunks&gt;</plot>",
+		"<runtime>1</runtime>",
+		`<uniqueid type="youtube" default="true">jNQXAC9IVRw</uniqueid>`,
+		"<tag>zoo</tag>",
+		"<aired>2005-04-23</aired>",
+		"<year>2005</year>",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("episode nfo missing %q:\n%s", want, data)
+		}
+	}
+
+	movie := filepath.Join(dir, "zoo.nfo")
+	if err := WriteNFO(movie, info, NFOMovie); err != nil {
+		t.Fatalf("WriteNFO(movie) error = %v", err)
+	}
+	data, _ = os.ReadFile(movie)
+	for _, want := range []string{"<movie>", "<premiered>2005-04-23</premiered>", "<studio>jawed</studio>"} {
+		if !strings.Contains(string(data), want) {
+			t.Fatalf("movie nfo missing %q:\n%s", want, data)
+		}
+	}
+	if strings.Contains(string(data), "<showtitle>") {
+		t.Fatalf("movie nfo has showtitle:\n%s", data)
+	}
+
+	if err := WriteNFO(movie, info, "season"); err == nil {
+		t.Fatal("WriteNFO(unknown style) error = nil")
+	}
+}
+
+func TestWriteShowNFO_KeepsExisting(t *testing.T) {
+	dir := t.TempDir()
+	info := &VideoInfo{Author: "jawed", ChannelID: "UC4QobU6STFB0P71PMvOGN5A"}
+	wrote, err := WriteShowNFO(dir, info)
+	if err != nil || !wrote {
+		t.Fatalf("WriteShowNFO() = %v, %v", wrote, err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, ShowNFOName))
+	if !strings.Contains(string(data), "<tvshow>") || !strings.Contains(string(data), "<title>jawed</title>") {
+		t.Fatalf("tvshow.nfo:\n%s", data)
+	}
+	if wrote, err := WriteShowNFO(dir, &VideoInfo{Author: "other"}); err != nil || wrote {
+		t.Fatalf("second WriteShowNFO() = %v, %v, want existing file kept", wrote, err)
+	}
+}
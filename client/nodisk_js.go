@@ -0,0 +1,6 @@
+//go:build js
+
+package client
+
+// js/wasm has no usable filesystem, so every client runs with Config.NoDisk.
+const forceNoDisk = true
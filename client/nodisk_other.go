@@ -0,0 +1,5 @@
+//go:build !js
+
+package client
+
+const forceNoDisk = false
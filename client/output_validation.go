@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const defaultOutputDurationTolerance = 2 * time.Second
+
+// validateOutput implements DownloadOptions.ValidateOutput.
+func (c *Client) validateOutput(ctx context.Context, res *DownloadResult, info *VideoInfo, options DownloadOptions) error {
+	if isPipeOutput(res.OutputPath) {
+		c.warnf("%s is a pipe; skipping output validation", res.OutputPath)
+		return nil
+	}
+	prober, ok := c.config.Muxer.(OutputProber)
+	if !ok || !c.config.Muxer.Available() {
+		c.warnf("output validation needs ffprobe; skipping check of %s", res.OutputPath)
+		return nil
+	}
+	c.emitDownloadEvent("validate", "start", res.VideoID, res.OutputPath, "")
+	probe, err := prober.ProbeOutput(ctx, res.OutputPath)
+	var problems []string
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		problems = []string{"probe failed: " + err.Error()}
+	} else {
+		tolerance := options.OutputDurationTolerance
+		if tolerance <= 0 {
+			tolerance = defaultOutputDurationTolerance
+		}
+		audioOnly := options.ExtractAudio != "" || options.Mode == SelectionModeMP3
+		problems = outputValidationProblems(probe, res.SelectedFormats, audioOnly, info, tolerance)
+	}
+	if len(problems) > 0 {
+		err := &OutputValidationError{Path: res.OutputPath, Problems: problems, Probe: probe}
+		c.emitDownloadEvent("validate", "failure", res.VideoID, res.OutputPath, err.Error())
+		return err
+	}
+	c.emitDownloadEvent("validate", "complete", res.VideoID, res.OutputPath,
+		fmt.Sprintf("duration=%s video=%d audio=%d frames=%d", probe.Duration, probe.VideoStreams, probe.AudioStreams, probe.VideoFrames))
+	return nil
+}
+
+// outputValidationProblems compares a probe of the output with what the
+// selected formats should have produced. audioOnly marks outputs rewritten
+// to a single audio stream (audio extraction, MP3).
+func outputValidationProblems(probe MediaProbe, selected []FormatInfo, audioOnly bool, info *VideoInfo, tolerance time.Duration) []string {
+	var wantVideo, wantAudio int
+	for _, f := range selected {
+		if f.HasVideo {
+			wantVideo++
+		}
+		if f.HasAudio {
+			wantAudio++
+		}
+	}
+	if audioOnly {
+		wantVideo, wantAudio = 0, min(wantAudio, 1)
+	}
+
+	var problems []string
+	if probe.VideoStreams < wantVideo {
+		problems = append(problems, fmt.Sprintf("video streams = %d, want %d", probe.VideoStreams, wantVideo))
+	}
+	if probe.AudioStreams < wantAudio {
+		problems = append(problems, fmt.Sprintf("audio streams = %d, want %d", probe.AudioStreams, wantAudio))
+	}
+	if wantVideo > 0 && probe.VideoStreams > 0 && probe.VideoFrames == 0 {
+		problems = append(problems, "no video frames")
+	}
+	if info != nil && info.DurationSec > 0 && !info.IsLive {
+		want := time.Duration(info.DurationSec) * time.Second
+		if diff := probe.Duration - want; diff > tolerance || diff < -tolerance {
+			problems = append(problems, fmt.Sprintf("duration = %s, want %s ± %s", probe.Duration.Round(time.Millisecond), want, tolerance))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,119 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type probingMuxer struct {
+	testMuxer
+	probe MediaProbe
+	paths []string
+}
+
+func (m *probingMuxer) ProbeOutput(ctx context.Context, path string) (MediaProbe, error) {
+	m.paths = append(m.paths, path)
+	return m.probe, nil
+}
+
+func TestDownload_ValidateOutputFlagsShortFile(t *testing.T) {
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		switch {
+		case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+			body := `{
+				"playabilityStatus":{"status":"OK"},
+				"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y","lengthSeconds":"19"},
+				"streamingData":{"formats":[
+					{"itag":18,"url":"https://media.example/v.mp4","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}
+				]}
+			}`
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+		case r.URL.Host == "media.example":
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("payload")), Header: make(http.Header)}, nil
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+	})}
+	muxer := &probingMuxer{probe: MediaProbe{Duration: 7 * time.Second, VideoStreams: 1, AudioStreams: 1, VideoFrames: 210}}
+	c := New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}, Muxer: muxer})
+
+	out := filepath.Join(t.TempDir(), "out.mp4")
+	res, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{Itag: 18, OutputPath: out, ValidateOutput: true})
+	if !errors.Is(err, ErrOutputInvalid) || ClassifyError(err) != ErrorCategoryOutputInvalid {
+		t.Fatalf("Download() error = %v, want ErrOutputInvalid", err)
+	}
+	var validationErr *OutputValidationError
+	if !errors.As(err, &validationErr) || validationErr.Path != out || len(validationErr.Problems) != 1 {
+		t.Fatalf("error = %#v", err)
+	}
+	if res == nil || res.OutputPath != out || len(muxer.paths) != 1 || muxer.paths[0] != out {
+		t.Fatalf("res=%+v probed=%v", res, muxer.paths)
+	}
+
+	muxer.probe.Duration = 18500 * time.Millisecond
+	if _, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{Itag: 18, OutputPath: out, ValidateOutput: true}); err != nil {
+		t.Fatalf("Download() with a matching probe error = %v", err)
+	}
+}
+
+func TestOutputValidationProblems(t *testing.T) {
+	video := FormatInfo{Itag: 137, HasVideo: true}
+	audio := FormatInfo{Itag: 140, HasAudio: true}
+	dub := FormatInfo{Itag: 140, HasAudio: true, Language: "es"}
+	info := &VideoInfo{DurationSec: 60}
+	tests := []struct {
+		name      string
+		probe     MediaProbe
+		selected  []FormatInfo
+		audioOnly bool
+		info      *VideoInfo
+		want      []string
+	}{
+		{
+			name:     "complete merge",
+			probe:    MediaProbe{Duration: 60 * time.Second, VideoStreams: 1, AudioStreams: 2, VideoFrames: 1800},
+			selected: []FormatInfo{video, audio, dub},
+			info:     info,
+		},
+		{
+			name:     "missing dub and frames",
+			probe:    MediaProbe{Duration: 60 * time.Second, VideoStreams: 1, AudioStreams: 1},
+			selected: []FormatInfo{video, audio, dub},
+			info:     info,
+			want:     []string{"audio streams = 1, want 2", "no video frames"},
+		},
+		{
+			name:      "extracted audio",
+			probe:     MediaProbe{Duration: 59 * time.Second, AudioStreams: 1},
+			selected:  []FormatInfo{video, audio},
+			audioOnly: true,
+			info:      info,
+		},
+		{
+			name:     "truncated",
+			probe:    MediaProbe{Duration: 30 * time.Second, VideoStreams: 1, AudioStreams: 1, VideoFrames: 900},
+			selected: []FormatInfo{video, audio},
+			info:     info,
+			want:     []string{"duration = 30s, want 1m0s ± 2s"},
+		},
+		{
+			name:     "live capture",
+			probe:    MediaProbe{Duration: 30 * time.Second, VideoStreams: 1, AudioStreams: 1, VideoFrames: 900},
+			selected: []FormatInfo{video, audio},
+			info:     &VideoInfo{DurationSec: 60, IsLive: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := outputValidationProblems(tt.probe, tt.selected, tt.audioOnly, tt.info, 2*time.Second)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Fatalf("problems = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
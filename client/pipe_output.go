@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+)
+
+// isPipeOutput reports whether path is a named pipe or character device,
+// such as a mkfifo pipe feeding another process or /dev/stdout. These take
+// bytes strictly in order: no resume, chunked WriteAt, Truncate or reading
+// the output back.
+func isPipeOutput(path string) bool {
+	st, err := os.Stat(path)
+	return err == nil && st.Mode()&(os.ModeNamedPipe|os.ModeCharDevice) != 0
+}
+
+// createOutput opens path for a sequential write from its first byte. A
+// pipe or device is opened write-only as is (a FIFO blocks until its
+// reader opens it); anything else is created or truncated.
+func createOutput(path string) (*os.File, error) {
+	if isPipeOutput(path) {
+		return os.OpenFile(path, os.O_WRONLY, 0)
+	}
+	return os.Create(path)
+}
+
+// downloadURLToPipe writes a direct stream to a pipe or device from one
+// open. Bytes already written cannot be taken back, so a dropped
+// connection resumes at the current offset instead of starting over.
+func downloadURLToPipe(
+	ctx context.Context,
+	httpClient *http.Client,
+	streamURL string,
+	outputPath string,
+	cfg effectiveDownloadTransportConfig,
+	videoID string,
+	requestHeaders http.Header,
+) (int64, error) {
+	publishSourceWatermark(ctx, 0)
+	file, err := createOutput(outputPath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	src := &resumingReader{
+		ctx:     ctx,
+		client:  httpClient,
+		url:     streamURL,
+		videoID: videoID,
+		headers: requestHeaders,
+		cfg:     cfg,
+	}
+	defer src.drop()
+	w := trackOutputSize(ctx, trackSourceWatermark(ctx, trackOutputDigest(ctx, file, outputPath, 0), 0), 0)
+	return io.Copy(w, countDownloadBytes(ctx, src))
+}
@@ -0,0 +1,78 @@
+//go:build unix
+
+package client
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// readFIFO creates a FIFO and drains it in the background; the returned
+// function waits for the writer to close it and returns what was read.
+func readFIFO(t *testing.T) (string, func() string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "out.fifo")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+	done := make(chan string, 1)
+	go func() {
+		f, err := os.Open(path)
+		if err != nil {
+			done <- "open: " + err.Error()
+			return
+		}
+		defer f.Close()
+		data, _ := io.ReadAll(f)
+		done <- string(data)
+	}()
+	return path, func() string { return <-done }
+}
+
+func TestDownload_WritesFIFOSequentially(t *testing.T) {
+	const payload = "media-payload"
+	var ranges []string
+	// The first connection drops after five bytes; chunked transfer is on
+	// by default, so a regular file would be probed and written with
+	// WriteAt.
+	c := sinkTestClient(t, payload, true, &ranges)
+	path, read := readFIFO(t)
+
+	res, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{OutputPath: path, Resume: true, WriteChecksum: true})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if got := read(); got != payload {
+		t.Fatalf("reader got %q, want %q", got, payload)
+	}
+	if strings.Join(ranges, ",") != ",bytes=5-" {
+		t.Fatalf("media requests = %q, want one open and a resume at the dropped offset", ranges)
+	}
+	if res.SHA256 != sha256Hex(payload) {
+		t.Fatalf("SHA256 = %q, want the digest of the streamed bytes", res.SHA256)
+	}
+	if st, err := os.Stat(path); err != nil || st.Mode()&os.ModeNamedPipe == 0 {
+		t.Fatalf("output is no longer a FIFO: %v %v", st.Mode(), err)
+	}
+}
+
+func TestDownload_FIFORejectsAudioExtraction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.fifo")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+	var ranges []string
+	c := sinkTestClient(t, "x", false, &ranges)
+	_, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{OutputPath: path, ExtractAudio: AudioFormatBest})
+	if err == nil || !strings.Contains(err.Error(), "is a pipe") {
+		t.Fatalf("Download() error = %v, want pipe rejection", err)
+	}
+	if len(ranges) != 0 {
+		t.Fatalf("media requests = %q, want none", ranges)
+	}
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+const defaultPlaybackPingInterval = 40 * time.Second
+
+// playbackPinger reports one download to the video's stats URLs as a
+// player watching it at normal speed would.
+type playbackPinger struct {
+	client    *Client
+	playback  string
+	watchtime string
+	cpn       string
+	itag      int
+	length    float64
+	start     time.Time
+	last      float64 // position reported by the previous watchtime ping
+}
+
+// startPlaybackPings sends the playback ping for a download and keeps
+// sending watchtime pings until the returned stop is called. It does
+// nothing unless EmulatePlayback.StatsPings is set and the player response
+// carries stats URLs.
+func (c *Client) startPlaybackPings(ctx context.Context, videoID string, info *VideoInfo, selected []types.FormatInfo) func() {
+	emulate := c.config.EmulatePlayback
+	state, ok := ctx.Value(mediaRequestKey{}).(*mediaRequestState)
+	if !emulate.Enable || !emulate.StatsPings || !ok || len(selected) == 0 {
+		return func() {}
+	}
+	session, ok := c.getSession(videoID)
+	if !ok || session.Response == nil {
+		return func() {}
+	}
+	tracking := session.Response.PlaybackTracking
+	p := &playbackPinger{
+		client:    c,
+		playback:  tracking.VideostatsPlaybackURL.BaseURL,
+		watchtime: tracking.VideostatsWatchtimeURL.BaseURL,
+		cpn:       state.cpn,
+		itag:      selected[0].Itag,
+		length:    float64(info.DurationSec),
+		start:     time.Now(),
+	}
+	if p.playback == "" && p.watchtime == "" {
+		return func() {}
+	}
+	interval := emulate.PingInterval
+	if interval <= 0 {
+		interval = defaultPlaybackPingInterval
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.send(ctx, p.playback, nil)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.watch(ctx, "playing")
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			cancel()
+			<-done
+			if p.position() > p.last {
+				final, cancelFinal := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+				defer cancelFinal()
+				p.watch(final, "paused")
+			}
+		})
+	}
+}
+
+// position is the playback position in seconds: the time since the
+// download started, capped at the video length.
+func (p *playbackPinger) position() float64 {
+	pos := time.Since(p.start).Seconds()
+	if p.length > 0 && pos > p.length {
+		pos = p.length
+	}
+	return pos
+}
+
+// watch reports the segment played since the previous watchtime ping.
+func (p *playbackPinger) watch(ctx context.Context, state string) {
+	pos := p.position()
+	p.send(ctx, p.watchtime, [][2]string{
+		{"st", formatSeconds(p.last)},
+		{"et", formatSeconds(pos)},
+		{"cmt", formatSeconds(pos)},
+		{"state", state},
+	})
+	p.last = pos
+}
+
+func (p *playbackPinger) send(ctx context.Context, base string, extra [][2]string) {
+	if base == "" {
+		return
+	}
+	m, err := parseMediaURL(base)
+	if err != nil {
+		return
+	}
+	m.set("ver", "2")
+	m.set("cpn", p.cpn)
+	m.set("fmt", strconv.Itoa(p.itag))
+	if p.length > 0 {
+		m.set("len", formatSeconds(p.length))
+	}
+	if len(extra) == 0 {
+		m.set("cmt", "0")
+	}
+	for _, kv := range extra {
+		m.set(kv[0], kv[1])
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.String(), nil)
+	if err != nil {
+		return
+	}
+	applyRequestHeaders(req, p.client.config.RequestHeaders)
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", p.client.pageUserAgent())
+	}
+	resp, err := p.client.httpClient().Do(req)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+func formatSeconds(sec float64) string {
+	return strconv.FormatFloat(sec, 'f', 3, 64)
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDownload_EmulatePlaybackPings(t *testing.T) {
+	const payload = "media-payload"
+	var (
+		mu        sync.Mutex
+		mediaCPN  []string
+		playback  []url.Values
+		watchtime []url.Values
+	)
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo","author":"jawed","lengthSeconds":"19"},
+					"playbackTracking":{
+						"videostatsPlaybackUrl":{"baseUrl":"https://s.youtube.com/api/stats/playback?ns=yt&docid=jNQXAC9IVRw&ei=abc"},
+						"videostatsWatchtimeUrl":{"baseUrl":"https://s.youtube.com/api/stats/watchtime?ns=yt&docid=jNQXAC9IVRw&ei=abc"}
+					},
+					"streamingData":{"formats":[
+						{"itag":18,"url":"https://rr1.googlevideo.com/videoplayback?itag=18","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.URL.Host == "rr1.googlevideo.com":
+				mediaCPN = append(mediaCPN, r.URL.Query().Get("cpn"))
+				// Give the download a measurable playback position.
+				time.Sleep(5 * time.Millisecond)
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(payload)), Header: make(http.Header)}, nil
+			case r.URL.Path == "/api/stats/playback":
+				playback = append(playback, r.URL.Query())
+				return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Header: make(http.Header)}, nil
+			case r.URL.Path == "/api/stats/watchtime":
+				watchtime = append(watchtime, r.URL.Query())
+				return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Header: make(http.Header)}, nil
+			}
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+		}),
+	}
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+		EmulatePlayback: PlaybackEmulationConfig{Enable: true, StatsPings: true, PingInterval: time.Hour},
+	})
+
+	out := filepath.Join(t.TempDir(), "out.mp4")
+	if _, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{OutputPath: out}); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(mediaCPN) == 0 || len(playback) != 1 || len(watchtime) != 1 {
+		t.Fatalf("requests: %d media, %d playback, %d watchtime pings", len(mediaCPN), len(playback), len(watchtime))
+	}
+	cpn := playback[0].Get("cpn")
+	if len(cpn) != 16 {
+		t.Fatalf("playback ping cpn = %q", cpn)
+	}
+	for _, got := range mediaCPN {
+		if got != cpn {
+			t.Fatalf("media request cpn = %q, want the pinged %q", got, cpn)
+		}
+	}
+	if p := playback[0]; p.Get("docid") != "jNQXAC9IVRw" || p.Get("ver") != "2" || p.Get("fmt") != "18" || p.Get("len") != "19.000" || p.Get("cmt") != "0" {
+		t.Fatalf("playback ping = %v", p)
+	}
+	if w := watchtime[0]; w.Get("cpn") != cpn || w.Get("st") != "0.000" || w.Get("et") == "0.000" || w.Get("state") != "paused" {
+		t.Fatalf("final watchtime ping = %v", w)
+	}
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/innertube"
+)
+
+// Account playlist IDs accepted by GetPlaylist. They list the signed-in
+// account's videos, so they need cookies in Config.HTTPClient's jar; the
+// playlist page only redirects to sign-in, so they are fetched from the
+// browse endpoint with cookie auth headers.
+const (
+	PlaylistWatchLater = "WL"
+	PlaylistLiked      = "LL"
+	PlaylistHistory    = "FEhistory"
+)
+
+// authPlaylist describes how an account playlist is browsed.
+type authPlaylist struct {
+	browseID string
+	title    string
+	// renderer is the key its entries are rendered under.
+	renderer string
+}
+
+var authPlaylists = map[string]authPlaylist{
+	PlaylistWatchLater: {browseID: "VLWL", title: "Watch later", renderer: "playlistVideoRenderer"},
+	PlaylistLiked:      {browseID: "VLLL", title: "Liked videos", renderer: "playlistVideoRenderer"},
+	PlaylistHistory:    {browseID: "FEhistory", title: "History", renderer: "videoRenderer"},
+}
+
+// authPlaylistShortcuts are the yt-dlp style inputs for account playlists.
+var authPlaylistShortcuts = map[string]string{
+	":ytwatchlater": PlaylistWatchLater,
+	":ytfav":        PlaylistLiked,
+	":ytlike":       PlaylistLiked,
+	":ythistory":    PlaylistHistory,
+}
+
+// hasCookieAuth reports whether the client's cookies can authenticate
+// browse requests.
+func (c *Client) hasCookieAuth() bool {
+	host := innertube.WebClient.Host
+	return innertube.BuildCookieAuthHeaders(c.httpClient(), host, time.Now(), innertube.CookieAuthContext{}).Get("Authorization") != ""
+}
+
+// fetchAuthPlaylist enumerates an account playlist through authenticated
+// browse requests.
+func (c *Client) fetchAuthPlaylist(ctx context.Context, playlistID string, special authPlaylist, options PlaylistOptions) (*PlaylistInfo, error) {
+	if !c.hasCookieAuth() {
+		return nil, fmt.Errorf("%w: playlist %s needs the account's cookies", ErrLoginRequired, playlistID)
+	}
+	c.emitExtractionEvent("playlist_page", "start", "web", "id="+playlistID+" authenticated")
+	body, err := c.postBrowse(ctx, browseQuery{BrowseID: special.browseID, Authenticated: true})
+	if err != nil {
+		return nil, err
+	}
+	var root any
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, err
+	}
+	info := &PlaylistInfo{
+		ID:    playlistID,
+		Title: findPlaylistTitle(root),
+		Items: findRendererItems(root, special.renderer),
+	}
+	if info.Title == "" {
+		info.Title = special.title
+	}
+
+	pendingContinuations := findContinuationTokens(root)
+	c.emitExtractionEvent("playlist_page", "success", "web", fmt.Sprintf("id=%s items=%d continuations=%d", playlistID, len(info.Items), len(pendingContinuations)))
+	visitorData := findVisitorData(root)
+	c.followPlaylistContinuations(info, pendingContinuations, options, func(continuation string) ([]PlaylistItem, []string, error) {
+		body, err := c.postBrowse(ctx, browseQuery{Continuation: continuation, VisitorData: visitorData, Authenticated: true})
+		if err != nil {
+			return nil, nil, err
+		}
+		var page any
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, nil, err
+		}
+		return findRendererItems(page, special.renderer), findContinuationTokens(page), nil
+	})
+
+	stats := info.ContinuationStats
+	c.emitExtractionEvent("playlist", "complete", "web", fmt.Sprintf("id=%s items=%d continuations=%d failed=%d stopped_by_limit=%t stopped_by_known=%t",
+		playlistID, len(info.Items), stats.Requested, stats.Failed, stats.StoppedByLimit, stats.StoppedByKnown))
+	if !stats.StoppedByKnown && stats.Failed == 0 {
+		c.storePlaylistCache(playlistCacheEntry{
+			ID:        info.ID,
+			Title:     info.Title,
+			Items:     info.Items,
+			FetchedAt: time.Now(),
+		})
+	}
+	return info, nil
+}
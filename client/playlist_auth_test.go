@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func signedInHTTPClient(t *testing.T, transport http.RoundTripper) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New() error = %v", err)
+	}
+	jar.SetCookies(&url.URL{Scheme: "https", Host: "www.youtube.com"}, []*http.Cookie{
+		{Name: "SAPISID", Value: "sapisid-value", Path: "/"},
+		{Name: "LOGIN_INFO", Value: "login", Path: "/"},
+	})
+	return &http.Client{Jar: jar, Transport: transport}
+}
+
+func TestGetPlaylist_HistoryBrowsesAuthenticated(t *testing.T) {
+	var browseIDs, continuations []string
+	httpClient := signedInHTTPClient(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method != http.MethodPost || r.URL.Path != "/youtubei/v1/browse" {
+			t.Fatalf("unexpected request: %s %s", r.Method, r.URL)
+		}
+		if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "SAPISIDHASH ") {
+			t.Fatalf("Authorization = %q, want SAPISIDHASH", auth)
+		}
+		var reqBody struct {
+			BrowseID     string `json:"browseId"`
+			Continuation string `json:"continuation"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Fatalf("decode browse request: %v", err)
+		}
+		browseIDs = append(browseIDs, reqBody.BrowseID)
+		continuations = append(continuations, reqBody.Continuation)
+		if reqBody.Continuation == "" {
+			return jsonResponse(t, map[string]any{
+				"contents": map[string]any{"itemSectionRenderer": map[string]any{"contents": []any{
+					map[string]any{"videoRenderer": map[string]any{"videoId": "aaaaaaaaaaa", "title": map[string]any{"runs": []any{map[string]any{"text": "one"}}}}},
+					map[string]any{"continuationItemRenderer": map[string]any{"continuationEndpoint": map[string]any{"continuationCommand": map[string]any{"token": "next"}}}},
+				}}},
+			}), nil
+		}
+		return jsonResponse(t, map[string]any{
+			"onResponseReceivedActions": []any{map[string]any{"appendContinuationItemsAction": map[string]any{"continuationItems": []any{
+				map[string]any{"itemSectionRenderer": map[string]any{"contents": []any{
+					map[string]any{"videoRenderer": map[string]any{"videoId": "bbbbbbbbbbb", "title": map[string]any{"runs": []any{map[string]any{"text": "two"}}}}},
+				}}},
+			}}}},
+		}), nil
+	}))
+
+	c := &Client{config: Config{HTTPClient: httpClient}}
+	got, err := c.GetPlaylist(context.Background(), ":ythistory")
+	if err != nil {
+		t.Fatalf("GetPlaylist() error = %v", err)
+	}
+	if got.ID != PlaylistHistory || got.Title != "History" {
+		t.Fatalf("playlist = %q %q, want FEhistory History", got.ID, got.Title)
+	}
+	if len(got.Items) != 2 || got.Items[0].VideoID != "aaaaaaaaaaa" || got.Items[1].VideoID != "bbbbbbbbbbb" || got.Items[1].Title != "two" {
+		t.Fatalf("unexpected items: %+v", got.Items)
+	}
+	if strings.Join(browseIDs, ",") != "FEhistory," || strings.Join(continuations, ",") != ",next" {
+		t.Fatalf("browse requests = %q / %q", browseIDs, continuations)
+	}
+}
+
+func TestGetPlaylist_WatchLaterUsesPlaylistBrowseID(t *testing.T) {
+	httpClient := signedInHTTPClient(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var reqBody struct {
+			BrowseID string `json:"browseId"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Fatalf("decode browse request: %v", err)
+		}
+		if reqBody.BrowseID != "VLWL" {
+			t.Fatalf("browseId = %q, want VLWL", reqBody.BrowseID)
+		}
+		return jsonResponse(t, map[string]any{
+			"metadata": map[string]any{"playlistMetadataRenderer": map[string]any{"title": "Watch later"}},
+			"contents": []any{map[string]any{"playlistVideoRenderer": map[string]any{"videoId": "ccccccccccc", "title": map[string]any{"simpleText": "[Private video]"}}}},
+		}), nil
+	}))
+
+	c := &Client{config: Config{HTTPClient: httpClient}}
+	got, err := c.GetPlaylist(context.Background(), "https://www.youtube.com/playlist?list=WL")
+	if err != nil {
+		t.Fatalf("GetPlaylist() error = %v", err)
+	}
+	if len(got.Items) != 1 || !got.Items[0].Unavailable {
+		t.Fatalf("unexpected items: %+v", got.Items)
+	}
+}
+
+func TestGetPlaylist_AccountPlaylistWithoutCookies(t *testing.T) {
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		t.Fatalf("unexpected request: %s", r.URL)
+		return nil, nil
+	})}
+	c := &Client{config: Config{HTTPClient: httpClient}}
+	_, err := c.GetPlaylist(context.Background(), "LL")
+	if !errors.Is(err, ErrLoginRequired) {
+		t.Fatalf("GetPlaylist() error = %v, want ErrLoginRequired", err)
+	}
+}
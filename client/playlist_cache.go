@@ -0,0 +1,113 @@
+package client
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// playlistCacheEntry is the stored form of a complete playlist enumeration.
+type playlistCacheEntry struct {
+	ID        string         `json:"id"`
+	Title     string         `json:"title"`
+	Items     []PlaylistItem `json:"items"`
+	ETag      string         `json:"etag,omitempty"`
+	FetchedAt time.Time      `json:"fetched_at"`
+}
+
+func (e *playlistCacheEntry) fresh(ttl time.Duration, now time.Time) bool {
+	return now.Sub(e.FetchedAt) < ttl
+}
+
+func (e *playlistCacheEntry) playlistInfo() *PlaylistInfo {
+	return &PlaylistInfo{
+		ID:        e.ID,
+		Title:     e.Title,
+		Items:     append([]PlaylistItem(nil), e.Items...),
+		FromCache: true,
+	}
+}
+
+// playlistCachePath returns "<CacheDir>/playlists/<id>.json", or "" when
+// playlist caching is disabled, kept in Config.Cache, or the ID is not a
+// plain path element.
+func (c *Client) playlistCachePath(playlistID string) string {
+	dir := strings.TrimSpace(c.config.CacheDir)
+	if dir == "" || c.config.Cache != nil || c.config.NoDisk || c.config.PlaylistCacheTTL <= 0 {
+		return ""
+	}
+	if playlistID == "" || strings.ContainsAny(playlistID, `/\.`) {
+		return ""
+	}
+	return filepath.Join(dir, "playlists", playlistID+".json")
+}
+
+// playlistCacheStore returns Config.Cache scoped to playlists when playlist
+// caching is enabled and goes through it.
+func (c *Client) playlistCacheStore(playlistID string) (Cache, bool) {
+	if c.config.Cache == nil || c.config.PlaylistCacheTTL <= 0 || playlistID == "" {
+		return nil, false
+	}
+	return prefixedCache{cache: c.config.Cache, prefix: playlistCachePrefix}, true
+}
+
+func (c *Client) loadPlaylistCache(playlistID string) (*playlistCacheEntry, bool) {
+	var (
+		data   []byte
+		source string
+	)
+	if store, ok := c.playlistCacheStore(playlistID); ok {
+		value, found := store.Get(playlistID)
+		if !found {
+			return nil, false
+		}
+		data, source = []byte(value), playlistCachePrefix+playlistID
+	} else {
+		path := c.playlistCachePath(playlistID)
+		if path == "" {
+			return nil, false
+		}
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, false
+		}
+		source = path
+	}
+	var entry playlistCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.ID != playlistID {
+		c.warnf("ignoring unreadable playlist cache %s", source)
+		return nil, false
+	}
+	return &entry, true
+}
+
+// storePlaylistCache writes entry to Config.Cache, or to CacheDir through a
+// temp file so concurrent readers never see a partial list. Failures only
+// warn; the cache is an optimization.
+func (c *Client) storePlaylistCache(entry playlistCacheEntry) {
+	if store, ok := c.playlistCacheStore(entry.ID); ok {
+		if data, err := json.Marshal(entry); err == nil {
+			store.Set(entry.ID, string(data))
+		}
+		return
+	}
+	path := c.playlistCachePath(entry.ID)
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		tmp := path + ".tmp"
+		if err = os.WriteFile(tmp, data, 0644); err == nil {
+			err = os.Rename(tmp, path)
+		}
+	}
+	if err != nil {
+		c.warnf("failed to write playlist cache %s: %v", path, err)
+	}
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const playlistCacheTestHTML = `<html><script>var ytInitialData = {"responseContext":{"visitorData":"visitor"},"metadata":{"playlistMetadataRenderer":{"title":"Uploads"}},"contents":[{"playlistVideoRenderer":{"videoId":"aaaaaaaaaaa","title":{"simpleText":"newest"}}},{"playlistVideoRenderer":{"videoId":"bbbbbbbbbbb","title":{"simpleText":"older"}}},{"continuationItemRenderer":{"continuationEndpoint":{"continuationCommand":{"token":"token-1"}}}}]};</script></html>`
+
+func playlistCacheTestClient(t *testing.T, pageRequests, browseRequests *int, ifNoneMatch *string) *http.Client {
+	t.Helper()
+	return &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/playlist":
+				*pageRequests++
+				*ifNoneMatch = r.Header.Get("If-None-Match")
+				if *ifNoneMatch == `"v1"` {
+					return &http.Response{StatusCode: http.StatusNotModified, Header: make(http.Header), Body: http.NoBody}, nil
+				}
+				header := make(http.Header)
+				header.Set("ETag", `"v1"`)
+				return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewBufferString(playlistCacheTestHTML))}, nil
+			case r.Method == http.MethodPost && r.URL.Path == "/youtubei/v1/browse":
+				*browseRequests++
+				return jsonResponse(t, map[string]any{
+					"onResponseReceivedActions": []any{map[string]any{
+						"appendContinuationItemsAction": map[string]any{
+							"continuationItems": []any{map[string]any{
+								"playlistVideoRenderer": map[string]any{
+									"videoId": "ccccccccccc",
+									"title":   map[string]any{"simpleText": "oldest"},
+								},
+							}},
+						},
+					}},
+				}), nil
+			}
+			t.Fatalf("unexpected request: %s", r.URL.String())
+			return nil, nil
+		}),
+	}
+}
+
+func TestGetPlaylist_CachesItemsAndRevalidatesWithETag(t *testing.T) {
+	var pages, browses int
+	var ifNoneMatch string
+	dir := t.TempDir()
+	c := &Client{config: Config{
+		HTTPClient:       playlistCacheTestClient(t, &pages, &browses, &ifNoneMatch),
+		CacheDir:         dir,
+		PlaylistCacheTTL: time.Hour,
+	}}
+
+	first, err := c.GetPlaylist(context.Background(), "PL1234567890")
+	if err != nil {
+		t.Fatalf("GetPlaylist() error = %v", err)
+	}
+	if first.FromCache || len(first.Items) != 3 || pages != 1 || browses != 1 {
+		t.Fatalf("first fetch: fromCache=%v items=%d pages=%d browses=%d", first.FromCache, len(first.Items), pages, browses)
+	}
+
+	second, err := c.GetPlaylist(context.Background(), "PL1234567890")
+	if err != nil {
+		t.Fatalf("GetPlaylist(cached) error = %v", err)
+	}
+	if !second.FromCache || len(second.Items) != 3 || second.Title != "Uploads" || pages != 1 {
+		t.Fatalf("fresh cache: fromCache=%v items=%d pages=%d", second.FromCache, len(second.Items), pages)
+	}
+
+	// Age the entry past the TTL; the page is revalidated with its ETag.
+	entry, ok := c.loadPlaylistCache("PL1234567890")
+	if !ok {
+		t.Fatal("expected cache entry on disk")
+	}
+	entry.FetchedAt = time.Now().Add(-2 * time.Hour)
+	c.storePlaylistCache(*entry)
+
+	third, err := c.GetPlaylist(context.Background(), "PL1234567890")
+	if err != nil {
+		t.Fatalf("GetPlaylist(stale) error = %v", err)
+	}
+	if ifNoneMatch != `"v1"` || !third.FromCache || len(third.Items) != 3 || pages != 2 || browses != 1 {
+		t.Fatalf("revalidation: if-none-match=%q fromCache=%v items=%d pages=%d browses=%d", ifNoneMatch, third.FromCache, len(third.Items), pages, browses)
+	}
+	if entry, _ := c.loadPlaylistCache("PL1234567890"); !entry.fresh(time.Hour, time.Now()) {
+		t.Fatalf("expected 304 to refresh cache timestamp, fetched_at=%v", entry.FetchedAt)
+	}
+}
+
+func TestGetPlaylistWithOptions_StopAtKnownSkipsPagingAndCache(t *testing.T) {
+	var pages, browses int
+	var ifNoneMatch string
+	dir := t.TempDir()
+	c := &Client{config: Config{
+		HTTPClient:       playlistCacheTestClient(t, &pages, &browses, &ifNoneMatch),
+		CacheDir:         dir,
+		PlaylistCacheTTL: time.Hour,
+	}}
+
+	got, err := c.GetPlaylistWithOptions(context.Background(), "PL1234567890", PlaylistOptions{
+		StopAtKnown: func(videoID string) bool { return videoID == "bbbbbbbbbbb" },
+	})
+	if err != nil {
+		t.Fatalf("GetPlaylistWithOptions() error = %v", err)
+	}
+	if !got.ContinuationStats.StoppedByKnown || browses != 0 || len(got.Items) != 2 {
+		t.Fatalf("stoppedByKnown=%v browses=%d items=%d", got.ContinuationStats.StoppedByKnown, browses, len(got.Items))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "playlists", "PL1234567890.json")); !os.IsNotExist(err) {
+		t.Fatalf("incremental result should not be cached, stat err=%v", err)
+	}
+}
+
+func TestGetPlaylist_EmitsEnumerationEvents(t *testing.T) {
+	var pages, browses int
+	var ifNoneMatch string
+	var events []string
+	c := &Client{config: Config{
+		HTTPClient:       playlistCacheTestClient(t, &pages, &browses, &ifNoneMatch),
+		CacheDir:         t.TempDir(),
+		PlaylistCacheTTL: time.Hour,
+		OnExtractionEvent: func(evt ExtractionEvent) {
+			events = append(events, evt.Stage+":"+evt.Phase+" "+evt.Detail)
+		},
+	}}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetPlaylist(context.Background(), "PL1234567890"); err != nil {
+			t.Fatalf("GetPlaylist() error = %v", err)
+		}
+	}
+	want := []string{
+		"playlist_page:start id=PL1234567890",
+		"playlist_page:success id=PL1234567890 items=2 continuations=1",
+		"playlist_continuation:start request=1",
+		"playlist_continuation:success items=1 total=3",
+		"playlist:complete id=PL1234567890 items=3 continuations=1 failed=0 stopped_by_limit=false stopped_by_known=false",
+		"playlist_page:cached id=PL1234567890 items=3",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Fatalf("events =\n%s\nwant\n%s", strings.Join(events, "\n"), strings.Join(want, "\n"))
+	}
+}
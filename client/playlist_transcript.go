@@ -8,12 +8,13 @@ import (
 	"errors"
 	"fmt"
 	"html"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/famomatic/ytv1/internal/httpx"
 	"github.com/famomatic/ytv1/internal/innertube"
 )
 
@@ -49,7 +50,8 @@ func (c *Client) GetSubtitleTracks(ctx context.Context, input string) ([]Subtitl
 }
 
 // GetTranscript fetches and parses transcript entries for a given language code.
-// If languageCode is empty, the first available track is used.
+// If languageCode is empty, the first available track is used. Parsed
+// transcripts are cached in the video session.
 func (c *Client) GetTranscript(ctx context.Context, input string, languageCode string) (*Transcript, error) {
 	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
 	defer cancel()
@@ -69,6 +71,12 @@ func (c *Client) GetTranscript(ctx context.Context, input string, languageCode s
 			Reason:       "no caption tracks",
 		}
 	}
+	return c.fetchTranscript(ctx, videoID, tracks, languageCode)
+}
+
+// fetchTranscript fetches and parses the track of tracks matching
+// languageCode, or returns the session's earlier parse of it.
+func (c *Client) fetchTranscript(ctx context.Context, videoID string, tracks []SubtitleTrack, languageCode string) (*Transcript, error) {
 	track, ok := chooseSubtitleTrack(tracks, languageCode, c.config.SubtitlePolicy)
 	if !ok {
 		return nil, &TranscriptUnavailableDetailError{
@@ -77,8 +85,12 @@ func (c *Client) GetTranscript(ctx context.Context, input string, languageCode s
 			Reason:       "requested language not found",
 		}
 	}
+	cache := c.transcriptCache(videoID)
+	if t, ok := cache.get(track.BaseURL); ok {
+		return t, nil
+	}
 
-	raw, err := fetchTranscriptXML(ctx, c.httpClient(), c.config.RequestHeaders, track.BaseURL)
+	raw, err := fetchTranscriptXML(ctx, c.httpClient(), c.config.RequestHeaders, c.pageUserAgent(), track.BaseURL)
 	if err != nil {
 		if errors.Is(err, ErrUnavailable) {
 			return nil, &TranscriptUnavailableDetailError{
@@ -97,19 +109,28 @@ func (c *Client) GetTranscript(ctx context.Context, input string, languageCode s
 			Reason:       err.Error(),
 		}
 	}
-	return &Transcript{
+	t := &Transcript{
 		VideoID:       videoID,
 		LanguageCode:  track.LanguageCode,
 		Name:          track.Name,
 		AutoGenerated: track.AutoGenerated,
 		SourceTrack:   track,
 		Entries:       entries,
-	}, nil
+	}
+	cache.put(track.BaseURL, t)
+	return t, nil
 }
 
 // GetPlaylist fetches and parses playlist metadata/items from playlist page initial data
 // and continuation requests.
 func (c *Client) GetPlaylist(ctx context.Context, input string) (*PlaylistInfo, error) {
+	return c.GetPlaylistWithOptions(ctx, input, PlaylistOptions{})
+}
+
+// GetPlaylistWithOptions is GetPlaylist with cache-aware and incremental
+// enumeration. With Config.PlaylistCacheTTL and either Config.CacheDir or
+// Config.Cache set, complete enumerations are cached per playlist ID.
+func (c *Client) GetPlaylistWithOptions(ctx context.Context, input string, options PlaylistOptions) (*PlaylistInfo, error) {
 	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
 	defer cancel()
 
@@ -117,23 +138,53 @@ func (c *Client) GetPlaylist(ctx context.Context, input string) (*PlaylistInfo,
 	if err != nil {
 		return nil, err
 	}
+	cached, hasCache := c.loadPlaylistCache(playlistID)
+	if hasCache && cached.fresh(c.config.PlaylistCacheTTL, time.Now()) {
+		c.emitExtractionEvent("playlist_page", "cached", "web", fmt.Sprintf("id=%s items=%d", playlistID, len(cached.Items)))
+		return cached.playlistInfo(), nil
+	}
+	info, err := c.fetchPlaylist(ctx, playlistID, cached, hasCache, options)
+	if err != nil {
+		c.emitExtractionEvent("playlist_page", "failure", "web", err.Error())
+		return nil, err
+	}
+	return info, nil
+}
+
+// fetchPlaylist enumerates a playlist from its page and continuations,
+// revalidating cached when hasCache is set.
+func (c *Client) fetchPlaylist(ctx context.Context, playlistID string, cached *playlistCacheEntry, hasCache bool, options PlaylistOptions) (*PlaylistInfo, error) {
+	if special, ok := authPlaylists[playlistID]; ok {
+		return c.fetchAuthPlaylist(ctx, playlistID, special, options)
+	}
+	c.emitExtractionEvent("playlist_page", "start", "web", "id="+playlistID)
 	pageURL := "https://www.youtube.com/playlist?list=" + url.QueryEscape(playlistID) + "&hl=en"
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("User-Agent", innertube.WebClient.UserAgent)
+	req.Header.Set("User-Agent", c.pageUserAgent())
 	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	httpx.AcceptCompressed(req)
+	if hasCache && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
 	applyRequestHeaders(req, c.config.RequestHeaders)
 	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && hasCache {
+		cached.FetchedAt = time.Now()
+		c.storePlaylistCache(*cached)
+		c.emitExtractionEvent("playlist_page", "cached", "web", fmt.Sprintf("id=%s items=%d revalidated", playlistID, len(cached.Items)))
+		return cached.playlistInfo(), nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("playlist fetch failed: status=%d", resp.StatusCode)
 	}
-	body, err := io.ReadAll(resp.Body)
+	body, err := httpx.ReadBody(resp)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +204,40 @@ func (c *Client) GetPlaylist(ctx context.Context, input string) (*PlaylistInfo,
 	}
 
 	pendingContinuations := findContinuationTokens(root)
+	c.emitExtractionEvent("playlist_page", "success", "web", fmt.Sprintf("id=%s items=%d continuations=%d", playlistID, len(info.Items), len(pendingContinuations)))
 	visitorData := findVisitorData(root)
+	c.followPlaylistContinuations(info, pendingContinuations, options, func(continuation string) ([]PlaylistItem, []string, error) {
+		browseResp, err := c.browse(ctx, continuation, visitorData)
+		if err != nil {
+			return nil, nil, err
+		}
+		newItems, nextTokens := parseBrowseResponse(browseResp)
+		return newItems, nextTokens, nil
+	})
+
+	stats := info.ContinuationStats
+	c.emitExtractionEvent("playlist", "complete", "web", fmt.Sprintf("id=%s items=%d continuations=%d failed=%d stopped_by_limit=%t stopped_by_known=%t",
+		playlistID, len(info.Items), stats.Requested, stats.Failed, stats.StoppedByLimit, stats.StoppedByKnown))
+	if !info.ContinuationStats.StoppedByKnown && info.ContinuationStats.Failed == 0 {
+		c.storePlaylistCache(playlistCacheEntry{
+			ID:        info.ID,
+			Title:     info.Title,
+			Items:     info.Items,
+			ETag:      resp.Header.Get("ETag"),
+			FetchedAt: time.Now(),
+		})
+	}
+	return info, nil
+}
+
+// followPlaylistContinuations pages info through pending continuation
+// tokens with fetch, within Config.PlaylistContinuationMaxRequests and
+// stopping at options.StopAtKnown. Failed pages are recorded as warnings.
+func (c *Client) followPlaylistContinuations(info *PlaylistInfo, pendingContinuations []string, options PlaylistOptions, fetch func(continuation string) ([]PlaylistItem, []string, error)) {
+	if containsKnownPlaylistItem(info.Items, options.StopAtKnown) {
+		info.ContinuationStats.StoppedByKnown = true
+		pendingContinuations = nil
+	}
 	seenContinuations := make(map[string]struct{}, len(pendingContinuations))
 	maxRequests := c.config.PlaylistContinuationMaxRequests
 	if maxRequests <= 0 {
@@ -182,19 +266,25 @@ func (c *Client) GetPlaylist(ctx context.Context, input string) (*PlaylistInfo,
 		seenContinuations[continuation] = struct{}{}
 		info.ContinuationStats.Requested++
 
-		browseResp, err := c.browse(ctx, continuation, visitorData)
+		c.emitExtractionEvent("playlist_continuation", "start", "web", fmt.Sprintf("request=%d", info.ContinuationStats.Requested))
+		newItems, nextTokens, err := fetch(continuation)
 		if err != nil {
 			// Fail gracefully on continuation error and continue remaining candidates.
 			info.ContinuationStats.Failed++
 			warn := continuationWarningFromError(continuation, err)
 			info.ContinuationWarnings = append(info.ContinuationWarnings, warn)
 			c.warnf("failed to fetch continuation: %v", err)
+			c.emitExtractionEvent("playlist_continuation", "failure", "web", err.Error())
 			continue
 		}
 		info.ContinuationStats.Succeeded++
 
-		newItems, nextTokens := parseBrowseResponse(browseResp)
 		info.Items = append(info.Items, newItems...)
+		c.emitExtractionEvent("playlist_continuation", "success", "web", fmt.Sprintf("items=%d total=%d", len(newItems), len(info.Items)))
+		if containsKnownPlaylistItem(newItems, options.StopAtKnown) {
+			info.ContinuationStats.StoppedByKnown = true
+			break
+		}
 		for _, token := range nextTokens {
 			token = strings.TrimSpace(token)
 			if token == "" {
@@ -206,21 +296,60 @@ func (c *Client) GetPlaylist(ctx context.Context, input string) (*PlaylistInfo,
 			pendingContinuations = append(pendingContinuations, token)
 		}
 	}
+}
 
-	return info, nil
+func containsKnownPlaylistItem(items []PlaylistItem, known func(videoID string) bool) bool {
+	if known == nil {
+		return false
+	}
+	for _, item := range items {
+		if known(item.VideoID) {
+			return true
+		}
+	}
+	return false
 }
 
 func (c *Client) browse(ctx context.Context, continuation string, visitorData string) (*innertube.BrowseResponse, error) {
-	clientProfile := innertube.WebClient
-	req := innertube.NewBrowseRequest(clientProfile, "", continuation, innertube.PlayerRequestOptions{
-		VisitorData: visitorData,
+	body, err := c.browseRaw(ctx, continuation, visitorData)
+	if err != nil {
+		return nil, err
+	}
+	var browseResp innertube.BrowseResponse
+	if err := json.Unmarshal(body, &browseResp); err != nil {
+		return nil, err
+	}
+	return &browseResp, nil
+}
+
+// browseRaw posts a browse continuation and returns the response JSON.
+func (c *Client) browseRaw(ctx context.Context, continuation string, visitorData string) ([]byte, error) {
+	return c.postBrowse(ctx, browseQuery{Continuation: continuation, VisitorData: visitorData})
+}
+
+// browseQuery is one web browse request: either a BrowseID or a
+// Continuation. Authenticated requests carry the cookie auth headers.
+type browseQuery struct {
+	BrowseID      string
+	Continuation  string
+	VisitorData   string
+	Authenticated bool
+}
+
+// postBrowse posts q to the web browse endpoint and returns the response JSON.
+func (c *Client) postBrowse(ctx context.Context, q browseQuery) ([]byte, error) {
+	clientProfile := innertube.WebClient.WithUserAgent(c.config.UserAgents.Metadata, c.config.UserAgents.MetadataByClient).
+		WithAPIHost(c.config.InnertubeHost, c.config.InnertubeHosts)
+	req := innertube.NewBrowseRequest(clientProfile, q.BrowseID, q.Continuation, innertube.PlayerRequestOptions{
+		VisitorData: q.VisitorData,
+		Language:    c.config.MetadataLanguage,
 	})
 	body, err := innertube.MarshalRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
-	apiURL := "https://" + clientProfile.Host + "/youtubei/v1/browse?key=" + clientProfile.APIKey
+	apiURL := clientProfile.APIURL("/youtubei/v1/browse") + "?key=" + clientProfile.APIKey
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
@@ -228,10 +357,19 @@ func (c *Client) browse(ctx context.Context, continuation string, visitorData st
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("User-Agent", clientProfile.UserAgent)
 	httpReq.Header.Set("Origin", "https://"+clientProfile.Host)
+	httpx.AcceptCompressed(httpReq)
 
 	// Add global request headers
 	applyRequestHeaders(httpReq, c.config.RequestHeaders)
+	if q.Authenticated {
+		for key, values := range innertube.BuildCookieAuthHeaders(c.httpClient(), clientProfile.Host, time.Now(), innertube.CookieAuthContext{}) {
+			httpReq.Header[key] = values
+		}
+	}
 
+	if err := c.quota.Wait(ctx, clientProfile.ID); err != nil {
+		return nil, err
+	}
 	resp, err := c.httpClient().Do(httpReq)
 	if err != nil {
 		return nil, err
@@ -242,11 +380,7 @@ func (c *Client) browse(ctx context.Context, continuation string, visitorData st
 		return nil, &browseRequestError{StatusCode: resp.StatusCode}
 	}
 
-	var browseResp innertube.BrowseResponse
-	if err := json.NewDecoder(resp.Body).Decode(&browseResp); err != nil {
-		return nil, err
-	}
-	return &browseResp, nil
+	return httpx.ReadBody(resp)
 }
 
 type browseRequestError struct {
@@ -423,7 +557,7 @@ func findAutoTrackByLanguage(tracks []SubtitleTrack, languageCode string) (Subti
 	return SubtitleTrack{}, false
 }
 
-func fetchTranscriptXML(ctx context.Context, httpClient *http.Client, headers http.Header, baseURL string) ([]byte, error) {
+func fetchTranscriptXML(ctx context.Context, httpClient *http.Client, headers http.Header, userAgent, baseURL string) ([]byte, error) {
 	u, err := url.Parse(baseURL)
 	if err != nil {
 		return nil, err
@@ -438,6 +572,8 @@ func fetchTranscriptXML(ctx context.Context, httpClient *http.Client, headers ht
 	if err != nil {
 		return nil, err
 	}
+	req.Header.Set("User-Agent", userAgent)
+	httpx.AcceptCompressed(req)
 	applyRequestHeaders(req, headers)
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -450,9 +586,10 @@ func fetchTranscriptXML(ctx context.Context, httpClient *http.Client, headers ht
 		}
 		return nil, fmt.Errorf("transcript fetch failed: status=%d", resp.StatusCode)
 	}
-	return io.ReadAll(resp.Body)
+	return httpx.ReadBody(resp)
 }
 
+// parseTranscriptXML parses legacy <text> transcripts and srv3 timedtext.
 func parseTranscriptXML(raw []byte) ([]TranscriptEntry, error) {
 	type textNode struct {
 		Start string `xml:"start,attr"`
@@ -460,12 +597,18 @@ func parseTranscriptXML(raw []byte) ([]TranscriptEntry, error) {
 		Text  string `xml:",chardata"`
 	}
 	type transcriptDoc struct {
-		Texts []textNode `xml:"text"`
+		Texts []textNode      `xml:"text"`
+		Pens  []srv3Pen       `xml:"head>pen"`
+		WPs   []srv3Window    `xml:"head>wp"`
+		Paras []srv3Paragraph `xml:"body>p"`
 	}
 	var doc transcriptDoc
 	if err := xml.Unmarshal(raw, &doc); err != nil {
 		return nil, err
 	}
+	if len(doc.Texts) == 0 && len(doc.Paras) > 0 {
+		return parseSRV3Paragraphs(doc.Paras, doc.Pens, doc.WPs), nil
+	}
 	out := make([]TranscriptEntry, 0, len(doc.Texts))
 	for _, n := range doc.Texts {
 		start, err := parseFloatString(n.Start)
@@ -570,9 +713,16 @@ func findPlaylistTitle(root any) string {
 }
 
 func findPlaylistItems(root any) []PlaylistItem {
+	return findRendererItems(root, "playlistVideoRenderer")
+}
+
+// findRendererItems collects the entries rendered under key. Feeds such as
+// watch history list videoRenderer entries, which share the playlist
+// renderer's fields.
+func findRendererItems(root any, key string) []PlaylistItem {
 	out := make([]PlaylistItem, 0, 32)
 	walkAny(root, func(m map[string]any) {
-		v, ok := m["playlistVideoRenderer"]
+		v, ok := m[key]
 		if !ok {
 			return
 		}
@@ -580,21 +730,71 @@ func findPlaylistItems(root any) []PlaylistItem {
 		if !ok {
 			return
 		}
-		videoID := getStringFromMap(itemMap, "videoId")
-		if videoID == "" {
+		if getStringFromMap(itemMap, "videoId") == "" {
 			return
 		}
-		out = append(out, PlaylistItem{
-			VideoID:         videoID,
-			Title:           getTextField(itemMap["title"]),
-			Author:          getTextField(itemMap["shortBylineText"]),
-			DurationSeconds: getTextField(itemMap["lengthText"]),
-			DurationSec:     parseDurationTextSeconds(getTextField(itemMap["lengthText"])),
-		})
+		// Round-trip through the typed renderer so both parse paths
+		// read the same fields.
+		raw, err := json.Marshal(itemMap)
+		if err != nil {
+			return
+		}
+		var renderer innertube.PlaylistVideoRenderer
+		if err := json.Unmarshal(raw, &renderer); err != nil {
+			return
+		}
+		out = append(out, playlistItemFromRenderer(renderer))
 	})
 	return out
 }
 
+// Placeholder titles YouTube lists private and deleted videos under.
+const (
+	privateVideoTitle = "[Private video]"
+	deletedVideoTitle = "[Deleted video]"
+)
+
+// playlistItemFromRenderer converts one playlistVideoRenderer. Dead entries
+// are recognized by their placeholder titles or isPlayable=false.
+func playlistItemFromRenderer(v innertube.PlaylistVideoRenderer) PlaylistItem {
+	length := captionName(v.LengthText)
+	item := PlaylistItem{
+		VideoID:         v.VideoID,
+		Title:           captionName(v.Title),
+		Author:          captionName(v.ShortBylineText),
+		DurationSeconds: length,
+		DurationSec:     parseDurationTextSeconds(length),
+		Index:           int(parseInt64String(strings.TrimSpace(captionName(v.Index)))),
+		ThumbnailURL:    largestThumbnailURL(v.Thumbnail.Thumbnails),
+	}
+	if item.DurationSec == 0 {
+		item.DurationSec = parseInt64String(v.LengthSeconds)
+	}
+	switch {
+	case item.Title == privateVideoTitle:
+		item.UnavailableReason = PlaylistItemPrivate
+	case item.Title == deletedVideoTitle:
+		item.UnavailableReason = PlaylistItemDeleted
+	case v.IsPlayable != nil && !*v.IsPlayable:
+		item.UnavailableReason = PlaylistItemUnavailable
+	}
+	item.Unavailable = item.UnavailableReason != ""
+	return item
+}
+
+func largestThumbnailURL(thumbnails []innertube.Thumbnail) string {
+	best := -1
+	for i, t := range thumbnails {
+		if t.URL != "" && (best < 0 || t.Width*t.Height > thumbnails[best].Width*thumbnails[best].Height) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return thumbnails[best].URL
+}
+
 func walkAny(v any, visitMap func(map[string]any)) {
 	switch x := v.(type) {
 	case map[string]any:
@@ -693,14 +893,7 @@ func parseBrowseResponse(resp *innertube.BrowseResponse) ([]PlaylistItem, []stri
 	extractFromItems := func(cItems []innertube.ContinuationItem) {
 		for _, item := range cItems {
 			if item.PlaylistVideoRenderer != nil {
-				v := item.PlaylistVideoRenderer
-				items = append(items, PlaylistItem{
-					VideoID:         v.VideoID,
-					Title:           captionName(v.Title),
-					Author:          captionName(v.ShortBylineText),
-					DurationSeconds: captionName(v.LengthText),
-					DurationSec:     parseDurationTextSeconds(captionName(v.LengthText)),
-				})
+				items = append(items, playlistItemFromRenderer(*item.PlaylistVideoRenderer))
 			}
 			if item.ContinuationItemRenderer != nil {
 				appendToken(item.ContinuationItemRenderer.ContinuationEndpoint.ContinuationCommand.Token)
@@ -735,14 +928,7 @@ func parseBrowseResponse(resp *innertube.BrowseResponse) ([]PlaylistItem, []stri
 					if section.ItemSectionRenderer != nil {
 						for _, item := range section.ItemSectionRenderer.Contents {
 							if item.PlaylistVideoRenderer != nil {
-								v := item.PlaylistVideoRenderer
-								items = append(items, PlaylistItem{
-									VideoID:         v.VideoID,
-									Title:           captionName(v.Title),
-									Author:          captionName(v.ShortBylineText),
-									DurationSeconds: captionName(v.LengthText),
-									DurationSec:     parseDurationTextSeconds(captionName(v.LengthText)),
-								})
+								items = append(items, playlistItemFromRenderer(*item.PlaylistVideoRenderer))
 							}
 						}
 					}
@@ -146,6 +146,44 @@ func TestGetPlaylist(t *testing.T) {
 	}
 }
 
+func TestGetPlaylist_ItemMetadataAndAvailability(t *testing.T) {
+	html := `<html><script>var ytInitialData = {"contents":[` +
+		`{"playlistVideoRenderer":{"videoId":"aaaaaaaaaaa","index":{"simpleText":"1"},"title":{"runs":[{"text":"one"}]},"shortBylineText":{"runs":[{"text":"author1"}]},"lengthSeconds":"75","isPlayable":true,` +
+		`"thumbnail":{"thumbnails":[{"url":"https://i.ytimg.com/vi/a/small.jpg","width":120,"height":90},{"url":"https://i.ytimg.com/vi/a/big.jpg","width":336,"height":188}]}}},` +
+		`{"playlistVideoRenderer":{"videoId":"bbbbbbbbbbb","index":{"simpleText":"2"},"title":{"runs":[{"text":"[Private video]"}]},"isPlayable":false}},` +
+		`{"playlistVideoRenderer":{"videoId":"ccccccccccc","index":{"simpleText":"3"},"title":{"runs":[{"text":"[Deleted video]"}]}}},` +
+		`{"playlistVideoRenderer":{"videoId":"ddddddddddd","index":{"simpleText":"4"},"title":{"runs":[{"text":"blocked"}]},"isPlayable":false,"unplayableText":{"simpleText":"Video unavailable"}}}` +
+		`]};</script></html>`
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(bytes.NewBufferString(html)),
+			}, nil
+		}),
+	}
+
+	c := &Client{config: Config{HTTPClient: httpClient}}
+	got, err := c.GetPlaylist(context.Background(), "https://www.youtube.com/playlist?list=PL1234567890")
+	if err != nil {
+		t.Fatalf("GetPlaylist() error = %v", err)
+	}
+	if len(got.Items) != 4 {
+		t.Fatalf("items len=%d, want 4", len(got.Items))
+	}
+	first := got.Items[0]
+	if first.Index != 1 || first.Author != "author1" || first.DurationSec != 75 || first.ThumbnailURL != "https://i.ytimg.com/vi/a/big.jpg" || first.Unavailable {
+		t.Fatalf("item[0] = %+v", first)
+	}
+	for i, want := range []string{"", PlaylistItemPrivate, PlaylistItemDeleted, PlaylistItemUnavailable} {
+		item := got.Items[i]
+		if item.UnavailableReason != want || item.Unavailable != (want != "") || item.Index != i+1 {
+			t.Fatalf("item[%d] = %+v, want reason %q", i, item, want)
+		}
+	}
+}
+
 func TestGetPlaylist_ContinuationSkipsInvalidToken(t *testing.T) {
 	html := `<html><script>var ytInitialData = {"responseContext":{"visitorData":"visitor"},"metadata":{"playlistMetadataRenderer":{"title":"My Playlist"}},"contents":[{"playlistVideoRenderer":{"videoId":"aaaaaaaaaaa","title":{"simpleText":"one"},"shortBylineText":{"runs":[{"text":"author1"}]},"lengthText":{"simpleText":"1:00"}}},{"playlistVideoRenderer":{"videoId":"bbbbbbbbbbb","title":{"runs":[{"text":"two"}]},"shortBylineText":{"runs":[{"text":"author2"}]},"lengthText":{"simpleText":"2:00"}}},{"continuationItemRenderer":{"continuationEndpoint":{"continuationCommand":{"token":"bad-token"}}}},{"continuationItemRenderer":{"continuationEndpoint":{"continuationCommand":{"token":"good-token-1"}}}}]};</script></html>`
 	httpClient := &http.Client{
@@ -6,16 +6,16 @@ import (
 	"github.com/famomatic/ytv1/internal/innertube"
 )
 
-func filterFormatsByPoTokenPolicy(formats []FormatInfo, cfg Config) ([]FormatInfo, []FormatSkipReason) {
-	if len(formats) == 0 {
+func filterFormatsByPoTokenPolicy(candidates []FormatInfo, cfg Config) ([]FormatInfo, []FormatSkipReason) {
+	if len(candidates) == 0 {
 		return nil, nil
 	}
 
 	hasProvider := cfg.PoTokenProvider != nil
-	kept := make([]FormatInfo, 0, len(formats))
+	kept := make([]FormatInfo, 0, len(candidates))
 	skips := make([]FormatSkipReason, 0)
 
-	for _, f := range formats {
+	for _, f := range candidates {
 		if f.IsDRM {
 			skips = append(skips, FormatSkipReason{
 				Itag:     f.Itag,
@@ -32,6 +32,14 @@ func filterFormatsByPoTokenPolicy(formats []FormatInfo, cfg Config) ([]FormatInf
 			})
 			continue
 		}
+		if hasFormatHint(f, FormatHintPremium) && cfg.CookieJar == nil {
+			skips = append(skips, FormatSkipReason{
+				Itag:     f.Itag,
+				Protocol: f.Protocol,
+				Reason:   "premium_requires_login",
+			})
+			continue
+		}
 		protocol := protocolFromFormat(f)
 		policy := poTokenFetchPolicyForSourceClient(f.SourceClient, protocol, cfg.PoTokenFetchPolicy)
 		if policy == innertube.PoTokenFetchPolicyRequired && !hasProvider {
@@ -48,6 +56,15 @@ func filterFormatsByPoTokenPolicy(formats []FormatInfo, cfg Config) ([]FormatInf
 	return kept, skips
 }
 
+func hasFormatHint(f FormatInfo, hint string) bool {
+	for _, h := range f.Hints {
+		if h == hint {
+			return true
+		}
+	}
+	return false
+}
+
 func poTokenFetchPolicyForSourceClient(
 	sourceClient string,
 	protocol innertube.VideoStreamingProtocol,
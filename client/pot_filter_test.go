@@ -2,6 +2,7 @@ package client
 
 import (
 	"errors"
+	"net/http/cookiejar"
 	"testing"
 
 	"github.com/famomatic/ytv1/internal/innertube"
@@ -97,3 +98,27 @@ func TestFilterFormatsByPoTokenPolicy_DropsDRMAndDamagedFormats(t *testing.T) {
 		t.Fatalf("unexpected skip reasons: %+v", skips)
 	}
 }
+
+func TestFilterFormatsByPoTokenPolicy_PremiumNeedsCookies(t *testing.T) {
+	formats := []FormatInfo{
+		{Itag: 616, Protocol: "hls", HasVideo: true, Hints: []string{FormatHintPremium}},
+		{Itag: 137, Protocol: "https", HasVideo: true},
+	}
+
+	kept, skips := filterFormatsByPoTokenPolicy(formats, Config{})
+	if len(kept) != 1 || kept[0].Itag != 137 {
+		t.Fatalf("unexpected kept formats: %+v", kept)
+	}
+	if len(skips) != 1 || skips[0].Reason != "premium_requires_login" {
+		t.Fatalf("unexpected skips: %+v", skips)
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New() error = %v", err)
+	}
+	kept, _ = filterFormatsByPoTokenPolicy(formats, Config{CookieJar: jar})
+	if len(kept) != 2 {
+		t.Fatalf("expected premium format kept with cookies, got %+v", kept)
+	}
+}
@@ -27,25 +27,19 @@ func protocolFromURL(rawURL string) innertube.VideoStreamingProtocol {
 }
 
 func hasPoTokenInURL(rawURL string) bool {
-	u, err := url.Parse(strings.TrimSpace(rawURL))
-	if err != nil {
-		return false
-	}
-	if strings.TrimSpace(u.Query().Get("pot")) != "" {
-		return true
-	}
-	return strings.Contains(u.Path, "/pot/")
+	m, err := parseMediaURL(rawURL)
+	return err == nil && m.has("pot")
 }
 
+// injectPoToken sets pot on rawURL, leaving its other parameters as they
+// are.
 func injectPoToken(rawURL string, token string) (string, error) {
-	u, err := url.Parse(strings.TrimSpace(rawURL))
+	m, err := parseMediaURL(rawURL)
 	if err != nil {
 		return "", err
 	}
-	q := u.Query()
-	q.Set("pot", strings.TrimSpace(token))
-	u.RawQuery = q.Encode()
-	return u.String(), nil
+	m.set("pot", strings.TrimSpace(token))
+	return m.String(), nil
 }
 
 func poTokenProviderClientID(sourceClient string) string {
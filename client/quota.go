@@ -0,0 +1,12 @@
+package client
+
+import "github.com/famomatic/ytv1/internal/innertube"
+
+// InnertubeUsage is a point-in-time view of one Innertube client's request volume.
+type InnertubeUsage = innertube.QuotaUsage
+
+// InnertubeUsage reports per-client Innertube request volume against the
+// configured InnertubeQuota ceilings, sorted by client ID.
+func (c *Client) InnertubeUsage() []InnertubeUsage {
+	return c.quota.Usage()
+}
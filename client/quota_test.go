@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInnertubeQuota_TracksAndCapsPlayerRequests(t *testing.T) {
+	var playerCalls int
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player") {
+				playerCalls++
+				body := `{"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},"streamingData":{"formats":[{"itag":18,"url":"https://media.example/v.mp4","mimeType":"video/mp4"}]}}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			}
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+		}),
+	}
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+		InnertubeQuota:  InnertubeQuotaConfig{RequestsPerHour: 1, MaxWait: time.Second},
+	})
+	if _, err := c.GetVideo(context.Background(), "jNQXAC9IVRw"); err != nil {
+		t.Fatalf("GetVideo() error = %v", err)
+	}
+	usage := c.InnertubeUsage()
+	if len(usage) != 1 || usage[0].Client != "mweb" || usage[0].Requests != 1 || usage[0].Limit != 1 {
+		t.Fatalf("InnertubeUsage() = %+v", usage)
+	}
+
+	c.dropSession("jNQXAC9IVRw")
+	if _, err := c.GetVideo(context.Background(), "jNQXAC9IVRw"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("second GetVideo() error = %v, want ErrQuotaExceeded", err)
+	}
+	if playerCalls != 1 {
+		t.Fatalf("player calls = %d, want 1", playerCalls)
+	}
+	if got := c.InnertubeUsage()[0].Rejected; got != 1 {
+		t.Fatalf("Rejected = %d, want 1", got)
+	}
+}
@@ -3,8 +3,11 @@ package client
 import (
 	"context"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/famomatic/ytv1/internal/httpx"
 	"github.com/famomatic/ytv1/internal/innertube"
 )
 
@@ -18,6 +21,19 @@ func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Con
 	return context.WithTimeout(ctx, timeout)
 }
 
+// withRetryBudget attaches a fresh Config.RetryBudget to ctx unless ctx
+// already carries one (e.g. GetVideo called from Download).
+func (c *Client) withRetryBudget(ctx context.Context) context.Context {
+	budget := c.config.RetryBudget
+	if budget.MaxRetries <= 0 && budget.MaxDelay <= 0 {
+		return ctx
+	}
+	if httpx.RetryBudgetFrom(ctx) != nil {
+		return ctx
+	}
+	return httpx.WithRetryBudget(ctx, httpx.NewRetryBudget(budget.MaxRetries, budget.MaxDelay))
+}
+
 func applyRequestHeaders(req *http.Request, headers http.Header) {
 	for k, vals := range headers {
 		for _, v := range vals {
@@ -26,9 +42,92 @@ func applyRequestHeaders(req *http.Request, headers http.Header) {
 	}
 }
 
+// applyMediaRequestHeaders prepares a media request: the media headers and,
+// with Config.MediaURLParams, the playback query parameters. Set any Range
+// header before calling it.
 func applyMediaRequestHeaders(req *http.Request, headers http.Header, videoID string) {
 	merged := buildMediaRequestHeaders(headers, videoID)
 	applyRequestHeaders(req, merged)
+	composeMediaRequestURL(req)
+}
+
+var (
+	defaultMediaCookieHosts = []string{"googlevideo.com", "youtube.com"}
+	mediaCookieSourceURL    = &url.URL{Scheme: "https", Host: "www.youtube.com", Path: "/"}
+)
+
+// mediaHeaders returns the configured request headers for a media request to
+// streamURL, with UserAgents.Media as User-Agent and a Cookie header when
+// MediaCookies forwarding allows it.
+func (c *Client) mediaHeaders(streamURL string) http.Header {
+	headers := c.config.RequestHeaders
+	userAgent := c.config.UserAgents.Media
+	cookie := c.mediaCookieHeader(streamURL)
+	if cookie == "" && userAgent == "" {
+		return headers
+	}
+	headers = cloneHeader(headers)
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	if userAgent != "" {
+		headers.Set("User-Agent", userAgent)
+	}
+	if cookie != "" {
+		if existing := headers.Get("Cookie"); existing != "" {
+			cookie = existing + "; " + cookie
+		}
+		headers.Set("Cookie", cookie)
+	}
+	return headers
+}
+
+// pageUserAgent is the User-Agent for YouTube web page fetches.
+func (c *Client) pageUserAgent() string {
+	return firstNonEmptyString(c.config.UserAgents.WatchPage, innertube.WebClient.UserAgent)
+}
+
+func (c *Client) mediaCookieHeader(streamURL string) string {
+	cfg := c.config.MediaCookies
+	jar := c.httpClient().Jar
+	if !cfg.Enable || jar == nil {
+		return ""
+	}
+	u, err := url.Parse(streamURL)
+	if err != nil || !mediaCookieHostAllowed(u.Hostname(), cfg.Hosts) {
+		return ""
+	}
+	// The jar already attaches its own cookies for the stream host; skip those
+	// names so requests to youtube.com do not carry duplicates.
+	attached := make(map[string]struct{})
+	for _, ck := range jar.Cookies(u) {
+		attached[ck.Name] = struct{}{}
+	}
+	parts := make([]string, 0, 8)
+	for _, ck := range jar.Cookies(mediaCookieSourceURL) {
+		if _, ok := attached[ck.Name]; ok {
+			continue
+		}
+		parts = append(parts, ck.Name+"="+ck.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func mediaCookieHostAllowed(host string, allowlist []string) bool {
+	if len(allowlist) == 0 {
+		allowlist = defaultMediaCookieHosts
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, entry := range allowlist {
+		entry = strings.ToLower(strings.Trim(strings.TrimSpace(entry), "."))
+		if entry == "" {
+			continue
+		}
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
 }
 
 func cloneHeader(h http.Header) http.Header {
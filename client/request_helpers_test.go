@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDownload_ForwardsSessionCookiesToAllowlistedMediaHost(t *testing.T) {
+	tests := []struct {
+		name       string
+		mediaURL   string
+		cookies    MediaCookieConfig
+		wantCookie string
+	}{
+		{
+			name:       "googlevideo allowed by default",
+			mediaURL:   "https://rr1---sn-abc.googlevideo.com/videoplayback?itag=18",
+			cookies:    MediaCookieConfig{Enable: true},
+			wantCookie: "SID=session",
+		},
+		{
+			name:     "disabled",
+			mediaURL: "https://rr1---sn-abc.googlevideo.com/videoplayback?itag=18",
+		},
+		{
+			name:     "host outside allowlist",
+			mediaURL: "https://media.example/v18.mp4",
+			cookies:  MediaCookieConfig{Enable: true},
+		},
+		{
+			name:       "custom allowlist",
+			mediaURL:   "https://cdn.media.example/v18.mp4",
+			cookies:    MediaCookieConfig{Enable: true, Hosts: []string{"media.example"}},
+			wantCookie: "SID=session",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotCookie string
+			httpClient := &http.Client{
+				Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+					switch {
+					case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+						body := `{
+							"playabilityStatus":{"status":"OK"},
+							"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+							"streamingData":{"formats":[
+								{"itag":18,"url":"` + tt.mediaURL + `","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}
+							]}
+						}`
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+					case r.Method == http.MethodGet && r.URL.String() == tt.mediaURL:
+						gotCookie = r.Header.Get("Cookie")
+						return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("payload")), Header: make(http.Header)}, nil
+					default:
+						return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+					}
+				}),
+			}
+			jar, err := cookiejar.New(nil)
+			if err != nil {
+				t.Fatalf("cookiejar.New() error = %v", err)
+			}
+			jar.SetCookies(&url.URL{Scheme: "https", Host: "youtube.com"}, []*http.Cookie{{Name: "SID", Value: "session", Domain: ".youtube.com"}})
+
+			c := New(Config{
+				HTTPClient:      httpClient,
+				CookieJar:       jar,
+				MediaCookies:    tt.cookies,
+				ClientOverrides: []string{"mweb"},
+			})
+			out := filepath.Join(t.TempDir(), "out.mp4")
+			if _, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{Itag: 18, OutputPath: out}); err != nil {
+				t.Fatalf("Download() error = %v", err)
+			}
+			if gotCookie != tt.wantCookie {
+				t.Fatalf("media Cookie header = %q, want %q", gotCookie, tt.wantCookie)
+			}
+		})
+	}
+}
+
+func TestMediaCookieHostAllowed(t *testing.T) {
+	if !mediaCookieHostAllowed("rr3---sn-x.googlevideo.com", nil) {
+		t.Fatal("expected googlevideo subdomain to be allowed by default")
+	}
+	if mediaCookieHostAllowed("evilgooglevideo.com", nil) {
+		t.Fatal("expected suffix without dot boundary to be rejected")
+	}
+	if !mediaCookieHostAllowed("YouTube.com.", []string{".youtube.com"}) {
+		t.Fatal("expected case/trailing-dot insensitive match")
+	}
+}
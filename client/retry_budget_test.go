@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownload_RetryBudgetSharedAcrossStages(t *testing.T) {
+	mediaURL := "https://media.example/v.mp4"
+	var playerCalls, mediaCalls int
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		switch {
+		case strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+			playerCalls++
+			if playerCalls == 1 {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+			}
+			body := `{"playabilityStatus":{"status":"OK"},
+				"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x"},
+				"streamingData":{"formats":[{"itag":18,"url":"` + mediaURL + `","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}]}}`
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+		case r.URL.String() == mediaURL:
+			if r.Header.Get("Range") != "bytes=0-0" { // skip the size probe
+				mediaCalls++
+			}
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header)}, nil
+	})}
+	c := New(Config{
+		HTTPClient:        httpClient,
+		ClientOverrides:   []string{"mweb"},
+		MetadataTransport: MetadataTransportConfig{MaxRetries: 5, InitialBackoff: time.Millisecond},
+		DownloadTransport: DownloadTransportConfig{MaxRetries: 5, InitialBackoff: time.Millisecond},
+		RetryBudget:       RetryBudgetConfig{MaxRetries: 3},
+	})
+
+	_, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{Itag: 18, OutputPath: filepath.Join(t.TempDir(), "out.mp4")})
+	if err == nil {
+		t.Fatal("Download() succeeded against a failing media host")
+	}
+	// One metadata retry leaves two of the three budgeted retries for the media download.
+	if playerCalls != 2 || mediaCalls != 3 {
+		t.Fatalf("player calls = %d, media calls = %d, want 2 and 3", playerCalls, mediaCalls)
+	}
+}
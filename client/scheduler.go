@@ -0,0 +1,328 @@
+package client
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Priority orders downloads waiting for a Scheduler slot. Higher values go
+// first; the named levels are the usual ones, but any int works.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// SchedulerConfig sets the global limits of a Scheduler. Zero means
+// unlimited.
+type SchedulerConfig struct {
+	// MaxExtractions bounds downloads resolving metadata and selecting
+	// formats (player requests, challenge solving) at the same time.
+	MaxExtractions int
+	// MaxStreams bounds downloads transferring media at the same time. A
+	// download holds one slot from its first media byte until its streams
+	// are written and merged; post-processing such as audio extraction runs
+	// outside the slot.
+	MaxStreams int
+	// MaxBytesPerSecond caps the combined media bandwidth of all scheduled
+	// downloads. External downloaders are not throttled. Capped transfers
+	// skip Config.ThrottleDetection and Config.SlowDownloadAbort, whose
+	// speed checks would mistake the cap for a slow URL.
+	MaxBytesPerSecond int64
+}
+
+// ScheduleOptions places one download in a Scheduler's queues.
+type ScheduleOptions struct {
+	Priority Priority
+	// Group names the job the download belongs to, such as a playlist or a
+	// daemon user. Among waiters of equal priority, a free slot goes to the
+	// group holding the fewest slots, so one large job cannot starve the
+	// others; ties go to the earliest waiter.
+	Group string
+}
+
+// SchedulerStats is a snapshot of a Scheduler's slots.
+type SchedulerStats struct {
+	ActiveExtractions int
+	QueuedExtractions int
+	ActiveStreams     int
+	QueuedStreams     int
+}
+
+// Scheduler runs downloads of one Client under global limits shared by all
+// of them, for daemons and libraries juggling many videos at once. It is
+// safe for concurrent use; downloads started through the Client directly
+// are not counted.
+type Scheduler struct {
+	client      *Client
+	extractions *slotPool
+	streams     *slotPool
+	rate        *byteRate
+}
+
+// NewScheduler returns a Scheduler for c with the given limits.
+func (c *Client) NewScheduler(config SchedulerConfig) *Scheduler {
+	s := &Scheduler{
+		client:      c,
+		extractions: newSlotPool(config.MaxExtractions),
+		streams:     newSlotPool(config.MaxStreams),
+	}
+	if config.MaxBytesPerSecond > 0 {
+		s.rate = &byteRate{perSecond: float64(config.MaxBytesPerSecond)}
+	}
+	return s
+}
+
+// Download is Client.Download waiting for the scheduler's slots and
+// sharing its bandwidth. Cancelling ctx also abandons a place in a queue.
+func (s *Scheduler) Download(ctx context.Context, input string, options DownloadOptions, sched ScheduleOptions) (*DownloadResult, error) {
+	return s.client.Download(s.scheduled(ctx, sched), input, options)
+}
+
+// StartDownload is Client.StartDownload under the scheduler. A paused job
+// gives up its slots; Resume queues it again.
+func (s *Scheduler) StartDownload(ctx context.Context, input string, options DownloadOptions, sched ScheduleOptions) (*Job, error) {
+	return s.client.StartDownload(s.scheduled(ctx, sched), input, options)
+}
+
+// Stats reports the slots in use and the downloads waiting for one.
+func (s *Scheduler) Stats() SchedulerStats {
+	var st SchedulerStats
+	st.ActiveExtractions, st.QueuedExtractions = s.extractions.counts()
+	st.ActiveStreams, st.QueuedStreams = s.streams.counts()
+	return st
+}
+
+type scheduleKey struct{}
+
+// scheduledRun is what a scheduled Download finds in its context.
+type scheduledRun struct {
+	scheduler *Scheduler
+	opts      ScheduleOptions
+}
+
+func (s *Scheduler) scheduled(ctx context.Context, sched ScheduleOptions) context.Context {
+	return context.WithValue(ctx, scheduleKey{}, &scheduledRun{scheduler: s, opts: sched})
+}
+
+func scheduledRunFrom(ctx context.Context) *scheduledRun {
+	run, _ := ctx.Value(scheduleKey{}).(*scheduledRun)
+	return run
+}
+
+// acquireExtraction waits for an extraction slot when ctx belongs to a
+// scheduled download. The returned release is safe to call more than once.
+func acquireExtraction(ctx context.Context) (func(), error) {
+	run := scheduledRunFrom(ctx)
+	if run == nil {
+		return func() {}, nil
+	}
+	return run.scheduler.extractions.acquire(ctx, run.opts)
+}
+
+// acquireStream is acquireExtraction for media stream slots.
+func acquireStream(ctx context.Context) (func(), error) {
+	run := scheduledRunFrom(ctx)
+	if run == nil {
+		return func() {}, nil
+	}
+	return run.scheduler.streams.acquire(ctx, run.opts)
+}
+
+// downloadRateLimited reports whether ctx belongs to a scheduled download
+// held to the scheduler's bandwidth.
+func downloadRateLimited(ctx context.Context) bool {
+	run := scheduledRunFrom(ctx)
+	return run != nil && run.scheduler.rate != nil
+}
+
+// limitDownloadRate throttles reads of media bytes to the scheduler's
+// bandwidth.
+func limitDownloadRate(ctx context.Context, r io.Reader) io.Reader {
+	if run := scheduledRunFrom(ctx); run != nil && run.scheduler.rate != nil {
+		return &rateLimitedReader{ctx: ctx, r: r, rate: run.scheduler.rate}
+	}
+	return r
+}
+
+// limitDownloadWriteRate is limitDownloadRate for writers (HLS/DASH).
+func limitDownloadWriteRate(ctx context.Context, w io.Writer) io.Writer {
+	if run := scheduledRunFrom(ctx); run != nil && run.scheduler.rate != nil {
+		return &rateLimitedWriter{ctx: ctx, w: w, rate: run.scheduler.rate}
+	}
+	return w
+}
+
+// slotPool is a counting semaphore granting slots by priority, then to the
+// group holding the fewest, then first come first served.
+type slotPool struct {
+	limit int
+
+	mu      sync.Mutex
+	active  int
+	byGroup map[string]int
+	queue   []*slotWaiter
+	seq     uint64
+}
+
+type slotWaiter struct {
+	opts    ScheduleOptions
+	seq     uint64
+	granted chan struct{}
+}
+
+func newSlotPool(limit int) *slotPool {
+	if limit <= 0 {
+		return nil
+	}
+	return &slotPool{limit: limit, byGroup: make(map[string]int)}
+}
+
+func (p *slotPool) acquire(ctx context.Context, opts ScheduleOptions) (func(), error) {
+	if p == nil {
+		return func() {}, nil
+	}
+	p.mu.Lock()
+	w := &slotWaiter{opts: opts, seq: p.seq, granted: make(chan struct{})}
+	p.seq++
+	p.queue = append(p.queue, w)
+	p.dispatchLocked()
+	p.mu.Unlock()
+
+	select {
+	case <-w.granted:
+	case <-ctx.Done():
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		select {
+		case <-w.granted:
+			// Granted while giving up; hand the slot on.
+			p.releaseLocked(opts.Group)
+		default:
+			p.removeLocked(w)
+		}
+		return nil, ctx.Err()
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.releaseLocked(opts.Group)
+		})
+	}, nil
+}
+
+func (p *slotPool) dispatchLocked() {
+	for p.active < p.limit && len(p.queue) > 0 {
+		next := 0
+		for i := 1; i < len(p.queue); i++ {
+			if p.beforeLocked(p.queue[i], p.queue[next]) {
+				next = i
+			}
+		}
+		w := p.queue[next]
+		p.queue = append(p.queue[:next], p.queue[next+1:]...)
+		p.active++
+		p.byGroup[w.opts.Group]++
+		close(w.granted)
+	}
+}
+
+func (p *slotPool) beforeLocked(a, b *slotWaiter) bool {
+	if a.opts.Priority != b.opts.Priority {
+		return a.opts.Priority > b.opts.Priority
+	}
+	if ga, gb := p.byGroup[a.opts.Group], p.byGroup[b.opts.Group]; ga != gb {
+		return ga < gb
+	}
+	return a.seq < b.seq
+}
+
+func (p *slotPool) releaseLocked(group string) {
+	p.active--
+	if p.byGroup[group]--; p.byGroup[group] <= 0 {
+		delete(p.byGroup, group)
+	}
+	p.dispatchLocked()
+}
+
+func (p *slotPool) removeLocked(w *slotWaiter) {
+	for i, q := range p.queue {
+		if q == w {
+			p.queue = append(p.queue[:i], p.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *slotPool) counts() (active, queued int) {
+	if p == nil {
+		return 0, 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.active, len(p.queue)
+}
+
+// byteRateBurst is how much idle time a byteRate banks for later bursts.
+const byteRateBurst = time.Second
+
+// byteRate is a shared bandwidth limit. Each transfer reserves its bytes
+// on a virtual clock and sleeps until the clock catches up, so concurrent
+// streams split the rate between them.
+type byteRate struct {
+	perSecond float64
+
+	mu   sync.Mutex
+	next time.Time // when the bytes reserved so far are paid for
+}
+
+func (b *byteRate) wait(ctx context.Context, n int) error {
+	if n <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	now := time.Now()
+	if floor := now.Add(-byteRateBurst); b.next.Before(floor) {
+		b.next = floor
+	}
+	b.next = b.next.Add(time.Duration(float64(n) / b.perSecond * float64(time.Second)))
+	delay := b.next.Sub(now)
+	b.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	return waitBackoff(ctx, delay)
+}
+
+type rateLimitedReader struct {
+	ctx  context.Context
+	r    io.Reader
+	rate *byteRate
+}
+
+func (l *rateLimitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	if waitErr := l.rate.wait(l.ctx, n); waitErr != nil {
+		return n, waitErr
+	}
+	return n, err
+}
+
+type rateLimitedWriter struct {
+	ctx  context.Context
+	w    io.Writer
+	rate *byteRate
+}
+
+func (l *rateLimitedWriter) Write(p []byte) (int, error) {
+	if err := l.rate.wait(l.ctx, len(p)); err != nil {
+		return 0, err
+	}
+	return l.w.Write(p)
+}
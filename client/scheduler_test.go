@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// queueWaiter starts an acquire in the background and waits until it is
+// queued, so waiters enter the pool in a known order.
+func queueWaiter(t *testing.T, p *slotPool, opts ScheduleOptions, order chan<- string, name string) {
+	t.Helper()
+	_, before := p.counts()
+	go func() {
+		release, err := p.acquire(context.Background(), opts)
+		if err != nil {
+			order <- "error: " + err.Error()
+			return
+		}
+		order <- name
+		release()
+	}()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, queued := p.counts(); queued > before {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s never queued", name)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSlotPool_PriorityThenGroupFairness(t *testing.T) {
+	p := newSlotPool(1)
+	hold, err := p.acquire(context.Background(), ScheduleOptions{Group: "playlist"})
+	if err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+	order := make(chan string, 4)
+	queueWaiter(t, p, ScheduleOptions{Group: "playlist"}, order, "playlist-2")
+	queueWaiter(t, p, ScheduleOptions{Group: "playlist"}, order, "playlist-3")
+	queueWaiter(t, p, ScheduleOptions{Priority: PriorityLow, Group: "user"}, order, "user-low")
+	queueWaiter(t, p, ScheduleOptions{Priority: PriorityHigh, Group: "user"}, order, "user-high")
+
+	hold()
+	want := []string{"user-high", "playlist-2", "playlist-3", "user-low"}
+	for i, w := range want {
+		if got := <-order; got != w {
+			t.Fatalf("grant %d = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestSlotPool_FreeSlotGoesToLeastServedGroup(t *testing.T) {
+	p := newSlotPool(2)
+	a1, _ := p.acquire(context.Background(), ScheduleOptions{Group: "a"})
+	a2, _ := p.acquire(context.Background(), ScheduleOptions{Group: "a"})
+	defer a2()
+	order := make(chan string, 2)
+	queueWaiter(t, p, ScheduleOptions{Group: "a"}, order, "a-3")
+	queueWaiter(t, p, ScheduleOptions{Group: "b"}, order, "b-1")
+
+	// Group a still holds a slot, so b is served first despite queueing later.
+	a1()
+	if got := <-order; got != "b-1" {
+		t.Fatalf("first grant = %q, want b-1", got)
+	}
+	if got := <-order; got != "a-3" {
+		t.Fatalf("second grant = %q, want a-3", got)
+	}
+}
+
+func TestSlotPool_CancelLeavesQueue(t *testing.T) {
+	p := newSlotPool(1)
+	hold, _ := p.acquire(context.Background(), ScheduleOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := p.acquire(ctx, ScheduleOptions{})
+		errc <- err
+	}()
+	for {
+		if _, queued := p.counts(); queued == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("acquire() error = %v, want context.Canceled", err)
+	}
+	hold()
+	if active, queued := p.counts(); active != 0 || queued != 0 {
+		t.Fatalf("counts = %d active, %d queued, want an empty pool", active, queued)
+	}
+}
+
+func TestScheduler_DownloadHonorsBandwidth(t *testing.T) {
+	const payload = "media-payload-of-some-length"
+	var ranges []string
+	c := sinkTestClient(t, payload, false, &ranges)
+	// The burst allowance covers the first second's worth of bytes; the rest
+	// waits for the rate.
+	s := c.NewScheduler(SchedulerConfig{MaxExtractions: 1, MaxStreams: 1, MaxBytesPerSecond: int64(len(payload)) * 4})
+	s.rate.next = time.Now()
+
+	out := filepath.Join(t.TempDir(), "out.mp4")
+	start := time.Now()
+	res, err := s.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{OutputPath: out}, ScheduleOptions{Group: "test"})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Fatalf("Download() took %v, want the 250ms the rate allows", elapsed)
+	}
+	if data, _ := os.ReadFile(res.OutputPath); string(data) != payload {
+		t.Fatalf("output = %q, want %q", data, payload)
+	}
+	if st := s.Stats(); st != (SchedulerStats{}) {
+		t.Fatalf("Stats() = %+v after the download, want all slots free", st)
+	}
+}
+
+func TestScheduler_BandwidthCapIsNotASlowDownload(t *testing.T) {
+	const payload = "media-payload-of-some-length"
+	var ranges []string
+	c := sinkTestClient(t, payload, false, &ranges)
+	c.config.SlowDownloadAbort = SlowDownloadAbortConfig{MinBytesPerSecond: 1 << 20, Window: 40 * time.Millisecond}
+	s := c.NewScheduler(SchedulerConfig{MaxBytesPerSecond: int64(len(payload)) * 4})
+	s.rate.next = time.Now()
+
+	out := filepath.Join(t.TempDir(), "out.mp4")
+	res, err := s.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{OutputPath: out}, ScheduleOptions{})
+	if err != nil {
+		t.Fatalf("Download() error = %v, want the capped transfer to finish", err)
+	}
+	if data, _ := os.ReadFile(res.OutputPath); string(data) != payload {
+		t.Fatalf("output = %q, want %q", data, payload)
+	}
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/famomatic/ytv1/internal/innertube"
+)
+
+// SchemaDrift describes a player response field, status or renderer type that
+// this package does not recognize. Drift never fails extraction; it flags
+// upstream changes before they become hard failures.
+type SchemaDrift = innertube.SchemaDrift
+
+// SchemaDrift kinds.
+const (
+	SchemaDriftPlayabilityStatus = innertube.DriftPlayabilityStatus
+	SchemaDriftUnknownField      = innertube.DriftUnknownField
+	SchemaDriftRenderer          = innertube.DriftRenderer
+	SchemaDriftTypeMismatch      = innertube.DriftTypeMismatch
+)
+
+// schemaDriftReporter forwards each distinct drift (per client, kind, path and
+// value) once per Client, so a batch run does not repeat the same finding.
+type schemaDriftReporter struct {
+	handler func(SchemaDrift)
+	logger  Logger
+
+	mu   sync.Mutex
+	seen map[SchemaDrift]struct{}
+}
+
+func newSchemaDriftReporter(handler func(SchemaDrift), logger Logger) *schemaDriftReporter {
+	return &schemaDriftReporter{handler: handler, logger: logger, seen: make(map[SchemaDrift]struct{})}
+}
+
+func (r *schemaDriftReporter) report(d SchemaDrift) {
+	key := d
+	key.VideoID = ""
+	r.mu.Lock()
+	_, dup := r.seen[key]
+	r.seen[key] = struct{}{}
+	r.mu.Unlock()
+	if dup {
+		return
+	}
+	r.logger.Warnf("player response schema drift (%s): %s %s=%q on video %s", d.Client, d.Kind, d.Path, d.Value, d.VideoID)
+	if r.handler != nil {
+		r.handler(d)
+	}
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSchemaDrift_ReportedOncePerFinding(t *testing.T) {
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player") {
+				body := `{"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},"streamingData":{"sabrConfig":{},"formats":[{"itag":18,"url":"https://media.example/v.mp4","mimeType":"video/mp4"}]}}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			}
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+		}),
+	}
+	var got []SchemaDrift
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+		OnSchemaDrift:   func(d SchemaDrift) { got = append(got, d) },
+	})
+	for i := 0; i < 2; i++ {
+		c.dropSession("jNQXAC9IVRw")
+		if _, err := c.GetVideo(context.Background(), "jNQXAC9IVRw"); err != nil {
+			t.Fatalf("GetVideo() error = %v", err)
+		}
+	}
+	if len(got) != 1 {
+		t.Fatalf("drift reports = %+v, want exactly one", got)
+	}
+	d := got[0]
+	if d.Kind != SchemaDriftUnknownField || d.Path != "streamingData" || d.Value != "sabrConfig" || d.Client != "mweb" || d.VideoID != "jNQXAC9IVRw" {
+		t.Fatalf("drift = %+v", d)
+	}
+}
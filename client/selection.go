@@ -1,8 +1,11 @@
 package client
 
 import (
+	"context"
 	"mime"
 	"strings"
+
+	"github.com/famomatic/ytv1/internal/selector"
 )
 
 // SelectionMode controls how a downloadable format is chosen when itag is not forced.
@@ -17,6 +20,50 @@ const (
 	SelectionModeMP3          SelectionMode = "mp3"
 )
 
+// Selector evaluation traces returned by ExplainSelection; see
+// selector.Explain.
+type (
+	SelectorGroupTrace  = selector.GroupTrace
+	SelectorSpecTrace   = selector.SpecTrace
+	SelectorFilterTrace = selector.FilterTrace
+	SelectorRejection   = selector.Rejection
+)
+
+// ExplainSelection returns the selector expression Download would evaluate
+// for options and a trace of its fallback groups over the candidate formats
+// (after PO token and size/height filtering). The trace is returned alongside
+// a selection error such as "no formats matched selector", and is empty for
+// an explicit itag. Download may still swap in a decipher-free alternative
+// or, with CheckFormats, drop candidates that fail probing.
+func (c *Client) ExplainSelection(ctx context.Context, input string, options DownloadOptions) (string, []SelectorGroupTrace, error) {
+	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+
+	videoID, err := c.resolveVideoInput(ctx, input)
+	if err != nil {
+		return "", nil, err
+	}
+	_, candidates, _, err := c.selectDownloadFormats(ctx, videoID, options)
+	if err != nil {
+		session, ok := c.getSession(videoID)
+		if !ok || session.Info == nil {
+			return "", nil, err
+		}
+		candidates, _ = filterFormatsByPoTokenPolicy(session.Info.Formats, c.config)
+	}
+	expr, trace := explainDownloadSelector(candidates, options)
+	return expr, trace, err
+}
+
+func explainDownloadSelector(candidates []FormatInfo, options DownloadOptions) (string, []SelectorGroupTrace) {
+	expr := downloadSelectorString(options)
+	if expr == "" {
+		return "", nil
+	}
+	_, trace, _ := selector.Explain(candidates, expr)
+	return expr, trace
+}
+
 func normalizeSelectionMode(mode SelectionMode) SelectionMode {
 	switch SelectionMode(strings.ToLower(strings.TrimSpace(string(mode)))) {
 	case "", SelectionModeBest:
@@ -1,6 +1,10 @@
 package client
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/famomatic/ytv1/internal/formats"
+)
 
 func TestSelectDownloadFormat_ModeBest(t *testing.T) {
 	formats := []FormatInfo{
@@ -90,3 +94,28 @@ func TestSelectDownloadFormat_PrefersKnownProtocolOverUnknown(t *testing.T) {
 		t.Fatalf("mp4videoonly mode selected itag=%d, want 299", got.Itag)
 	}
 }
+
+func TestSelectDownloadFormat_AudioOnlyPicksLiveHLSRendition(t *testing.T) {
+	parsed, err := formats.ParseHLSManifest(`#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="234",NAME="Default",DEFAULT=YES,URI="https://manifest.googlevideo.com/itag/234/index.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=4000000,RESOLUTION=1920x1080,CODECS="avc1.640028,mp4a.40.2",AUDIO="234"
+https://manifest.googlevideo.com/itag/270/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=1500000,RESOLUTION=1280x720,CODECS="avc1.4D401F,mp4a.40.2",AUDIO="234"
+https://manifest.googlevideo.com/itag/232/index.m3u8
+`, "https://manifest.googlevideo.com/master.m3u8")
+	if err != nil {
+		t.Fatalf("ParseHLSManifest() error = %v", err)
+	}
+	infos := make([]FormatInfo, 0, len(parsed))
+	for _, f := range parsed {
+		infos = append(infos, toFormatInfo(f))
+	}
+
+	got, ok := selectDownloadFormat(infos, DownloadOptions{Mode: SelectionModeAudioOnly})
+	if !ok {
+		t.Fatal("selectDownloadFormat() not found")
+	}
+	if got.Itag != 234 || got.HasVideo {
+		t.Fatalf("audioonly mode selected itag=%d (video=%v), want the 234 audio rendition", got.Itag, got.HasVideo)
+	}
+}
@@ -1,7 +1,9 @@
 package client
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -86,3 +88,72 @@ func TestSessionCacheConcurrentAccess_NoPanic(t *testing.T) {
 		t.Fatalf("expected sessions to be populated")
 	}
 }
+
+func sizedSession(descriptionBytes int) videoSession {
+	return videoSession{Response: &innertube.PlayerResponse{
+		VideoDetails: innertube.VideoDetails{ShortDescription: strings.Repeat("x", descriptionBytes)},
+	}}
+}
+
+func TestSessionCacheMaxBytesEvictsLRU(t *testing.T) {
+	c := &Client{
+		config:   Config{SessionCacheMaxBytes: 25_000},
+		sessions: make(map[string]videoSession),
+	}
+
+	c.putSession("a", sizedSession(10_000))
+	time.Sleep(2 * time.Millisecond)
+	c.putSession("b", sizedSession(10_000))
+	if usage := c.SessionCacheUsage(); usage.Entries != 2 || usage.Bytes < 20_000 || usage.Evictions != 0 {
+		t.Fatalf("usage after two sessions = %+v", usage)
+	}
+	time.Sleep(2 * time.Millisecond)
+	c.putSession("c", sizedSession(10_000))
+
+	if _, ok := c.getSession("a"); ok {
+		t.Fatal("expected least-recently-used session a to be evicted")
+	}
+	usage := c.SessionCacheUsage()
+	if usage.Entries != 2 || usage.Bytes > 25_000 || usage.Evictions != 1 || usage.MaxBytes != 25_000 {
+		t.Fatalf("usage = %+v, want 2 entries within budget and one eviction", usage)
+	}
+
+	// A session larger than the whole budget is still kept on its own.
+	c.putSession("huge", sizedSession(100_000))
+	if _, ok := c.getSession("huge"); !ok {
+		t.Fatal("expected the newest session to be kept even over budget")
+	}
+	if usage := c.SessionCacheUsage(); usage.Entries != 1 || usage.Evictions != 3 {
+		t.Fatalf("usage = %+v, want only the oversized session", usage)
+	}
+}
+
+func TestDropSessionResponsesReleasesAndReextracts(t *testing.T) {
+	c := newMockClientForPlayerJSON(t, `{
+		"playabilityStatus":{"status":"OK"},
+		"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y","shortDescription":"`+strings.Repeat("d", 4096)+`"},
+		"streamingData":{"formats":[{"itag":18,"url":"https://media.example/v.mp4","mimeType":"video/mp4","bitrate":1000}]}
+	}`)
+	c.config.DropSessionResponses = true
+
+	streams, err := c.ResolveDownloadURLs(context.Background(), "jNQXAC9IVRw", DownloadOptions{Itag: 18})
+	if err != nil || len(streams) != 1 {
+		t.Fatalf("ResolveDownloadURLs() = %v, %v", streams, err)
+	}
+	session, ok := c.getSession("jNQXAC9IVRw")
+	if !ok || session.Response != nil || session.Info == nil {
+		t.Fatalf("session = %+v, want formats kept and response dropped", session)
+	}
+	usage := c.SessionCacheUsage()
+	// Info keeps its own copy of the description; the response's is gone.
+	if usage.ResponsesDropped != 1 || usage.Bytes >= 2*4096 {
+		t.Fatalf("usage = %+v, want the dropped response no longer counted", usage)
+	}
+
+	if _, err := c.ResolveStreamURL(context.Background(), "jNQXAC9IVRw", 18); err != nil {
+		t.Fatalf("ResolveStreamURL() after drop error = %v", err)
+	}
+	if session, _ := c.getSession("jNQXAC9IVRw"); session.Response == nil {
+		t.Fatal("expected ResolveStreamURL to re-extract the dropped response")
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import "encoding/json"
+
+// SessionCacheUsage is the occupancy of the client's in-memory video
+// session cache, which keeps each extracted video's player response and
+// formats for later URL resolution.
+type SessionCacheUsage struct {
+	Entries int
+	// Bytes approximates the memory the cached sessions retain.
+	Bytes int64
+	// MaxEntries and MaxBytes are Config.SessionCacheMaxEntries and
+	// Config.SessionCacheMaxBytes, 0 when unbounded.
+	MaxEntries int
+	MaxBytes   int64
+	// Evictions counts sessions evicted to stay within MaxEntries or
+	// MaxBytes; TTL expiry is not counted.
+	Evictions int64
+	// ResponsesDropped counts player responses released under
+	// Config.DropSessionResponses.
+	ResponsesDropped int64
+}
+
+// SessionCacheUsage reports the current session cache occupancy.
+func (c *Client) SessionCacheUsage() SessionCacheUsage {
+	c.sessionsMu.Lock()
+	defer c.sessionsMu.Unlock()
+	usage := SessionCacheUsage{
+		Entries:          len(c.sessions),
+		MaxEntries:       max(c.config.SessionCacheMaxEntries, 0),
+		MaxBytes:         max(c.config.SessionCacheMaxBytes, 0),
+		Evictions:        c.sessionEvictions,
+		ResponsesDropped: c.responsesDropped,
+	}
+	for _, s := range c.sessions {
+		usage.Bytes += s.Bytes
+	}
+	return usage
+}
+
+// approxSessionBytes estimates what a session retains by the JSON size of
+// its response and formats: strings (URLs, ciphers, descriptions) dominate
+// both, so the encoding tracks memory closely enough for a budget.
+func approxSessionBytes(s videoSession) int64 {
+	var n int64
+	for _, v := range []any{s.Response, s.Info, s.DuplicateFormats} {
+		if b, err := json.Marshal(v); err == nil {
+			n += int64(len(b))
+		}
+	}
+	return n
+}
+
+// releaseSessionResponse drops the video's player response once its URLs
+// are resolved, under Config.DropSessionResponses. The session keeps its
+// formats and player URL; resolving a ciphered format again re-extracts.
+func (c *Client) releaseSessionResponse(videoID string) {
+	if !c.config.DropSessionResponses {
+		return
+	}
+	videoID, err := normalizeVideoID(videoID)
+	if err != nil {
+		return
+	}
+	c.sessionsMu.Lock()
+	defer c.sessionsMu.Unlock()
+	s, ok := c.sessions[videoID]
+	if !ok || s.Response == nil {
+		return
+	}
+	s.Response = nil
+	s.Bytes = approxSessionBytes(s)
+	c.sessions[videoID] = s
+	c.responsesDropped++
+}
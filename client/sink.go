@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/sink"
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+// OutputSink stores finished downloads outside the local filesystem. See
+// DownloadOptions.Sink.
+type OutputSink interface {
+	// Upload stores everything read from r under key.
+	Upload(ctx context.Context, key string, r io.Reader) error
+	// URL returns the location of key, e.g. s3://bucket/key.
+	URL(key string) string
+}
+
+// defaultSinkKeyTemplate names outputs when DownloadOptions.OutputPath is
+// empty, matching the local default names.
+const defaultSinkKeyTemplate = "%(id)s-%(itag)s.%(ext)s"
+
+// IsRemoteOutput reports whether output names remote storage (s3://, gs://,
+// webdav:// or webdav+http://) rather than a local path.
+func IsRemoteOutput(output string) bool {
+	return sink.IsRemote(output)
+}
+
+// OpenOutputSink opens the remote storage named by an output template such
+// as s3://bucket/prefix/%(id)s.%(ext)s. It returns the sink and the
+// template below its root, to pass as DownloadOptions.Sink and OutputPath.
+//
+// Uploads go through the client's HTTP client. Credentials come from the
+// environment: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN,
+// AWS_REGION and AWS_ENDPOINT_URL_S3 for s3://; an HMAC key pair in
+// GCS_HMAC_ACCESS_KEY_ID and GCS_HMAC_SECRET for gs://; user:password@ in
+// the URL or WEBDAV_USERNAME and WEBDAV_PASSWORD for WebDAV.
+func (c *Client) OpenOutputSink(output string) (OutputSink, string, error) {
+	s, key, err := sink.Parse(output, c.httpClient(), os.Getenv)
+	if err != nil {
+		return nil, "", err
+	}
+	return s, key, nil
+}
+
+// downloadToSink implements DownloadOptions.Sink.
+func (c *Client) downloadToSink(ctx context.Context, videoID string, info *VideoInfo, formats, selected []types.FormatInfo, options DownloadOptions, meta types.Metadata) (*DownloadResult, error) {
+	if c.canStreamToSink(selected, options) {
+		res, err := c.streamToSink(ctx, videoID, selected[0], options, meta)
+		if res != nil {
+			res.ExpiresAt = earliestExpiry(res.SelectedFormats)
+			res.totalStreamTransfers()
+		}
+		return res, err
+	}
+	return c.stageToSink(ctx, videoID, info, formats, selected, options, meta)
+}
+
+// canStreamToSink reports whether the selection is one direct stream that
+// needs no local file: no manifest, merge, transcoding, external
+// downloader or output probe.
+func (c *Client) canStreamToSink(selected []types.FormatInfo, options DownloadOptions) bool {
+	if len(selected) != 1 || c.config.ExternalDownloader != nil {
+		return false
+	}
+	f := selected[0]
+	if f.Protocol == "hls" || f.Protocol == "dash" || strings.HasSuffix(f.URL, ".m3u8") || strings.HasSuffix(f.URL, ".mpd") {
+		return false
+	}
+	return options.Mode != SelectionModeMP3 && options.ExtractAudio == "" && !options.ValidateOutput
+}
+
+// streamToSink copies one direct stream into the sink without a local
+// file, hashing it on the way when a checksum is requested. A stream
+// interrupted mid-transfer is reopened at its offset with a Range request.
+func (c *Client) streamToSink(ctx context.Context, videoID string, f types.FormatInfo, options DownloadOptions, meta types.Metadata) (*DownloadResult, error) {
+	template := options.OutputPath
+	if template == "" {
+		template = defaultSinkKeyTemplate
+	}
+	key := singleOutputPath(videoID, meta.Title, meta.Artist, f, template, options.Mode)
+	location := options.Sink.URL(key)
+
+	streamURL, err := c.resolveSelectedFormatURL(ctx, videoID, f)
+	if err != nil {
+		return nil, err
+	}
+	releaseStream, err := acquireStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer releaseStream()
+	c.emitDownloadEvent("download", "destination", videoID, location, fmt.Sprintf("itag=%d", f.Itag))
+	c.emitDownloadEvent("download", "start", videoID, location, fmt.Sprintf("itag=%d", f.Itag))
+
+	ctx, stats := withDownloadStats(ctx)
+	src := &resumingReader{
+		ctx:     ctx,
+		client:  c.httpClient(),
+		url:     streamURL,
+		videoID: videoID,
+		headers: c.mediaHeaders(streamURL),
+		cfg:     normalizeDownloadTransportConfig(c.config.DownloadTransport),
+	}
+	defer src.drop()
+	r := io.TeeReader(countDownloadBytes(ctx, src), trackOutputSize(ctx, io.Discard, 0))
+	var digest hash.Hash
+	if options.WriteChecksum {
+		digest = sha256.New()
+		r = io.TeeReader(r, digest)
+	}
+	start := time.Now()
+	err = options.Sink.Upload(ctx, key, r)
+	stream := newDownloadStreamResult(f, streamURL, key, src.offset, int(stats.retries.Load()))
+	stream.setTransfer(src.offset, time.Since(start))
+	if err != nil {
+		attempt := downloadAttemptFromFormatAndURL(f, streamURL, err)
+		c.emitDownloadEvent("download", "failure", videoID, location, formatDownloadFailureDetail(attempt))
+		return nil, wrapDownloadFailure(err, attempt)
+	}
+	c.emitDownloadEvent("download", "complete", videoID, location, fmt.Sprintf("bytes=%d", src.offset))
+
+	res := &DownloadResult{
+		VideoID:         videoID,
+		Itag:            f.Itag,
+		OutputPath:      key,
+		Bytes:           src.offset,
+		SelectedFormats: []types.FormatInfo{f},
+		Streams:         []DownloadStreamResult{stream},
+	}
+	if digest != nil {
+		sum := hex.EncodeToString(digest.Sum(nil))
+		line := checksumLine(sum, path.Base(key))
+		if err := options.Sink.Upload(ctx, key+ChecksumSuffix, strings.NewReader(line)); err != nil {
+			return res, fmt.Errorf("upload checksum %s: %w", options.Sink.URL(key+ChecksumSuffix), err)
+		}
+		res.SHA256 = sum
+		c.emitDownloadEvent("checksum", "complete", videoID, location, "sha256="+sum)
+	}
+	return res, nil
+}
+
+// stageToSink builds the output in a temporary directory with the local
+// download path, uploads it and its checksum sidecar, then removes the
+// directory.
+func (c *Client) stageToSink(ctx context.Context, videoID string, info *VideoInfo, formats, selected []types.FormatInfo, options DownloadOptions, meta types.Metadata) (*DownloadResult, error) {
+	stage, err := os.MkdirTemp("", "ytv1-sink-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stage)
+
+	template := options.OutputPath
+	if template == "" {
+		template = defaultSinkKeyTemplate
+	}
+	local := options
+	local.Sink = nil
+	local.Resume = false
+	local.SetMtime = false
+	local.OutputPath = filepath.Join(stage, filepath.FromSlash(template))
+	res, err := c.downloadLocal(ctx, videoID, info, formats, selected, local, meta)
+	if err != nil {
+		return res, err
+	}
+
+	staged := res.OutputPath
+	rel, err := filepath.Rel(stage, staged)
+	if err != nil {
+		return res, err
+	}
+	key := filepath.ToSlash(rel)
+	if err := c.uploadStagedFile(ctx, options.Sink, videoID, staged, key); err != nil {
+		return res, err
+	}
+	if res.SHA256 != "" {
+		if err := c.uploadStagedFile(ctx, options.Sink, videoID, staged+ChecksumSuffix, key+ChecksumSuffix); err != nil {
+			return res, err
+		}
+	}
+	res.OutputPath = key
+	for i := range res.Streams {
+		if res.Streams[i].Path == staged {
+			res.Streams[i].Path = key
+		}
+	}
+	return res, nil
+}
+
+func (c *Client) uploadStagedFile(ctx context.Context, s OutputSink, videoID, localPath, key string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	location := s.URL(key)
+	c.emitDownloadEvent("upload", "start", videoID, location, "")
+	if err := s.Upload(ctx, key, f); err != nil {
+		c.emitDownloadEvent("upload", "failure", videoID, location, err.Error())
+		return fmt.Errorf("upload %s: %w", location, err)
+	}
+	c.emitDownloadEvent("upload", "complete", videoID, location, "")
+	return nil
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (m *memorySink) Upload(ctx context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = string(data)
+	return nil
+}
+
+func (m *memorySink) URL(key string) string { return "mem://" + key }
+
+// brokenBody returns data, then fails as a dropped connection does.
+type brokenBody struct{ r io.Reader }
+
+func (b *brokenBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if errors.Is(err, io.EOF) {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (b *brokenBody) Close() error { return nil }
+
+// sinkTestClient serves one progressive format. With drop set, the first
+// media connection breaks after five bytes.
+func sinkTestClient(t *testing.T, payload string, drop bool, ranges *[]string) *Client {
+	t.Helper()
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo","author":"jawed"},
+					"streamingData":{"formats":[
+						{"itag":18,"url":"https://media.example/v18.mp4","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.Host == "media.example":
+				rng := r.Header.Get("Range")
+				*ranges = append(*ranges, rng)
+				switch {
+				case rng == "" && !drop:
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(payload)), Header: make(http.Header)}, nil
+				case rng == "":
+					return &http.Response{StatusCode: http.StatusOK, Body: &brokenBody{r: strings.NewReader(payload[:5])}, Header: make(http.Header)}, nil
+				case rng == "bytes=5-":
+					return &http.Response{StatusCode: http.StatusPartialContent, Body: io.NopCloser(strings.NewReader(payload[5:])), Header: make(http.Header)}, nil
+				}
+			}
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+		}),
+	}
+	return New(Config{
+		HTTPClient:        httpClient,
+		ClientOverrides:   []string{"mweb"},
+		DownloadTransport: DownloadTransportConfig{MaxRetries: 1, InitialBackoff: time.Millisecond},
+	})
+}
+
+func TestDownload_StreamsToSink(t *testing.T) {
+	const payload = "media-payload"
+	var ranges []string
+	c := sinkTestClient(t, payload, true, &ranges)
+	s := &memorySink{objects: map[string]string{}}
+
+	res, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{
+		OutputPath:    "yt/%(uploader)s/%(title)s.%(ext)s",
+		Sink:          s,
+		WriteChecksum: true,
+	})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if res.OutputPath != "yt/jawed/Me at the zoo.mp4" || res.Bytes != int64(len(payload)) {
+		t.Fatalf("OutputPath = %q, Bytes = %d", res.OutputPath, res.Bytes)
+	}
+	if got := s.objects[res.OutputPath]; got != payload {
+		t.Fatalf("uploaded %q, want %q", got, payload)
+	}
+	if got, want := s.objects[res.OutputPath+ChecksumSuffix], sha256Hex(payload)+"  Me at the zoo.mp4\n"; got != want {
+		t.Fatalf("checksum sidecar = %q, want %q", got, want)
+	}
+	if strings.Join(ranges, ",") != ",bytes=5-" {
+		t.Fatalf("media requests = %q, want a resume from the dropped offset", ranges)
+	}
+	if res.Streams[0].Retries != 1 {
+		t.Fatalf("Retries = %d, want 1", res.Streams[0].Retries)
+	}
+	if _, err := os.Stat("yt"); !os.IsNotExist(err) {
+		t.Fatalf("streamed download created a local directory: %v", err)
+	}
+}
+
+func TestDownload_StagesPostProcessedOutputForSink(t *testing.T) {
+	const payload = "media-payload"
+	var ranges []string
+	c := sinkTestClient(t, payload, false, &ranges)
+	s := &memorySink{objects: map[string]string{}}
+
+	// ValidateOutput needs the local file, so the download is staged.
+	res, err := c.Download(context.Background(), "jNQXAC9IVRw", DownloadOptions{
+		OutputPath:     "%(id)s.%(ext)s",
+		Sink:           s,
+		ValidateOutput: true,
+	})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if res.OutputPath != "jNQXAC9IVRw.mp4" || s.objects["jNQXAC9IVRw.mp4"] != payload {
+		t.Fatalf("OutputPath = %q, objects = %v", res.OutputPath, s.objects)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/famomatic/ytv1/internal/formats"
 )
 
 // StreamOptions controls format selection for stream-first APIs.
@@ -13,11 +15,56 @@ type StreamOptions struct {
 	Mode SelectionMode
 }
 
+// ResolvedStream is one selected format with its fully resolved playback URL.
+type ResolvedStream struct {
+	// Format.ExpiresAt is when URL stops working.
+	Format FormatInfo
+	URL    string
+	// Headers are the request headers media hosts expect alongside URL.
+	Headers http.Header
+}
+
+// ResolveDownloadURLs applies Download format selection and returns the final
+// deciphered URL for each selected stream without transferring media.
+// Merge selections yield one entry per stream in selector order (video before audio).
+func (c *Client) ResolveDownloadURLs(ctx context.Context, input string, options DownloadOptions) ([]ResolvedStream, error) {
+	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+
+	videoID, err := c.resolveVideoInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	videoID, _, _, selected, err := c.selectDownloadTarget(ctx, videoID, options)
+	if err != nil {
+		return nil, err
+	}
+	defer c.releaseSessionResponse(videoID)
+
+	out := make([]ResolvedStream, 0, len(selected))
+	for _, f := range selected {
+		streamURL, err := c.resolveSelectedFormatURL(ctx, videoID, f)
+		if err != nil {
+			return nil, err
+		}
+		if expires := formats.URLExpiry(streamURL); !expires.IsZero() {
+			f.ExpiresAt = expires
+		}
+		out = append(out, ResolvedStream{
+			Format:  f,
+			URL:     streamURL,
+			Headers: buildMediaRequestHeaders(c.mediaHeaders(streamURL), videoID),
+		})
+	}
+	return out, nil
+}
+
 // OpenStream resolves and opens a readable stream without writing a local file.
 // Returned FormatInfo describes the selected stream format.
 func (c *Client) OpenStream(ctx context.Context, input string, options StreamOptions) (io.ReadCloser, FormatInfo, error) {
 	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
 	defer cancel()
+	ctx = c.withMediaRequestState(ctx)
 
 	videoID, err := normalizeVideoID(input)
 	if err != nil {
@@ -57,11 +104,12 @@ func (c *Client) OpenStream(ctx context.Context, input string, options StreamOpt
 	if err != nil {
 		return nil, FormatInfo{}, err
 	}
+	c.releaseSessionResponse(videoID)
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
 	if err != nil {
 		return nil, FormatInfo{}, err
 	}
-	applyMediaRequestHeaders(req, c.config.RequestHeaders, videoID)
+	applyMediaRequestHeaders(req, c.mediaHeaders(streamURL), videoID)
 	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return nil, FormatInfo{}, err
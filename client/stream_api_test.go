@@ -100,3 +100,54 @@ func TestOpenFormatStream_NoPlayableFormat(t *testing.T) {
 		t.Fatalf("OpenFormatStream() error = %v, want %v", err, ErrNoPlayableFormats)
 	}
 }
+
+func TestResolveDownloadURLs_MergeSelectionReturnsVideoThenAudio(t *testing.T) {
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body: io.NopCloser(bytes.NewBufferString(`{
+						"playabilityStatus":{"status":"OK"},
+						"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo","author":"jawed"},
+						"streamingData":{"adaptiveFormats":[
+							{"itag":248,"url":"https://stream.local/v248.webm","mimeType":"video/webm","bitrate":1000,"width":1920,"height":1080},
+							{"itag":251,"url":"https://stream.local/a251.webm","mimeType":"audio/webm","bitrate":160}
+						]}
+					}`)),
+				}, nil
+			case r.Method == http.MethodGet && r.URL.Path == "/watch":
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(bytes.NewBufferString(`<html><script src="/s/player/test/base.js"></script></html>`)),
+				}, nil
+			default:
+				t.Fatalf("unexpected request: %s %s", r.Method, r.URL.String())
+				return nil, nil
+			}
+		}),
+	}
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+	})
+
+	streams, err := c.ResolveDownloadURLs(context.Background(), "jNQXAC9IVRw", DownloadOptions{
+		FormatSelector: "bestvideo+bestaudio",
+	})
+	if err != nil {
+		t.Fatalf("ResolveDownloadURLs() error = %v", err)
+	}
+	if len(streams) != 2 {
+		t.Fatalf("streams len = %d, want 2", len(streams))
+	}
+	if streams[0].URL != "https://stream.local/v248.webm" || streams[1].URL != "https://stream.local/a251.webm" {
+		t.Fatalf("unexpected urls: %q %q", streams[0].URL, streams[1].URL)
+	}
+	if got := streams[0].Headers.Get("Referer"); got != "https://www.youtube.com/watch?v=jNQXAC9IVRw" {
+		t.Fatalf("Referer = %q", got)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -33,11 +34,24 @@ func ResolveSubtitleOutputFormat(raw string) SubtitleOutputFormat {
 	return SubtitleOutputFormatSRT
 }
 
+// TranscriptWriteOptions tunes WriteTranscriptWithOptions.
+type TranscriptWriteOptions struct {
+	// NoStyling writes VTT cues as plain text, dropping srv3 positioning,
+	// bold/italic/underline pens and per-word karaoke timestamps.
+	NoStyling bool
+}
+
 // WriteTranscript serializes transcript entries to the selected subtitle format.
+// VTT output keeps srv3 positioning and styling; SRT output is plain text.
 func WriteTranscript(path string, transcript *Transcript, format SubtitleOutputFormat) error {
+	return WriteTranscriptWithOptions(path, transcript, format, TranscriptWriteOptions{})
+}
+
+// WriteTranscriptWithOptions is WriteTranscript with output tuning.
+func WriteTranscriptWithOptions(path string, transcript *Transcript, format SubtitleOutputFormat, opts TranscriptWriteOptions) error {
 	switch format {
 	case SubtitleOutputFormatVTT:
-		return writeTranscriptAsVTT(path, transcript)
+		return writeTranscriptAsVTT(path, transcript, !opts.NoStyling)
 	default:
 		return writeTranscriptAsSRT(path, transcript)
 	}
@@ -65,7 +79,7 @@ func writeTranscriptAsSRT(path string, transcript *Transcript) error {
 	return nil
 }
 
-func writeTranscriptAsVTT(path string, transcript *Transcript) error {
+func writeTranscriptAsVTT(path string, transcript *Transcript, styled bool) error {
 	if dir := filepath.Dir(path); dir != "." && dir != "" {
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return err
@@ -86,13 +100,69 @@ func writeTranscriptAsVTT(path string, transcript *Transcript) error {
 	for _, entry := range transcript.Entries {
 		start := formatVTTTimestamp(entry.StartSec)
 		end := formatVTTTimestamp(entry.StartSec + entry.DurSec)
-		if _, err := fmt.Fprintf(f, "%s --> %s\n%s\n\n", start, end, strings.TrimSpace(entry.Text)); err != nil {
+		text := strings.TrimSpace(entry.Text)
+		settings := ""
+		if styled {
+			text = vttStyledCueText(entry)
+			settings = vttCueSettings(entry.Position)
+		}
+		if _, err := fmt.Fprintf(f, "%s --> %s%s\n%s\n\n", start, end, settings, text); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// vttCueSettings maps an srv3 window position onto VTT line/position/align
+// settings. The anchor grid row picks the line alignment and its column the
+// position alignment.
+func vttCueSettings(pos *CuePosition) string {
+	if pos == nil {
+		return ""
+	}
+	row, col := pos.AnchorPoint/3, pos.AnchorPoint%3
+	lineAlign := [...]string{"start", "center", "end"}[row]
+	positionAlign := [...]string{"line-left", "center", "line-right"}[col]
+	textAlign := [...]string{"left", "center", "right"}[col]
+	return fmt.Sprintf(" line:%s%%,%s position:%s%%,%s align:%s",
+		formatCuePercent(pos.VerticalPct), lineAlign,
+		formatCuePercent(pos.HorizontalPct), positionAlign, textAlign)
+}
+
+func formatCuePercent(v float64) string {
+	return strconv.FormatFloat(math.Max(0, math.Min(100, v)), 'f', -1, 64)
+}
+
+// vttStyledCueText renders srv3 segments with <b>/<i>/<u> tags and karaoke
+// timestamp tags, escaping markup characters in the text. Timestamps outside
+// the cue are dropped because players reject them.
+func vttStyledCueText(entry TranscriptEntry) string {
+	if len(entry.Segments) == 0 {
+		return vttEscaper.Replace(strings.TrimSpace(entry.Text))
+	}
+	end := entry.StartSec + entry.DurSec
+	var b strings.Builder
+	for _, seg := range entry.Segments {
+		if at := entry.StartSec + seg.OffsetSec; seg.OffsetSec > 0 && (entry.DurSec <= 0 || at < end) {
+			b.WriteString("<" + formatVTTTimestamp(at) + ">")
+		}
+		text := vttEscaper.Replace(seg.Text)
+		if seg.Underline {
+			text = "<u>" + text + "</u>"
+		}
+		if seg.Italic {
+			text = "<i>" + text + "</i>"
+		}
+		if seg.Bold {
+			text = "<b>" + text + "</b>"
+		}
+		b.WriteString(text)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
 func formatSRTTimestamp(sec float64) string {
 	if sec < 0 {
 		sec = 0
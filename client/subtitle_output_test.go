@@ -70,3 +70,76 @@ func TestWriteTranscript_VTT(t *testing.T) {
 		t.Fatalf("unexpected vtt output: %q", txt)
 	}
 }
+
+func TestParseTranscriptXML_SRV3(t *testing.T) {
+	raw := []byte(`<?xml version="1.0" encoding="utf-8" ?><timedtext format="3">
+<head>
+<pen id="1" b="1"/>
+<wp id="0" ap="7" ah="50" av="100"/>
+<wp id="1" ap="0" ah="10" av="5"/>
+</head>
+<body>
+<p t="1000" d="2000" wp="1"><s>hello</s><s t="500" p="1"> world</s></p>
+<p t="3000" d="10" a="1">
+</p>
+<p t="4000" d="1500" wp="0">plain &amp;amp; simple</p>
+</body>
+</timedtext>`)
+	entries, err := parseTranscriptXML(raw)
+	if err != nil {
+		t.Fatalf("parseTranscriptXML() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %+v, want 2", entries)
+	}
+	first := entries[0]
+	if first.StartSec != 1 || first.DurSec != 2 || first.Text != "hello world" {
+		t.Fatalf("first entry = %+v", first)
+	}
+	if first.Position == nil || *first.Position != (CuePosition{AnchorPoint: 0, HorizontalPct: 10, VerticalPct: 5}) {
+		t.Fatalf("first position = %+v", first.Position)
+	}
+	if len(first.Segments) != 2 || first.Segments[1].OffsetSec != 0.5 || !first.Segments[1].Bold {
+		t.Fatalf("first segments = %+v", first.Segments)
+	}
+	second := entries[1]
+	if second.Text != "plain & simple" || second.Position != nil || second.Segments != nil {
+		t.Fatalf("second entry = %+v", second)
+	}
+}
+
+func TestWriteTranscript_VTTStyling(t *testing.T) {
+	transcript := &Transcript{
+		Entries: []TranscriptEntry{
+			{
+				StartSec: 1, DurSec: 2, Text: "hello world",
+				Position: &CuePosition{AnchorPoint: 0, HorizontalPct: 10, VerticalPct: 5},
+				Segments: []TranscriptSegment{{Text: "hello"}, {OffsetSec: 0.5, Text: " world", Bold: true}},
+			},
+			{StartSec: 4, DurSec: 1, Text: "a < b"},
+		},
+	}
+	dir := t.TempDir()
+	styledPath := filepath.Join(dir, "styled.vtt")
+	if err := WriteTranscript(styledPath, transcript, SubtitleOutputFormatVTT); err != nil {
+		t.Fatalf("WriteTranscript(VTT) error = %v", err)
+	}
+	raw, _ := os.ReadFile(styledPath)
+	txt := string(raw)
+	want := "00:00:01.000 --> 00:00:03.000 line:5%,start position:10%,line-left align:left\nhello<00:00:01.500><b> world</b>\n"
+	if !strings.Contains(txt, want) {
+		t.Fatalf("styled vtt output = %q, want it to contain %q", txt, want)
+	}
+	if !strings.Contains(txt, "\na &lt; b\n") {
+		t.Fatalf("styled vtt output should escape cue text: %q", txt)
+	}
+
+	plainPath := filepath.Join(dir, "plain.vtt")
+	if err := WriteTranscriptWithOptions(plainPath, transcript, SubtitleOutputFormatVTT, TranscriptWriteOptions{NoStyling: true}); err != nil {
+		t.Fatalf("WriteTranscriptWithOptions(NoStyling) error = %v", err)
+	}
+	raw, _ = os.ReadFile(plainPath)
+	if !strings.Contains(string(raw), "00:00:01.000 --> 00:00:03.000\nhello world\n") {
+		t.Fatalf("plain vtt output = %q", raw)
+	}
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// srv3 timedtext ("format 3") elements. Times are integer milliseconds.
+type srv3Pen struct {
+	ID        string `xml:"id,attr"`
+	Bold      string `xml:"b,attr"`
+	Italic    string `xml:"i,attr"`
+	Underline string `xml:"u,attr"`
+}
+
+// srv3Window is a <wp> window position: anchor point plus its location.
+type srv3Window struct {
+	ID string `xml:"id,attr"`
+	AP string `xml:"ap,attr"`
+	AH string `xml:"ah,attr"`
+	AV string `xml:"av,attr"`
+}
+
+type srv3Span struct {
+	T    string `xml:"t,attr"`
+	Pen  string `xml:"p,attr"`
+	Text string `xml:",chardata"`
+}
+
+type srv3Paragraph struct {
+	T      string     `xml:"t,attr"`
+	D      string     `xml:"d,attr"`
+	Pen    string     `xml:"p,attr"`
+	Window string     `xml:"wp,attr"`
+	Spans  []srv3Span `xml:"s"`
+	Text   string     `xml:",chardata"`
+}
+
+// YouTube's default window: anchor bottom-center at 50% across, 100% down.
+var defaultCuePosition = CuePosition{AnchorPoint: 7, HorizontalPct: 50, VerticalPct: 100}
+
+func parseSRV3Paragraphs(paras []srv3Paragraph, pens []srv3Pen, windows []srv3Window) []TranscriptEntry {
+	penByID := make(map[string]srv3Pen, len(pens))
+	for _, p := range pens {
+		penByID[p.ID] = p
+	}
+	posByID := make(map[string]*CuePosition, len(windows))
+	for _, w := range windows {
+		posByID[w.ID] = srv3CuePosition(w)
+	}
+
+	out := make([]TranscriptEntry, 0, len(paras))
+	for _, p := range paras {
+		startMS, err := strconv.Atoi(strings.TrimSpace(p.T))
+		if err != nil {
+			continue
+		}
+		durMS, _ := strconv.Atoi(strings.TrimSpace(p.D))
+		entry := TranscriptEntry{
+			StartSec: float64(startMS) / 1000,
+			DurSec:   float64(durMS) / 1000,
+			Position: posByID[p.Window],
+		}
+
+		spans := p.Spans
+		if len(spans) == 0 {
+			spans = []srv3Span{{Text: p.Text}}
+		}
+		styled := false
+		var text strings.Builder
+		for _, s := range spans {
+			pen, ok := penByID[s.Pen]
+			if !ok {
+				pen = penByID[p.Pen]
+			}
+			offsetMS, _ := strconv.Atoi(strings.TrimSpace(s.T))
+			seg := TranscriptSegment{
+				OffsetSec: float64(offsetMS) / 1000,
+				Text:      html.UnescapeString(strings.ReplaceAll(s.Text, "\n", " ")),
+				Bold:      pen.Bold == "1",
+				Italic:    pen.Italic == "1",
+				Underline: pen.Underline == "1",
+			}
+			if seg.OffsetSec > 0 || seg.Bold || seg.Italic || seg.Underline {
+				styled = true
+			}
+			text.WriteString(seg.Text)
+			entry.Segments = append(entry.Segments, seg)
+		}
+		entry.Text = strings.TrimSpace(text.String())
+		if entry.Text == "" {
+			// Auto captions interleave empty "append" paragraphs.
+			continue
+		}
+		if !styled {
+			entry.Segments = nil
+		}
+		out = append(out, entry)
+	}
+	return out
+}
+
+// srv3CuePosition returns nil for the default placement so writers can
+// leave ordinary cues without settings.
+func srv3CuePosition(w srv3Window) *CuePosition {
+	pos := defaultCuePosition
+	if v, err := strconv.Atoi(strings.TrimSpace(w.AP)); err == nil && v >= 0 && v <= 8 {
+		pos.AnchorPoint = v
+	}
+	if v, err := strconv.ParseFloat(strings.TrimSpace(w.AH), 64); err == nil {
+		pos.HorizontalPct = v
+	}
+	if v, err := strconv.ParseFloat(strings.TrimSpace(w.AV), 64); err == nil {
+		pos.VerticalPct = v
+	}
+	if pos == defaultCuePosition {
+		return nil
+	}
+	return &pos
+}
@@ -0,0 +1,264 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+// throttledError is the cancel cause used when a transfer shows the
+// throttled-URL signature.
+type throttledError struct {
+	BytesPerSecond int64
+}
+
+func (e *throttledError) Error() string {
+	return fmt.Sprintf("download throttled: %d B/s", e.BytesPerSecond)
+}
+
+type effectiveThrottleDetectionConfig struct {
+	Disable           bool
+	MinBytesPerSecond int64
+	Window            time.Duration
+	MinContentLength  int64
+	MaxRefreshes      int
+}
+
+func normalizeThrottleDetectionConfig(cfg ThrottleDetectionConfig) effectiveThrottleDetectionConfig {
+	out := effectiveThrottleDetectionConfig{
+		Disable:           cfg.Disable,
+		MinBytesPerSecond: cfg.MinBytesPerSecond,
+		Window:            cfg.Window,
+		MinContentLength:  cfg.MinContentLength,
+		MaxRefreshes:      cfg.MaxRefreshes,
+	}
+	if out.MinBytesPerSecond <= 0 {
+		out.MinBytesPerSecond = 100 << 10 // 100 KiB/s
+	}
+	if out.Window <= 0 {
+		out.Window = 15 * time.Second
+	}
+	if out.MinContentLength <= 0 {
+		out.MinContentLength = 10 << 20 // 10 MiB
+	}
+	if out.MaxRefreshes <= 0 {
+		out.MaxRefreshes = 1
+	}
+	return out
+}
+
+// fetchStreamWithThrottleRefresh downloads one stream while watching for the
+// throttled-URL signature. When it is seen, the format URL is re-extracted
+// with a fresh n solve and the transfer resumes from the partial file when
+// the new URL serves the same bytes (same itag and clen), or restarts.
+func (c *Client) fetchStreamWithThrottleRefresh(
+	ctx context.Context,
+	videoID string,
+	streamURL string,
+	outputPath string,
+	f types.FormatInfo,
+	resume bool,
+) (DownloadStreamResult, error) {
+	cfg := normalizeThrottleDetectionConfig(c.config.ThrottleDetection)
+	// Refreshing means re-extracting from YouTube, and restarts the
+	// transfer, which a pipe cannot take.
+	cfg.Disable = cfg.Disable || c.isExtractedVideo(videoID) || isPipeOutput(outputPath)
+	slow := c.config.SlowDownloadAbort
+	if slow.Window <= 0 {
+		slow.Window = 30 * time.Second
+	}
+	// Under a scheduler bandwidth cap the measured speed is the cap's, not
+	// the URL's.
+	if downloadRateLimited(ctx) {
+		cfg.Disable = true
+		slow.MinBytesPerSecond = 0
+	}
+	var prior DownloadStreamResult
+	for refresh := 0; ; refresh++ {
+		canRefresh := !cfg.Disable && refresh < cfg.MaxRefreshes
+		monitor := canRefresh && throttleDetectionApplies(f, streamURL, cfg)
+		stream, err := c.fetchStreamOnce(ctx, videoID, streamURL, outputPath, f, resume, monitor, cfg, slow)
+		stream.addPriorAttempt(prior)
+		var throttled *throttledError
+		var tooSlow *SlowDownloadError
+		switch {
+		case errors.As(err, &throttled):
+			c.warnf("download throttled: itag=%d speed=%dB/s; refreshing stream url", f.Itag, throttled.BytesPerSecond)
+			c.emitDownloadEvent("download", "throttled", videoID, outputPath, fmt.Sprintf("itag=%d bytes_per_sec=%d host=%s", f.Itag, throttled.BytesPerSecond, stream.URLHost))
+		case errors.As(err, &tooSlow):
+			c.emitDownloadEvent("download", "slow", videoID, outputPath, fmt.Sprintf("itag=%d bytes_per_sec=%d host=%s", f.Itag, tooSlow.BytesPerSecond, stream.URLHost))
+			if !canRefresh || f.Protocol == "hls" || f.Protocol == "dash" {
+				return stream, err
+			}
+			c.warnf("download too slow: itag=%d speed=%dB/s; refreshing stream url", f.Itag, tooSlow.BytesPerSecond)
+		default:
+			return stream, err
+		}
+		prior = stream
+
+		alt, altURL, refreshErr := c.refreshThrottledFormatURL(ctx, videoID, f)
+		if refreshErr != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return stream, ctxErr
+			}
+			c.warnf("throttled stream url refresh failed: itag=%d reason=%v", f.Itag, refreshErr)
+			resume = false
+		} else {
+			resume = c.canResumeRefreshedStream(f, streamURL, alt, altURL)
+			f, streamURL = alt, altURL
+		}
+	}
+}
+
+// canResumeRefreshedStream reports whether the partial file of a stream
+// fetched from oldURL can be continued from newURL with a Range request:
+// both must name the same itag and known size, and the partial must have
+// been written in order (chunked transfers preallocate the whole file).
+func (c *Client) canResumeRefreshedStream(old types.FormatInfo, oldURL string, alt types.FormatInfo, newURL string) bool {
+	if c.config.DownloadTransport.EnableChunked || c.config.DownloadTransport.MultiSource {
+		return false
+	}
+	size := knownContentLength(old, oldURL)
+	return size > 0 && old.Itag == alt.Itag && knownContentLength(alt, newURL) == size
+}
+
+func (c *Client) fetchStreamOnce(
+	ctx context.Context,
+	videoID string,
+	streamURL string,
+	outputPath string,
+	f types.FormatInfo,
+	resume bool,
+	monitor bool,
+	cfg effectiveThrottleDetectionConfig,
+	slow SlowDownloadAbortConfig,
+) (DownloadStreamResult, error) {
+	ctx, stats := withDownloadStats(ctx)
+	started := time.Now()
+	watched := monitor || slow.MinBytesPerSecond > 0
+	if watched {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		if monitor {
+			stop := watchSpeed(ctx, stats, cfg.MinBytesPerSecond, cfg.Window, func(rate int64) {
+				cancel(&throttledError{BytesPerSecond: rate})
+			})
+			defer stop()
+		}
+		if slow.MinBytesPerSecond > 0 {
+			stop := watchSpeed(ctx, stats, slow.MinBytesPerSecond, slow.Window, func(rate int64) {
+				cancel(&SlowDownloadError{BytesPerSecond: rate, MinBytesPerSecond: slow.MinBytesPerSecond, Window: slow.Window})
+			})
+			defer stop()
+		}
+	}
+	err := c.downloadStream(ctx, videoID, streamURL, outputPath, f, resume)
+	if err != nil && watched {
+		var throttled *throttledError
+		var tooSlow *SlowDownloadError
+		if cause := context.Cause(ctx); errors.As(cause, &throttled) || errors.As(cause, &tooSlow) {
+			err = cause
+		}
+	}
+	if errors.Is(err, ErrMaxFileSizeExceeded) {
+		// A truncated file would otherwise be resumed on the next run.
+		_ = os.Remove(outputPath)
+	}
+	stream := newDownloadStreamResult(f, streamURL, outputPath, getFileSize(outputPath), int(stats.retries.Load()))
+	stream.Discontinuities = stats.discontinuityList()
+	stream.setTransfer(stats.bytes.Load(), time.Since(started))
+	return stream, err
+}
+
+// watchSpeed samples transferred bytes over tumbling windows and calls
+// abort with the measured rate when a full window stays below minRate.
+func watchSpeed(ctx context.Context, stats *downloadStats, minRate int64, window time.Duration, abort func(rate int64)) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(window / 4)
+		defer ticker.Stop()
+		windowStart := time.Now()
+		windowBytes := stats.bytes.Load()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				elapsed := now.Sub(windowStart)
+				if elapsed < window {
+					continue
+				}
+				bytes := stats.bytes.Load()
+				rate := int64(float64(bytes-windowBytes) / elapsed.Seconds())
+				if rate < minRate {
+					abort(rate)
+					return
+				}
+				windowStart, windowBytes = now, bytes
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// throttleDetectionApplies limits detection to direct URLs of streams known
+// to be large (throttling only shows up on long transfers). Streams of
+// unknown size are left alone: a refresh could not resume them.
+func throttleDetectionApplies(f types.FormatInfo, streamURL string, cfg effectiveThrottleDetectionConfig) bool {
+	if f.Protocol == "hls" || f.Protocol == "dash" {
+		return false
+	}
+	return knownContentLength(f, streamURL) >= cfg.MinContentLength
+}
+
+// knownContentLength returns the stream size from format metadata or the
+// URL's clen parameter, or 0 when unknown.
+func knownContentLength(f types.FormatInfo, streamURL string) int64 {
+	if f.ContentLength > 0 {
+		return f.ContentLength
+	}
+	if u, err := url.Parse(streamURL); err == nil {
+		size, _ := strconv.ParseInt(u.Query().Get("clen"), 10, 64)
+		return size
+	}
+	return 0
+}
+
+// refreshThrottledFormatURL drops the cached session and n solution for the
+// format, re-extracts the video, and resolves a fresh URL for the same stream.
+func (c *Client) refreshThrottledFormatURL(ctx context.Context, videoID string, f types.FormatInfo) (types.FormatInfo, string, error) {
+	if session, ok := c.getSession(videoID); ok {
+		if u, err := url.Parse(f.URL); err == nil {
+			if n := strings.TrimSpace(u.Query().Get("n")); n != "" {
+				c.forgetChallengeN(session.PlayerURL, n)
+			}
+		}
+	}
+	c.dropSession(videoID)
+
+	info, err := c.GetVideo(ctx, videoID)
+	if err != nil {
+		return types.FormatInfo{}, "", err
+	}
+	for _, alt := range info.Formats {
+		if alt.Itag != f.Itag || alt.Language != f.Language || alt.Protocol != f.Protocol {
+			continue
+		}
+		altURL, err := c.resolveSelectedFormatURL(ctx, videoID, alt)
+		if err != nil {
+			return types.FormatInfo{}, "", err
+		}
+		return alt, altURL, nil
+	}
+	return types.FormatInfo{}, "", fmt.Errorf("itag %d missing after re-extraction", f.Itag)
+}
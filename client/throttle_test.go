@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stallingBody yields one byte and then blocks until the request is canceled,
+// mimicking a throttled googlevideo URL.
+type stallingBody struct {
+	ctx  context.Context
+	sent bool
+}
+
+func (b *stallingBody) Read(p []byte) (int, error) {
+	if !b.sent {
+		b.sent = true
+		p[0] = 's'
+		return 1, nil
+	}
+	<-b.ctx.Done()
+	return 0, b.ctx.Err()
+}
+
+func (b *stallingBody) Close() error { return nil }
+
+func TestDownload_ThrottledURLIsRefreshed(t *testing.T) {
+	videoID := "jNQXAC9IVRw"
+	mediaBase := "https://media.example"
+	var playerCalls atomic.Int32
+	var resumeRange atomic.Value
+	var events []DownloadEvent
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				path := "/fast.mp4"
+				if playerCalls.Add(1) == 1 {
+					path = "/slow.mp4"
+				}
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+					"streamingData":{"formats":[
+						{"itag":18,"url":"` + mediaBase + path + `","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500,"contentLength":"52428800"}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.Path == "/watch":
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`<html><script src="/s/player/test/base.js"></script></html>`)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/slow.mp4":
+				return &http.Response{StatusCode: http.StatusOK, Body: &stallingBody{ctx: r.Context()}, Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.String() == mediaBase+"/fast.mp4":
+				if rng := r.Header.Get("Range"); rng != "" {
+					resumeRange.Store(rng)
+					return &http.Response{StatusCode: http.StatusPartialContent, Body: io.NopCloser(strings.NewReader("ayload")), Header: make(http.Header)}, nil
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("payload")), Header: make(http.Header)}, nil
+			default:
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+			}
+		}),
+	}
+
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+		ThrottleDetection: ThrottleDetectionConfig{
+			MinBytesPerSecond: 1 << 20,
+			Window:            40 * time.Millisecond,
+		},
+		OnDownloadEvent: func(evt DownloadEvent) { events = append(events, evt) },
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	out := filepath.Join(t.TempDir(), "out.mp4")
+	res, err := c.Download(ctx, videoID, DownloadOptions{Itag: 18, OutputPath: out})
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	data, err := os.ReadFile(out)
+	// The stalled transfer wrote "s"; the refreshed URL supplies the rest.
+	if err != nil || string(data) != "sayload" {
+		t.Fatalf("output=%q err=%v", data, err)
+	}
+	if got, _ := resumeRange.Load().(string); got != "bytes=1-" {
+		t.Fatalf("refreshed url range = %q, want the partial file resumed", got)
+	}
+	if got := playerCalls.Load(); got != 2 {
+		t.Fatalf("player calls=%d, want 2 (initial + refresh)", got)
+	}
+	if res.Bytes != int64(len("payload")) {
+		t.Fatalf("bytes=%d", res.Bytes)
+	}
+	var sawThrottled bool
+	for _, evt := range events {
+		if evt.Stage == "download" && evt.Phase == "throttled" {
+			sawThrottled = true
+		}
+	}
+	if !sawThrottled {
+		t.Fatalf("expected throttled event, got=%v", events)
+	}
+}
+
+func TestThrottleDetectionApplies_SkipsSmallAndManifestStreams(t *testing.T) {
+	cfg := normalizeThrottleDetectionConfig(ThrottleDetectionConfig{})
+	if throttleDetectionApplies(FormatInfo{Protocol: "https", ContentLength: 1 << 20}, "https://x/v", cfg) {
+		t.Fatal("expected small stream to skip detection")
+	}
+	if throttleDetectionApplies(FormatInfo{Protocol: "https"}, "https://x/v?clen=1024", cfg) {
+		t.Fatal("expected clen-sized small stream to skip detection")
+	}
+	if throttleDetectionApplies(FormatInfo{Protocol: "dash", ContentLength: 1 << 30}, "https://x/m.mpd", cfg) {
+		t.Fatal("expected manifest stream to skip detection")
+	}
+	if throttleDetectionApplies(FormatInfo{Protocol: "https"}, "https://x/v", cfg) {
+		t.Fatal("expected stream of unknown size to skip detection")
+	}
+	if !throttleDetectionApplies(FormatInfo{Protocol: "https", ContentLength: 1 << 30}, "https://x/v", cfg) {
+		t.Fatal("expected large direct stream to use detection")
+	}
+}
+
+func TestDownload_SlowDownloadAbort(t *testing.T) {
+	httpClient := &http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			switch {
+			case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+				body := `{
+					"playabilityStatus":{"status":"OK"},
+					"videoDetails":{"videoId":"jNQXAC9IVRw","title":"x","author":"y"},
+					"streamingData":{"formats":[
+						{"itag":18,"url":"https://media.example/slow.mp4","mimeType":"video/mp4; codecs=\"avc1, mp4a\"","width":640,"height":360,"bitrate":500}
+					]}
+				}`
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+			case r.Method == http.MethodGet && r.URL.Path == "/slow.mp4":
+				return &http.Response{StatusCode: http.StatusOK, Body: &stallingBody{ctx: r.Context()}, Header: make(http.Header)}, nil
+			default:
+				return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("not found")), Header: make(http.Header)}, nil
+			}
+		}),
+	}
+
+	var sawSlow bool
+	c := New(Config{
+		HTTPClient:        httpClient,
+		ClientOverrides:   []string{"mweb"},
+		ThrottleDetection: ThrottleDetectionConfig{Disable: true},
+		SlowDownloadAbort: SlowDownloadAbortConfig{
+			MinBytesPerSecond: 1 << 20,
+			Window:            40 * time.Millisecond,
+		},
+		OnDownloadEvent: func(evt DownloadEvent) {
+			if evt.Stage == "download" && evt.Phase == "slow" {
+				sawSlow = true
+			}
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := c.Download(ctx, "jNQXAC9IVRw", DownloadOptions{Itag: 18, OutputPath: filepath.Join(t.TempDir(), "out.mp4")})
+	if !errors.Is(err, ErrDownloadTooSlow) {
+		t.Fatalf("Download() error = %v, want ErrDownloadTooSlow", err)
+	}
+	if ClassifyError(err) != ErrorCategoryDownloadTooSlow {
+		t.Fatalf("ClassifyError() = %q", ClassifyError(err))
+	}
+	if !sawSlow {
+		t.Fatal("expected slow event")
+	}
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// thumbnailLadder is the i.ytimg.com thumbnail filename ladder, best first.
+// maxresdefault and sddefault exist only for some uploads, so a fixed
+// maxresdefault URL often 404s.
+var thumbnailLadder = []string{"maxresdefault", "sddefault", "hqdefault", "mqdefault"}
+
+const thumbnailBaseURL = "https://i.ytimg.com/vi/"
+
+// ResolveBestThumbnail probes the thumbnail ladder with HEAD requests and
+// returns the URL of the highest-resolution thumbnail that exists.
+func (c *Client) ResolveBestThumbnail(ctx context.Context, input string) (string, error) {
+	videoID, err := normalizeVideoID(input)
+	if err != nil {
+		return "", err
+	}
+	var lastErr error
+	for _, name := range thumbnailLadder {
+		thumbURL := thumbnailBaseURL + videoID + "/" + name + ".jpg"
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, thumbURL, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("User-Agent", c.pageUserAgent())
+		applyRequestHeaders(req, c.config.RequestHeaders)
+		resp, err := c.httpClient().Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return thumbURL, nil
+		}
+		lastErr = fmt.Errorf("%s: status=%d", name, resp.StatusCode)
+	}
+	return "", fmt.Errorf("no thumbnail found for %s: %w", videoID, lastErr)
+}
+
+// WriteThumbnail saves the video's best thumbnail (see ResolveBestThumbnail)
+// to outputPath and returns the URL it was fetched from. It fails with
+// ErrDiskDisabled under Config.NoDisk.
+func (c *Client) WriteThumbnail(ctx context.Context, input string, outputPath string) (string, error) {
+	if c.config.NoDisk {
+		return "", fmt.Errorf("%w: WriteThumbnail", ErrDiskDisabled)
+	}
+	thumbURL, err := c.ResolveBestThumbnail(ctx, input)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, thumbURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", c.pageUserAgent())
+	applyRequestHeaders(req, c.config.RequestHeaders)
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("thumbnail fetch failed: status=%d", resp.StatusCode)
+	}
+	if dir := filepath.Dir(outputPath); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+	}
+	f, err := os.Create(outputPath)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	return thumbURL, nil
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func thumbnailTransport(t *testing.T, existing map[string]bool, heads *[]string) roundTripFunc {
+	return func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host != "i.ytimg.com" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		name := strings.TrimSuffix(filepath.Base(r.URL.Path), ".jpg")
+		if r.Method == http.MethodHead {
+			*heads = append(*heads, name)
+		}
+		status := http.StatusNotFound
+		if existing[name] {
+			status = http.StatusOK
+		}
+		return &http.Response{StatusCode: status, Header: make(http.Header), Body: io.NopCloser(strings.NewReader("jpeg:" + name))}, nil
+	}
+}
+
+func TestResolveBestThumbnail_ProbesLadder(t *testing.T) {
+	var heads []string
+	c := &Client{config: Config{HTTPClient: &http.Client{Transport: thumbnailTransport(t, map[string]bool{"hqdefault": true, "mqdefault": true}, &heads)}}}
+	got, err := c.ResolveBestThumbnail(context.Background(), "jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("ResolveBestThumbnail() error = %v", err)
+	}
+	if got != "https://i.ytimg.com/vi/jNQXAC9IVRw/hqdefault.jpg" {
+		t.Fatalf("ResolveBestThumbnail() = %q", got)
+	}
+	if strings.Join(heads, ",") != "maxresdefault,sddefault,hqdefault" {
+		t.Fatalf("probed %v", heads)
+	}
+
+	heads = nil
+	c = &Client{config: Config{HTTPClient: &http.Client{Transport: thumbnailTransport(t, nil, &heads)}}}
+	if _, err := c.ResolveBestThumbnail(context.Background(), "jNQXAC9IVRw"); err == nil || !strings.Contains(err.Error(), "status=404") {
+		t.Fatalf("ResolveBestThumbnail() error = %v, want not found", err)
+	}
+}
+
+func TestWriteThumbnail(t *testing.T) {
+	var heads []string
+	c := &Client{config: Config{HTTPClient: &http.Client{Transport: thumbnailTransport(t, map[string]bool{"maxresdefault": true}, &heads)}}}
+	path := filepath.Join(t.TempDir(), "sub", "clip.jpg")
+	got, err := c.WriteThumbnail(context.Background(), "jNQXAC9IVRw", path)
+	if err != nil {
+		t.Fatalf("WriteThumbnail() error = %v", err)
+	}
+	if !strings.HasSuffix(got, "/maxresdefault.jpg") {
+		t.Fatalf("WriteThumbnail() url = %q", got)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "jpeg:maxresdefault" {
+		t.Fatalf("thumbnail file = %q, %v", data, err)
+	}
+}
+
+func TestWriteThumbnail_NoDisk(t *testing.T) {
+	c := New(Config{
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			t.Fatalf("unexpected request with NoDisk: %s", r.URL)
+			return nil, nil
+		})},
+		NoDisk: true,
+	})
+	out := filepath.Join(t.TempDir(), "thumbs", "thumb.jpg")
+	if _, err := c.WriteThumbnail(context.Background(), "jNQXAC9IVRw", out); !errors.Is(err, ErrDiskDisabled) {
+		t.Fatalf("WriteThumbnail() error = %v, want ErrDiskDisabled", err)
+	}
+	if _, err := os.Stat(filepath.Dir(out)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("WriteThumbnail created %s with NoDisk: %v", filepath.Dir(out), err)
+	}
+}
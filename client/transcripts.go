@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// transcriptFetchConcurrency bounds the caption fetches of one
+// GetTranscripts call.
+const transcriptFetchConcurrency = 4
+
+// GetTranscripts fetches transcripts for several languages from one
+// caption-track listing, concurrently. The result has one entry per
+// language in langs order, nil where that language failed; the failures
+// are reported together as a *TranscriptBatchError. Other errors (the
+// video itself failing) return no transcripts. An empty language code
+// picks the default track, as in GetTranscript.
+func (c *Client) GetTranscripts(ctx context.Context, input string, langs []string) ([]*Transcript, error) {
+	ctx, cancel := withDefaultTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+
+	videoID, err := normalizeVideoID(input)
+	if err != nil {
+		return nil, err
+	}
+	tracks, err := c.GetSubtitleTracks(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*Transcript, len(langs))
+	errs := make([]error, len(langs))
+	sem := make(chan struct{}, transcriptFetchConcurrency)
+	var wg sync.WaitGroup
+	for i, lang := range langs {
+		if len(tracks) == 0 {
+			errs[i] = &TranscriptUnavailableDetailError{VideoID: videoID, LanguageCode: lang, Reason: "no caption tracks"}
+			continue
+		}
+		if j := slices.Index(langs[:i], lang); j >= 0 {
+			continue // filled from j below
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			out[i], errs[i] = c.fetchTranscript(ctx, videoID, tracks, lang)
+		}()
+	}
+	wg.Wait()
+
+	batch := &TranscriptBatchError{VideoID: videoID}
+	for i, lang := range langs {
+		if j := slices.Index(langs[:i], lang); j >= 0 {
+			out[i], errs[i] = out[j], errs[j]
+		}
+		if errs[i] != nil {
+			batch.LanguageCodes = append(batch.LanguageCodes, lang)
+			batch.Errors = append(batch.Errors, errs[i])
+		}
+	}
+	if len(batch.Errors) > 0 {
+		return out, batch
+	}
+	return out, nil
+}
+
+// transcriptCache holds a video session's parsed transcripts by track
+// URL, so subtitle writing and repeated GetTranscript calls parse each
+// track once.
+type transcriptCache struct {
+	mu          sync.Mutex
+	transcripts map[string]*Transcript
+}
+
+func newTranscriptCache() *transcriptCache {
+	return &transcriptCache{transcripts: make(map[string]*Transcript)}
+}
+
+// get returns a copy of the cached transcript, which callers may modify.
+func (c *transcriptCache) get(trackURL string) (*Transcript, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.transcripts[trackURL]
+	if !ok {
+		return nil, false
+	}
+	cp := *t
+	cp.Entries = slices.Clone(t.Entries)
+	return &cp, true
+}
+
+func (c *transcriptCache) put(trackURL string, t *Transcript) {
+	if c == nil {
+		return
+	}
+	cp := *t
+	cp.Entries = slices.Clone(t.Entries)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transcripts[trackURL] = &cp
+}
+
+// transcriptCache returns videoID's session transcript cache, or nil
+// without a session.
+func (c *Client) transcriptCache(videoID string) *transcriptCache {
+	session, ok := c.getSession(videoID)
+	if !ok {
+		return nil
+	}
+	return session.Transcripts
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetTranscripts_OneListingCachedTracks(t *testing.T) {
+	var players, captions atomic.Int32
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := ""
+		switch {
+		case r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+			players.Add(1)
+			body = `{
+				"playabilityStatus":{"status":"OK"},
+				"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo"},
+				"captions":{"playerCaptionsTracklistRenderer":{"captionTracks":[
+					{"baseUrl":"https://caption.local/api?lang=en","languageCode":"en","name":{"simpleText":"English"}},
+					{"baseUrl":"https://caption.local/api?lang=de","languageCode":"de","name":{"simpleText":"Deutsch"}}
+				]}}
+			}`
+		case r.URL.Host == "caption.local":
+			captions.Add(1)
+			body = `<transcript><text start="0.0" dur="1.0">` + r.URL.Query().Get("lang") + `</text></transcript>`
+		default:
+			return &http.Response{StatusCode: http.StatusNotFound, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}
+	c := New(Config{HTTPClient: httpClient, ClientOverrides: []string{"mweb"}})
+
+	got, err := c.GetTranscripts(context.Background(), "jNQXAC9IVRw", []string{"en", "fr", "de", "en"})
+	var batch *TranscriptBatchError
+	if !errors.As(err, &batch) || len(batch.LanguageCodes) != 1 || batch.LanguageCodes[0] != "fr" || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("GetTranscripts() error = %v, want a batch error for fr only", err)
+	}
+	if len(got) != 4 || got[1] != nil {
+		t.Fatalf("GetTranscripts() = %v, want four entries with fr nil", got)
+	}
+	for i, want := range map[int]string{0: "en", 2: "de", 3: "en"} {
+		if got[i] == nil || got[i].LanguageCode != want || got[i].Entries[0].Text != want {
+			t.Fatalf("transcript %d = %+v, want %s", i, got[i], want)
+		}
+	}
+
+	again, err := c.GetTranscript(context.Background(), "jNQXAC9IVRw", "de")
+	if err != nil || again.Entries[0].Text != "de" {
+		t.Fatalf("GetTranscript() = %+v, %v", again, err)
+	}
+	if players.Load() != 1 || captions.Load() != 2 {
+		t.Fatalf("%d player and %d caption requests, want one listing and one fetch per track", players.Load(), captions.Load())
+	}
+}
@@ -1,6 +1,11 @@
 package client
 
-import "github.com/famomatic/ytv1/internal/types"
+import (
+	"time"
+
+	"github.com/famomatic/ytv1/internal/formats"
+	"github.com/famomatic/ytv1/internal/types"
+)
 
 // VideoInfo is the package-level metadata result.
 type VideoInfo struct {
@@ -19,11 +24,102 @@ type VideoInfo struct {
 	Formats         []FormatInfo
 	DashManifestURL string
 	HLSManifestURL  string
+	// IsUpcoming marks a scheduled live stream or premiere that has not
+	// started yet; it has no formats until then.
+	IsUpcoming bool
+	// IsPremiere marks a premiere (an upload first shown as a live event),
+	// upcoming or past.
+	IsPremiere bool
+	// ScheduledStartTime is when an upcoming stream or premiere begins, or
+	// zero when unknown.
+	ScheduledStartTime time.Time
+	// BestThumbnailURL is the highest-resolution thumbnail that exists, set
+	// when Config.ProbeThumbnails is enabled.
+	BestThumbnailURL string
+	// LiveReplay is the replay state of a live stream that has ended, or
+	// empty for other videos.
+	LiveReplay LiveReplayStatus
+	// LiveTargetDuration is a live stream's segment duration and
+	// LiveDVRWindow how far behind the live edge it can be read, from the
+	// formats' targetDurationSec and maxDvrDurationSec; zero for other
+	// videos. Live downloads use them when the manifest gives no refresh
+	// interval or window.
+	LiveTargetDuration time.Duration
+	LiveDVRWindow      time.Duration
+	// LatencyClass is a live stream's latency mode, or empty for other
+	// videos.
+	LatencyClass LatencyClass
+	// TrailerVideoID is a trailer YouTube plays in the video's place, such as
+	// a premiere's waiting-room trailer. See DownloadOptions.Trailer.
+	TrailerVideoID string
+	// DefaultLanguage is the language the title and description were
+	// written in, or empty when YouTube does not say.
+	DefaultLanguage string
+	// Language is the language of Title and Description: DefaultLanguage,
+	// or Config.MetadataLanguage when a translation replaced them.
+	Language string
+	// LocalizedTitle and LocalizedDescription are the microformat title and
+	// description in Config.MetadataLanguage ("en" when unset). They equal
+	// the original text when no translation exists.
+	LocalizedTitle       string
+	LocalizedDescription string
+	// Extractor is the registered name of the Extractor that produced this
+	// info, or empty for YouTube.
+	Extractor string
 }
 
+// LiveReplayStatus is the replay state of a finished live stream.
+type LiveReplayStatus string
+
+// VideoInfo.LiveReplay values.
+const (
+	// LiveReplayProcessing marks a replay YouTube is still processing: only
+	// the early, often low-resolution DVR encode is listed, and possibly
+	// only its last hours.
+	LiveReplayProcessing LiveReplayStatus = "processing"
+	// LiveReplayReady marks a processed replay with its full format list.
+	LiveReplayReady LiveReplayStatus = "ready"
+)
+
+// LatencyClass is the latency mode a live stream was set up with. Lower
+// latency streams use shorter segments and manifest reloads.
+type LatencyClass string
+
+// VideoInfo.LatencyClass values.
+const (
+	LatencyNormal   LatencyClass = "normal"
+	LatencyLow      LatencyClass = "low"
+	LatencyUltraLow LatencyClass = "ultra_low"
+)
+
 // FormatInfo is the normalized public format model.
 type FormatInfo = types.FormatInfo
 
+// FormatInfo.Hints values.
+const (
+	// FormatHintDRM marks DRM-protected formats; they cannot be downloaded.
+	FormatHintDRM = formats.HintDRM
+	// FormatHintSABROnly marks formats listed without a URL or cipher, only
+	// reachable through server-driven adaptive streaming; they cannot be downloaded.
+	FormatHintSABROnly = formats.HintSABROnly
+	// FormatHintPremium marks enhanced-bitrate formats served only to Premium sessions.
+	FormatHintPremium = formats.HintPremium
+	// FormatHintDeprecated marks itags that are no longer offered for new uploads.
+	FormatHintDeprecated = formats.HintDeprecated
+)
+
+// MuxTrack is one input stream passed to MultiTrackMuxer.
+type MuxTrack = types.MuxTrack
+
+// MediaProbe is the stream summary returned by OutputProber.
+type MediaProbe = types.MediaProbe
+
+// ExternalDownload is one direct media transfer passed to ExternalDownloader.
+type ExternalDownload = types.ExternalDownload
+
+// DownloadProgress is a progress sample reported by ExternalDownloader.
+type DownloadProgress = types.DownloadProgress
+
 // SubtitleTrack describes one subtitle/caption track.
 type SubtitleTrack struct {
 	LanguageCode  string
@@ -40,6 +136,33 @@ type TranscriptEntry struct {
 	StartSec float64
 	DurSec   float64
 	Text     string
+	// Position is the srv3 window placement. Nil means YouTube's default
+	// (bottom center), including every legacy-format transcript.
+	Position *CuePosition
+	// Segments are srv3 spans with their own start offset (word-by-word
+	// auto captions) or pen styling. Nil for plain cues; Text always holds
+	// the concatenated plain text.
+	Segments []TranscriptSegment
+}
+
+// CuePosition places a caption window on the video frame.
+type CuePosition struct {
+	// AnchorPoint is the window point being placed, on YouTube's 3x3 grid:
+	// 0 top-left, 1 top-center, ... 4 center, ... 7 bottom-center, 8 bottom-right.
+	AnchorPoint int
+	// HorizontalPct and VerticalPct locate the anchor point, 0-100.
+	HorizontalPct float64
+	VerticalPct   float64
+}
+
+// TranscriptSegment is one styled or separately timed span of a caption.
+type TranscriptSegment struct {
+	// OffsetSec is the span start relative to the entry start.
+	OffsetSec float64
+	Text      string
+	Bold      bool
+	Italic    bool
+	Underline bool
 }
 
 // Transcript is a normalized transcript payload.
@@ -59,8 +182,26 @@ type PlaylistItem struct {
 	Author          string
 	DurationSeconds string
 	DurationSec     int64
+	// Index is the 1-based position YouTube shows for the entry, or 0 when
+	// not given.
+	Index int
+	// ThumbnailURL is the largest thumbnail listed for the entry.
+	ThumbnailURL string
+	// Unavailable marks private, deleted and otherwise unplayable entries;
+	// fetching them fails, so callers can skip them without GetVideo.
+	// UnavailableReason is PlaylistItemPrivate, PlaylistItemDeleted or
+	// PlaylistItemUnavailable.
+	Unavailable       bool
+	UnavailableReason string
 }
 
+// PlaylistItem.UnavailableReason values.
+const (
+	PlaylistItemPrivate     = "private"
+	PlaylistItemDeleted     = "deleted"
+	PlaylistItemUnavailable = "unavailable"
+)
+
 // PlaylistContinuationWarning describes a non-fatal continuation traversal issue.
 type PlaylistContinuationWarning struct {
 	Token      string
@@ -76,6 +217,9 @@ type PlaylistContinuationStats struct {
 	SkippedDuplicate int
 	SkippedEmpty     int
 	StoppedByLimit   bool
+	// StoppedByKnown is set when PlaylistOptions.StopAtKnown matched an item
+	// and the remaining continuations were not requested.
+	StoppedByKnown bool
 }
 
 // PlaylistInfo is a normalized playlist payload.
@@ -85,4 +229,15 @@ type PlaylistInfo struct {
 	Items                []PlaylistItem
 	ContinuationWarnings []PlaylistContinuationWarning
 	ContinuationStats    PlaylistContinuationStats
+	// FromCache is set when Items came from the on-disk playlist cache.
+	FromCache bool
+}
+
+// PlaylistOptions controls GetPlaylistWithOptions enumeration.
+type PlaylistOptions struct {
+	// StopAtKnown enables incremental enumeration: once a fetched page holds
+	// a video ID for which it returns true, paging stops. This suits
+	// newest-first playlists (e.g. channel uploads) checked against a
+	// download archive. Incremental results are never written to the cache.
+	StopAtKnown func(videoID string) bool
 }
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"time"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+// earliestExpiry returns the first ExpiresAt among formats, or zero when none
+// is known.
+func earliestExpiry(formats []types.FormatInfo) time.Time {
+	var earliest time.Time
+	for _, f := range formats {
+		if !f.ExpiresAt.IsZero() && (earliest.IsZero() || f.ExpiresAt.Before(earliest)) {
+			earliest = f.ExpiresAt
+		}
+	}
+	return earliest
+}
+
+// estimateDownloadTime guesses how long fetching selected takes: their size
+// at the speed of the client's last download or, before any download, at
+// playback speed, which is what throttled transfers get.
+func (c *Client) estimateDownloadTime(info *VideoInfo, selected []types.FormatInfo) time.Duration {
+	var size, bitrate int64
+	for _, f := range selected {
+		size += f.ContentLength
+		bitrate += int64(f.Bitrate)
+	}
+	if speed := c.lastBytesPerSecond.Load(); speed > 0 && size > 0 {
+		return time.Duration(float64(size) / float64(speed) * float64(time.Second))
+	}
+	if size > 0 && bitrate > 0 {
+		return time.Duration(float64(size) * 8 / float64(bitrate) * float64(time.Second))
+	}
+	if info != nil {
+		return time.Duration(info.DurationSec) * time.Second
+	}
+	return 0
+}
+
+// warnShortURLLifetime warns when the selected URLs expire before the
+// download is likely to finish. Transfers outliving their URL are refreshed
+// on 403, but only after a fresh player request.
+func (c *Client) warnShortURLLifetime(videoID string, info *VideoInfo, selected []types.FormatInfo, now time.Time) {
+	expires := earliestExpiry(selected)
+	if expires.IsZero() {
+		return
+	}
+	remaining := expires.Sub(now)
+	if estimate := c.estimateDownloadTime(info, selected); estimate > remaining {
+		c.warnf("stream URLs for %s expire in %s, before the estimated download time of %s; expect a URL refresh mid-transfer",
+			videoID, max(remaining, 0).Truncate(time.Second), estimate.Truncate(time.Second))
+	}
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+func TestWarnShortURLLifetime(t *testing.T) {
+	now := time.Now()
+	logger := &testLogger{}
+	c := &Client{logger: logger}
+	selected := []types.FormatInfo{
+		{Itag: 137, ContentLength: 900 << 20, Bitrate: 4_000_000, ExpiresAt: now.Add(time.Hour)},
+		{Itag: 140, ContentLength: 60 << 20, Bitrate: 128_000, ExpiresAt: now.Add(2 * time.Hour)},
+	}
+
+	// Before any download, the estimate is playback speed: ~30 minutes.
+	c.warnShortURLLifetime("jNQXAC9IVRw", nil, selected, now)
+	if len(logger.warnings) != 0 {
+		t.Fatalf("warnings = %v, want none", logger.warnings)
+	}
+
+	// At the last observed 100 KiB/s the transfer takes well over an hour.
+	c.lastBytesPerSecond.Store(100 << 10)
+	c.warnShortURLLifetime("jNQXAC9IVRw", nil, selected, now)
+	if len(logger.warnings) != 1 || !strings.Contains(logger.warnings[0], "expire in 1h0m0s") {
+		t.Fatalf("warnings = %v", logger.warnings)
+	}
+
+	if got := earliestExpiry(selected); !got.Equal(selected[0].ExpiresAt) {
+		t.Fatalf("earliestExpiry() = %v, want %v", got, selected[0].ExpiresAt)
+	}
+	if got := earliestExpiry([]types.FormatInfo{{Itag: 18}}); !got.IsZero() {
+		t.Fatalf("earliestExpiry(unknown) = %v, want zero", got)
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/httpx"
+)
+
+// urlProbe is what a one-byte range request learned about a stream URL.
+type urlProbe struct {
+	StatusCode int
+	// AcceptsRanges is set when the server answered 206 Partial Content.
+	AcceptsRanges bool
+	// ContentLength is the total size from Content-Range, or 0 if unknown.
+	ContentLength int64
+	RetryAfter    time.Duration
+}
+
+// urlProbeCache remembers probe results per stream URL for one video
+// session, so CheckFormats, selection retries and the chunked downloader do
+// not each repeat the same range request. A new extraction starts a new
+// session, and with it an empty cache, as stream URLs change.
+type urlProbeCache struct {
+	mu     sync.Mutex
+	probes map[string]urlProbe
+}
+
+func newURLProbeCache() *urlProbeCache {
+	return &urlProbeCache{probes: make(map[string]urlProbe)}
+}
+
+func (c *urlProbeCache) get(streamURL string) (urlProbe, bool) {
+	if c == nil {
+		return urlProbe{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.probes[streamURL]
+	return p, ok
+}
+
+func (c *urlProbeCache) put(streamURL string, p urlProbe) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probes[streamURL] = p
+}
+
+// urlProbeCacheKey carries the video session's urlProbeCache to download
+// helpers that do not see the Client.
+type urlProbeCacheKey struct{}
+
+func withURLProbeCache(ctx context.Context, cache *urlProbeCache) context.Context {
+	if cache == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, urlProbeCacheKey{}, cache)
+}
+
+// urlProbes returns videoID's session probe cache, or nil without a session.
+func (c *Client) urlProbes(videoID string) *urlProbeCache {
+	session, ok := c.getSession(videoID)
+	if !ok {
+		return nil
+	}
+	return session.Probes
+}
+
+// probeStreamURL issues a one-byte range request against streamURL, or
+// returns the cached result of an earlier one. Any HTTP response is cached;
+// transport errors are not.
+func probeStreamURL(
+	ctx context.Context,
+	httpClient *http.Client,
+	streamURL string,
+	videoID string,
+	requestHeaders http.Header,
+) (urlProbe, error) {
+	cache, _ := ctx.Value(urlProbeCacheKey{}).(*urlProbeCache)
+	if p, ok := cache.get(streamURL); ok {
+		return p, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, streamURL, nil)
+	if err != nil {
+		return urlProbe{}, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	applyMediaRequestHeaders(req, requestHeaders, videoID)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return urlProbe{}, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	p := urlProbe{
+		StatusCode:    resp.StatusCode,
+		AcceptsRanges: resp.StatusCode == http.StatusPartialContent,
+		RetryAfter:    httpx.ParseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+	if p.AcceptsRanges {
+		cr := strings.TrimSpace(resp.Header.Get("Content-Range"))
+		// expected form: bytes 0-0/12345
+		if slash := strings.LastIndex(cr, "/"); slash >= 0 && slash < len(cr)-1 {
+			var total int64
+			if _, err := fmt.Sscanf(cr[slash+1:], "%d", &total); err == nil && total > 0 {
+				p.ContentLength = total
+			}
+		}
+	}
+	cache.put(streamURL, p)
+	return p, nil
+}
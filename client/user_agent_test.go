@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserAgents_PerStage(t *testing.T) {
+	sheet := frameTestSheet(t)
+	seen := map[string]string{}
+	httpClient := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := ""
+		switch {
+		case strings.Contains(r.URL.Path, "/youtubei/v1/player"):
+			payload, _ := io.ReadAll(r.Body)
+			if !strings.Contains(string(payload), `"userAgent":"meta-mweb"`) {
+				t.Errorf("player request context lacks the metadata User-Agent: %s", payload)
+			}
+			seen["player"] = r.UserAgent()
+			body = `{
+				"playabilityStatus":{"status":"OK"},
+				"videoDetails":{"videoId":"jNQXAC9IVRw","title":"Me at the zoo","lengthSeconds":"19"},
+				"storyboards":{"playerStoryboardSpecRenderer":{"spec":"https://i.ytimg.com/sb/jNQXAC9IVRw/storyboard3_L$L/$N.jpg|16#16#4#2#2#5000#M$M#sig"}}
+			}`
+		case r.URL.Host == "i.ytimg.com":
+			seen["storyboard"] = r.UserAgent()
+			body = string(sheet)
+		default:
+			seen[r.URL.Host+r.URL.Path] = r.UserAgent()
+			return &http.Response{StatusCode: http.StatusNotFound, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}
+
+	c := New(Config{
+		HTTPClient:      httpClient,
+		ClientOverrides: []string{"mweb"},
+		UserAgents: UserAgentConfig{
+			Metadata:         "meta-all",
+			MetadataByClient: map[string]string{"mweb": "meta-mweb"},
+			WatchPage:        "page-ua",
+			Media:            "media-ua",
+		},
+	})
+	if _, err := c.GetFrame(context.Background(), "jNQXAC9IVRw", 6*time.Second); err != nil {
+		t.Fatalf("GetFrame() error = %v", err)
+	}
+	if seen["player"] != "meta-mweb" {
+		t.Fatalf("player User-Agent = %q, want the per-client override", seen["player"])
+	}
+	if seen["storyboard"] != "media-ua" {
+		t.Fatalf("storyboard User-Agent = %q, want the media User-Agent", seen["storyboard"])
+	}
+
+	if _, err := c.GetPlaylist(context.Background(), "PLxxxxxxxxxxxxxxxx"); err == nil {
+		t.Fatal("expected the playlist page fetch to fail against the stub")
+	}
+	if ua := seen["www.youtube.com/playlist"]; ua != "page-ua" {
+		t.Fatalf("playlist page User-Agent = %q, want the watch-page User-Agent (seen %v)", ua, seen)
+	}
+}
@@ -0,0 +1,50 @@
+package client
+
+import "path/filepath"
+
+// WorkspaceLockName is the lock file AcquireWorkspaceLock uses for an output
+// directory. Like merge intermediate locks it is refreshed while held, and
+// cleanup treats one without a recent heartbeat as an orphan.
+const WorkspaceLockName = intermediateLockSuffix
+
+// WorkspaceLock is an advisory lock that keeps concurrent runs from
+// interleaving writes to the same download archive or output directory.
+type WorkspaceLock struct {
+	locks []*intermediateLock
+}
+
+// AcquireWorkspaceLock locks each of paths, in order: a lock file is created
+// at each path and kept fresh until Release. It fails with a
+// *WorkspaceLockedError when another live run holds one of them, releasing
+// any it already took; locks left by crashed runs are taken over.
+func AcquireWorkspaceLock(paths ...string) (*WorkspaceLock, error) {
+	w := &WorkspaceLock{}
+	seen := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if _, dup := seen[path]; dup {
+			continue
+		}
+		seen[path] = struct{}{}
+		l, err := acquireLockFile(path)
+		if err != nil {
+			w.Release()
+			return nil, err
+		}
+		w.locks = append(w.locks, l)
+	}
+	return w, nil
+}
+
+// Release removes the lock files. It is safe on a nil lock.
+func (w *WorkspaceLock) Release() {
+	if w == nil {
+		return
+	}
+	for _, l := range w.locks {
+		l.release()
+	}
+	w.locks = nil
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAcquireWorkspaceLock(t *testing.T) {
+	dir := t.TempDir()
+	archiveLock := filepath.Join(dir, "archive.txt"+WorkspaceLockName)
+	dirLock := filepath.Join(dir, WorkspaceLockName)
+
+	held, err := AcquireWorkspaceLock(dirLock)
+	if err != nil {
+		t.Fatalf("AcquireWorkspaceLock() error = %v", err)
+	}
+
+	// A second run fails on the held lock and gives back the ones it took.
+	_, err = AcquireWorkspaceLock(archiveLock, dirLock)
+	var locked *WorkspaceLockedError
+	if !errors.Is(err, ErrWorkspaceLocked) || !errors.As(err, &locked) || locked.PID != os.Getpid() {
+		t.Fatalf("second acquire error = %v", err)
+	}
+	if _, err := os.Stat(archiveLock); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("partially acquired lock left behind: %v", err)
+	}
+
+	held.Release()
+	held.Release()
+	lock, err := AcquireWorkspaceLock(archiveLock, dirLock, dirLock)
+	if err != nil {
+		t.Fatalf("acquire after release error = %v", err)
+	}
+	lock.Release()
+	for _, path := range []string{archiveLock, dirLock} {
+		if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("lock %s not removed: %v", path, err)
+		}
+	}
+}
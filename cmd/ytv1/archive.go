@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/famomatic/ytv1/client"
+	"github.com/famomatic/ytv1/internal/cli"
+)
+
+// ytDlpYouTubeKey is the extractor key yt-dlp writes in front of YouTube IDs.
+const ytDlpYouTubeKey = "youtube"
+
+// parseArchiveLine returns the archive key for one line of a ytv1 or yt-dlp
+// archive, and the line format when the line says which one it is: yt-dlp's
+// "youtube <id>" and ytv1's bare ID both become the bare ID, other extractor
+// keys are the same in both formats.
+func parseArchiveLine(line string) (key, format string, ok bool) {
+	line = strings.TrimSpace(line)
+	if extractor, id, found := strings.Cut(line, " "); found && strings.EqualFold(extractor, ytDlpYouTubeKey) {
+		if _, err := client.ExtractVideoID(id); err != nil || id != strings.TrimSpace(id) {
+			return "", "", false
+		}
+		return id, cli.ArchiveFormatYtDlp, true
+	}
+	if !validArchiveEntry(line) {
+		return "", "", false
+	}
+	if !strings.Contains(line, " ") {
+		format = cli.ArchiveFormatYtv1
+	}
+	return line, format, true
+}
+
+// formatArchiveLine renders key as an archive line in format.
+func formatArchiveLine(key, format string) string {
+	if format == cli.ArchiveFormatYtDlp && !strings.Contains(key, " ") {
+		return ytDlpYouTubeKey + " " + key
+	}
+	return key
+}
+
+// readArchiveKeys returns the distinct keys of an archive in file order, the
+// format of its first YouTube entry, and the number of lines skipped as
+// invalid.
+func readArchiveKeys(r io.Reader) (keys []string, format string, skipped int, err error) {
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		key, lineFormat, ok := parseArchiveLine(scanner.Text())
+		if !ok {
+			skipped++
+			continue
+		}
+		if format == "" {
+			format = lineFormat
+		}
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	return keys, format, skipped, scanner.Err()
+}
+
+// runArchive runs the archive subcommand. "archive convert IN [OUT]" rewrites
+// IN in --archive-format, to OUT (which may be IN) or to stdout.
+func runArchive(w io.Writer, opts cli.Options) error {
+	if opts.ArchiveAction != "convert" {
+		return fmt.Errorf("unknown action %q: want convert", opts.ArchiveAction)
+	}
+	if len(opts.URLs) < 1 || len(opts.URLs) > 2 {
+		return errors.New("usage: ytv1 archive convert --archive-format ytv1|yt-dlp IN [OUT]")
+	}
+	switch opts.ArchiveFormat {
+	case cli.ArchiveFormatYtv1, cli.ArchiveFormatYtDlp:
+	case "":
+		return errors.New("--archive-format is required: want ytv1 or yt-dlp")
+	default:
+		return fmt.Errorf("invalid --archive-format %q: want ytv1 or yt-dlp", opts.ArchiveFormat)
+	}
+
+	in, err := os.Open(opts.URLs[0])
+	if err != nil {
+		return err
+	}
+	keys, _, skipped, err := readArchiveKeys(in)
+	in.Close()
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(formatArchiveLine(key, opts.ArchiveFormat) + "\n")
+	}
+	if len(opts.URLs) == 1 {
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	// Write through a temporary file so converting in place cannot truncate
+	// the archive.
+	out := opts.URLs[1]
+	tmp := out + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Converted %d entries to %s format: %s (skipped %d invalid lines)\n", len(keys), opts.ArchiveFormat, out, skipped)
+	return nil
+}
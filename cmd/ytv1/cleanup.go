@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/famomatic/ytv1/client"
+	"github.com/famomatic/ytv1/internal/cli"
+)
+
+// runCleanup removes intermediate files older than --older-than under --dir.
+// Newer intermediates are left alone: their names are deterministic, so
+// rerunning the original download with --continue resumes them.
+func runCleanup(w io.Writer, opts cli.Options, now time.Time) error {
+	dir := strings.TrimSpace(opts.CleanupDir)
+	if dir == "" {
+		dir = "."
+	}
+	if opts.CleanupOlderThan < 0 {
+		return errors.New("--older-than must not be negative")
+	}
+	files, err := client.FindOrphanedFiles(dir, now.Add(-opts.CleanupOlderThan))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(w, "No intermediate files older than %s in %s\n", opts.CleanupOlderThan, dir)
+		return nil
+	}
+	var removed, bytes int64
+	var errs []error
+	for _, f := range files {
+		age := now.Sub(f.ModTime).Truncate(time.Minute)
+		if opts.Simulate {
+			fmt.Fprintf(w, "[simulate] would remove %s (%d bytes, age %s)\n", f.Path, f.Size, age)
+			continue
+		}
+		if err := os.Remove(f.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		fmt.Fprintf(w, "Removed %s (%d bytes, age %s)\n", f.Path, f.Size, age)
+		removed++
+		bytes += f.Size
+	}
+	if !opts.Simulate {
+		fmt.Fprintf(w, "Cleanup summary: removed=%d bytes=%d failed=%d\n", removed, bytes, len(errs))
+	}
+	return errors.Join(errs...)
+}
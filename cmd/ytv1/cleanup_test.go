@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/cli"
+)
+
+func TestRunCleanup_RemovesStaleIntermediates(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	stale := filepath.Join(dir, "a.mp4.f137.video")
+	fresh := filepath.Join(dir, "b.mp4.f137.video")
+	for _, p := range []string{stale, fresh} {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	old := now.Add(-30 * time.Hour)
+	if err := os.Chtimes(stale, old, old); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	opts := cli.Options{CleanupDir: dir, CleanupOlderThan: 24 * time.Hour, Simulate: true}
+	if err := runCleanup(&out, opts, now); err != nil {
+		t.Fatalf("runCleanup(simulate) error = %v", err)
+	}
+	if !strings.Contains(out.String(), "[simulate] would remove "+stale) {
+		t.Fatalf("unexpected simulate output:\n%s", out.String())
+	}
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("simulate removed %s: %v", stale, err)
+	}
+
+	out.Reset()
+	opts.Simulate = false
+	if err := runCleanup(&out, opts, now); err != nil {
+		t.Fatalf("runCleanup() error = %v", err)
+	}
+	if _, err := os.Stat(stale); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected %s removed, stat err=%v", stale, err)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatalf("expected %s kept: %v", fresh, err)
+	}
+	if !strings.Contains(out.String(), "removed=1 bytes=4 failed=0") {
+		t.Fatalf("unexpected summary:\n%s", out.String())
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/famomatic/ytv1/client"
+	"github.com/famomatic/ytv1/internal/cli"
+)
+
+// completionFlag is one registered flag as the completion scripts see it.
+type completionFlag struct {
+	token  string // "-f" or "--format"
+	usage  string
+	isBool bool
+	// values are offered for the flag's argument; list values are completed
+	// as a comma-separated list.
+	values []string
+	list   bool
+}
+
+var completionCommandHelp = map[string]string{
+	cli.CommandSync:       "Download uploads that are new since the previous sync run",
+	cli.CommandCleanup:    "Remove intermediate files left behind by crashed downloads",
+	cli.CommandReportBug:  "Bundle captured fixtures for a bug report",
+	cli.CommandCompletion: "Print a shell completion script",
+	cli.CommandUpdate:     "Replace ytv1 with the latest signed release",
+	cli.CommandArchive:    "Convert a download archive between ytv1 and yt-dlp formats",
+}
+
+// runCompletion writes the completion script for shell, built from the flags
+// registered on fs.
+func runCompletion(w io.Writer, shell string, fs *flag.FlagSet) error {
+	flags := completionFlags(fs)
+	switch shell {
+	case "bash":
+		writeBashCompletion(w, flags)
+	case "zsh":
+		writeZshCompletion(w, flags)
+	case "fish":
+		writeFishCompletion(w, flags)
+	case "powershell":
+		writePowerShellCompletion(w, flags)
+	default:
+		return fmt.Errorf("unsupported shell %q: want %s", shell, strings.Join(cli.CompletionShells, ", "))
+	}
+	return nil
+}
+
+func completionFlags(fs *flag.FlagSet) []completionFlag {
+	type valueSet struct {
+		values []string
+		list   bool
+	}
+	sets := map[string]valueSet{
+		"f":              {values: cli.FormatPresets},
+		"format":         {values: cli.FormatPresets},
+		"clients":        {values: client.InnertubeClientNames(), list: true},
+		"format-table":   {values: []string{cli.FormatTablePretty, cli.FormatTableJSON, cli.FormatTableTSV}},
+		"format-columns": {values: cli.FormatColumns, list: true},
+		"audio-format":   {values: []string{client.AudioFormatBest, client.AudioFormatOpus, client.AudioFormatM4A}},
+		"preset":         {values: []string{cli.PresetPodcast}},
+		"playlist-order": {values: []string{cli.PlaylistOrderNewest, cli.PlaylistOrderOldest, cli.PlaylistOrderShortest, cli.PlaylistOrderViews}},
+		"sub-format":     {values: []string{"best", string(client.SubtitleOutputFormatVTT), string(client.SubtitleOutputFormatSRT)}},
+	}
+	var out []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		cf := completionFlag{token: "--" + f.Name, usage: f.Usage}
+		if len(f.Name) == 1 {
+			cf.token = "-" + f.Name
+		}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			cf.isBool = true
+		}
+		if set, ok := sets[f.Name]; ok {
+			cf.values, cf.list = set.values, set.list
+		}
+		out = append(out, cf)
+	})
+	return out
+}
+
+func writeBashCompletion(w io.Writer, flags []completionFlag) {
+	var tokens []string
+	for _, f := range flags {
+		tokens = append(tokens, f.token)
+	}
+	fmt.Fprintln(w, "# bash completion for ytv1; load with: source <(ytv1 completion bash)")
+	fmt.Fprintln(w, "_ytv1() {")
+	fmt.Fprintln(w, `    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"`)
+	fmt.Fprintln(w, `    if [[ ${COMP_WORDS[1]} == completion && $COMP_CWORD -eq 2 ]]; then`)
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(cli.CompletionShells, " "))
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, `    case "$prev" in`)
+	for _, f := range flags {
+		if len(f.values) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "    %s)\n", f.token)
+		if f.list {
+			fmt.Fprintln(w, `        local head=""`)
+			fmt.Fprintln(w, `        [[ $cur == *,* ]] && head="${cur%,*},"`)
+			fmt.Fprintf(w, "        COMPREPLY=($(compgen -P \"$head\" -W %q -- \"${cur##*,}\"))\n", strings.Join(f.values, " "))
+			fmt.Fprintln(w, "        compopt -o nospace 2>/dev/null")
+		} else {
+			fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(f.values, " "))
+		}
+		fmt.Fprintln(w, "        return")
+		fmt.Fprintln(w, "        ;;")
+	}
+	fmt.Fprintln(w, "    esac")
+	fmt.Fprintln(w, `    if [[ $cur == -* ]]; then`)
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(tokens, " "))
+	fmt.Fprintln(w, "    elif [[ $COMP_CWORD -eq 1 ]]; then")
+	fmt.Fprintf(w, "        COMPREPLY=($(compgen -W %q -- \"$cur\"))\n", strings.Join(cli.Commands, " "))
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, "complete -o default -F _ytv1 ytv1")
+}
+
+func writeZshCompletion(w io.Writer, flags []completionFlag) {
+	fmt.Fprintln(w, "#compdef ytv1")
+	fmt.Fprintln(w, "# zsh completion for ytv1; save as _ytv1 in a directory on $fpath")
+	fmt.Fprintln(w, "_ytv1() {")
+	fmt.Fprintln(w, "    if [[ $words[2] == completion ]]; then")
+	fmt.Fprintf(w, "        (( CURRENT == 3 )) && compadd -- %s\n", strings.Join(cli.CompletionShells, " "))
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    fi")
+	fmt.Fprintln(w, "    local -a commands")
+	fmt.Fprintln(w, "    commands=(")
+	for _, cmd := range cli.Commands {
+		fmt.Fprintf(w, "        %s\n", zshQuote(cmd+":"+completionCommandHelp[cmd]))
+	}
+	fmt.Fprintln(w, "    )")
+	fmt.Fprintln(w, "    _arguments \\")
+	for _, f := range flags {
+		desc := "[" + zshEscapeDescription(f.usage) + "]"
+		var spec string
+		switch {
+		case f.isBool:
+			spec = f.token + desc
+		case f.list:
+			spec = f.token + "=" + desc + ":value:_values -s , value " + strings.Join(f.values, " ")
+		case len(f.values) > 0:
+			spec = f.token + "=" + desc + ":value:(" + strings.Join(f.values, " ") + ")"
+		default:
+			spec = f.token + "=" + desc + ":value:_files"
+		}
+		fmt.Fprintf(w, "        %s \\\n", zshQuote(spec))
+	}
+	fmt.Fprintln(w, "        '1: :{_describe command commands}' \\")
+	fmt.Fprintln(w, "        '*:URL:_urls'")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintln(w, `_ytv1 "$@"`)
+}
+
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func zshEscapeDescription(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+func writeFishCompletion(w io.Writer, flags []completionFlag) {
+	fmt.Fprintln(w, "# fish completion for ytv1; save as ~/.config/fish/completions/ytv1.fish")
+	fmt.Fprintf(w, "set -l ytv1_commands %s\n", strings.Join(cli.Commands, " "))
+	fmt.Fprintln(w, "complete -c ytv1 -f")
+	for _, cmd := range cli.Commands {
+		fmt.Fprintf(w, "complete -c ytv1 -n \"not __fish_seen_subcommand_from $ytv1_commands\" -a %s -d %s\n", cmd, fishQuote(completionCommandHelp[cmd]))
+	}
+	fmt.Fprintf(w, "complete -c ytv1 -n \"__fish_seen_subcommand_from completion\" -a %s\n", fishQuote(strings.Join(cli.CompletionShells, " ")))
+	for _, f := range flags {
+		name := strings.TrimLeft(f.token, "-")
+		opt := "-l " + name
+		if len(name) == 1 {
+			opt = "-s " + name
+		}
+		line := fmt.Sprintf("complete -c ytv1 %s -d %s", opt, fishQuote(f.usage))
+		switch {
+		case f.isBool:
+		case f.list:
+			line += " -x -a " + fishQuote("(__fish_complete_list , 'string split \" \" \""+strings.Join(f.values, " ")+"\"')")
+		case len(f.values) > 0:
+			line += " -x -a " + fishQuote(strings.Join(f.values, " "))
+		default:
+			line += " -r -F"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+func writePowerShellCompletion(w io.Writer, flags []completionFlag) {
+	fmt.Fprintln(w, "# PowerShell completion for ytv1; add to $PROFILE: ytv1 completion powershell | Out-String | Invoke-Expression")
+	fmt.Fprintln(w, "Register-ArgumentCompleter -Native -CommandName ytv1 -ScriptBlock {")
+	fmt.Fprintln(w, "    param($wordToComplete, $commandAst, $cursorPosition)")
+	fmt.Fprintln(w, "    $flags = [ordered]@{")
+	for _, f := range flags {
+		fmt.Fprintf(w, "        %s = %s\n", psQuote(f.token), psQuote(f.usage))
+	}
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "    $values = @{")
+	for _, f := range flags {
+		if len(f.values) > 0 && !f.list {
+			fmt.Fprintf(w, "        %s = %s\n", psQuote(f.token), psArray(f.values))
+		}
+	}
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "    $lists = @{")
+	for _, f := range flags {
+		if f.list {
+			fmt.Fprintf(w, "        %s = %s\n", psQuote(f.token), psArray(f.values))
+		}
+	}
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintf(w, "    $commands = %s\n", psArray(cli.Commands))
+	fmt.Fprintln(w, "    $words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })")
+	fmt.Fprintln(w, "    $prev = if ($wordToComplete -ne '') { $words[-2] } else { $words[-1] }")
+	fmt.Fprintln(w, "    $kind = 'ParameterValue'")
+	fmt.Fprintln(w, "    if ($words.Count -ge 2 -and $words[1] -eq 'completion') {")
+	fmt.Fprintf(w, "        $candidates = %s\n", psArray(cli.CompletionShells))
+	fmt.Fprintln(w, "    } elseif ($lists.Contains($prev)) {")
+	fmt.Fprintln(w, "        $head = if ($wordToComplete -match '^(.*,)') { $Matches[1] } else { '' }")
+	fmt.Fprintln(w, "        $candidates = $lists[$prev] | ForEach-Object { $head + $_ }")
+	fmt.Fprintln(w, "    } elseif ($values.Contains($prev)) {")
+	fmt.Fprintln(w, "        $candidates = $values[$prev]")
+	fmt.Fprintln(w, "    } elseif ($wordToComplete -like '-*') {")
+	fmt.Fprintln(w, "        $candidates = $flags.Keys")
+	fmt.Fprintln(w, "        $kind = 'ParameterName'")
+	fmt.Fprintln(w, "    } elseif ($words.Count -le 2) {")
+	fmt.Fprintln(w, "        $candidates = $commands")
+	fmt.Fprintln(w, "    } else {")
+	fmt.Fprintln(w, "        return")
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "    $candidates | Where-Object { $_ -like \"$wordToComplete*\" } | ForEach-Object {")
+	fmt.Fprintln(w, "        $tip = if ($kind -eq 'ParameterName') { $flags[$_] } else { $_ }")
+	fmt.Fprintln(w, "        [System.Management.Automation.CompletionResult]::new($_, $_, $kind, $tip)")
+	fmt.Fprintln(w, "    }")
+	fmt.Fprintln(w, "}")
+}
+
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func psArray(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = psQuote(v)
+	}
+	return "@(" + strings.Join(quoted, ", ") + ")"
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func testCompletionFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet("ytv1", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.String("f", "best", "Video format code")
+	fs.String("clients", "", "Comma-separated Innertube client order override")
+	fs.String("cookies", "", "Netscape formatted cookies file")
+	fs.Bool("verbose", false, "Print [debug] info: it's verbose")
+	return fs
+}
+
+func TestRunCompletion_Bash(t *testing.T) {
+	var buf bytes.Buffer
+	if err := runCompletion(&buf, "bash", testCompletionFlagSet()); err != nil {
+		t.Fatalf("runCompletion() error = %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		`compgen -W "--clients --cookies -f --verbose"`,
+		"    -f)\n",
+		"bestvideo+bestaudio",
+		`compgen -P "$head"`,
+		" web ",
+		`compgen -W "sync cleanup report-bug completion update archive"`,
+		"complete -o default -F _ytv1 ytv1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("bash script missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "--cookies)") {
+		t.Fatalf("free-form flag should fall back to default completion:\n%s", got)
+	}
+}
+
+func TestRunCompletion_QuotesDescriptions(t *testing.T) {
+	cases := map[string]string{
+		"zsh":        `'--verbose[Print \[debug\] info\: it'\''s verbose]'`,
+		"fish":       `complete -c ytv1 -l verbose -d 'Print [debug] info: it\'s verbose'`,
+		"powershell": `'--verbose' = 'Print [debug] info: it''s verbose'`,
+	}
+	for shell, want := range cases {
+		var buf bytes.Buffer
+		if err := runCompletion(&buf, shell, testCompletionFlagSet()); err != nil {
+			t.Fatalf("runCompletion(%s) error = %v", shell, err)
+		}
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("%s script missing %q:\n%s", shell, want, buf.String())
+		}
+	}
+}
+
+func TestRunCompletion_UnsupportedShell(t *testing.T) {
+	err := runCompletion(io.Discard, "tcsh", testCompletionFlagSet())
+	if err == nil || !strings.Contains(err.Error(), "bash, zsh, fish, powershell") {
+		t.Fatalf("runCompletion() error = %v", err)
+	}
+}
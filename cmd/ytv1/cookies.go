@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/famomatic/ytv1/internal/cookies"
+)
+
+// saveCookieJar writes jar back to path when a server set, rotated or
+// expired a cookie during the run, so long-lived sessions survive between
+// runs. Only the cookie lines are replaced: comments and lines the parser
+// skipped stay as the user wrote them. It goes through a temp file so an
+// interrupted write keeps the previous file intact. It is a no-op for jars
+// it cannot enumerate.
+func saveCookieJar(path string, jar http.CookieJar) error {
+	j, ok := jar.(*cookies.Jar)
+	if !ok || !j.Changed() {
+		return nil
+	}
+	original, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := cookies.RewriteNetscape(&buf, original, j.All()); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/famomatic/ytv1/client"
+	"github.com/famomatic/ytv1/report"
 )
 
 func TestRemediationHintsForAttempts_MissingPOT(t *testing.T) {
@@ -142,3 +143,79 @@ func TestEmitJSONFailure(t *testing.T) {
 		t.Fatalf("error.category=%v", errMap["category"])
 	}
 }
+
+func TestPlayabilityLines_GeoRestricted(t *testing.T) {
+	countries := make([]string, 0, 25)
+	for i := 0; i < 25; i++ {
+		countries = append(countries, string(rune('A'+i))+"X")
+	}
+	err := &client.UnavailableDetailError{Attempts: []client.AttemptDetail{
+		{Client: "web", Stage: "request", HTTPStatus: 500},
+		{
+			Client:               "mweb",
+			Stage:                "playability",
+			PlayabilityStatus:    "UNPLAYABLE",
+			PlayabilityReason:    "Video unavailable",
+			PlayabilitySubreason: "The uploader has not made this video available in your country",
+			GeoRestricted:        true,
+			AvailableCountries:   countries,
+		},
+	}}
+
+	lines := playabilityLines(err)
+	if len(lines) != 3 {
+		t.Fatalf("playabilityLines() = %q, want 3 lines", lines)
+	}
+	if lines[0] != "reason: Video unavailable (UNPLAYABLE)" {
+		t.Fatalf("reason line = %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "not made this video available") {
+		t.Fatalf("subreason line = %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[2], "available countries: AX, BX") || !strings.HasSuffix(lines[2], "(+5 more)") {
+		t.Fatalf("countries line = %q", lines[2])
+	}
+	if got := playabilityLines(errors.New("boom")); got != nil {
+		t.Fatalf("playabilityLines(untyped) = %q, want nil", got)
+	}
+}
+
+func TestEmitJSONFailure_IncludesPlayability(t *testing.T) {
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe() error = %v", err)
+	}
+	os.Stdout = w
+	defer func() {
+		os.Stdout = oldStdout
+	}()
+
+	emitJSONFailure("jNQXAC9IVRw", &client.LoginRequiredDetailError{Attempts: []client.AttemptDetail{{
+		Client:            "web",
+		Stage:             "playability",
+		PlayabilityStatus: "LOGIN_REQUIRED",
+		PlayabilityReason: "Join this channel to get access to members-only content",
+		LoginRequired:     true,
+	}}}, exitCodeLoginRequired)
+
+	_ = w.Close()
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, r); err != nil {
+		t.Fatalf("io.Copy() error = %v", err)
+	}
+	var payload report.Failure
+	if err := json.Unmarshal(buf.Bytes(), &payload); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, payload=%q", err, buf.String())
+	}
+	if payload.SchemaVersion != report.SchemaVersion {
+		t.Fatalf("schema_version = %d, want %d", payload.SchemaVersion, report.SchemaVersion)
+	}
+	p := payload.Error.Playability
+	if p == nil {
+		t.Fatalf("error.playability missing: %s", buf.String())
+	}
+	if p.Status != "LOGIN_REQUIRED" || !strings.Contains(p.Reason, "members-only") {
+		t.Fatalf("error.playability = %+v", p)
+	}
+}
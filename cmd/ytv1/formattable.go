@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/famomatic/ytv1/client"
+	"github.com/famomatic/ytv1/internal/cli"
+)
+
+// formatColumn renders one -F column. text feeds the pretty and TSV modes and
+// value the JSON mode, where nil marks an unknown value.
+type formatColumn struct {
+	header     string
+	alignRight bool
+	text       func(client.FormatInfo) string
+	value      func(client.FormatInfo) any
+}
+
+var formatColumnsByName = map[string]formatColumn{
+	"itag": {
+		header: "ID", alignRight: true,
+		text:  func(f client.FormatInfo) string { return strconv.Itoa(f.Itag) },
+		value: func(f client.FormatInfo) any { return f.Itag },
+	},
+	"ext": {
+		header: "EXT",
+		text:   func(f client.FormatInfo) string { return mimeExt(f.MimeType) },
+		value:  func(f client.FormatInfo) any { return mimeExt(f.MimeType) },
+	},
+	"res": {
+		header: "RESOLUTION",
+		text:   formatResolution,
+		value:  func(f client.FormatInfo) any { return formatResolution(f) },
+	},
+	"fps": {
+		header: "FPS", alignRight: true,
+		text:  func(f client.FormatInfo) string { return positiveInt(f.FPS) },
+		value: func(f client.FormatInfo) any { return nilIfZero(int64(f.FPS)) },
+	},
+	"tbr": {
+		header: "TBR", alignRight: true,
+		text: func(f client.FormatInfo) string {
+			if f.Bitrate <= 0 {
+				return ""
+			}
+			return strconv.Itoa(f.Bitrate/1000) + "k"
+		},
+		value: func(f client.FormatInfo) any { return nilIfZero(int64(f.Bitrate / 1000)) },
+	},
+	"size": {
+		header: "FILESIZE", alignRight: true,
+		text:  func(f client.FormatInfo) string { return formatByteSize(f.ContentLength) },
+		value: func(f client.FormatInfo) any { return nilIfZero(f.ContentLength) },
+	},
+	"proto": {
+		header: "PROTO",
+		text:   func(f client.FormatInfo) string { return f.Protocol },
+		value:  func(f client.FormatInfo) any { return f.Protocol },
+	},
+	"vcodec": {
+		header: "VCODEC",
+		text:   func(f client.FormatInfo) string { v, _ := formatCodecs(f); return v },
+		value:  func(f client.FormatInfo) any { v, _ := formatCodecs(f); return v },
+	},
+	"acodec": {
+		header: "ACODEC",
+		text:   func(f client.FormatInfo) string { _, a := formatCodecs(f); return a },
+		value:  func(f client.FormatInfo) any { _, a := formatCodecs(f); return a },
+	},
+	"note": {
+		header: "NOTE",
+		text:   formatNote,
+		value:  func(f client.FormatInfo) any { return formatNote(f) },
+	},
+}
+
+// printFormats writes the -F listing in the --format-table mode, limited to
+// --format-columns. Both were validated by cli.ToClientConfig.
+func printFormats(w io.Writer, info *client.VideoInfo, opts cli.Options) error {
+	names, err := cli.ParseFormatColumns(opts.FormatColumns)
+	if err != nil {
+		return err
+	}
+	cols := make([]formatColumn, len(names))
+	for i, name := range names {
+		cols[i] = formatColumnsByName[name]
+	}
+	switch opts.FormatTable {
+	case cli.FormatTableJSON:
+		return writeFormatTableJSON(w, info.Formats, names, cols)
+	case cli.FormatTableTSV:
+		return writeFormatTableTSV(w, info.Formats, names, cols)
+	default:
+		fmt.Fprintf(w, "Title: %s\n", info.Title)
+		return writeFormatTablePretty(w, info.Formats, cols)
+	}
+}
+
+func writeFormatTablePretty(w io.Writer, formats []client.FormatInfo, cols []formatColumn) error {
+	rows := make([][]string, 0, len(formats)+1)
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.header
+	}
+	rows = append(rows, header)
+	for _, f := range formats {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = c.text(f)
+		}
+		rows = append(rows, row)
+	}
+	widths := make([]int, len(cols))
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b strings.Builder
+	writeRow := func(row []string) {
+		b.Reset()
+		for i, cell := range row {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			switch {
+			case cols[i].alignRight:
+				b.WriteString(pad + cell)
+			case i == len(row)-1:
+				// No trailing padding on the last column.
+				b.WriteString(cell)
+			default:
+				b.WriteString(cell + pad)
+			}
+		}
+		fmt.Fprintln(w, b.String())
+	}
+	writeRow(rows[0])
+	rule := make([]string, len(cols))
+	for i := range cols {
+		rule[i] = strings.Repeat("-", widths[i])
+	}
+	writeRow(rule)
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return nil
+}
+
+// writeFormatTableTSV writes a header of column names, then one line per format.
+func writeFormatTableTSV(w io.Writer, formats []client.FormatInfo, names []string, cols []formatColumn) error {
+	if _, err := fmt.Fprintln(w, strings.Join(names, "\t")); err != nil {
+		return err
+	}
+	tsvCell := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	cells := make([]string, len(cols))
+	for _, f := range formats {
+		for i, c := range cols {
+			cells[i] = tsvCell.Replace(c.text(f))
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(cells, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFormatTableJSON writes one object per format keyed by column name.
+// Numbers stay numeric (tbr in kbit/s, size in bytes).
+func writeFormatTableJSON(w io.Writer, formats []client.FormatInfo, names []string, cols []formatColumn) error {
+	out := make([]map[string]any, 0, len(formats))
+	for _, f := range formats {
+		row := make(map[string]any, len(cols))
+		for i, c := range cols {
+			row[names[i]] = c.value(f)
+		}
+		out = append(out, row)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func formatResolution(f client.FormatInfo) string {
+	switch {
+	case f.Width > 0 && f.Height > 0:
+		return fmt.Sprintf("%dx%d", f.Width, f.Height)
+	case f.HasAudio && !f.HasVideo:
+		return "audio only"
+	default:
+		return ""
+	}
+}
+
+// formatCodecs splits the mime "codecs" parameter into video and audio codecs,
+// using "none" for the track kind a format lacks.
+func formatCodecs(f client.FormatInfo) (vcodec, acodec string) {
+	var codecs []string
+	if _, params, err := mime.ParseMediaType(f.MimeType); err == nil {
+		for _, c := range strings.Split(params["codecs"], ",") {
+			if c = strings.TrimSpace(c); c != "" {
+				codecs = append(codecs, c)
+			}
+		}
+	}
+	at := func(i int) string {
+		if i < len(codecs) {
+			return codecs[i]
+		}
+		return ""
+	}
+	switch {
+	case f.HasVideo && f.HasAudio:
+		return at(0), at(1)
+	case f.HasVideo:
+		return at(0), "none"
+	case f.HasAudio:
+		return "none", at(0)
+	default:
+		return at(0), at(1)
+	}
+}
+
+func formatByteSize(n int64) string {
+	if n <= 0 {
+		return ""
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	value := float64(n)
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		value /= unit
+		if value < unit || suffix == "GiB" {
+			return fmt.Sprintf("%.2f%s", value, suffix)
+		}
+	}
+	return ""
+}
+
+func positiveInt(v int) string {
+	if v <= 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+func nilIfZero(v int64) any {
+	if v <= 0 {
+		return nil
+	}
+	return v
+}
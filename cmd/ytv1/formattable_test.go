@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/famomatic/ytv1/client"
+	"github.com/famomatic/ytv1/internal/cli"
+)
+
+var formatTableFixture = &client.VideoInfo{
+	Title: "Me at the zoo",
+	Formats: []client.FormatInfo{
+		{Itag: 18, MimeType: `video/mp4; codecs="avc1.42001E, mp4a.40.2"`, HasVideo: true, HasAudio: true, Width: 640, Height: 360, FPS: 30, Bitrate: 500000, Protocol: "https", ContentLength: 3 << 20},
+		{Itag: 251, MimeType: `audio/webm; codecs="opus"`, HasAudio: true, Bitrate: 160000, Protocol: "https"},
+	},
+}
+
+func TestFormatColumnsAllRenderable(t *testing.T) {
+	for _, name := range cli.FormatColumns {
+		if _, ok := formatColumnsByName[name]; !ok {
+			t.Errorf("column %q has no renderer", name)
+		}
+	}
+}
+
+func TestPrintFormats_PrettyAligned(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printFormats(&buf, formatTableFixture, cli.Options{FormatColumns: "itag,res,size,acodec"}); err != nil {
+		t.Fatalf("printFormats() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	want := []string{
+		"Title: Me at the zoo",
+		" ID  RESOLUTION  FILESIZE  ACODEC",
+		"---  ----------  --------  ---------",
+		" 18  640x360      3.00MiB  mp4a.40.2",
+		"251  audio only            opus",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("pretty table =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPrintFormats_TSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printFormats(&buf, formatTableFixture, cli.Options{FormatTable: cli.FormatTableTSV, FormatColumns: "itag,ext,vcodec,note"}); err != nil {
+		t.Fatalf("printFormats() error = %v", err)
+	}
+	want := "itag\text\tvcodec\tnote\n18\tmp4\tavc1.42001E\tav\n251\twebm\tnone\taudio only\n"
+	if buf.String() != want {
+		t.Fatalf("tsv =\n%q\nwant\n%q", buf.String(), want)
+	}
+}
+
+func TestPrintFormats_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printFormats(&buf, formatTableFixture, cli.Options{FormatTable: cli.FormatTableJSON, FormatColumns: "itag,tbr,size"}); err != nil {
+		t.Fatalf("printFormats() error = %v", err)
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rows); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v\n%s", err, buf.String())
+	}
+	if len(rows) != 2 || rows[0]["itag"] != float64(18) || rows[0]["tbr"] != float64(500) || rows[0]["size"] != float64(3<<20) {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if v, ok := rows[1]["size"]; !ok || v != nil {
+		t.Fatalf("unknown size should be null, got %+v", rows[1])
+	}
+}
@@ -1,15 +1,17 @@
 package main
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,11 +20,26 @@ import (
 	"github.com/famomatic/ytv1/client"
 	"github.com/famomatic/ytv1/internal/cli"
 	"github.com/famomatic/ytv1/internal/playerjs"
+	"github.com/famomatic/ytv1/report"
 )
 
 var verboseLifecyclePrinter *lifecyclePrinter
+
+// cliExtractionEvents receives extraction events the CLI itself produces,
+// such as playlist item scheduling, alongside the client's own; nil when
+// neither --verbose nor --events-ndjson is set.
+var cliExtractionEvents func(client.ExtractionEvent)
 var activeDownloadArchive *downloadArchive
 
+// remoteOutput is the storage an s3://, gs:// or webdav:// -o uploads to;
+// nil for local output.
+var remoteOutput *outputSink
+
+type outputSink struct {
+	sink client.OutputSink
+	key  string // output template below the sink root
+}
+
 const (
 	exitCodeSuccess             = 0
 	exitCodeGenericFailure      = 1
@@ -35,12 +52,65 @@ const (
 	exitCodeDownloadFailed      = 8
 	exitCodeMP3ConfigRequired   = 9
 	exitCodeTranscriptParse     = 10
+	// exitCodePartialSuccess reports a batch where some inputs succeeded and
+	// others failed; an all-failed batch keeps the highest per-input code.
+	exitCodePartialSuccess = 11
 )
 
 func main() {
 	opts := cli.ParseFlags()
 
-	if len(opts.URLs) == 0 {
+	if opts.Command == cli.CommandCleanup {
+		if err := runCleanup(os.Stdout, opts, time.Now()); err != nil {
+			log.Fatalf("cleanup: %v", err)
+		}
+		return
+	}
+	if opts.Command == cli.CommandCompletion {
+		if err := runCompletion(os.Stdout, opts.CompletionShell, flag.CommandLine); err != nil {
+			log.Fatalf("completion: %v", err)
+		}
+		return
+	}
+	if opts.Command == cli.CommandUpdate {
+		if err := runUpdateCommand(opts); err != nil {
+			log.Fatalf("update: %v", err)
+		}
+		return
+	}
+	if opts.Command == cli.CommandArchive {
+		if err := runArchive(os.Stdout, opts); err != nil {
+			log.Fatalf("archive: %v", err)
+		}
+		return
+	}
+	if opts.Command == cli.CommandReportBug {
+		if err := runReportBug(os.Stdout, opts, time.Now()); err != nil {
+			log.Fatalf("report-bug: %v", err)
+		}
+		return
+	}
+	if opts.Capabilities && opts.Command == "" {
+		cfg, err := cli.ToClientConfig(opts)
+		if err != nil {
+			log.Fatalf("Failed to initialize config: %v", err)
+		}
+		if err := emitCapabilities(os.Stdout, client.New(cfg)); err != nil {
+			log.Fatalf("capabilities: %v", err)
+		}
+		return
+	}
+	if opts.CheckConfig {
+		cfg, err := cli.ToClientConfig(opts)
+		if err != nil {
+			log.Fatalf("check-config: %v", err)
+		}
+		if !checkConfig(os.Stdout, cfg) {
+			os.Exit(exitCodeInvalidInput)
+		}
+		return
+	}
+	if len(opts.URLs) == 0 && opts.Command != cli.CommandSync {
 		fmt.Println("Usage: ytv1 [OPTIONS] URL [URL...]")
 		// We don't exit 1 if help or version was requested, but ParseFlags handles Help auto-exit.
 		// If explicit Help flag wasn't handled by standard flag package (it usually is), we might descend here.
@@ -53,11 +123,21 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to initialize config: %v", err)
 	}
-	if strings.TrimSpace(opts.DownloadArchive) != "" {
+	var lock *client.WorkspaceLock
+	if paths := workspaceLockPaths(opts); len(paths) > 0 {
+		if lock, err = client.AcquireWorkspaceLock(paths...); err != nil {
+			log.Fatalf("%v (pass --no-lock to run anyway)", err)
+		}
+		defer lock.Release()
+	}
+	if strings.TrimSpace(opts.DownloadArchive) != "" && !(opts.Simulate && !fileExists(opts.DownloadArchive)) {
 		archive, err := newDownloadArchive(opts.DownloadArchive)
 		if err != nil {
 			log.Fatalf("Failed to initialize download archive: %v", err)
 		}
+		if opts.ArchiveFormat != "" {
+			archive.format = opts.ArchiveFormat
+		}
 		activeDownloadArchive = archive
 		defer func() {
 			if err := archive.Close(); err != nil {
@@ -67,10 +147,50 @@ func main() {
 	}
 	attachLifecycleHandlers(&cfg, opts)
 	c := client.New(cfg)
+	if client.IsRemoteOutput(opts.OutputTemplate) {
+		s, key, err := c.OpenOutputSink(opts.OutputTemplate)
+		if err != nil {
+			log.Fatalf("Failed to open output sink: %v", err)
+		}
+		remoteOutput = &outputSink{sink: s, key: key}
+	}
 	ctx := context.Background()
-	exitCode := processInputsWithExitCode(ctx, c, opts.URLs, opts, processURL)
-	if exitCode != exitCodeSuccess {
-		os.Exit(exitCode)
+	var run report.Run
+	if opts.Command == cli.CommandSync {
+		run, err = runSync(ctx, c, opts)
+		if err != nil {
+			log.Fatalf("sync: %v", err)
+		}
+	} else {
+		run = runInputs(ctx, c, opts.URLs, opts, processURL)
+	}
+	if opts.CookiesWriteBack {
+		if err := saveCookieJar(opts.CookiesFile, cfg.CookieJar); err != nil {
+			warnf(opts, "failed to write cookies back to %s: %v", opts.CookiesFile, err)
+		}
+	}
+	usage := c.BandwidthUsage()
+	run.BytesReceived, run.MaxTotalBytes = usage.Bytes, usage.Limit
+	if len(run.Items) > 1 && !opts.PrintJSON {
+		printRunSummary(os.Stdout, run)
+	}
+	if opts.Verbose {
+		printInnertubeUsage(os.Stderr, c.InnertubeUsage())
+		printBandwidthUsage(os.Stderr, usage)
+	}
+	if path := strings.TrimSpace(opts.WriteReport); path != "" {
+		if err := writeRunReport(path, run); err != nil {
+			log.Printf("Failed to write report %s: %v", path, err)
+			if run.ExitCode == exitCodeSuccess {
+				run.ExitCode = exitCodeGenericFailure
+			}
+		}
+	}
+	if run.ExitCode != exitCodeSuccess {
+		// os.Exit skips deferred calls; a leftover lock would hold off the
+		// next run until it goes stale.
+		lock.Release()
+		os.Exit(run.ExitCode)
 	}
 }
 
@@ -91,49 +211,232 @@ func processInputsWithExitCode(
 	opts cli.Options,
 	processor func(context.Context, *client.Client, string, cli.Options) error,
 ) int {
-	exitCode := exitCodeSuccess
+	return runInputs(ctx, c, urls, opts, processor).ExitCode
+}
+
+func runInputs(
+	ctx context.Context,
+	c *client.Client,
+	urls []string,
+	opts cli.Options,
+	processor func(context.Context, *client.Client, string, cli.Options) error,
+) report.Run {
+	run := report.Run{SchemaVersion: report.SchemaVersion, Total: len(urls), Items: make([]report.RunItem, 0, len(urls))}
+	highest := exitCodeSuccess
 	for _, url := range urls {
-		if err := processor(ctx, c, url, opts); err != nil {
-			code := classifyExitCode(err)
-			if code > exitCode {
-				exitCode = code
-			}
-			if opts.PrintJSON {
-				emitJSONFailure(url, err, code)
-			} else {
-				log.Printf("Error processing %s: %v", url, err)
-			}
-			if (opts.OverrideDiagnostics || opts.Verbose) && !opts.PrintJSON {
-				printAttemptDiagnostics(err)
-			}
-			if opts.AbortOnError {
-				break
+		if run.Aborted {
+			run.Skipped++
+			run.Items = append(run.Items, report.RunItem{Input: url, Status: report.StatusSkipped})
+			continue
+		}
+		err := processor(ctx, c, url, opts)
+		if err == nil {
+			run.Succeeded++
+			run.Items = append(run.Items, report.RunItem{Input: url, Status: report.StatusOK})
+			continue
+		}
+		code := classifyExitCode(err)
+		if code > highest {
+			highest = code
+		}
+		category := string(client.ClassifyError(err))
+		run.Failed++
+		if run.Categories == nil {
+			run.Categories = make(map[string]int)
+		}
+		run.Categories[category]++
+		run.Items = append(run.Items, report.RunItem{
+			Input:    url,
+			Status:   report.StatusFailed,
+			Category: category,
+			ExitCode: code,
+			Error:    err.Error(),
+		})
+		if opts.PrintJSON {
+			emitJSONFailure(url, err, code)
+		} else {
+			log.Printf("Error processing %s: %v", url, err)
+			for _, line := range playabilityLines(err) {
+				log.Printf("  %s", line)
 			}
 		}
+		if (opts.OverrideDiagnostics || opts.Verbose) && !opts.PrintJSON {
+			printAttemptDiagnostics(err)
+		}
+		// Every later input would fail the same way once the budget is spent.
+		if opts.AbortOnError || errors.Is(err, client.ErrMaxTotalBytesExceeded) {
+			run.Aborted = true
+		}
+	}
+	run.ExitCode = highest
+	if run.Failed > 0 && run.Succeeded > 0 {
+		run.ExitCode = exitCodePartialSuccess
+	}
+	return run
+}
+
+// printRunSummary writes per-input status lines followed by totals and
+// failure counts per error category.
+func printRunSummary(w io.Writer, run report.Run) {
+	fmt.Fprintln(w, "Run summary:")
+	for _, item := range run.Items {
+		line := fmt.Sprintf("  [%s] %s", item.Status, item.Input)
+		if item.Status == report.StatusFailed {
+			line += fmt.Sprintf(" category=%s exit=%d", item.Category, item.ExitCode)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "total=%d succeeded=%d failed=%d skipped=%d exit=%d\n",
+		run.Total, run.Succeeded, run.Failed, run.Skipped, run.ExitCode)
+	fmt.Fprintln(w, formatBandwidth(run.BytesReceived, run.MaxTotalBytes))
+	categories := make([]string, 0, len(run.Categories))
+	for category := range run.Categories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		fmt.Fprintf(w, "  %s=%d\n", category, run.Categories[category])
+	}
+}
+
+// printInnertubeUsage writes one line per Innertube client with its request
+// volume against --innertube-rate-limit.
+func printInnertubeUsage(w io.Writer, usage []client.InnertubeUsage) {
+	for _, u := range usage {
+		limit := "unlimited"
+		if u.Limit > 0 {
+			limit = strconv.Itoa(u.Limit) + "/h"
+		}
+		fmt.Fprintf(w, "[innertube] client=%s requests=%d last_hour=%d limit=%s delayed=%d rejected=%d\n",
+			u.Client, u.Requests, u.LastHour, limit, u.Delayed, u.Rejected)
+	}
+}
+
+// printBandwidthUsage writes the bytes received against --max-total-bytes.
+func printBandwidthUsage(w io.Writer, usage client.BandwidthUsage) {
+	fmt.Fprintf(w, "[bandwidth] %s\n", formatBandwidth(usage.Bytes, usage.Limit))
+}
+
+func formatBandwidth(received, limit int64) string {
+	if limit <= 0 {
+		return fmt.Sprintf("bytes_received=%d limit=unlimited", received)
+	}
+	return fmt.Sprintf("bytes_received=%d limit=%d", received, limit)
+}
+
+func writeRunReport(path string, run report.Run) error {
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
 	}
-	return exitCode
+	return os.WriteFile(path, append(data, '\n'), 0644)
 }
 
 func attachLifecycleHandlers(cfg *client.Config, opts cli.Options) {
-	if !opts.Verbose {
-		return
+	var onExtraction []func(client.ExtractionEvent)
+	var onDownload []func(client.DownloadEvent)
+	var onDrift []func(client.SchemaDrift)
+	if opts.Verbose {
+		lp := newLifecyclePrinter(time.Now)
+		verboseLifecyclePrinter = lp
+		onExtraction = append(onExtraction, func(evt client.ExtractionEvent) {
+			fmt.Println(lp.formatExtractionEvent(evt))
+		})
+		onDownload = append(onDownload, func(evt client.DownloadEvent) {
+			fmt.Println(lp.formatDownloadEvent(evt))
+		})
+		onDrift = append(onDrift, func(d client.SchemaDrift) {
+			fmt.Fprintln(os.Stderr, formatSchemaDrift(d))
+		})
+	}
+	if opts.EventsNDJSON {
+		ew := newEventWriter(os.Stderr, time.Now)
+		onExtraction = append(onExtraction, ew.extraction)
+		onDownload = append(onDownload, ew.download)
+		onDrift = append(onDrift, ew.schemaDrift)
 	}
-	lp := newLifecyclePrinter(time.Now)
-	verboseLifecyclePrinter = lp
-	cfg.OnExtractionEvent = func(evt client.ExtractionEvent) {
-		fmt.Println(lp.formatExtractionEvent(evt))
+	if len(onExtraction) > 0 {
+		cfg.OnExtractionEvent = func(evt client.ExtractionEvent) {
+			for _, fn := range onExtraction {
+				fn(evt)
+			}
+		}
+		cliExtractionEvents = cfg.OnExtractionEvent
 	}
-	cfg.OnDownloadEvent = func(evt client.DownloadEvent) {
-		fmt.Println(lp.formatDownloadEvent(evt))
+	if len(onDownload) > 0 {
+		cfg.OnDownloadEvent = func(evt client.DownloadEvent) {
+			for _, fn := range onDownload {
+				fn(evt)
+			}
+		}
+	}
+	if len(onDrift) > 0 {
+		cfg.OnSchemaDrift = func(d client.SchemaDrift) {
+			for _, fn := range onDrift {
+				fn(d)
+			}
+		}
+	}
+	if cfg.RateLimitCooldown.Threshold > 0 {
+		cfg.OnRateLimitState = func(s client.RateLimitState) {
+			warnf(opts, "%s", formatRateLimitState(s))
+		}
 	}
 }
 
+// formatRateLimitState renders a cool-down state change.
+func formatRateLimitState(s client.RateLimitState) string {
+	if !s.CoolingDown {
+		return "rate limiting cleared, resuming normal pace"
+	}
+	return fmt.Sprintf("HTTP %d responses: pausing new extractions until %s (cool-down #%d)",
+		s.LastStatus, s.Until.Local().Format(time.TimeOnly), s.Level)
+}
+
+// formatSchemaDrift renders a drift finding for verbose output.
+func formatSchemaDrift(d client.SchemaDrift) string {
+	return fmt.Sprintf("[schema] %s at %s: %q (client=%s video=%s)", d.Kind, d.Path, d.Value, d.Client, d.VideoID)
+}
+
+// eventWriter serializes lifecycle events as report.Event NDJSON lines.
+// Client callbacks may fire from concurrent download workers.
+type eventWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	now func() time.Time
+}
+
+func newEventWriter(w io.Writer, now func() time.Time) *eventWriter {
+	return &eventWriter{enc: json.NewEncoder(w), now: now}
+}
+
+func (w *eventWriter) extraction(evt client.ExtractionEvent) {
+	w.write(report.NewExtractionEvent(w.now(), evt))
+}
+
+func (w *eventWriter) download(evt client.DownloadEvent) {
+	w.write(report.NewDownloadEvent(w.now(), evt))
+}
+
+func (w *eventWriter) schemaDrift(d client.SchemaDrift) {
+	w.write(report.NewSchemaDriftEvent(w.now(), d))
+}
+
+func (w *eventWriter) write(evt report.Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_ = w.enc.Encode(evt)
+}
+
 func processURL(ctx context.Context, c *client.Client, url string, opts cli.Options) error {
 	totalStart := time.Now()
 	// 1. Check if it is a playlist
 	// For now, treat everything as video unless we want to support playlists explicitly here
 	// client.GetVideo handles video IDs.
 	// Check prompt for playlist ID extraction
+	if client.IsFeedURL(url) {
+		return processFeed(ctx, c, url, opts)
+	}
 	if !opts.NoPlaylist {
 		if playlistID, err := client.ExtractPlaylistID(url); err == nil && playlistID != "" {
 			return processPlaylist(ctx, c, playlistID, opts)
@@ -146,12 +449,42 @@ func processURL(ctx context.Context, c *client.Client, url string, opts cli.Opti
 	if shouldSkipDownloadByArchive(url) {
 		return nil
 	}
+	if shouldSkipFailedVideo(url, opts) {
+		return nil
+	}
+	if remoteOutput != nil && !opts.Simulate {
+		// Sidecars (subtitles, thumbnail, .nfo, debug report) are written
+		// to a staging directory laid out like the sink, then uploaded.
+		stage, err := os.MkdirTemp("", "ytv1-sidecars-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(stage)
+		// ctx is replaced by the per-video timeout below, which is
+		// cancelled before this runs.
+		uploadCtx := ctx
+		defer func() {
+			if err := uploadStagedSidecars(uploadCtx, remoteOutput.sink, stage); err != nil {
+				warnf(opts, "%v", err)
+			}
+		}()
+		opts.OutputTemplate = filepath.Join(stage, filepath.FromSlash(remoteOutput.key))
+	}
 
-	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	baseCtx := ctx
+	ctx, cancel := context.WithTimeout(baseCtx, 10*time.Minute)
 	defer cancel()
 
+	getVideo := c.GetVideo
+	if metadataOnlyRun(opts) {
+		getVideo = c.GetVideoMetadata
+	}
 	extractStart := time.Now()
-	info, err := c.GetVideo(ctx, url)
+	info, err := getVideo(ctx, url)
+	if opts.WriteDebugReport {
+		writeDebugReport(ctx, c, url, info, opts)
+	}
+	recordVideoAttempt(url, err, opts)
 	if err != nil {
 		if opts.Verbose {
 			fmt.Println(formatExtractionEvent(client.ExtractionEvent{
@@ -173,13 +506,55 @@ func processURL(ctx context.Context, c *client.Client, url string, opts cli.Opti
 		}))
 	}
 
+	if info.LiveReplay == client.LiveReplayProcessing {
+		if !opts.WaitForProcessing {
+			fmt.Printf("Replay of %s is still processing; formats may be low resolution or incomplete (pass --wait-for-processing to wait)\n", info.ID)
+		} else {
+			// The wait can outlast the per-video timeout, which restarts once
+			// the processed formats are listed.
+			info, err = waitForProcessedReplay(baseCtx, info, opts.ProcessingPoll, func(ctx context.Context) (*client.VideoInfo, error) {
+				return getVideo(ctx, url)
+			})
+			if err != nil {
+				return err
+			}
+			cancel()
+			var cancelProcessed context.CancelFunc
+			ctx, cancelProcessed = context.WithTimeout(baseCtx, 10*time.Minute)
+			defer cancelProcessed()
+		}
+	}
+
+	if info.Extractor != "" && activeDownloadArchive.Has(downloadArchiveKey(info)) {
+		// Extractor inputs have no ID until extracted.
+		fmt.Printf("Skipping (in archive): %s\n", downloadArchiveKey(info))
+		return nil
+	}
+
+	if dates := dateRange(opts); !dates.Contains(uploadDate(info)) {
+		fmt.Printf("Skipping %s [%s]: upload date outside --dateafter/--datebefore\n", info.Title, info.ID)
+		return nil
+	}
+
 	if opts.PrintJSON || opts.DumpSingleJSON {
 		return emitDumpSingleJSON(os.Stdout, url, info)
 	}
 
+	if opts.VerboseSelector {
+		expr, trace, err := c.ExplainSelection(ctx, url, buildDownloadOptions(opts))
+		writeSelectorTrace(os.Stdout, expr, trace, err)
+	}
+
+	if opts.GetURL {
+		streams, err := c.ResolveDownloadURLs(ctx, url, buildDownloadOptions(opts))
+		if err != nil {
+			return err
+		}
+		return writeResolvedStreams(os.Stdout, streams, opts.ReferrerHeaders)
+	}
+
 	if opts.ListFormats {
-		printFormats(info)
-		return nil // yt-dlp stops after listing formats
+		return printFormats(os.Stdout, info, opts) // yt-dlp stops after listing formats
 	}
 
 	if opts.WriteSubs || opts.WriteAutoSubs {
@@ -187,60 +562,317 @@ func processURL(ctx context.Context, c *client.Client, url string, opts cli.Opti
 			return err
 		}
 	}
+	if opts.WriteThumbnail {
+		if err := writeRequestedThumbnail(ctx, c, info, opts); err != nil {
+			return err
+		}
+	}
+	if opts.WriteNFO {
+		if err := writeRequestedNFO(info, opts); err != nil {
+			return err
+		}
+	}
 
 	if opts.SkipDownload {
 		fmt.Printf("Skipping download for %s\n", info.Title)
 		return nil
 	}
 
+	downloadOpts := buildDownloadOptions(opts)
+	if opts.Simulate {
+		downloadOpts.Simulate = true
+		res, err := c.Download(ctx, url, downloadOpts)
+		if err != nil {
+			return err
+		}
+		writeSimulatedDownload(os.Stdout, info, res)
+		return nil
+	}
+
+	if info.IsUpcoming && len(info.Formats) == 0 {
+		fmt.Println(describeUpcoming(info, opts.DownloadTrailer))
+	}
 	fmt.Printf("Downloading: %s [%s]\n", info.Title, info.ID)
-	res, err := c.Download(ctx, url, buildDownloadOptions(opts))
+	res, err := downloadWithFullRetries(ctx, opts.FullRetries, fullRetryBackoff(opts),
+		func(ctx context.Context) (*client.DownloadResult, error) {
+			return c.Download(ctx, url, downloadOpts)
+		},
+		func(ctx context.Context, attempt int, cause error) error {
+			fmt.Printf("Retrying download of %s (%d/%d) after: %v\n", info.ID, attempt, opts.FullRetries, cause)
+			// GetVideo replaces the cached session, so the next Download
+			// resolves fresh stream URLs and resumes any partial output.
+			_, err := c.GetVideo(ctx, url)
+			return err
+		},
+	)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Downloaded to: %s\n", res.OutputPath)
+	fmt.Printf("Downloaded to: %s\n", outputLocation(res.OutputPath))
+	if res.SHA256 != "" {
+		fmt.Printf("SHA-256: %s (%s)\n", res.SHA256, outputLocation(res.OutputPath+client.ChecksumSuffix))
+	}
 	if opts.Verbose && verboseLifecyclePrinter != nil {
 		timing := verboseLifecyclePrinter.popVideoTiming(info.ID)
-		videoMs := timing.downloadVideoMs
-		audioMs := timing.downloadAudioMs
-		if videoMs == 0 && audioMs == 0 && timing.downloadSingleMs > 0 {
-			videoMs = timing.downloadSingleMs
-		}
-		downloadTotalMs := videoMs + audioMs
-		avgSpeed := "0B/s"
-		if downloadTotalMs > 0 {
-			bps := int64(float64(res.Bytes) / (float64(downloadTotalMs) / 1000.0))
-			avgSpeed = fmt.Sprintf("%dB/s", bps)
-		}
-		fmt.Printf(
-			"total_elapsed_ms=%d extract_ms=%d download_ms(video/audio)=%d/%d merge_ms=%d final_size=%d avg_speed=%s\n",
-			time.Since(totalStart).Milliseconds(),
-			extractMs,
-			videoMs,
-			audioMs,
-			timing.mergeMs,
-			res.Bytes,
-			avgSpeed,
-		)
-	}
-	if err := recordCompletedDownload(info.ID); err != nil {
+		fmt.Println(formatDownloadSummary(res, time.Since(totalStart).Milliseconds(), extractMs, timing.mergeMs))
+		writeDownloadAudit(os.Stdout, res)
+	}
+	if res.VideoID != info.ID {
+		// Only the trailer was fetched; the video itself is still pending.
+		return nil
+	}
+	if err := recordCompletedDownload(downloadArchiveKey(info)); err != nil {
 		return err
 	}
 	return nil
 }
 
+// outputLocation is where a download result path lives: a URL in the
+// remote output sink, or the local path itself.
+func outputLocation(path string) string {
+	if remoteOutput == nil {
+		return path
+	}
+	return remoteOutput.sink.URL(path)
+}
+
+// uploadStagedSidecars uploads every file under stage to s, keyed by its
+// path relative to stage.
+func uploadStagedSidecars(ctx context.Context, s client.OutputSink, stage string) error {
+	return filepath.WalkDir(stage, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(stage, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		key := filepath.ToSlash(rel)
+		if err := s.Upload(ctx, key, f); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", s.URL(key), err)
+		}
+		fmt.Printf("Uploaded: %s\n", s.URL(key))
+		return nil
+	})
+}
+
+// waitForProcessedReplay re-extracts a live replay that is still processing
+// every interval, via fetch, until YouTube lists its processed formats.
+func waitForProcessedReplay(ctx context.Context, info *client.VideoInfo, interval time.Duration, fetch func(context.Context) (*client.VideoInfo, error)) (*client.VideoInfo, error) {
+	for info.LiveReplay == client.LiveReplayProcessing {
+		fmt.Printf("Replay of %s is still processing; checking again in %s\n", info.ID, interval)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		next, err := fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		info = next
+	}
+	return info, nil
+}
+
+// describeUpcoming explains what happens to a video that has not started.
+func describeUpcoming(info *client.VideoInfo, downloadTrailer bool) string {
+	kind := "Live stream"
+	if info.IsPremiere {
+		kind = "Premiere"
+	}
+	msg := fmt.Sprintf("%s %s has not started yet", kind, info.ID)
+	if !info.ScheduledStartTime.IsZero() {
+		msg += " (scheduled for " + info.ScheduledStartTime.Local().Format(time.RFC1123) + ")"
+	}
+	switch {
+	case info.TrailerVideoID == "":
+		return msg + "; no trailer is available"
+	case downloadTrailer:
+		return msg + "; downloading trailer " + info.TrailerVideoID
+	default:
+		return msg + "; pass --download-trailer to fetch trailer " + info.TrailerVideoID
+	}
+}
+
+const (
+	defaultFullRetryBackoff = 2 * time.Second
+	maxFullRetryBackoff     = 30 * time.Second
+)
+
+func fullRetryBackoff(opts cli.Options) time.Duration {
+	if opts.RetrySleepMS >= 0 {
+		return time.Duration(opts.RetrySleepMS) * time.Millisecond
+	}
+	return defaultFullRetryBackoff
+}
+
+// downloadWithFullRetries runs download and, while it fails with a download
+// failure category, waits with doubling backoff, calls reextract and tries
+// again, up to retries extra times. Extraction, selection and size-limit
+// failures are returned immediately since a fresh URL cannot fix them.
+func downloadWithFullRetries(
+	ctx context.Context,
+	retries int,
+	backoff time.Duration,
+	download func(context.Context) (*client.DownloadResult, error),
+	reextract func(ctx context.Context, attempt int, cause error) error,
+) (*client.DownloadResult, error) {
+	res, err := download(ctx)
+	for attempt := 1; attempt <= retries && err != nil && isFullRetryable(ctx, err); attempt++ {
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxFullRetryBackoff {
+			backoff = maxFullRetryBackoff
+		}
+		if reErr := reextract(ctx, attempt, err); reErr != nil {
+			return nil, reErr
+		}
+		res, err = download(ctx)
+	}
+	return res, err
+}
+
+func isFullRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return client.ClassifyError(err) == client.ErrorCategoryDownloadFailed
+}
+
+// writeSimulatedDownload prints the planned output of a --simulate run.
+func writeSimulatedDownload(w io.Writer, info *client.VideoInfo, res *client.DownloadResult) {
+	fmt.Fprintf(w, "[simulate] %s [%s] -> %s\n", info.Title, info.ID, res.OutputPath)
+	for _, s := range res.Streams {
+		if s.Path == res.OutputPath {
+			fmt.Fprintf(w, "[simulate]   itag=%d proto=%s\n", s.Itag, s.Protocol)
+			continue
+		}
+		fmt.Fprintf(w, "[simulate]   itag=%d proto=%s part=%s\n", s.Itag, s.Protocol, s.Path)
+	}
+	if res.FallbackReason != "" {
+		fmt.Fprintf(w, "[simulate]   fallback=%s\n", res.FallbackReason)
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(strings.TrimSpace(path))
+	return err == nil
+}
+
+// workspaceLockPaths returns the lock files guarding a run that writes
+// downloads: --lock-file, or one next to the download archive and one in the
+// output template's directory. Runs that write nothing take no lock.
+func workspaceLockPaths(opts cli.Options) []string {
+	if opts.NoLock || opts.Simulate || opts.ListFormats || opts.GetURL {
+		return nil
+	}
+	if path := strings.TrimSpace(opts.LockFile); path != "" {
+		return []string{path}
+	}
+	var paths []string
+	if archive := strings.TrimSpace(opts.DownloadArchive); archive != "" {
+		paths = append(paths, archive+client.WorkspaceLockName)
+	}
+	if client.IsRemoteOutput(opts.OutputTemplate) {
+		return paths
+	}
+	dir := strings.TrimSpace(opts.OutputTemplate)
+	if i := strings.Index(dir, "%("); i >= 0 {
+		dir = dir[:i]
+	}
+	dir = filepath.Dir(dir)
+	// The lock file needs the directory the download would create anyway.
+	_ = os.MkdirAll(dir, 0o755)
+	return append(paths, filepath.Join(dir, client.WorkspaceLockName))
+}
+
+// formatDownloadSummary renders the verbose one-line summary. Download times
+// and speed come from the result's stream measurements; merge time is only
+// known from lifecycle events.
+func formatDownloadSummary(res *client.DownloadResult, totalMs, extractMs, mergeMs int64) string {
+	var videoMs, audioMs int64
+	for i, s := range res.Streams {
+		// A lone stream counts as video, as single-file downloads always did.
+		audioPart := len(res.Streams) > 1 && i < len(res.SelectedFormats) &&
+			res.SelectedFormats[i].HasAudio && !res.SelectedFormats[i].HasVideo
+		if audioPart {
+			audioMs += s.Elapsed.Milliseconds()
+		} else {
+			videoMs += s.Elapsed.Milliseconds()
+		}
+	}
+	return fmt.Sprintf(
+		"total_elapsed_ms=%d extract_ms=%d download_ms(video/audio)=%d/%d merge_ms=%d final_size=%d avg_speed=%dB/s",
+		totalMs,
+		extractMs,
+		videoMs,
+		audioMs,
+		mergeMs,
+		res.Bytes,
+		res.BytesPerSecond,
+	)
+}
+
+// writeDownloadAudit prints what Download actually fetched, one line per stream.
+func writeDownloadAudit(w io.Writer, res *client.DownloadResult) {
+	for _, s := range res.Streams {
+		line := fmt.Sprintf("stream itag=%d proto=%s host=%s bytes=%d retries=%d", s.Itag, s.Protocol, s.URLHost, s.Bytes, s.Retries)
+		if s.Elapsed > 0 {
+			line += fmt.Sprintf(" transferred=%d elapsed_ms=%d speed=%dB/s", s.Transferred, s.Elapsed.Milliseconds(), s.BytesPerSecond)
+		}
+		fmt.Fprintln(w, line)
+	}
+	if res.FallbackReason != "" {
+		fmt.Fprintf(w, "fallback=%s\n", res.FallbackReason)
+	}
+}
+
 func buildDownloadOptions(opts cli.Options) client.DownloadOptions {
 	downloadOpts := client.DownloadOptions{
-		Mode:        client.SelectionModeBest,
-		OutputPath:  opts.OutputTemplate, // Client handles templating slightly different, usually expects strict path or ""
-		MergeOutput: true,                // Always try to merge on 'best'
-		Resume:      !opts.NoContinue,
+		Mode:          client.SelectionModeBest,
+		OutputPath:    opts.OutputTemplate, // Client handles templating slightly different, usually expects strict path or ""
+		MergeOutput:   true,                // Always try to merge on 'best'
+		Resume:        !opts.NoContinue,
+		CheckFormats:  opts.CheckFormats,
+		MaxHeight:     opts.MaxResolution,
+		Trailer:       opts.DownloadTrailer,
+		LiveOffset:    opts.LiveOffset,
+		SetMtime:      !opts.NoMtime,
+		WriteChecksum: opts.WriteChecksums,
+	}
+	if opts.ValidateOutput {
+		downloadOpts.ValidateOutput = true
+		downloadOpts.OutputDurationTolerance = opts.ValidateTolerance
+	}
+	if strings.TrimSpace(opts.MaxFileSize) != "" {
+		// Validated by cli.ToClientConfig before downloads start.
+		downloadOpts.MaxFileSize, _ = cli.ParseByteSize(opts.MaxFileSize)
+	}
+	if opts.ExtractAudio {
+		downloadOpts.ExtractAudio = opts.AudioFormat
+	}
+	if remoteOutput != nil {
+		downloadOpts.Sink = remoteOutput.sink
+		downloadOpts.OutputPath = remoteOutput.key
 	}
 
 	raw := strings.TrimSpace(opts.FormatSelector)
 	lower := strings.ToLower(raw)
 	switch lower {
 	case "", "best":
+		if opts.ExtractAudio {
+			// Like yt-dlp -x: no point fetching video only to drop it.
+			downloadOpts.Mode = client.SelectionModeAudioOnly
+		}
 		return downloadOpts
 	case "bestvideo+bestaudio":
 		downloadOpts.FormatSelector = "bestvideo+bestaudio/best"
@@ -268,34 +900,206 @@ func buildDownloadOptions(opts cli.Options) client.DownloadOptions {
 	return downloadOpts
 }
 
+// writeResolvedStreams prints one URL per selected stream (video before audio).
+// With headers enabled, the shared media request headers follow as "Name: value" lines.
+// A forwarded account Cookie is left out: stdout ends up in shell history and logs.
+func writeResolvedStreams(w io.Writer, streams []client.ResolvedStream, headers bool) error {
+	for _, s := range streams {
+		if _, err := fmt.Fprintln(w, s.URL); err != nil {
+			return err
+		}
+	}
+	if !headers || len(streams) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(streams[0].Headers))
+	for name := range streams[0].Headers {
+		if strings.EqualFold(name, "Cookie") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", name, streams[0].Headers.Get(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func processPlaylist(ctx context.Context, c *client.Client, playlistID string, opts cli.Options) error {
 	fmt.Printf("Fetching playlist: %s\n", playlistID)
-	playlist, err := c.GetPlaylist(ctx, playlistID)
+	playlist, err := c.GetPlaylistWithOptions(ctx, playlistID, playlistOptions(opts))
 	if err != nil {
 		return err
 	}
 	fmt.Printf("Playlist: %s (%d videos)\n", playlist.Title, len(playlist.Items))
+	if playlist.FromCache {
+		fmt.Println("Playlist items loaded from cache")
+	}
+	if playlist.ContinuationStats.StoppedByKnown {
+		fmt.Println("Playlist paging stopped at an archived video (--playlist-incremental)")
+	}
 	if opts.FlatPlaylist {
 		return emitFlatPlaylist(playlist.Items, opts, os.Stdout)
 	}
 
-	summary, failures := runPlaylistItems(ctx, c, playlist.Items, opts, processURL)
+	items := orderPlaylistItems(ctx, c, playlist.Items, opts)
+	summary, failures := runPlaylistItems(ctx, c, items, opts, processURL)
+	return finishPlaylistRun("Playlist", summary, failures)
+}
+
+// orderPlaylistItems reorders items for --playlist-order so that partial runs
+// get the most wanted videos first. Upload dates and view counts are not in
+// playlist listings, so each item's metadata is fetched first (durations
+// only for items the listing left without one). Items whose metadata cannot
+// be fetched keep their relative order after the others.
+func orderPlaylistItems(ctx context.Context, c *client.Client, items []client.PlaylistItem, opts cli.Options) []client.PlaylistItem {
+	if opts.PlaylistOrder == "" || len(items) < 2 {
+		return items
+	}
+	fmt.Printf("Fetching metadata of %d items for --playlist-order %s\n", len(items), opts.PlaylistOrder)
+	infos := make(map[string]*client.VideoInfo, len(items))
+	for _, item := range items {
+		if opts.PlaylistOrder == cli.PlaylistOrderShortest && item.DurationSec > 0 {
+			infos[item.VideoID] = &client.VideoInfo{ID: item.VideoID, DurationSec: item.DurationSec}
+			continue
+		}
+		info, err := c.GetVideo(ctx, item.VideoID)
+		if err != nil {
+			warnf(opts, "--playlist-order: metadata for %s unavailable, ordering it last: %v", item.VideoID, err)
+			continue
+		}
+		infos[item.VideoID] = info
+	}
+	return sortPlaylistItems(items, infos, opts.PlaylistOrder)
+}
+
+// sortPlaylistItems returns items stably sorted by order using infos. Items
+// without an info, or without the key order needs, sort last.
+func sortPlaylistItems(items []client.PlaylistItem, infos map[string]*client.VideoInfo, order string) []client.PlaylistItem {
+	type keyed struct {
+		item client.PlaylistItem
+		key  int64
+		ok   bool
+	}
+	keys := make([]keyed, len(items))
+	for i, item := range items {
+		keys[i].item = item
+		info := infos[item.VideoID]
+		if info == nil {
+			continue
+		}
+		switch order {
+		case cli.PlaylistOrderNewest, cli.PlaylistOrderOldest:
+			if t := uploadDate(info); !t.IsZero() {
+				keys[i].key, keys[i].ok = t.Unix(), true
+			}
+		case cli.PlaylistOrderShortest:
+			keys[i].key, keys[i].ok = info.DurationSec, info.DurationSec > 0
+		case cli.PlaylistOrderViews:
+			keys[i].key, keys[i].ok = info.ViewCount, true
+		}
+	}
+	sort.SliceStable(keys, func(a, b int) bool {
+		ka, kb := keys[a], keys[b]
+		if ka.ok != kb.ok {
+			return ka.ok
+		}
+		switch order {
+		case cli.PlaylistOrderOldest, cli.PlaylistOrderShortest:
+			return ka.key < kb.key
+		default:
+			return ka.key > kb.key
+		}
+	})
+	out := make([]client.PlaylistItem, len(keys))
+	for i, k := range keys {
+		out[i] = k.item
+	}
+	return out
+}
+
+func finishPlaylistRun(label string, summary playlistRunSummary, failures []playlistItemFailure) error {
 	fmt.Printf(
-		"Playlist summary: total=%d succeeded=%d failed=%d aborted=%t\n",
+		"%s summary: total=%d succeeded=%d failed=%d skipped=%d aborted=%t\n",
+		label,
 		summary.Total,
 		summary.Succeeded,
 		summary.Failed,
+		summary.Skipped,
 		summary.Aborted,
 	)
 	if len(failures) > 0 {
 		for _, failure := range failures {
 			log.Printf("Failed to process %s: %v", failure.VideoID, failure.Err)
 		}
-		return fmt.Errorf("playlist completed with failures: failed=%d/%d", summary.Failed, summary.Total)
+		return fmt.Errorf("%s completed with failures: failed=%d/%d", strings.ToLower(label), summary.Failed, summary.Total)
 	}
 	return nil
 }
 
+// processFeed runs a YouTube RSS/Atom feed like a playlist. Entries outside
+// --dateafter/--datebefore are dropped by their published date before any
+// extraction.
+func processFeed(ctx context.Context, c *client.Client, feedURL string, opts cli.Options) error {
+	fmt.Printf("Fetching feed: %s\n", feedURL)
+	feed, err := c.GetFeed(ctx, feedURL)
+	if err != nil {
+		return err
+	}
+	items := feedPlaylistItems(feed.Entries, dateRange(opts))
+	fmt.Printf("Feed: %s (%d entries, %d in date range)\n", feed.Title, len(feed.Entries), len(items))
+	if opts.FlatPlaylist {
+		return emitFlatPlaylist(items, opts, os.Stdout)
+	}
+	items = orderPlaylistItems(ctx, c, items, opts)
+	summary, failures := runPlaylistItems(ctx, c, items, opts, processURL)
+	return finishPlaylistRun("Feed", summary, failures)
+}
+
+func feedPlaylistItems(entries []client.ChannelFeedEntry, dates cli.DateRange) []client.PlaylistItem {
+	items := make([]client.PlaylistItem, 0, len(entries))
+	for _, e := range entries {
+		if !dates.Contains(e.Published) {
+			continue
+		}
+		items = append(items, client.PlaylistItem{VideoID: e.VideoID, Title: e.Title})
+	}
+	return items
+}
+
+func dateRange(opts cli.Options) cli.DateRange {
+	// Validated by cli.ToClientConfig before processing starts.
+	dates, _ := cli.ParseDateRange(opts.DateAfter, opts.DateBefore)
+	return dates
+}
+
+// uploadDate returns the video's upload (or publish) day, or zero if unknown.
+func uploadDate(info *client.VideoInfo) time.Time {
+	for _, raw := range []string{info.UploadDate, info.PublishDate} {
+		if len(raw) < len("2006-01-02") {
+			continue
+		}
+		if t, err := time.Parse("2006-01-02", raw[:len("2006-01-02")]); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func playlistOptions(opts cli.Options) client.PlaylistOptions {
+	if !opts.PlaylistIncremental {
+		return client.PlaylistOptions{}
+	}
+	if activeDownloadArchive == nil {
+		warnf(opts, "--playlist-incremental has no effect without --download-archive")
+		return client.PlaylistOptions{}
+	}
+	return client.PlaylistOptions{StopAtKnown: activeDownloadArchive.Has}
+}
+
 func emitFlatPlaylist(items []client.PlaylistItem, opts cli.Options, w io.Writer) error {
 	if opts.PrintJSON {
 		enc := json.NewEncoder(w)
@@ -306,6 +1110,21 @@ func emitFlatPlaylist(items []client.PlaylistItem, opts cli.Options, w io.Writer
 				"title": item.Title,
 				"url":   "https://www.youtube.com/watch?v=" + item.VideoID,
 			}
+			if item.DurationSec > 0 {
+				payload["duration"] = item.DurationSec
+			}
+			if item.Author != "" {
+				payload["uploader"] = item.Author
+			}
+			if item.ThumbnailURL != "" {
+				payload["thumbnails"] = []map[string]string{{"url": item.ThumbnailURL}}
+			}
+			if item.Index > 0 {
+				payload["playlist_index"] = item.Index
+			}
+			if item.Unavailable {
+				payload["availability"] = item.UnavailableReason
+			}
 			if err := enc.Encode(payload); err != nil {
 				return err
 			}
@@ -324,7 +1143,11 @@ type playlistRunSummary struct {
 	Total     int
 	Succeeded int
 	Failed    int
-	Aborted   bool
+	// Skipped counts entries skipped under --ignore-no-formats-error:
+	// private, deleted and unavailable listing entries, which are not
+	// fetched, and entries that fail as unavailable or without formats.
+	Skipped int
+	Aborted bool
 }
 
 type playlistItemFailure struct {
@@ -342,8 +1165,29 @@ func runPlaylistItems(
 	summary := playlistRunSummary{Total: len(items)}
 	failures := make([]playlistItemFailure, 0)
 	for i, item := range items {
+		position := fmt.Sprintf("index=%d/%d id=%s", i+1, len(items), item.VideoID)
+		if summary.Aborted {
+			emitPlaylistItemEvent("skip", appendDetail(position, "reason=aborted"))
+			continue
+		}
+		if item.Unavailable && opts.IgnoreNoFormats {
+			fmt.Printf("[%d/%d] Skipping %s (%s): %s video\n", i+1, len(items), item.Title, item.VideoID, item.UnavailableReason)
+			emitPlaylistItemEvent("skip", appendDetail(position, "reason="+item.UnavailableReason))
+			summary.Skipped++
+			continue
+		}
 		fmt.Printf("[%d/%d] Processing %s (%s)...\n", i+1, len(items), item.Title, item.VideoID)
-		if err := processor(ctx, c, item.VideoID, opts); err != nil {
+		emitPlaylistItemEvent("start", position)
+		err := processor(ctx, c, item.VideoID, opts)
+		if err != nil && opts.IgnoreNoFormats && isNoFormatsError(err) {
+			reason := string(client.ClassifyError(err))
+			fmt.Printf("[%d/%d] Skipping %s (%s): %v\n", i+1, len(items), item.Title, item.VideoID, err)
+			emitPlaylistItemEvent("skip", appendDetail(position, "reason="+reason))
+			summary.Skipped++
+			continue
+		}
+		if err != nil {
+			emitPlaylistItemEvent("failure", appendDetail(position, err.Error()))
 			summary.Failed++
 			failures = append(failures, playlistItemFailure{
 				VideoID: item.VideoID,
@@ -351,27 +1195,58 @@ func runPlaylistItems(
 			})
 			if opts.AbortOnError {
 				summary.Aborted = true
-				break
 			}
 			continue
 		}
+		emitPlaylistItemEvent("success", position)
 		summary.Succeeded++
 	}
 	return summary, failures
 }
 
-func printFormats(info *client.VideoInfo) {
-	fmt.Printf("Title: %s\n", info.Title)
-	fmt.Println("ID | Ext | Resolution | FPS | Bitrate | Proto | Codec | Note")
-	fmt.Println("---|-----|------------|-----|---------|-------|-------|------")
-	for _, f := range info.Formats {
-		fmt.Printf("%3d|%4s|%4dx%-4d|%3d|%6dk|%5s|%s|%s\n",
-			f.Itag, mimeExt(f.MimeType), f.Width, f.Height, f.FPS, f.Bitrate/1000, f.Protocol, f.MimeType, formatTrackNote(f))
+// isNoFormatsError reports whether err means the video itself cannot be
+// downloaded (removed, private, region-blocked or without formats), as
+// opposed to a failure a retry or other settings could fix.
+func isNoFormatsError(err error) bool {
+	return errors.Is(err, client.ErrUnavailable) || errors.Is(err, client.ErrNoPlayableFormats)
+}
+
+// emitPlaylistItemEvent reports the scheduling of one playlist item as a
+// "playlist_item" extraction event.
+func emitPlaylistItemEvent(phase, detail string) {
+	if cliExtractionEvents == nil {
+		return
+	}
+	cliExtractionEvents(client.ExtractionEvent{Stage: "playlist_item", Phase: phase, Client: "queue", Detail: detail})
+}
+
+// formatHintNotes renders client format hints for the -F Note column.
+var formatHintNotes = map[string]string{
+	client.FormatHintDRM:        "DRM, not downloadable",
+	client.FormatHintSABROnly:   "SABR only, not downloadable",
+	client.FormatHintPremium:    "Premium, needs a Premium login",
+	client.FormatHintDeprecated: "deprecated itag",
+}
+
+func formatNote(f client.FormatInfo) string {
+	notes := make([]string, 0, 1+len(f.Hints))
+	if track := formatTrackNote(f); track != "" {
+		notes = append(notes, track)
 	}
+	for _, h := range f.Hints {
+		if note, ok := formatHintNotes[h]; ok {
+			notes = append(notes, note)
+		} else {
+			notes = append(notes, h)
+		}
+	}
+	return strings.Join(notes, ", ")
 }
 
 func formatTrackNote(f client.FormatInfo) string {
 	switch {
+	case f.HasAudio && !f.HasVideo && f.Language != "":
+		return "audio only [" + f.Language + "]"
 	case f.HasAudio && !f.HasVideo:
 		return "audio only"
 	case f.HasVideo && !f.HasAudio:
@@ -419,16 +1294,29 @@ func writeRequestedSubtitles(
 		langs = []string{"en"}
 	}
 
+	transcripts, err := c.GetTranscripts(ctx, input, langs)
+	var batch *client.TranscriptBatchError
+	if err != nil && !errors.As(err, &batch) {
+		return fmt.Errorf("failed to write subtitles: %w", err)
+	}
 	written := 0
 	failures := make([]string, 0, len(langs))
-	for _, lang := range langs {
-		transcript, err := c.GetTranscript(ctx, input, lang)
-		if err != nil {
-			failures = append(failures, fmt.Sprintf("%s(%v)", lang, err))
+	if batch != nil {
+		for i, lang := range batch.LanguageCodes {
+			failures = append(failures, fmt.Sprintf("%s(%v)", lang, batch.Errors[i]))
+		}
+	}
+	for _, transcript := range transcripts {
+		if transcript == nil {
 			continue
 		}
-		outputPath := subtitleOutputPath(opts.OutputTemplate, info, transcript.LanguageCode, string(subFormat))
-		if err := client.WriteTranscript(outputPath, transcript, subFormat); err != nil {
+		outputPath := subtitleOutputPath(opts.OutputTemplate, templateInfo(info, opts), transcript.LanguageCode, string(subFormat))
+		if opts.Simulate {
+			written++
+			fmt.Printf("[simulate] subtitle -> %s\n", outputPath)
+			continue
+		}
+		if err := client.WriteTranscriptWithOptions(outputPath, transcript, subFormat, client.TranscriptWriteOptions{NoStyling: opts.NoSubStyling}); err != nil {
 			failures = append(failures, fmt.Sprintf("%s(%v)", transcript.LanguageCode, err))
 			continue
 		}
@@ -445,6 +1333,72 @@ func writeRequestedSubtitles(
 	return nil
 }
 
+// writeRequestedThumbnail saves info's best thumbnail for --write-thumbnail.
+func writeRequestedThumbnail(ctx context.Context, c *client.Client, info *client.VideoInfo, opts cli.Options) error {
+	outputPath := thumbnailOutputPath(opts.OutputTemplate, templateInfo(info, opts))
+	if opts.Simulate {
+		fmt.Printf("[simulate] thumbnail -> %s\n", outputPath)
+		return nil
+	}
+	if _, err := c.WriteThumbnail(ctx, info.ID, outputPath); err != nil {
+		return fmt.Errorf("failed to write thumbnail: %w", err)
+	}
+	fmt.Printf("Written thumbnail: %s\n", outputPath)
+	return nil
+}
+
+// writeRequestedNFO writes info's .nfo for --write-nfo and, in episode
+// style, a tvshow.nfo for the channel folder holding it.
+func writeRequestedNFO(info *client.VideoInfo, opts cli.Options) error {
+	outputPath := sidecarOutputPath(opts.OutputTemplate, templateInfo(info, opts), "nfo", "nfo")
+	style := client.NFOStyle(opts.NFOStyle)
+	if style == "" {
+		style = client.NFOEpisode
+	}
+	if opts.Simulate {
+		fmt.Printf("[simulate] nfo -> %s\n", outputPath)
+		return nil
+	}
+	if err := client.WriteNFO(outputPath, info, style); err != nil {
+		return fmt.Errorf("failed to write nfo: %w", err)
+	}
+	fmt.Printf("Written NFO: %s\n", outputPath)
+	if dir := filepath.Dir(outputPath); style == client.NFOEpisode && dir != "." {
+		wrote, err := client.WriteShowNFO(dir, info)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", client.ShowNFOName, err)
+		}
+		if wrote {
+			fmt.Printf("Written NFO: %s\n", filepath.Join(dir, client.ShowNFOName))
+		}
+	}
+	return nil
+}
+
+// thumbnailOutputPath places the thumbnail next to the templated output
+// file, with a .jpg extension.
+func thumbnailOutputPath(outputTemplate string, info *client.VideoInfo) string {
+	return sidecarOutputPath(outputTemplate, info, "jpg", "thumbnail")
+}
+
+// sidecarOutputPath places a sidecar file next to the templated output
+// file, swapping its extension for ext; %(itag)s expands to itag.
+func sidecarOutputPath(outputTemplate string, info *client.VideoInfo, ext, itag string) string {
+	if strings.TrimSpace(outputTemplate) == "" {
+		return info.ID + "." + ext
+	}
+	base := strings.TrimSpace(outputTemplate)
+	base = strings.ReplaceAll(base, "%(id)s", sanitizeTemplateToken(info.ID))
+	base = strings.ReplaceAll(base, "%(title)s", sanitizeTemplateToken(info.Title))
+	base = strings.ReplaceAll(base, "%(uploader)s", sanitizeTemplateToken(info.Author))
+	base = strings.ReplaceAll(base, "%(ext)s", ext)
+	base = strings.ReplaceAll(base, "%(itag)s", itag)
+	if strings.TrimSpace(base) == "" {
+		return info.ID + "." + ext
+	}
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "." + ext
+}
+
 func warnf(opts cli.Options, format string, args ...any) {
 	if opts.NoWarnings {
 		return
@@ -498,28 +1452,29 @@ func subtitleOutputPath(outputTemplate string, info *client.VideoInfo, lang stri
 }
 
 func sanitizeTemplateToken(v string) string {
-	v = strings.TrimSpace(v)
-	if v == "" {
-		return "unknown"
-	}
-	var b strings.Builder
-	for _, r := range v {
-		switch r {
-		case '<', '>', ':', '"', '/', '\\', '|', '?', '*':
-			b.WriteRune('_')
-		default:
-			if r < 32 {
-				b.WriteRune('_')
-				continue
-			}
-			b.WriteRune(r)
-		}
-	}
-	out := strings.TrimSpace(b.String())
-	if out == "" {
-		return "unknown"
+	return client.SanitizeFileName(v, client.TextNormalization{})
+}
+
+// templateInfo returns info with the title and uploader normalized like the
+// client does for media file names, so sidecar files are named to match.
+func templateInfo(info *client.VideoInfo, opts cli.Options) *client.VideoInfo {
+	text := cli.TextNormalization(opts)
+	if text == (client.TextNormalization{}) {
+		return info
+	}
+	named := *info
+	named.Title = text.Apply(info.Title)
+	named.Author = text.Apply(info.Author)
+	return &named
+}
+
+// metadataOnlyRun reports whether processURL stops before resolving any
+// stream URL, so extraction can skip the player JS.
+func metadataOnlyRun(opts cli.Options) bool {
+	if opts.GetURL || opts.Simulate || opts.VerboseSelector {
+		return false
 	}
-	return out
+	return opts.PrintJSON || opts.DumpSingleJSON || opts.ListFormats || opts.SkipDownload
 }
 
 func shouldSkipDownloadByArchive(input string) bool {
@@ -537,6 +1492,24 @@ func shouldSkipDownloadByArchive(input string) bool {
 	return true
 }
 
+// downloadArchiveKey is the video ID for YouTube and "<extractor> <id>" for
+// extractor videos, so IDs from different sources cannot collide.
+func downloadArchiveKey(info *client.VideoInfo) string {
+	if info.Extractor == "" {
+		return info.ID
+	}
+	return info.Extractor + " " + info.ID
+}
+
+// validArchiveEntry accepts the keys downloadArchiveKey produces.
+func validArchiveEntry(key string) bool {
+	if extractor, id, ok := strings.Cut(key, " "); ok {
+		return extractor != "" && id != "" && !strings.ContainsAny(id, " \t")
+	}
+	_, err := client.ExtractVideoID(key)
+	return err == nil
+}
+
 func recordCompletedDownload(videoID string) error {
 	if activeDownloadArchive == nil {
 		return nil
@@ -592,11 +1565,18 @@ func printAttemptDiagnostics(err error) {
 
 func printGenericRemediationHints(err error) {
 	var noPlayableDetail *client.NoPlayableFormatsDetailError
+	var upcoming *client.UpcomingVideoError
 	switch {
 	case errors.Is(err, client.ErrInvalidInput):
 		fmt.Println("hint: unsupported input. Use a full YouTube URL or 11-char video ID, then retry.")
 	case errors.Is(err, client.ErrLoginRequired):
 		fmt.Println("hint: login-required content. Retry with --cookies <netscape.txt> and --visitor-data <VISITOR_INFO1_LIVE>.")
+	case errors.As(err, &upcoming):
+		if upcoming.TrailerVideoID != "" {
+			fmt.Println("hint: the video has not started yet. Retry after its scheduled start, or pass --download-trailer to fetch the trailer.")
+			return
+		}
+		fmt.Println("hint: the video has not started yet. Retry after its scheduled start.")
 	case errors.Is(err, client.ErrNoPlayableFormats):
 		if errors.As(err, &noPlayableDetail) && noPlayableDetail.Selector != "" {
 			fmt.Printf("hint: selector %q matched no formats (%s). Retry with -F and adjust -f expression.\n", noPlayableDetail.Selector, noPlayableDetail.SelectionError)
@@ -705,11 +1685,9 @@ func newLifecyclePrinter(now func() time.Time) *lifecyclePrinter {
 	}
 }
 
+// videoTiming holds lifecycle-derived timings the DownloadResult lacks.
 type videoTiming struct {
-	downloadVideoMs  int64
-	downloadAudioMs  int64
-	downloadSingleMs int64
-	mergeMs          int64
+	mergeMs int64
 }
 
 func (p *lifecyclePrinter) formatExtractionEvent(evt client.ExtractionEvent) string {
@@ -757,18 +1735,7 @@ func (p *lifecyclePrinter) formatDownloadEvent(evt client.DownloadEvent) string
 					detail = appendDetail(detail, fmt.Sprintf("speed_bps=%d", int64(speedBPS)))
 					detail = appendDetail(detail, fmt.Sprintf("speed_mib_s=%.2f", speedMiB))
 				}
-				role := inferDownloadRole(evt.Path)
-				detail = appendDetail(detail, "part="+role)
-				vt := p.videoTimings[evt.VideoID]
-				switch role {
-				case "video":
-					vt.downloadVideoMs += elapsed
-				case "audio":
-					vt.downloadAudioMs += elapsed
-				default:
-					vt.downloadSingleMs += elapsed
-				}
-				p.videoTimings[evt.VideoID] = vt
+				detail = appendDetail(detail, "part="+inferDownloadRole(evt.Path))
 			}
 			if evt.Stage == "merge" && evt.Phase == "complete" {
 				vt := p.videoTimings[evt.VideoID]
@@ -841,29 +1808,92 @@ func inferDownloadRole(path string) string {
 	}
 }
 
-type ytdlpDumpSingleJSON struct {
-	ID           string             `json:"id"`
-	Title        string             `json:"title,omitempty"`
-	WebpageURL   string             `json:"webpage_url,omitempty"`
-	OriginalURL  string             `json:"original_url,omitempty"`
-	Extractor    string             `json:"extractor,omitempty"`
-	ExtractorKey string             `json:"extractor_key,omitempty"`
-	URL          string             `json:"url,omitempty"`
-	Ext          string             `json:"ext,omitempty"`
-	Formats      []ytdlpFormatEntry `json:"formats,omitempty"`
+// writeSelectorTrace prints the --verbose-selector evaluation of expr, one
+// "[selector]" line per group, spec, clause and rejected format.
+func writeSelectorTrace(w io.Writer, expr string, trace []client.SelectorGroupTrace, err error) {
+	if expr == "" && err == nil {
+		fmt.Fprintln(w, "[selector] explicit itag; no selector evaluated")
+		return
+	}
+	if expr != "" {
+		fmt.Fprintf(w, "[selector] %s\n", expr)
+	}
+	for i, group := range trace {
+		result := "matched"
+		if !group.Matched {
+			result = "no match"
+		}
+		fmt.Fprintf(w, "[selector] group %d %s: %s\n", i+1, group.Group, result)
+		for _, spec := range group.Specs {
+			fmt.Fprintf(w, "[selector]   %s: candidates=%s picked=%s\n", spec.Spec, joinItags(spec.Candidates), joinItags(spec.Picked))
+			for _, f := range spec.Filters {
+				fmt.Fprintf(w, "[selector]     %s matched %s\n", f.Clause, joinItags(f.Matched))
+			}
+			for _, r := range spec.Rejected {
+				fmt.Fprintf(w, "[selector]     rejected %d: %s\n", r.Itag, r.Reason)
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(w, "[selector] error: %v\n", err)
+	}
 }
 
-type ytdlpFormatEntry struct {
-	FormatID string `json:"format_id,omitempty"`
-	URL      string `json:"url,omitempty"`
-	Ext      string `json:"ext,omitempty"`
-	VCodec   string `json:"vcodec,omitempty"`
-	ACodec   string `json:"acodec,omitempty"`
-	Width    int    `json:"width,omitempty"`
-	Height   int    `json:"height,omitempty"`
-	FPS      int    `json:"fps,omitempty"`
-	TBR      int    `json:"tbr,omitempty"`
-	Protocol string `json:"protocol,omitempty"`
+func joinItags(itags []int) string {
+	if len(itags) == 0 {
+		return "-"
+	}
+	parts := make([]string, len(itags))
+	for i, itag := range itags {
+		parts[i] = strconv.Itoa(itag)
+	}
+	return strings.Join(parts, ",")
+}
+
+// writeDebugReport writes the --write-debug-report file for url, named after
+// the output template like subtitles ("<base>.debug.json"). Failures are only
+// warned about so they never fail the download.
+func writeDebugReport(ctx context.Context, c *client.Client, url string, info *client.VideoInfo, opts cli.Options) {
+	r := c.DebugReport(ctx, url, buildDownloadOptions(opts))
+	if info == nil {
+		info = &client.VideoInfo{ID: r.VideoID}
+	}
+	path := debugReportPath(opts.OutputTemplate, templateInfo(info, opts))
+	data, err := json.MarshalIndent(report.NewDebugReport(r), "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		warnf(opts, "failed to write debug report %s: %v", path, err)
+		return
+	}
+	fmt.Printf("Written debug report: %s\n", path)
+}
+
+func debugReportPath(outputTemplate string, info *client.VideoInfo) string {
+	return subtitleOutputPath(outputTemplate, info, "debug", "json")
+}
+
+// emitCapabilities prints the --capabilities document.
+// checkConfig prints each client.Config.Validate problem, or that there are
+// none, and reports whether cfg is clean.
+func checkConfig(w io.Writer, cfg client.Config) bool {
+	errs := cfg.Validate()
+	if len(errs) == 0 {
+		fmt.Fprintln(w, "config OK")
+		return true
+	}
+	for _, err := range errs {
+		fmt.Fprintf(w, "config: %v\n", err)
+	}
+	fmt.Fprintf(w, "%d problem(s) found\n", len(errs))
+	return false
+}
+
+func emitCapabilities(w io.Writer, c *client.Client) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report.NewCapabilities(c.Capabilities(), currentVersion()))
 }
 
 func emitDumpSingleJSON(w io.Writer, input string, info *client.VideoInfo) error {
@@ -873,37 +1903,44 @@ func emitDumpSingleJSON(w io.Writer, input string, info *client.VideoInfo) error
 	return enc.Encode(payload)
 }
 
-func buildDumpSingleJSONPayload(input string, info *client.VideoInfo) ytdlpDumpSingleJSON {
+func buildDumpSingleJSONPayload(input string, info *client.VideoInfo) report.Video {
 	webURL := canonicalWatchURL(input, info.ID)
 	bestURL, bestExt := pickBestDirectFormatURL(info.Formats)
-	formats := make([]ytdlpFormatEntry, 0, len(info.Formats))
+	formats := make([]report.Format, 0, len(info.Formats))
 	for _, f := range info.Formats {
 		if strings.TrimSpace(f.URL) == "" {
 			continue
 		}
-		formats = append(formats, ytdlpFormatEntry{
-			FormatID: strconv.Itoa(f.Itag),
-			URL:      f.URL,
-			Ext:      mimeExt(f.MimeType),
-			VCodec:   codecLabel(f.HasVideo),
-			ACodec:   codecLabel(f.HasAudio),
-			Width:    f.Width,
-			Height:   f.Height,
-			FPS:      f.FPS,
-			TBR:      f.Bitrate / 1000,
-			Protocol: f.Protocol,
+		var expiresAt int64
+		if !f.ExpiresAt.IsZero() {
+			expiresAt = f.ExpiresAt.Unix()
+		}
+		formats = append(formats, report.Format{
+			FormatID:  strconv.Itoa(f.Itag),
+			URL:       f.URL,
+			Ext:       mimeExt(f.MimeType),
+			VCodec:    codecLabel(f.HasVideo),
+			ACodec:    codecLabel(f.HasAudio),
+			Width:     f.Width,
+			Height:    f.Height,
+			FPS:       f.FPS,
+			TBR:       f.Bitrate / 1000,
+			Protocol:  f.Protocol,
+			Hints:     f.Hints,
+			ExpiresAt: expiresAt,
 		})
 	}
-	return ytdlpDumpSingleJSON{
-		ID:           info.ID,
-		Title:        info.Title,
-		WebpageURL:   webURL,
-		OriginalURL:  strings.TrimSpace(input),
-		Extractor:    "youtube",
-		ExtractorKey: "Youtube",
-		URL:          bestURL,
-		Ext:          bestExt,
-		Formats:      formats,
+	return report.Video{
+		SchemaVersion: report.SchemaVersion,
+		ID:            info.ID,
+		Title:         info.Title,
+		WebpageURL:    webURL,
+		OriginalURL:   strings.TrimSpace(input),
+		Extractor:     "youtube",
+		ExtractorKey:  "Youtube",
+		URL:           bestURL,
+		Ext:           bestExt,
+		Formats:       formats,
 	}
 }
 
@@ -971,33 +2008,78 @@ func compareFormatQuality(a, b client.FormatInfo) int {
 	}
 }
 
-type cliErrorReport struct {
-	OK       bool           `json:"ok"`
-	Input    string         `json:"input"`
-	ExitCode int            `json:"exit_code"`
-	Error    cliErrorDetail `json:"error"`
+// maxPrintedCountries bounds the available-countries list in human output;
+// geo allowlists can name most of the world.
+const maxPrintedCountries = 20
+
+// playabilityDetail returns the first playability rejection recorded in the
+// error's attempt matrix, so geo-blocked and members-only failures can show
+// YouTube's own reason text without --verbose.
+func playabilityDetail(err error) *report.Playability {
+	attempts, ok := client.AttemptDetails(err)
+	if !ok {
+		return nil
+	}
+	for _, a := range attempts {
+		if a.PlayabilityStatus == "" && a.PlayabilityReason == "" {
+			continue
+		}
+		return &report.Playability{
+			Status:             a.PlayabilityStatus,
+			Reason:             a.PlayabilityReason,
+			Subreason:          a.PlayabilitySubreason,
+			AvailableCountries: a.AvailableCountries,
+		}
+	}
+	return nil
 }
 
-type cliErrorDetail struct {
-	Category string                 `json:"category"`
-	Message  string                 `json:"message"`
-	Attempts []client.AttemptDetail `json:"attempts,omitempty"`
+func playabilityLines(err error) []string {
+	detail := playabilityDetail(err)
+	if detail == nil {
+		return nil
+	}
+	var lines []string
+	if detail.Reason != "" {
+		line := "reason: " + detail.Reason
+		if detail.Status != "" {
+			line += " (" + detail.Status + ")"
+		}
+		lines = append(lines, line)
+	}
+	if detail.Subreason != "" && detail.Subreason != detail.Reason {
+		lines = append(lines, "subreason: "+detail.Subreason)
+	}
+	if n := len(detail.AvailableCountries); n > 0 {
+		countries := detail.AvailableCountries
+		if n > maxPrintedCountries {
+			countries = countries[:maxPrintedCountries]
+		}
+		line := "available countries: " + strings.Join(countries, ", ")
+		if n > maxPrintedCountries {
+			line += fmt.Sprintf(" (+%d more)", n-maxPrintedCountries)
+		}
+		lines = append(lines, line)
+	}
+	return lines
 }
 
 func emitJSONFailure(input string, err error, exitCode int) {
-	report := cliErrorReport{
-		OK:       false,
-		Input:    input,
-		ExitCode: exitCode,
-		Error: cliErrorDetail{
-			Category: string(client.ClassifyError(err)),
-			Message:  err.Error(),
+	failure := report.Failure{
+		SchemaVersion: report.SchemaVersion,
+		OK:            false,
+		Input:         input,
+		ExitCode:      exitCode,
+		Error: report.ErrorDetail{
+			Category:    string(client.ClassifyError(err)),
+			Message:     err.Error(),
+			Playability: playabilityDetail(err),
 		},
 	}
 	if attempts, ok := client.AttemptDetails(err); ok && len(attempts) > 0 {
-		report.Error.Attempts = attempts
+		failure.Error.Attempts = attempts
 	}
-	_ = json.NewEncoder(os.Stdout).Encode(report)
+	_ = json.NewEncoder(os.Stdout).Encode(failure)
 }
 
 func classifyExitCode(err error) int {
@@ -1014,7 +2096,7 @@ func classifyExitCode(err error) int {
 		return exitCodeChallengeUnresolved
 	case client.ErrorCategoryAllClientsFailed:
 		return exitCodeAllClientsFailed
-	case client.ErrorCategoryDownloadFailed:
+	case client.ErrorCategoryDownloadFailed, client.ErrorCategoryMaxFileSizeExceeded, client.ErrorCategoryMaxTotalBytesExceeded, client.ErrorCategoryDownloadTooSlow, client.ErrorCategoryOutputInvalid:
 		return exitCodeDownloadFailed
 	case client.ErrorCategoryMP3TranscoderNotConfigured:
 		return exitCodeMP3ConfigRequired
@@ -1030,6 +2112,10 @@ type downloadArchive struct {
 	file *os.File
 	mu   sync.Mutex
 	ids  map[string]struct{}
+	// format is the line format new entries are written in.
+	format string
+	// failures is the retry ledger kept next to the archive.
+	failures *retryLedger
 }
 
 func newDownloadArchive(path string) (*downloadArchive, error) {
@@ -1042,31 +2128,32 @@ func newDownloadArchive(path string) (*downloadArchive, error) {
 			return nil, err
 		}
 	}
+	failures, err := loadRetryLedger(cleanPath + ".failures.json")
+	if err != nil {
+		return nil, err
+	}
 	f, err := os.OpenFile(cleanPath, os.O_CREATE|os.O_RDWR, 0644)
 	if err != nil {
 		return nil, err
 	}
 
-	archive := &downloadArchive{
-		path: cleanPath,
-		file: f,
-		ids:  make(map[string]struct{}),
-	}
-	scanner := bufio.NewScanner(f)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" {
-			continue
-		}
-		if _, err := client.ExtractVideoID(line); err != nil {
-			continue
-		}
-		archive.ids[line] = struct{}{}
-	}
-	if err := scanner.Err(); err != nil {
+	// yt-dlp archives are read as they are; new entries follow the format
+	// the file already uses.
+	keys, format, _, err := readArchiveKeys(f)
+	if err != nil {
 		_ = f.Close()
 		return nil, err
 	}
+	archive := &downloadArchive{
+		path:     cleanPath,
+		file:     f,
+		ids:      make(map[string]struct{}, len(keys)),
+		format:   format,
+		failures: failures,
+	}
+	for _, key := range keys {
+		archive.ids[key] = struct{}{}
+	}
 	if _, err := f.Seek(0, io.SeekEnd); err != nil {
 		_ = f.Close()
 		return nil, err
@@ -1095,7 +2182,7 @@ func (a *downloadArchive) Add(videoID string) error {
 	if a == nil {
 		return nil
 	}
-	if _, err := client.ExtractVideoID(videoID); err != nil {
+	if !validArchiveEntry(videoID) {
 		return fmt.Errorf("invalid video id for archive: %q", videoID)
 	}
 	a.mu.Lock()
@@ -1103,7 +2190,7 @@ func (a *downloadArchive) Add(videoID string) error {
 	if _, exists := a.ids[videoID]; exists {
 		return nil
 	}
-	if _, err := a.file.WriteString(videoID + "\n"); err != nil {
+	if _, err := a.file.WriteString(formatArchiveLine(videoID, a.format) + "\n"); err != nil {
 		return err
 	}
 	if err := a.file.Sync(); err != nil {
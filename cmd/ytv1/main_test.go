@@ -5,8 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,6 +18,8 @@ import (
 
 	"github.com/famomatic/ytv1/client"
 	"github.com/famomatic/ytv1/internal/cli"
+	"github.com/famomatic/ytv1/internal/cookies"
+	"github.com/famomatic/ytv1/report"
 )
 
 func TestFormatExtractionEvent(t *testing.T) {
@@ -125,6 +130,13 @@ func TestBuildDownloadOptions_CustomSelectorPassthrough(t *testing.T) {
 	}
 }
 
+func TestFormatNote_IncludesHints(t *testing.T) {
+	got := formatNote(client.FormatInfo{HasVideo: true, Hints: []string{client.FormatHintDRM, "future_hint"}})
+	if want := "video only, DRM, not downloadable, future_hint"; got != want {
+		t.Fatalf("formatNote() = %q, want %q", got, want)
+	}
+}
+
 func TestFormatTrackNote(t *testing.T) {
 	cases := []struct {
 		name string
@@ -155,6 +167,14 @@ func TestFormatTrackNote(t *testing.T) {
 			},
 			want: "av",
 		},
+		{
+			name: "audio language",
+			in: client.FormatInfo{
+				HasAudio: true,
+				Language: "es",
+			},
+			want: "audio only [es]",
+		},
 		{
 			name: "none",
 			in: client.FormatInfo{
@@ -175,6 +195,53 @@ func TestFormatTrackNote(t *testing.T) {
 	}
 }
 
+func TestBuildDownloadOptions_Mtime(t *testing.T) {
+	if !buildDownloadOptions(cli.Options{}).SetMtime {
+		t.Fatal("SetMtime = false, want the upload date mtime by default")
+	}
+	if buildDownloadOptions(cli.Options{NoMtime: true}).SetMtime {
+		t.Fatal("SetMtime = true with --no-mtime")
+	}
+}
+
+type recordingSink map[string]string
+
+func (s recordingSink) Upload(ctx context.Context, key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	s[key] = string(data)
+	return err
+}
+
+func (s recordingSink) URL(key string) string { return "s3://bucket/" + key }
+
+func TestRemoteOutput(t *testing.T) {
+	sink := recordingSink{}
+	remoteOutput = &outputSink{sink: sink, key: "yt/%(id)s.%(ext)s"}
+	defer func() { remoteOutput = nil }()
+
+	got := buildDownloadOptions(cli.Options{OutputTemplate: "s3://bucket/yt/%(id)s.%(ext)s"})
+	if got.Sink == nil || got.OutputPath != "yt/%(id)s.%(ext)s" {
+		t.Fatalf("Sink = %v, OutputPath = %q", got.Sink, got.OutputPath)
+	}
+	if loc := outputLocation("yt/abc.mp4"); loc != "s3://bucket/yt/abc.mp4" {
+		t.Fatalf("outputLocation() = %q", loc)
+	}
+
+	stage := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(stage, "yt"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(stage, "yt", "abc.nfo"), []byte("<movie/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := uploadStagedSidecars(context.Background(), sink, stage); err != nil {
+		t.Fatalf("uploadStagedSidecars() error = %v", err)
+	}
+	if len(sink) != 1 || sink["yt/abc.nfo"] != "<movie/>" {
+		t.Fatalf("uploaded = %v", sink)
+	}
+}
+
 func TestBuildDownloadOptions_NumericItag(t *testing.T) {
 	got := buildDownloadOptions(cli.Options{
 		FormatSelector: "251",
@@ -199,6 +266,17 @@ func TestBuildDownloadOptions_MP3Mode(t *testing.T) {
 	}
 }
 
+func TestBuildDownloadOptions_ExtractAudio(t *testing.T) {
+	got := buildDownloadOptions(cli.Options{FormatSelector: "best", ExtractAudio: true, AudioFormat: "m4a"})
+	if got.Mode != client.SelectionModeAudioOnly || got.ExtractAudio != client.AudioFormatM4A {
+		t.Fatalf("Mode=%q ExtractAudio=%q", got.Mode, got.ExtractAudio)
+	}
+	got = buildDownloadOptions(cli.Options{FormatSelector: "140", ExtractAudio: true, AudioFormat: "best"})
+	if got.Itag != 140 || got.ExtractAudio != client.AudioFormatBest {
+		t.Fatalf("Itag=%d ExtractAudio=%q", got.Itag, got.ExtractAudio)
+	}
+}
+
 func TestBuildDownloadOptions_ResumeDefaultEnabled(t *testing.T) {
 	got := buildDownloadOptions(cli.Options{})
 	if !got.Resume {
@@ -265,6 +343,39 @@ func TestProcessInputsWithExitCode_SelectsHighestCode(t *testing.T) {
 	}
 }
 
+func TestSortPlaylistItems(t *testing.T) {
+	items := []client.PlaylistItem{{VideoID: "a"}, {VideoID: "b"}, {VideoID: "c"}, {VideoID: "d"}}
+	infos := map[string]*client.VideoInfo{
+		"a": {UploadDate: "2024-03-01", DurationSec: 300, ViewCount: 10},
+		"b": {UploadDate: "2025-01-01", DurationSec: 60, ViewCount: 5000},
+		"c": {DurationSec: 120, ViewCount: 700},
+		// d has no metadata and always sorts last.
+	}
+	ids := func(items []client.PlaylistItem) string {
+		out := make([]string, 0, len(items))
+		for _, item := range items {
+			out = append(out, item.VideoID)
+		}
+		return strings.Join(out, ",")
+	}
+	for order, want := range map[string]string{
+		cli.PlaylistOrderNewest:   "b,a,c,d",
+		cli.PlaylistOrderOldest:   "a,b,c,d",
+		cli.PlaylistOrderShortest: "b,c,a,d",
+		cli.PlaylistOrderViews:    "b,c,a,d",
+	} {
+		if got := ids(sortPlaylistItems(items, infos, order)); got != want {
+			t.Fatalf("sortPlaylistItems(%s) = %s, want %s", order, got, want)
+		}
+	}
+	if got := ids(items); got != "a,b,c,d" {
+		t.Fatalf("input reordered: %s", got)
+	}
+	if got := ids(orderPlaylistItems(context.Background(), nil, items, cli.Options{})); got != "a,b,c,d" {
+		t.Fatalf("orderPlaylistItems(no order) = %s", got)
+	}
+}
+
 func TestRunPlaylistItems_ContinueOnError(t *testing.T) {
 	items := []client.PlaylistItem{
 		{VideoID: "a", Title: "A"},
@@ -319,6 +430,90 @@ func TestRunPlaylistItems_AbortOnError(t *testing.T) {
 	}
 }
 
+func TestRunPlaylistItems_SkipsUnavailable(t *testing.T) {
+	items := []client.PlaylistItem{
+		{VideoID: "a", Title: "A"},
+		{VideoID: "b", Title: "[Private video]", Unavailable: true, UnavailableReason: client.PlaylistItemPrivate},
+		{VideoID: "c", Title: "C"},
+	}
+	var processed []string
+	summary, failures := runPlaylistItems(context.Background(), nil, items, cli.Options{IgnoreNoFormats: true}, func(_ context.Context, _ *client.Client, id string, _ cli.Options) error {
+		processed = append(processed, id)
+		return nil
+	})
+	if summary.Total != 3 || summary.Succeeded != 2 || summary.Skipped != 1 || summary.Failed != 0 || len(failures) != 0 {
+		t.Fatalf("unexpected summary: %+v failures=%v", summary, failures)
+	}
+	if strings.Join(processed, ",") != "a,c" {
+		t.Fatalf("processed = %v, want the unavailable entry skipped", processed)
+	}
+}
+
+func TestRunPlaylistItems_SkipsNoFormatsErrors(t *testing.T) {
+	items := []client.PlaylistItem{{VideoID: "a"}, {VideoID: "b"}, {VideoID: "c"}, {VideoID: "d"}}
+	summary, failures := runPlaylistItems(context.Background(), nil, items, cli.Options{IgnoreNoFormats: true, AbortOnError: true}, func(_ context.Context, _ *client.Client, id string, _ cli.Options) error {
+		switch id {
+		case "a":
+			return &client.UnavailableDetailError{}
+		case "b":
+			return fmt.Errorf("extract: %w", client.ErrNoPlayableFormats)
+		case "c":
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	if summary.Skipped != 2 || summary.Failed != 1 || summary.Succeeded != 0 || !summary.Aborted {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if len(failures) != 1 || failures[0].VideoID != "c" {
+		t.Fatalf("failures = %+v, want only the transient error", failures)
+	}
+}
+
+func TestRunPlaylistItems_NoFormatsPolicyOff(t *testing.T) {
+	items := []client.PlaylistItem{
+		{VideoID: "a", Title: "[Deleted video]", Unavailable: true, UnavailableReason: client.PlaylistItemDeleted},
+		{VideoID: "b"},
+	}
+	var processed []string
+	summary, failures := runPlaylistItems(context.Background(), nil, items, cli.Options{}, func(_ context.Context, _ *client.Client, id string, _ cli.Options) error {
+		processed = append(processed, id)
+		return client.ErrUnavailable
+	})
+	if strings.Join(processed, ",") != "a,b" {
+		t.Fatalf("processed = %v, want every entry attempted", processed)
+	}
+	if summary.Skipped != 0 || summary.Failed != 2 || len(failures) != 2 {
+		t.Fatalf("unexpected summary: %+v failures=%v", summary, failures)
+	}
+}
+
+func TestRunPlaylistItems_EmitsItemEvents(t *testing.T) {
+	var events []string
+	cliExtractionEvents = func(evt client.ExtractionEvent) {
+		events = append(events, evt.Stage+":"+evt.Phase+" "+evt.Detail)
+	}
+	defer func() { cliExtractionEvents = nil }()
+
+	items := []client.PlaylistItem{{VideoID: "a"}, {VideoID: "b"}, {VideoID: "c"}}
+	runPlaylistItems(context.Background(), nil, items, cli.Options{AbortOnError: true}, func(_ context.Context, _ *client.Client, id string, _ cli.Options) error {
+		if id == "b" {
+			return errors.New("fail-b")
+		}
+		return nil
+	})
+	want := []string{
+		"playlist_item:start index=1/3 id=a",
+		"playlist_item:success index=1/3 id=a",
+		"playlist_item:start index=2/3 id=b",
+		"playlist_item:failure index=2/3 id=b fail-b",
+		"playlist_item:skip index=3/3 id=c reason=aborted",
+	}
+	if strings.Join(events, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("events = %q, want %q", events, want)
+	}
+}
+
 func TestParseSubtitleLanguages(t *testing.T) {
 	got := parseSubtitleLanguages("ko, en,ko,  ")
 	if len(got) != 2 || got[0] != "ko" || got[1] != "en" {
@@ -346,6 +541,40 @@ func TestSubtitleOutputPath_Template(t *testing.T) {
 	}
 }
 
+func TestThumbnailOutputPath(t *testing.T) {
+	info := &client.VideoInfo{ID: "abc123", Title: "title/name", Author: "owner"}
+	if path := thumbnailOutputPath("", info); path != "abc123.jpg" {
+		t.Fatalf("default path=%q, want abc123.jpg", path)
+	}
+	if path := thumbnailOutputPath("out/%(uploader)s/%(title)s.%(ext)s", info); path != "out/owner/title_name.jpg" {
+		t.Fatalf("template path=%q, want out/owner/title_name.jpg", path)
+	}
+}
+
+func TestWriteRequestedNFO_EpisodeWritesShowNFO(t *testing.T) {
+	dir := t.TempDir()
+	info := &client.VideoInfo{ID: "abc123", Title: "clip", Author: "owner", ChannelID: "UCowner"}
+	opts := cli.Options{WriteNFO: true, NFOStyle: cli.NFOStyleEpisode, OutputTemplate: filepath.Join(dir, "%(uploader)s", "%(title)s [%(id)s].%(ext)s")}
+	if err := writeRequestedNFO(info, opts); err != nil {
+		t.Fatalf("writeRequestedNFO() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "owner", "clip [abc123].nfo")); err != nil {
+		t.Fatalf("episode nfo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "owner", client.ShowNFOName)); err != nil {
+		t.Fatalf("tvshow.nfo: %v", err)
+	}
+
+	opts.NFOStyle = cli.NFOStyleMovie
+	opts.OutputTemplate = filepath.Join(dir, "movies", "%(title)s.%(ext)s")
+	if err := writeRequestedNFO(info, opts); err != nil {
+		t.Fatalf("writeRequestedNFO(movie) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "movies", client.ShowNFOName)); !os.IsNotExist(err) {
+		t.Fatalf("movie style wrote tvshow.nfo: %v", err)
+	}
+}
+
 func TestSubtitleOutputPath_TemplateVTT(t *testing.T) {
 	path := subtitleOutputPath("%(title)s.%(ext)s", &client.VideoInfo{
 		ID:     "abc123",
@@ -357,6 +586,16 @@ func TestSubtitleOutputPath_TemplateVTT(t *testing.T) {
 	}
 }
 
+func TestDebugReportPath(t *testing.T) {
+	info := &client.VideoInfo{ID: "abc123", Title: "title/name"}
+	if path := debugReportPath("", info); path != "abc123.debug.json" {
+		t.Fatalf("path=%q, want %q", path, "abc123.debug.json")
+	}
+	if path := debugReportPath("out/%(title)s.%(ext)s", info); path != "out/title_name.debug.json" {
+		t.Fatalf("path=%q, want %q", path, "out/title_name.debug.json")
+	}
+}
+
 func TestResolveSubtitleOutputFormat(t *testing.T) {
 	if got := client.ResolveSubtitleOutputFormat("vtt/srt"); got != client.SubtitleOutputFormatVTT {
 		t.Fatalf("ResolveSubtitleOutputFormat(vtt/srt)=%q, want %q", got, client.SubtitleOutputFormatVTT)
@@ -427,6 +666,35 @@ func TestWriteTranscriptAsVTT(t *testing.T) {
 	}
 }
 
+func TestWorkspaceLockPaths(t *testing.T) {
+	dir := t.TempDir()
+	got := workspaceLockPaths(cli.Options{
+		DownloadArchive: filepath.Join(dir, "archive.txt"),
+		OutputTemplate:  filepath.Join(dir, "music", "%(uploader)s", "%(title)s.%(ext)s"),
+	})
+	want := []string{
+		filepath.Join(dir, "archive.txt") + client.WorkspaceLockName,
+		filepath.Join(dir, "music", client.WorkspaceLockName),
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+	if got := workspaceLockPaths(cli.Options{OutputTemplate: "s3://bucket/%(id)s.%(ext)s"}); len(got) != 0 {
+		t.Fatalf("remote output paths = %v, want no local output lock", got)
+	}
+	if got := workspaceLockPaths(cli.Options{}); len(got) != 1 || got[0] != client.WorkspaceLockName {
+		t.Fatalf("default paths = %v", got)
+	}
+	if got := workspaceLockPaths(cli.Options{LockFile: "/tmp/ytv1.lock", DownloadArchive: "a.txt"}); len(got) != 1 || got[0] != "/tmp/ytv1.lock" {
+		t.Fatalf("--lock-file paths = %v", got)
+	}
+	for _, opts := range []cli.Options{{NoLock: true}, {Simulate: true}, {ListFormats: true}, {GetURL: true}} {
+		if got := workspaceLockPaths(opts); got != nil {
+			t.Fatalf("workspaceLockPaths(%+v) = %v, want none", opts, got)
+		}
+	}
+}
+
 func TestDownloadArchive_LoadIgnoresCorruptedLines(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "archive.txt")
 	content := "jNQXAC9IVRw\nnot-a-video-id\nhttps://example.com/watch?v=bad\nDSYFmhjDbvs\n"
@@ -521,6 +789,95 @@ func TestRecordCompletedDownload_AppendsArchive(t *testing.T) {
 	}
 }
 
+func TestDownloadArchive_ExtractorKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.txt")
+	if err := os.WriteFile(path, []byte("jNQXAC9IVRw\nportal town-hall-42\nnot an id\n"), 0644); err != nil {
+		t.Fatalf("seed archive: %v", err)
+	}
+	archive, err := newDownloadArchive(path)
+	if err != nil {
+		t.Fatalf("newDownloadArchive() error = %v", err)
+	}
+	defer archive.Close()
+
+	key := downloadArchiveKey(&client.VideoInfo{ID: "town-hall-42", Extractor: "portal"})
+	if key != "portal town-hall-42" || !archive.Has(key) {
+		t.Fatalf("key=%q loaded=%v", key, archive.Has(key))
+	}
+	if got := downloadArchiveKey(&client.VideoInfo{ID: "jNQXAC9IVRw"}); got != "jNQXAC9IVRw" {
+		t.Fatalf("youtube key=%q", got)
+	}
+	if archive.Has("not an id") {
+		t.Fatalf("invalid line should not load")
+	}
+	if err := archive.Add("portal town-hall-43"); err != nil {
+		t.Fatalf("Add(extractor key) error = %v", err)
+	}
+	if err := archive.Add("no-extractor-prefix"); err == nil {
+		t.Fatalf("expected invalid key error")
+	}
+}
+
+func TestDownloadArchive_YtDlpFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.txt")
+	if err := os.WriteFile(path, []byte("youtube jNQXAC9IVRw\nportal town-hall-42\n"), 0644); err != nil {
+		t.Fatalf("seed archive: %v", err)
+	}
+	archive, err := newDownloadArchive(path)
+	if err != nil {
+		t.Fatalf("newDownloadArchive() error = %v", err)
+	}
+	if !archive.Has("jNQXAC9IVRw") || !archive.Has("portal town-hall-42") {
+		t.Fatalf("yt-dlp entries not loaded: %v", archive.ids)
+	}
+	// New entries follow the format the file already uses.
+	if err := archive.Add("DSYFmhjDbvs"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	archive.Close()
+	raw, _ := os.ReadFile(path)
+	if want := "youtube jNQXAC9IVRw\nportal town-hall-42\nyoutube DSYFmhjDbvs\n"; string(raw) != want {
+		t.Fatalf("archive = %q, want %q", raw, want)
+	}
+}
+
+func TestRunArchive_Convert(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "archive.txt")
+	if err := os.WriteFile(in, []byte("jNQXAC9IVRw\nportal town-hall-42\nnot an id\nyoutube jNQXAC9IVRw\n"), 0644); err != nil {
+		t.Fatalf("seed archive: %v", err)
+	}
+
+	var out bytes.Buffer
+	opts := cli.Options{ArchiveAction: "convert", ArchiveFormat: cli.ArchiveFormatYtDlp, URLs: []string{in, in}}
+	if err := runArchive(&out, opts); err != nil {
+		t.Fatalf("runArchive(to yt-dlp) error = %v", err)
+	}
+	raw, _ := os.ReadFile(in)
+	if want := "youtube jNQXAC9IVRw\nportal town-hall-42\n"; string(raw) != want {
+		t.Fatalf("converted in place = %q, want %q", raw, want)
+	}
+	if !strings.Contains(out.String(), "Converted 2 entries") || !strings.Contains(out.String(), "skipped 1 invalid") {
+		t.Fatalf("summary = %q", out.String())
+	}
+
+	out.Reset()
+	opts = cli.Options{ArchiveAction: "convert", ArchiveFormat: cli.ArchiveFormatYtv1, URLs: []string{in}}
+	if err := runArchive(&out, opts); err != nil {
+		t.Fatalf("runArchive(to ytv1) error = %v", err)
+	}
+	if want := "jNQXAC9IVRw\nportal town-hall-42\n"; out.String() != want {
+		t.Fatalf("stdout = %q, want %q", out.String(), want)
+	}
+
+	if err := runArchive(&out, cli.Options{ArchiveAction: "convert", URLs: []string{in}}); err == nil {
+		t.Fatal("expected an error without --archive-format")
+	}
+	if err := runArchive(&out, cli.Options{ArchiveAction: "merge", ArchiveFormat: cli.ArchiveFormatYtv1, URLs: []string{in}}); err == nil {
+		t.Fatal("expected an error for an unknown action")
+	}
+}
+
 func TestWarnf_SuppressedByNoWarnings(t *testing.T) {
 	var buf bytes.Buffer
 	prevWriter := log.Writer()
@@ -598,6 +955,34 @@ func TestEmitFlatPlaylist_JSON(t *testing.T) {
 	}
 }
 
+func TestEmitFlatPlaylist_JSONItemMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	err := emitFlatPlaylist([]client.PlaylistItem{
+		{VideoID: "jNQXAC9IVRw", Title: "one", Author: "jawed", DurationSec: 19, Index: 3, ThumbnailURL: "https://i.ytimg.com/vi/x/hq.jpg"},
+		{VideoID: "DSYFmhjDbvs", Title: "[Private video]", Unavailable: true, UnavailableReason: client.PlaylistItemPrivate},
+	}, cli.Options{PrintJSON: true}, &buf)
+	if err != nil {
+		t.Fatalf("emitFlatPlaylist() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var first, second map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if first["duration"] != float64(19) || first["uploader"] != "jawed" || first["playlist_index"] != float64(3) || first["availability"] != nil {
+		t.Fatalf("unexpected first payload: %v", first)
+	}
+	if thumbs, _ := first["thumbnails"].([]any); len(thumbs) != 1 {
+		t.Fatalf("unexpected thumbnails: %v", first["thumbnails"])
+	}
+	if second["availability"] != "private" || second["duration"] != nil {
+		t.Fatalf("unexpected second payload: %v", second)
+	}
+}
+
 func TestBuildDumpSingleJSONPayload_IncludesPlayableURL(t *testing.T) {
 	info := &client.VideoInfo{
 		ID:    "jNQXAC9IVRw",
@@ -633,3 +1018,578 @@ func TestBuildDumpSingleJSONPayload_IncludesPlayableURL(t *testing.T) {
 		t.Fatalf("formats len=%d, want 2", len(payload.Formats))
 	}
 }
+
+func TestWriteResolvedStreams_URLsThenHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	headers := make(http.Header)
+	headers.Set("Referer", "https://www.youtube.com/watch?v=jNQXAC9IVRw")
+	headers.Set("User-Agent", "ua-test")
+	headers.Set("Cookie", "SID=secret")
+	err := writeResolvedStreams(&buf, []client.ResolvedStream{
+		{Format: client.FormatInfo{Itag: 248}, URL: "https://cdn.example/v", Headers: headers},
+		{Format: client.FormatInfo{Itag: 251}, URL: "https://cdn.example/a", Headers: headers},
+	}, true)
+	if err != nil {
+		t.Fatalf("writeResolvedStreams() error = %v", err)
+	}
+	want := "https://cdn.example/v\nhttps://cdn.example/a\nReferer: https://www.youtube.com/watch?v=jNQXAC9IVRw\nUser-Agent: ua-test\n"
+	if buf.String() != want {
+		t.Fatalf("output=%q, want %q", buf.String(), want)
+	}
+}
+
+func TestBuildDownloadOptions_CheckFormats(t *testing.T) {
+	got := buildDownloadOptions(cli.Options{FormatSelector: "bestaudio", CheckFormats: true})
+	if !got.CheckFormats {
+		t.Fatalf("CheckFormats=%v, want true", got.CheckFormats)
+	}
+	if got.Mode != client.SelectionModeAudioOnly {
+		t.Fatalf("Mode = %q, want %q", got.Mode, client.SelectionModeAudioOnly)
+	}
+}
+
+func TestWriteDownloadAudit_StreamsAndFallback(t *testing.T) {
+	var buf bytes.Buffer
+	writeDownloadAudit(&buf, &client.DownloadResult{
+		Streams: []client.DownloadStreamResult{
+			{Itag: 18, Protocol: "https", URLHost: "rr1.googlevideo.com", Bytes: 42, Retries: 2},
+		},
+		FallbackReason: "muxer_unavailable",
+	})
+	want := "stream itag=18 proto=https host=rr1.googlevideo.com bytes=42 retries=2\nfallback=muxer_unavailable\n"
+	if buf.String() != want {
+		t.Fatalf("output=%q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteDownloadAudit_TransferStats(t *testing.T) {
+	var buf bytes.Buffer
+	writeDownloadAudit(&buf, &client.DownloadResult{
+		Streams: []client.DownloadStreamResult{
+			{Itag: 96, Protocol: "hls", URLHost: "manifest.googlevideo.com", Bytes: 4096, Transferred: 2048, Elapsed: 2 * time.Second, BytesPerSecond: 1024},
+		},
+	})
+	want := "stream itag=96 proto=hls host=manifest.googlevideo.com bytes=4096 retries=0 transferred=2048 elapsed_ms=2000 speed=1024B/s\n"
+	if buf.String() != want {
+		t.Fatalf("output=%q, want %q", buf.String(), want)
+	}
+}
+
+func TestFormatDownloadSummary_UsesStreamMeasurements(t *testing.T) {
+	res := &client.DownloadResult{
+		Bytes: 3000,
+		SelectedFormats: []client.FormatInfo{
+			{Itag: 248, HasVideo: true},
+			{Itag: 251, HasAudio: true},
+		},
+		Streams: []client.DownloadStreamResult{
+			{Itag: 248, Elapsed: 1500 * time.Millisecond},
+			{Itag: 251, Elapsed: 500 * time.Millisecond},
+		},
+		Elapsed:        2 * time.Second,
+		BytesPerSecond: 1400,
+	}
+	got := formatDownloadSummary(res, 4000, 300, 200)
+	want := "total_elapsed_ms=4000 extract_ms=300 download_ms(video/audio)=1500/500 merge_ms=200 final_size=3000 avg_speed=1400B/s"
+	if got != want {
+		t.Fatalf("summary=%q, want %q", got, want)
+	}
+
+	audioOnly := &client.DownloadResult{
+		SelectedFormats: []client.FormatInfo{{Itag: 140, HasAudio: true}},
+		Streams:         []client.DownloadStreamResult{{Itag: 140, Elapsed: time.Second}},
+	}
+	if got := formatDownloadSummary(audioOnly, 0, 0, 0); !strings.Contains(got, "download_ms(video/audio)=1000/0") {
+		t.Fatalf("single-stream summary=%q", got)
+	}
+}
+
+func TestBuildDownloadOptions_Limits(t *testing.T) {
+	got := buildDownloadOptions(cli.Options{
+		MaxFileSize:   "50M",
+		MaxResolution: 1080,
+	})
+	if got.MaxFileSize != 50<<20 {
+		t.Fatalf("MaxFileSize = %d, want %d", got.MaxFileSize, 50<<20)
+	}
+	if got.MaxHeight != 1080 {
+		t.Fatalf("MaxHeight = %d, want 1080", got.MaxHeight)
+	}
+}
+
+func TestDownloadWithFullRetries_ReextractsUntilSuccess(t *testing.T) {
+	downloadErr := errors.Join(errors.New("unexpected EOF"), &client.DownloadFailureDetailError{})
+	calls := 0
+	var reextracts []int
+	res, err := downloadWithFullRetries(context.Background(), 3, 0,
+		func(context.Context) (*client.DownloadResult, error) {
+			calls++
+			if calls < 3 {
+				return nil, downloadErr
+			}
+			return &client.DownloadResult{OutputPath: "out.mp4"}, nil
+		},
+		func(_ context.Context, attempt int, cause error) error {
+			if cause != downloadErr {
+				t.Fatalf("reextract cause = %v", cause)
+			}
+			reextracts = append(reextracts, attempt)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("downloadWithFullRetries() error = %v", err)
+	}
+	if res.OutputPath != "out.mp4" || calls != 3 {
+		t.Fatalf("result=%+v calls=%d", res, calls)
+	}
+	if len(reextracts) != 2 || reextracts[0] != 1 || reextracts[1] != 2 {
+		t.Fatalf("reextract attempts = %v", reextracts)
+	}
+}
+
+func TestDownloadWithFullRetries_StopsOnNonDownloadFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries int
+		err     error
+		want    int
+	}{
+		{name: "disabled", retries: 0, err: &client.DownloadFailureDetailError{}, want: 1},
+		{name: "exhausted", retries: 2, err: &client.DownloadFailureDetailError{}, want: 3},
+		{name: "unavailable", retries: 2, err: client.ErrUnavailable, want: 1},
+		{name: "max filesize", retries: 2, err: errors.Join(&client.MaxFileSizeError{Limit: 1}, &client.DownloadFailureDetailError{}), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			_, err := downloadWithFullRetries(context.Background(), tt.retries, 0,
+				func(context.Context) (*client.DownloadResult, error) {
+					calls++
+					return nil, tt.err
+				},
+				func(context.Context, int, error) error { return nil },
+			)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if calls != tt.want {
+				t.Fatalf("download calls = %d, want %d", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaitForProcessedReplay_PollsUntilReady(t *testing.T) {
+	fetches := 0
+	info, err := waitForProcessedReplay(context.Background(), &client.VideoInfo{ID: "a", LiveReplay: client.LiveReplayProcessing}, time.Millisecond,
+		func(context.Context) (*client.VideoInfo, error) {
+			fetches++
+			status := client.LiveReplayProcessing
+			if fetches == 3 {
+				status = client.LiveReplayReady
+			}
+			return &client.VideoInfo{ID: "a", LiveReplay: status}, nil
+		})
+	if err != nil {
+		t.Fatalf("waitForProcessedReplay() error = %v", err)
+	}
+	if fetches != 3 || info.LiveReplay != client.LiveReplayReady {
+		t.Fatalf("fetches = %d, status = %q", fetches, info.LiveReplay)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = waitForProcessedReplay(ctx, &client.VideoInfo{ID: "a", LiveReplay: client.LiveReplayProcessing}, time.Hour,
+		func(context.Context) (*client.VideoInfo, error) {
+			t.Fatal("fetched after cancellation")
+			return nil, nil
+		})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("waitForProcessedReplay() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestRunInputs_PartialSuccessReport(t *testing.T) {
+	results := map[string]error{
+		"a": nil,
+		"b": client.ErrUnavailable,
+		"c": errors.Join(errors.New("EOF"), &client.DownloadFailureDetailError{}),
+	}
+	run := runInputs(context.Background(), nil, []string{"a", "b", "c"}, cli.Options{}, func(_ context.Context, _ *client.Client, url string, _ cli.Options) error {
+		return results[url]
+	})
+	if run.ExitCode != exitCodePartialSuccess {
+		t.Fatalf("exit code=%d, want %d", run.ExitCode, exitCodePartialSuccess)
+	}
+	if run.Total != 3 || run.Succeeded != 1 || run.Failed != 2 || run.Skipped != 0 {
+		t.Fatalf("unexpected counts: %+v", run)
+	}
+	if run.Categories[string(client.ErrorCategoryUnavailable)] != 1 || run.Categories[string(client.ErrorCategoryDownloadFailed)] != 1 {
+		t.Fatalf("categories = %v", run.Categories)
+	}
+	if run.Items[1].Status != report.StatusFailed || run.Items[1].ExitCode != exitCodeUnavailable {
+		t.Fatalf("item b = %+v", run.Items[1])
+	}
+
+	var out bytes.Buffer
+	printRunSummary(&out, run)
+	for _, want := range []string{
+		"[ok] a",
+		"[failed] b category=unavailable exit=4",
+		"total=3 succeeded=1 failed=2 skipped=0 exit=11",
+		"download_failed=1",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("summary missing %q:\n%s", want, out.String())
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := writeRunReport(path, run); err != nil {
+		t.Fatalf("writeRunReport() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var decoded report.Run
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if decoded.SchemaVersion != report.SchemaVersion || decoded.ExitCode != exitCodePartialSuccess || len(decoded.Items) != 3 || decoded.Items[2].Category != string(client.ErrorCategoryDownloadFailed) {
+		t.Fatalf("decoded run = %+v", decoded)
+	}
+}
+
+func TestRunInputs_AbortMarksRemainingSkipped(t *testing.T) {
+	run := runInputs(context.Background(), nil, []string{"a", "b", "c"}, cli.Options{AbortOnError: true}, func(_ context.Context, _ *client.Client, url string, _ cli.Options) error {
+		if url == "b" {
+			return client.ErrNoPlayableFormats
+		}
+		return nil
+	})
+	if !run.Aborted || run.Skipped != 1 || run.Items[2].Status != report.StatusSkipped {
+		t.Fatalf("unexpected run: %+v", run)
+	}
+	if run.ExitCode != exitCodePartialSuccess {
+		t.Fatalf("exit code=%d, want %d", run.ExitCode, exitCodePartialSuccess)
+	}
+}
+
+func TestRunInputs_SpentByteBudgetSkipsRemaining(t *testing.T) {
+	run := runInputs(context.Background(), nil, []string{"a", "b", "c"}, cli.Options{}, func(_ context.Context, _ *client.Client, url string, _ cli.Options) error {
+		if url == "a" {
+			return &client.MaxTotalBytesError{Limit: 100, Bytes: 120}
+		}
+		return nil
+	})
+	if !run.Aborted || run.Skipped != 2 || run.Items[0].Category != string(client.ErrorCategoryMaxTotalBytesExceeded) || run.Items[0].ExitCode != exitCodeDownloadFailed {
+		t.Fatalf("unexpected run: %+v", run)
+	}
+
+	run.BytesReceived, run.MaxTotalBytes = 120, 100
+	var out bytes.Buffer
+	printRunSummary(&out, run)
+	if !strings.Contains(out.String(), "bytes_received=120 limit=100") {
+		t.Fatalf("summary missing bandwidth tally:\n%s", out.String())
+	}
+}
+
+func TestWriteSimulatedDownload(t *testing.T) {
+	var out bytes.Buffer
+	writeSimulatedDownload(&out, &client.VideoInfo{ID: "jNQXAC9IVRw", Title: "Clip"}, &client.DownloadResult{
+		OutputPath: "out/Clip.mp4",
+		Simulated:  true,
+		Streams: []client.DownloadStreamResult{
+			{Itag: 137, Protocol: "https", Path: "out/Clip.mp4.f137.video"},
+			{Itag: 140, Protocol: "https", Path: "out/Clip.mp4.f140.audio"},
+		},
+	})
+	want := "[simulate] Clip [jNQXAC9IVRw] -> out/Clip.mp4\n" +
+		"[simulate]   itag=137 proto=https part=out/Clip.mp4.f137.video\n" +
+		"[simulate]   itag=140 proto=https part=out/Clip.mp4.f140.audio\n"
+	if out.String() != want {
+		t.Fatalf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestPlaylistOptions_IncrementalUsesArchive(t *testing.T) {
+	if got := playlistOptions(cli.Options{PlaylistIncremental: true, NoWarnings: true}); got.StopAtKnown != nil {
+		t.Fatal("expected no StopAtKnown without an archive")
+	}
+
+	archive, err := newDownloadArchive(filepath.Join(t.TempDir(), "archive.txt"))
+	if err != nil {
+		t.Fatalf("newDownloadArchive() error = %v", err)
+	}
+	defer archive.Close()
+	if err := archive.Add("jNQXAC9IVRw"); err != nil {
+		t.Fatalf("archive.Add() error = %v", err)
+	}
+	prev := activeDownloadArchive
+	activeDownloadArchive = archive
+	defer func() { activeDownloadArchive = prev }()
+
+	if got := playlistOptions(cli.Options{}); got.StopAtKnown != nil {
+		t.Fatal("expected no StopAtKnown without --playlist-incremental")
+	}
+	got := playlistOptions(cli.Options{PlaylistIncremental: true})
+	if got.StopAtKnown == nil || !got.StopAtKnown("jNQXAC9IVRw") || got.StopAtKnown("DSYFmhjDbvs") {
+		t.Fatal("expected StopAtKnown to match archived IDs only")
+	}
+}
+
+func TestFeedPlaylistItems_AppliesDateRange(t *testing.T) {
+	entries := []client.ChannelFeedEntry{
+		{VideoID: "ccccccccccc", Title: "new", Published: time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)},
+		{VideoID: "bbbbbbbbbbb", Title: "mid", Published: time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)},
+		{VideoID: "aaaaaaaaaaa", Title: "old", Published: time.Date(2026, 9, 1, 9, 0, 0, 0, time.UTC)},
+	}
+	dates, err := cli.ParseDateRange("20261001", "20261010")
+	if err != nil {
+		t.Fatalf("ParseDateRange() error = %v", err)
+	}
+	items := feedPlaylistItems(entries, dates)
+	if len(items) != 1 || items[0].VideoID != "bbbbbbbbbbb" || items[0].Title != "mid" {
+		t.Fatalf("items = %+v", items)
+	}
+	if got := feedPlaylistItems(entries, cli.DateRange{}); len(got) != 3 {
+		t.Fatalf("open range items = %d, want 3", len(got))
+	}
+}
+
+func TestUploadDate(t *testing.T) {
+	if got := uploadDate(&client.VideoInfo{UploadDate: "2024-01-02T08:00:00-07:00"}); !got.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("uploadDate(upload) = %v", got)
+	}
+	if got := uploadDate(&client.VideoInfo{PublishDate: "2005-04-23"}); got.Year() != 2005 {
+		t.Fatalf("uploadDate(publish) = %v", got)
+	}
+	if got := uploadDate(&client.VideoInfo{}); !got.IsZero() {
+		t.Fatalf("uploadDate(unknown) = %v, want zero", got)
+	}
+}
+
+func TestEventWriter_EmitsVersionedNDJSON(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	var buf bytes.Buffer
+	ew := newEventWriter(&buf, func() time.Time { return at })
+	ew.extraction(client.ExtractionEvent{Stage: "player_api", Phase: "start", Client: "web"})
+	ew.download(client.DownloadEvent{Stage: "download", Phase: "complete", VideoID: "jNQXAC9IVRw", Path: "out.mp4"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 NDJSON lines, got %d:\n%s", len(lines), buf.String())
+	}
+	var first, second report.Event
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("json.Unmarshal(line 0) error = %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("json.Unmarshal(line 1) error = %v", err)
+	}
+	if first.SchemaVersion != report.SchemaVersion || first.Kind != report.EventExtraction || first.Client != "web" || !first.Time.Equal(at) {
+		t.Fatalf("extraction event = %+v", first)
+	}
+	if second.Kind != report.EventDownload || second.VideoID != "jNQXAC9IVRw" || second.Path != "out.mp4" {
+		t.Fatalf("download event = %+v", second)
+	}
+}
+
+func TestEventWriter_SchemaDrift(t *testing.T) {
+	var buf bytes.Buffer
+	ew := newEventWriter(&buf, time.Now)
+	ew.schemaDrift(client.SchemaDrift{Client: "web", VideoID: "jNQXAC9IVRw", Kind: client.SchemaDriftRenderer, Path: "captions", Value: "newRenderer"})
+	var evt report.Event
+	if err := json.Unmarshal(buf.Bytes(), &evt); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if evt.Kind != report.EventSchemaDrift || evt.Phase != client.SchemaDriftRenderer || evt.Detail != "captions: newRenderer" || evt.VideoID != "jNQXAC9IVRw" {
+		t.Fatalf("schema drift event = %+v", evt)
+	}
+}
+
+func TestBuildDumpSingleJSONPayload_IncludesSchemaVersion(t *testing.T) {
+	payload := buildDumpSingleJSONPayload("jNQXAC9IVRw", &client.VideoInfo{ID: "jNQXAC9IVRw", Title: "x"})
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"schema_version":1`) {
+		t.Fatalf("payload missing schema_version: %s", data)
+	}
+}
+
+func TestBuildDumpSingleJSONPayload_ExposesURLExpiry(t *testing.T) {
+	info := &client.VideoInfo{ID: "jNQXAC9IVRw", Formats: []client.FormatInfo{
+		{Itag: 18, URL: "https://rr1---sn.googlevideo.com/videoplayback?expire=1700000000", ExpiresAt: time.Unix(1700000000, 0)},
+		{Itag: 22, URL: "https://example.com/v.mp4"},
+	}}
+	data, err := json.Marshal(buildDumpSingleJSONPayload("jNQXAC9IVRw", info))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if strings.Count(string(data), `"expires_at"`) != 1 || !strings.Contains(string(data), `"expires_at":1700000000`) {
+		t.Fatalf("payload = %s", data)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	cfg, err := cli.ToClientConfig(cli.Options{})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	var out bytes.Buffer
+	if !checkConfig(&out, cfg) || out.String() != "config OK\n" {
+		t.Fatalf("default options: %q", out.String())
+	}
+
+	out.Reset()
+	cfg.ClientOverrides = []string{"webb"}
+	if checkConfig(&out, cfg) {
+		t.Fatal("checkConfig() = true for an unknown client")
+	}
+	if !strings.Contains(out.String(), `config: ClientOverrides[0]: unknown Innertube client "webb"`) || !strings.Contains(out.String(), "2 problem(s) found") {
+		t.Fatalf("output = %q", out.String())
+	}
+}
+
+func TestEmitCapabilities(t *testing.T) {
+	orig := version
+	version = "v9.9.9"
+	defer func() { version = orig }()
+
+	var buf bytes.Buffer
+	if err := emitCapabilities(&buf, client.New(client.Config{})); err != nil {
+		t.Fatalf("emitCapabilities() error = %v", err)
+	}
+	var got report.Capabilities
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v\n%s", err, buf.String())
+	}
+	if got.SchemaVersion != report.SchemaVersion || got.Version != "v9.9.9" {
+		t.Fatalf("schema_version=%d version=%q", got.SchemaVersion, got.Version)
+	}
+	if !got.Protocols["dash"] || got.Protocols["sabr"] || len(got.Clients) == 0 || len(got.Caches) == 0 {
+		t.Fatalf("capabilities = %s", buf.String())
+	}
+}
+
+func TestDescribeUpcoming(t *testing.T) {
+	info := &client.VideoInfo{ID: "prem1ereVID", IsUpcoming: true, IsPremiere: true, TrailerVideoID: "trailerVID1"}
+	if got := describeUpcoming(info, false); !strings.HasPrefix(got, "Premiere prem1ereVID has not started yet") || !strings.HasSuffix(got, "pass --download-trailer to fetch trailer trailerVID1") {
+		t.Fatalf("describeUpcoming() = %q", got)
+	}
+	if got := describeUpcoming(info, true); !strings.HasSuffix(got, "; downloading trailer trailerVID1") {
+		t.Fatalf("describeUpcoming(trailer) = %q", got)
+	}
+	info = &client.VideoInfo{ID: "stream00001", IsUpcoming: true, ScheduledStartTime: time.Unix(1792051200, 0)}
+	if got := describeUpcoming(info, true); !strings.HasPrefix(got, "Live stream stream00001 has not started yet (scheduled for ") || !strings.HasSuffix(got, "; no trailer is available") {
+		t.Fatalf("describeUpcoming(stream) = %q", got)
+	}
+}
+
+func TestPrintInnertubeUsage(t *testing.T) {
+	var buf bytes.Buffer
+	printInnertubeUsage(&buf, []client.InnertubeUsage{
+		{Client: "ios", Requests: 2, LastHour: 2},
+		{Client: "web", Limit: 300, Requests: 12, LastHour: 9, Delayed: 3, Rejected: 1},
+	})
+	want := "[innertube] client=ios requests=2 last_hour=2 limit=unlimited delayed=0 rejected=0\n" +
+		"[innertube] client=web requests=12 last_hour=9 limit=300/h delayed=3 rejected=1\n"
+	if buf.String() != want {
+		t.Fatalf("printInnertubeUsage() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteSelectorTrace(t *testing.T) {
+	var out bytes.Buffer
+	writeSelectorTrace(&out, "bv+ba/b", []client.SelectorGroupTrace{
+		{Group: "bv+ba", Specs: []client.SelectorSpecTrace{{
+			Spec:     "bv",
+			Filters:  []client.SelectorFilterTrace{{Clause: "bestvideo"}},
+			Rejected: []client.SelectorRejection{{Itag: 18, Reason: "fails bestvideo"}},
+		}}},
+		{Group: "b", Matched: true, Specs: []client.SelectorSpecTrace{{
+			Spec:       "b",
+			Filters:    []client.SelectorFilterTrace{{Clause: "best", Matched: []int{18}}},
+			Candidates: []int{18},
+			Picked:     []int{18},
+		}}},
+	}, nil)
+	want := "[selector] bv+ba/b\n" +
+		"[selector] group 1 bv+ba: no match\n" +
+		"[selector]   bv: candidates=- picked=-\n" +
+		"[selector]     bestvideo matched -\n" +
+		"[selector]     rejected 18: fails bestvideo\n" +
+		"[selector] group 2 b: matched\n" +
+		"[selector]   b: candidates=18 picked=18\n" +
+		"[selector]     best matched 18\n"
+	if out.String() != want {
+		t.Fatalf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestSaveCookieJar_WritesOnlyWhenChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	jar, err := cookies.NewJar([]*http.Cookie{
+		{Name: "SID", Value: "old", Domain: ".youtube.com", Path: "/", Expires: time.Unix(1999999999, 0), Secure: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saveCookieJar(path, jar); err != nil {
+		t.Fatalf("saveCookieJar() error = %v", err)
+	}
+	if fileExists(path) {
+		t.Fatal("unchanged jar should not be written")
+	}
+
+	u, _ := url.Parse("https://www.youtube.com/")
+	jar.SetCookies(u, []*http.Cookie{{Name: "SID", Value: "new", Domain: "youtube.com", Path: "/", MaxAge: 3600, Secure: true}})
+	if err := saveCookieJar(path, jar); err != nil {
+		t.Fatalf("saveCookieJar() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "\tSID\tnew\n") {
+		t.Fatalf("cookies file = %q", data)
+	}
+
+	// Comments and lines the parser skipped survive the write-back.
+	original := "# Netscape HTTP Cookie File\n# exported for ytv1\n.youtube.com\tTRUE\t/\tTRUE\t1999999999\tSID\told\nnot a cookie line\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	jar.SetCookies(u, []*http.Cookie{{Name: "SID", Value: "newer", Domain: "youtube.com", Path: "/", MaxAge: 3600, Secure: true}})
+	if err := saveCookieJar(path, jar); err != nil {
+		t.Fatalf("saveCookieJar() error = %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if got := string(data); !strings.HasPrefix(got, "# Netscape HTTP Cookie File\n# exported for ytv1\nnot a cookie line\n") ||
+		strings.Count(got, "\tSID\t") != 1 || !strings.Contains(got, "\tSID\tnewer\n") {
+		t.Fatalf("cookies file = %q", got)
+	}
+}
+
+func TestMetadataOnlyRun(t *testing.T) {
+	cases := []struct {
+		opts cli.Options
+		want bool
+	}{
+		{cli.Options{PrintJSON: true}, true},
+		{cli.Options{SkipDownload: true, WriteSubs: true}, true},
+		{cli.Options{ListFormats: true}, true},
+		{cli.Options{}, false},
+		{cli.Options{SkipDownload: true, GetURL: true}, false},
+		{cli.Options{PrintJSON: true, Simulate: true}, false},
+	}
+	for _, tc := range cases {
+		if got := metadataOnlyRun(tc.opts); got != tc.want {
+			t.Errorf("metadataOnlyRun(%+v) = %v, want %v", tc.opts, got, tc.want)
+		}
+	}
+}
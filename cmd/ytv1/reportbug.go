@@ -0,0 +1,102 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/cli"
+)
+
+// reportBugRoot is the top-level directory inside report-bug archives.
+const reportBugRoot = "ytv1-bug-report"
+
+// runReportBug bundles the files under --capture-dir, plus an environment
+// summary, into a .tar.gz that can be attached to an issue.
+func runReportBug(w io.Writer, opts cli.Options, now time.Time) error {
+	dir := strings.TrimSpace(opts.CaptureDir)
+	if dir == "" {
+		return errors.New("report-bug requires --capture-dir")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			files = append(files, e.Name())
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no captures in %s; rerun the failing command with --capture-dir %s first", dir, dir)
+	}
+
+	out := strings.TrimSpace(opts.ReportBugBundle)
+	if out == "" {
+		out = "ytv1-bug-report-" + now.UTC().Format("20060102T150405") + ".tar.gz"
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := writeReportBugArchive(f, dir, files, now); err != nil {
+		_ = f.Close()
+		_ = os.Remove(out)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Wrote %s (%d captured file(s)); review it before attaching to an issue\n", out, len(files))
+	return nil
+}
+
+func writeReportBugArchive(dst io.Writer, dir string, files []string, now time.Time) error {
+	gz := gzip.NewWriter(dst)
+	tw := tar.NewWriter(gz)
+	env := []byte(reportBugEnvironment())
+	if err := tw.WriteHeader(&tar.Header{Name: reportBugRoot + "/environment.txt", Mode: 0644, Size: int64(len(env)), ModTime: now}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(env); err != nil {
+		return err
+	}
+	for _, name := range files {
+		if err := addReportBugFile(tw, filepath.Join(dir, name), reportBugRoot+"/"+name); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func addReportBugFile(tw *tar.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: st.Size(), ModTime: st.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func reportBugEnvironment() string {
+	return fmt.Sprintf("ytv1: %s\ngo: %s\nos/arch: %s/%s\n", currentVersion(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/cli"
+)
+
+func TestRunReportBug_BundlesCaptures(t *testing.T) {
+	captureDir := t.TempDir()
+	for name, body := range map[string]string{
+		"captures.ndjson": `{"kind":"player_js"}` + "\n",
+		"20261015T120000.000-player_js-abcd1234.js": "var a=1;",
+	} {
+		if err := os.WriteFile(filepath.Join(captureDir, name), []byte(body), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	bundle := filepath.Join(t.TempDir(), "bug.tar.gz")
+	var out bytes.Buffer
+	if err := runReportBug(&out, cli.Options{CaptureDir: captureDir, ReportBugBundle: bundle}, time.Now()); err != nil {
+		t.Fatalf("runReportBug() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "2 captured file(s)") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+
+	f, err := os.Open(bundle)
+	if err != nil {
+		t.Fatalf("Open(bundle) error = %v", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar Next() error = %v", err)
+		}
+		names = append(names, hdr.Name)
+	}
+	sort.Strings(names)
+	want := []string{
+		"ytv1-bug-report/20261015T120000.000-player_js-abcd1234.js",
+		"ytv1-bug-report/captures.ndjson",
+		"ytv1-bug-report/environment.txt",
+	}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("archive entries = %v, want %v", names, want)
+	}
+}
+
+func TestRunReportBug_EmptyCaptureDir(t *testing.T) {
+	err := runReportBug(io.Discard, cli.Options{CaptureDir: t.TempDir()}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "no captures") {
+		t.Fatalf("runReportBug(empty) error = %v", err)
+	}
+}
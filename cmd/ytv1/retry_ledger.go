@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/famomatic/ytv1/client"
+	"github.com/famomatic/ytv1/internal/cli"
+)
+
+// Retry ledger backoff: the first failure waits retryLedgerBaseDelay, each
+// further one doubles it up to retryLedgerMaxDelay.
+const (
+	retryLedgerBaseDelay = 24 * time.Hour
+	retryLedgerMaxDelay  = 30 * 24 * time.Hour
+)
+
+// retryLedger is the --download-archive sidecar (<archive>.failures.json)
+// recording videos whose extraction failed in a way that is unlikely to fix
+// itself soon (deleted, private, login-only), so batch and sync runs do not
+// retry them every time. It is rewritten through a temp file on each change.
+type retryLedger struct {
+	path string
+	mu   sync.Mutex
+	// Videos is keyed like the archive (see downloadArchiveKey).
+	Videos map[string]*retryLedgerEntry `json:"videos"`
+}
+
+type retryLedgerEntry struct {
+	Category   client.ErrorCategory `json:"category"`
+	Error      string               `json:"error"`
+	Failures   int                  `json:"failures"`
+	LastFailed time.Time            `json:"last_failed"`
+	RetryAfter time.Time            `json:"retry_after"`
+}
+
+// retryLedgerCategories are the failure categories worth backing off from;
+// network and challenge failures are retried on the next run as before.
+var retryLedgerCategories = map[client.ErrorCategory]bool{
+	client.ErrorCategoryUnavailable:       true,
+	client.ErrorCategoryLoginRequired:     true,
+	client.ErrorCategoryNoPlayableFormats: true,
+}
+
+func loadRetryLedger(path string) (*retryLedger, error) {
+	ledger := &retryLedger{path: path, Videos: make(map[string]*retryLedgerEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ledger, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, ledger); err != nil {
+		return nil, fmt.Errorf("invalid retry ledger %s: %w", path, err)
+	}
+	if ledger.Videos == nil {
+		ledger.Videos = make(map[string]*retryLedgerEntry)
+	}
+	return ledger, nil
+}
+
+// blocked returns the entry for key if its retry time has not come yet.
+func (l *retryLedger) blocked(key string, now time.Time) (retryLedgerEntry, bool) {
+	if l == nil {
+		return retryLedgerEntry{}, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.Videos[key]
+	if !ok || !now.Before(entry.RetryAfter) {
+		return retryLedgerEntry{}, false
+	}
+	return *entry, true
+}
+
+// recordFailure notes a failed attempt at key. Errors outside
+// retryLedgerCategories are not recorded.
+func (l *retryLedger) recordFailure(key string, err error, now time.Time) error {
+	category := client.ClassifyError(err)
+	if l == nil || !retryLedgerCategories[category] {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry, ok := l.Videos[key]
+	if !ok {
+		entry = &retryLedgerEntry{}
+		l.Videos[key] = entry
+	}
+	entry.Category = category
+	entry.Error = err.Error()
+	entry.Failures++
+	entry.LastFailed = now.UTC()
+	entry.RetryAfter = entry.LastFailed.Add(retryLedgerDelay(entry.Failures))
+	return l.saveLocked()
+}
+
+// clear forgets key after it was extracted successfully.
+func (l *retryLedger) clear(key string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.Videos[key]; !ok {
+		return nil
+	}
+	delete(l.Videos, key)
+	return l.saveLocked()
+}
+
+func retryLedgerDelay(failures int) time.Duration {
+	delay := retryLedgerBaseDelay
+	for i := 1; i < failures && delay < retryLedgerMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > retryLedgerMaxDelay {
+		delay = retryLedgerMaxDelay
+	}
+	return delay
+}
+
+func (l *retryLedger) saveLocked() error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// shouldSkipFailedVideo reports whether input is a video the retry ledger
+// says to leave alone for now. --retry-failed disables the check.
+func shouldSkipFailedVideo(input string, opts cli.Options) bool {
+	if activeDownloadArchive == nil || opts.RetryFailed {
+		return false
+	}
+	videoID, err := client.ExtractVideoID(input)
+	if err != nil {
+		return false
+	}
+	entry, ok := activeDownloadArchive.failures.blocked(videoID, time.Now())
+	if !ok {
+		return false
+	}
+	fmt.Printf("Skipping (%s, failed %d times; retry after %s or use --retry-failed): %s\n",
+		entry.Category, entry.Failures, entry.RetryAfter.Local().Format(time.RFC3339), videoID)
+	return true
+}
+
+// recordVideoAttempt updates the retry ledger with the outcome of extracting
+// input: failures are recorded, a success forgets earlier ones.
+func recordVideoAttempt(input string, err error, opts cli.Options) {
+	if activeDownloadArchive == nil || opts.Simulate {
+		return
+	}
+	videoID, idErr := client.ExtractVideoID(input)
+	if idErr != nil {
+		return
+	}
+	ledger := activeDownloadArchive.failures
+	var ledgerErr error
+	if err != nil {
+		ledgerErr = ledger.recordFailure(videoID, err, time.Now())
+	} else {
+		ledgerErr = ledger.clear(videoID)
+	}
+	if ledgerErr != nil {
+		warnf(opts, "failed to update retry ledger: %v", ledgerErr)
+	}
+}
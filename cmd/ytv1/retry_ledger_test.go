@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/famomatic/ytv1/client"
+	"github.com/famomatic/ytv1/internal/cli"
+)
+
+func TestRetryLedger_BacksOffAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.txt.failures.json")
+	ledger, err := loadRetryLedger(path)
+	if err != nil {
+		t.Fatalf("loadRetryLedger() error = %v", err)
+	}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	unavailable := fmt.Errorf("extract: %w", client.ErrUnavailable)
+
+	if err := ledger.recordFailure("jNQXAC9IVRw", errors.New("connection reset"), now); err != nil {
+		t.Fatalf("recordFailure(transient) error = %v", err)
+	}
+	if _, ok := ledger.blocked("jNQXAC9IVRw", now); ok {
+		t.Fatalf("transient failure recorded in ledger")
+	}
+	for i := 0; i < 2; i++ {
+		if err := ledger.recordFailure("jNQXAC9IVRw", unavailable, now); err != nil {
+			t.Fatalf("recordFailure() error = %v", err)
+		}
+	}
+
+	reloaded, err := loadRetryLedger(path)
+	if err != nil {
+		t.Fatalf("reload error = %v", err)
+	}
+	entry, ok := reloaded.blocked("jNQXAC9IVRw", now.Add(47*time.Hour))
+	if !ok || entry.Category != client.ErrorCategoryUnavailable || entry.Failures != 2 || !entry.RetryAfter.Equal(now.Add(48*time.Hour)) {
+		t.Fatalf("entry = %+v ok=%v, want second failure backed off 48h", entry, ok)
+	}
+	if _, ok := reloaded.blocked("jNQXAC9IVRw", now.Add(48*time.Hour)); ok {
+		t.Fatalf("still blocked once the retry time came")
+	}
+
+	if err := reloaded.clear("jNQXAC9IVRw"); err != nil {
+		t.Fatalf("clear() error = %v", err)
+	}
+	if again, _ := loadRetryLedger(path); len(again.Videos) != 0 {
+		t.Fatalf("cleared ledger = %+v", again.Videos)
+	}
+}
+
+func TestRetryLedgerDelay_Caps(t *testing.T) {
+	if got := retryLedgerDelay(1); got != retryLedgerBaseDelay {
+		t.Fatalf("retryLedgerDelay(1) = %v", got)
+	}
+	if got := retryLedgerDelay(20); got != retryLedgerMaxDelay {
+		t.Fatalf("retryLedgerDelay(20) = %v, want cap", got)
+	}
+}
+
+func TestShouldSkipFailedVideo(t *testing.T) {
+	archive, err := newDownloadArchive(filepath.Join(t.TempDir(), "archive.txt"))
+	if err != nil {
+		t.Fatalf("newDownloadArchive() error = %v", err)
+	}
+	defer archive.Close()
+	prev := activeDownloadArchive
+	activeDownloadArchive = archive
+	defer func() { activeDownloadArchive = prev }()
+
+	url := "https://www.youtube.com/watch?v=jNQXAC9IVRw"
+	recordVideoAttempt(url, client.ErrLoginRequired, cli.Options{Simulate: true})
+	if shouldSkipFailedVideo(url, cli.Options{}) {
+		t.Fatalf("failure recorded during --simulate")
+	}
+	recordVideoAttempt(url, client.ErrLoginRequired, cli.Options{})
+	if !shouldSkipFailedVideo(url, cli.Options{}) {
+		t.Fatalf("expected ledger skip")
+	}
+	if shouldSkipFailedVideo(url, cli.Options{RetryFailed: true}) {
+		t.Fatalf("--retry-failed did not override the ledger")
+	}
+	recordVideoAttempt(url, nil, cli.Options{})
+	if shouldSkipFailedVideo(url, cli.Options{}) {
+		t.Fatalf("success did not clear the ledger")
+	}
+}
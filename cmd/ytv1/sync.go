@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/famomatic/ytv1/client"
+	"github.com/famomatic/ytv1/internal/cli"
+	"github.com/famomatic/ytv1/report"
+)
+
+// maxSyncSeenPerChannel bounds remembered upload IDs per channel. The RSS
+// feed only lists the newest ~15 uploads, so older IDs can be dropped.
+const maxSyncSeenPerChannel = 200
+
+// syncState is the --state file: uploads already handled, per channel ID.
+type syncState struct {
+	Channels map[string]*syncChannelState `json:"channels"`
+}
+
+type syncChannelState struct {
+	Title       string    `json:"title,omitempty"`
+	LastChecked time.Time `json:"last_checked"`
+	Seen        []string  `json:"seen"`
+}
+
+func (s *syncChannelState) has(videoID string) bool {
+	for _, id := range s.Seen {
+		if id == videoID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *syncChannelState) markSeen(videoID string) {
+	if s.has(videoID) {
+		return
+	}
+	s.Seen = append(s.Seen, videoID)
+	if extra := len(s.Seen) - maxSyncSeenPerChannel; extra > 0 {
+		s.Seen = append([]string(nil), s.Seen[extra:]...)
+	}
+}
+
+func loadSyncState(path string) (*syncState, error) {
+	state := &syncState{Channels: make(map[string]*syncChannelState)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid sync state %s: %w", path, err)
+	}
+	if state.Channels == nil {
+		state.Channels = make(map[string]*syncChannelState)
+	}
+	return state, nil
+}
+
+// save writes the state through a temp file so an interrupted run keeps the
+// previous state intact.
+func (s *syncState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readChannelList reads one channel per line, skipping blanks and # comments.
+func readChannelList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var channels []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		channels = append(channels, line)
+	}
+	return channels, scanner.Err()
+}
+
+type syncRunner struct {
+	state     *syncState
+	statePath string
+	// dryRun leaves the state file untouched (--simulate).
+	dryRun   bool
+	fetch    func(ctx context.Context, channel string) (*client.ChannelFeed, error)
+	download func(ctx context.Context, videoID string) error
+	now      func() time.Time
+}
+
+// syncChannel downloads feed entries not yet in the channel's state, oldest
+// first. The first run for a channel only records the current feed as a
+// baseline. Failed downloads stay unseen so the next run retries them.
+func (r *syncRunner) syncChannel(ctx context.Context, channel string) error {
+	feed, err := r.fetch(ctx, channel)
+	if err != nil {
+		return err
+	}
+	name := feed.Title
+	if name == "" {
+		name = feed.ChannelID
+	}
+	entry := r.state.Channels[feed.ChannelID]
+	if entry == nil {
+		entry = &syncChannelState{}
+		for i := len(feed.Entries) - 1; i >= 0; i-- {
+			entry.markSeen(feed.Entries[i].VideoID)
+		}
+		fmt.Printf("[sync] %s: first run, recorded %d current uploads as baseline\n", name, len(feed.Entries))
+		return r.commit(feed, entry)
+	}
+
+	var pending []client.ChannelFeedEntry
+	for i := len(feed.Entries) - 1; i >= 0; i-- {
+		if !entry.has(feed.Entries[i].VideoID) {
+			pending = append(pending, feed.Entries[i])
+		}
+	}
+	fmt.Printf("[sync] %s: %d new upload(s)\n", name, len(pending))
+	var errs []error
+	for _, e := range pending {
+		if err := r.download(ctx, e.VideoID); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.VideoID, err))
+			continue
+		}
+		entry.markSeen(e.VideoID)
+	}
+	if err := r.commit(feed, entry); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func (r *syncRunner) commit(feed *client.ChannelFeed, entry *syncChannelState) error {
+	if r.dryRun {
+		return nil
+	}
+	entry.Title = feed.Title
+	entry.LastChecked = r.now().UTC()
+	r.state.Channels[feed.ChannelID] = entry
+	if err := r.state.save(r.statePath); err != nil {
+		return fmt.Errorf("failed to write sync state: %w", err)
+	}
+	return nil
+}
+
+// runSync runs the sync subcommand; each channel is one report input.
+func runSync(ctx context.Context, c *client.Client, opts cli.Options) (report.Run, error) {
+	if strings.TrimSpace(opts.SyncChannels) == "" || strings.TrimSpace(opts.SyncState) == "" {
+		return report.Run{}, errors.New("sync requires --channels and --state")
+	}
+	channels, err := readChannelList(opts.SyncChannels)
+	if err != nil {
+		return report.Run{}, err
+	}
+	state, err := loadSyncState(opts.SyncState)
+	if err != nil {
+		return report.Run{}, err
+	}
+	r := &syncRunner{
+		state:     state,
+		statePath: opts.SyncState,
+		dryRun:    opts.Simulate,
+		fetch:     c.GetChannelFeed,
+		download: func(ctx context.Context, videoID string) error {
+			return processURL(ctx, c, videoID, opts)
+		},
+		now: time.Now,
+	}
+	return runInputs(ctx, c, channels, opts, func(ctx context.Context, _ *client.Client, channel string, _ cli.Options) error {
+		return r.syncChannel(ctx, channel)
+	}), nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/famomatic/ytv1/client"
+)
+
+func TestSyncRunner_BaselineThenDownloadsNewUploads(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state", "state.json")
+	state, err := loadSyncState(statePath)
+	if err != nil {
+		t.Fatalf("loadSyncState(missing) error = %v", err)
+	}
+	feed := &client.ChannelFeed{
+		ChannelID: "UCabcdefghijklmnopqrstuv",
+		Title:     "Example",
+		Entries:   []client.ChannelFeedEntry{{VideoID: "bbbbbbbbbbb"}, {VideoID: "aaaaaaaaaaa"}},
+	}
+	var downloaded []string
+	failing := map[string]bool{}
+	r := &syncRunner{
+		state:     state,
+		statePath: statePath,
+		fetch: func(context.Context, string) (*client.ChannelFeed, error) {
+			return feed, nil
+		},
+		download: func(_ context.Context, videoID string) error {
+			downloaded = append(downloaded, videoID)
+			if failing[videoID] {
+				return client.ErrUnavailable
+			}
+			return nil
+		},
+		now: func() time.Time { return time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC) },
+	}
+
+	if err := r.syncChannel(context.Background(), "@example"); err != nil {
+		t.Fatalf("baseline syncChannel() error = %v", err)
+	}
+	if len(downloaded) != 0 {
+		t.Fatalf("baseline downloaded %v", downloaded)
+	}
+
+	feed.Entries = append([]client.ChannelFeedEntry{{VideoID: "ddddddddddd"}, {VideoID: "ccccccccccc"}}, feed.Entries...)
+	failing["ddddddddddd"] = true
+	err = r.syncChannel(context.Background(), "@example")
+	if !errors.Is(err, client.ErrUnavailable) {
+		t.Fatalf("syncChannel() error = %v, want ErrUnavailable", err)
+	}
+	if !reflect.DeepEqual(downloaded, []string{"ccccccccccc", "ddddddddddd"}) {
+		t.Fatalf("downloaded = %v, want oldest new upload first", downloaded)
+	}
+
+	reloaded, err := loadSyncState(statePath)
+	if err != nil {
+		t.Fatalf("loadSyncState() error = %v", err)
+	}
+	entry := reloaded.Channels["UCabcdefghijklmnopqrstuv"]
+	if entry == nil || entry.Title != "Example" || !entry.LastChecked.Equal(r.now()) {
+		t.Fatalf("state entry = %+v", entry)
+	}
+	if !reflect.DeepEqual(entry.Seen, []string{"aaaaaaaaaaa", "bbbbbbbbbbb", "ccccccccccc"}) {
+		t.Fatalf("seen = %v, failed upload must stay unseen", entry.Seen)
+	}
+
+	downloaded = nil
+	r.state = reloaded
+	failing["ddddddddddd"] = false
+	if err := r.syncChannel(context.Background(), "@example"); err != nil {
+		t.Fatalf("retry syncChannel() error = %v", err)
+	}
+	if !reflect.DeepEqual(downloaded, []string{"ddddddddddd"}) {
+		t.Fatalf("retry downloaded = %v", downloaded)
+	}
+}
+
+func TestSyncRunner_DryRunLeavesStateUntouched(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	r := &syncRunner{
+		state:     &syncState{Channels: map[string]*syncChannelState{}},
+		statePath: statePath,
+		dryRun:    true,
+		fetch: func(context.Context, string) (*client.ChannelFeed, error) {
+			return &client.ChannelFeed{ChannelID: "UCabcdefghijklmnopqrstuv", Entries: []client.ChannelFeedEntry{{VideoID: "aaaaaaaaaaa"}}}, nil
+		},
+		download: func(context.Context, string) error { return nil },
+		now:      time.Now,
+	}
+	if err := r.syncChannel(context.Background(), "UCabcdefghijklmnopqrstuv"); err != nil {
+		t.Fatalf("syncChannel() error = %v", err)
+	}
+	if _, err := os.Stat(statePath); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("dry run wrote state, stat err=%v", err)
+	}
+}
+
+func TestReadChannelList_SkipsCommentsAndBlanks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "channels.txt")
+	content := "# news\nUCabcdefghijklmnopqrstuv\n\n  @example  \n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	got, err := readChannelList(path)
+	if err != nil {
+		t.Fatalf("readChannelList() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"UCabcdefghijklmnopqrstuv", "@example"}) {
+		t.Fatalf("channels = %v", got)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/cli"
+	"github.com/famomatic/ytv1/internal/selfupdate"
+)
+
+// Set by release builds:
+//
+//	-ldflags "-X main.version=v1.2.3 -X main.releasePublicKey=<base64 ed25519>"
+var (
+	version          = ""
+	releasePublicKey = ""
+)
+
+// currentVersion is the release tag, or the module version for go install
+// builds, or "(devel)".
+func currentVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+func newUpdater(opts cli.Options) (*selfupdate.Updater, error) {
+	u := &selfupdate.Updater{Endpoint: strings.TrimSpace(opts.UpdateReleaseURL)}
+	if proxy := strings.TrimSpace(opts.ProxyURL); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --proxy: %w", err)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxyURL)
+		u.HTTPClient = &http.Client{Transport: transport}
+	}
+	if releasePublicKey != "" {
+		key, err := selfupdate.ParsePublicKey(releasePublicKey)
+		if err != nil {
+			return nil, err
+		}
+		u.PublicKey = key
+	}
+	return u, nil
+}
+
+func runUpdateCommand(opts cli.Options) error {
+	u, err := newUpdater(opts)
+	if err != nil {
+		return err
+	}
+	exePath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(exePath); err == nil {
+		exePath = resolved
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	return runUpdate(ctx, os.Stdout, opts, u, exePath, currentVersion())
+}
+
+// runUpdate replaces exePath with the latest release when it is newer than
+// current. With --check it only reports. The tag compared here comes from
+// unsigned release JSON; Download only installs a binary whose signed
+// checksums name that same tag, so an older release cannot pass as newer.
+func runUpdate(ctx context.Context, w io.Writer, opts cli.Options, u *selfupdate.Updater, exePath, current string) error {
+	rel, err := u.Latest(ctx)
+	if err != nil {
+		return err
+	}
+	cmp, comparable := selfupdate.CompareVersions(current, rel.TagName)
+	if comparable && cmp >= 0 {
+		fmt.Fprintf(w, "ytv1 %s is up to date (latest release %s)\n", current, rel.TagName)
+		return nil
+	}
+	if opts.UpdateCheck {
+		fmt.Fprintf(w, "Update available: %s -> %s\n", current, rel.TagName)
+		return nil
+	}
+	bin, err := u.Download(ctx, rel)
+	if errors.Is(err, selfupdate.ErrNoPublicKey) {
+		return errors.New("this build has no release signing key, so downloads cannot be verified; install the release manually")
+	}
+	if err != nil {
+		return err
+	}
+	if err := selfupdate.ReplaceExecutable(exePath, bin); err != nil {
+		return fmt.Errorf("replace %s: %w", exePath, err)
+	}
+	fmt.Fprintf(w, "Updated %s: %s -> %s\n", exePath, current, rel.TagName)
+	return nil
+}
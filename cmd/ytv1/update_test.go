@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/famomatic/ytv1/internal/cli"
+	"github.com/famomatic/ytv1/internal/selfupdate"
+)
+
+func testUpdater(t *testing.T, tag string, bin []byte) *selfupdate.Updater {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := selfupdate.AssetName("linux", "amd64")
+	sum := sha256.Sum256(bin)
+	sums := []byte("# version: " + tag + "\n" + hex.EncodeToString(sum[:]) + "  " + name + "\n")
+	files := map[string][]byte{
+		name:                      bin,
+		selfupdate.ChecksumsAsset: sums,
+		selfupdate.SignatureAsset: ed25519.Sign(priv, sums),
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest" {
+			var assets []string
+			for n := range files {
+				assets = append(assets, `{"name":"`+n+`","browser_download_url":"`+srv.URL+`/`+n+`"}`)
+			}
+			_, _ = w.Write([]byte(`{"tag_name":"` + tag + `","assets":[` + strings.Join(assets, ",") + `]}`))
+			return
+		}
+		_, _ = w.Write(files[strings.TrimPrefix(r.URL.Path, "/")])
+	}))
+	t.Cleanup(srv.Close)
+	return &selfupdate.Updater{Endpoint: srv.URL + "/latest", PublicKey: pub, GOOS: "linux", GOARCH: "amd64"}
+}
+
+func testExecutable(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ytv1")
+	if err := os.WriteFile(path, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunUpdate_UpToDate(t *testing.T) {
+	exe := testExecutable(t)
+	var out bytes.Buffer
+	if err := runUpdate(context.Background(), &out, cli.Options{}, testUpdater(t, "v1.2.0", []byte("new")), exe, "v1.2.0"); err != nil {
+		t.Fatalf("runUpdate() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "is up to date") {
+		t.Fatalf("output = %q", out.String())
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "old" {
+		t.Fatalf("executable replaced: %q", data)
+	}
+}
+
+func TestRunUpdate_CheckOnly(t *testing.T) {
+	exe := testExecutable(t)
+	var out bytes.Buffer
+	if err := runUpdate(context.Background(), &out, cli.Options{UpdateCheck: true}, testUpdater(t, "v1.3.0", []byte("new")), exe, "v1.2.0"); err != nil {
+		t.Fatalf("runUpdate() error = %v", err)
+	}
+	if out.String() != "Update available: v1.2.0 -> v1.3.0\n" {
+		t.Fatalf("output = %q", out.String())
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "old" {
+		t.Fatalf("executable replaced: %q", data)
+	}
+}
+
+func TestRunUpdate_ReplacesExecutable(t *testing.T) {
+	exe := testExecutable(t)
+	var out bytes.Buffer
+	if err := runUpdate(context.Background(), &out, cli.Options{}, testUpdater(t, "v1.3.0", []byte("new")), exe, "(devel)"); err != nil {
+		t.Fatalf("runUpdate() error = %v", err)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "new" {
+		t.Fatalf("executable = %q, want new", data)
+	}
+	if !strings.Contains(out.String(), "Updated ") {
+		t.Fatalf("output = %q", out.String())
+	}
+}
+
+func TestRunUpdate_RequiresSigningKey(t *testing.T) {
+	exe := testExecutable(t)
+	u := testUpdater(t, "v1.3.0", []byte("new"))
+	u.PublicKey = nil
+	err := runUpdate(context.Background(), &bytes.Buffer{}, cli.Options{}, u, exe, "v1.2.0")
+	if err == nil || !strings.Contains(err.Error(), "no release signing key") {
+		t.Fatalf("runUpdate() error = %v", err)
+	}
+}
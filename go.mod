@@ -2,11 +2,14 @@ module github.com/famomatic/ytv1
 
 go 1.23
 
-require github.com/dop251/goja v0.0.0-20260106131823-651366fbe6e3
+require (
+	github.com/andybalholm/brotli v1.2.5
+	github.com/dop251/goja v0.0.0-20260106131823-651366fbe6e3
+	golang.org/x/text v0.3.8
+)
 
 require (
 	github.com/dlclark/regexp2 v1.11.4 // indirect
 	github.com/go-sourcemap/sourcemap v2.1.3+incompatible // indirect
 	github.com/google/pprof v0.0.0-20230207041349-798e818bf904 // indirect
-	golang.org/x/text v0.3.8 // indirect
 )
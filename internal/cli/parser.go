@@ -4,55 +4,232 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"net/http"
-	"net/http/cookiejar"
-	"net/url"
+	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/famomatic/ytv1/client"
 	"github.com/famomatic/ytv1/internal/cookies"
+	"github.com/famomatic/ytv1/internal/downloader"
 	"github.com/famomatic/ytv1/internal/muxer"
 )
 
+const (
+	// CommandSync downloads uploads that are new since the previous sync run.
+	CommandSync = "sync"
+	// CommandCleanup removes intermediate files left behind by crashed downloads.
+	CommandCleanup = "cleanup"
+	// CommandReportBug bundles captured fixtures for a bug report.
+	CommandReportBug = "report-bug"
+	// CommandCompletion prints a shell completion script.
+	CommandCompletion = "completion"
+	// CommandUpdate replaces the executable with the latest signed release.
+	CommandUpdate = "update"
+	// CommandArchive converts download archives between line formats.
+	CommandArchive = "archive"
+)
+
+// Commands lists the subcommands in usage order.
+var Commands = []string{CommandSync, CommandCleanup, CommandReportBug, CommandCompletion, CommandUpdate, CommandArchive}
+
+// CompletionShells lists the shells "completion" can generate scripts for.
+var CompletionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// FormatPresets lists the -f shorthands offered by shell completion.
+// Any selector expression is still accepted.
+var FormatPresets = []string{
+	"best", "worst", "bestvideo+bestaudio", "bv+ba/b", "bestvideo", "bestaudio",
+	"worstvideo", "worstaudio", "videoonly", "audioonly", "mp4", "mp3",
+}
+
+// -F output modes (--format-table).
+const (
+	FormatTablePretty = "pretty"
+	FormatTableJSON   = "json"
+	FormatTableTSV    = "tsv"
+)
+
+// Download archive line formats (--archive-format).
+const (
+	ArchiveFormatYtv1  = "ytv1"
+	ArchiveFormatYtDlp = "yt-dlp"
+)
+
+// Playlist download orders (--playlist-order).
+const (
+	PlaylistOrderNewest   = "newest"
+	PlaylistOrderOldest   = "oldest"
+	PlaylistOrderShortest = "shortest"
+	PlaylistOrderViews    = "views"
+)
+
+// FormatColumns lists the -F columns in default order.
+var FormatColumns = []string{"itag", "ext", "res", "fps", "tbr", "size", "proto", "vcodec", "acodec", "note"}
+
+// PresetPodcast (--preset podcast) downloads best audio as tagged opus/m4a
+// into per-channel folders and records finished IDs in an archive file.
+const PresetPodcast = "podcast"
+
+const (
+	podcastOutputTemplate = "%(uploader)s/%(title)s [%(id)s].%(ext)s"
+	podcastArchiveFile    = "podcast-archive.txt"
+)
+
+// PresetMediaServer (--preset mediaserver) lays videos out for a Jellyfin or
+// Kodi library: one folder per channel holding each video with its .nfo and
+// thumbnail, and a tvshow.nfo naming the channel.
+const PresetMediaServer = "mediaserver"
+
+const mediaServerOutputTemplate = "%(uploader)s/%(title)s [%(id)s].%(ext)s"
+
+// --nfo-style values.
+const (
+	NFOStyleEpisode = "episode"
+	NFOStyleMovie   = "movie"
+)
+
 // Options holds all command-line options.
 type Options struct {
 	// Input
 	URLs []string
 
+	// Command is the subcommand named by the first argument ("", "sync",
+	// "cleanup", "report-bug", "completion", "update" or "archive").
+	Command string
+
+	// Completion
+	CompletionShell string // completion <shell>
+
+	// Archive
+	ArchiveAction string // archive <action>
+
+	// Update
+	UpdateCheck      bool   // update --check
+	UpdateReleaseURL string // update --release-url
+
+	// Sync
+	SyncChannels string // sync --channels
+	SyncState    string // sync --state
+
+	// Cleanup
+	CleanupDir       string        // cleanup --dir
+	CleanupOlderThan time.Duration // cleanup --older-than
+
+	// Fixture capture
+	CaptureDir      string // --capture-dir
+	CaptureRedactID bool   // --capture-redact-id
+	ReportBugBundle string // report-bug --bundle
+
 	// General
 	Help    bool
 	Version bool
 
 	// Network
-	ProxyURL    string
-	CookiesFile string // --cookies
+	ProxyURL            string
+	UserAgent           string        // --user-agent
+	CookiesFile         string        // --cookies
+	ForwardMediaCookies bool          // --forward-media-cookies
+	MediaURLParams      string        // --media-url-params
+	EmulatePlayback     bool          // --emulate-playback
+	PlaybackPings       bool          // --playback-pings
+	InnertubeHost       string        // --innertube-host
+	CookiesWriteBack    bool          // --cookies-write-back
+	NoConsentBypass     bool          // --no-consent-bypass
+	InnertubeRateLimit  int           // --innertube-rate-limit
+	InnertubeMaxWait    time.Duration // --innertube-max-wait
+	CooldownAfter       int           // --cooldown-after
+	CooldownMax         time.Duration // --cooldown-max
+	ClientTimeout       time.Duration // --client-timeout
+	ClientBreakerAfter  int           // --client-breaker-after
+	ClassifyProbe       bool          // --classify-probe
+	ConcurrentFragments int           // -N, --concurrent-fragments
+	MultiSource         bool          // --multi-source
 
 	// Video Selection
-	FormatSelector string // -f, --format
-	ListFormats    bool   // -F, --list-formats
+	FormatSelector  string // -f, --format
+	ListFormats     bool   // -F, --list-formats
+	FormatTable     string // --format-table
+	FormatColumns   string // --format-columns
+	GetURL          bool   // -g, --get-url
+	ReferrerHeaders bool   // --referrer-headers
+	CheckFormats    bool   // --check-formats
+	NoFormatDedup   bool   // --no-format-dedup
+	MaxFileSize     string // --max-filesize
+	MaxTotalBytes   string // --max-total-bytes
+	MaxResolution   int    // --max-resolution
+	DateAfter       string // --dateafter
+	DateBefore      string // --datebefore
+	DownloadTrailer bool   // --download-trailer
+	MetadataLang    string // --metadata-lang
+	// LiveOffset only applies to live HLS captures.
+	LiveOffset time.Duration // --live-offset
+	// WaitForProcessing polls a live replay that is still processing every
+	// ProcessingPoll until its full formats are listed.
+	WaitForProcessing bool          // --wait-for-processing
+	ProcessingPoll    time.Duration // --processing-poll-interval
 
 	// Download / Filesystem
-	OutputTemplate  string // -o, --output
-	DownloadArchive string // --download-archive
-	SkipDownload    bool   // --skip-download
-	NoWarnings      bool   // --no-warnings
-	NoContinue      bool   // --no-continue
-	AbortOnError    bool   // --abort-on-error
-	IgnoreErrors    bool   // -i, --ignore-errors
-	DownloadRetries int    // --retries
-	RetrySleepMS    int    // --retry-sleep-ms
-	WriteSubs       bool   // --write-subs
-	WriteAutoSubs   bool   // --write-auto-subs
-	SubLangs        string // --sub-lang
-	SubFormat       string // --sub-format
-	FlatPlaylist    bool   // --flat-playlist
-	NoPlaylist      bool   // --no-playlist
-	YesPlaylist     bool   // --yes-playlist
+	OutputTemplate  string        // -o, --output
+	NormalizeText   bool          // --normalize-unicode
+	StripEmoji      bool          // --strip-emoji
+	DownloadArchive string        // --download-archive
+	ArchiveFormat   string        // --archive-format
+	LockFile        string        // --lock-file
+	NoLock          bool          // --no-lock
+	RetryFailed     bool          // --retry-failed
+	WriteReport     string        // --write-report
+	SkipDownload    bool          // --skip-download
+	Simulate        bool          // -s, --simulate
+	NoWarnings      bool          // --no-warnings
+	NoContinue      bool          // --no-continue
+	NoMtime         bool          // --no-mtime
+	WriteChecksums  bool          // --write-checksums
+	AbortOnError    bool          // --abort-on-error
+	IgnoreErrors    bool          // -i, --ignore-errors
+	IgnoreNoFormats bool          // --ignore-no-formats-error
+	DownloadRetries int           // --retries
+	FullRetries     int           // --download-retries-full
+	RetrySleepMS    int           // --retry-sleep-ms
+	RetryBudget     int           // --retry-budget
+	RetryBudgetWait time.Duration // --retry-budget-wait
+	ThrottledRate   string        // --throttled-rate
+	AbortOnSlow     string        // --abort-on-slow
+	AbortOnSlowFor  time.Duration // --abort-on-slow-window
+	Downloader      string        // --downloader
+	DownloaderArgs  string        // --downloader-args
+	WriteSubs       bool          // --write-subs
+	WriteAutoSubs   bool          // --write-auto-subs
+	SubLangs        string        // --sub-lang
+	SubFormat       string        // --sub-format
+	NoSubStyling    bool          // --no-sub-styling
+	WriteThumbnail  bool          // --write-thumbnail
+	WriteNFO        bool          // --write-nfo
+	NFOStyle        string        // --nfo-style
+	FlatPlaylist    bool          // --flat-playlist
+	NoPlaylist      bool          // --no-playlist
+	YesPlaylist     bool          // --yes-playlist
+	PlaylistOrder   string        // --playlist-order
+
+	// Caching
+	CacheDir            string        // --cache-dir
+	PlaylistCacheTTL    time.Duration // --playlist-cache-ttl
+	PlaylistIncremental bool          // --playlist-incremental
 
 	// Post-processing
-	MergeOutput bool // --merge-output-format (implied true in ytv1 currently, but we can make it explicit or toggle)
+	MergeOutput       bool          // --merge-output-format (implied true in ytv1 currently, but we can make it explicit or toggle)
+	ExtractAudio      bool          // -x, --extract-audio
+	AudioFormat       string        // --audio-format
+	ValidateOutput    bool          // --validate-output
+	ValidateTolerance time.Duration // --validate-duration-tolerance
+	Preset            string        // --preset
+	ConfigFile        string        // --config
+	Profile           string        // --profile
+
+	// profileErr is a --profile failure, reported by ToClientConfig.
+	profileErr error
 
 	// Advanced / Debug
 	ClientsOverrides    string // --clients
@@ -62,12 +239,19 @@ type Options struct {
 	PoToken             string // --po-token
 	FFmpegLocation      string // --ffmpeg-location
 	ClientHedgeMS       int    // --client-hedge-ms
+	StrictChallenges    bool   // --strict-challenges
 
 	// Verbosity / Debug
 	Verbose         bool
+	VerboseSelector bool // --verbose-selector
 	PrintJSON       bool // --print-json
+	EventsNDJSON    bool // --events-ndjson
 	DumpSingleJSON  bool // --dump-single-json
 	PlayerJSURLOnly bool // --playerjs (legacy/debug)
+	Capabilities    bool // --capabilities
+	CheckConfig     bool // --check-config
+	// WriteDebugReport writes <output base>.debug.json per video.
+	WriteDebugReport bool // --write-debug-report
 }
 
 // ParseFlags parses command-line arguments into Options.
@@ -82,27 +266,86 @@ func ParseFlags() Options {
 	flag.StringVar(&formatShort, "f", "best", "Video format code")
 	flag.StringVar(&formatLong, "format", "best", "Video format code")
 
-	flag.StringVar(&outputShort, "o", "", "Output filename template")
-	flag.StringVar(&outputLong, "output", "", "Output filename template")
+	flag.StringVar(&outputShort, "o", "", "Output filename template; s3://, gs://, webdav:// or webdav+http:// uploads to remote storage")
+	flag.StringVar(&outputLong, "output", "", "Output filename template; s3://, gs://, webdav:// or webdav+http:// uploads to remote storage")
+	flag.BoolVar(&opts.NormalizeText, "normalize-unicode", false, "NFC-normalize titles and uploader names and strip bidi control characters in file names and embedded metadata")
+	flag.BoolVar(&opts.StripEmoji, "strip-emoji", false, "Remove emoji from file names and embedded metadata")
 
 	flag.BoolVar(&listFormatsShort, "F", false, "List available formats")
 	flag.BoolVar(&listFormatsLong, "list-formats", false, "List available formats")
+	flag.StringVar(&opts.FormatTable, "format-table", FormatTablePretty, "Output mode for -F: pretty, json or tsv")
+	flag.StringVar(&opts.FormatColumns, "format-columns", "", "Comma-separated -F columns (default "+strings.Join(FormatColumns, ",")+")")
+
+	flag.BoolVar(&opts.GetURL, "g", false, "Print resolved stream URL(s) for the selected format(s) and exit")
+	flag.BoolVar(&opts.GetURL, "get-url", false, "Print resolved stream URL(s) for the selected format(s) and exit")
+	flag.BoolVar(&opts.ReferrerHeaders, "referrer-headers", false, "With -g, also print the request headers media hosts expect")
+
+	flag.BoolVar(&opts.CheckFormats, "check-formats", false, "Probe selected format URLs and fall back to the next alternative when unavailable")
+	flag.BoolVar(&opts.NoFormatDedup, "no-format-dedup", false, "List every copy of a stream served by different clients or URLs instead of the healthiest one")
+	flag.BoolVar(&opts.DownloadTrailer, "download-trailer", false, "Download the trailer of a premiere or stream that has not started yet instead of failing")
+	flag.BoolVar(&opts.WaitForProcessing, "wait-for-processing", false, "Wait for a finished live stream's replay to finish processing instead of downloading the low-resolution early encode")
+	flag.DurationVar(&opts.ProcessingPoll, "processing-poll-interval", 5*time.Minute, "How often --wait-for-processing re-checks a processing replay")
+	flag.DurationVar(&opts.LiveOffset, "live-offset", 0, "Start live HLS captures this far behind the live edge (e.g. 10m), within the DVR window; 0 starts at the oldest available segment")
+	flag.StringVar(&opts.MaxFileSize, "max-filesize", "", "Skip formats larger than SIZE (e.g. 50M) and abort transfers that exceed it")
+	flag.StringVar(&opts.MaxTotalBytes, "max-total-bytes", "", "Stop the run once SIZE (e.g. 10G) has been received in total, media and metadata included")
+	flag.IntVar(&opts.MaxResolution, "max-resolution", 0, "Limit video formats to this height (e.g. 1080)")
+	flag.StringVar(&opts.DateAfter, "dateafter", "", "Only process videos uploaded on or after this date (YYYYMMDD or today-N(day|week|month|year))")
+	flag.StringVar(&opts.MetadataLang, "metadata-lang", "", "Use the title and description translated to this language (e.g. ko) in output and embedded metadata")
+	flag.StringVar(&opts.DateBefore, "datebefore", "", "Only process videos uploaded on or before this date (same forms as --dateafter)")
 
 	flag.StringVar(&opts.ProxyURL, "proxy", "", "Use the specified HTTP/HTTPS/SOCKS proxy")
+	flag.StringVar(&opts.UserAgent, "user-agent", "", "User-Agent for web pages, player JS and media downloads (Innertube API calls keep each client's own)")
+	flag.IntVar(&opts.InnertubeRateLimit, "innertube-rate-limit", 0, "Cap Innertube API requests per client per hour, queueing the excess (0 = unlimited)")
+	flag.IntVar(&opts.CooldownAfter, "cooldown-after", 0, "Pause new extractions after this many consecutive HTTP 403/429 responses, 30s first and doubling per repeat (0 = off)")
+	flag.DurationVar(&opts.CooldownMax, "cooldown-max", 0, "Longest single --cooldown-after pause (default 10m)")
+	flag.DurationVar(&opts.ClientTimeout, "client-timeout", 0, "Give up on one Innertube client's player request after this long, retries included (0 = no limit)")
+	flag.IntVar(&opts.ClientBreakerAfter, "client-breaker-after", 0, "Skip an Innertube client for 5m after this many consecutive --client-timeout timeouts, then probe it again (0 = off)")
+	flag.BoolVar(&opts.ClassifyProbe, "classify-probe", false, "Probe with one Innertube client first; age-gated videos then use only clients that can play them and unavailable ones fail at once")
+	flag.DurationVar(&opts.InnertubeMaxWait, "innertube-max-wait", 0, "Fail instead of queueing an Innertube request longer than this (0 = wait)")
 	flag.StringVar(&opts.CookiesFile, "cookies", "", "Netscape formatted cookies file")
+	flag.BoolVar(&opts.NoConsentBypass, "no-consent-bypass", false, "Do not answer YouTube's cookie-consent interstitial with SOCS/CONSENT cookies")
+	flag.BoolVar(&opts.ForwardMediaCookies, "forward-media-cookies", false, "Send youtube.com cookies from --cookies to googlevideo.com media hosts")
+	flag.StringVar(&opts.MediaURLParams, "media-url-params", "", "Comma-separated playback parameters to add to media requests: cpn, range, rn")
+	flag.BoolVar(&opts.EmulatePlayback, "emulate-playback", false, "Send one stable playback nonce (cpn) on all media requests of a download")
+	flag.StringVar(&opts.InnertubeHost, "innertube-host", "", "Send Innertube API requests to HOST or a base URL (e.g. youtubei.googleapis.com); comma-separated CLIENT=HOST entries route single clients")
+	flag.BoolVar(&opts.PlaybackPings, "playback-pings", false, "Send playback and watchtime stats pings while downloading (implies --emulate-playback)")
+	flag.BoolVar(&opts.CookiesWriteBack, "cookies-write-back", false, "Write cookies YouTube set or rotated during the run back to the --cookies file on exit")
 
 	flag.BoolVar(&opts.SkipDownload, "skip-download", false, "Do not download the video")
+	flag.BoolVar(&opts.Simulate, "simulate", false, "Extract, select formats and template output paths, then print what would be written without writing anything")
+	flag.BoolVar(&opts.Simulate, "s", false, "Alias of --simulate (yt-dlp compatibility)")
 	flag.BoolVar(&opts.NoWarnings, "no-warnings", false, "Suppress non-critical warning messages")
-	flag.StringVar(&opts.DownloadArchive, "download-archive", "", "File to store downloaded video IDs for idempotent reruns")
+	flag.StringVar(&opts.DownloadArchive, "download-archive", "", "File to store downloaded video IDs for idempotent reruns; videos that fail as unavailable are backed off in <file>.failures.json")
+	flag.StringVar(&opts.ArchiveFormat, "archive-format", "", "Line format for new download archive entries: ytv1 (bare YouTube IDs) or yt-dlp (\"youtube <id>\"); default matches the existing file. Also the target of archive convert")
+	flag.StringVar(&opts.LockFile, "lock-file", "", "Advisory lock file guarding this run (default: <archive>.ytv1-lock and .ytv1-lock in the output directory)")
+	flag.BoolVar(&opts.NoLock, "no-lock", false, "Do not take the workspace lock that stops concurrent runs from sharing an archive or output directory")
+	flag.BoolVar(&opts.RetryFailed, "retry-failed", false, "Retry videos the --download-archive retry ledger is backing off from (deleted, private, login-only)")
+	flag.StringVar(&opts.WriteReport, "write-report", "", "Write an end-of-run JSON report (per-input status, error categories, exit code) to this file")
 	flag.BoolVar(&opts.NoContinue, "no-continue", false, "Do not resume partially downloaded files")
+	flag.BoolVar(&opts.WriteChecksums, "write-checksums", false, "Write a SHA-256 <output>.sha256 sidecar (sha256sum format) for each downloaded file")
+	flag.BoolVar(&opts.NoMtime, "no-mtime", false, "Do not set the downloaded file's modification time to the video's upload date")
 	continueDownloads := true
 	flag.BoolVar(&continueDownloads, "continue", true, "Resume partially downloaded files (yt-dlp compatibility alias)")
 	flag.BoolVar(&opts.AbortOnError, "abort-on-error", false, "Abort batch processing on first error")
 	flag.BoolVar(&opts.AbortOnError, "no-ignore-errors", false, "Abort on download error (yt-dlp compatibility alias)")
 	flag.BoolVar(&opts.IgnoreErrors, "ignore-errors", false, "Continue on download errors (yt-dlp compatibility alias)")
 	flag.BoolVar(&opts.IgnoreErrors, "i", false, "Alias of --ignore-errors (yt-dlp compatibility)")
+	flag.BoolVar(&opts.IgnoreNoFormats, "ignore-no-formats-error", true, "Skip playlist entries that are private, deleted, unavailable or have no formats instead of counting them as failures")
+	failNoFormats := false
+	flag.BoolVar(&failNoFormats, "no-ignore-no-formats-error", false, "Count unavailable playlist entries as failures")
 	flag.IntVar(&opts.DownloadRetries, "retries", -1, "Download retry count override (-1 keeps defaults)")
+	flag.IntVar(&opts.FullRetries, "download-retries-full", 0, "Re-extract and resume a failed video download up to N times")
 	flag.IntVar(&opts.RetrySleepMS, "retry-sleep-ms", -1, "Download retry initial backoff in milliseconds (-1 keeps defaults)")
+	flag.IntVar(&opts.RetryBudget, "retry-budget", 0, "Total retries per video shared by metadata, player JS and download stages (0 = per-stage limits only)")
+	flag.DurationVar(&opts.RetryBudgetWait, "retry-budget-wait", 0, "Total backoff time per video across all stages (e.g. 30s; 0 = unlimited)")
+	flag.IntVar(&opts.ConcurrentFragments, "concurrent-fragments", 0, "Download each stream in byte-range chunks with this many parallel requests (0 = default)")
+	flag.IntVar(&opts.ConcurrentFragments, "N", 0, "Alias of --concurrent-fragments (yt-dlp compatibility)")
+	flag.BoolVar(&opts.MultiSource, "multi-source", false, "Fetch chunks of each direct stream from every mirror serving the same bytes (same-size formats and alternate CDN nodes) in parallel")
+	flag.StringVar(&opts.AbortOnSlow, "abort-on-slow", "", "Abort a stream whose average speed stays below RATE bytes per second (e.g. 50K), refreshing its URL once and then falling back to another format")
+	flag.DurationVar(&opts.AbortOnSlowFor, "abort-on-slow-window", 0, "How long the speed must stay below --abort-on-slow (default 30s)")
+	flag.StringVar(&opts.ThrottledRate, "throttled-rate", "", "Minimum download rate in bytes per second (e.g. 100K) below which throttling is assumed and the stream URL is re-extracted")
+	flag.StringVar(&opts.Downloader, "downloader", "", "External downloader for direct media URLs: aria2c or curl, or a path to either (default: built-in)")
+	flag.StringVar(&opts.DownloaderArgs, "downloader-args", "", "Extra arguments passed to the external downloader (shell-quoted)")
 	writeSRT := false
 	flag.BoolVar(&writeSRT, "write-srt", false, "Alias of --write-subs that forces SRT output (yt-dlp compatibility)")
 	flag.BoolVar(&opts.WriteSubs, "write-subs", false, "Write subtitle file")
@@ -110,10 +353,27 @@ func ParseFlags() Options {
 	flag.StringVar(&opts.SubLangs, "sub-lang", "en", "Languages of the subtitles to download (optional) separated by commas")
 	flag.StringVar(&opts.SubLangs, "sub-langs", "en", "Alias of --sub-lang (yt-dlp compatibility)")
 	flag.StringVar(&opts.SubFormat, "sub-format", "best", "Subtitle format preference (e.g. vtt/srt, best)")
+	flag.BoolVar(&opts.WriteThumbnail, "write-thumbnail", false, "Write the best available thumbnail (probing maxres, sd, hq, mq) next to the output file")
+	flag.BoolVar(&opts.WriteNFO, "write-nfo", false, "Write a Kodi/Jellyfin .nfo metadata file next to the output file")
+	flag.StringVar(&opts.NFOStyle, "nfo-style", NFOStyleEpisode, "Style of --write-nfo files: episode (channel as a show, plus tvshow.nfo in its folder) or movie")
+	flag.BoolVar(&opts.NoSubStyling, "no-sub-styling", false, "Write VTT subtitles as plain text, without YouTube positioning, pen styles and per-word timestamps")
 	flag.BoolVar(&opts.FlatPlaylist, "flat-playlist", false, "Do not resolve and download playlist items, emit flat entries only")
 	flag.BoolVar(&opts.FlatPlaylist, "extract-flat", false, "Alias of --flat-playlist (yt-dlp compatibility)")
 	flag.BoolVar(&opts.NoPlaylist, "no-playlist", false, "Download only the video, if the URL refers to a video and a playlist")
 	flag.BoolVar(&opts.YesPlaylist, "yes-playlist", false, "Download the playlist, if the URL refers to a video and a playlist")
+	flag.StringVar(&opts.PlaylistOrder, "playlist-order", "", "Download playlist items in this order: newest, oldest, shortest or views (fetches each item's metadata first)")
+	flag.StringVar(&opts.CacheDir, "cache-dir", "", "Directory for on-disk caches (default: user cache dir/ytv1)")
+	flag.DurationVar(&opts.PlaylistCacheTTL, "playlist-cache-ttl", 0, "Reuse cached playlist item lists younger than this (e.g. 6h); 0 disables the cache")
+	flag.BoolVar(&opts.PlaylistIncremental, "playlist-incremental", false, "Stop paging a playlist at the first page holding a --download-archive entry (newest-first playlists)")
+
+	flag.BoolVar(&opts.ExtractAudio, "extract-audio", false, "Convert the download to an audio-only file tagged with title/uploader/date (requires ffmpeg)")
+	flag.BoolVar(&opts.ExtractAudio, "x", false, "Alias of --extract-audio (yt-dlp compatibility)")
+	flag.StringVar(&opts.AudioFormat, "audio-format", "best", "Audio format for --extract-audio: best (keep source codec), opus or m4a")
+	flag.BoolVar(&opts.ValidateOutput, "validate-output", false, "Check the finished file with ffprobe: stream counts, video frames and duration (requires ffprobe)")
+	flag.DurationVar(&opts.ValidateTolerance, "validate-duration-tolerance", 0, "Allowed duration difference for --validate-output (default 2s)")
+	flag.StringVar(&opts.ConfigFile, "config", "", "Config file holding --profile definitions (default: user config dir/ytv1/config)")
+	flag.StringVar(&opts.Profile, "profile", "", "Apply the named profile from the config file; explicit flags still win")
+	flag.StringVar(&opts.Preset, "preset", "", "Apply a flag preset; explicit flags still win. podcast: -x -f bestaudio -o \"%(uploader)s/%(title)s [%(id)s].%(ext)s\" --download-archive podcast-archive.txt; mediaserver: --write-nfo --write-thumbnail -o \"%(uploader)s/%(title)s [%(id)s].%(ext)s\"")
 
 	flag.BoolVar(&opts.PrintJSON, "print-json", false, "Be quiet and print the video information as JSON")
 	flag.BoolVar(&opts.PrintJSON, "J", false, "Alias of --print-json (yt-dlp compatibility)")
@@ -121,8 +381,23 @@ func ParseFlags() Options {
 	flag.BoolVar(&opts.PrintJSON, "dump-json", false, "Alias of --print-json (yt-dlp compatibility)")
 	flag.BoolVar(&opts.DumpSingleJSON, "dump-single-json", false, "Print a yt-dlp compatible single-entry JSON payload")
 	flag.BoolVar(&opts.PlayerJSURLOnly, "playerjs", false, "Print player base.js URL only (debug)")
+	flag.BoolVar(&opts.WriteDebugReport, "write-debug-report", false, "Write a JSON debug report per video (clients tried, player version, challenges, PO token filtering, selector trace, final URL parameters) next to the output")
+	flag.BoolVar(&opts.Capabilities, "capabilities", false, "Print supported protocols, clients, muxer/transcoder, JS engine and caches as JSON, then exit")
+	flag.BoolVar(&opts.CheckConfig, "check-config", false, "Check the options for inconsistent settings, print what to fix, then exit (non-zero when problems are found)")
 
 	flag.BoolVar(&opts.Verbose, "verbose", false, "Print various debugging information")
+	flag.BoolVar(&opts.VerboseSelector, "verbose-selector", false, "Print how the format selector was evaluated: formats matching each clause, candidates, picks and rejection reasons")
+	flag.BoolVar(&opts.EventsNDJSON, "events-ndjson", false, "Write extraction/download lifecycle events to stderr as versioned NDJSON")
+
+	flag.StringVar(&opts.SyncChannels, "channels", "", "sync: file listing one channel per line (UC... ID, channel URL or @handle)")
+	flag.StringVar(&opts.SyncState, "state", "", "sync: JSON file recording uploads already seen per channel")
+	flag.StringVar(&opts.CleanupDir, "dir", ".", "cleanup: directory to scan recursively for leftover intermediate files")
+	flag.DurationVar(&opts.CleanupOlderThan, "older-than", 24*time.Hour, "cleanup: only remove files not modified for this long")
+	flag.StringVar(&opts.CaptureDir, "capture-dir", "", "Save anonymized player responses/player JS that failed parsing or deciphering to this directory")
+	flag.BoolVar(&opts.CaptureRedactID, "capture-redact-id", false, "Also redact the video ID inside captured responses")
+	flag.StringVar(&opts.ReportBugBundle, "bundle", "", "report-bug: output archive path (default ytv1-bug-report-<time>.tar.gz)")
+	flag.BoolVar(&opts.UpdateCheck, "check", false, "update: only report whether a newer release exists")
+	flag.StringVar(&opts.UpdateReleaseURL, "release-url", "", "update: release API URL (default: latest GitHub release)")
 
 	// Advanced / Debug flags from original main.go
 	flag.StringVar(&opts.ClientsOverrides, "clients", "", "Comma-separated Innertube client order override")
@@ -132,15 +407,32 @@ func ParseFlags() Options {
 	flag.StringVar(&opts.PoToken, "po-token", "", "Static PO token override (applied to POT-required requests)")
 	flag.StringVar(&opts.FFmpegLocation, "ffmpeg-location", "", "Path to ffmpeg binary")
 	flag.IntVar(&opts.ClientHedgeMS, "client-hedge-ms", 350, "Delay(ms) before launching lower-priority fallback clients")
+	flag.BoolVar(&opts.StrictChallenges, "strict-challenges", false, "Fail instead of using the original URL when the n challenge cannot be solved")
 
 	// Custom usage
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: ytv1 [OPTIONS] URL [URL...]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: ytv1 [OPTIONS] URL [URL...]\n")
+		fmt.Fprintf(os.Stderr, "       ytv1 sync --channels FILE --state FILE [OPTIONS]\n")
+		fmt.Fprintf(os.Stderr, "       ytv1 cleanup [--dir DIR] [--older-than 24h] [--simulate]\n")
+		fmt.Fprintf(os.Stderr, "       ytv1 report-bug --capture-dir DIR [--bundle FILE]\n")
+		fmt.Fprintf(os.Stderr, "       ytv1 completion bash|zsh|fish|powershell\n")
+		fmt.Fprintf(os.Stderr, "       ytv1 update [--check] [--proxy URL]\n")
+		fmt.Fprintf(os.Stderr, "       ytv1 archive convert --archive-format ytv1|yt-dlp IN [OUT]\n\n")
 		fmt.Fprintln(os.Stderr, "Options:")
 		flag.PrintDefaults()
 	}
 
-	flag.Parse()
+	args := os.Args[1:]
+	if len(args) > 0 && isCommand(args[0]) {
+		opts.Command = args[0]
+		args = args[1:]
+	}
+	_ = flag.CommandLine.Parse(args)
+	if opts.Profile != "" {
+		explicit := make(map[string]bool)
+		flag.CommandLine.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+		opts.profileErr = applyProfile(flag.CommandLine, opts.ConfigFile, opts.Profile, explicit)
+	}
 
 	// Consolidate aliases
 	opts.FormatSelector = pickValue(formatShort, formatLong, "best")
@@ -152,6 +444,9 @@ func ParseFlags() Options {
 	if opts.IgnoreErrors {
 		opts.AbortOnError = false
 	}
+	if failNoFormats {
+		opts.IgnoreNoFormats = false
+	}
 	if opts.YesPlaylist {
 		opts.NoPlaylist = false
 	}
@@ -159,11 +454,54 @@ func ParseFlags() Options {
 		opts.WriteSubs = true
 		opts.SubFormat = "srt"
 	}
+	// Profile settings count as explicit for the preset.
+	explicit := make(map[string]bool)
+	flag.CommandLine.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	applyPreset(&opts, explicit)
 
 	opts.URLs = flag.Args()
+	if opts.Command == CommandCompletion && len(opts.URLs) > 0 {
+		opts.CompletionShell, opts.URLs = opts.URLs[0], opts.URLs[1:]
+	}
+	if opts.Command == CommandArchive && len(opts.URLs) > 0 {
+		opts.ArchiveAction, opts.URLs = opts.URLs[0], opts.URLs[1:]
+	}
 	return opts
 }
 
+func isCommand(arg string) bool {
+	for _, cmd := range Commands {
+		if arg == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+// applyPreset fills in the preset's flags that were not given explicitly.
+// Unknown presets are left for ToClientConfig to reject.
+func applyPreset(opts *Options, explicit map[string]bool) {
+	switch opts.Preset {
+	case PresetPodcast:
+		opts.ExtractAudio = true
+		if !explicit["f"] && !explicit["format"] {
+			opts.FormatSelector = "bestaudio"
+		}
+		if !explicit["o"] && !explicit["output"] {
+			opts.OutputTemplate = podcastOutputTemplate
+		}
+		if !explicit["download-archive"] {
+			opts.DownloadArchive = podcastArchiveFile
+		}
+	case PresetMediaServer:
+		opts.WriteNFO = true
+		opts.WriteThumbnail = true
+		if !explicit["o"] && !explicit["output"] {
+			opts.OutputTemplate = mediaServerOutputTemplate
+		}
+	}
+}
+
 func pickValue(v1, v2, def string) string {
 	if v1 != def {
 		return v1
@@ -178,8 +516,12 @@ func pickValue(v1, v2, def string) string {
 // ToClientConfig converts Options to client.Config.
 func ToClientConfig(opts Options) (client.Config, error) {
 	cfg := client.Config{
-		ProxyURL:    opts.ProxyURL,
-		VisitorData: opts.VisitorData,
+		ProxyURL:         opts.ProxyURL,
+		VisitorData:      opts.VisitorData,
+		StrictChallenges: opts.StrictChallenges,
+	}
+	if opts.profileErr != nil {
+		return cfg, opts.profileErr
 	}
 	langs := parseSubLangs(opts.SubLangs)
 	if len(langs) > 0 {
@@ -199,11 +541,172 @@ func ToClientConfig(opts Options) (client.Config, error) {
 		cfg.DownloadTransport.MaxRetries = opts.DownloadRetries
 		cfg.MetadataTransport.MaxRetries = opts.DownloadRetries
 	}
+	if ua := strings.TrimSpace(opts.UserAgent); ua != "" {
+		cfg.UserAgents = client.UserAgentConfig{WatchPage: ua, PlayerJS: ua, Media: ua}
+	}
+	if opts.ConcurrentFragments < 0 {
+		return cfg, fmt.Errorf("invalid --concurrent-fragments: must not be negative")
+	}
+	if opts.ConcurrentFragments > 0 {
+		cfg.DownloadTransport.EnableChunked = true
+		cfg.DownloadTransport.MaxConcurrency = opts.ConcurrentFragments
+	}
+	cfg.DownloadTransport.MultiSource = opts.MultiSource
+	cfg.DisableFormatDedup = opts.NoFormatDedup
+	cfg.DisableConsentBypass = opts.NoConsentBypass
+	if lang := strings.TrimSpace(opts.MetadataLang); lang != "" {
+		if strings.ContainsAny(lang, " ,;") {
+			return cfg, fmt.Errorf("invalid --metadata-lang %q: want a single language code such as ko or pt-BR", lang)
+		}
+		cfg.MetadataLanguage = lang
+	}
+	cfg.TextNormalization = TextNormalization(opts)
 	if opts.RetrySleepMS >= 0 {
 		backoff := time.Duration(opts.RetrySleepMS) * time.Millisecond
 		cfg.DownloadTransport.InitialBackoff = backoff
 		cfg.MetadataTransport.InitialBackoff = backoff
 	}
+	if opts.RetryBudget < 0 {
+		return cfg, fmt.Errorf("invalid --retry-budget: must not be negative")
+	}
+	if opts.RetryBudgetWait < 0 {
+		return cfg, fmt.Errorf("invalid --retry-budget-wait: must not be negative")
+	}
+	cfg.RetryBudget = client.RetryBudgetConfig{MaxRetries: opts.RetryBudget, MaxDelay: opts.RetryBudgetWait}
+	if opts.LiveOffset < 0 {
+		return cfg, fmt.Errorf("invalid --live-offset: must not be negative")
+	}
+	if opts.WaitForProcessing && opts.ProcessingPoll <= 0 {
+		return cfg, fmt.Errorf("invalid --processing-poll-interval: must be positive")
+	}
+	if opts.InnertubeRateLimit < 0 {
+		return cfg, fmt.Errorf("invalid --innertube-rate-limit: must not be negative")
+	}
+	cfg.InnertubeQuota.RequestsPerHour = opts.InnertubeRateLimit
+	cfg.InnertubeQuota.MaxWait = opts.InnertubeMaxWait
+	if opts.CooldownAfter < 0 || opts.CooldownMax < 0 {
+		return cfg, fmt.Errorf("invalid --cooldown-after/--cooldown-max: must not be negative")
+	}
+	cfg.RateLimitCooldown.Threshold = opts.CooldownAfter
+	cfg.RateLimitCooldown.Max = opts.CooldownMax
+	if opts.ClientTimeout < 0 || opts.ClientBreakerAfter < 0 {
+		return cfg, fmt.Errorf("invalid --client-timeout/--client-breaker-after: must not be negative")
+	}
+	if opts.ClientBreakerAfter > 0 && opts.ClientTimeout == 0 {
+		return cfg, fmt.Errorf("invalid --client-breaker-after: requires --client-timeout")
+	}
+	cfg.ClientTimeout = opts.ClientTimeout
+	cfg.ClientCircuitBreaker.Threshold = opts.ClientBreakerAfter
+	cfg.ClassifyProbe = opts.ClassifyProbe
+	switch opts.Preset {
+	case "", PresetPodcast, PresetMediaServer:
+	default:
+		return cfg, fmt.Errorf("invalid --preset %q: want %s or %s", opts.Preset, PresetPodcast, PresetMediaServer)
+	}
+	switch opts.NFOStyle {
+	case "", NFOStyleEpisode, NFOStyleMovie:
+	default:
+		return cfg, fmt.Errorf("invalid --nfo-style %q: want %s or %s", opts.NFOStyle, NFOStyleEpisode, NFOStyleMovie)
+	}
+	switch opts.ArchiveFormat {
+	case "", ArchiveFormatYtv1, ArchiveFormatYtDlp:
+	default:
+		return cfg, fmt.Errorf("invalid --archive-format %q: want %s or %s", opts.ArchiveFormat, ArchiveFormatYtv1, ArchiveFormatYtDlp)
+	}
+	switch opts.FormatTable {
+	case "", FormatTablePretty, FormatTableJSON, FormatTableTSV:
+	default:
+		return cfg, fmt.Errorf("invalid --format-table %q: want pretty, json or tsv", opts.FormatTable)
+	}
+	switch opts.PlaylistOrder {
+	case "", PlaylistOrderNewest, PlaylistOrderOldest, PlaylistOrderShortest, PlaylistOrderViews:
+	default:
+		return cfg, fmt.Errorf("invalid --playlist-order %q: want newest, oldest, shortest or views", opts.PlaylistOrder)
+	}
+	if _, err := ParseFormatColumns(opts.FormatColumns); err != nil {
+		return cfg, fmt.Errorf("invalid --format-columns: %w", err)
+	}
+	if opts.ExtractAudio {
+		switch opts.AudioFormat {
+		case client.AudioFormatBest, client.AudioFormatOpus, client.AudioFormatM4A:
+		default:
+			return cfg, fmt.Errorf("invalid --audio-format %q: want best, opus or m4a", opts.AudioFormat)
+		}
+	}
+	cfg.CaptureDir = strings.TrimSpace(opts.CaptureDir)
+	cfg.CaptureRedactVideoID = opts.CaptureRedactID
+	if strings.TrimSpace(opts.ThrottledRate) != "" {
+		rate, err := parseByteRate(opts.ThrottledRate)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --throttled-rate: %w", err)
+		}
+		cfg.ThrottleDetection.MinBytesPerSecond = rate
+	}
+	if strings.TrimSpace(opts.AbortOnSlow) != "" {
+		rate, err := parseByteRate(opts.AbortOnSlow)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --abort-on-slow: %w", err)
+		}
+		cfg.SlowDownloadAbort.MinBytesPerSecond = rate
+	} else if opts.AbortOnSlowFor != 0 {
+		return cfg, fmt.Errorf("invalid --abort-on-slow-window: requires --abort-on-slow")
+	}
+	if opts.AbortOnSlowFor < 0 {
+		return cfg, fmt.Errorf("invalid --abort-on-slow-window: must not be negative")
+	}
+	cfg.SlowDownloadAbort.Window = opts.AbortOnSlowFor
+	if opts.ValidateTolerance != 0 && !opts.ValidateOutput {
+		return cfg, fmt.Errorf("invalid --validate-duration-tolerance: requires --validate-output")
+	}
+	if opts.ValidateTolerance < 0 {
+		return cfg, fmt.Errorf("invalid --validate-duration-tolerance: must not be negative")
+	}
+	if strings.TrimSpace(opts.MaxFileSize) != "" {
+		if _, err := ParseByteSize(opts.MaxFileSize); err != nil {
+			return cfg, fmt.Errorf("invalid --max-filesize: %w", err)
+		}
+	}
+	if strings.TrimSpace(opts.MaxTotalBytes) != "" {
+		limit, err := ParseByteSize(opts.MaxTotalBytes)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --max-total-bytes: %w", err)
+		}
+		cfg.MaxTotalBytes = limit
+	}
+
+	if _, err := ParseDateRange(opts.DateAfter, opts.DateBefore); err != nil {
+		return cfg, fmt.Errorf("invalid --dateafter/--datebefore: %w", err)
+	}
+	cfg.CacheDir = strings.TrimSpace(opts.CacheDir)
+	if opts.PlaylistCacheTTL > 0 {
+		cfg.PlaylistCacheTTL = opts.PlaylistCacheTTL
+		if cfg.CacheDir == "" {
+			dir, err := os.UserCacheDir()
+			if err != nil {
+				return cfg, fmt.Errorf("--playlist-cache-ttl requires --cache-dir: %w", err)
+			}
+			cfg.CacheDir = filepath.Join(dir, "ytv1")
+		}
+	}
+
+	if name := strings.TrimSpace(opts.Downloader); name != "" {
+		args, err := downloader.SplitArgs(opts.DownloaderArgs)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --downloader-args: %w", err)
+		}
+		path := ""
+		if strings.ContainsAny(name, `/\`) {
+			path = name
+			name = strings.TrimSuffix(filepath.Base(name), ".exe")
+		}
+		ext, err := downloader.NewExternalDownloader(name, path, args)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid --downloader: %w", err)
+		}
+		cfg.ExternalDownloader = ext
+	} else if strings.TrimSpace(opts.DownloaderArgs) != "" {
+		return cfg, fmt.Errorf("invalid --downloader-args: requires --downloader")
+	}
 
 	// Muxer check (ffmpeg)
 	cfg.Muxer = muxer.NewFFmpegMuxer(opts.FFmpegLocation)
@@ -221,6 +724,9 @@ func ToClientConfig(opts Options) (client.Config, error) {
 		}
 	}
 
+	if opts.CookiesWriteBack && opts.CookiesFile == "" {
+		return cfg, fmt.Errorf("invalid --cookies-write-back: requires --cookies")
+	}
 	// Load Cookies
 	if opts.CookiesFile != "" {
 		f, err := os.Open(opts.CookiesFile)
@@ -229,41 +735,110 @@ func ToClientConfig(opts Options) (client.Config, error) {
 		}
 		defer f.Close()
 
-		cookiesList, err := cookies.ParseNetscape(f)
+		cookiesList, diags, err := cookies.ParseNetscapeWithDiagnostics(f)
 		if err != nil {
 			return cfg, fmt.Errorf("failed to parse cookies file: %w", err)
 		}
+		if !opts.NoWarnings {
+			for _, d := range diags {
+				log.Printf("WARNING: cookies file %s: skipped %v", opts.CookiesFile, d)
+			}
+		}
 
-		jar, err := cookiejar.New(nil)
+		jar, err := cookies.NewJar(cookiesList)
 		if err != nil {
 			return cfg, fmt.Errorf("failed to create cookie jar: %w", err)
 		}
 
-		// Map by domain
-		domainCookies := make(map[string][]*http.Cookie)
-		for _, c := range cookiesList {
-			domainCookies[c.Domain] = append(domainCookies[c.Domain], c)
-		}
+		cfg.CookieJar = jar
+		cfg.MediaCookies.Enable = opts.ForwardMediaCookies
+	}
 
-		for domain, cs := range domainCookies {
-			// Construct a fake URL for the domain
-			scheme := "http"
-			// Check if any cookie is secure
-			for _, c := range cs {
-				if c.Secure {
-					scheme = "https"
-					break
-				}
+	params, err := parseMediaURLParams(opts.MediaURLParams)
+	if err != nil {
+		return cfg, fmt.Errorf("invalid --media-url-params: %w", err)
+	}
+	cfg.MediaURLParams = params
+	cfg.EmulatePlayback.Enable = opts.EmulatePlayback || opts.PlaybackPings
+	cfg.EmulatePlayback.StatsPings = opts.PlaybackPings
+
+	cfg.InnertubeHost, cfg.InnertubeHosts = parseInnertubeHost(opts.InnertubeHost)
+
+	return cfg, nil
+}
+
+// parseInnertubeHost splits an --innertube-host value into the host for
+// all clients and per-client CLIENT=HOST overrides. Config.Validate
+// reports malformed hosts.
+func parseInnertubeHost(raw string) (string, map[string]string) {
+	var (
+		host string
+		byID map[string]string
+	)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		id, h, ok := strings.Cut(part, "=")
+		switch {
+		case part == "":
+		case !ok:
+			host = part
+		default:
+			if byID == nil {
+				byID = make(map[string]string)
 			}
-			host := strings.TrimPrefix(domain, ".")
-			u := &url.URL{Scheme: scheme, Host: host}
-			jar.SetCookies(u, cs)
+			byID[strings.ToLower(strings.TrimSpace(id))] = strings.TrimSpace(h)
 		}
+	}
+	return host, byID
+}
 
-		cfg.CookieJar = jar
+// parseMediaURLParams parses a --media-url-params list.
+func parseMediaURLParams(raw string) (client.MediaURLParamsConfig, error) {
+	var params client.MediaURLParamsConfig
+	for _, part := range strings.Split(raw, ",") {
+		switch name := strings.ToLower(strings.TrimSpace(part)); name {
+		case "":
+		case "cpn":
+			params.CPN = true
+		case "range":
+			params.Range = true
+		case "rn":
+			params.RequestNumber = true
+		default:
+			return params, fmt.Errorf("unknown parameter %q (want cpn, range, rn)", name)
+		}
 	}
+	return params, nil
+}
 
-	return cfg, nil
+// ParseFormatColumns splits a --format-columns value, returning FormatColumns
+// when raw is empty.
+func ParseFormatColumns(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return append([]string(nil), FormatColumns...), nil
+	}
+	var cols []string
+	for _, part := range strings.Split(raw, ",") {
+		col := strings.ToLower(strings.TrimSpace(part))
+		if col == "" {
+			continue
+		}
+		known := false
+		for _, c := range FormatColumns {
+			if c == col {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q (want %s)", col, strings.Join(FormatColumns, ","))
+		}
+		cols = append(cols, col)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("no columns selected")
+	}
+	return cols, nil
 }
 
 func parseSubLangs(raw string) []string {
@@ -284,8 +859,140 @@ func parseSubLangs(raw string) []string {
 	return out
 }
 
+// ParseByteSize parses sizes such as "500K" or "1.5G" (same forms as --throttled-rate).
+func ParseByteSize(raw string) (int64, error) {
+	return parseByteRate(raw)
+}
+
+// parseByteRate parses yt-dlp style byte rates such as "50000", "100K" or "1.5M".
+func parseByteRate(raw string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "/S"), "B")
+	multiplier := 1.0
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier != 1 {
+			s = s[:len(s)-1]
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("%q is not a positive byte rate", raw)
+	}
+	return int64(v * multiplier), nil
+}
+
+// DateRange is the inclusive upload-date window from --dateafter/--datebefore.
+// A zero bound is open.
+type DateRange struct {
+	After  time.Time
+	Before time.Time
+}
+
+// IsZero reports whether neither bound is set.
+func (r DateRange) IsZero() bool {
+	return r.After.IsZero() && r.Before.IsZero()
+}
+
+// Contains reports whether date (compared by UTC calendar day) is inside the
+// range. A zero date is unknown and always passes.
+func (r DateRange) Contains(date time.Time) bool {
+	if date.IsZero() {
+		return true
+	}
+	day := truncateDay(date)
+	if !r.After.IsZero() && day.Before(r.After) {
+		return false
+	}
+	if !r.Before.IsZero() && day.After(r.Before) {
+		return false
+	}
+	return true
+}
+
+// ParseDateRange parses yt-dlp style dates: "YYYYMMDD", "today"/"now", or a
+// relative form such as "today-2weeks" or "now-1day" (day, week, month, year).
+func ParseDateRange(after, before string) (DateRange, error) {
+	return parseDateRange(after, before, time.Now())
+}
+
+func parseDateRange(after, before string, now time.Time) (DateRange, error) {
+	var r DateRange
+	var err error
+	if strings.TrimSpace(after) != "" {
+		if r.After, err = parseDate(after, now); err != nil {
+			return r, err
+		}
+	}
+	if strings.TrimSpace(before) != "" {
+		if r.Before, err = parseDate(before, now); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+func parseDate(raw string, now time.Time) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if t, err := time.Parse("20060102", s); err == nil {
+		return t, nil
+	}
+	base, rest := s, ""
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		base, rest = s[:i], s[i+1:]
+	}
+	if base != "today" && base != "now" {
+		return time.Time{}, fmt.Errorf("%q is not a date (YYYYMMDD or today[-N(day|week|month|year)])", raw)
+	}
+	day := truncateDay(now)
+	if rest == "" {
+		return day, nil
+	}
+	i := 0
+	for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+		i++
+	}
+	n, err := strconv.Atoi(rest[:i])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q has no relative amount", raw)
+	}
+	switch strings.TrimSuffix(rest[i:], "s") {
+	case "day":
+		return day.AddDate(0, 0, -n), nil
+	case "week":
+		return day.AddDate(0, 0, -7*n), nil
+	case "month":
+		return day.AddDate(0, -n, 0), nil
+	case "year":
+		return day.AddDate(-n, 0, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("%q has an unknown relative unit", raw)
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
 type staticPoTokenProvider string
 
 func (p staticPoTokenProvider) GetToken(_ context.Context, _ string) (string, error) {
 	return string(p), nil
 }
+
+// TextNormalization returns the file name and metadata normalization
+// selected by --normalize-unicode and --strip-emoji.
+func TextNormalization(opts Options) client.TextNormalization {
+	return client.TextNormalization{
+		NFC:        opts.NormalizeText,
+		StripBidi:  opts.NormalizeText,
+		StripEmoji: opts.StripEmoji,
+	}
+}
@@ -5,8 +5,15 @@ import (
 	"flag"
 	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/famomatic/ytv1/client"
+	"github.com/famomatic/ytv1/internal/cookies"
+	"github.com/famomatic/ytv1/internal/downloader"
 )
 
 func TestToClientConfig_StaticPoTokenProvider(t *testing.T) {
@@ -57,6 +64,53 @@ func TestToClientConfig_RetryOverrides(t *testing.T) {
 	}
 }
 
+func TestToClientConfig_ThrottledRate(t *testing.T) {
+	cfg, err := ToClientConfig(Options{ThrottledRate: "100K"})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.ThrottleDetection.MinBytesPerSecond != 100*1024 {
+		t.Fatalf("MinBytesPerSecond = %d, want %d", cfg.ThrottleDetection.MinBytesPerSecond, 100*1024)
+	}
+	if _, err := ToClientConfig(Options{ThrottledRate: "fast"}); err == nil {
+		t.Fatal("expected invalid --throttled-rate to fail")
+	}
+}
+
+func TestToClientConfig_MediaURLParams(t *testing.T) {
+	cfg, err := ToClientConfig(Options{MediaURLParams: "cpn, RN"})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	want := client.MediaURLParamsConfig{CPN: true, RequestNumber: true}
+	if cfg.MediaURLParams != want {
+		t.Fatalf("MediaURLParams = %+v, want %+v", cfg.MediaURLParams, want)
+	}
+	if _, err := ToClientConfig(Options{MediaURLParams: "cpn,sq"}); err == nil {
+		t.Fatal("expected unknown --media-url-params name to fail")
+	}
+}
+
+func TestToClientConfig_PlaybackPingsImplyEmulation(t *testing.T) {
+	cfg, err := ToClientConfig(Options{PlaybackPings: true})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if !cfg.EmulatePlayback.Enable || !cfg.EmulatePlayback.StatsPings {
+		t.Fatalf("EmulatePlayback = %+v, want enabled with stats pings", cfg.EmulatePlayback)
+	}
+}
+
+func TestToClientConfig_InnertubeHost(t *testing.T) {
+	cfg, err := ToClientConfig(Options{InnertubeHost: "youtubei.googleapis.com, IOS=http://proxy:8080"})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.InnertubeHost != "youtubei.googleapis.com" || !reflect.DeepEqual(cfg.InnertubeHosts, map[string]string{"ios": "http://proxy:8080"}) {
+		t.Fatalf("InnertubeHost = %q, InnertubeHosts = %v", cfg.InnertubeHost, cfg.InnertubeHosts)
+	}
+}
+
 func TestToClientConfig_SubtitlePolicyFromFlags(t *testing.T) {
 	cfg, err := ToClientConfig(Options{
 		SubLangs:      "ko, en ,ko",
@@ -175,6 +229,33 @@ func TestParseFlags_SubLangsAliasSingleDashCompatibility(t *testing.T) {
 	}
 }
 
+func TestParseFlags_IgnoreNoFormatsErrorPolicy(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+
+	for _, tc := range []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"ytv1", "jNQXAC9IVRw"}, true},
+		{[]string{"ytv1", "--no-ignore-no-formats-error", "jNQXAC9IVRw"}, false},
+		{[]string{"ytv1", "--ignore-no-formats-error=false", "jNQXAC9IVRw"}, false},
+	} {
+		os.Args = tc.args
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+		flag.CommandLine.SetOutput(io.Discard)
+
+		opts := ParseFlags()
+		if opts.IgnoreNoFormats != tc.want {
+			t.Fatalf("%v: IgnoreNoFormats=%v, want %v", tc.args[1:], opts.IgnoreNoFormats, tc.want)
+		}
+	}
+}
+
 func TestParseFlags_WriteSRTAliasForcesSRTOutput(t *testing.T) {
 	origArgs := os.Args
 	origFlagSet := flag.CommandLine
@@ -219,3 +300,522 @@ func TestParseFlags_DumpSingleJSON(t *testing.T) {
 		t.Fatalf("URLs=%v, want [jNQXAC9IVRw]", opts.URLs)
 	}
 }
+
+func TestParseFlags_GetURLAndReferrerHeaders(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+
+	os.Args = []string{"ytv1", "-g", "--referrer-headers", "jNQXAC9IVRw"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseFlags()
+	if !opts.GetURL {
+		t.Fatalf("GetURL=%v, want true", opts.GetURL)
+	}
+	if !opts.ReferrerHeaders {
+		t.Fatalf("ReferrerHeaders=%v, want true", opts.ReferrerHeaders)
+	}
+	if len(opts.URLs) != 1 || opts.URLs[0] != "jNQXAC9IVRw" {
+		t.Fatalf("URLs=%v, want [jNQXAC9IVRw]", opts.URLs)
+	}
+}
+
+func TestToClientConfig_PlaylistCache(t *testing.T) {
+	cfg, err := ToClientConfig(Options{CacheDir: "/tmp/ytv1-cache", PlaylistCacheTTL: 6 * time.Hour})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.CacheDir != "/tmp/ytv1-cache" || cfg.PlaylistCacheTTL != 6*time.Hour {
+		t.Fatalf("CacheDir=%q PlaylistCacheTTL=%v", cfg.CacheDir, cfg.PlaylistCacheTTL)
+	}
+	t.Setenv("XDG_CACHE_HOME", "/tmp/xdg-cache")
+	t.Setenv("HOME", "/tmp/home")
+	cfg, err = ToClientConfig(Options{PlaylistCacheTTL: time.Hour})
+	if err != nil {
+		t.Fatalf("ToClientConfig(default dir) error = %v", err)
+	}
+	if cfg.CacheDir == "" || filepath.Base(cfg.CacheDir) != "ytv1" {
+		t.Fatalf("default CacheDir = %q, want <user cache dir>/ytv1", cfg.CacheDir)
+	}
+}
+
+func TestToClientConfig_InnertubeQuota(t *testing.T) {
+	cfg, err := ToClientConfig(Options{InnertubeRateLimit: 500, InnertubeMaxWait: time.Minute})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.InnertubeQuota.RequestsPerHour != 500 || cfg.InnertubeQuota.MaxWait != time.Minute {
+		t.Fatalf("InnertubeQuota = %+v", cfg.InnertubeQuota)
+	}
+	if _, err := ToClientConfig(Options{InnertubeRateLimit: -1}); err == nil {
+		t.Fatal("expected negative --innertube-rate-limit to fail")
+	}
+}
+
+func TestToClientConfig_UserAgent(t *testing.T) {
+	cfg, err := ToClientConfig(Options{UserAgent: " custom/1.0 "})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	want := client.UserAgentConfig{WatchPage: "custom/1.0", PlayerJS: "custom/1.0", Media: "custom/1.0"}
+	if !reflect.DeepEqual(cfg.UserAgents, want) {
+		t.Fatalf("UserAgents = %+v, want %+v", cfg.UserAgents, want)
+	}
+}
+
+func TestToClientConfig_MultiSource(t *testing.T) {
+	cfg, err := ToClientConfig(Options{MultiSource: true, ConcurrentFragments: 8})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if !cfg.DownloadTransport.MultiSource || !cfg.DownloadTransport.EnableChunked || cfg.DownloadTransport.MaxConcurrency != 8 {
+		t.Fatalf("DownloadTransport = %+v", cfg.DownloadTransport)
+	}
+}
+
+func TestToClientConfig_NoFormatDedup(t *testing.T) {
+	cfg, err := ToClientConfig(Options{NoFormatDedup: true})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if !cfg.DisableFormatDedup {
+		t.Fatal("DisableFormatDedup = false, want true")
+	}
+}
+
+func TestToClientConfig_ProcessingPollInterval(t *testing.T) {
+	if _, err := ToClientConfig(Options{WaitForProcessing: true, ProcessingPoll: time.Minute}); err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if _, err := ToClientConfig(Options{WaitForProcessing: true}); err == nil {
+		t.Fatal("expected error for --wait-for-processing with a zero poll interval")
+	}
+}
+
+func TestToClientConfig_AbortOnSlow(t *testing.T) {
+	cfg, err := ToClientConfig(Options{AbortOnSlow: "50K", AbortOnSlowFor: 20 * time.Second})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.SlowDownloadAbort.MinBytesPerSecond != 50<<10 || cfg.SlowDownloadAbort.Window != 20*time.Second {
+		t.Fatalf("SlowDownloadAbort = %+v", cfg.SlowDownloadAbort)
+	}
+	if _, err := ToClientConfig(Options{AbortOnSlowFor: time.Minute}); err == nil {
+		t.Fatal("expected error for --abort-on-slow-window without --abort-on-slow")
+	}
+}
+
+func TestToClientConfig_ValidateDurationTolerance(t *testing.T) {
+	if _, err := ToClientConfig(Options{ValidateOutput: true, ValidateTolerance: 5 * time.Second}); err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if _, err := ToClientConfig(Options{ValidateTolerance: 5 * time.Second}); err == nil {
+		t.Fatal("expected error for --validate-duration-tolerance without --validate-output")
+	}
+}
+
+func TestToClientConfig_TextNormalization(t *testing.T) {
+	cfg, err := ToClientConfig(Options{NormalizeText: true})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if !cfg.TextNormalization.NFC || !cfg.TextNormalization.StripBidi || cfg.TextNormalization.StripEmoji {
+		t.Fatalf("TextNormalization = %+v", cfg.TextNormalization)
+	}
+	if cfg, _ := ToClientConfig(Options{StripEmoji: true}); cfg.TextNormalization != (client.TextNormalization{StripEmoji: true}) {
+		t.Fatalf("TextNormalization = %+v", cfg.TextNormalization)
+	}
+}
+
+func TestToClientConfig_RetryBudget(t *testing.T) {
+	cfg, err := ToClientConfig(Options{RetryBudget: 5, RetryBudgetWait: 30 * time.Second})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.RetryBudget.MaxRetries != 5 || cfg.RetryBudget.MaxDelay != 30*time.Second {
+		t.Fatalf("RetryBudget = %+v", cfg.RetryBudget)
+	}
+	if _, err := ToClientConfig(Options{RetryBudget: -1}); err == nil {
+		t.Fatal("expected error for a negative --retry-budget")
+	}
+}
+
+func TestToClientConfig_NoConsentBypass(t *testing.T) {
+	cfg, err := ToClientConfig(Options{NoConsentBypass: true})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if !cfg.DisableConsentBypass {
+		t.Fatal("DisableConsentBypass = false, want true")
+	}
+}
+
+func TestToClientConfig_MetadataLang(t *testing.T) {
+	cfg, err := ToClientConfig(Options{MetadataLang: " ko "})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.MetadataLanguage != "ko" {
+		t.Fatalf("MetadataLanguage = %q, want ko", cfg.MetadataLanguage)
+	}
+	if _, err := ToClientConfig(Options{MetadataLang: "ko,en"}); err == nil {
+		t.Fatal("expected error for a language list")
+	}
+}
+
+func TestToClientConfig_ExternalDownloader(t *testing.T) {
+	cfg, err := ToClientConfig(Options{Downloader: "/opt/bin/aria2c", DownloaderArgs: `-x16 --header "X-Foo: bar"`})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	ext, ok := cfg.ExternalDownloader.(*downloader.ExternalDownloader)
+	if !ok {
+		t.Fatalf("ExternalDownloader = %T", cfg.ExternalDownloader)
+	}
+	want := &downloader.ExternalDownloader{Name: "aria2c", Path: "/opt/bin/aria2c", Args: []string{"-x16", "--header", "X-Foo: bar"}}
+	if !reflect.DeepEqual(ext, want) {
+		t.Fatalf("ExternalDownloader = %+v, want %+v", ext, want)
+	}
+	for _, opts := range []Options{{Downloader: "wget"}, {DownloaderArgs: "-x16"}, {Downloader: "curl", DownloaderArgs: `"open`}} {
+		if _, err := ToClientConfig(opts); err == nil {
+			t.Fatalf("ToClientConfig(%+v) error = nil", opts)
+		}
+	}
+}
+
+func TestToClientConfig_RateLimitCooldown(t *testing.T) {
+	cfg, err := ToClientConfig(Options{CooldownAfter: 3, CooldownMax: 5 * time.Minute})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.RateLimitCooldown.Threshold != 3 || cfg.RateLimitCooldown.Max != 5*time.Minute {
+		t.Fatalf("RateLimitCooldown = %+v", cfg.RateLimitCooldown)
+	}
+	if _, err := ToClientConfig(Options{CooldownAfter: -1}); err == nil {
+		t.Fatal("expected negative --cooldown-after to fail")
+	}
+}
+
+func TestToClientConfig_ClientTimeoutAndBreaker(t *testing.T) {
+	cfg, err := ToClientConfig(Options{ClientTimeout: 4 * time.Second, ClientBreakerAfter: 2})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.ClientTimeout != 4*time.Second || cfg.ClientCircuitBreaker.Threshold != 2 {
+		t.Fatalf("ClientTimeout=%v ClientCircuitBreaker=%+v", cfg.ClientTimeout, cfg.ClientCircuitBreaker)
+	}
+	if _, err := ToClientConfig(Options{ClientBreakerAfter: 2}); err == nil {
+		t.Fatal("expected --client-breaker-after without --client-timeout to fail")
+	}
+}
+
+func TestToClientConfig_CookiesWriteBack(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte(".youtube.com\tTRUE\t/\tTRUE\t1999999999\tSID\tabc\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ToClientConfig(Options{CookiesFile: path, CookiesWriteBack: true})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if _, ok := cfg.CookieJar.(*cookies.Jar); !ok {
+		t.Fatalf("CookieJar = %T, want *cookies.Jar", cfg.CookieJar)
+	}
+	if _, err := ToClientConfig(Options{CookiesWriteBack: true}); err == nil {
+		t.Fatal("expected --cookies-write-back without --cookies to fail")
+	}
+}
+
+func TestParseFlags_LiveOffset(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+
+	os.Args = []string{"ytv1", "--live-offset", "10m", "jNQXAC9IVRw"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseFlags()
+	if opts.LiveOffset != 10*time.Minute {
+		t.Fatalf("LiveOffset = %v, want 10m", opts.LiveOffset)
+	}
+	if _, err := ToClientConfig(Options{LiveOffset: -time.Minute}); err == nil {
+		t.Fatal("expected negative --live-offset to fail")
+	}
+}
+
+func TestParseFlags_SyncCommand(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+
+	os.Args = []string{"ytv1", "sync", "--channels", "channels.txt", "--state", "state.json", "-f", "bestaudio"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseFlags()
+	if opts.Command != CommandSync {
+		t.Fatalf("Command=%q, want %q", opts.Command, CommandSync)
+	}
+	if opts.SyncChannels != "channels.txt" || opts.SyncState != "state.json" {
+		t.Fatalf("SyncChannels=%q SyncState=%q", opts.SyncChannels, opts.SyncState)
+	}
+	if opts.FormatSelector != "bestaudio" || len(opts.URLs) != 0 {
+		t.Fatalf("FormatSelector=%q URLs=%v", opts.FormatSelector, opts.URLs)
+	}
+}
+
+func TestParseFlags_CleanupCommand(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+
+	os.Args = []string{"ytv1", "cleanup", "--dir", "downloads", "--older-than", "6h"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseFlags()
+	if opts.Command != CommandCleanup {
+		t.Fatalf("Command=%q, want %q", opts.Command, CommandCleanup)
+	}
+	if opts.CleanupDir != "downloads" || opts.CleanupOlderThan != 6*time.Hour {
+		t.Fatalf("CleanupDir=%q CleanupOlderThan=%v", opts.CleanupDir, opts.CleanupOlderThan)
+	}
+}
+
+func TestParseFlags_ReportBugCommand(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+
+	os.Args = []string{"ytv1", "report-bug", "--capture-dir", "captures", "--bundle", "bug.tar.gz"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseFlags()
+	if opts.Command != CommandReportBug {
+		t.Fatalf("Command=%q, want %q", opts.Command, CommandReportBug)
+	}
+	if opts.CaptureDir != "captures" || opts.ReportBugBundle != "bug.tar.gz" {
+		t.Fatalf("CaptureDir=%q ReportBugBundle=%q", opts.CaptureDir, opts.ReportBugBundle)
+	}
+}
+
+func TestParseFlags_CompletionCommand(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+
+	os.Args = []string{"ytv1", "completion", "zsh"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseFlags()
+	if opts.Command != CommandCompletion || opts.CompletionShell != "zsh" {
+		t.Fatalf("Command=%q CompletionShell=%q", opts.Command, opts.CompletionShell)
+	}
+	if len(opts.URLs) != 0 {
+		t.Fatalf("URLs=%v, want none", opts.URLs)
+	}
+}
+
+func TestParseFlags_ArchiveCommand(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+
+	os.Args = []string{"ytv1", "archive", "--archive-format", "yt-dlp", "convert", "in.txt", "out.txt"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseFlags()
+	if opts.Command != CommandArchive || opts.ArchiveAction != "convert" || opts.ArchiveFormat != ArchiveFormatYtDlp {
+		t.Fatalf("Command=%q ArchiveAction=%q ArchiveFormat=%q", opts.Command, opts.ArchiveAction, opts.ArchiveFormat)
+	}
+	if strings.Join(opts.URLs, " ") != "in.txt out.txt" {
+		t.Fatalf("URLs=%v, want the archive paths", opts.URLs)
+	}
+	if _, err := ToClientConfig(Options{ArchiveFormat: "youtube-dl"}); err == nil || !strings.Contains(err.Error(), "--archive-format") {
+		t.Fatalf("ToClientConfig(bad archive format) error = %v", err)
+	}
+}
+
+func TestParseFlags_UpdateCommand(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+
+	os.Args = []string{"ytv1", "update", "--check", "--release-url", "https://example.com/latest"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseFlags()
+	if opts.Command != CommandUpdate || !opts.UpdateCheck || opts.UpdateReleaseURL != "https://example.com/latest" {
+		t.Fatalf("Command=%q UpdateCheck=%v UpdateReleaseURL=%q", opts.Command, opts.UpdateCheck, opts.UpdateReleaseURL)
+	}
+	if len(opts.URLs) != 0 {
+		t.Fatalf("URLs=%v, want none", opts.URLs)
+	}
+}
+
+func TestToClientConfig_Capture(t *testing.T) {
+	cfg, err := ToClientConfig(Options{CaptureDir: "captures", CaptureRedactID: true})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.CaptureDir != "captures" || !cfg.CaptureRedactVideoID {
+		t.Fatalf("CaptureDir=%q CaptureRedactVideoID=%v", cfg.CaptureDir, cfg.CaptureRedactVideoID)
+	}
+}
+
+func TestToClientConfig_MaxTotalBytes(t *testing.T) {
+	cfg, err := ToClientConfig(Options{MaxTotalBytes: "1.5G"})
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.MaxTotalBytes != 1536*1024*1024 {
+		t.Fatalf("MaxTotalBytes = %d", cfg.MaxTotalBytes)
+	}
+	if _, err := ToClientConfig(Options{MaxTotalBytes: "lots"}); err == nil {
+		t.Fatal("expected invalid --max-total-bytes to fail")
+	}
+}
+
+func TestParseFlags_PodcastPreset(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+
+	os.Args = []string{"ytv1", "--preset", "podcast", "--download-archive", "mine.txt", "PLxyz"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseFlags()
+	if !opts.ExtractAudio || opts.AudioFormat != "best" || opts.FormatSelector != "bestaudio" {
+		t.Fatalf("ExtractAudio=%v AudioFormat=%q FormatSelector=%q", opts.ExtractAudio, opts.AudioFormat, opts.FormatSelector)
+	}
+	if opts.OutputTemplate != podcastOutputTemplate {
+		t.Fatalf("OutputTemplate=%q", opts.OutputTemplate)
+	}
+	if opts.DownloadArchive != "mine.txt" {
+		t.Fatalf("DownloadArchive=%q, explicit flag should win over preset", opts.DownloadArchive)
+	}
+}
+
+func TestParseFlags_MediaServerPreset(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+
+	os.Args = []string{"ytv1", "--preset", "mediaserver", "--nfo-style", "movie", "PLxyz"}
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flag.CommandLine.SetOutput(io.Discard)
+
+	opts := ParseFlags()
+	if !opts.WriteNFO || !opts.WriteThumbnail || opts.NFOStyle != NFOStyleMovie {
+		t.Fatalf("WriteNFO=%v WriteThumbnail=%v NFOStyle=%q", opts.WriteNFO, opts.WriteThumbnail, opts.NFOStyle)
+	}
+	if opts.OutputTemplate != mediaServerOutputTemplate {
+		t.Fatalf("OutputTemplate=%q", opts.OutputTemplate)
+	}
+	if _, err := ToClientConfig(opts); err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if _, err := ToClientConfig(Options{NFOStyle: "season"}); err == nil || !strings.Contains(err.Error(), "--nfo-style") {
+		t.Fatalf("ToClientConfig(bad nfo style) error = %v", err)
+	}
+}
+
+func TestToClientConfig_PresetAndAudioFormatValidation(t *testing.T) {
+	if _, err := ToClientConfig(Options{Preset: "vlog"}); err == nil || !strings.Contains(err.Error(), "--preset") {
+		t.Fatalf("ToClientConfig(bad preset) error = %v", err)
+	}
+	if _, err := ToClientConfig(Options{ExtractAudio: true, AudioFormat: "flac"}); err == nil || !strings.Contains(err.Error(), "--audio-format") {
+		t.Fatalf("ToClientConfig(bad audio format) error = %v", err)
+	}
+}
+
+func TestToClientConfig_FormatTableValidation(t *testing.T) {
+	if _, err := ToClientConfig(Options{FormatTable: "xml"}); err == nil || !strings.Contains(err.Error(), "--format-table") {
+		t.Fatalf("ToClientConfig(bad table) error = %v", err)
+	}
+	if _, err := ToClientConfig(Options{PlaylistOrder: "random"}); err == nil || !strings.Contains(err.Error(), "--playlist-order") {
+		t.Fatalf("ToClientConfig(bad playlist order) error = %v", err)
+	}
+	if _, err := ToClientConfig(Options{PlaylistOrder: PlaylistOrderViews}); err != nil {
+		t.Fatalf("ToClientConfig(views) error = %v", err)
+	}
+	if _, err := ToClientConfig(Options{FormatColumns: "itag,codec"}); err == nil || !strings.Contains(err.Error(), "--format-columns") {
+		t.Fatalf("ToClientConfig(bad column) error = %v", err)
+	}
+	cols, err := ParseFormatColumns(" ITAG , res,")
+	if err != nil || strings.Join(cols, ",") != "itag,res" {
+		t.Fatalf("ParseFormatColumns() = %v, %v", cols, err)
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	now := time.Date(2026, 10, 15, 18, 30, 0, 0, time.UTC)
+	r, err := parseDateRange("today-2weeks", "20261010", now)
+	if err != nil {
+		t.Fatalf("parseDateRange() error = %v", err)
+	}
+	if want := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC); !r.After.Equal(want) {
+		t.Fatalf("After = %v, want %v", r.After, want)
+	}
+	cases := map[time.Time]bool{
+		time.Date(2026, 9, 30, 23, 59, 0, 0, time.UTC): false,
+		time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC):   true,
+		time.Date(2026, 10, 10, 22, 0, 0, 0, time.UTC): true,
+		time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC):  false,
+		{}: true,
+	}
+	for date, want := range cases {
+		if got := r.Contains(date); got != want {
+			t.Fatalf("Contains(%v) = %v, want %v", date, got, want)
+		}
+	}
+	if r, _ := parseDateRange("now-1month", "", now); !r.After.Equal(time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("now-1month After = %v", r.After)
+	}
+	for _, bad := range []string{"yesterday", "today-2fortnights", "2026-10-01"} {
+		if _, err := parseDateRange(bad, "", now); err == nil {
+			t.Fatalf("parseDateRange(%q) expected error", bad)
+		}
+	}
+	if _, err := ToClientConfig(Options{DateAfter: "soon"}); err == nil {
+		t.Fatal("expected invalid --dateafter to fail ToClientConfig")
+	}
+}
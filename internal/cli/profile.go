@@ -0,0 +1,310 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Profile is a named set of flag values from the config file, applied with
+// --profile NAME:
+//
+//	# ~/.config/ytv1/config
+//	profile "fast" {
+//	    clients = ["ios", "android"]
+//	    format = "bv*[height<=1080]+ba"
+//	    concurrency = 8
+//	}
+//
+// Keys are long flag names (or ProfileKeyAliases); list values are joined
+// with commas. Settings end at a newline or ";".
+type Profile struct {
+	Name     string
+	Settings []ProfileSetting
+}
+
+// ProfileSetting is one key = value line of a profile.
+type ProfileSetting struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+// ProfileKeyAliases maps config-file keys to the flags they set.
+var ProfileKeyAliases = map[string]string{
+	"concurrency": "concurrent-fragments",
+}
+
+// profileShortFlags maps long flags to their short aliases, so a value given
+// on the command line under either name wins over the profile.
+var profileShortFlags = map[string]string{
+	"format":               "f",
+	"output":               "o",
+	"list-formats":         "F",
+	"get-url":              "g",
+	"simulate":             "s",
+	"ignore-errors":        "i",
+	"extract-audio":        "x",
+	"dump-json":            "j",
+	"concurrent-fragments": "N",
+}
+
+// DefaultConfigPath is the config file --profile reads without --config:
+// ytv1/config under the user config directory.
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ytv1", "config")
+}
+
+// LoadProfiles reads the profiles of a config file.
+func LoadProfiles(path string) (map[string]Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := ParseProfiles(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return profiles, nil
+}
+
+// ParseProfiles parses config file text into profiles by name.
+func ParseProfiles(src string) (map[string]Profile, error) {
+	s := &profileScanner{src: src, line: 1}
+	profiles := make(map[string]Profile)
+	for {
+		tok, err := s.next()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case tok.kind == tokEOF:
+			return profiles, nil
+		case tok.kind == tokNewline || (tok.kind == tokPunct && tok.text == ";"):
+			continue
+		case tok.kind != tokBare || tok.text != "profile":
+			return nil, fmt.Errorf("line %d: want profile \"NAME\" { ... }, got %q", tok.line, tok.text)
+		}
+		name, err := s.expect(tokString, "profile name")
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := profiles[name.text]; dup {
+			return nil, fmt.Errorf("line %d: duplicate profile %q", name.line, name.text)
+		}
+		if _, err := s.expectPunct("{"); err != nil {
+			return nil, err
+		}
+		profile := Profile{Name: name.text}
+		for {
+			tok, err := s.next()
+			if err != nil {
+				return nil, err
+			}
+			if tok.kind == tokNewline || (tok.kind == tokPunct && tok.text == ";") {
+				continue
+			}
+			if tok.kind == tokPunct && tok.text == "}" {
+				break
+			}
+			if tok.kind == tokEOF {
+				return nil, fmt.Errorf("line %d: profile %q is missing its closing \"}\"", tok.line, profile.Name)
+			}
+			if tok.kind != tokBare {
+				return nil, fmt.Errorf("line %d: want a setting name in profile %q, got %q", tok.line, profile.Name, tok.text)
+			}
+			if _, err := s.expectPunct("="); err != nil {
+				return nil, err
+			}
+			value, err := s.value()
+			if err != nil {
+				return nil, err
+			}
+			profile.Settings = append(profile.Settings, ProfileSetting{Key: tok.text, Value: value, Line: tok.line})
+		}
+		profiles[profile.Name] = profile
+	}
+}
+
+// applyProfile sets the flags of profile name from configPath on fs, except
+// those given on the command line (explicit).
+func applyProfile(fs *flag.FlagSet, configPath, name string, explicit map[string]bool) error {
+	if configPath == "" {
+		configPath = DefaultConfigPath()
+	}
+	profiles, err := LoadProfiles(configPath)
+	if err != nil {
+		return fmt.Errorf("--profile %s: %w", name, err)
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return fmt.Errorf("--profile %s: no such profile in %s", name, configPath)
+	}
+	for _, setting := range profile.Settings {
+		flagName := setting.Key
+		if alias, ok := ProfileKeyAliases[flagName]; ok {
+			flagName = alias
+		}
+		if flagName == "profile" || flagName == "config" || fs.Lookup(flagName) == nil {
+			return fmt.Errorf("--profile %s: line %d: unknown setting %q", name, setting.Line, setting.Key)
+		}
+		if explicit[flagName] || explicit[profileShortFlags[flagName]] {
+			continue
+		}
+		if err := fs.Set(flagName, setting.Value); err != nil {
+			return fmt.Errorf("--profile %s: line %d: %s: %w", name, setting.Line, setting.Key, err)
+		}
+	}
+	return nil
+}
+
+type profileTokenKind int
+
+const (
+	tokEOF profileTokenKind = iota
+	tokNewline
+	tokString
+	tokBare
+	tokPunct
+)
+
+type profileToken struct {
+	kind profileTokenKind
+	text string
+	line int
+}
+
+type profileScanner struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (s *profileScanner) next() (profileToken, error) {
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == '#':
+			for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+				s.pos++
+			}
+		case c == '\n':
+			s.pos++
+			s.line++
+			return profileToken{kind: tokNewline, text: "\n", line: s.line - 1}, nil
+		case unicode.IsSpace(rune(c)):
+			s.pos++
+		case c == '"':
+			return s.quoted()
+		case strings.IndexByte("{}[]=,;", c) >= 0:
+			s.pos++
+			return profileToken{kind: tokPunct, text: string(c), line: s.line}, nil
+		default:
+			start := s.pos
+			for s.pos < len(s.src) && !unicode.IsSpace(rune(s.src[s.pos])) && strings.IndexByte("{}[]=,;#\"", s.src[s.pos]) < 0 {
+				s.pos++
+			}
+			return profileToken{kind: tokBare, text: s.src[start:s.pos], line: s.line}, nil
+		}
+	}
+	return profileToken{kind: tokEOF, line: s.line}, nil
+}
+
+func (s *profileScanner) quoted() (profileToken, error) {
+	start := s.pos
+	s.pos++
+	for s.pos < len(s.src) {
+		switch s.src[s.pos] {
+		case '\\':
+			s.pos += 2
+			continue
+		case '\n':
+			return profileToken{}, fmt.Errorf("line %d: unterminated string", s.line)
+		case '"':
+			s.pos++
+			text, err := strconv.Unquote(s.src[start:s.pos])
+			if err != nil {
+				return profileToken{}, fmt.Errorf("line %d: invalid string %s: %w", s.line, s.src[start:s.pos], err)
+			}
+			return profileToken{kind: tokString, text: text, line: s.line}, nil
+		}
+		s.pos++
+	}
+	return profileToken{}, fmt.Errorf("line %d: unterminated string", s.line)
+}
+
+// skipNewlines returns the next token that is not a newline.
+func (s *profileScanner) skipNewlines() (profileToken, error) {
+	for {
+		tok, err := s.next()
+		if err != nil || tok.kind != tokNewline {
+			return tok, err
+		}
+	}
+}
+
+func (s *profileScanner) expect(kind profileTokenKind, what string) (profileToken, error) {
+	tok, err := s.skipNewlines()
+	if err != nil {
+		return tok, err
+	}
+	if tok.kind != kind {
+		return tok, fmt.Errorf("line %d: want %s, got %q", tok.line, what, tok.text)
+	}
+	return tok, nil
+}
+
+func (s *profileScanner) expectPunct(p string) (profileToken, error) {
+	tok, err := s.expect(tokPunct, strconv.Quote(p))
+	if err == nil && tok.text != p {
+		err = fmt.Errorf("line %d: want %q, got %q", tok.line, p, tok.text)
+	}
+	return tok, err
+}
+
+// value reads a string, bare word or list of them after "key =".
+func (s *profileScanner) value() (string, error) {
+	tok, err := s.next()
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case tok.kind == tokString || tok.kind == tokBare:
+		return tok.text, nil
+	case tok.kind == tokPunct && tok.text == "[":
+		var items []string
+		for {
+			item, err := s.skipNewlines()
+			if err != nil {
+				return "", err
+			}
+			if item.kind == tokPunct && item.text == "]" {
+				return strings.Join(items, ","), nil
+			}
+			if item.kind != tokString && item.kind != tokBare {
+				return "", fmt.Errorf("line %d: want a list item, got %q", item.line, item.text)
+			}
+			items = append(items, item.text)
+			sep, err := s.skipNewlines()
+			if err != nil {
+				return "", err
+			}
+			if sep.kind == tokPunct && sep.text == "]" {
+				return strings.Join(items, ","), nil
+			}
+			if sep.kind != tokPunct || sep.text != "," {
+				return "", fmt.Errorf("line %d: want \",\" or \"]\", got %q", sep.line, sep.text)
+			}
+		}
+	default:
+		return "", fmt.Errorf("line %d: missing value", tok.line)
+	}
+}
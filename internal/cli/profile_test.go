@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testProfiles = `# recurring jobs
+profile "fast" { clients=["ios","android"]; format="bv*[height<=1080]+ba"; concurrency=8 }
+
+profile "archive" {
+    download-archive = archive.txt   # bare words work too
+    abort-on-error = true
+    sub-langs = [
+        "en",
+        "de",
+    ]
+}
+`
+
+func TestParseProfiles(t *testing.T) {
+	profiles, err := ParseProfiles(testProfiles)
+	if err != nil {
+		t.Fatalf("ParseProfiles() error = %v", err)
+	}
+	fast := profiles["fast"]
+	if len(fast.Settings) != 3 || fast.Settings[0].Value != "ios,android" || fast.Settings[1].Value != "bv*[height<=1080]+ba" || fast.Settings[2].Key != "concurrency" {
+		t.Fatalf("fast = %+v", fast)
+	}
+	archive := profiles["archive"]
+	if len(archive.Settings) != 3 || archive.Settings[0].Value != "archive.txt" || archive.Settings[0].Line != 5 || archive.Settings[2].Value != "en,de" {
+		t.Fatalf("archive = %+v", archive)
+	}
+
+	for _, bad := range []string{
+		`fast { format = "best" }`,
+		`profile "a" { format = "best"`,
+		`profile "a" { format "best" }`,
+		`profile "a" { clients = ["ios" "web"] }`,
+		`profile "a" { format = "best }`,
+		`profile "a" {} profile "a" {}`,
+	} {
+		if _, err := ParseProfiles(bad); err == nil {
+			t.Fatalf("ParseProfiles(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestParseFlags_Profile(t *testing.T) {
+	origArgs := os.Args
+	origFlagSet := flag.CommandLine
+	defer func() {
+		os.Args = origArgs
+		flag.CommandLine = origFlagSet
+	}()
+	configPath := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(configPath, []byte(testProfiles), 0644); err != nil {
+		t.Fatal(err)
+	}
+	parse := func(args ...string) Options {
+		os.Args = append([]string{"ytv1"}, args...)
+		flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+		flag.CommandLine.SetOutput(io.Discard)
+		return ParseFlags()
+	}
+
+	opts := parse("--config", configPath, "--profile", "fast", "-f", "best", "jNQXAC9IVRw")
+	if opts.ClientsOverrides != "ios,android" || opts.ConcurrentFragments != 8 {
+		t.Fatalf("profile not applied: clients=%q N=%d", opts.ClientsOverrides, opts.ConcurrentFragments)
+	}
+	if opts.FormatSelector != "best" {
+		t.Fatalf("FormatSelector = %q, want the explicit -f to win", opts.FormatSelector)
+	}
+	cfg, err := ToClientConfig(opts)
+	if err != nil {
+		t.Fatalf("ToClientConfig() error = %v", err)
+	}
+	if cfg.DownloadTransport.MaxConcurrency != 8 || !cfg.DownloadTransport.EnableChunked {
+		t.Fatalf("DownloadTransport = %+v", cfg.DownloadTransport)
+	}
+
+	opts = parse("--config", configPath, "--profile", "fast", "jNQXAC9IVRw")
+	if opts.FormatSelector != "bv*[height<=1080]+ba" {
+		t.Fatalf("FormatSelector = %q, want the profile's", opts.FormatSelector)
+	}
+
+	opts = parse("--config", configPath, "--profile", "missing", "jNQXAC9IVRw")
+	if _, err := ToClientConfig(opts); err == nil || !strings.Contains(err.Error(), "no such profile") {
+		t.Fatalf("ToClientConfig(missing profile) error = %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(`profile "x" { colour = "red" }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts = parse("--config", configPath, "--profile", "x", "jNQXAC9IVRw")
+	if _, err := ToClientConfig(opts); err == nil || !strings.Contains(err.Error(), `unknown setting "colour"`) {
+		t.Fatalf("ToClientConfig(unknown setting) error = %v", err)
+	}
+}
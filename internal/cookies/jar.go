@@ -0,0 +1,189 @@
+package cookies
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Jar is an http.CookieJar that remembers every cookie it holds, including
+// the ones servers set or rotate later, so they can be written back to a
+// cookies file. net/http/cookiejar can only answer per-URL lookups and drops
+// expiry and path on the way out.
+type Jar struct {
+	inner *cookiejar.Jar
+
+	mu      sync.Mutex
+	cookies map[jarKey]*http.Cookie
+	changed bool
+	now     func() time.Time
+}
+
+type jarKey struct {
+	domain, path, name string
+}
+
+// NewJar returns a Jar seeded with cookies as ParseNetscape returns them.
+// Seeding does not count as a change; expired entries are dropped.
+func NewJar(initial []*http.Cookie) (*Jar, error) {
+	inner, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	j := &Jar{inner: inner, cookies: make(map[jarKey]*http.Cookie), now: time.Now}
+
+	// Map by domain
+	domainCookies := make(map[string][]*http.Cookie)
+	for _, c := range initial {
+		domainCookies[c.Domain] = append(domainCookies[c.Domain], c)
+	}
+	for domain, cs := range domainCookies {
+		// Construct a fake URL for the domain
+		scheme := "http"
+		// Check if any cookie is secure
+		for _, c := range cs {
+			if c.Secure {
+				scheme = "https"
+				break
+			}
+		}
+		host := strings.TrimPrefix(domain, ".")
+		if !strings.HasPrefix(domain, ".") {
+			// cookiejar scopes a cookie to the request host only when it
+			// carries no Domain attribute.
+			hostCookies := make([]*http.Cookie, len(cs))
+			for i, c := range cs {
+				cp := *c
+				cp.Domain = ""
+				hostCookies[i] = &cp
+			}
+			cs = hostCookies
+		}
+		inner.SetCookies(&url.URL{Scheme: scheme, Host: host}, cs)
+	}
+	// Track the file's entries as written so a write-back keeps host-only
+	// and domain cookies apart.
+	now := j.now()
+	for _, c := range initial {
+		if !c.Expires.IsZero() && !c.Expires.After(now) {
+			continue
+		}
+		stored := *c
+		stored.Domain = strings.ToLower(c.Domain)
+		if stored.Path == "" {
+			stored.Path = "/"
+		}
+		j.cookies[jarKey{domain: stored.Domain, path: stored.Path, name: c.Name}] = &stored
+	}
+	return j, nil
+}
+
+// SetCookies implements http.CookieJar.
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.inner.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	host := strings.ToLower(u.Hostname())
+	now := j.now()
+	for _, c := range cookies {
+		domain := strings.ToLower(c.Domain)
+		if domain == "" {
+			domain = host
+		} else {
+			if !strings.HasPrefix(domain, ".") {
+				domain = "." + domain
+			}
+			// cookiejar rejects cookies for domains the host is not in.
+			if host != domain[1:] && !strings.HasSuffix(host, domain) {
+				continue
+			}
+		}
+		path := c.Path
+		if path == "" || path[0] != '/' {
+			path = defaultPath(u.Path)
+		}
+		key := jarKey{domain: domain, path: path, name: c.Name}
+
+		expires := c.Expires
+		if c.MaxAge > 0 {
+			expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+		if c.MaxAge < 0 || (!expires.IsZero() && !expires.After(now)) {
+			if _, ok := j.cookies[key]; ok {
+				delete(j.cookies, key)
+				j.changed = true
+			}
+			continue
+		}
+
+		stored := &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   domain,
+			Path:     path,
+			Expires:  expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+		if prev, ok := j.cookies[key]; ok && prev.Value == stored.Value && prev.Expires.Equal(stored.Expires) && prev.Secure == stored.Secure {
+			continue
+		}
+		j.cookies[key] = stored
+		j.changed = true
+	}
+}
+
+// Cookies implements http.CookieJar.
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	return j.inner.Cookies(u)
+}
+
+// Changed reports whether a server set, rotated or expired a cookie since
+// the jar was seeded.
+func (j *Jar) Changed() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.changed
+}
+
+// All returns the unexpired cookies, sorted by domain, path and name.
+func (j *Jar) All() []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.now()
+	out := make([]*http.Cookie, 0, len(j.cookies))
+	for _, c := range j.cookies {
+		if !c.Expires.IsZero() && !c.Expires.After(now) {
+			continue
+		}
+		cp := *c
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].Domain != out[b].Domain {
+			return out[a].Domain < out[b].Domain
+		}
+		if out[a].Path != out[b].Path {
+			return out[a].Path < out[b].Path
+		}
+		return out[a].Name < out[b].Name
+	})
+	return out
+}
+
+// defaultPath is the RFC 6265 section 5.1.4 default cookie path.
+func defaultPath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
@@ -0,0 +1,88 @@
+package cookies
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJar_TracksRotatedCookies(t *testing.T) {
+	jar, err := NewJar([]*http.Cookie{
+		{Name: "SID", Value: "old", Domain: ".youtube.com", Path: "/", Expires: time.Unix(1999999999, 0), Secure: true},
+		{Name: "GONE", Value: "x", Domain: ".youtube.com", Path: "/", Expires: time.Unix(1999999999, 0)},
+		{Name: "STALE", Value: "x", Domain: ".youtube.com", Path: "/", Expires: time.Unix(1000, 0)},
+	})
+	if err != nil {
+		t.Fatalf("NewJar() error = %v", err)
+	}
+	if jar.Changed() {
+		t.Fatal("seeding should not count as a change")
+	}
+	if got := len(jar.All()); got != 2 {
+		t.Fatalf("All() returned %d cookies, want 2 (expired entry dropped)", got)
+	}
+
+	u, _ := url.Parse("https://www.youtube.com/youtubei/v1/player")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "SID", Value: "old", Domain: "youtube.com", Path: "/", Expires: time.Unix(1999999999, 0), Secure: true},
+	})
+	if jar.Changed() {
+		t.Fatal("re-setting an identical cookie should not count as a change")
+	}
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "SID", Value: "new", Domain: "youtube.com", Path: "/", MaxAge: 3600, Secure: true},
+		{Name: "GONE", Domain: "youtube.com", Path: "/", MaxAge: -1},
+		{Name: "HOSTONLY", Value: "h"},
+		{Name: "FOREIGN", Value: "f", Domain: "example.com"},
+	})
+	if !jar.Changed() {
+		t.Fatal("expected rotated cookies to mark the jar changed")
+	}
+
+	all := jar.All()
+	var names []string
+	for _, c := range all {
+		names = append(names, c.Domain+c.Path+" "+c.Name+"="+c.Value)
+	}
+	want := ".youtube.com/ SID=new|www.youtube.com/youtubei/v1 HOSTONLY=h"
+	if got := strings.Join(names, "|"); got != want {
+		t.Fatalf("All() = %q, want %q", got, want)
+	}
+	if all[0].Expires.IsZero() {
+		t.Fatal("Max-Age should be converted to an expiry")
+	}
+
+	var sent []string
+	for _, c := range jar.Cookies(u) {
+		sent = append(sent, c.Name+"="+c.Value)
+	}
+	if got := strings.Join(sent, ","); !strings.Contains(got, "SID=new") || strings.Contains(got, "GONE") {
+		t.Fatalf("Cookies() = %q", got)
+	}
+}
+
+func TestNewJar_ScopesHostOnlyCookies(t *testing.T) {
+	jar, err := NewJar([]*http.Cookie{
+		{Name: "HOST", Value: "h", Domain: "www.youtube.com", Path: "/"},
+		{Name: "DOMAIN", Value: "d", Domain: ".youtube.com", Path: "/"},
+	})
+	if err != nil {
+		t.Fatalf("NewJar() error = %v", err)
+	}
+	names := func(rawURL string) string {
+		u, _ := url.Parse(rawURL)
+		var out []string
+		for _, c := range jar.Cookies(u) {
+			out = append(out, c.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	if got := names("https://www.youtube.com/"); !strings.Contains(got, "HOST") || !strings.Contains(got, "DOMAIN") {
+		t.Fatalf("www cookies = %q, want HOST and DOMAIN", got)
+	}
+	if got := names("https://music.youtube.com/"); got != "DOMAIN" {
+		t.Fatalf("music cookies = %q, want only DOMAIN", got)
+	}
+}
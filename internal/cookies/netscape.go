@@ -2,6 +2,8 @@ package cookies
 
 import (
 	"bufio"
+	"bytes"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
@@ -9,34 +11,129 @@ import (
 	"time"
 )
 
+// httpOnlyPrefix marks HttpOnly cookies in files written by curl, browsers'
+// export extensions and yt-dlp. Such lines would otherwise read as comments.
+const httpOnlyPrefix = "#HttpOnly_"
+
+// LineError describes a cookies.txt line ParseNetscape skipped.
+type LineError struct {
+	Line   int
+	Reason string
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Reason)
+}
+
 // ParseNetscape parses a Netscape cookies.txt format.
 // Format: domain flag path secure expiration name value
+// Malformed lines and expired cookies are skipped; use
+// ParseNetscapeWithDiagnostics to learn which lines were malformed.
 func ParseNetscape(r io.Reader) ([]*http.Cookie, error) {
+	cookies, _, err := ParseNetscapeWithDiagnostics(r)
+	return cookies, err
+}
+
+// ParseNetscapeWithDiagnostics is ParseNetscape that also reports every
+// malformed line it skipped, with its 1-based line number.
+//
+// Domains follow the flag field: with TRUE the cookie also matches
+// subdomains and Domain gets a leading dot; with FALSE it is host-only and
+// Domain has none. Lines with a "#HttpOnly_" prefix are cookies, not
+// comments.
+func ParseNetscapeWithDiagnostics(r io.Reader) ([]*http.Cookie, []LineError, error) {
+	return parseNetscape(r, time.Now())
+}
+
+func parseNetscape(r io.Reader, now time.Time) ([]*http.Cookie, []LineError, error) {
 	var cookies []*http.Cookie
+	var diags []LineError
 	scanner := bufio.NewScanner(r)
+	// Session cookies for signed-in accounts can run to several KB.
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 
+	lineNo := 0
 	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
+		lineNo++
+		// Only strip line endings and surrounding spaces: a trailing tab
+		// is an empty value, not padding.
+		line := strings.Trim(scanner.Text(), " \r\n")
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		httpOnly := false
+		if strings.HasPrefix(line, httpOnlyPrefix) {
+			httpOnly = true
+			line = line[len(httpOnlyPrefix):]
+		} else if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
+		bad := func(format string, args ...any) {
+			diags = append(diags, LineError{Line: lineNo, Reason: fmt.Sprintf(format, args...)})
+		}
 
 		parts := strings.Split(line, "\t")
-		if len(parts) < 7 {
+		if len(parts) != 7 {
+			bad("expected 7 tab-separated fields, got %d", len(parts))
 			continue
 		}
 
 		// Field 0: Domain
-		domain := parts[0]
-		// Field 1: Flag (TRUE/FALSE) - treat as string, ignore?
+		domain := strings.ToLower(strings.TrimSpace(parts[0]))
+		if strings.TrimPrefix(domain, ".") == "" {
+			bad("empty domain")
+			continue
+		}
+		// Field 1: Include subdomains (TRUE/FALSE)
+		var includeSubdomains bool
+		switch strings.ToUpper(parts[1]) {
+		case "TRUE":
+			includeSubdomains = true
+		case "FALSE":
+		default:
+			bad("subdomain flag %q is not TRUE or FALSE", parts[1])
+			continue
+		}
+		if includeSubdomains {
+			domain = "." + strings.TrimPrefix(domain, ".")
+		} else {
+			domain = strings.TrimPrefix(domain, ".")
+		}
 		// Field 2: Path
 		path := parts[2]
+		if !strings.HasPrefix(path, "/") {
+			bad("path %q does not start with /", path)
+			continue
+		}
 		// Field 3: Secure (TRUE/FALSE)
-		secure := strings.EqualFold(parts[3], "TRUE")
-		// Field 4: Expiration (Unix timestamp)
-		expiresUnix, _ := strconv.ParseInt(parts[4], 10, 64)
+		var secure bool
+		switch strings.ToUpper(parts[3]) {
+		case "TRUE":
+			secure = true
+		case "FALSE":
+		default:
+			bad("secure flag %q is not TRUE or FALSE", parts[3])
+			continue
+		}
+		// Field 4: Expiration (Unix timestamp, 0 for a session cookie)
+		expiresUnix, err := strconv.ParseInt(parts[4], 10, 64)
+		if err != nil || expiresUnix < 0 {
+			bad("expiration %q is not a Unix timestamp", parts[4])
+			continue
+		}
+		var expires time.Time
+		if expiresUnix > 0 {
+			expires = time.Unix(expiresUnix, 0)
+			if !expires.After(now) {
+				continue
+			}
+		}
 		// Field 5: Name
 		name := parts[5]
+		if name == "" {
+			bad("empty cookie name")
+			continue
+		}
 		// Field 6: Value
 		value := parts[6]
 
@@ -45,12 +142,92 @@ func ParseNetscape(r io.Reader) ([]*http.Cookie, error) {
 			Value:    value,
 			Domain:   domain,
 			Path:     path,
-			Expires:  time.Unix(expiresUnix, 0),
+			Expires:  expires,
 			Secure:   secure,
-			HttpOnly: true, // Generally safe assumption for session cookies? Not stored in file though.
+			HttpOnly: httpOnly,
 		}
 		cookies = append(cookies, cookie)
 	}
 
-	return cookies, scanner.Err()
+	return cookies, diags, scanner.Err()
+}
+
+// hostOnly reports whether c, as returned by ParseNetscape or Jar.All,
+// matches only its exact host rather than subdomains too.
+func hostOnly(c *http.Cookie) bool {
+	return !strings.HasPrefix(c.Domain, ".")
+}
+
+// WriteNetscape writes cookies in the format ParseNetscape reads. Cookies
+// without an expiry are written as session cookies (expiration 0) and
+// HttpOnly cookies get the "#HttpOnly_" prefix.
+func WriteNetscape(w io.Writer, cookies []*http.Cookie) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("# Netscape HTTP Cookie File\n")
+	writeCookieLines(bw, cookies)
+	return bw.Flush()
+}
+
+// RewriteNetscape writes cookies over original, a cookies.txt file, keeping
+// everything in it that is not a cookie: comments, blank lines and the
+// lines ParseNetscape skips as malformed stay in order, and the cookie
+// lines are replaced by cookies, after them. An empty original is written
+// as WriteNetscape does.
+func RewriteNetscape(w io.Writer, original []byte, cookies []*http.Cookie) error {
+	if len(bytes.TrimSpace(original)) == 0 {
+		return WriteNetscape(w, cookies)
+	}
+	_, diags, err := parseNetscape(bytes.NewReader(original), time.Now())
+	if err != nil {
+		return err
+	}
+	malformed := make(map[int]bool, len(diags))
+	for _, d := range diags {
+		malformed[d.Line] = true
+	}
+	bw := bufio.NewWriter(w)
+	scanner := bufio.NewScanner(bytes.NewReader(original))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		trimmed := strings.Trim(line, " \r\n")
+		if lineNo == 1 {
+			trimmed = strings.TrimPrefix(trimmed, "\ufeff")
+		}
+		isComment := strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, httpOnlyPrefix)
+		if isComment || strings.TrimSpace(trimmed) == "" || malformed[lineNo] {
+			bw.WriteString(line + "\n")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	writeCookieLines(bw, cookies)
+	return bw.Flush()
+}
+
+func writeCookieLines(bw *bufio.Writer, cookies []*http.Cookie) {
+	for _, c := range cookies {
+		domain := c.Domain
+		if c.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		includeSubdomains := "TRUE"
+		if hostOnly(c) {
+			includeSubdomains = "FALSE"
+		}
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+		secure := "FALSE"
+		if c.Secure {
+			secure = "TRUE"
+		}
+		var expires int64
+		if !c.Expires.IsZero() {
+			expires = c.Expires.Unix()
+		}
+		fmt.Fprintf(bw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, includeSubdomains, path, secure, expires, c.Name, c.Value)
+	}
 }
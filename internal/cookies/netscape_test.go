@@ -1,8 +1,11 @@
 package cookies
 
 import (
+	"bytes"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseNetscape(t *testing.T) {
@@ -46,3 +49,170 @@ func TestParseNetscape(t *testing.T) {
 		t.Error("c2.Secure should be false")
 	}
 }
+
+func TestWriteNetscape_RoundTrip(t *testing.T) {
+	in := []*http.Cookie{
+		{Name: "SID", Value: "abc", Domain: ".youtube.com", Path: "/", Expires: time.Unix(1999999999, 0), Secure: true},
+		{Name: "YSC", Value: "session", Domain: "www.youtube.com", Path: "/"},
+	}
+	var buf strings.Builder
+	if err := WriteNetscape(&buf, in); err != nil {
+		t.Fatalf("WriteNetscape() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "www.youtube.com\tFALSE\t/\tFALSE\t0\tYSC\tsession\n") {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+
+	out, err := ParseNetscape(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ParseNetscape() error = %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("expected 2 cookies, got %d", len(out))
+	}
+	if out[0].Domain != ".youtube.com" || out[0].Value != "abc" || !out[0].Secure || out[0].Expires.Unix() != 1999999999 {
+		t.Errorf("out[0] = %+v", out[0])
+	}
+	if !out[1].Expires.IsZero() {
+		t.Errorf("session cookie expiry = %v, want zero", out[1].Expires)
+	}
+}
+
+func TestParseNetscape_HttpOnlyAndHostOnly(t *testing.T) {
+	input := "#HttpOnly_.youtube.com\tTRUE\t/\tTRUE\t1999999999\tSID\tabc\n" +
+		"www.youtube.com\tFALSE\t/\tFALSE\t0\tYSC\t\n" +
+		"youtube.com\tTRUE\t/\tFALSE\t0\tPREF\tf1=1\n" +
+		".music.youtube.com\tFALSE\t/\tFALSE\t0\tHOST\th\n"
+	cookies, err := ParseNetscape(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseNetscape() error = %v", err)
+	}
+	if len(cookies) != 4 {
+		t.Fatalf("expected 4 cookies, got %d", len(cookies))
+	}
+	if c := cookies[0]; !c.HttpOnly || c.Domain != ".youtube.com" || c.Name != "SID" {
+		t.Errorf("cookies[0] = %+v, want HttpOnly SID on .youtube.com", c)
+	}
+	if c := cookies[1]; c.HttpOnly || c.Domain != "www.youtube.com" || c.Value != "" {
+		t.Errorf("cookies[1] = %+v, want host-only YSC with empty value", c)
+	}
+	if c := cookies[2]; c.Domain != ".youtube.com" {
+		t.Errorf("cookies[2].Domain = %q, want .youtube.com for a TRUE flag", c.Domain)
+	}
+	if c := cookies[3]; c.Domain != "music.youtube.com" {
+		t.Errorf("cookies[3].Domain = %q, want music.youtube.com for a FALSE flag", c.Domain)
+	}
+}
+
+func TestParseNetscape_PrunesExpiredAndReportsMalformedLines(t *testing.T) {
+	input := "# Netscape HTTP Cookie File\n" +
+		".youtube.com\tTRUE\t/\tTRUE\t1000\tOLD\tx\n" +
+		".youtube.com\tTRUE\t/\tTRUE\n" +
+		".youtube.com\tMAYBE\t/\tTRUE\t0\tA\tx\n" +
+		".youtube.com\tTRUE\t/\tTRUE\tsoon\tB\tx\n" +
+		".youtube.com\tTRUE\tnoslash\tTRUE\t0\tC\tx\n" +
+		".youtube.com\tTRUE\t/\tTRUE\t0\t\tx\n" +
+		".youtube.com\tTRUE\t/\tTRUE\t1999999999\tOK\tx\n"
+	cookies, diags, err := parseNetscape(strings.NewReader(input), time.Unix(1500000000, 0))
+	if err != nil {
+		t.Fatalf("parseNetscape() error = %v", err)
+	}
+	if len(cookies) != 1 || cookies[0].Name != "OK" {
+		t.Fatalf("cookies = %+v, want only OK", cookies)
+	}
+	var lines []int
+	for _, d := range diags {
+		lines = append(lines, d.Line)
+	}
+	want := []int{3, 4, 5, 6, 7}
+	if len(lines) != len(want) {
+		t.Fatalf("diagnostic lines = %v, want %v", lines, want)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Fatalf("diagnostic lines = %v, want %v", lines, want)
+		}
+	}
+	if got := diags[0].Error(); got != "line 3: expected 7 tab-separated fields, got 4" {
+		t.Errorf("diags[0].Error() = %q", got)
+	}
+}
+
+func TestWriteNetscape_PreservesHttpOnlyAndHostOnly(t *testing.T) {
+	in := []*http.Cookie{
+		{Name: "SID", Value: "abc", Domain: ".youtube.com", Path: "/", HttpOnly: true},
+		{Name: "YSC", Value: "s", Domain: "www.youtube.com", Path: "/"},
+	}
+	var buf strings.Builder
+	if err := WriteNetscape(&buf, in); err != nil {
+		t.Fatalf("WriteNetscape() error = %v", err)
+	}
+	out, err := ParseNetscape(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("ParseNetscape() error = %v", err)
+	}
+	if len(out) != 2 || !out[0].HttpOnly || out[0].Domain != ".youtube.com" || out[1].HttpOnly || out[1].Domain != "www.youtube.com" {
+		t.Fatalf("round trip = %+v %+v", out[0], out[1])
+	}
+}
+
+func FuzzParseNetscape(f *testing.F) {
+	f.Add(".youtube.com\tTRUE\t/\tTRUE\t1999999999\tSID\tabc\n")
+	f.Add("#HttpOnly_www.youtube.com\tFALSE\t/\tFALSE\t0\tYSC\t\n")
+	f.Add("\ufeff# comment\n\n.a\tTRUE\t/\tFALSE\t-1\tN\tv\r\n")
+	f.Fuzz(func(t *testing.T, input string) {
+		cookies, diags, err := parseNetscape(strings.NewReader(input), time.Unix(1500000000, 0))
+		if err != nil {
+			return
+		}
+		for _, d := range diags {
+			if d.Line < 1 {
+				t.Fatalf("diagnostic with line %d", d.Line)
+			}
+		}
+		for _, c := range cookies {
+			if c.Name == "" || !strings.HasPrefix(c.Path, "/") || strings.TrimPrefix(c.Domain, ".") == "" {
+				t.Fatalf("invalid cookie accepted: %+v", c)
+			}
+		}
+		// Whatever was accepted must survive a write/parse round trip.
+		var buf strings.Builder
+		if err := WriteNetscape(&buf, cookies); err != nil {
+			t.Fatalf("WriteNetscape() error = %v", err)
+		}
+		again, _, err := parseNetscape(strings.NewReader(buf.String()), time.Unix(1500000000, 0))
+		if err != nil {
+			t.Fatalf("reparse error = %v", err)
+		}
+		if len(again) != len(cookies) {
+			t.Fatalf("round trip kept %d of %d cookies:\n%s", len(again), len(cookies), buf.String())
+		}
+	})
+}
+
+func TestRewriteNetscape_KeepsCommentsAndMalformedLines(t *testing.T) {
+	original := "\ufeff# Netscape HTTP Cookie File\n" +
+		"# my account\n" +
+		".youtube.com\tTRUE\t/\tTRUE\t1999999999\tSID\told\n" +
+		"#HttpOnly_.youtube.com\tTRUE\t/\tTRUE\t1999999999\tHSID\told\n" +
+		"\n" +
+		".youtube.com\tMAYBE\t/\tTRUE\t1999999999\tBAD\tx\n"
+	cookies := []*http.Cookie{{Name: "SID", Value: "new", Domain: ".youtube.com", Path: "/", Expires: time.Unix(1999999999, 0), Secure: true}}
+	var buf bytes.Buffer
+	if err := RewriteNetscape(&buf, []byte(original), cookies); err != nil {
+		t.Fatalf("RewriteNetscape() error = %v", err)
+	}
+	want := "\ufeff# Netscape HTTP Cookie File\n" +
+		"# my account\n" +
+		"\n" +
+		".youtube.com\tMAYBE\t/\tTRUE\t1999999999\tBAD\tx\n" +
+		".youtube.com\tTRUE\t/\tTRUE\t1999999999\tSID\tnew\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("RewriteNetscape() =\n%q\nwant\n%q", got, want)
+	}
+
+	buf.Reset()
+	if err := RewriteNetscape(&buf, nil, cookies); err != nil || !strings.HasPrefix(buf.String(), "# Netscape HTTP Cookie File\n") {
+		t.Fatalf("RewriteNetscape(empty) = %q, %v", buf.String(), err)
+	}
+}
@@ -18,10 +18,19 @@ type DASHDownloader struct {
 	Headers          http.Header
 	Transport        TransportConfig
 
+	// Discontinuities lists Period boundaries crossed in the written output.
+	Discontinuities []Discontinuity
+
+	// Live supplies refresh hints a dynamic MPD omits.
+	Live LiveHints
+
 	// State
 	seenSegments     map[string]bool
-	lastSeq          int64
+	lastSeq          map[string]int64 // per Period; sequence numbers restart each Period
 	skippedFragments int
+	written          int64
+	lastPeriod       string
+	wroteSegment     bool
 }
 
 func NewDASHDownloader(client *http.Client, manifestURL, representationID string) *DASHDownloader {
@@ -30,7 +39,7 @@ func NewDASHDownloader(client *http.Client, manifestURL, representationID string
 		ManifestURL:      manifestURL,
 		RepresentationID: representationID,
 		seenSegments:     make(map[string]bool),
-		lastSeq:          -1,
+		lastSeq:          make(map[string]int64),
 	}
 }
 
@@ -44,11 +53,17 @@ func (d *DASHDownloader) WithTransportConfig(cfg TransportConfig) *DASHDownloade
 	return d
 }
 
+func (d *DASHDownloader) WithLiveHints(hints LiveHints) *DASHDownloader {
+	d.Live = hints
+	return d
+}
+
 // ... helper structs (dashMPD, dashPeriod, etc. as defined before) ...
 type dashMPD struct {
 	XMLName                   xml.Name     `xml:"MPD"`
 	Type                      string       `xml:"type,attr"`
 	MinimumUpdatePeriod       string       `xml:"minimumUpdatePeriod,attr"`
+	TimeShiftBufferDepth      string       `xml:"timeShiftBufferDepth,attr"`
 	AvailabilityStartTime     string       `xml:"availabilityStartTime,attr"`
 	MediaPresentationDuration string       `xml:"mediaPresentationDuration,attr"`
 	MinBufferTime             string       `xml:"minBufferTime,attr"`
@@ -57,6 +72,9 @@ type dashMPD struct {
 }
 
 type dashPeriod struct {
+	ID            string              `xml:"id,attr"`
+	Start         string              `xml:"start,attr"`
+	BaseURL       string              `xml:"BaseURL"`
 	AdaptationSet []dashAdaptationSet `xml:"AdaptationSet"`
 }
 
@@ -92,8 +110,15 @@ type dashS struct {
 }
 
 type dashSegment struct {
-	URL string
-	Seq int64
+	URL    string
+	Seq    int64
+	Period string
+}
+
+// key identifies a segment across manifest refreshes; URLs may repeat
+// between Periods that restart numbering on a shared template.
+func (s dashSegment) key() string {
+	return s.Period + "|" + s.URL
 }
 
 func (d *DASHDownloader) Download(ctx context.Context, w io.Writer) error {
@@ -104,7 +129,7 @@ func (d *DASHDownloader) Download(ctx context.Context, w io.Writer) error {
 		default:
 		}
 
-		manifest, err := d.fetchManifest(ctx)
+		manifest, header, err := d.fetchManifest(ctx)
 		if err != nil {
 			return err
 		}
@@ -114,7 +139,7 @@ func (d *DASHDownloader) Download(ctx context.Context, w io.Writer) error {
 			return err
 		}
 
-		segments, timeout, err := d.extractSegments(mpd)
+		segments, err := d.extractSegments(mpd)
 		if err != nil {
 			return err
 		}
@@ -129,10 +154,10 @@ func (d *DASHDownloader) Download(ctx context.Context, w io.Writer) error {
 
 		// Download new segments
 		for _, seg := range segments {
-			if seg.Seq <= d.lastSeq && d.lastSeq != -1 {
+			if last, ok := d.lastSeq[seg.Period]; ok && seg.Seq <= last {
 				continue
 			}
-			if d.seenSegments[seg.URL] {
+			if d.seenSegments[seg.key()] {
 				continue
 			}
 
@@ -142,28 +167,19 @@ func (d *DASHDownloader) Download(ctx context.Context, w io.Writer) error {
 					if limit := d.Transport.MaxSkippedFragments; limit > 0 && d.skippedFragments > limit {
 						return fmt.Errorf("failed to download segment seq=%d (skip limit exceeded): %w", seg.Seq, err)
 					}
-					d.lastSeq = seg.Seq
-					d.seenSegments[seg.URL] = true
+					d.lastSeq[seg.Period] = seg.Seq
+					d.seenSegments[seg.key()] = true
 					continue
 				}
 				return err
 			}
-
-			d.lastSeq = seg.Seq
-			d.seenSegments[seg.URL] = true
 		}
 
 		if !isDynamic {
 			return nil
 		}
 
-		// Wait
-		sleepTime := timeout
-		if sleepTime == 0 {
-			sleepTime = 5 * time.Second
-		}
-
-		timer := time.NewTimer(sleepTime)
+		timer := time.NewTimer(manifestRefreshDelay(mpd, header, d.Live, time.Now()))
 		select {
 		case <-ctx.Done():
 			timer.Stop()
@@ -176,8 +192,7 @@ func (d *DASHDownloader) Download(ctx context.Context, w io.Writer) error {
 func (d *DASHDownloader) downloadSegmentsConcurrent(ctx context.Context, segments []dashSegment, w io.Writer) error {
 	type item struct {
 		index int
-		seq   int64
-		url   string
+		seg   dashSegment
 		body  []byte
 		err   error
 	}
@@ -202,8 +217,7 @@ func (d *DASHDownloader) downloadSegmentsConcurrent(ctx context.Context, segment
 			body, err := doGETBytesWithRetry(ctx, d.Client, seg.URL, d.Headers, d.Transport)
 			out[i] = item{
 				index: i,
-				seq:   seg.Seq,
-				url:   seg.URL,
+				seg:   seg,
 				body:  body,
 				err:   err,
 			}
@@ -216,19 +230,33 @@ func (d *DASHDownloader) downloadSegmentsConcurrent(ctx context.Context, segment
 
 	for _, it := range out {
 		if it.err != nil {
-			return fmt.Errorf("failed to download segment seq=%d: %w", it.seq, it.err)
+			return fmt.Errorf("failed to download segment seq=%d: %w", it.seg.Seq, it.err)
 		}
-		if _, err := w.Write(it.body); err != nil {
+		if err := d.writeSegment(it.seg, it.body, w); err != nil {
 			return err
 		}
-		d.lastSeq = it.seq
-		d.seenSegments[it.url] = true
 	}
 	return nil
 }
 
-func (d *DASHDownloader) fetchManifest(ctx context.Context) ([]byte, error) {
-	return doGETBytesWithRetry(ctx, d.Client, d.ManifestURL, d.Headers, d.Transport)
+func (d *DASHDownloader) fetchManifest(ctx context.Context) ([]byte, http.Header, error) {
+	return doGETWithRetry(ctx, d.Client, d.ManifestURL, d.Headers, d.Transport)
+}
+
+// manifestRefreshDelay is the wait before reloading a dynamic MPD: its
+// minimumUpdatePeriod, or the response freshness when longer, within half
+// of the timeShiftBufferDepth window. live stands in for either when the
+// MPD leaves it out.
+func manifestRefreshDelay(mpd *dashMPD, header http.Header, live LiveHints, now time.Time) time.Duration {
+	var hint, window time.Duration
+	if mpd.MinimumUpdatePeriod != "" {
+		hint, _ = parseISODuration(mpd.MinimumUpdatePeriod)
+	}
+	if mpd.TimeShiftBufferDepth != "" {
+		window, _ = parseISODuration(mpd.TimeShiftBufferDepth)
+	}
+	hint, window = live.fill(hint, window)
+	return refreshDelay(hint, responseFreshness(header, now), window)
 }
 
 func parseDASH(data []byte) (*dashMPD, error) {
@@ -239,7 +267,7 @@ func parseDASH(data []byte) (*dashMPD, error) {
 	return &mpd, nil
 }
 
-func (d *DASHDownloader) extractSegments(mpd *dashMPD) ([]dashSegment, time.Duration, error) {
+func (d *DASHDownloader) extractSegments(mpd *dashMPD) ([]dashSegment, error) {
 	// Find Representation
 	var rep *dashRepresentation
 	var adapt *dashAdaptationSet
@@ -265,20 +293,87 @@ func (d *DASHDownloader) extractSegments(mpd *dashMPD) ([]dashSegment, time.Dura
 	}
 
 	if !found {
-		return nil, 0, fmt.Errorf("representation %s not found", d.RepresentationID)
+		return nil, fmt.Errorf("representation %s not found", d.RepresentationID)
+	}
+
+	// Multi-period manifests (ad-stitched, DVR) repeat the stream in every
+	// Period, sometimes under a different Representation id.
+	var segments []dashSegment
+	for i := range mpd.Period {
+		period := &mpd.Period[i]
+		periodAdapt, periodRep := matchPeriodRepresentation(period, d.RepresentationID, adapt.MimeType, rep.Bandwidth)
+		if periodRep == nil {
+			continue
+		}
+		periodSegments, err := d.periodSegments(mpd, period, periodAdapt, periodRep, periodKey(period, i))
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, periodSegments...)
 	}
+	return segments, nil
+}
 
+// periodKey identifies period across manifest refreshes: its id, else its
+// start (with its BaseURL, when it has one), since the index shifts when
+// DVR Periods expire off the front. The index is the last resort.
+func periodKey(period *dashPeriod, index int) string {
+	if period.ID != "" {
+		return period.ID
+	}
+	if period.Start != "" {
+		if period.BaseURL != "" {
+			return period.BaseURL + "@" + period.Start
+		}
+		return period.Start
+	}
+	return fmt.Sprintf("%d", index)
+}
+
+// matchPeriodRepresentation finds the Representation continuing the selected
+// stream in period: the same id, else the closest bandwidth with the same
+// mime type.
+func matchPeriodRepresentation(period *dashPeriod, id, mimeType string, bandwidth int) (*dashAdaptationSet, *dashRepresentation) {
+	var bestAdapt *dashAdaptationSet
+	var bestRep *dashRepresentation
+	bestDiff := -1
+	for i := range period.AdaptationSet {
+		a := &period.AdaptationSet[i]
+		for j := range a.Representation {
+			r := &a.Representation[j]
+			if r.ID == id {
+				return a, r
+			}
+			if mimeType == "" || a.MimeType != mimeType {
+				continue
+			}
+			diff := r.Bandwidth - bandwidth
+			if diff < 0 {
+				diff = -diff
+			}
+			if bestDiff == -1 || diff < bestDiff {
+				bestAdapt, bestRep, bestDiff = a, r, diff
+			}
+		}
+	}
+	return bestAdapt, bestRep
+}
+
+func (d *DASHDownloader) periodSegments(mpd *dashMPD, period *dashPeriod, adapt *dashAdaptationSet, rep *dashRepresentation, periodID string) ([]dashSegment, error) {
 	// Resolve Template
 	tmpl := rep.SegmentTemplate
 	if tmpl == nil {
 		tmpl = adapt.SegmentTemplate
 	}
 	if tmpl == nil {
-		return nil, 0, fmt.Errorf("SegmentTemplate not found for representation %s", d.RepresentationID)
+		return nil, fmt.Errorf("SegmentTemplate not found for representation %s", rep.ID)
 	}
 
 	// Resolve BaseURL
 	baseURL := mpd.BaseURL
+	if period.BaseURL != "" {
+		baseURL = period.BaseURL
+	}
 	if rep.BaseURL != "" {
 		baseURL = rep.BaseURL // Overrides? Or appends? DASH standard says ... complex. Assuming override or relative.
 		// YouTube usually puts BaseURL in Rep usually? Or MPD level.
@@ -287,7 +382,7 @@ func (d *DASHDownloader) extractSegments(mpd *dashMPD) ([]dashSegment, time.Dura
 	// Timeline processing
 	if tmpl.SegmentTimeline == nil {
 		// Number based template?
-		return nil, 0, fmt.Errorf("SegmentTimeline missing (Number-based template not implemented)")
+		return nil, fmt.Errorf("SegmentTimeline missing (Number-based template not implemented)")
 	}
 
 	var segments []dashSegment
@@ -309,7 +404,7 @@ func (d *DASHDownloader) extractSegments(mpd *dashMPD) ([]dashSegment, time.Dura
 		count := s.R + 1
 		for i := int64(0); i < count; i++ {
 			// Generate URL
-			urlStr := strings.ReplaceAll(tmpl.Media, "$RepresentationID$", d.RepresentationID)
+			urlStr := strings.ReplaceAll(tmpl.Media, "$RepresentationID$", rep.ID)
 			urlStr = strings.ReplaceAll(urlStr, "$Number$", fmt.Sprintf("%d", currentSeq))
 			urlStr = strings.ReplaceAll(urlStr, "$Time$", fmt.Sprintf("%d", currentTime))
 			urlStr = strings.ReplaceAll(urlStr, "$Bandwidth$", fmt.Sprintf("%d", rep.Bandwidth))
@@ -317,24 +412,16 @@ func (d *DASHDownloader) extractSegments(mpd *dashMPD) ([]dashSegment, time.Dura
 			fullURL := resolveURL(d.ManifestURL, baseURL+urlStr)
 
 			segments = append(segments, dashSegment{
-				URL: fullURL,
-				Seq: currentSeq,
+				URL:    fullURL,
+				Seq:    currentSeq,
+				Period: periodID,
 			})
 
 			currentTime += s.D
 			currentSeq++
 		}
 	}
-
-	// Duration calculation (minimumUpdatePeriod)
-	timeout := 5 * time.Second
-	if mpd.MinimumUpdatePeriod != "" {
-		if d, err := parseDuration(mpd.MinimumUpdatePeriod); err == nil {
-			timeout = d
-		}
-	}
-
-	return segments, timeout, nil
+	return segments, nil
 }
 
 func (d *DASHDownloader) downloadSegment(ctx context.Context, seg dashSegment, w io.Writer) error {
@@ -342,13 +429,28 @@ func (d *DASHDownloader) downloadSegment(ctx context.Context, seg dashSegment, w
 	if err != nil {
 		return err
 	}
-	_, err = w.Write(body)
-	return err
+	return d.writeSegment(seg, body, w)
 }
 
-func parseDuration(s string) (time.Duration, error) {
-	// ISO 8601 duration parser (PT1S)
-	// Go doesn't have native ISO duration parser.
-	// Simple approximation for PT#S
-	return time.ParseDuration(strings.ToLower(strings.ReplaceAll(s, "PT", "")))
+// writeSegment writes body and records a Period boundary when seg starts a
+// different Period than the previously written segment.
+func (d *DASHDownloader) writeSegment(seg dashSegment, body []byte, w io.Writer) error {
+	if d.wroteSegment && seg.Period != d.lastPeriod {
+		d.Discontinuities = append(d.Discontinuities, Discontinuity{
+			Offset:   d.written,
+			Sequence: seg.Seq,
+			Period:   seg.Period,
+			Reason:   "period",
+		})
+	}
+	n, err := w.Write(body)
+	d.written += int64(n)
+	if err != nil {
+		return err
+	}
+	d.wroteSegment = true
+	d.lastPeriod = seg.Period
+	d.lastSeq[seg.Period] = seg.Seq
+	d.seenSegments[seg.key()] = true
+	return nil
 }
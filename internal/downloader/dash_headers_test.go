@@ -3,8 +3,10 @@ package downloader
 import (
 	"bytes"
 	"context"
+	"encoding/xml"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -208,3 +210,115 @@ func TestDASHDownloader_StaticConcurrentDownloadKeepsOrder(t *testing.T) {
 		t.Fatalf("ordered segment payload mismatch: got=%q want=AB", got)
 	}
 }
+
+func TestDASHDownloader_MultiPeriodRecordsDiscontinuities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/manifest.mpd":
+			w.Write([]byte(`<?xml version="1.0"?>
+<MPD type="static" xmlns="urn:mpeg:dash:schema:mpd:2011">
+  <Period id="content">
+    <AdaptationSet mimeType="video/mp4">
+      <Representation id="248" bandwidth="1000000">
+        <SegmentTemplate media="seg-$Number$.m4s" startNumber="1">
+          <SegmentTimeline><S d="1" r="1"/></SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+  <Period id="ad">
+    <BaseURL>ad/</BaseURL>
+    <AdaptationSet mimeType="video/mp4">
+      <Representation id="ad-low" bandwidth="200000">
+        <SegmentTemplate media="seg-$Number$.m4s" startNumber="1">
+          <SegmentTimeline><S d="1"/></SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+      <Representation id="ad-high" bandwidth="900000">
+        <SegmentTemplate media="seg-$Number$.m4s" startNumber="1">
+          <SegmentTimeline><S d="1"/></SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+    <AdaptationSet mimeType="audio/mp4">
+      <Representation id="ad-audio" bandwidth="1000000"/>
+    </AdaptationSet>
+  </Period>
+  <Period id="content-2">
+    <BaseURL>p3/</BaseURL>
+    <AdaptationSet mimeType="video/mp4">
+      <Representation id="248" bandwidth="1000000">
+        <SegmentTemplate media="seg-$Number$.m4s" startNumber="1">
+          <SegmentTimeline><S d="1"/></SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+  </Period>
+</MPD>`))
+		case "/seg-1.m4s", "/seg-2.m4s", "/ad/seg-1.m4s", "/p3/seg-1.m4s":
+			w.Write([]byte("[" + r.URL.Path + "]"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dl := NewDASHDownloader(server.Client(), server.URL+"/manifest.mpd", "248")
+	var buf bytes.Buffer
+	if err := dl.Download(context.Background(), &buf); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if got, want := buf.String(), "[/seg-1.m4s][/seg-2.m4s][/ad/seg-1.m4s][/p3/seg-1.m4s]"; got != want {
+		t.Fatalf("output=%q, want %q", got, want)
+	}
+	want := []Discontinuity{
+		{Offset: int64(len("[/seg-1.m4s][/seg-2.m4s]")), Sequence: 1, Period: "ad", Reason: "period"},
+		{Offset: int64(len("[/seg-1.m4s][/seg-2.m4s][/ad/seg-1.m4s]")), Sequence: 1, Period: "content-2", Reason: "period"},
+	}
+	if len(dl.Discontinuities) != len(want) {
+		t.Fatalf("discontinuities=%+v, want %+v", dl.Discontinuities, want)
+	}
+	for i := range want {
+		if dl.Discontinuities[i] != want[i] {
+			t.Fatalf("discontinuity[%d]=%+v, want %+v", i, dl.Discontinuities[i], want[i])
+		}
+	}
+}
+
+func TestDASHDownloader_PeriodsWithoutIDKeyOnStart(t *testing.T) {
+	period := func(start, base string) string {
+		return `
+  <Period start="` + start + `">
+    <BaseURL>` + base + `</BaseURL>
+    <AdaptationSet mimeType="video/mp4">
+      <Representation id="248" bandwidth="1000000">
+        <SegmentTemplate media="seg-$Number$.m4s" startNumber="1">
+          <SegmentTimeline><S d="1"/></SegmentTimeline>
+        </SegmentTemplate>
+      </Representation>
+    </AdaptationSet>
+  </Period>`
+	}
+	parse := func(periods ...string) []dashSegment {
+		var mpd dashMPD
+		doc := `<MPD type="dynamic" xmlns="urn:mpeg:dash:schema:mpd:2011">` + strings.Join(periods, "") + `</MPD>`
+		if err := xml.Unmarshal([]byte(doc), &mpd); err != nil {
+			t.Fatalf("xml.Unmarshal() error = %v", err)
+		}
+		segments, err := NewDASHDownloader(http.DefaultClient, "https://example.com/live/manifest.mpd", "248").extractSegments(&mpd)
+		if err != nil {
+			t.Fatalf("extractSegments() error = %v", err)
+		}
+		return segments
+	}
+
+	before := parse(period("PT0S", "p1/"), period("PT60S", "p2/"))
+	// The first Period expired off the DVR window: indices shift, starts do not.
+	after := parse(period("PT60S", "p2/"))
+	if len(before) != 2 || len(after) != 1 {
+		t.Fatalf("segments before=%d after=%d", len(before), len(after))
+	}
+	if before[1].key() != after[0].key() || before[0].Period == before[1].Period {
+		t.Fatalf("period keys before=%q,%q after=%q", before[0].Period, before[1].Period, after[0].Period)
+	}
+}
@@ -16,3 +16,14 @@ type Downloader interface {
 type ProgressReporter interface {
 	OnProgress(bytesWritten int64, totalBytes int64)
 }
+
+// Discontinuity marks a point in the written output where media timestamps or
+// encoding parameters may restart: a new DASH Period, an HLS
+// EXT-X-DISCONTINUITY, or media lost from an LL-HLS segment. Naively concatenated output is only continuous
+// between boundaries, so callers can split or re-time at Offset.
+type Discontinuity struct {
+	Offset   int64  // bytes written before the boundary
+	Sequence int64  // first segment sequence number after the boundary
+	Period   string // DASH Period id (start, then index, when absent); empty for HLS
+	Reason   string // "period", "discontinuity" or "incomplete_segment"
+}
@@ -0,0 +1,238 @@
+package downloader
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+// External downloader names accepted by NewExternalDownloader.
+const (
+	ExternalAria2c = "aria2c"
+	ExternalCurl   = "curl"
+)
+
+// ExternalDownloader hands one direct media transfer to aria2c or curl and
+// parses the program's progress output.
+type ExternalDownloader struct {
+	Name string   // ExternalAria2c or ExternalCurl
+	Path string   // executable; defaults to Name
+	Args []string // extra arguments, placed before the URL
+}
+
+// NewExternalDownloader returns an ExternalDownloader for name ("aria2c",
+// "aria2" or "curl"). If path is empty, the program is looked up in PATH.
+func NewExternalDownloader(name, path string, args []string) (*ExternalDownloader, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case ExternalAria2c, "aria2":
+		name = ExternalAria2c
+	case ExternalCurl:
+		name = ExternalCurl
+	default:
+		return nil, fmt.Errorf("unsupported external downloader %q (want aria2c or curl)", name)
+	}
+	if strings.TrimSpace(path) == "" {
+		path = name
+	}
+	return &ExternalDownloader{Name: name, Path: path, Args: append([]string(nil), args...)}, nil
+}
+
+// aria2cArgs returns the aria2c arguments for req. The URL and headers are
+// read from inputFile, written with aria2cInput.
+func aria2cArgs(req types.ExternalDownload, extra []string, inputFile string) []string {
+	args := []string{
+		"--allow-overwrite=true",
+		"--auto-file-renaming=false",
+		"--file-allocation=none",
+		"--console-log-level=warn",
+		"--summary-interval=1",
+		"--download-result=hide",
+		"--dir", filepath.Dir(req.OutputPath),
+		"--out", filepath.Base(req.OutputPath),
+	}
+	if req.Resume {
+		args = append(args, "--continue=true")
+	}
+	args = append(args, extra...)
+	return append(args, "--input-file", inputFile)
+}
+
+// aria2cInput is the aria2c input file for req: the URL followed by its
+// headers as indented per-download options.
+func aria2cInput(req types.ExternalDownload) string {
+	var b strings.Builder
+	b.WriteString(req.URL + "\n")
+	for _, h := range headerLines(req.Headers) {
+		b.WriteString(" header=" + h + "\n")
+	}
+	return b.String()
+}
+
+// curlArgs returns the curl arguments for req. The headers are read from
+// headerFile, written with curlHeaders; without headers it is empty.
+func curlArgs(req types.ExternalDownload, extra []string, headerFile string) []string {
+	args := []string{"--location", "--fail", "--show-error", "--output", req.OutputPath}
+	if req.Resume {
+		args = append(args, "--continue-at", "-")
+	}
+	if headerFile != "" {
+		args = append(args, "--header", "@"+headerFile)
+	}
+	args = append(args, extra...)
+	return append(args, "--", req.URL)
+}
+
+// curlHeaders is the curl header file for req, one "Name: value" per line.
+func curlHeaders(req types.ExternalDownload) string {
+	var b strings.Builder
+	for _, h := range headerLines(req.Headers) {
+		b.WriteString(h + "\n")
+	}
+	return b.String()
+}
+
+// headerLines flattens headers into sorted "Name: value" lines, dropping
+// values that would break the line.
+func headerLines(headers http.Header) []string {
+	var lines []string
+	for k, vals := range headers {
+		for _, v := range vals {
+			if strings.ContainsAny(v, "\r\n") {
+				continue
+			}
+			lines = append(lines, http.CanonicalHeaderKey(k)+": "+v)
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// aria2cProgressPattern matches the readout "[#2089b0 1.2MiB/10MiB(12%) CN:16 DL:3.4MiB ETA:2s]".
+var aria2cProgressPattern = regexp.MustCompile(`\[#\w+\s+([\d.]+[KMGT]?i?B)/([\d.]+[KMGT]?i?B)`)
+
+func parseAria2cProgress(line string) (types.DownloadProgress, bool) {
+	m := aria2cProgressPattern.FindStringSubmatch(line)
+	if m == nil {
+		return types.DownloadProgress{}, false
+	}
+	done, ok1 := parseProgressSize(m[1])
+	total, ok2 := parseProgressSize(m[2])
+	if !ok1 || !ok2 {
+		return types.DownloadProgress{}, false
+	}
+	return types.DownloadProgress{Bytes: done, Total: total}, true
+}
+
+// parseCurlProgress reads a row of curl's progress meter:
+// "% Total % Received % Xferd Average-Dload Upload Total Spent Left Speed".
+func parseCurlProgress(line string) (types.DownloadProgress, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 12 {
+		return types.DownloadProgress{}, false
+	}
+	if _, err := strconv.Atoi(fields[0]); err != nil {
+		return types.DownloadProgress{}, false
+	}
+	total, ok1 := parseProgressSize(fields[1])
+	done, ok2 := parseProgressSize(fields[3])
+	if !ok1 || !ok2 {
+		return types.DownloadProgress{}, false
+	}
+	return types.DownloadProgress{Bytes: done, Total: total}, true
+}
+
+// parseProgressSize parses sizes such as "512", "4608k", "10.0M" (curl) or
+// "1.2MiB", "0B" (aria2c). Both programs use 1024-based units.
+func parseProgressSize(s string) (int64, bool) {
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), "i")
+	mult := float64(1)
+	if n := len(s); n > 0 {
+		switch s[n-1] {
+		case 'k', 'K':
+			mult = 1 << 10
+		case 'M':
+			mult = 1 << 20
+		case 'G':
+			mult = 1 << 30
+		case 'T':
+			mult = 1 << 40
+		}
+		if mult > 1 {
+			s = s[:n-1]
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return int64(v * mult), true
+}
+
+// scanProgressLines is bufio.ScanLines that also splits on bare \r.
+func scanProgressLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// SplitArgs splits a command-line argument string the way a POSIX shell
+// would for words: whitespace separates arguments, single and double quotes
+// group them, and a backslash escapes the next character outside single
+// quotes.
+func SplitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				args = append(args, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("trailing backslash")
+	}
+	if inWord {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
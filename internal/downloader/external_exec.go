@@ -0,0 +1,112 @@
+//go:build !js
+
+package downloader
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+// Available reports whether the downloader executable can be found.
+func (d *ExternalDownloader) Available() bool {
+	_, err := exec.LookPath(d.Path)
+	return err == nil
+}
+
+// Download runs the external program for req, calling progress (if non-nil)
+// with every progress sample it prints. Request headers, which may carry
+// cookies, are passed in a private temporary file rather than as arguments
+// any local user could read from the process list.
+func (d *ExternalDownloader) Download(ctx context.Context, req types.ExternalDownload, progress func(types.DownloadProgress)) error {
+	var args []string
+	var parse func(string) (types.DownloadProgress, bool)
+	switch d.Name {
+	case ExternalAria2c:
+		input, err := writePrivateFile(aria2cInput(req))
+		if err != nil {
+			return err
+		}
+		defer os.Remove(input)
+		args, parse = aria2cArgs(req, d.Args, input), parseAria2cProgress
+	case ExternalCurl:
+		var headerFile string
+		if headers := curlHeaders(req); headers != "" {
+			var err error
+			if headerFile, err = writePrivateFile(headers); err != nil {
+				return err
+			}
+			defer os.Remove(headerFile)
+		}
+		args, parse = curlArgs(req, d.Args, headerFile), parseCurlProgress
+	default:
+		return fmt.Errorf("unsupported external downloader %q", d.Name)
+	}
+
+	pr, pw := io.Pipe()
+	cmd := exec.CommandContext(ctx, d.Path, args...)
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	done := make(chan string, 1)
+	go func() {
+		// Both programs redraw their progress line with \r.
+		var last string
+		scanner := bufio.NewScanner(pr)
+		scanner.Split(scanProgressLines)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			if p, ok := parse(line); ok {
+				if progress != nil {
+					progress(p)
+				}
+				continue
+			}
+			last = line
+		}
+		_, _ = io.Copy(io.Discard, pr)
+		done <- last
+	}()
+
+	err := cmd.Run()
+	_ = pw.Close()
+	last := <-done
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if last != "" {
+			return fmt.Errorf("%s failed: %w: %s", d.Name, err, last)
+		}
+		return fmt.Errorf("%s failed: %w", d.Name, err)
+	}
+	return nil
+}
+
+// writePrivateFile writes content to a new temporary file only the current
+// user can read (os.CreateTemp uses mode 0600) and returns its path.
+func writePrivateFile(content string) (string, error) {
+	f, err := os.CreateTemp("", "ytv1-external-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
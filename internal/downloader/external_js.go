@@ -0,0 +1,23 @@
+//go:build js
+
+package downloader
+
+import (
+	"context"
+	"errors"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+// errNoProcesses is returned on js/wasm, where programs cannot be started.
+var errNoProcesses = errors.New("external downloaders need os/exec, which js/wasm lacks")
+
+// Available always reports false on js/wasm.
+func (d *ExternalDownloader) Available() bool {
+	return false
+}
+
+// Download always fails on js/wasm.
+func (d *ExternalDownloader) Download(ctx context.Context, req types.ExternalDownload, progress func(types.DownloadProgress)) error {
+	return errNoProcesses
+}
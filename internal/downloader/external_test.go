@@ -0,0 +1,162 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+func TestNewExternalDownloader(t *testing.T) {
+	d, err := NewExternalDownloader("Aria2", "", []string{"-x16"})
+	if err != nil || d.Name != ExternalAria2c || d.Path != "aria2c" {
+		t.Fatalf("NewExternalDownloader(aria2) = %+v, %v", d, err)
+	}
+	if _, err := NewExternalDownloader("wget", "", nil); err == nil {
+		t.Fatal("NewExternalDownloader(wget) error = nil, want unsupported")
+	}
+}
+
+func TestExternalDownloaderArgs(t *testing.T) {
+	req := types.ExternalDownload{
+		URL:        "https://media.example/v.mp4",
+		Headers:    http.Header{"User-Agent": {"ua"}, "Referer": {"https://www.youtube.com/"}},
+		OutputPath: filepath.Join("dir", "out.mp4"),
+		Resume:     true,
+	}
+	gotAria := aria2cArgs(req, []string{"-x16"}, "input.txt")
+	wantAria := []string{
+		"--allow-overwrite=true", "--auto-file-renaming=false", "--file-allocation=none",
+		"--console-log-level=warn", "--summary-interval=1", "--download-result=hide",
+		"--dir", "dir", "--out", "out.mp4", "--continue=true",
+		"-x16", "--input-file", "input.txt",
+	}
+	if !reflect.DeepEqual(gotAria, wantAria) {
+		t.Fatalf("aria2cArgs() = %q\nwant %q", gotAria, wantAria)
+	}
+	if got, want := aria2cInput(req), req.URL+"\n header=Referer: https://www.youtube.com/\n header=User-Agent: ua\n"; got != want {
+		t.Fatalf("aria2cInput() = %q, want %q", got, want)
+	}
+	gotCurl := curlArgs(req, nil, "headers.txt")
+	wantCurl := []string{
+		"--location", "--fail", "--show-error", "--output", req.OutputPath, "--continue-at", "-",
+		"--header", "@headers.txt",
+		"--", req.URL,
+	}
+	if !reflect.DeepEqual(gotCurl, wantCurl) {
+		t.Fatalf("curlArgs() = %q\nwant %q", gotCurl, wantCurl)
+	}
+	if got, want := curlHeaders(req), "Referer: https://www.youtube.com/\nUser-Agent: ua\n"; got != want {
+		t.Fatalf("curlHeaders() = %q, want %q", got, want)
+	}
+}
+
+func TestExternalDownloader_HeadersStayOutOfArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the downloader")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "curl")
+	// Record the arguments and the header file, which must be private.
+	body := "#!/bin/sh\n" +
+		"for a in \"$@\"; do\n" +
+		"  echo \"$a\" >> \"$OUT/args\"\n" +
+		"  case \"$a\" in @*) f=\"${a#@}\"; cat \"$f\" > \"$OUT/headers\"; find \"$f\" -perm 600 > \"$OUT/private\";; esac\n" +
+		"done\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OUT", dir)
+	d, err := NewExternalDownloader("curl", script, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := types.ExternalDownload{
+		URL:        "https://media.example/v.mp4",
+		Headers:    http.Header{"Cookie": {"SID=secret"}},
+		OutputPath: filepath.Join(dir, "out.mp4"),
+	}
+	if err := d.Download(context.Background(), req, nil); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	headers, _ := os.ReadFile(filepath.Join(dir, "headers"))
+	private, _ := os.ReadFile(filepath.Join(dir, "private"))
+	if strings.Contains(string(args), "secret") || string(headers) != "Cookie: SID=secret\n" || len(private) == 0 {
+		t.Fatalf("args=%q headers=%q private=%q", args, headers, private)
+	}
+}
+
+func TestParseExternalProgress(t *testing.T) {
+	tests := []struct {
+		parse func(string) (types.DownloadProgress, bool)
+		line  string
+		want  types.DownloadProgress
+		ok    bool
+	}{
+		{parseAria2cProgress, "[#2089b0 1.5MiB/10MiB(15%) CN:16 DL:3.4MiB ETA:2s]", types.DownloadProgress{Bytes: 3 << 19, Total: 10 << 20}, true},
+		{parseAria2cProgress, "[#2089b0 0B/0B CN:1 DL:0B]", types.DownloadProgress{}, true},
+		{parseAria2cProgress, "*** Download Progress Summary ***", types.DownloadProgress{}, false},
+		{parseCurlProgress, " 45 10.0M   45 4608k    0     0  1234k      0  0:00:08  0:00:03  0:00:05 1234k", types.DownloadProgress{Bytes: 4608 << 10, Total: 10 << 20}, true},
+		{parseCurlProgress, "  % Total    % Received % Xferd  Average Speed   Time    Time     Time  Current", types.DownloadProgress{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.parse(tt.line)
+		if ok != tt.ok || got != tt.want {
+			t.Fatalf("parse(%q) = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExternalDownloader_ReportsProgressAndErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the downloader")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "curl")
+	body := "#!/bin/sh\n" +
+		"printf '  %% Total    %% Received\\r 50  2048   50  1024    0     0  1024      0  0:00:02  0:00:01  0:00:01  1024\\r' >&2\n" +
+		"printf '100  2048  100  2048    0     0  2048      0  0:00:01  0:00:01 --:--:--  2048\\n' >&2\n" +
+		"[ \"$FAIL\" = 1 ] && { echo 'curl: (22) The requested URL returned error: 403' >&2; exit 22; }\n" +
+		"exit 0\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewExternalDownloader("curl", script, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var samples []types.DownloadProgress
+	req := types.ExternalDownload{URL: "https://media.example/v.mp4", OutputPath: filepath.Join(dir, "out.mp4")}
+	if err := d.Download(context.Background(), req, func(p types.DownloadProgress) { samples = append(samples, p) }); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	want := []types.DownloadProgress{{Bytes: 1024, Total: 2048}, {Bytes: 2048, Total: 2048}}
+	if !reflect.DeepEqual(samples, want) {
+		t.Fatalf("progress = %+v, want %+v", samples, want)
+	}
+
+	t.Setenv("FAIL", "1")
+	err = d.Download(context.Background(), req, nil)
+	if err == nil || err.Error() != "curl failed: exit status 22: curl: (22) The requested URL returned error: 403" {
+		t.Fatalf("Download() error = %v", err)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	got, err := SplitArgs(`-x16 --header "X-Foo: a b" 'it''s' c\ d`)
+	want := []string{"-x16", "--header", "X-Foo: a b", "its", "c d"}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("SplitArgs() = %q, %v; want %q", got, err, want)
+	}
+	if _, err := SplitArgs(`"open`); err == nil {
+		t.Fatal("SplitArgs(unterminated) error = nil")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -23,18 +24,54 @@ type HLSDownloader struct {
 	Headers     http.Header
 	Transport   TransportConfig
 
+	// Discontinuities lists EXT-X-DISCONTINUITY boundaries in the written output.
+	Discontinuities []Discontinuity
+
+	// LiveOffset starts a live capture this far behind the live edge instead
+	// of at the oldest segment of the DVR window. Offsets beyond the window
+	// start at its oldest segment. It has no effect on VOD playlists.
+	LiveOffset time.Duration
+
+	// Live supplies refresh hints a live playlist omits.
+	Live LiveHints
+
 	// State
+	offsetApplied    bool
 	seenSegments     map[string]bool
 	lastSeq          int
 	skippedFragments int
+	written          int64
+	// LL-HLS state: partialSeq is the segment being assembled from parts
+	// (-1 when none) and partialCount how many of its parts were consumed.
+	partialSeq   int
+	partialCount int
 }
 
 type hlsSegment struct {
-	URL      string
+	URL      string // empty for the open LL-HLS segment that only has parts so far
 	Duration float64
 	Key      *hlsKey
 	Map      *hlsMap
 	Seq      int
+	Parts    []hlsPart
+	// Discontinuity is set when EXT-X-DISCONTINUITY precedes the segment.
+	Discontinuity bool
+}
+
+// hlsPart is an LL-HLS partial segment (EXT-X-PART or EXT-X-PRELOAD-HINT).
+type hlsPart struct {
+	URL    string
+	Offset int64 // byte range start; -1 when the whole resource is used
+	Length int64 // byte range length; 0 means to the end of the resource
+}
+
+type hlsPlaylist struct {
+	Segments       []hlsSegment
+	TargetDuration float64
+	PartTarget     float64
+	CanBlockReload bool
+	PreloadHint    *hlsPart
+	HintSeq        int // media sequence the preload hint belongs to
 }
 
 type hlsKey struct {
@@ -54,6 +91,7 @@ func NewHLSDownloader(client *http.Client, playlistURL string) *HLSDownloader {
 		PlaylistURL:  playlistURL,
 		seenSegments: make(map[string]bool),
 		lastSeq:      -1,
+		partialSeq:   -1,
 	}
 }
 
@@ -67,7 +105,20 @@ func (h *HLSDownloader) WithTransportConfig(cfg TransportConfig) *HLSDownloader
 	return h
 }
 
+// WithLiveOffset sets LiveOffset.
+func (h *HLSDownloader) WithLiveOffset(offset time.Duration) *HLSDownloader {
+	h.LiveOffset = offset
+	return h
+}
+
+func (h *HLSDownloader) WithLiveHints(hints LiveHints) *HLSDownloader {
+	h.Live = hints
+	return h
+}
+
 func (h *HLSDownloader) Download(ctx context.Context, w io.Writer) error {
+	w = &countingWriter{w: w, n: &h.written}
+	playlistURL := h.PlaylistURL
 	for {
 		select {
 		case <-ctx.Done():
@@ -76,30 +127,63 @@ func (h *HLSDownloader) Download(ctx context.Context, w io.Writer) error {
 		}
 
 		// 1. Fetch Media Playlist
-		manifest, err := h.fetchManifest(ctx, h.PlaylistURL)
+		manifest, header, err := h.fetchManifest(ctx, playlistURL)
 		if err != nil {
 			return err
 		}
 
 		// 2. Parse Segments
-		segments, targetDuration, err := h.parseSegments(ctx, manifest, h.PlaylistURL)
+		playlist, err := h.parsePlaylist(ctx, manifest, h.PlaylistURL)
 		if err != nil {
 			return err
 		}
 		isLive := !strings.Contains(manifest, "#EXT-X-ENDLIST")
+		if isLive && h.LiveOffset > 0 && !h.offsetApplied {
+			// Seek once, on the first playlist; later reloads continue from there.
+			h.offsetApplied = true
+			if start, ok := liveOffsetStart(playlist, h.LiveOffset); ok {
+				h.lastSeq = start - 1
+			}
+		}
+
+		// VOD playlists are fetched in parallel when the transport allows it.
+		if !isLive && normalizeTransportConfig(h.Transport).MaxConcurrency > 1 {
+			if pending, ok := h.pendingVODSegments(playlist); ok && len(pending) > 1 {
+				return h.downloadSegmentsConcurrent(ctx, pending, w)
+			}
+		}
 
 		// 3. Process new segments
 		newSegments := 0
-		for _, seg := range segments {
+		for _, seg := range playlist.Segments {
 			// Basic dedup by Sequence Number if available, else URL
 			if seg.Seq <= h.lastSeq && h.lastSeq != -1 {
 				continue
 			}
+			// LL-HLS: the open segment and any segment already started from
+			// parts are completed part by part so no media is written twice.
+			if seg.URL == "" || seg.Seq == h.partialSeq {
+				if seg.URL != "" && len(seg.Parts) == 0 {
+					h.noteIncompleteSegment(seg)
+				}
+				n, err := h.downloadParts(ctx, seg, w, isLive)
+				if err != nil {
+					return err
+				}
+				newSegments += n
+				if seg.URL != "" {
+					h.lastSeq = seg.Seq
+					h.seenSegments[seg.URL] = true
+					h.partialSeq, h.partialCount = -1, 0
+				}
+				continue
+			}
 			if h.seenSegments[seg.URL] {
 				// Fallback dedup (shouldn't happen with proper Seq)
 				continue
 			}
 
+			h.noteDiscontinuity(seg)
 			if err := h.downloadSegment(ctx, seg, w); err != nil {
 				if isLive && shouldSkipFragmentError(err, h.Transport) {
 					h.skippedFragments++
@@ -117,6 +201,9 @@ func (h *HLSDownloader) Download(ctx context.Context, w io.Writer) error {
 			h.seenSegments[seg.URL] = true
 			newSegments++
 		}
+		if isLive && h.downloadPreloadHint(ctx, playlist, w) {
+			newSegments++
+		}
 
 		// 4. Check for End List
 		if !isLive {
@@ -124,15 +211,16 @@ func (h *HLSDownloader) Download(ctx context.Context, w io.Writer) error {
 		}
 
 		// 5. Wait before refresh
-		sleepTime := time.Duration(targetDuration * float64(time.Second))
-		if sleepTime == 0 {
-			sleepTime = 5 * time.Second
+		if playlist.CanBlockReload && playlist.PartTarget > 0 {
+			// Blocking reload: the server holds the response until the next
+			// part exists, so only pause when the last reload made no progress.
+			msn, part := h.nextMediaSequence()
+			playlistURL = blockingReloadURL(h.PlaylistURL, msn, part)
+			if newSegments > 0 {
+				continue
+			}
 		}
-		// If we found no new segments, maybe backoff slightly not needed as we sleep targetDuration
-		// Usually targetDuration / 2 or full targetDuration.
-		// yt-dlp logic is complex, simple approach: wait targetDuration.
-
-		timer := time.NewTimer(sleepTime)
+		timer := time.NewTimer(playlistRefreshDelay(playlist, header, newSegments > 0, h.Live, time.Now()))
 		select {
 		case <-ctx.Done():
 			timer.Stop()
@@ -142,20 +230,178 @@ func (h *HLSDownloader) Download(ctx context.Context, w io.Writer) error {
 	}
 }
 
-func (h *HLSDownloader) fetchManifest(ctx context.Context, url string) (string, error) {
-	body, err := doGETBytesWithRetry(ctx, h.Client, url, h.Headers, h.Transport)
+// liveOffsetStart returns the media sequence to start at so that at least
+// offset of complete segments lies between it and the live edge, clamped to
+// the oldest segment in the playlist. Segments without an EXTINF duration
+// count as the target duration.
+func liveOffsetStart(playlist *hlsPlaylist, offset time.Duration) (int, bool) {
+	var behind float64
+	start, found := 0, false
+	for i := len(playlist.Segments) - 1; i >= 0; i-- {
+		seg := playlist.Segments[i]
+		if seg.URL == "" {
+			// The open LL-HLS segment is at the edge itself.
+			continue
+		}
+		start, found = seg.Seq, true
+		d := seg.Duration
+		if d <= 0 {
+			d = playlist.TargetDuration
+		}
+		behind += d
+		if behind >= offset.Seconds() {
+			break
+		}
+	}
+	return start, found
+}
+
+// playlistRefreshDelay is the wait before reloading a live playlist: the
+// target duration after a reload that added segments, half of it after one
+// that did not (RFC 8216 section 6.3.4), or the response freshness when
+// longer, within half of the playlist's window. live stands in for the
+// target duration and window when the playlist lacks them.
+func playlistRefreshDelay(playlist *hlsPlaylist, header http.Header, changed bool, live LiveHints, now time.Time) time.Duration {
+	var listed float64
+	for _, seg := range playlist.Segments {
+		listed += seg.Duration
+	}
+	hint, window := live.fill(
+		time.Duration(targetDuration(playlist)*float64(time.Second)),
+		time.Duration(listed*float64(time.Second)),
+	)
+	if !changed {
+		hint /= 2
+	}
+	return refreshDelay(hint, responseFreshness(header, now), window)
+}
+
+// targetDuration is the playlist refresh interval: the part target for
+// LL-HLS playlists, else the segment target duration.
+func targetDuration(playlist *hlsPlaylist) float64 {
+	if playlist.PartTarget > 0 {
+		return playlist.PartTarget
+	}
+	return playlist.TargetDuration
+}
+
+// downloadParts writes the parts of seg that were not written yet and
+// returns how many it wrote.
+func (h *HLSDownloader) downloadParts(ctx context.Context, seg hlsSegment, w io.Writer, isLive bool) (int, error) {
+	if h.partialSeq != seg.Seq {
+		h.partialSeq, h.partialCount = seg.Seq, 0
+	}
+	if h.partialCount == 0 {
+		h.noteDiscontinuity(seg)
+	}
+	written := 0
+	for i, part := range seg.Parts {
+		if h.seenSegments[part.key()] {
+			continue
+		}
+		if err := h.downloadPart(ctx, part, w); err != nil {
+			if isLive && shouldSkipFragmentError(err, h.Transport) {
+				h.skippedFragments++
+				if limit := h.Transport.MaxSkippedFragments; limit > 0 && h.skippedFragments > limit {
+					return written, fmt.Errorf("failed to download part seq=%d part=%d (skip limit exceeded): %w", seg.Seq, i, err)
+				}
+				h.seenSegments[part.key()] = true
+				h.partialCount++
+				continue
+			}
+			return written, fmt.Errorf("failed to download part seq=%d part=%d: %w", seg.Seq, i, err)
+		}
+		h.seenSegments[part.key()] = true
+		h.partialCount++
+		written++
+	}
+	return written, nil
+}
+
+// downloadPreloadHint fetches the hinted next part ahead of the playlist
+// listing it. Failures are ignored: the part is retried once it is listed.
+func (h *HLSDownloader) downloadPreloadHint(ctx context.Context, playlist *hlsPlaylist, w io.Writer) bool {
+	hint := playlist.PreloadHint
+	if hint == nil || h.seenSegments[hint.key()] {
+		return false
+	}
+	// Only continue the segment being assembled or start the next one;
+	// anything else would write media out of order.
+	if playlist.HintSeq != h.partialSeq && playlist.HintSeq != h.lastSeq+1 {
+		return false
+	}
+	if err := h.downloadPart(ctx, *hint, w); err != nil {
+		return false
+	}
+	if h.partialSeq != playlist.HintSeq {
+		h.partialSeq, h.partialCount = playlist.HintSeq, 0
+	}
+	h.seenSegments[hint.key()] = true
+	h.partialCount++
+	return true
+}
+
+// noteDiscontinuity records a boundary at the current output offset when seg
+// follows EXT-X-DISCONTINUITY and is not the first media written.
+func (h *HLSDownloader) noteDiscontinuity(seg hlsSegment) {
+	if !seg.Discontinuity || h.written == 0 {
+		return
+	}
+	h.Discontinuities = append(h.Discontinuities, Discontinuity{
+		Offset:   h.written,
+		Sequence: int64(seg.Seq),
+		Reason:   "discontinuity",
+	})
+}
+
+// noteIncompleteSegment records a boundary after a segment that was started
+// from parts but completed in a playlist that no longer lists them: the rest
+// of it cannot be fetched without writing its start twice, so the output
+// skips from the parts written so far to the next segment.
+func (h *HLSDownloader) noteIncompleteSegment(seg hlsSegment) {
+	h.Discontinuities = append(h.Discontinuities, Discontinuity{
+		Offset:   h.written,
+		Sequence: int64(seg.Seq) + 1,
+		Reason:   "incomplete_segment",
+	})
+}
+
+// nextMediaSequence returns the media sequence number and part index the
+// downloader needs next, as used by LL-HLS blocking playlist reload.
+func (h *HLSDownloader) nextMediaSequence() (int, int) {
+	if h.partialSeq != -1 {
+		return h.partialSeq, h.partialCount
+	}
+	return h.lastSeq + 1, 0
+}
+
+func blockingReloadURL(playlistURL string, msn, part int) string {
+	u, err := url.Parse(playlistURL)
+	if err != nil {
+		return playlistURL
+	}
+	q := u.Query()
+	q.Set("_HLS_msn", strconv.Itoa(msn))
+	q.Set("_HLS_part", strconv.Itoa(part))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func (h *HLSDownloader) fetchManifest(ctx context.Context, url string) (string, http.Header, error) {
+	body, header, err := doGETWithRetry(ctx, h.Client, url, h.Headers, h.Transport)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return string(body), nil
+	return string(body), header, nil
 }
 
-func (h *HLSDownloader) parseSegments(ctx context.Context, manifest, manifestURL string) ([]hlsSegment, float64, error) {
+func (h *HLSDownloader) parsePlaylist(ctx context.Context, manifest, manifestURL string) (*hlsPlaylist, error) {
 	scanner := bufio.NewScanner(strings.NewReader(manifest))
-	var segments []hlsSegment
+	playlist := &hlsPlaylist{}
 	var currentKey *hlsKey
 	var currentMap *hlsMap
-	var targetDuration float64
+	var pendingParts []hlsPart
+	discontinuity := false
 
 	seq := 0 // Default start
 
@@ -167,7 +413,7 @@ func (h *HLSDownloader) parseSegments(ctx context.Context, manifest, manifestURL
 
 		if strings.HasPrefix(line, "#EXT-X-TARGETDURATION:") {
 			if v, err := strconv.ParseFloat(line[22:], 64); err == nil {
-				targetDuration = v
+				playlist.TargetDuration = v
 			}
 			continue
 		}
@@ -179,10 +425,45 @@ func (h *HLSDownloader) parseSegments(ctx context.Context, manifest, manifestURL
 			continue
 		}
 
+		if line == "#EXT-X-DISCONTINUITY" {
+			discontinuity = true
+			continue
+		}
+
+		if strings.HasPrefix(line, "#EXT-X-PART-INF:") {
+			attrs := formats.ParseM3U8Attrs(line[16:])
+			if v, err := strconv.ParseFloat(attrs["PART-TARGET"], 64); err == nil {
+				playlist.PartTarget = v
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "#EXT-X-SERVER-CONTROL:") {
+			attrs := formats.ParseM3U8Attrs(line[22:])
+			playlist.CanBlockReload = attrs["CAN-BLOCK-RELOAD"] == "YES"
+			continue
+		}
+
+		if strings.HasPrefix(line, "#EXT-X-PART:") {
+			// Encrypted parts cannot be decrypted independently of their
+			// segment, so those segments are only fetched once complete.
+			if part, ok := parsePart(line[12:], manifestURL, pendingParts); ok && !isEncrypted(currentKey) {
+				pendingParts = append(pendingParts, part)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "#EXT-X-PRELOAD-HINT:") {
+			if hint, ok := parsePreloadHint(line[20:], manifestURL); ok && !isEncrypted(currentKey) {
+				playlist.PreloadHint = &hint
+			}
+			continue
+		}
+
 		if strings.HasPrefix(line, "#EXT-X-KEY:") {
 			k, err := parseKey(line[11:], manifestURL)
 			if err != nil {
-				return nil, 0, err
+				return nil, err
 			}
 			currentKey = k
 			continue
@@ -196,7 +477,7 @@ func (h *HLSDownloader) parseSegments(ctx context.Context, manifest, manifestURL
 		}
 
 		if strings.HasPrefix(line, "#EXTINF:") {
-			// duration := parseExtInf(line)
+			duration, _ := strconv.ParseFloat(strings.TrimSpace(strings.SplitN(line[8:], ",", 2)[0]), 64)
 			// Next line is URL
 			if scanner.Scan() {
 				urlLine := strings.TrimSpace(scanner.Text())
@@ -206,44 +487,68 @@ func (h *HLSDownloader) parseSegments(ctx context.Context, manifest, manifestURL
 				if currentKey != nil && currentKey.Method == "AES-128" && len(currentKey.Key) == 0 {
 					keyBytes, err := h.fetchKey(ctx, currentKey.URI)
 					if err != nil {
-						return nil, 0, fmt.Errorf("failed to fetch key: %w", err)
+						return nil, fmt.Errorf("failed to fetch key: %w", err)
 					}
 					currentKey.Key = keyBytes
 				}
 
-				segments = append(segments, hlsSegment{
-					URL: fullURL,
-					Key: currentKey,
-					Map: currentMap,
-					Seq: seq,
+				playlist.Segments = append(playlist.Segments, hlsSegment{
+					URL:           fullURL,
+					Duration:      duration,
+					Key:           currentKey,
+					Map:           currentMap,
+					Seq:           seq,
+					Parts:         pendingParts,
+					Discontinuity: discontinuity,
 				})
+				pendingParts = nil
+				discontinuity = false
 				seq++
 			}
 		}
 	}
-	return segments, targetDuration, nil
+	if len(pendingParts) > 0 {
+		playlist.Segments = append(playlist.Segments, hlsSegment{
+			Map:           currentMap,
+			Seq:           seq,
+			Parts:         pendingParts,
+			Discontinuity: discontinuity,
+		})
+	}
+	playlist.HintSeq = seq
+	return playlist, nil
 }
 
 func (h *HLSDownloader) downloadSegment(ctx context.Context, seg hlsSegment, w io.Writer) error {
-	body, err := doGETBytesWithRetry(ctx, h.Client, seg.URL, h.Headers, h.Transport)
+	body, err := h.fetchSegment(ctx, seg)
 	if err != nil {
 		return err
 	}
+	_, err = w.Write(body)
+	return err
+}
+
+// fetchSegment returns the media of seg, decrypted when it is encrypted.
+func (h *HLSDownloader) fetchSegment(ctx context.Context, seg hlsSegment) ([]byte, error) {
+	body, err := doGETBytesWithRetry(ctx, h.Client, seg.URL, h.Headers, h.Transport)
+	if err != nil {
+		return nil, err
+	}
 	// Decrypt if needed
 	if seg.Key != nil && seg.Key.Method == "AES-128" {
 		if len(seg.Key.Key) == 0 {
-			return fmt.Errorf("key not fetched for encrypted segment")
+			return nil, fmt.Errorf("key not fetched for encrypted segment")
 		}
 		block, err := aes.NewCipher(seg.Key.Key)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		cbc := cipher.NewCBCDecrypter(block, seg.Key.IV)
 		if len(body) == 0 {
-			return nil
+			return nil, nil
 		}
 		if len(body)%aes.BlockSize != 0 {
-			return fmt.Errorf("encrypted data not block aligned")
+			return nil, fmt.Errorf("encrypted data not block aligned")
 		}
 		cbc.CryptBlocks(body, body)
 		// Remove padding (PKCS7)
@@ -252,14 +557,103 @@ func (h *HLSDownloader) downloadSegment(ctx context.Context, seg hlsSegment, w i
 			// This happens if key is wrong or data is corrupt.
 			// For now, return error or maybe just warn and write raw?
 			// Return error to be safe.
-			return fmt.Errorf("invalid padding")
+			return nil, fmt.Errorf("invalid padding")
 		}
 		body = body[:len(body)-padding]
+	}
+	return body, nil
+}
 
-		_, err = w.Write(body)
-		return err
+// pendingVODSegments returns the segments of a VOD playlist not written yet,
+// such as the rest of a live stream that just ended. It reports false when
+// a segment has to be assembled from LL-HLS parts.
+func (h *HLSDownloader) pendingVODSegments(playlist *hlsPlaylist) ([]hlsSegment, bool) {
+	var pending []hlsSegment
+	for _, seg := range playlist.Segments {
+		if seg.Seq <= h.lastSeq && h.lastSeq != -1 {
+			continue
+		}
+		if seg.URL == "" || seg.Seq == h.partialSeq {
+			return nil, false
+		}
+		if h.seenSegments[seg.URL] {
+			continue
+		}
+		pending = append(pending, seg)
 	}
+	return pending, true
+}
 
+// downloadSegmentsConcurrent fetches segments MaxConcurrency at a time and
+// writes them in playlist order. A segment's slot is only freed once it is
+// written, so at most MaxConcurrency segments are held in memory.
+func (h *HLSDownloader) downloadSegmentsConcurrent(ctx context.Context, segments []hlsSegment, w io.Writer) error {
+	type result struct {
+		body []byte
+		err  error
+	}
+	cfg := normalizeTransportConfig(h.Transport)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan result, len(segments))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+	sem := make(chan struct{}, cfg.MaxConcurrency)
+	go func() {
+		for i, seg := range segments {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				body, err := h.fetchSegment(ctx, seg)
+				results[i] <- result{body: body, err: err}
+			}()
+		}
+	}()
+
+	for i, seg := range segments {
+		var r result
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if r.err != nil {
+			return fmt.Errorf("failed to download segment seq=%d: %w", seg.Seq, r.err)
+		}
+		h.noteDiscontinuity(seg)
+		if _, err := w.Write(r.body); err != nil {
+			return err
+		}
+		h.lastSeq = seg.Seq
+		h.seenSegments[seg.URL] = true
+		<-sem
+	}
+	return nil
+}
+
+// downloadPart writes an unencrypted LL-HLS part, honoring its byte range.
+func (h *HLSDownloader) downloadPart(ctx context.Context, part hlsPart, w io.Writer) error {
+	headers := h.Headers
+	if part.Offset >= 0 {
+		headers = cloneHeader(h.Headers)
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		if part.Length > 0 {
+			headers.Set("Range", fmt.Sprintf("bytes=%d-%d", part.Offset, part.Offset+part.Length-1))
+		} else {
+			headers.Set("Range", fmt.Sprintf("bytes=%d-", part.Offset))
+		}
+	}
+	body, err := doGETBytesWithRetry(ctx, h.Client, part.URL, headers, h.Transport)
+	if err != nil {
+		return err
+	}
 	_, err = w.Write(body)
 	return err
 }
@@ -285,6 +679,69 @@ func parseKey(attrs, manifestURL string) (*hlsKey, error) {
 	return key, nil
 }
 
+// key identifies a part across playlist reloads. The length is left out so a
+// preload hint with an open-ended range matches its later EXT-X-PART entry.
+func (p hlsPart) key() string {
+	if p.Offset < 0 {
+		return p.URL
+	}
+	return p.URL + "@" + strconv.FormatInt(p.Offset, 10)
+}
+
+func isEncrypted(key *hlsKey) bool {
+	return key != nil && key.Method != "" && key.Method != "NONE"
+}
+
+// parsePart parses EXT-X-PART attributes. A BYTERANGE without an offset
+// continues where the previous part of the same resource ended.
+func parsePart(attrs, manifestURL string, previous []hlsPart) (hlsPart, bool) {
+	m := formats.ParseM3U8Attrs(attrs)
+	if m["URI"] == "" {
+		return hlsPart{}, false
+	}
+	part := hlsPart{URL: resolveURL(manifestURL, m["URI"]), Offset: -1}
+	if raw := m["BYTERANGE"]; raw != "" {
+		lengthRaw, offsetRaw, hasOffset := strings.Cut(raw, "@")
+		length, err := strconv.ParseInt(lengthRaw, 10, 64)
+		if err != nil || length <= 0 {
+			return hlsPart{}, false
+		}
+		part.Length = length
+		part.Offset = 0
+		if hasOffset {
+			if part.Offset, err = strconv.ParseInt(offsetRaw, 10, 64); err != nil {
+				return hlsPart{}, false
+			}
+		} else if n := len(previous); n > 0 && previous[n-1].URL == part.URL && previous[n-1].Offset >= 0 {
+			part.Offset = previous[n-1].Offset + previous[n-1].Length
+		}
+	}
+	return part, true
+}
+
+// parsePreloadHint parses EXT-X-PRELOAD-HINT attributes; only TYPE=PART
+// hints are used.
+func parsePreloadHint(attrs, manifestURL string) (hlsPart, bool) {
+	m := formats.ParseM3U8Attrs(attrs)
+	if m["TYPE"] != "PART" || m["URI"] == "" {
+		return hlsPart{}, false
+	}
+	hint := hlsPart{URL: resolveURL(manifestURL, m["URI"]), Offset: -1}
+	if raw, ok := m["BYTERANGE-START"]; ok {
+		start, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || start < 0 {
+			return hlsPart{}, false
+		}
+		hint.Offset = start
+		if raw := m["BYTERANGE-LENGTH"]; raw != "" {
+			if hint.Length, err = strconv.ParseInt(raw, 10, 64); err != nil {
+				return hlsPart{}, false
+			}
+		}
+	}
+	return hint, true
+}
+
 func parseMap(attrs string) (*hlsMap, error) {
 	m := formats.ParseM3U8Attrs(attrs)
 	// URI is mandatory for MAP
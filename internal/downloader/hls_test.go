@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -263,3 +264,266 @@ func TestHLSDownloader_SkipLimitExceeded(t *testing.T) {
 		t.Fatal("expected skip-limit error")
 	}
 }
+
+func TestHLSDownloader_LowLatencyPartsAndPreloadHint(t *testing.T) {
+	var (
+		mu            sync.Mutex
+		playlistCalls int
+		reloadQuery   string
+		fullSeg1Calls int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/playlist.m3u8":
+			playlistCalls++
+			header := "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-PART-INF:PART-TARGET=0.01\n" +
+				"#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=0.03\n#EXT-X-MEDIA-SEQUENCE:0\n" +
+				"#EXT-X-PART:DURATION=1,URI=\"s0.p0.ts\"\n#EXTINF:1.0,\nsegment-0.ts\n" +
+				"#EXT-X-PART:DURATION=0.5,URI=\"s1.p0.ts\"\n#EXT-X-PART:DURATION=0.5,URI=\"s1.p1.ts\"\n"
+			if playlistCalls == 1 {
+				fmt.Fprint(w, header+"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"s1.p2.ts\"\n")
+				return
+			}
+			reloadQuery = r.URL.RawQuery
+			fmt.Fprint(w, header+"#EXT-X-PART:DURATION=0.5,URI=\"s1.p2.ts\"\n#EXT-X-PART:DURATION=0.5,URI=\"s1.p3.ts\"\n"+
+				"#EXTINF:2.0,\nsegment-1.ts\n#EXT-X-ENDLIST\n")
+		case "/segment-0.ts":
+			w.Write([]byte("seg0|"))
+		case "/segment-1.ts":
+			fullSeg1Calls++
+			w.Write([]byte("seg1-full|"))
+		default:
+			// Parts are served as their own resources: /s1.pN.ts -> "s1.pN|".
+			w.Write([]byte(r.URL.Path[1:len(r.URL.Path)-3] + "|"))
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	dl := NewHLSDownloader(server.Client(), server.URL+"/playlist.m3u8")
+	var buf bytes.Buffer
+	if err := dl.Download(ctx, &buf); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+
+	if got, want := buf.String(), "seg0|s1.p0|s1.p1|s1.p2|s1.p3|"; got != want {
+		t.Fatalf("output=%q, want %q", got, want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fullSeg1Calls != 0 {
+		t.Fatalf("full segment-1 fetched %d times after its parts were written", fullSeg1Calls)
+	}
+	if reloadQuery != "_HLS_msn=1&_HLS_part=3" {
+		t.Fatalf("blocking reload query=%q, want _HLS_msn=1&_HLS_part=3", reloadQuery)
+	}
+}
+
+func TestHLSDownloader_PartialSegmentWithoutPartsIsMarked(t *testing.T) {
+	var (
+		mu            sync.Mutex
+		playlistCalls int
+		fullSeg1Calls int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/playlist.m3u8":
+			playlistCalls++
+			header := "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-PART-INF:PART-TARGET=0.01\n#EXT-X-MEDIA-SEQUENCE:0\n" +
+				"#EXTINF:1.0,\nsegment-0.ts\n"
+			if playlistCalls == 1 {
+				fmt.Fprint(w, header+"#EXT-X-PART:DURATION=0.5,URI=\"s1.p0.ts\"\n#EXT-X-PART:DURATION=0.5,URI=\"s1.p1.ts\"\n")
+				return
+			}
+			// The reload closes segment 1 but no longer lists its parts.
+			fmt.Fprint(w, header+"#EXTINF:2.0,\nsegment-1.ts\n#EXTINF:1.0,\nsegment-2.ts\n#EXT-X-ENDLIST\n")
+		case "/segment-0.ts":
+			w.Write([]byte("seg0|"))
+		case "/segment-1.ts":
+			fullSeg1Calls++
+			w.Write([]byte("seg1-full|"))
+		case "/segment-2.ts":
+			w.Write([]byte("seg2|"))
+		default:
+			w.Write([]byte(r.URL.Path[1:len(r.URL.Path)-3] + "|"))
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	dl := NewHLSDownloader(server.Client(), server.URL+"/playlist.m3u8")
+	var buf bytes.Buffer
+	if err := dl.Download(ctx, &buf); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if got, want := buf.String(), "seg0|s1.p0|s1.p1|seg2|"; got != want {
+		t.Fatalf("output=%q, want %q", got, want)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fullSeg1Calls != 0 {
+		t.Fatalf("full segment-1 fetched %d times after its first parts were written", fullSeg1Calls)
+	}
+	want := Discontinuity{Offset: int64(len("seg0|s1.p0|s1.p1|")), Sequence: 2, Reason: "incomplete_segment"}
+	if len(dl.Discontinuities) != 1 || dl.Discontinuities[0] != want {
+		t.Fatalf("discontinuities=%+v, want [%+v]", dl.Discontinuities, want)
+	}
+}
+
+func TestParsePart_ByteRangeContinuesPreviousPart(t *testing.T) {
+	first, ok := parsePart(`DURATION=0.5,URI="seg.mp4",BYTERANGE="100@0"`, "https://example.com/live/index.m3u8", nil)
+	if !ok || first.URL != "https://example.com/live/seg.mp4" || first.Offset != 0 || first.Length != 100 {
+		t.Fatalf("first part=%+v ok=%v", first, ok)
+	}
+	second, ok := parsePart(`DURATION=0.5,URI="seg.mp4",BYTERANGE="50"`, "https://example.com/live/index.m3u8", []hlsPart{first})
+	if !ok || second.Offset != 100 || second.Length != 50 {
+		t.Fatalf("second part=%+v ok=%v", second, ok)
+	}
+	hint, ok := parsePreloadHint(`TYPE=PART,URI="seg.mp4",BYTERANGE-START=150`, "https://example.com/live/index.m3u8")
+	if !ok || hint.key() != "https://example.com/live/seg.mp4@150" || hint.Length != 0 {
+		t.Fatalf("hint=%+v ok=%v", hint, ok)
+	}
+}
+
+func TestHLSDownloader_RecordsDiscontinuities(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/playlist.m3u8":
+			fmt.Fprint(w, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXT-X-MEDIA-SEQUENCE:10\n"+
+				"#EXT-X-DISCONTINUITY\n#EXTINF:2.0,\na.ts\n#EXTINF:2.0,\nb.ts\n"+
+				"#EXT-X-DISCONTINUITY\n#EXTINF:2.0,\nad.ts\n#EXT-X-ENDLIST\n")
+		default:
+			w.Write([]byte(r.URL.Path[1:]))
+		}
+	}))
+	defer server.Close()
+
+	dl := NewHLSDownloader(server.Client(), server.URL+"/playlist.m3u8")
+	var buf bytes.Buffer
+	if err := dl.Download(context.Background(), &buf); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if got := buf.String(); got != "a.tsb.tsad.ts" {
+		t.Fatalf("output=%q", got)
+	}
+	// The leading discontinuity precedes any written media and is not a boundary.
+	want := Discontinuity{Offset: int64(len("a.tsb.ts")), Sequence: 12, Reason: "discontinuity"}
+	if len(dl.Discontinuities) != 1 || dl.Discontinuities[0] != want {
+		t.Fatalf("discontinuities=%+v, want [%+v]", dl.Discontinuities, want)
+	}
+}
+
+func TestHLSDownloader_LiveOffsetSeeksBehindEdge(t *testing.T) {
+	var playlistCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/playlist.m3u8":
+			// A 5-segment DVR window of 2s segments, then one more segment
+			// and the end of the stream.
+			if atomic.AddInt32(&playlistCalls, 1) == 1 {
+				fmt.Fprintf(w, "#EXTM3U\n#EXT-X-TARGETDURATION:0.01\n#EXT-X-MEDIA-SEQUENCE:10\n")
+				for i := 10; i < 15; i++ {
+					fmt.Fprintf(w, "#EXTINF:2.0,\nseg-%d.ts\n", i)
+				}
+				return
+			}
+			fmt.Fprintf(w, "#EXTM3U\n#EXT-X-TARGETDURATION:0.01\n#EXT-X-MEDIA-SEQUENCE:12\n")
+			for i := 12; i < 16; i++ {
+				fmt.Fprintf(w, "#EXTINF:2.0,\nseg-%d.ts\n", i)
+			}
+			fmt.Fprintf(w, "#EXT-X-ENDLIST\n")
+		default:
+			fmt.Fprintf(w, "[%s]", strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/seg-"), ".ts"))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		offset time.Duration
+		want   string
+	}{
+		{offset: 0, want: "[10][11][12][13][14][15]"},
+		{offset: 3 * time.Second, want: "[13][14][15]"},
+		{offset: 4 * time.Second, want: "[13][14][15]"},
+		{offset: time.Hour, want: "[10][11][12][13][14][15]"}, // clamped to the DVR window
+	}
+	for _, tt := range tests {
+		atomic.StoreInt32(&playlistCalls, 0)
+		dl := NewHLSDownloader(server.Client(), server.URL+"/playlist.m3u8").WithLiveOffset(tt.offset)
+		var buf bytes.Buffer
+		if err := dl.Download(context.Background(), &buf); err != nil {
+			t.Fatalf("offset=%v: Download() error = %v", tt.offset, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Fatalf("offset=%v: output = %q, want %q", tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestHLSDownloader_LiveOffsetIgnoredForVOD(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/playlist.m3u8" {
+			fmt.Fprintf(w, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n#EXTINF:2.0,\na.ts\n#EXTINF:2.0,\nb.ts\n#EXT-X-ENDLIST\n")
+			return
+		}
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	dl := NewHLSDownloader(server.Client(), server.URL+"/playlist.m3u8").WithLiveOffset(time.Second)
+	var buf bytes.Buffer
+	if err := dl.Download(context.Background(), &buf); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if got := buf.String(); got != "/a.ts/b.ts" {
+		t.Fatalf("output = %q, want both segments", got)
+	}
+}
+
+func TestHLSDownloader_VODFetchesSegmentsConcurrently(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/playlist.m3u8" {
+			fmt.Fprintf(w, "#EXTM3U\n#EXT-X-TARGETDURATION:2\n")
+			for i := 0; i < 6; i++ {
+				fmt.Fprintf(w, "#EXTINF:2.0,\n%d.ts\n", i)
+			}
+			fmt.Fprintf(w, "#EXT-X-ENDLIST\n")
+			return
+		}
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		// Earlier segments answer last, so in-order writing is exercised.
+		var seg int
+		fmt.Sscanf(r.URL.Path, "/%d.ts", &seg)
+		time.Sleep(time.Duration(6-seg) * 10 * time.Millisecond)
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	dl := NewHLSDownloader(server.Client(), server.URL+"/playlist.m3u8").WithTransportConfig(TransportConfig{
+		MaxConcurrency: 3,
+	})
+	var buf bytes.Buffer
+	if err := dl.Download(context.Background(), &buf); err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if got, want := buf.String(), "/0.ts/1.ts/2.ts/3.ts/4.ts/5.ts"; got != want {
+		t.Fatalf("output = %q, want %q", got, want)
+	}
+	if p := peak.Load(); p < 2 || p > 3 {
+		t.Fatalf("peak concurrent segment fetches = %d, want 2..3", p)
+	}
+}
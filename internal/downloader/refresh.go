@@ -0,0 +1,136 @@
+package downloader
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultManifestRefresh is the live reload interval when the manifest gives
+// none.
+const defaultManifestRefresh = 5 * time.Second
+
+// LiveHints are a live stream's settings from outside its manifest (the
+// player response), used where the manifest states none.
+type LiveHints struct {
+	// TargetDuration is the stream's segment duration, the reload interval
+	// when the manifest gives no minimumUpdatePeriod or target duration.
+	TargetDuration time.Duration
+	// Window is the DVR window, bounding the reload interval when the
+	// manifest does not state its own.
+	Window time.Duration
+}
+
+// fill returns hint and window, replacing unknown (zero) values with h.
+func (h LiveHints) fill(hint, window time.Duration) (time.Duration, time.Duration) {
+	if hint <= 0 {
+		hint = h.TargetDuration
+	}
+	if window <= 0 {
+		window = h.Window
+	}
+	return hint, window
+}
+
+// parseISODuration parses an ISO 8601 duration as used by DASH manifests
+// ("PT5S", "PT1M30.5S", "P1DT2H"). Years and months have no fixed length and
+// are rejected; weeks and days count as 7 and 1 times 24 hours.
+func parseISODuration(s string) (time.Duration, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(s), "P")
+	if !ok || rest == "" || strings.HasSuffix(rest, "T") {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+	var total float64
+	inTime := false
+	for rest != "" {
+		if rest[0] == 'T' {
+			if inTime {
+				return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+			}
+			inTime, rest = true, rest[1:]
+			continue
+		}
+		i := strings.IndexFunc(rest, func(r rune) bool { return (r < '0' || r > '9') && r != '.' && r != ',' })
+		if i <= 0 {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+		}
+		n, err := strconv.ParseFloat(strings.Replace(rest[:i], ",", ".", 1), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+		}
+		var unit time.Duration
+		switch designator := rest[i]; {
+		case !inTime && designator == 'W':
+			unit = 7 * 24 * time.Hour
+		case !inTime && designator == 'D':
+			unit = 24 * time.Hour
+		case inTime && designator == 'H':
+			unit = time.Hour
+		case inTime && designator == 'M':
+			unit = time.Minute
+		case inTime && designator == 'S':
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("unsupported ISO 8601 duration %q", s)
+		}
+		total += n * float64(unit)
+		rest = rest[i+1:]
+	}
+	return time.Duration(total), nil
+}
+
+// responseFreshness returns how long a manifest response stays fresh per its
+// Cache-Control max-age (less Age) or Expires header. Reloading earlier only
+// returns the copy a CDN already has. It returns 0 without caching headers
+// or with no-cache/no-store.
+func responseFreshness(h http.Header, now time.Time) time.Duration {
+	if h == nil {
+		return 0
+	}
+	for _, directive := range strings.Split(h.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+		switch name {
+		case "no-cache", "no-store":
+			return 0
+		case "max-age":
+			sec, err := strconv.Atoi(strings.Trim(value, `"`))
+			if err != nil || sec <= 0 {
+				return 0
+			}
+			age, _ := strconv.Atoi(h.Get("Age"))
+			if fresh := time.Duration(sec-age) * time.Second; fresh > 0 {
+				return fresh
+			}
+			return 0
+		}
+	}
+	if expires, err := http.ParseTime(h.Get("Expires")); err == nil {
+		if date, err := http.ParseTime(h.Get("Date")); err == nil {
+			now = date
+		}
+		if fresh := expires.Sub(now); fresh > 0 {
+			return fresh
+		}
+	}
+	return 0
+}
+
+// refreshDelay picks the wait before reloading a live manifest: the
+// manifest's own hint (minimumUpdatePeriod, target duration) or, when
+// longer, the response freshness, capped at half the live window (when
+// known) so segments cannot fall out of it between reloads.
+func refreshDelay(hint, freshness, window time.Duration) time.Duration {
+	wait := hint
+	if wait <= 0 {
+		wait = defaultManifestRefresh
+	}
+	if freshness > wait {
+		wait = freshness
+	}
+	if window > 0 && wait > window/2 {
+		wait = window / 2
+	}
+	return wait
+}
@@ -0,0 +1,99 @@
+package downloader
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseISODuration(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"PT5S":           5 * time.Second,
+		"PT1M30S":        90 * time.Second,
+		"PT2M":           2 * time.Minute,
+		"PT0H0M2.500S":   2500 * time.Millisecond,
+		"PT0,5S":         500 * time.Millisecond,
+		"P1DT2H":         26 * time.Hour,
+		"P1W":            7 * 24 * time.Hour,
+		"P0DT0H0M10.01S": 10010 * time.Millisecond,
+	} {
+		if got, err := parseISODuration(in); err != nil || got != want {
+			t.Errorf("parseISODuration(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "5S", "P", "PT", "PT5", "P1Y", "P2M", "PT1D", "PTT5S", "PT-5S"} {
+		if got, err := parseISODuration(in); err == nil {
+			t.Errorf("parseISODuration(%q) = %v, want error", in, got)
+		}
+	}
+}
+
+func TestResponseFreshness(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		header http.Header
+		want   time.Duration
+	}{
+		{http.Header{"Cache-Control": {"public, max-age=4"}}, 4 * time.Second},
+		{http.Header{"Cache-Control": {"max-age=10"}, "Age": {"7"}}, 3 * time.Second},
+		{http.Header{"Cache-Control": {"max-age=10"}, "Age": {"12"}}, 0},
+		{http.Header{"Cache-Control": {"no-cache, max-age=10"}}, 0},
+		{http.Header{"Expires": {now.Add(6 * time.Second).Format(http.TimeFormat)}}, 6 * time.Second},
+		{http.Header{"Expires": {now.Add(6 * time.Second).Format(http.TimeFormat)}, "Date": {now.Add(-time.Minute).Format(http.TimeFormat)}}, 66 * time.Second},
+		{http.Header{}, 0},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		if got := responseFreshness(tt.header, now); got != tt.want {
+			t.Errorf("responseFreshness(%v) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestManifestRefreshDelays(t *testing.T) {
+	now := time.Now()
+	mpd := &dashMPD{MinimumUpdatePeriod: "PT1M30S", TimeShiftBufferDepth: "PT2M"}
+	if got := manifestRefreshDelay(mpd, nil, LiveHints{}, now); got != time.Minute {
+		t.Errorf("DASH delay capped by the buffer = %v, want 1m", got)
+	}
+	mpd = &dashMPD{MinimumUpdatePeriod: "PT2S"}
+	if got := manifestRefreshDelay(mpd, http.Header{"Cache-Control": {"max-age=5"}}, LiveHints{}, now); got != 5*time.Second {
+		t.Errorf("DASH delay with max-age = %v, want 5s", got)
+	}
+	if got := manifestRefreshDelay(&dashMPD{}, nil, LiveHints{}, now); got != defaultManifestRefresh {
+		t.Errorf("DASH delay without hints = %v, want %v", got, defaultManifestRefresh)
+	}
+
+	playlist := &hlsPlaylist{TargetDuration: 4, Segments: []hlsSegment{{Duration: 4}, {Duration: 4}, {Duration: 4}}}
+	if got := playlistRefreshDelay(playlist, nil, true, LiveHints{}, now); got != 4*time.Second {
+		t.Errorf("HLS delay after new segments = %v, want 4s", got)
+	}
+	if got := playlistRefreshDelay(playlist, nil, false, LiveHints{}, now); got != 2*time.Second {
+		t.Errorf("HLS delay after an unchanged reload = %v, want 2s", got)
+	}
+	if got := playlistRefreshDelay(playlist, http.Header{"Cache-Control": {"max-age=30"}}, true, LiveHints{}, now); got != 6*time.Second {
+		t.Errorf("HLS delay with long max-age = %v, want half the 12s window", got)
+	}
+}
+
+func TestRefreshDelays_LiveHints(t *testing.T) {
+	now := time.Now()
+	live := LiveHints{TargetDuration: 2 * time.Second, Window: 3 * time.Second}
+	if got := manifestRefreshDelay(&dashMPD{}, nil, live, now); got != 1500*time.Millisecond {
+		t.Errorf("DASH delay from hints = %v, want the 2s target within half the 3s window", got)
+	}
+	if got := manifestRefreshDelay(&dashMPD{MinimumUpdatePeriod: "PT1S"}, nil, live, now); got != time.Second {
+		t.Errorf("DASH delay = %v, want the MPD's own 1s", got)
+	}
+	if got := manifestRefreshDelay(&dashMPD{}, nil, LiveHints{TargetDuration: time.Second}, now); got != time.Second {
+		t.Errorf("DASH delay from target duration = %v, want 1s", got)
+	}
+
+	playlist := &hlsPlaylist{TargetDuration: 4, Segments: []hlsSegment{{Duration: 4}, {Duration: 4}, {Duration: 4}}}
+	if got := playlistRefreshDelay(playlist, nil, true, live, now); got != 4*time.Second {
+		t.Errorf("HLS delay = %v, want the playlist's own 4s target", got)
+	}
+	if got := playlistRefreshDelay(&hlsPlaylist{}, nil, false, live, now); got != time.Second {
+		t.Errorf("HLS delay from hints after an unchanged reload = %v, want half the 2s target", got)
+	}
+}
@@ -6,9 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
+
+	"github.com/famomatic/ytv1/internal/httpx"
 )
 
 // TransportConfig controls retry/backoff behavior for downloader HTTP requests.
@@ -20,6 +20,9 @@ type TransportConfig struct {
 	MaxConcurrency           int
 	SkipUnavailableFragments bool
 	MaxSkippedFragments      int
+	// OnRetry, if set, is called before each retry backoff. It may be invoked
+	// concurrently when fragments download in parallel.
+	OnRetry func()
 }
 
 type effectiveTransportConfig struct {
@@ -75,22 +78,11 @@ func normalizeTransportConfig(cfg TransportConfig) effectiveTransportConfig {
 	}
 }
 
-func (c effectiveTransportConfig) backoffFor(attempt int) time.Duration {
-	backoff := c.InitialBackoff
-	for i := 0; i < attempt; i++ {
-		backoff *= 2
-		if backoff > c.MaxBackoff {
-			return c.MaxBackoff
-		}
-	}
-	return backoff
-}
-
 func isRetryableError(err error, cfg effectiveTransportConfig) bool {
 	if err == nil {
 		return false
 	}
-	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || httpx.IsPermanent(err) {
 		return false
 	}
 	var statusErr *downloadHTTPStatusError
@@ -135,12 +127,25 @@ func doGETBytesWithRetry(
 	headers http.Header,
 	cfg TransportConfig,
 ) ([]byte, error) {
+	body, _, err := doGETWithRetry(ctx, client, rawURL, headers, cfg)
+	return body, err
+}
+
+// doGETWithRetry is doGETBytesWithRetry, also returning the response headers
+// of the successful attempt.
+func doGETWithRetry(
+	ctx context.Context,
+	client *http.Client,
+	rawURL string,
+	headers http.Header,
+	cfg TransportConfig,
+) ([]byte, http.Header, error) {
 	effectiveCfg := normalizeTransportConfig(cfg)
 	var lastErr error
 	for attempt := 0; attempt <= effectiveCfg.MaxRetries; attempt++ {
 		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		applyRequestHeaders(req, headers)
 		resp, err := client.Do(req)
@@ -149,56 +154,42 @@ func doGETBytesWithRetry(
 		} else {
 			body, readErr := func() ([]byte, error) {
 				defer resp.Body.Close()
-				if resp.StatusCode != http.StatusOK {
+				if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
 					return nil, &downloadHTTPStatusError{
 						StatusCode: resp.StatusCode,
-						RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+						RetryAfter: httpx.ParseRetryAfter(resp.Header.Get("Retry-After")),
 					}
 				}
 				return io.ReadAll(resp.Body)
 			}()
 			if readErr == nil {
-				return body, nil
+				return body, resp.Header, nil
 			}
 			lastErr = readErr
 		}
 		if !isRetryableError(lastErr, effectiveCfg) || attempt == effectiveCfg.MaxRetries {
-			return nil, lastErr
+			return nil, nil, lastErr
 		}
-		backoff := effectiveCfg.backoffFor(attempt)
+		backoff := httpx.Backoff(effectiveCfg.InitialBackoff, effectiveCfg.MaxBackoff, attempt)
 		var statusErr *downloadHTTPStatusError
 		if errors.As(lastErr, &statusErr) && statusErr.RetryAfter > backoff {
 			backoff = statusErr.RetryAfter
 		}
+		backoff, ok := httpx.AllowRetry(ctx, backoff)
+		if !ok {
+			return nil, nil, lastErr
+		}
+		if cfg.OnRetry != nil {
+			cfg.OnRetry()
+		}
 		if err := waitBackoff(ctx, backoff); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 	if lastErr != nil {
-		return nil, lastErr
-	}
-	return nil, fmt.Errorf("request failed with unknown retry error")
-}
-
-func parseRetryAfter(raw string) time.Duration {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
-		return 0
-	}
-	if seconds, err := strconv.Atoi(raw); err == nil {
-		if seconds < 0 {
-			return 0
-		}
-		return time.Duration(seconds) * time.Second
-	}
-	if when, err := http.ParseTime(raw); err == nil {
-		d := time.Until(when)
-		if d < 0 {
-			return 0
-		}
-		return d
+		return nil, nil, lastErr
 	}
-	return 0
+	return nil, nil, fmt.Errorf("request failed with unknown retry error")
 }
 
 func max(a, b int) int {
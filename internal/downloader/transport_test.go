@@ -37,13 +37,3 @@ func TestDoGETBytesWithRetry_RetriesOn429(t *testing.T) {
 		t.Fatalf("call count=%d, want 2", got)
 	}
 }
-
-func TestParseRetryAfter_SecondsAndHTTPDate(t *testing.T) {
-	if d := parseRetryAfter("1"); d != time.Second {
-		t.Fatalf("seconds parse mismatch: got=%v want=%v", d, time.Second)
-	}
-	when := time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat)
-	if d := parseRetryAfter(when); d <= 0 {
-		t.Fatalf("http-date parse mismatch: got=%v", d)
-	}
-}
@@ -1,6 +1,7 @@
 package downloader
 
 import (
+	"io"
 	"net/url"
 )
 
@@ -15,3 +16,14 @@ func resolveURL(base, ref string) string {
 	}
 	return b.ResolveReference(r).String()
 }
+
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
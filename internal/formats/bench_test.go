@@ -0,0 +1,68 @@
+package formats
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/famomatic/ytv1/internal/innertube"
+)
+
+// parseAllocBudget is the allocation budget per Parse call on
+// benchPlayerResponse(200), about 25% above the measured baseline in
+// docs/benchmarks/baseline.txt.
+const parseAllocBudget = 770
+
+// benchPlayerResponse builds a response with n adaptive formats, every
+// other one behind a signatureCipher, like a web client response.
+func benchPlayerResponse(n int) *innertube.PlayerResponse {
+	resp := &innertube.PlayerResponse{
+		PlayabilityStatus: innertube.PlayabilityStatus{Status: "OK"},
+	}
+	for i := 0; i < n; i++ {
+		itag := 133 + i%40
+		streamURL := fmt.Sprintf("https://rr1---sn-x.googlevideo.com/videoplayback?expire=1760000000&id=o-ABC&itag=%d&n=abcd", itag)
+		f := innertube.Format{
+			Itag:             itag,
+			Bitrate:          50_000 + i*7_919,
+			ApproxDurationMs: "213341",
+			ContentLength:    strconv.Itoa(1_000_000 + i*1_024),
+			InitRange:        &innertube.Range{Start: "0", End: "731"},
+			IndexRange:       &innertube.Range{Start: "732", End: "1200"},
+		}
+		if i%2 == 0 {
+			f.MimeType = `video/mp4; codecs="avc1.640028"`
+			f.Width, f.Height, f.FPS = 1920, 1080, 30
+		} else {
+			f.MimeType = `audio/webm; codecs="opus"`
+			f.AudioSampleRate = "48000"
+			f.AudioChannels = 2
+		}
+		if i%4 < 2 {
+			f.URL = streamURL
+		} else {
+			f.SignatureCipher = url.Values{"url": {streamURL}, "sp": {"sig"}, "s": {"encrypted"}}.Encode()
+		}
+		resp.StreamingData.AdaptiveFormats = append(resp.StreamingData.AdaptiveFormats, f)
+	}
+	return resp
+}
+
+func BenchmarkParse(b *testing.B) {
+	resp := benchPlayerResponse(200)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if got := Parse(resp); len(got) != 200 {
+			b.Fatalf("Parse() returned %d formats, want 200", len(got))
+		}
+	}
+}
+
+func TestParseAllocBudget(t *testing.T) {
+	resp := benchPlayerResponse(200)
+	allocs := testing.AllocsPerRun(20, func() { Parse(resp) })
+	if allocs > parseAllocBudget {
+		t.Errorf("Parse() allocs = %v, budget %v", allocs, parseAllocBudget)
+	}
+}
@@ -17,11 +17,16 @@ type DASHManifest struct {
 	Formats    []Format
 }
 
-func FetchDASHManifest(ctx context.Context, client *http.Client, url string) (*DASHManifest, error) {
+func FetchDASHManifest(ctx context.Context, client *http.Client, url string, headers http.Header) (*DASHManifest, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	for k, values := range headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
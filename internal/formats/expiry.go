@@ -0,0 +1,50 @@
+package formats
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URLExpiry returns when a signed googlevideo URL stops working, from its
+// "expire" query parameter or the "/expire/<unix>/" path segment manifest
+// URLs use. It returns the zero time when the URL carries neither.
+func URLExpiry(rawURL string) time.Time {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return time.Time{}
+	}
+	raw := u.Query().Get("expire")
+	if raw == "" {
+		segments := strings.Split(u.Path, "/")
+		for i := 0; i+1 < len(segments); i++ {
+			if segments[i] == "expire" {
+				raw = segments[i+1]
+				break
+			}
+		}
+	}
+	sec, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || sec <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// Expiry is URLExpiry of f's URL or, for ciphered formats, of the URL inside
+// the signature cipher; deciphering does not change the expiry.
+func Expiry(f Format) time.Time {
+	if f.URL != "" {
+		return URLExpiry(f.URL)
+	}
+	cipher := f.SignatureCipher
+	if cipher == "" {
+		cipher = f.Cipher
+	}
+	params, err := url.ParseQuery(cipher)
+	if err != nil {
+		return time.Time{}
+	}
+	return URLExpiry(params.Get("url"))
+}
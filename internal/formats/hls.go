@@ -17,11 +17,16 @@ type HLSManifest struct {
 	Formats    []Format
 }
 
-func FetchHLSManifest(ctx context.Context, client *http.Client, url string) (*HLSManifest, error) {
+func FetchHLSManifest(ctx context.Context, client *http.Client, url string, headers http.Header) (*HLSManifest, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	for k, values := range headers {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -48,12 +53,17 @@ func FetchHLSManifest(ctx context.Context, client *http.Client, url string) (*HL
 }
 
 // ParseHLSManifest parses an HLS master playlist into normalized formats.
+// Audio renditions (EXT-X-MEDIA TYPE=AUDIO with a URI) become audio-only
+// formats, and the variants playing them (AUDIO=<group>) video-only ones,
+// so audio selectors on live streams pick the rendition.
 func ParseHLSManifest(raw, manifestURL string) ([]Format, error) {
 	if strings.TrimSpace(raw) == "" {
 		return nil, nil
 	}
 
 	formats := make([]Format, 0, 16)
+	renditions := make(map[string][]int) // audio GROUP-ID -> rendition indexes
+	variantGroups := make(map[int]string)
 	scanner := bufio.NewScanner(strings.NewReader(raw))
 	var pendingStreamAttrs map[string]string
 	for scanner.Scan() {
@@ -76,16 +86,26 @@ func ParseHLSManifest(raw, manifestURL string) ([]Format, error) {
 			}
 			u := resolveM3U8RefURL(manifestURL, uri)
 			f := Format{
-				Itag:     inferItagFromURL(u),
-				URL:      u,
-				MimeType: inferMimeFromM3U8Codecs(attrs["CODECS"]),
-				Bitrate:  parseInt(attrs["BANDWIDTH"]),
-				Protocol: "hls",
+				Itag:           inferItagFromURL(u),
+				URL:            u,
+				MimeType:       "audio/mp4",
+				Bitrate:        parseInt(attrs["BANDWIDTH"]),
+				Protocol:       "hls",
+				Container:      "mp4",
+				Language:       strings.TrimSpace(attrs["LANGUAGE"]),
+				AudioTrackName: strings.TrimSpace(attrs["NAME"]),
+				AudioIsDefault: strings.EqualFold(attrs["DEFAULT"], "YES"),
+			}
+			if codecs := strings.TrimSpace(attrs["CODECS"]); codecs != "" {
+				f.MimeType = inferMimeFromM3U8Codecs(codecs)
+				f.Codecs = extractCodecsFromMime(f.MimeType)
 			}
 			if channels := parseInt(attrs["CHANNELS"]); channels > 0 {
 				f.AudioChannels = channels
 			}
-			f.HasAudio, f.HasVideo = deriveMediaFlags(f, true)
+			f.HasAudio, f.HasVideo = true, false
+			group := attrs["GROUP-ID"]
+			renditions[group] = append(renditions[group], len(formats))
 			formats = append(formats, f)
 			continue
 		}
@@ -118,15 +138,65 @@ func ParseHLSManifest(raw, manifestURL string) ([]Format, error) {
 			f.Codecs = codecs
 		}
 		f.HasAudio, f.HasVideo = deriveMediaFlags(f, true)
+		if group := pendingStreamAttrs["AUDIO"]; group != "" {
+			variantGroups[len(formats)] = group
+		}
 		formats = append(formats, f)
 		pendingStreamAttrs = nil
 	}
 	if err := scanner.Err(); err != nil {
 		return nil, err
 	}
+	linkHLSAudioGroups(formats, renditions, variantGroups)
 	return formats, nil
 }
 
+// linkHLSAudioGroups splits the CODECS of each variant with a rendered
+// audio group: the variant keeps the video codecs and becomes video-only,
+// and the group's renditions without CODECS take the audio ones.
+func linkHLSAudioGroups(formats []Format, renditions map[string][]int, variantGroups map[int]string) {
+	for i, group := range variantGroups {
+		members := renditions[group]
+		if len(members) == 0 {
+			continue
+		}
+		var videoCodecs, audioCodecs []string
+		for _, codec := range formats[i].Codecs {
+			if isAudioCodec(codec) {
+				audioCodecs = append(audioCodecs, codec)
+			} else {
+				videoCodecs = append(videoCodecs, codec)
+			}
+		}
+		v := &formats[i]
+		v.Codecs = videoCodecs
+		v.MimeType = "video/mp4"
+		if len(videoCodecs) > 0 {
+			v.MimeType = `video/mp4; codecs="` + strings.Join(videoCodecs, ",") + `"`
+		}
+		v.HasAudio, v.HasVideo = false, true
+		if len(audioCodecs) == 0 {
+			continue
+		}
+		for _, j := range members {
+			if a := &formats[j]; len(a.Codecs) == 0 {
+				a.Codecs = audioCodecs
+				a.MimeType = `audio/mp4; codecs="` + strings.Join(audioCodecs, ",") + `"`
+			}
+		}
+	}
+}
+
+func isAudioCodec(codec string) bool {
+	lc := strings.ToLower(strings.TrimSpace(codec))
+	for _, prefix := range []string{"mp4a", "opus", "vorbis", "aac", "ac-3", "ec-3", "dtse", "flac"} {
+		if strings.HasPrefix(lc, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 // ParseM3U8Attrs parses M3U8 attribute lists (KEY=VALUE,...).
 func ParseM3U8Attrs(raw string) map[string]string {
 	out := map[string]string{}
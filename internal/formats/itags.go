@@ -0,0 +1,185 @@
+package formats
+
+// ItagInfo is static knowledge about a YouTube itag, independent of any
+// particular player response.
+type ItagInfo struct {
+	Container string // default container extension, e.g. "mp4", "webm", "m4a"
+	VCodec    string // empty for audio-only itags
+	ACodec    string // empty for video-only itags
+	Height    int    // nominal height; 0 when it varies per video
+	FPS       int    // 0 when it follows the source
+	// HLSOnly itags are only served inside HLS manifests.
+	HLSOnly bool
+	// Premium itags (enhanced bitrate) are only served to YouTube Premium sessions.
+	Premium bool
+	// Deprecated itags are no longer offered for new uploads.
+	Deprecated bool
+}
+
+// Hint values describe why a format may not download as-is.
+const (
+	HintDRM        = "drm"
+	HintSABROnly   = "sabr_only"
+	HintPremium    = "premium"
+	HintDeprecated = "deprecated"
+)
+
+var knownItags = map[int]ItagInfo{
+	// Legacy progressive formats.
+	5:  {Container: "flv", VCodec: "h263", ACodec: "mp3", Height: 240, Deprecated: true},
+	6:  {Container: "flv", VCodec: "h263", ACodec: "mp3", Height: 270, Deprecated: true},
+	13: {Container: "3gp", VCodec: "mp4v", ACodec: "aac", Deprecated: true},
+	17: {Container: "3gp", VCodec: "mp4v", ACodec: "aac", Height: 144, Deprecated: true},
+	18: {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 360},
+	22: {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 720},
+	34: {Container: "flv", VCodec: "avc1", ACodec: "aac", Height: 360, Deprecated: true},
+	35: {Container: "flv", VCodec: "avc1", ACodec: "aac", Height: 480, Deprecated: true},
+	36: {Container: "3gp", VCodec: "mp4v", ACodec: "aac", Height: 180, Deprecated: true},
+	37: {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 1080, Deprecated: true},
+	38: {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 3072, Deprecated: true},
+	43: {Container: "webm", VCodec: "vp8", ACodec: "vorbis", Height: 360, Deprecated: true},
+	44: {Container: "webm", VCodec: "vp8", ACodec: "vorbis", Height: 480, Deprecated: true},
+	45: {Container: "webm", VCodec: "vp8", ACodec: "vorbis", Height: 720, Deprecated: true},
+	46: {Container: "webm", VCodec: "vp8", ACodec: "vorbis", Height: 1080, Deprecated: true},
+	59: {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 480, Deprecated: true},
+	78: {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 480, Deprecated: true},
+
+	// 3D.
+	82:  {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 360, Deprecated: true},
+	83:  {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 480, Deprecated: true},
+	84:  {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 720, Deprecated: true},
+	85:  {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 1080, Deprecated: true},
+	100: {Container: "webm", VCodec: "vp8", ACodec: "vorbis", Height: 360, Deprecated: true},
+	101: {Container: "webm", VCodec: "vp8", ACodec: "vorbis", Height: 480, Deprecated: true},
+	102: {Container: "webm", VCodec: "vp8", ACodec: "vorbis", Height: 720, Deprecated: true},
+
+	// HLS muxed.
+	91:  {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 144, HLSOnly: true},
+	92:  {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 240, HLSOnly: true},
+	93:  {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 360, HLSOnly: true},
+	94:  {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 480, HLSOnly: true},
+	95:  {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 720, HLSOnly: true},
+	96:  {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 1080, HLSOnly: true},
+	132: {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 240, HLSOnly: true, Deprecated: true},
+	151: {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 72, HLSOnly: true, Deprecated: true},
+	300: {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 720, FPS: 60, HLSOnly: true},
+	301: {Container: "mp4", VCodec: "avc1", ACodec: "aac", Height: 1080, FPS: 60, HLSOnly: true},
+
+	// HLS video-only.
+	229: {Container: "mp4", VCodec: "avc1", Height: 240, HLSOnly: true},
+	230: {Container: "mp4", VCodec: "avc1", Height: 360, HLSOnly: true},
+	231: {Container: "mp4", VCodec: "avc1", Height: 480, HLSOnly: true},
+	232: {Container: "mp4", VCodec: "avc1", Height: 720, HLSOnly: true},
+	269: {Container: "mp4", VCodec: "avc1", Height: 144, HLSOnly: true},
+	270: {Container: "mp4", VCodec: "avc1", Height: 1080, HLSOnly: true},
+	616: {Container: "mp4", VCodec: "vp9", Height: 1080, HLSOnly: true, Premium: true},
+
+	// HLS audio-only renditions of live streams and premieres.
+	233: {Container: "mp4", ACodec: "aac", HLSOnly: true},
+	234: {Container: "mp4", ACodec: "aac", HLSOnly: true},
+
+	// DASH mp4 video.
+	133: {Container: "mp4", VCodec: "avc1", Height: 240},
+	134: {Container: "mp4", VCodec: "avc1", Height: 360},
+	135: {Container: "mp4", VCodec: "avc1", Height: 480},
+	136: {Container: "mp4", VCodec: "avc1", Height: 720},
+	137: {Container: "mp4", VCodec: "avc1", Height: 1080},
+	138: {Container: "mp4", VCodec: "avc1"},
+	160: {Container: "mp4", VCodec: "avc1", Height: 144},
+	212: {Container: "mp4", VCodec: "avc1", Height: 480},
+	264: {Container: "mp4", VCodec: "avc1", Height: 1440},
+	266: {Container: "mp4", VCodec: "avc1", Height: 2160},
+	298: {Container: "mp4", VCodec: "avc1", Height: 720, FPS: 60},
+	299: {Container: "mp4", VCodec: "avc1", Height: 1080, FPS: 60},
+
+	// DASH audio.
+	139: {Container: "m4a", ACodec: "aac"},
+	140: {Container: "m4a", ACodec: "aac"},
+	141: {Container: "m4a", ACodec: "aac"},
+	256: {Container: "m4a", ACodec: "aac"},
+	258: {Container: "m4a", ACodec: "aac"},
+	325: {Container: "m4a", ACodec: "dtse"},
+	328: {Container: "m4a", ACodec: "ec-3"},
+	171: {Container: "webm", ACodec: "vorbis"},
+	172: {Container: "webm", ACodec: "vorbis"},
+	249: {Container: "webm", ACodec: "opus"},
+	250: {Container: "webm", ACodec: "opus"},
+	251: {Container: "webm", ACodec: "opus"},
+
+	// DASH webm video.
+	167: {Container: "webm", VCodec: "vp8", Height: 360},
+	168: {Container: "webm", VCodec: "vp8", Height: 480},
+	169: {Container: "webm", VCodec: "vp8", Height: 720},
+	170: {Container: "webm", VCodec: "vp8", Height: 1080},
+	218: {Container: "webm", VCodec: "vp8", Height: 480},
+	219: {Container: "webm", VCodec: "vp8", Height: 480},
+	278: {Container: "webm", VCodec: "vp9", Height: 144},
+	242: {Container: "webm", VCodec: "vp9", Height: 240},
+	243: {Container: "webm", VCodec: "vp9", Height: 360},
+	244: {Container: "webm", VCodec: "vp9", Height: 480},
+	245: {Container: "webm", VCodec: "vp9", Height: 480},
+	246: {Container: "webm", VCodec: "vp9", Height: 480},
+	247: {Container: "webm", VCodec: "vp9", Height: 720},
+	248: {Container: "webm", VCodec: "vp9", Height: 1080},
+	271: {Container: "webm", VCodec: "vp9", Height: 1440},
+	272: {Container: "webm", VCodec: "vp9", Height: 2160},
+	302: {Container: "webm", VCodec: "vp9", Height: 720, FPS: 60},
+	303: {Container: "webm", VCodec: "vp9", Height: 1080, FPS: 60},
+	308: {Container: "webm", VCodec: "vp9", Height: 1440, FPS: 60},
+	313: {Container: "webm", VCodec: "vp9", Height: 2160},
+	315: {Container: "webm", VCodec: "vp9", Height: 2160, FPS: 60},
+
+	// VP9.2 HDR.
+	330: {Container: "webm", VCodec: "vp9.2", Height: 144, FPS: 60},
+	331: {Container: "webm", VCodec: "vp9.2", Height: 240, FPS: 60},
+	332: {Container: "webm", VCodec: "vp9.2", Height: 360, FPS: 60},
+	333: {Container: "webm", VCodec: "vp9.2", Height: 480, FPS: 60},
+	334: {Container: "webm", VCodec: "vp9.2", Height: 720, FPS: 60},
+	335: {Container: "webm", VCodec: "vp9.2", Height: 1080, FPS: 60},
+	336: {Container: "webm", VCodec: "vp9.2", Height: 1440, FPS: 60},
+	337: {Container: "webm", VCodec: "vp9.2", Height: 2160, FPS: 60},
+
+	// AV1.
+	394: {Container: "mp4", VCodec: "av01", Height: 144},
+	395: {Container: "mp4", VCodec: "av01", Height: 240},
+	396: {Container: "mp4", VCodec: "av01", Height: 360},
+	397: {Container: "mp4", VCodec: "av01", Height: 480},
+	398: {Container: "mp4", VCodec: "av01", Height: 720},
+	399: {Container: "mp4", VCodec: "av01", Height: 1080},
+	400: {Container: "mp4", VCodec: "av01", Height: 1440},
+	401: {Container: "mp4", VCodec: "av01", Height: 2160},
+	402: {Container: "mp4", VCodec: "av01", Height: 4320},
+	571: {Container: "mp4", VCodec: "av01", Height: 4320},
+}
+
+// LookupItag returns static knowledge about itag.
+func LookupItag(itag int) (ItagInfo, bool) {
+	info, ok := knownItags[itag]
+	return info, ok
+}
+
+// Hints combines runtime format properties with the itag table into stable
+// hint values, most severe first. DRM and SABR-only formats cannot be
+// downloaded; the rest are informational.
+func Hints(f Format) []string {
+	var hints []string
+	if f.IsDRM {
+		hints = append(hints, HintDRM)
+	}
+	// Formats listed without a URL or a cipher are only reachable through
+	// SABR (server-driven adaptive streaming), which is not supported.
+	if f.IsDamaged && !f.IsDRM {
+		hints = append(hints, HintSABROnly)
+	}
+	info, ok := LookupItag(f.Itag)
+	if !ok {
+		return hints
+	}
+	if info.Premium {
+		hints = append(hints, HintPremium)
+	}
+	if info.Deprecated {
+		hints = append(hints, HintDeprecated)
+	}
+	return hints
+}
@@ -0,0 +1,48 @@
+package formats
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/famomatic/ytv1/internal/innertube"
+)
+
+func TestLookupItag(t *testing.T) {
+	info, ok := LookupItag(251)
+	if !ok || info.Container != "webm" || info.ACodec != "opus" || info.VCodec != "" {
+		t.Fatalf("LookupItag(251) = %+v, %v", info, ok)
+	}
+	if _, ok := LookupItag(99999); ok {
+		t.Fatal("expected unknown itag lookup to fail")
+	}
+}
+
+func TestHints_CombinesRuntimeAndItagTable(t *testing.T) {
+	resp := &innertube.PlayerResponse{
+		StreamingData: innertube.StreamingData{
+			Formats: []innertube.Format{
+				{Itag: 17, URL: "https://example.com/v.3gp", MimeType: `video/3gpp; codecs="mp4v.20.3, mp4a.40.2"`},
+			},
+			AdaptiveFormats: []innertube.Format{
+				{Itag: 137, URL: "https://example.com/v.mp4", MimeType: `video/mp4; codecs="avc1.640028"`, DRMFamilies: []string{"WIDEVINE"}},
+				{Itag: 248, MimeType: `video/webm; codecs="vp9"`},
+				{Itag: 616, URL: "https://example.com/index.m3u8", MimeType: `video/mp4; codecs="vp09.00.40.08"`},
+				{Itag: 140, URL: "https://example.com/a.m4a", MimeType: `audio/mp4; codecs="mp4a.40.2"`},
+			},
+		},
+	}
+	got := map[int][]string{}
+	for _, f := range Parse(resp) {
+		got[f.Itag] = Hints(f)
+	}
+	want := map[int][]string{
+		17:  {HintDeprecated},
+		137: {HintDRM},
+		248: {HintSABROnly},
+		616: {HintPremium},
+		140: nil,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Hints() = %v, want %v", got, want)
+	}
+}
@@ -58,3 +58,33 @@ v/itag/22/prog.m3u8
 	}
 }
 
+
+func TestParseHLSManifest_AudioRenditionGroups(t *testing.T) {
+	raw := `#EXTM3U
+#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID="234",NAME="Default",LANGUAGE="en",DEFAULT=YES,AUTOSELECT=YES,URI="https://manifest.googlevideo.com/api/manifest/hls_playlist/itag/234/index.m3u8"
+#EXT-X-STREAM-INF:BANDWIDTH=1500000,RESOLUTION=1280x720,FRAME-RATE=30,CODECS="avc1.4D401F,mp4a.40.2",AUDIO="234"
+https://manifest.googlevideo.com/api/manifest/hls_playlist/itag/232/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360,CODECS="avc1.4D401E,mp4a.40.2"
+https://manifest.googlevideo.com/api/manifest/hls_playlist/itag/93/index.m3u8
+`
+	out, err := ParseHLSManifest(raw, "https://manifest.googlevideo.com/master.m3u8")
+	if err != nil {
+		t.Fatalf("ParseHLSManifest() error = %v", err)
+	}
+	if len(out) != 3 {
+		t.Fatalf("len(out)=%d, want 3: %+v", len(out), out)
+	}
+	audio, video, muxed := out[0], out[1], out[2]
+	if audio.Itag != 234 || !audio.HasAudio || audio.HasVideo || audio.MimeType != `audio/mp4; codecs="mp4a.40.2"` {
+		t.Fatalf("audio rendition = %+v", audio)
+	}
+	if audio.Language != "en" || audio.AudioTrackName != "Default" || !audio.AudioIsDefault {
+		t.Fatalf("audio rendition track = %q %q %v", audio.Language, audio.AudioTrackName, audio.AudioIsDefault)
+	}
+	if video.Itag != 232 || video.HasAudio || !video.HasVideo || len(video.Codecs) != 1 || video.Codecs[0] != "avc1.4D401F" {
+		t.Fatalf("variant with an audio group = %+v, want video-only", video)
+	}
+	if muxed.Itag != 93 || !muxed.HasAudio || !muxed.HasVideo {
+		t.Fatalf("variant without an audio group = %+v, want muxed", muxed)
+	}
+}
@@ -42,6 +42,9 @@ type Format struct {
 	SignatureCipher  string
 	Cipher           string
 	SourceClient     string
+	Language         string // audio track language, e.g. "en"
+	AudioTrackName   string
+	AudioIsDefault   bool
 }
 
 type Range struct {
@@ -58,9 +61,32 @@ func Parse(resp *innertube.PlayerResponse) []Format {
 	formats := make([]Format, 0, len(resp.StreamingData.Formats)+len(resp.StreamingData.AdaptiveFormats))
 	isLive := resp.PlayabilityStatus.IsLive()
 
+	// A response repeats a handful of mime types across all its formats;
+	// parse each once and share the (read-only) codec list.
+	type mimeDetails struct {
+		container string
+		codecs    []string
+	}
+	mimes := make(map[string]mimeDetails, 8)
+	// Init and index ranges of every format share one backing array.
+	ranges := make([]Range, 0, 2*cap(formats))
+	parseRange := func(r *innertube.Range) *Range {
+		if r == nil {
+			return nil
+		}
+		ranges = append(ranges, Range{Start: parseInt64(r.Start), End: parseInt64(r.End)})
+		return &ranges[len(ranges)-1]
+	}
+
 	extract := func(raw []innertube.Format, adaptive bool) {
 		for _, f := range raw {
-			container, codecs := parseMimeDetails(f.MimeType)
+			details, ok := mimes[f.MimeType]
+			if !ok {
+				details.container, details.codecs = parseMimeDetails(f.MimeType)
+				details.codecs = details.codecs[:len(details.codecs):len(details.codecs)]
+				mimes[f.MimeType] = details
+			}
+			container, codecs := details.container, details.codecs
 			parsed := Format{
 				Itag:             f.Itag,
 				URL:              f.URL,
@@ -79,7 +105,7 @@ func Parse(resp *innertube.PlayerResponse) []Format {
 				ProjectionType:   f.ProjectionType,
 				AverageBitrate:   f.AverageBitrate,
 				ThisIsLive:       isLive,
-				Protocol:         deriveProtocol(f),
+				Protocol:         normalizeProtocol(f.URL),
 				SignatureCipher:  f.SignatureCipher,
 				Cipher:           f.Cipher,
 				IsDRM:            len(f.DRMFamilies) > 0,
@@ -91,8 +117,20 @@ func Parse(resp *innertube.PlayerResponse) []Format {
 				IndexRange:       parseRange(f.IndexRange),
 			}
 
+			if f.AudioTrack != nil {
+				parsed.Language = audioTrackLanguage(f.AudioTrack.ID)
+				parsed.AudioTrackName = f.AudioTrack.DisplayName
+				parsed.AudioIsDefault = f.AudioTrack.AudioIsDefault
+			}
+
 			parsed.Ciphered = parsed.URL == "" && (parsed.SignatureCipher != "" || parsed.Cipher != "")
-			parsed.IsDamaged = strings.TrimSpace(parsed.URL) == "" && !hasCipherURL(f)
+			if parsed.Protocol == "unknown" || strings.TrimSpace(parsed.URL) == "" {
+				streamURL, ok := cipherURL(f)
+				if parsed.Protocol == "unknown" && ok {
+					parsed.Protocol = normalizeProtocol(streamURL)
+				}
+				parsed.IsDamaged = strings.TrimSpace(parsed.URL) == "" && !ok
+			}
 			parsed.HasAudio, parsed.HasVideo = deriveMediaFlags(parsed, adaptive)
 
 			formats = append(formats, parsed)
@@ -105,6 +143,15 @@ func Parse(resp *innertube.PlayerResponse) []Format {
 	return formats
 }
 
+// audioTrackLanguage strips the variant suffix from audioTrack ids ("en.4" -> "en").
+func audioTrackLanguage(id string) string {
+	id = strings.TrimSpace(id)
+	if i := strings.IndexByte(id, '.'); i >= 0 {
+		id = id[:i]
+	}
+	return id
+}
+
 func parseInt(raw string) int {
 	v, err := strconv.Atoi(raw)
 	if err != nil {
@@ -121,16 +168,6 @@ func parseInt64(raw string) int64 {
 	return v
 }
 
-func parseRange(r *innertube.Range) *Range {
-	if r == nil {
-		return nil
-	}
-	return &Range{
-		Start: parseInt64(r.Start),
-		End:   parseInt64(r.End),
-	}
-}
-
 func parseMimeDetails(raw string) (container string, codecs []string) {
 	mediaType, params, err := mime.ParseMediaType(raw)
 	if err != nil {
@@ -188,60 +225,35 @@ func deriveMediaFlags(f Format, adaptive bool) (hasAudio bool, hasVideo bool) {
 	return hasAudio, hasVideo
 }
 
-func deriveProtocol(raw innertube.Format) string {
-	if p := normalizeProtocol(raw.URL); p != "unknown" {
-		return p
-	}
-
-	cipher := raw.SignatureCipher
-	if cipher == "" {
-		cipher = raw.Cipher
-	}
-	if cipher == "" {
-		return "unknown"
-	}
-
-	params, err := url.ParseQuery(cipher)
-	if err != nil {
-		return "unknown"
-	}
-
-	return normalizeProtocol(params.Get("url"))
-}
-
-func hasCipherURL(raw innertube.Format) bool {
+// cipherURL returns the stream URL inside a format's signatureCipher (or
+// legacy cipher), parsing the cipher once for every caller.
+func cipherURL(raw innertube.Format) (string, bool) {
 	cipher := raw.SignatureCipher
 	if cipher == "" {
 		cipher = raw.Cipher
 	}
 	if strings.TrimSpace(cipher) == "" {
-		return false
+		return "", false
 	}
 	params, err := url.ParseQuery(cipher)
 	if err != nil {
-		return false
+		return "", false
 	}
 	rawURL := strings.TrimSpace(params.Get("url"))
 	if rawURL == "" {
-		return false
+		return "", false
 	}
 	if _, err := url.Parse(rawURL); err != nil {
-		return false
+		return "", false
 	}
-	return true
+	return rawURL, true
 }
 
+// normalizeProtocol maps a URL's scheme to a format protocol. It reads the
+// scheme in place rather than parsing the whole URL, which Parse would do
+// for every format.
 func normalizeProtocol(rawURL string) string {
-	if rawURL == "" {
-		return "unknown"
-	}
-
-	u, err := url.Parse(rawURL)
-	if err != nil {
-		return "unknown"
-	}
-
-	switch strings.ToLower(u.Scheme) {
+	switch strings.ToLower(urlScheme(rawURL)) {
 	case "http", "https":
 		return "https"
 	case "dash":
@@ -252,3 +264,28 @@ func normalizeProtocol(rawURL string) string {
 		return "unknown"
 	}
 }
+
+// urlScheme returns rawURL's scheme, or "" when it has none or rawURL is
+// not a URL url.Parse would accept (control characters, bad scheme).
+func urlScheme(rawURL string) string {
+	for i := 0; i < len(rawURL); i++ {
+		if c := rawURL[i]; c < 0x20 || c == 0x7f {
+			return ""
+		}
+	}
+	for i := 0; i < len(rawURL); i++ {
+		c := rawURL[i]
+		switch {
+		case 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' || c == '+' || c == '-' || c == '.':
+			if i == 0 {
+				return ""
+			}
+		case c == ':':
+			return rawURL[:i]
+		default:
+			return ""
+		}
+	}
+	return ""
+}
@@ -2,6 +2,7 @@ package formats
 
 import (
 	"testing"
+	"time"
 
 	"github.com/famomatic/ytv1/internal/innertube"
 )
@@ -184,3 +185,47 @@ func TestParse_DRMFamiliesSetsDRMFlag(t *testing.T) {
 		t.Fatal("expected DRM families to map to IsDRM")
 	}
 }
+
+func TestParse_AudioTrackSetsLanguage(t *testing.T) {
+	resp := &innertube.PlayerResponse{
+		StreamingData: innertube.StreamingData{
+			AdaptiveFormats: []innertube.Format{
+				{
+					Itag:       140,
+					URL:        "https://example.com/audio",
+					MimeType:   `audio/mp4; codecs="mp4a.40.2"`,
+					AudioTrack: &innertube.AudioTrack{DisplayName: "English (United States) original", ID: "en-US.4", AudioIsDefault: true},
+				},
+			},
+		},
+	}
+
+	out := Parse(resp)
+	if len(out) != 1 {
+		t.Fatalf("expected 1 format, got %d", len(out))
+	}
+	if out[0].Language != "en-US" {
+		t.Fatalf("Language=%q, want en-US", out[0].Language)
+	}
+	if out[0].AudioTrackName != "English (United States) original" || !out[0].AudioIsDefault {
+		t.Fatalf("unexpected audio track fields: %+v", out[0])
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	want := time.Unix(1700000000, 0)
+	for _, f := range []Format{
+		{URL: "https://rr1---sn.googlevideo.com/videoplayback?expire=1700000000&itag=18"},
+		{URL: "https://manifest.googlevideo.com/api/manifest/hls_variant/expire/1700000000/ei/abc/file/index.m3u8"},
+		{SignatureCipher: "s=abc&sp=sig&url=https%3A%2F%2Frr1---sn.googlevideo.com%2Fvideoplayback%3Fexpire%3D1700000000"},
+	} {
+		if got := Expiry(f); !got.Equal(want) {
+			t.Errorf("Expiry(%+v) = %v, want %v", f, got, want)
+		}
+	}
+	for _, raw := range []string{"https://example.com/a", "https://example.com/a?expire=soon", ""} {
+		if got := URLExpiry(raw); !got.IsZero() {
+			t.Errorf("URLExpiry(%q) = %v, want zero", raw, got)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package httpx
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// AcceptEncoding is the Accept-Encoding sent with metadata requests
+// (Innertube API, watch pages, player JS). Innertube JSON and watch HTML
+// compress roughly tenfold, which matters most on slow links.
+const AcceptEncoding = "br, gzip"
+
+// AcceptCompressed asks for a compressed response. Setting Accept-Encoding
+// turns off net/http's transparent gzip handling, so the response must be
+// read with ReadBody or DecodedBody.
+func AcceptCompressed(req *http.Request) {
+	req.Header.Set("Accept-Encoding", AcceptEncoding)
+}
+
+// DecodedBody returns resp.Body with its Content-Encoding (gzip, br or
+// identity) undone. Closing resp.Body stays the caller's job.
+func DecodedBody(resp *http.Response) (io.Reader, error) {
+	switch enc := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))); enc {
+	case "", "identity":
+		return resp.Body, nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	case "br":
+		return brotli.NewReader(resp.Body), nil
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", enc)
+	}
+}
+
+// ReadBody reads the whole decoded response body.
+func ReadBody(resp *http.Response) ([]byte, error) {
+	body, err := DecodedBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(body)
+}
@@ -0,0 +1,65 @@
+package httpx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestReadBody_DecodesContentEncoding(t *testing.T) {
+	const payload = `{"playabilityStatus":{"status":"OK"}}`
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write([]byte(payload))
+	_ = gw.Close()
+
+	var br bytes.Buffer
+	bw := brotli.NewWriter(&br)
+	_, _ = bw.Write([]byte(payload))
+	_ = bw.Close()
+
+	tests := []struct {
+		encoding string
+		body     []byte
+	}{
+		{"", []byte(payload)},
+		{"identity", []byte(payload)},
+		{"gzip", gz.Bytes()},
+		{"x-gzip", gz.Bytes()},
+		{"br", br.Bytes()},
+		{" BR ", br.Bytes()},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(tt.body))}
+		if tt.encoding != "" {
+			resp.Header.Set("Content-Encoding", tt.encoding)
+		}
+		got, err := ReadBody(resp)
+		if err != nil {
+			t.Fatalf("ReadBody(%q) error = %v", tt.encoding, err)
+		}
+		if string(got) != payload {
+			t.Fatalf("ReadBody(%q) = %q, want %q", tt.encoding, got, payload)
+		}
+	}
+}
+
+func TestReadBody_RejectsUnknownEncoding(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Content-Encoding": {"zstd"}}, Body: io.NopCloser(bytes.NewReader(nil))}
+	if _, err := ReadBody(resp); err == nil {
+		t.Fatal("ReadBody(zstd) error = nil, want unsupported encoding")
+	}
+}
+
+func TestAcceptCompressed(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://www.youtube.com/watch?v=x", nil)
+	AcceptCompressed(req)
+	if got := req.Header.Get("Accept-Encoding"); got != AcceptEncoding {
+		t.Fatalf("Accept-Encoding = %q, want %q", got, AcceptEncoding)
+	}
+}
@@ -0,0 +1,125 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backoff returns the wait before retry number attempt (0-based): a uniformly
+// random duration up to initial*2^attempt, capped at maxBackoff ("full
+// jitter"), so that workers failing together do not retry in lockstep.
+func Backoff(initial, maxBackoff time.Duration, attempt int) time.Duration {
+	ceiling := initial
+	for i := 0; i < attempt && ceiling < maxBackoff; i++ {
+		ceiling *= 2
+	}
+	ceiling = min(ceiling, maxBackoff)
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling + 1)
+}
+
+// ParseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date. It returns 0 when the header is absent or invalid.
+func ParseRetryAfter(raw string) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(raw); err == nil {
+		return max(0, time.Until(when))
+	}
+	return 0
+}
+
+// IsPermanent reports whether err, or an error it wraps, declares itself not
+// worth retrying with a Retryable method returning false, as a spent
+// bandwidth budget does.
+func IsPermanent(err error) bool {
+	var r interface{ Retryable() bool }
+	return errors.As(err, &r) && !r.Retryable()
+}
+
+// RetryBudget caps the retries of one operation across all of its stages
+// (metadata, player JS, media and fragment downloads), so that per-stage
+// retry counts cannot add up past what the caller is willing to spend.
+type RetryBudget struct {
+	maxRetries int           // 0 = unlimited
+	maxDelay   time.Duration // 0 = unlimited
+
+	mu      sync.Mutex
+	retries int
+	delay   time.Duration
+}
+
+// NewRetryBudget returns a budget of maxRetries retries and maxDelay of
+// cumulative backoff. A zero limit is unlimited.
+func NewRetryBudget(maxRetries int, maxDelay time.Duration) *RetryBudget {
+	return &RetryBudget{maxRetries: max(0, maxRetries), maxDelay: max(0, maxDelay)}
+}
+
+// Spent returns the retries taken and backoff granted so far.
+func (b *RetryBudget) Spent() (int, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retries, b.delay
+}
+
+func (b *RetryBudget) take(wait time.Duration) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxRetries > 0 && b.retries >= b.maxRetries {
+		return 0, false
+	}
+	if b.maxDelay > 0 && b.delay+wait > b.maxDelay {
+		wait = b.maxDelay - b.delay
+	}
+	b.retries++
+	b.delay += wait
+	return wait, true
+}
+
+type retryBudgetKey struct{}
+
+// WithRetryBudget returns ctx carrying b.
+func WithRetryBudget(ctx context.Context, b *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, b)
+}
+
+// RetryBudgetFrom returns the budget carried by ctx, or nil.
+func RetryBudgetFrom(ctx context.Context) *RetryBudget {
+	b, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return b
+}
+
+// AllowRetry reports whether a retry after waiting wait may proceed, and
+// returns the wait to use. The wait is trimmed to half of the time left
+// before ctx's deadline, leaving the rest for the retried request, and to
+// what remains of ctx's RetryBudget. Retries are refused once the deadline
+// has passed or the budget has no retries left.
+func AllowRetry(ctx context.Context, wait time.Duration) (time.Duration, bool) {
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return 0, false
+		}
+		wait = min(wait, remaining/2)
+	}
+	if b := RetryBudgetFrom(ctx); b != nil {
+		return b.take(wait)
+	}
+	return wait, true
+}
@@ -0,0 +1,89 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestBackoff_FullJitterWithinCeiling(t *testing.T) {
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 200; i++ {
+		d := Backoff(100*time.Millisecond, time.Second, 2)
+		if d < 0 || d > 400*time.Millisecond {
+			t.Fatalf("Backoff(attempt 2) = %v, want within [0, 400ms]", d)
+		}
+		seen[d] = true
+		if d := Backoff(100*time.Millisecond, time.Second, 10); d > time.Second {
+			t.Fatalf("Backoff(attempt 10) = %v, want capped at 1s", d)
+		}
+	}
+	if len(seen) < 2 {
+		t.Fatal("Backoff returned the same wait every time, want jitter")
+	}
+	if d := Backoff(0, time.Second, 3); d != 0 {
+		t.Fatalf("Backoff(0 initial) = %v, want 0", d)
+	}
+}
+
+func TestParseRetryAfter_SecondsAndHTTPDate(t *testing.T) {
+	if d := ParseRetryAfter("1"); d != time.Second {
+		t.Fatalf("seconds parse mismatch: got=%v want=%v", d, time.Second)
+	}
+	when := time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat)
+	if d := ParseRetryAfter(when); d <= 0 {
+		t.Fatalf("http-date parse mismatch: got=%v", d)
+	}
+}
+
+func TestAllowRetry_SharedBudget(t *testing.T) {
+	ctx := WithRetryBudget(context.Background(), NewRetryBudget(2, 3*time.Second))
+	if wait, ok := AllowRetry(ctx, 2*time.Second); !ok || wait != 2*time.Second {
+		t.Fatalf("first retry = %v, %v", wait, ok)
+	}
+	// Only 1s of backoff is left.
+	if wait, ok := AllowRetry(ctx, 2*time.Second); !ok || wait != time.Second {
+		t.Fatalf("second retry = %v, %v, want the wait trimmed to 1s", wait, ok)
+	}
+	if _, ok := AllowRetry(ctx, 0); ok {
+		t.Fatal("third retry allowed past MaxRetries")
+	}
+	if retries, delay := RetryBudgetFrom(ctx).Spent(); retries != 2 || delay != 3*time.Second {
+		t.Fatalf("Spent() = %d, %v", retries, delay)
+	}
+}
+
+func TestAllowRetry_TrimsToDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	wait, ok := AllowRetry(ctx, time.Minute)
+	if !ok || wait > 500*time.Millisecond {
+		t.Fatalf("AllowRetry() = %v, %v, want at most half the remaining time", wait, ok)
+	}
+
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	if _, ok := AllowRetry(expired, 0); ok {
+		t.Fatal("retry allowed after the deadline")
+	}
+	if wait, ok := AllowRetry(context.Background(), time.Minute); !ok || wait != time.Minute {
+		t.Fatalf("AllowRetry(no budget, no deadline) = %v, %v", wait, ok)
+	}
+}
+
+type permanentError struct{}
+
+func (permanentError) Error() string   { return "permanent" }
+func (permanentError) Retryable() bool { return false }
+
+func TestIsPermanent(t *testing.T) {
+	if !IsPermanent(fmt.Errorf("get: %w", permanentError{})) {
+		t.Fatal("wrapped permanent error not detected")
+	}
+	if IsPermanent(errors.New("connection reset")) || IsPermanent(nil) {
+		t.Fatal("plain errors must stay retryable")
+	}
+}
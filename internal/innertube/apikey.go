@@ -4,15 +4,24 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+
+	"github.com/famomatic/ytv1/internal/httpx"
+	"github.com/famomatic/ytv1/internal/types"
 )
 
 var innertubeAPIKeyPattern = regexp.MustCompile(`(?i)["']INNERTUBE_API_KEY["']\s*:\s*["']([^"']+)["']`)
+
+// Embed and TV pages carry the key and version in their player config too.
+var playerConfigAPIKeyPattern = regexp.MustCompile(`["']innertubeApiKey["']\s*:\s*["']([^"']+)["']`)
+var clientVersionPattern = regexp.MustCompile(`(?i)["']INNERTUBE_CLIENT_VERSION["']\s*:\s*["']([^"']+)["']`)
+var playerConfigClientVersionPattern = regexp.MustCompile(`["']innertubeContextClientVersion["']\s*:\s*["']([^"']+)["']`)
+var clientNamePattern = regexp.MustCompile(`(?i)["']INNERTUBE_CLIENT_NAME["']\s*:\s*["']([^"']+)["']`)
+var contextClientNamePattern = regexp.MustCompile(`(?i)["']INNERTUBE_CONTEXT_CLIENT_NAME["']\s*:\s*["']?(\d+)["']?`)
 var visitorDataPattern = regexp.MustCompile(`(?i)["']VISITOR_DATA["']\s*:\s*["']([^"']+)["']`)
 var delegatedSessionIDPattern = regexp.MustCompile(`(?i)["']DELEGATED_SESSION_ID["']\s*:\s*["']([^"']+)["']`)
 var userSessionIDPattern = regexp.MustCompile(`(?i)["']USER_SESSION_ID["']\s*:\s*["']([^"']+)["']`)
@@ -31,6 +40,16 @@ type resolvedWatchData struct {
 	UserSessionID      string
 	SessionIndex       *int
 	SignatureTimestamp int
+	// ClientName and ContextClientNameID identify the client the page was
+	// built for (WEB for /watch, WEB_EMBEDDED_PLAYER for /embed, TVHTML5 for
+	// /tv), and ClientVersion is that client's current version.
+	ClientName          string
+	ContextClientNameID int
+	ClientVersion       string
+	// DeferredPlayerURL is the player whose signature timestamp a
+	// metadata-only extraction skipped fetching; see
+	// ResolveSignatureTimestamp.
+	DeferredPlayerURL string
 }
 
 type APIKeyResolver struct {
@@ -99,6 +118,26 @@ func (r *APIKeyResolver) ResolveVisitorData(ctx context.Context, profile ClientP
 	return strings.TrimSpace(resolved.VisitorData)
 }
 
+// RefreshVisitorData fetches profile's page again, replacing its cached
+// data, and returns the new visitor data or "" if the page has none. It is
+// for responses rejecting the visitor data sent, which the cache would
+// only repeat.
+func (r *APIKeyResolver) RefreshVisitorData(ctx context.Context, profile ClientProfile, videoID string) string {
+	if r == nil || r.httpClient == nil {
+		return ""
+	}
+	cacheKey := profileCacheKey(profile)
+	if cacheKey == "" {
+		return ""
+	}
+	resolved, err := r.fetchFromWatch(ctx, profile, videoID)
+	if err != nil || strings.TrimSpace(resolved.VisitorData) == "" {
+		return ""
+	}
+	r.set(cacheKey, resolved)
+	return strings.TrimSpace(resolved.VisitorData)
+}
+
 func (r *APIKeyResolver) ResolveCookieAuthContext(ctx context.Context, profile ClientProfile, videoID string) CookieAuthContext {
 	if r == nil || r.httpClient == nil {
 		return CookieAuthContext{}
@@ -127,6 +166,13 @@ func (r *APIKeyResolver) ResolveSignatureTimestamp(ctx context.Context, profile
 		return 0
 	}
 	if data, ok := r.get(cacheKey); ok {
+		if data.DeferredPlayerURL != "" && !types.MetadataOnlyFromContext(ctx) {
+			if sts, err := r.extractSignatureTimestampFromPlayerJS(ctx, profile, data.DeferredPlayerURL); err == nil {
+				data.SignatureTimestamp = sts
+			}
+			data.DeferredPlayerURL = ""
+			r.set(cacheKey, data)
+		}
 		return data.SignatureTimestamp
 	}
 	resolved, err := r.fetchFromWatch(ctx, profile, videoID)
@@ -137,6 +183,34 @@ func (r *APIKeyResolver) ResolveSignatureTimestamp(ctx context.Context, profile
 	return resolved.SignatureTimestamp
 }
 
+// ResolveClientVersion returns the client version configured on the page
+// fetched for profile (the watch, embed or TV page, see
+// watchPageURLForProfile), or "" if that page belongs to another client or
+// names no version. It replaces the hardcoded profile.Version, which goes
+// stale as YouTube ships new builds.
+func (r *APIKeyResolver) ResolveClientVersion(ctx context.Context, profile ClientProfile, videoID string) string {
+	if r == nil || r.httpClient == nil {
+		return ""
+	}
+	cacheKey := profileCacheKey(profile)
+	if cacheKey == "" {
+		return ""
+	}
+	data, ok := r.get(cacheKey)
+	if !ok {
+		resolved, err := r.fetchFromWatch(ctx, profile, videoID)
+		if err != nil && resolved.APIKey == "" && resolved.VisitorData == "" {
+			return ""
+		}
+		r.set(cacheKey, resolved)
+		data = resolved
+	}
+	if !data.matchesClient(profile) {
+		return ""
+	}
+	return strings.TrimSpace(data.ClientVersion)
+}
+
 func (r *APIKeyResolver) get(host string) (resolvedWatchData, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -160,6 +234,7 @@ func (r *APIKeyResolver) fetchFromWatch(ctx context.Context, profile ClientProfi
 		req.Header.Set("User-Agent", profile.UserAgent)
 	}
 	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	httpx.AcceptCompressed(req)
 
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
@@ -171,15 +246,17 @@ func (r *APIKeyResolver) fetchFromWatch(ctx context.Context, profile ClientProfi
 		return resolvedWatchData{}, fmt.Errorf("watch request failed: status=%d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := httpx.ReadBody(resp)
 	if err != nil {
 		return resolvedWatchData{}, err
 	}
 
 	resolved := resolvedWatchData{}
-	match := innertubeAPIKeyPattern.FindSubmatch(body)
-	if len(match) >= 2 {
-		resolved.APIKey = strings.TrimSpace(string(match[1]))
+	resolved.APIKey = firstSubmatch(body, innertubeAPIKeyPattern, playerConfigAPIKeyPattern)
+	resolved.ClientName = firstSubmatch(body, clientNamePattern)
+	resolved.ClientVersion = firstSubmatch(body, clientVersionPattern, playerConfigClientVersionPattern)
+	if id, err := strconv.Atoi(firstSubmatch(body, contextClientNamePattern)); err == nil {
+		resolved.ContextClientNameID = id
 	}
 	visitorMatch := visitorDataPattern.FindSubmatch(body)
 	if len(visitorMatch) >= 2 {
@@ -216,18 +293,35 @@ func (r *APIKeyResolver) fetchFromWatch(ctx context.Context, profile ClientProfi
 		}
 	}
 	if resolved.SignatureTimestamp == 0 {
-		if playerURL := extractPlayerURLFromWatchBody(body); playerURL != "" {
+		if playerURL := extractPlayerURLFromWatchBody(body); playerURL != "" && types.MetadataOnlyFromContext(ctx) {
+			// Only ciphered stream URLs need the timestamp; fetch the player
+			// when one is requested.
+			resolved.DeferredPlayerURL = playerURL
+		} else if playerURL != "" {
 			if sts, err := r.extractSignatureTimestampFromPlayerJS(ctx, profile, playerURL); err == nil {
 				resolved.SignatureTimestamp = sts
 			}
 		}
 	}
 	if resolved.APIKey == "" {
-		return resolved, fmt.Errorf("INNERTUBE_API_KEY not found in watch page")
+		return resolved, fmt.Errorf("INNERTUBE_API_KEY not found in %s", watchURL)
 	}
 	return resolved, nil
 }
 
+// firstSubmatch returns the trimmed first group of the first pattern that
+// matches body.
+func firstSubmatch(body []byte, patterns ...*regexp.Regexp) string {
+	for _, re := range patterns {
+		if match := re.FindSubmatch(body); len(match) >= 2 {
+			if v := strings.TrimSpace(string(match[1])); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
 func extractPlayerURLFromWatchBody(body []byte) string {
 	for _, re := range []*regexp.Regexp{playerJSURLCfgPattern, webPlayerContextJSURLPattern, playerURLPattern} {
 		match := re.FindSubmatch(body)
@@ -263,6 +357,7 @@ func (r *APIKeyResolver) extractSignatureTimestampFromPlayerJS(ctx context.Conte
 	if ua := strings.TrimSpace(profile.UserAgent); ua != "" {
 		req.Header.Set("User-Agent", ua)
 	}
+	httpx.AcceptCompressed(req)
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
 		return 0, err
@@ -271,7 +366,7 @@ func (r *APIKeyResolver) extractSignatureTimestampFromPlayerJS(ctx context.Conte
 	if resp.StatusCode != http.StatusOK {
 		return 0, fmt.Errorf("player js request failed: status=%d", resp.StatusCode)
 	}
-	body, err := io.ReadAll(resp.Body)
+	body, err := httpx.ReadBody(resp)
 	if err != nil {
 		return 0, err
 	}
@@ -302,6 +397,15 @@ func parseDataSyncID(dataSyncID string) (string, string) {
 	return "", strings.TrimSpace(parts[0])
 }
 
+// matchesClient reports whether the page data was built for profile's client.
+// Pages that do not say match no client.
+func (d resolvedWatchData) matchesClient(profile ClientProfile) bool {
+	if d.ContextClientNameID > 0 && profile.ContextNameID > 0 {
+		return d.ContextClientNameID == profile.ContextNameID
+	}
+	return d.ClientName != "" && strings.EqualFold(d.ClientName, profile.Name)
+}
+
 func (d resolvedWatchData) toCookieAuthContext() CookieAuthContext {
 	return CookieAuthContext{
 		DelegatedSessionID: strings.TrimSpace(d.DelegatedSessionID),
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"sync/atomic"
 	"testing"
+
+	"github.com/famomatic/ytv1/internal/types"
 )
 
 func TestAPIKeyResolver_ResolvesFromWatchPage(t *testing.T) {
@@ -135,3 +137,94 @@ func TestAPIKeyResolver_ResolveSignatureTimestampFromPlayerJSFallback(t *testing
 		t.Fatalf("ResolveSignatureTimestamp()=%d, want 20494", sts)
 	}
 }
+
+// hostRewriteTransport sends every request to srv, so pages on fixed hosts
+// (www.youtube.com/embed, /tv) can be served by a test server.
+type hostRewriteTransport struct {
+	srv *httptest.Server
+}
+
+func (t hostRewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Host = strings.TrimPrefix(t.srv.URL, "https://")
+	return t.srv.Client().Transport.RoundTrip(r)
+}
+
+func TestAPIKeyResolver_ResolvesEmbedAndTVConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/embed/"):
+			_, _ = w.Write([]byte(`<script>ytcfg.set({"INNERTUBE_CONTEXT_CLIENT_NAME":56,"WEB_PLAYER_CONTEXT_CONFIGS":{"WEB_PLAYER_CONTEXT_CONFIG_ID_EMBEDDED_PLAYER":{"innertubeApiKey":"embed_key","innertubeContextClientVersion":"1.20991231.01.00"}}});</script>`))
+		case r.URL.Path == "/tv":
+			_, _ = w.Write([]byte(`<script>ytcfg.set({"INNERTUBE_API_KEY":"tv_key","INNERTUBE_CLIENT_NAME":"TVHTML5","INNERTUBE_CLIENT_VERSION":"7.20991231.10.00","STS":20600});</script>`))
+		case r.URL.Path == "/watch":
+			_, _ = w.Write([]byte(`<script>ytcfg.set({"INNERTUBE_API_KEY":"web_key","INNERTUBE_CONTEXT_CLIENT_NAME":1,"INNERTUBE_CLIENT_VERSION":"2.20991231.00.00"});</script>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	resolver := NewAPIKeyResolver(&http.Client{Transport: hostRewriteTransport{srv: srv}})
+	ctx := context.Background()
+
+	if key, err := resolver.Resolve(ctx, WebEmbeddedClient, "jNQXAC9IVRw"); err != nil || key != "embed_key" {
+		t.Fatalf("embedded Resolve() = %q, %v", key, err)
+	}
+	if v := resolver.ResolveClientVersion(ctx, WebEmbeddedClient, "jNQXAC9IVRw"); v != "1.20991231.01.00" {
+		t.Fatalf("embedded ResolveClientVersion() = %q", v)
+	}
+	if key, err := resolver.Resolve(ctx, TVClient, "jNQXAC9IVRw"); err != nil || key != "tv_key" {
+		t.Fatalf("tv Resolve() = %q, %v", key, err)
+	}
+	if v := resolver.ResolveClientVersion(ctx, TVClient, "jNQXAC9IVRw"); v != "7.20991231.10.00" {
+		t.Fatalf("tv ResolveClientVersion() = %q", v)
+	}
+	if sts := resolver.ResolveSignatureTimestamp(ctx, TVClient, "jNQXAC9IVRw"); sts != 20600 {
+		t.Fatalf("tv ResolveSignatureTimestamp() = %d", sts)
+	}
+
+	// The watch page carries WEB's version; other clients fetching it keep theirs.
+	if v := resolver.ResolveClientVersion(ctx, WebClient, "jNQXAC9IVRw"); v != "2.20991231.00.00" {
+		t.Fatalf("web ResolveClientVersion() = %q", v)
+	}
+	if v := resolver.ResolveClientVersion(ctx, AndroidClient, "jNQXAC9IVRw"); v != "" {
+		t.Fatalf("android ResolveClientVersion() = %q, want none from the WEB page", v)
+	}
+}
+
+func TestAPIKeyResolver_MetadataOnlyDefersPlayerJS(t *testing.T) {
+	var playerFetches int
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/watch":
+			_, _ = w.Write([]byte(`<script>ytcfg.set({"INNERTUBE_API_KEY":"dynamic_key_123","PLAYER_JS_URL":"\/s\/player\/abcd1234\/player_ias.vflset\/en_US\/base.js"});</script>`))
+		case "/s/player/abcd1234/player_ias.vflset/en_US/base.js":
+			playerFetches++
+			_, _ = w.Write([]byte(`var cfg = {signatureTimestamp: 20494};`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	resolver := NewAPIKeyResolver(srv.Client())
+	profile := WebClient
+	profile.Host = strings.TrimPrefix(srv.URL, "https://")
+
+	metaCtx := types.WithMetadataOnly(context.Background())
+	if key, err := resolver.Resolve(metaCtx, profile, "jNQXAC9IVRw"); err != nil || key != "dynamic_key_123" {
+		t.Fatalf("Resolve() = %q, %v", key, err)
+	}
+	if sts := resolver.ResolveSignatureTimestamp(metaCtx, profile, "jNQXAC9IVRw"); sts != 0 || playerFetches != 0 {
+		t.Fatalf("metadata-only ResolveSignatureTimestamp() = %d after %d player fetches, want 0 and none", sts, playerFetches)
+	}
+	for range 2 {
+		if sts := resolver.ResolveSignatureTimestamp(context.Background(), profile, "jNQXAC9IVRw"); sts != 20494 {
+			t.Fatalf("ResolveSignatureTimestamp() = %d, want 20494", sts)
+		}
+	}
+	if playerFetches != 1 {
+		t.Fatalf("player fetches = %d, want the deferred one only", playerFetches)
+	}
+}
@@ -29,7 +29,10 @@ func ResolveVisitorData(httpClient *http.Client, host string, configured string)
 	return ""
 }
 
-// BuildCookieAuthHeaders builds yt-dlp style auth headers from cookies.
+// BuildCookieAuthHeaders builds yt-dlp style auth headers from cookies for a
+// request to host. The SAPISIDHASH family is hashed against the origin the
+// request is made for (see AuthOrigin), so music.youtube.com, m.youtube.com
+// and API hosts each get values YouTube accepts.
 func BuildCookieAuthHeaders(httpClient *http.Client, host string, now time.Time, ctx CookieAuthContext) http.Header {
 	out := make(http.Header)
 	if strings.TrimSpace(ctx.DelegatedSessionID) != "" {
@@ -46,7 +49,8 @@ func BuildCookieAuthHeaders(httpClient *http.Client, host string, now time.Time,
 	if httpClient == nil || httpClient.Jar == nil {
 		return out
 	}
-	cookies := cookiesForHost(httpClient, host)
+	origin, cookieHost := AuthOrigin(host)
+	cookies := cookiesForHost(httpClient, cookieHost)
 	if len(cookies) == 0 {
 		return out
 	}
@@ -60,7 +64,6 @@ func BuildCookieAuthHeaders(httpClient *http.Client, host string, now time.Time,
 		cookieByName[name] = c.Value
 	}
 
-	origin := "https://" + host
 	authValues := make([]string, 0, 3)
 	appendAuth := func(scheme string, sid string) {
 		sid = strings.TrimSpace(sid)
@@ -69,7 +72,7 @@ func BuildCookieAuthHeaders(httpClient *http.Client, host string, now time.Time,
 		}
 		authValues = append(authValues, scheme+" "+sidHash(now.Unix(), sid, origin, strings.TrimSpace(ctx.UserSessionID)))
 	}
-	appendAuth("SAPISIDHASH", firstNonEmpty(cookieByName["SAPISID"], cookieByName["APISID"]))
+	appendAuth("SAPISIDHASH", firstNonEmpty(cookieByName["SAPISID"], cookieByName["APISID"], cookieByName["__Secure-3PAPISID"]))
 	appendAuth("SAPISID1PHASH", cookieByName["__Secure-1PAPISID"])
 	appendAuth("SAPISID3PHASH", cookieByName["__Secure-3PAPISID"])
 	if len(authValues) > 0 {
@@ -83,6 +86,23 @@ func BuildCookieAuthHeaders(httpClient *http.Client, host string, now time.Time,
 	return out
 }
 
+// AuthOrigin returns the origin SAPISIDHASH values are computed for when
+// requesting host, and the host whose cookies hold the SAPISID family.
+// youtube.com hosts (www, m, music, ...) are their own origin; Google API
+// hosts such as youtubei.googleapis.com are called on behalf of
+// www.youtube.com and use its cookies.
+func AuthOrigin(host string) (origin string, cookieHost string) {
+	host = strings.TrimSpace(host)
+	name := strings.ToLower(host)
+	if h, _, found := strings.Cut(name, ":"); found {
+		name = h
+	}
+	if name == "googleapis.com" || strings.HasSuffix(name, ".googleapis.com") {
+		return "https://www.youtube.com", "www.youtube.com"
+	}
+	return "https://" + host, host
+}
+
 func sidHash(ts int64, sid string, origin string, userSessionID string) string {
 	hashParts := make([]string, 0, 4)
 	if userSessionID != "" {
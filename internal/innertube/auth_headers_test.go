@@ -84,3 +84,56 @@ func TestBuildCookieAuthHeadersIncludesSessionHeaders(t *testing.T) {
 		t.Fatalf("expected authorization suffix marker for user session id, got %q", headers.Get("Authorization"))
 	}
 }
+
+func TestBuildCookieAuthHeadersPerOrigin(t *testing.T) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New() error = %v", err)
+	}
+	u, _ := url.Parse("https://www.youtube.com")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "SAPISID", Value: "sid", Path: "/", Domain: ".youtube.com"},
+		{Name: "__Secure-1PAPISID", Value: "sid1p", Path: "/", Domain: ".youtube.com", Secure: true},
+		{Name: "__Secure-3PAPISID", Value: "sid3p", Path: "/", Domain: ".youtube.com", Secure: true},
+	})
+	client := &http.Client{Jar: jar}
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		host   string
+		origin string
+	}{
+		{"www.youtube.com", "https://www.youtube.com"},
+		{"music.youtube.com", "https://music.youtube.com"},
+		{"m.youtube.com", "https://m.youtube.com"},
+		{"youtubei.googleapis.com", "https://www.youtube.com"},
+	}
+	for _, tt := range tests {
+		headers := BuildCookieAuthHeaders(client, tt.host, now, CookieAuthContext{})
+		want := "SAPISIDHASH " + sidHash(now.Unix(), "sid", tt.origin, "") +
+			" SAPISID1PHASH " + sidHash(now.Unix(), "sid1p", tt.origin, "") +
+			" SAPISID3PHASH " + sidHash(now.Unix(), "sid3p", tt.origin, "")
+		if got := headers.Get("Authorization"); got != want {
+			t.Fatalf("%s: authorization=%q, want %q", tt.host, got, want)
+		}
+		if got := headers.Get("X-Origin"); got != tt.origin {
+			t.Fatalf("%s: x-origin=%q, want %q", tt.host, got, tt.origin)
+		}
+	}
+}
+
+func TestBuildCookieAuthHeadersFallsBackTo3PAPISID(t *testing.T) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New() error = %v", err)
+	}
+	u, _ := url.Parse("https://www.youtube.com")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "__Secure-3PAPISID", Value: "sid3p", Path: "/", Domain: ".youtube.com", Secure: true},
+	})
+	now := time.Unix(1700000000, 0)
+	headers := BuildCookieAuthHeaders(&http.Client{Jar: jar}, "www.youtube.com", now, CookieAuthContext{})
+	if !strings.HasPrefix(headers.Get("Authorization"), "SAPISIDHASH "+sidHash(now.Unix(), "sid3p", "https://www.youtube.com", "")+" ") {
+		t.Fatalf("authorization=%q, want SAPISIDHASH from __Secure-3PAPISID", headers.Get("Authorization"))
+	}
+}
@@ -17,6 +17,18 @@ type ExtractionEvent struct {
 // ExtractionEventHandler handles extraction events from orchestrator/client flows.
 type ExtractionEventHandler func(ExtractionEvent)
 
+// ResponseAnomaly describes a player response that failed to parse or lacked
+// data a playable response always carries.
+type ResponseAnomaly struct {
+	Client  string
+	VideoID string
+	Reason  string
+	Body    []byte
+}
+
+// ResponseAnomalyHandler receives response anomalies for fixture capture.
+type ResponseAnomalyHandler func(ResponseAnomaly)
+
 // PoTokenProvider defines an interface for injecting PO Tokens.
 type PoTokenProvider interface {
 	GetToken(ctx context.Context, clientID string) (string, error)
@@ -31,18 +43,48 @@ type Config struct {
 	VisitorData                   string
 	PlayerJSBaseURL               string
 	PlayerJSUserAgent             string
+	WatchPageUserAgent            string
+	MetadataUserAgent             string
+	MetadataUserAgents            map[string]string
+	APIHost                       string
+	APIHosts                      map[string]string
 	PlayerJSHeaders               http.Header
 	PlayerJSPreferredLocale       string
+	MetadataLanguage              string
 	ClientOverrides               []string
 	ClientSkip                    []string
 	RequestHeaders                http.Header
 	RequestTimeout                time.Duration
 	DisableFallbackClients        bool
+	DisableWatchPageFallback      bool
 	MetadataTransport             MetadataTransportConfig
 	EnableDynamicAPIKeyResolution bool
 	UseAdPlaybackContext          bool
 	ClientHedgeDelay              time.Duration
-	OnExtractionEvent             ExtractionEventHandler
+	// ClientTimeout bounds one client's player attempt, retries included.
+	ClientTimeout time.Duration
+	// ClientCircuitBreaker takes clients that keep timing out out of rotation.
+	ClientCircuitBreaker CircuitBreakerConfig
+	// ClassifyProbe tries the first primary client alone before the rest
+	// and uses its playability status to narrow the clients tried next:
+	// age-gated videos go straight to clients that can play them, deleted
+	// or region-blocked ones fail without further requests.
+	ClassifyProbe     bool
+	OnExtractionEvent ExtractionEventHandler
+	// QuotaGuard paces player requests per client; nil disables pacing.
+	QuotaGuard *QuotaGuard
+	// OnResponseAnomaly receives raw bodies of anomalous player responses.
+	OnResponseAnomaly ResponseAnomalyHandler
+	// OnSchemaDrift receives unexpected statuses, fields and renderer types
+	// seen while decoding player responses.
+	OnSchemaDrift SchemaDriftHandler
+}
+
+// CircuitBreakerConfig opens a client's circuit after Threshold consecutive
+// timeouts for Cooldown, after which one request probes it again.
+type CircuitBreakerConfig struct {
+	Threshold int
+	Cooldown  time.Duration
 }
 
 type MetadataTransportConfig struct {
@@ -1,6 +1,9 @@
 package innertube
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+)
 
 // VideoStreamingProtocol represents the protocol used for video streaming.
 type VideoStreamingProtocol string
@@ -43,15 +46,57 @@ type ClientProfile struct {
 	SupportsAdPlaybackContext bool
 	RequiresAuth              bool
 	Host                      string
-	Headers                   http.Header
-	Screen                    string // e.g. "EMBED"
-	PlayerParams              string
+	// APIHost, when set, receives the client's /youtubei/v1 API requests
+	// instead of Host: a host such as "youtubei.googleapis.com" or a base
+	// URL such as "http://proxy.internal:8080". Pages, cookies and the
+	// Origin header stay on Host.
+	APIHost      string
+	Headers      http.Header
+	Screen       string // e.g. "EMBED"
+	PlayerParams string
 
 	// PoTokenPolicy map keyed by protocol (https, dash, hls).
 	PoTokenPolicy map[VideoStreamingProtocol]PoTokenPolicy
 }
 
+// WithUserAgent returns p with its User-Agent replaced by byClient[p.ID] or,
+// failing that, by fallback. Empty overrides leave it unchanged.
+func (p ClientProfile) WithUserAgent(fallback string, byClient map[string]string) ClientProfile {
+	if ua := byClient[p.ID]; ua != "" {
+		p.UserAgent = ua
+	} else if fallback != "" {
+		p.UserAgent = fallback
+	}
+	return p
+}
+
+// WithAPIHost returns p with its APIHost set to byClient[p.ID] or, failing
+// that, to fallback. Empty overrides leave it unchanged.
+func (p ClientProfile) WithAPIHost(fallback string, byClient map[string]string) ClientProfile {
+	if host := strings.TrimSpace(byClient[p.ID]); host != "" {
+		p.APIHost = host
+	} else if host := strings.TrimSpace(fallback); host != "" {
+		p.APIHost = host
+	}
+	return p
+}
+
+// APIURL returns the URL of the Innertube endpoint path (e.g.
+// "/youtubei/v1/player") for p.
+func (p ClientProfile) APIURL(path string) string {
+	host := strings.TrimSpace(p.APIHost)
+	if host == "" {
+		host = p.Host
+	}
+	if strings.Contains(host, "://") {
+		return strings.TrimSuffix(host, "/") + path
+	}
+	return "https://" + host + path
+}
+
 type Registry interface {
 	Get(name string) (ClientProfile, bool)
 	All() []ClientProfile
+	// Names lists the accepted client names, aliases included, sorted.
+	Names() []string
 }
@@ -0,0 +1,221 @@
+package innertube
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when a request would have to wait longer than
+// QuotaConfig.MaxWait for its client's request budget.
+var ErrQuotaExceeded = errors.New("innertube request quota exceeded")
+
+// QuotaConfig bounds Innertube API request volume per client.
+type QuotaConfig struct {
+	// RequestsPerHour is the default per-client ceiling. Zero or negative
+	// leaves clients without a PerClient entry unlimited.
+	RequestsPerHour int
+	// PerClient overrides RequestsPerHour for specific client IDs (e.g. "web").
+	PerClient map[string]int
+	// Burst is how many requests may be sent back-to-back before pacing starts.
+	// Zero uses min(10, limit).
+	Burst int
+	// MaxWait fails a request with ErrQuotaExceeded instead of queueing it for
+	// longer than this. Zero queues until the context is done.
+	MaxWait time.Duration
+}
+
+// Enabled reports whether any ceiling is configured.
+func (c QuotaConfig) Enabled() bool {
+	if c.RequestsPerHour > 0 {
+		return true
+	}
+	for _, limit := range c.PerClient {
+		if limit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// QuotaUsage is a point-in-time view of one client's request volume.
+type QuotaUsage struct {
+	Client string
+	// Limit is the configured requests/hour ceiling (0 = unlimited).
+	Limit int
+	// Requests is the total number of requests admitted.
+	Requests int64
+	// LastHour counts requests admitted during the trailing hour.
+	LastHour int
+	// Waiting is the number of requests currently queued for budget.
+	Waiting int
+	// Delayed counts requests that had to wait for budget.
+	Delayed int64
+	// Rejected counts requests failed with ErrQuotaExceeded.
+	Rejected int64
+}
+
+// QuotaGuard paces Innertube requests with one token bucket per client.
+// A nil *QuotaGuard admits every request immediately.
+type QuotaGuard struct {
+	cfg QuotaConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*quotaBucket
+}
+
+type quotaBucket struct {
+	limit    int
+	rate     float64 // tokens per second
+	burst    float64
+	tokens   float64
+	updated  time.Time
+	admitted []time.Time // trailing-hour admissions, oldest first
+	usage    QuotaUsage
+}
+
+// NewQuotaGuard returns a guard for cfg. Usage is tracked even when no
+// ceiling is configured.
+func NewQuotaGuard(cfg QuotaConfig) *QuotaGuard {
+	return &QuotaGuard{cfg: cfg, now: time.Now, buckets: make(map[string]*quotaBucket)}
+}
+
+// Wait blocks until client may send one request, queueing behind earlier
+// waiters. It returns ctx.Err() when the context ends first and
+// ErrQuotaExceeded when the wait would exceed MaxWait.
+func (g *QuotaGuard) Wait(ctx context.Context, client string) error {
+	if g == nil {
+		return nil
+	}
+	delay, err := g.reserve(client)
+	if err != nil || delay <= 0 {
+		return err
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		g.finishWait(client, true)
+		return nil
+	case <-ctx.Done():
+		g.finishWait(client, false)
+		return ctx.Err()
+	}
+}
+
+// Usage returns per-client usage sorted by client ID.
+func (g *QuotaGuard) Usage() []QuotaUsage {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	out := make([]QuotaUsage, 0, len(g.buckets))
+	for _, b := range g.buckets {
+		b.trim(now)
+		u := b.usage
+		u.LastHour = len(b.admitted)
+		out = append(out, u)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Client < out[j].Client })
+	return out
+}
+
+func (g *QuotaGuard) reserve(client string) (time.Duration, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	b := g.bucket(client, now)
+	b.trim(now)
+	if b.limit <= 0 {
+		b.admit(now)
+		return 0, nil
+	}
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		b.admit(now)
+		return 0, nil
+	}
+	delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if g.cfg.MaxWait > 0 && delay > g.cfg.MaxWait {
+		b.usage.Rejected++
+		return 0, ErrQuotaExceeded
+	}
+	// Reserve the token now so later callers queue behind this one.
+	b.tokens--
+	b.usage.Waiting++
+	b.usage.Delayed++
+	return delay, nil
+}
+
+func (g *QuotaGuard) finishWait(client string, admitted bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	b := g.bucket(client, now)
+	b.usage.Waiting--
+	if admitted {
+		b.admit(now)
+		return
+	}
+	b.tokens++
+}
+
+func (g *QuotaGuard) bucket(client string, now time.Time) *quotaBucket {
+	if b, ok := g.buckets[client]; ok {
+		return b
+	}
+	limit := g.cfg.RequestsPerHour
+	if v, ok := g.cfg.PerClient[client]; ok {
+		limit = v
+	}
+	b := &quotaBucket{limit: limit, updated: now, usage: QuotaUsage{Client: client}}
+	if limit > 0 {
+		burst := g.cfg.Burst
+		if burst <= 0 {
+			burst = 10
+		}
+		if burst > limit {
+			burst = limit
+		}
+		b.usage.Limit = limit
+		b.rate = float64(limit) / time.Hour.Seconds()
+		b.burst = float64(burst)
+		b.tokens = b.burst
+	}
+	g.buckets[client] = b
+	return b
+}
+
+func (b *quotaBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	b.updated = now
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+func (b *quotaBucket) admit(now time.Time) {
+	b.usage.Requests++
+	b.admitted = append(b.admitted, now)
+}
+
+func (b *quotaBucket) trim(now time.Time) {
+	cutoff := now.Add(-time.Hour)
+	i := 0
+	for i < len(b.admitted) && !b.admitted[i].After(cutoff) {
+		i++
+	}
+	if i > 0 {
+		b.admitted = append(b.admitted[:0], b.admitted[i:]...)
+	}
+}
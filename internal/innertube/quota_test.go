@@ -0,0 +1,79 @@
+package innertube
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQuotaGuard_BurstThenPacesAndRejects(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	g := NewQuotaGuard(QuotaConfig{RequestsPerHour: 60, Burst: 2, MaxWait: 30 * time.Second})
+	g.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if err := g.Wait(context.Background(), "web"); err != nil {
+			t.Fatalf("Wait(burst %d) error = %v", i, err)
+		}
+	}
+	// One token refills per minute at 60/hour; the next request would wait ~60s.
+	if err := g.Wait(context.Background(), "web"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Wait(over budget) error = %v, want ErrQuotaExceeded", err)
+	}
+	now = now.Add(time.Minute)
+	if err := g.Wait(context.Background(), "web"); err != nil {
+		t.Fatalf("Wait(after refill) error = %v", err)
+	}
+	// Other clients have their own bucket.
+	if err := g.Wait(context.Background(), "ios"); err != nil {
+		t.Fatalf("Wait(ios) error = %v", err)
+	}
+
+	usage := g.Usage()
+	if len(usage) != 2 || usage[0].Client != "ios" || usage[1].Client != "web" {
+		t.Fatalf("Usage() = %+v", usage)
+	}
+	web := usage[1]
+	if web.Limit != 60 || web.Requests != 3 || web.LastHour != 3 || web.Rejected != 1 || web.Waiting != 0 {
+		t.Fatalf("web usage = %+v", web)
+	}
+	now = now.Add(2 * time.Hour)
+	if got := g.Usage()[1].LastHour; got != 0 {
+		t.Fatalf("LastHour after 2h = %d, want 0", got)
+	}
+}
+
+func TestQuotaGuard_CancelledWaitReturnsToken(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	g := NewQuotaGuard(QuotaConfig{RequestsPerHour: 1, PerClient: map[string]int{"tv": 0}})
+	g.now = func() time.Time { return now }
+
+	if err := g.Wait(context.Background(), "web"); err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := g.Wait(ctx, "web"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait(cancelled) error = %v, want context.Canceled", err)
+	}
+	if b := g.buckets["web"]; b.tokens != 0 || b.usage.Waiting != 0 || b.usage.Delayed != 1 {
+		t.Fatalf("bucket after cancel = tokens %.2f usage %+v", b.tokens, b.usage)
+	}
+	// A zero PerClient entry leaves that client unlimited.
+	for i := 0; i < 5; i++ {
+		if err := g.Wait(context.Background(), "tv"); err != nil {
+			t.Fatalf("Wait(tv) error = %v", err)
+		}
+	}
+}
+
+func TestQuotaGuard_NilAdmitsEverything(t *testing.T) {
+	var g *QuotaGuard
+	if err := g.Wait(context.Background(), "web"); err != nil {
+		t.Fatalf("nil Wait() error = %v", err)
+	}
+	if g.Usage() != nil {
+		t.Fatal("expected nil usage from nil guard")
+	}
+}
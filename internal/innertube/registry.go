@@ -1,6 +1,9 @@
 package innertube
 
-import "sync"
+import (
+	"sort"
+	"sync"
+)
 
 type defaultRegistry struct {
 	clients map[string]ClientProfile
@@ -46,3 +49,15 @@ func (r *defaultRegistry) All() []ClientProfile {
 	}
 	return all
 }
+
+func (r *defaultRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.clients))
+	for name := range r.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
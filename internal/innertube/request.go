@@ -87,6 +87,27 @@ type PlayerRequestOptions struct {
 	SignatureTimestamp int
 	UseAdPlayback      bool
 	PlayerParams       string
+	// Language is the "hl" interface language; localized fields such as the
+	// microformat title and description follow it. Empty means "en".
+	Language string
+}
+
+// requestLanguage returns the "hl" value for lang, defaulting to "en".
+func requestLanguage(lang string) string {
+	if lang = strings.TrimSpace(lang); lang != "" {
+		return lang
+	}
+	return "en"
+}
+
+// AcceptLanguageHeader returns an Accept-Language value preferring lang and
+// falling back to English.
+func AcceptLanguageHeader(lang string) string {
+	lang = strings.TrimSpace(lang)
+	if lang == "" || lang == "en" || lang == "en-US" {
+		return "en-US,en;q=0.9"
+	}
+	return lang + ",en-US;q=0.8,en;q=0.7"
 }
 
 func NewPlayerRequest(profile ClientProfile, videoID string, opts ...PlayerRequestOptions) *PlayerRequest {
@@ -98,7 +119,7 @@ func NewPlayerRequest(profile ClientProfile, videoID string, opts ...PlayerReque
 		ClientName:       profile.Name,
 		ClientVersion:    profile.Version,
 		UserAgent:        profile.UserAgent,
-		AcceptLanguage:   "en",
+		AcceptLanguage:   requestLanguage(options.Language),
 		VisitorData:      options.VisitorData,
 		TimeZone:         "UTC",
 		UtcOffsetMinutes: 0,
@@ -152,7 +173,7 @@ func NewBrowseRequest(profile ClientProfile, browseID string, continuation strin
 		ClientName:       profile.Name,
 		ClientVersion:    profile.Version,
 		UserAgent:        profile.UserAgent,
-		AcceptLanguage:   "en",
+		AcceptLanguage:   requestLanguage(options.Language),
 		VisitorData:      options.VisitorData,
 		TimeZone:         "UTC",
 		UtcOffsetMinutes: 0,
@@ -30,6 +30,19 @@ func TestNewPlayerRequestIncludesVisitorData(t *testing.T) {
 	}
 }
 
+func TestNewPlayerRequestLanguage(t *testing.T) {
+	if hl := NewPlayerRequest(WebClient, "jNQXAC9IVRw").Context.Client.AcceptLanguage; hl != "en" {
+		t.Fatalf("default hl = %q, want en", hl)
+	}
+	req := NewPlayerRequest(WebClient, "jNQXAC9IVRw", PlayerRequestOptions{Language: "ko"})
+	if req.Context.Client.AcceptLanguage != "ko" {
+		t.Fatalf("hl = %q, want ko", req.Context.Client.AcceptLanguage)
+	}
+	if got := AcceptLanguageHeader("ko"); got != "ko,en-US;q=0.8,en;q=0.7" {
+		t.Fatalf("AcceptLanguageHeader(ko) = %q", got)
+	}
+}
+
 func TestNewPlayerRequestEmbeddedContext(t *testing.T) {
 	req := NewPlayerRequest(WebEmbeddedClient, "jNQXAC9IVRw")
 	if req.Context.ThirdParty == nil {
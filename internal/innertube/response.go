@@ -7,7 +7,24 @@ type PlayerResponse struct {
 	VideoDetails      VideoDetails      `json:"videoDetails"`
 	Microformat       Microformat       `json:"microformat"`
 	Captions          Captions          `json:"captions"`
+	Storyboards       Storyboards       `json:"storyboards"`
+	PlaybackTracking  PlaybackTracking  `json:"playbackTracking"`
 	SourceClient      string            `json:"-"`
+	// SignatureTimestamp is the STS sent with the request that produced this
+	// response, or 0 if none was resolved.
+	SignatureTimestamp int `json:"-"`
+	// FailedAttempts lists the clients that failed before SourceClient
+	// succeeded, in client order.
+	FailedAttempts []ClientAttempt `json:"-"`
+	// PlayerURL is the player JS URL of the watch page the response was
+	// scraped from (watch-page fallback only), saving a second page fetch.
+	PlayerURL string `json:"-"`
+}
+
+// ClientAttempt is one failed player request.
+type ClientAttempt struct {
+	Client string
+	Error  string
 }
 
 type BrowseResponse struct {
@@ -89,10 +106,17 @@ type ContinuationCommand struct {
 }
 
 type PlaylistVideoRenderer struct {
-	VideoID         string   `json:"videoId"`
-	Title           LangText `json:"title"`
-	ShortBylineText LangText `json:"shortBylineText"`
-	LengthText      LangText `json:"lengthText"`
+	VideoID         string           `json:"videoId"`
+	Title           LangText         `json:"title"`
+	ShortBylineText LangText         `json:"shortBylineText"`
+	LengthText      LangText         `json:"lengthText"`
+	LengthSeconds   string           `json:"lengthSeconds"`
+	Index           LangText         `json:"index"`
+	Thumbnail       ThumbnailDetails `json:"thumbnail"`
+	// IsPlayable is false for private, deleted and otherwise unavailable
+	// entries, which keep their slot in the playlist.
+	IsPlayable     *bool    `json:"isPlayable"`
+	UnplayableText LangText `json:"unplayableText"`
 }
 
 type PlayabilityStatus struct {
@@ -112,17 +136,56 @@ func (p *PlayabilityStatus) IsLive() bool {
 	return p.LiveStreamability != nil
 }
 
+// IsUpcoming reports a scheduled live stream or premiere that has not
+// started; such responses carry metadata but no streaming data.
+func (p *PlayabilityStatus) IsUpcoming() bool {
+	return p.Status == "LIVE_STREAM_OFFLINE"
+}
+
+// TrailerVideoID returns the trailer shown instead of the video, if any.
+func (p *PlayabilityStatus) TrailerVideoID() string {
+	if p.ErrorScreen == nil {
+		return ""
+	}
+	for _, r := range []*YpcTrailerRenderer{p.ErrorScreen.YpcTrailerRenderer, p.ErrorScreen.PlayerLegacyDesktopYpcTrailerRenderer} {
+		if r != nil && r.TrailerVideoID != "" {
+			return r.TrailerVideoID
+		}
+	}
+	return ""
+}
+
 type LiveStreamability struct {
 	LiveStreamabilityRenderer LiveStreamabilityRenderer `json:"liveStreamabilityRenderer"`
 }
 
 type LiveStreamabilityRenderer struct {
-	VideoId     string `json:"videoId"`
-	PollDelayMs string `json:"pollDelayMs"`
+	VideoId      string        `json:"videoId"`
+	PollDelayMs  string        `json:"pollDelayMs"`
+	OfflineSlate *OfflineSlate `json:"offlineSlate"`
+}
+
+type OfflineSlate struct {
+	LiveStreamOfflineSlateRenderer *LiveStreamOfflineSlateRenderer `json:"liveStreamOfflineSlateRenderer"`
+}
+
+// LiveStreamOfflineSlateRenderer is the waiting-room slate of an upcoming
+// stream or premiere.
+type LiveStreamOfflineSlateRenderer struct {
+	ScheduledStartTime string   `json:"scheduledStartTime"` // unix seconds
+	MainText           LangText `json:"mainText"`
+	SubtitleText       LangText `json:"subtitleText"`
 }
 
 type ErrorScreen struct {
-	PlayerErrorMessageRenderer *PlayerErrorMessageRenderer `json:"playerErrorMessageRenderer"`
+	PlayerErrorMessageRenderer            *PlayerErrorMessageRenderer `json:"playerErrorMessageRenderer"`
+	YpcTrailerRenderer                    *YpcTrailerRenderer         `json:"ypcTrailerRenderer"`
+	PlayerLegacyDesktopYpcTrailerRenderer *YpcTrailerRenderer         `json:"playerLegacyDesktopYpcTrailerRenderer"`
+}
+
+// YpcTrailerRenderer points at a trailer played in place of the video.
+type YpcTrailerRenderer struct {
+	TrailerVideoID string `json:"trailerVideoId"`
 }
 
 type PlayerErrorMessageRenderer struct {
@@ -139,28 +202,40 @@ type StreamingData struct {
 }
 
 type Format struct {
-	Itag             int      `json:"itag"`
-	URL              string   `json:"url"`
-	MimeType         string   `json:"mimeType"`
-	Bitrate          int      `json:"bitrate"`
-	Width            int      `json:"width"`
-	Height           int      `json:"height"`
-	FPS              int      `json:"fps"`
-	InitRange        *Range   `json:"initRange"`
-	IndexRange       *Range   `json:"indexRange"`
-	LastModified     string   `json:"lastModified"`
-	ContentLength    string   `json:"contentLength"`
-	Quality          string   `json:"quality"`
-	QualityLabel     string   `json:"qualityLabel"`
-	ProjectionType   string   `json:"projectionType"`
-	AverageBitrate   int      `json:"averageBitrate"`
-	AudioQuality     string   `json:"audioQuality"`
-	ApproxDurationMs string   `json:"approxDurationMs"`
-	AudioSampleRate  string   `json:"audioSampleRate"`
-	AudioChannels    int      `json:"audioChannels"`
-	SignatureCipher  string   `json:"signatureCipher"`
-	Cipher           string   `json:"cipher"` // Legacy
-	DRMFamilies      []string `json:"drmFamilies"`
+	Itag             int         `json:"itag"`
+	URL              string      `json:"url"`
+	MimeType         string      `json:"mimeType"`
+	Bitrate          int         `json:"bitrate"`
+	Width            int         `json:"width"`
+	Height           int         `json:"height"`
+	FPS              int         `json:"fps"`
+	InitRange        *Range      `json:"initRange"`
+	IndexRange       *Range      `json:"indexRange"`
+	LastModified     string      `json:"lastModified"`
+	ContentLength    string      `json:"contentLength"`
+	Quality          string      `json:"quality"`
+	QualityLabel     string      `json:"qualityLabel"`
+	ProjectionType   string      `json:"projectionType"`
+	AverageBitrate   int         `json:"averageBitrate"`
+	AudioQuality     string      `json:"audioQuality"`
+	ApproxDurationMs string      `json:"approxDurationMs"`
+	AudioSampleRate  string      `json:"audioSampleRate"`
+	AudioChannels    int         `json:"audioChannels"`
+	SignatureCipher  string      `json:"signatureCipher"`
+	Cipher           string      `json:"cipher"` // Legacy
+	DRMFamilies      []string    `json:"drmFamilies"`
+	AudioTrack       *AudioTrack `json:"audioTrack"`
+	// TargetDurationSec and MaxDvrDurationSec are set on live formats: the
+	// segment duration and the seekable DVR window.
+	TargetDurationSec int `json:"targetDurationSec"`
+	MaxDvrDurationSec int `json:"maxDvrDurationSec"`
+}
+
+// AudioTrack describes one language/dub variant of a multi-audio video.
+type AudioTrack struct {
+	DisplayName    string `json:"displayName"`
+	ID             string `json:"id"` // e.g. "en.4"
+	AudioIsDefault bool   `json:"audioIsDefault"`
 }
 
 type Range struct {
@@ -184,6 +259,14 @@ type VideoDetails struct {
 	IsPrivate         bool             `json:"isPrivate"`
 	IsUnpluggedCorpus bool             `json:"isUnpluggedCorpus"`
 	IsLiveContent     bool             `json:"isLiveContent"`
+	IsUpcoming        bool             `json:"isUpcoming"`
+	// IsPostLiveDvr marks a live stream that has ended but whose replay
+	// is still being processed.
+	IsPostLiveDvr bool `json:"isPostLiveDvr"`
+	// LatencyClass is a live stream's latency mode, e.g.
+	// MDE_STREAM_OPTIMIZATIONS_RENDERER_LATENCY_LOW.
+	LatencyClass           string `json:"latencyClass"`
+	IsLowLatencyLiveStream bool   `json:"isLowLatencyLiveStream"`
 }
 
 type ThumbnailDetails struct {
@@ -217,6 +300,17 @@ type PlayerMicroformatRenderer struct {
 	PublishDate        string           `json:"publishDate"`
 	OwnerChannelName   string           `json:"ownerChannelName"`
 	UploadDate         string           `json:"uploadDate"`
+	// DefaultLanguage is the language the uploader wrote the title and
+	// description in; Title and Description follow the request's "hl".
+	DefaultLanguage string `json:"defaultLanguage"`
+	// LiveBroadcastDetails is set for live streams and premieres.
+	LiveBroadcastDetails *LiveBroadcastDetails `json:"liveBroadcastDetails"`
+}
+
+type LiveBroadcastDetails struct {
+	IsLiveNow      bool   `json:"isLiveNow"`
+	StartTimestamp string `json:"startTimestamp"` // RFC 3339
+	EndTimestamp   string `json:"endTimestamp"`
 }
 
 type Embed struct {
@@ -230,6 +324,28 @@ type SimpleText struct {
 	SimpleText string `json:"simpleText"`
 }
 
+// PlaybackTracking holds the stats URLs a player reports playback to.
+type PlaybackTracking struct {
+	VideostatsPlaybackURL  TrackingURL `json:"videostatsPlaybackUrl"`
+	VideostatsWatchtimeURL TrackingURL `json:"videostatsWatchtimeUrl"`
+}
+
+// TrackingURL is one playback stats endpoint.
+type TrackingURL struct {
+	BaseURL string `json:"baseUrl"`
+}
+
+// Storyboards holds the seek-preview sprite sheet spec of VOD videos.
+type Storyboards struct {
+	PlayerStoryboardSpecRenderer StoryboardSpecRenderer `json:"playerStoryboardSpecRenderer"`
+}
+
+// StoryboardSpecRenderer.Spec is "<url template>|<level>|<level>...", each
+// level being "width#height#count#columns#rows#intervalMs#name#sigh".
+type StoryboardSpecRenderer struct {
+	Spec string `json:"spec"`
+}
+
 type Captions struct {
 	PlayerCaptionsTracklistRenderer PlayerCaptionsTracklistRenderer `json:"playerCaptionsTracklistRenderer"`
 }
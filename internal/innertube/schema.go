@@ -0,0 +1,193 @@
+package innertube
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaDrift kinds.
+const (
+	// DriftPlayabilityStatus is a playabilityStatus.status outside the known set.
+	DriftPlayabilityStatus = "playability_status"
+	// DriftUnknownField is a streamingData or format key this package has not seen.
+	DriftUnknownField = "unknown_field"
+	// DriftRenderer is an unseen renderer type in errorScreen, microformat or captions.
+	DriftRenderer = "renderer"
+	// DriftTypeMismatch is a known field whose JSON type no longer matches.
+	DriftTypeMismatch = "type_mismatch"
+)
+
+// SchemaDrift records one way a player response departed from the shape this
+// package was written against. It is telemetry only: decoding continues.
+type SchemaDrift struct {
+	Client  string
+	VideoID string
+	Kind    string
+	// Path is the dotted JSON path, with [] for array elements.
+	Path string
+	// Value is the unexpected status, key or renderer name, or for type
+	// mismatches "<json type> -> <go type>".
+	Value string
+}
+
+// SchemaDriftHandler receives schema drift observed in player responses.
+type SchemaDriftHandler func(SchemaDrift)
+
+var knownPlayabilityStatuses = stringSet(
+	"OK",
+	"UNPLAYABLE",
+	"ERROR",
+	"LOGIN_REQUIRED",
+	"LIVE_STREAM_OFFLINE",
+	"CONTENT_CHECK_REQUIRED",
+	"AGE_CHECK_REQUIRED",
+	"AGE_VERIFICATION_REQUIRED",
+)
+
+// Keys seen in current responses, decoded or not.
+var knownStreamingDataKeys = stringSet(
+	"expiresInSeconds",
+	"formats",
+	"adaptiveFormats",
+	"dashManifestUrl",
+	"hlsManifestUrl",
+	"serverAbrStreamingUrl",
+	"probeUrl",
+	"licenseInfos",
+	"drmParams",
+	"initialAuthorizedDrmTrackTypes",
+)
+
+var knownFormatKeys = stringSet(
+	"itag", "url", "mimeType", "bitrate", "width", "height", "fps",
+	"initRange", "indexRange", "lastModified", "contentLength",
+	"quality", "qualityLabel", "qualityOrdinal", "projectionType",
+	"averageBitrate", "audioQuality", "approxDurationMs",
+	"audioSampleRate", "audioChannels", "signatureCipher", "cipher",
+	"drmFamilies", "drmTrackType", "audioTrack", "colorInfo",
+	"highReplication", "loudnessDb", "isDrc", "isVb", "xtags", "type",
+	"targetDurationSec", "maxDvrDurationSec", "spatialAudioType",
+	"trackAbsoluteLoudnessLkfs", "fairPlayKeyUri", "distinctParams",
+)
+
+var knownRenderers = map[string]map[string]struct{}{
+	"playabilityStatus.errorScreen": stringSet(
+		"playerErrorMessageRenderer",
+		"playerLegacyDesktopYpcOfferRenderer",
+		"playerLegacyDesktopYpcTrailerRenderer",
+		"ypcTrailerRenderer",
+		"playerKavRenderer",
+	),
+	"microformat": stringSet("playerMicroformatRenderer", "microformatDataRenderer"),
+	"captions":    stringSet("playerCaptionsTracklistRenderer", "playerCaptionsRenderer"),
+}
+
+// playerResponseProbe keeps the raw keys DecodePlayerResponse inspects.
+type playerResponseProbe struct {
+	PlayabilityStatus struct {
+		Status      string                     `json:"status"`
+		ErrorScreen map[string]json.RawMessage `json:"errorScreen"`
+	} `json:"playabilityStatus"`
+	StreamingData map[string]json.RawMessage `json:"streamingData"`
+	Microformat   map[string]json.RawMessage `json:"microformat"`
+	Captions      map[string]json.RawMessage `json:"captions"`
+}
+
+// DecodePlayerResponse unmarshals a /player body leniently. A field whose JSON
+// type changed is left zero and reported as drift instead of failing the whole
+// response; unknown statuses, streamingData keys and renderer types are also
+// reported. Only malformed JSON returns an error. Client and VideoID are left
+// for the caller to fill in.
+func DecodePlayerResponse(body []byte) (*PlayerResponse, []SchemaDrift, error) {
+	var resp PlayerResponse
+	var drift []SchemaDrift
+	if err := json.Unmarshal(body, &resp); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return nil, nil, err
+		}
+		// encoding/json finishes decoding the remaining fields and reports
+		// only the first mismatch.
+		drift = append(drift, SchemaDrift{
+			Kind:  DriftTypeMismatch,
+			Path:  normalizeDriftPath(typeErr.Field),
+			Value: typeErr.Value + " -> " + typeErr.Type.String(),
+		})
+	}
+
+	var probe playerResponseProbe
+	if err := json.Unmarshal(body, &probe); err != nil {
+		// The typed decode above already succeeded; an unexpected container
+		// type here is itself drift but not worth more than the first report.
+		return &resp, drift, nil
+	}
+	if status := probe.PlayabilityStatus.Status; status != "" {
+		if _, ok := knownPlayabilityStatuses[status]; !ok {
+			drift = append(drift, SchemaDrift{Kind: DriftPlayabilityStatus, Path: "playabilityStatus.status", Value: status})
+		}
+	}
+	drift = appendUnknownKeys(drift, DriftUnknownField, "streamingData", probe.StreamingData, knownStreamingDataKeys)
+	for _, list := range []string{"formats", "adaptiveFormats"} {
+		raw, ok := probe.StreamingData[list]
+		if !ok {
+			continue
+		}
+		var entries []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			continue
+		}
+		seen := make(map[string]struct{})
+		for _, entry := range entries {
+			for key := range entry {
+				seen[key] = struct{}{}
+			}
+		}
+		drift = appendUnknownKeys(drift, DriftUnknownField, "streamingData."+list+"[]", seen, knownFormatKeys)
+	}
+	drift = appendUnknownKeys(drift, DriftRenderer, "playabilityStatus.errorScreen", probe.PlayabilityStatus.ErrorScreen, knownRenderers["playabilityStatus.errorScreen"])
+	drift = appendUnknownKeys(drift, DriftRenderer, "microformat", probe.Microformat, knownRenderers["microformat"])
+	drift = appendUnknownKeys(drift, DriftRenderer, "captions", probe.Captions, knownRenderers["captions"])
+	return &resp, drift, nil
+}
+
+// appendUnknownKeys appends one drift entry per key of m missing from known,
+// in sorted order so reports are stable.
+func appendUnknownKeys[V any](drift []SchemaDrift, kind, path string, m map[string]V, known map[string]struct{}) []SchemaDrift {
+	var unknown []string
+	for key := range m {
+		if _, ok := known[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		drift = append(drift, SchemaDrift{Kind: kind, Path: path, Value: key})
+	}
+	return drift
+}
+
+// normalizeDriftPath rewrites array indexes ("formats.3.bitrate") as
+// "formats[].bitrate" so the same drift dedupes across responses.
+func normalizeDriftPath(field string) string {
+	parts := strings.Split(field, ".")
+	out := parts[:0]
+	for _, p := range parts {
+		if _, err := strconv.Atoi(p); err == nil && len(out) > 0 {
+			out[len(out)-1] += "[]"
+			continue
+		}
+		out = append(out, p)
+	}
+	return strings.Join(out, ".")
+}
+
+func stringSet(values ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
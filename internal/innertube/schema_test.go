@@ -0,0 +1,51 @@
+package innertube
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodePlayerResponse_ReportsDrift(t *testing.T) {
+	body := `{
+		"playabilityStatus":{"status":"SABR_REQUIRED","errorScreen":{"playerCaptchaViewModel":{}}},
+		"streamingData":{"adaptiveFormats":[{"itag":251,"url":"https://media.example/a","sabrParams":"x"}],"sabrConfig":{}},
+		"microformat":{"playerMicroformatRenderer":{"title":{"simpleText":"t"}}},
+		"videoDetails":{"videoId":"jNQXAC9IVRw"}
+	}`
+	resp, drift, err := DecodePlayerResponse([]byte(body))
+	if err != nil {
+		t.Fatalf("DecodePlayerResponse() error = %v", err)
+	}
+	if resp.VideoDetails.VideoID != "jNQXAC9IVRw" || len(resp.StreamingData.AdaptiveFormats) != 1 {
+		t.Fatalf("decoded response = %+v", resp)
+	}
+	want := []SchemaDrift{
+		{Kind: DriftPlayabilityStatus, Path: "playabilityStatus.status", Value: "SABR_REQUIRED"},
+		{Kind: DriftUnknownField, Path: "streamingData", Value: "sabrConfig"},
+		{Kind: DriftUnknownField, Path: "streamingData.adaptiveFormats[]", Value: "sabrParams"},
+		{Kind: DriftRenderer, Path: "playabilityStatus.errorScreen", Value: "playerCaptchaViewModel"},
+	}
+	if !reflect.DeepEqual(drift, want) {
+		t.Fatalf("drift = %+v\nwant %+v", drift, want)
+	}
+}
+
+func TestDecodePlayerResponse_ToleratesTypeChange(t *testing.T) {
+	body := `{"playabilityStatus":{"status":"OK"},"streamingData":{"formats":[{"itag":18,"bitrate":"1000","url":"https://media.example/v"}]},"videoDetails":{"videoId":"jNQXAC9IVRw"}}`
+	resp, drift, err := DecodePlayerResponse([]byte(body))
+	if err != nil {
+		t.Fatalf("DecodePlayerResponse() error = %v", err)
+	}
+	if resp.VideoDetails.VideoID != "jNQXAC9IVRw" || len(resp.StreamingData.Formats) != 1 || resp.StreamingData.Formats[0].URL == "" {
+		t.Fatalf("decoded response = %+v", resp)
+	}
+	if len(drift) != 1 || drift[0].Kind != DriftTypeMismatch || drift[0].Path != "streamingData.formats[].bitrate" {
+		t.Fatalf("drift = %+v", drift)
+	}
+}
+
+func TestDecodePlayerResponse_MalformedJSON(t *testing.T) {
+	if _, _, err := DecodePlayerResponse([]byte(`{"playabilityStatus":`)); err == nil {
+		t.Fatal("expected error for malformed JSON")
+	}
+}
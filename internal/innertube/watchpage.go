@@ -0,0 +1,55 @@
+package innertube
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrNoInitialPlayerResponse is returned when a watch page carries no
+// ytInitialPlayerResponse object.
+var ErrNoInitialPlayerResponse = errors.New("ytInitialPlayerResponse not found in watch page")
+
+// WatchPageURL returns the desktop watch page of videoID. The bpctr and
+// has_verified parameters skip the content-warning interstitial, which would
+// otherwise replace the embedded player response.
+func WatchPageURL(videoID string) string {
+	return "https://www.youtube.com/watch?v=" + strings.TrimSpace(videoID) + "&bpctr=9999999999&has_verified=1"
+}
+
+// ExtractInitialPlayerResponse returns the raw JSON of the player response a
+// watch page embeds as `var ytInitialPlayerResponse = {...};`. It is the same
+// object the player API returns for the WEB client.
+func ExtractInitialPlayerResponse(body []byte) ([]byte, error) {
+	const marker = "ytInitialPlayerResponse"
+	rest := body
+	for {
+		idx := bytes.Index(rest, []byte(marker))
+		if idx < 0 {
+			return nil, ErrNoInitialPlayerResponse
+		}
+		rest = rest[idx+len(marker):]
+		// Accept both `ytInitialPlayerResponse = {` and
+		// `window["ytInitialPlayerResponse"] = {`.
+		value := bytes.TrimLeft(rest, "\"'] \t\r\n")
+		if len(value) == 0 || value[0] != '=' {
+			continue
+		}
+		value = bytes.TrimLeft(value[1:], " \t\r\n")
+		if len(value) == 0 || value[0] != '{' {
+			continue
+		}
+		var raw json.RawMessage
+		if err := json.NewDecoder(bytes.NewReader(value)).Decode(&raw); err != nil {
+			continue
+		}
+		return raw, nil
+	}
+}
+
+// ExtractWatchPagePlayerURL returns the player JS URL referenced by a watch
+// page, or "" if none is found.
+func ExtractWatchPagePlayerURL(body []byte) string {
+	return extractPlayerURLFromWatchBody(body)
+}
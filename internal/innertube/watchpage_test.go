@@ -0,0 +1,40 @@
+package innertube
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExtractInitialPlayerResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "var assignment",
+			body: `<script>var ytInitialPlayerResponse = {"videoDetails":{"title":"a;b {c}"}};var meta = {};</script>`,
+			want: `{"videoDetails":{"title":"a;b {c}"}}`,
+		},
+		{
+			name: "window property after a bare mention",
+			body: `<script>if (ytInitialPlayerResponse) {}; window["ytInitialPlayerResponse"] = {"playabilityStatus":{"status":"OK"}};</script>`,
+			want: `{"playabilityStatus":{"status":"OK"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractInitialPlayerResponse([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("ExtractInitialPlayerResponse() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("ExtractInitialPlayerResponse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ExtractInitialPlayerResponse([]byte(`<html>var ytInitialPlayerResponse = null;</html>`)); !errors.Is(err, ErrNoInitialPlayerResponse) {
+		t.Fatalf("ExtractInitialPlayerResponse(null) error = %v, want ErrNoInitialPlayerResponse", err)
+	}
+}
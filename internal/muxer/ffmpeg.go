@@ -2,9 +2,16 @@ package muxer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/famomatic/ytv1/internal/types"
 )
@@ -47,20 +54,7 @@ func (f *FFmpegMuxer) Merge(ctx context.Context, videoPath, audioPath, outputPat
 	}
 
 	// Add Metadata
-	if meta.Title != "" {
-		args = append(args, "-metadata", "title="+meta.Title)
-	}
-	if meta.Artist != "" {
-		args = append(args, "-metadata", "artist="+meta.Artist)
-	}
-	if meta.Date != "" {
-		args = append(args, "-metadata", "date="+meta.Date)
-		// Also standard creation_time?
-		// args = append(args, "-metadata", "creation_time="+meta.Date)
-	}
-	if meta.Description != "" {
-		args = append(args, "-metadata", "comment="+meta.Description)
-	}
+	args = appendMetadataArgs(args, meta)
 
 	args = append(args, "-y", outputPath)
 
@@ -78,3 +72,177 @@ func (f *FFmpegMuxer) Merge(ctx context.Context, videoPath, audioPath, outputPat
 
 	return nil
 }
+
+// MergeTracks merges any number of video/audio inputs into one output file,
+// mapping every input and tagging each audio stream with its language/title.
+// It deletes the input files upon successful merge.
+func (f *FFmpegMuxer) MergeTracks(ctx context.Context, tracks []types.MuxTrack, outputPath string, meta types.Metadata) error {
+	// ffmpeg -i v.mp4 -i a1.m4a -i a2.m4a -map 0 -map 1 -map 2 -c copy
+	//   -metadata:s:a:0 language=en -metadata:s:a:1 language=es -y output.mkv
+	if len(tracks) == 0 {
+		return fmt.Errorf("ffmpeg merge failed: no input tracks")
+	}
+	var args []string
+	for _, t := range tracks {
+		args = append(args, "-i", t.Path)
+	}
+	for i := range tracks {
+		args = append(args, "-map", strconv.Itoa(i))
+	}
+	args = append(args, "-c", "copy")
+
+	audioIndex := 0
+	for _, t := range tracks {
+		if t.Kind != "audio" {
+			continue
+		}
+		stream := "-metadata:s:a:" + strconv.Itoa(audioIndex)
+		if t.Language != "" {
+			args = append(args, stream, "language="+t.Language)
+		}
+		if t.Title != "" {
+			args = append(args, stream, "title="+t.Title)
+		}
+		audioIndex++
+	}
+
+	args = appendMetadataArgs(args, meta)
+	args = append(args, "-y", outputPath)
+
+	cmd := exec.CommandContext(ctx, f.Path, args...)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg merge failed: %w", err)
+	}
+
+	for _, t := range tracks {
+		_ = os.Remove(t.Path)
+	}
+	return nil
+}
+
+// ExtractAudio writes the first audio stream of inputPath to outputPath with
+// metadata. The container follows outputPath's extension; transcode re-encodes
+// to Opus for .opus outputs and AAC otherwise. inputPath is left in place.
+func (f *FFmpegMuxer) ExtractAudio(ctx context.Context, inputPath, outputPath string, transcode bool, meta types.Metadata) error {
+	// ffmpeg -i in.webm -vn -map 0:a:0 -c:a copy -metadata title=... -y out.opus
+	codec := "copy"
+	if transcode {
+		codec = "aac"
+		if strings.EqualFold(filepath.Ext(outputPath), ".opus") {
+			codec = "libopus"
+		}
+	}
+	args := []string{"-i", inputPath, "-vn", "-map", "0:a:0", "-c:a", codec}
+	args = appendMetadataArgs(args, meta)
+	args = append(args, "-y", outputPath)
+
+	cmd := exec.CommandContext(ctx, f.Path, args...)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg audio extraction failed: %w", err)
+	}
+	return nil
+}
+
+// ExtractFrame writes the video frame at at of inputURL to outputPath as JPEG.
+// Seeking before the input makes ffmpeg use the container index and range
+// requests, so only a short stretch of the stream is downloaded.
+func (f *FFmpegMuxer) ExtractFrame(ctx context.Context, inputURL string, headers http.Header, at time.Duration, outputPath string) error {
+	// ffmpeg -headers "User-Agent: ...\r\n" -ss 12.5 -i URL -frames:v 1 -q:v 2 -y out.jpg
+	var args []string
+	if len(headers) > 0 {
+		keys := make([]string, 0, len(headers))
+		for k := range headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		for _, k := range keys {
+			for _, v := range headers[k] {
+				b.WriteString(k + ": " + v + "\r\n")
+			}
+		}
+		args = append(args, "-headers", b.String())
+	}
+	args = append(args,
+		"-ss", strconv.FormatFloat(at.Seconds(), 'f', 3, 64),
+		"-i", inputURL,
+		"-frames:v", "1",
+		"-q:v", "2",
+		"-y", outputPath,
+	)
+
+	cmd := exec.CommandContext(ctx, f.Path, args...)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg frame extraction failed: %w", err)
+	}
+	return nil
+}
+
+// ProbeOutput reports the duration, stream counts and video frame count of
+// path using ffprobe from ffmpeg's directory (or PATH, when ffmpeg is looked
+// up there). Frames are counted from packets, which needs no decoding.
+func (f *FFmpegMuxer) ProbeOutput(ctx context.Context, path string) (types.MediaProbe, error) {
+	// ffprobe -v error -count_packets -show_entries stream=codec_type,nb_read_packets:format=duration -of json in.mp4
+	dir, name := filepath.Split(f.Path)
+	probePath := dir + strings.Replace(name, "ffmpeg", "ffprobe", 1)
+	cmd := exec.CommandContext(ctx, probePath,
+		"-v", "error",
+		"-count_packets",
+		"-show_entries", "stream=codec_type,nb_read_packets:format=duration",
+		"-of", "json",
+		path,
+	)
+	out, err := cmd.Output()
+	if err != nil {
+		return types.MediaProbe{}, fmt.Errorf("ffprobe failed: %w", err)
+	}
+	var report struct {
+		Streams []struct {
+			CodecType     string `json:"codec_type"`
+			NbReadPackets string `json:"nb_read_packets"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		return types.MediaProbe{}, fmt.Errorf("ffprobe output: %w", err)
+	}
+	var probe types.MediaProbe
+	if seconds, err := strconv.ParseFloat(report.Format.Duration, 64); err == nil {
+		probe.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	for _, s := range report.Streams {
+		switch s.CodecType {
+		case "video":
+			if probe.VideoStreams == 0 {
+				probe.VideoFrames, _ = strconv.ParseInt(s.NbReadPackets, 10, 64)
+			}
+			probe.VideoStreams++
+		case "audio":
+			probe.AudioStreams++
+		}
+	}
+	return probe, nil
+}
+
+func appendMetadataArgs(args []string, meta types.Metadata) []string {
+	if meta.Title != "" {
+		args = append(args, "-metadata", "title="+meta.Title)
+	}
+	if meta.Artist != "" {
+		args = append(args, "-metadata", "artist="+meta.Artist)
+	}
+	if meta.Date != "" {
+		args = append(args, "-metadata", "date="+meta.Date)
+		// Also standard creation_time?
+		// args = append(args, "-metadata", "creation_time="+meta.Date)
+	}
+	if meta.Description != "" {
+		args = append(args, "-metadata", "comment="+meta.Description)
+	}
+	if meta.Language != "" {
+		args = append(args, "-metadata", "language="+meta.Language)
+	}
+	return args
+}
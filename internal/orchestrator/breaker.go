@@ -0,0 +1,123 @@
+package orchestrator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/innertube"
+)
+
+// defaultBreakerCooldown is how long an open circuit stays open when
+// CircuitBreakerConfig.Cooldown is zero.
+const defaultBreakerCooldown = 5 * time.Minute
+
+// attemptOutcome is what a client attempt tells the circuit breaker.
+type attemptOutcome int
+
+const (
+	// outcomeAbandoned: the attempt was canceled before it finished, for
+	// instance because an earlier client already succeeded.
+	outcomeAbandoned attemptOutcome = iota
+	// outcomeResponded: the client answered, successfully or not.
+	outcomeResponded
+	// outcomeTimedOut: the attempt ran into ClientTimeout.
+	outcomeTimedOut
+)
+
+// clientBreaker tracks consecutive timeouts per client. A client reaching
+// the threshold is skipped until its cool-down ends; then a single probe
+// attempt is let through, which closes the circuit when the client answers
+// and reopens it when it times out again. A nil *clientBreaker allows
+// everything.
+type clientBreaker struct {
+	cfg innertube.CircuitBreakerConfig
+	now func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*breakerState
+}
+
+type breakerState struct {
+	timeouts  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newClientBreaker(cfg innertube.CircuitBreakerConfig) *clientBreaker {
+	if cfg.Threshold <= 0 {
+		return nil
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaultBreakerCooldown
+	}
+	return &clientBreaker{cfg: cfg, now: time.Now, clients: make(map[string]*breakerState)}
+}
+
+// allow reports whether client may be tried now, claiming the probe slot of
+// a circuit whose cool-down has ended. Every allowed attempt must be
+// followed by record.
+func (b *clientBreaker) allow(client string) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := b.clients[client]
+	if st == nil || st.openUntil.IsZero() {
+		return true
+	}
+	if b.now().Before(st.openUntil) || st.probing {
+		return false
+	}
+	st.probing = true
+	return true
+}
+
+// record feeds the outcome of an allowed attempt back.
+func (b *clientBreaker) record(client string, outcome attemptOutcome) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := b.clients[client]
+	switch outcome {
+	case outcomeResponded:
+		delete(b.clients, client)
+	case outcomeTimedOut:
+		if st == nil {
+			st = &breakerState{}
+			b.clients[client] = st
+		}
+		st.timeouts++
+		st.probing = false
+		// A failed probe reopens the circuit straight away.
+		if st.timeouts >= b.cfg.Threshold || !st.openUntil.IsZero() {
+			st.openUntil = b.now().Add(b.cfg.Cooldown)
+		}
+	default:
+		if st != nil {
+			st.probing = false
+		}
+	}
+}
+
+// filter splits clients into those allowed now and those skipped with an
+// open circuit. When every client is open, all of them are allowed: a slow
+// answer beats none.
+func (b *clientBreaker) filter(clients []innertube.ClientProfile) (allowed []innertube.ClientProfile, skipped []string) {
+	if b == nil {
+		return clients, nil
+	}
+	for _, c := range clients {
+		if b.allow(profileIDOrName(c)) {
+			allowed = append(allowed, c)
+		} else {
+			skipped = append(skipped, profileIDOrName(c))
+		}
+	}
+	if len(allowed) == 0 {
+		return clients, nil
+	}
+	return allowed, skipped
+}
@@ -0,0 +1,166 @@
+package orchestrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/innertube"
+)
+
+func TestClientBreaker_OpensProbesAndCloses(t *testing.T) {
+	b := newClientBreaker(innertube.CircuitBreakerConfig{Threshold: 2, Cooldown: time.Minute})
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	b.record("tv", outcomeTimedOut)
+	b.record("tv", outcomeResponded)
+	b.record("tv", outcomeTimedOut)
+	if !b.allow("tv") {
+		t.Fatal("an answer in between should reset the timeout run")
+	}
+	b.record("tv", outcomeTimedOut)
+	if b.allow("tv") {
+		t.Fatal("circuit not open after 2 consecutive timeouts")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow("tv") {
+		t.Fatal("no probe allowed after the cool-down")
+	}
+	if b.allow("tv") {
+		t.Fatal("second probe allowed while the first is in flight")
+	}
+	b.record("tv", outcomeTimedOut)
+	if b.allow("tv") {
+		t.Fatal("failed probe did not reopen the circuit")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow("tv") {
+		t.Fatal("no probe allowed after the second cool-down")
+	}
+	b.record("tv", outcomeAbandoned)
+	if !b.allow("tv") {
+		t.Fatal("abandoned probe kept the probe slot")
+	}
+	b.record("tv", outcomeResponded)
+	if !b.allow("tv") || !b.allow("tv") {
+		t.Fatal("answered probe did not close the circuit")
+	}
+}
+
+func TestClientBreaker_AllOpenTriesEveryClient(t *testing.T) {
+	b := newClientBreaker(innertube.CircuitBreakerConfig{Threshold: 1})
+	b.record("web", outcomeTimedOut)
+	b.record("mweb", outcomeTimedOut)
+	clients := []innertube.ClientProfile{innertube.WebClient, innertube.MWebClient}
+	clients[0].ID, clients[1].ID = "web", "mweb"
+
+	allowed, skipped := b.filter(clients)
+	if len(allowed) != 2 || len(skipped) != 0 {
+		t.Fatalf("allowed=%d skipped=%v, want every client when all are open", len(allowed), skipped)
+	}
+	b.record("mweb", outcomeResponded)
+	allowed, skipped = b.filter(clients)
+	if len(allowed) != 1 || profileIDOrName(allowed[0]) != "mweb" || len(skipped) != 1 || skipped[0] != "web" {
+		t.Fatalf("allowed=%v skipped=%v", allowed, skipped)
+	}
+}
+
+func TestEngineClientTimeoutTripsBreaker(t *testing.T) {
+	web := innertube.WebClient
+	web.ID = "web"
+	mweb := innertube.MWebClient
+	mweb.ID = "mweb"
+	var webCalls atomic.Int32
+
+	tr := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"clientName":"WEB"`) {
+			webCalls.Add(1)
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(`{"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"jNQXAC9IVRw","title":"ok","author":"yt"}}`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	var mu sync.Mutex
+	var skips []string
+	engine := NewEngine(
+		selectorStub{clients: []innertube.ClientProfile{web, mweb}},
+		innertube.Config{
+			HTTPClient:               &http.Client{Transport: tr},
+			DisableFallbackClients:   true,
+			DisableWatchPageFallback: true,
+			MetadataTransport:        innertube.MetadataTransportConfig{MaxRetries: 0},
+			ClientTimeout:            50 * time.Millisecond,
+			ClientCircuitBreaker:     innertube.CircuitBreakerConfig{Threshold: 1, Cooldown: time.Hour},
+			OnExtractionEvent: func(evt innertube.ExtractionEvent) {
+				if evt.Phase == "skip" {
+					mu.Lock()
+					skips = append(skips, evt.Client)
+					mu.Unlock()
+				}
+			},
+		},
+	)
+	now := time.Now()
+	engine.breaker.now = func() time.Time { return now }
+
+	resp, err := engine.GetVideoInfo(context.Background(), "jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("GetVideoInfo() error = %v", err)
+	}
+	if resp.SourceClient != "mweb" || len(resp.FailedAttempts) != 1 || !strings.Contains(resp.FailedAttempts[0].Error, "timeout") {
+		t.Fatalf("source=%s attempts=%+v, want mweb after a web timeout", resp.SourceClient, resp.FailedAttempts)
+	}
+
+	if _, err := engine.GetVideoInfo(context.Background(), "jNQXAC9IVRw"); err != nil {
+		t.Fatalf("second GetVideoInfo() error = %v", err)
+	}
+	if webCalls.Load() != 1 || len(skips) != 1 || skips[0] != "web" {
+		t.Fatalf("web calls=%d skips=%v, want web skipped while open", webCalls.Load(), skips)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := engine.GetVideoInfo(context.Background(), "jNQXAC9IVRw"); err != nil {
+		t.Fatalf("third GetVideoInfo() error = %v", err)
+	}
+	if webCalls.Load() != 2 {
+		t.Fatalf("web calls=%d, want a probe after the cool-down", webCalls.Load())
+	}
+}
+
+func TestEngineClientTimeoutError(t *testing.T) {
+	tr := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	})
+	engine := NewEngine(
+		selectorStub{clients: []innertube.ClientProfile{innertube.WebClient}},
+		innertube.Config{
+			HTTPClient:               &http.Client{Transport: tr},
+			DisableFallbackClients:   true,
+			DisableWatchPageFallback: true,
+			ClientTimeout:            20 * time.Millisecond,
+		},
+	)
+	_, err := engine.GetVideoInfo(context.Background(), "jNQXAC9IVRw")
+	var all *AllClientsFailedError
+	var timeoutErr *ClientTimeoutError
+	if !errors.As(err, &all) || len(all.Attempts) != 1 || !errors.As(all.Attempts[0].Err, &timeoutErr) || timeoutErr.Timeout != 20*time.Millisecond {
+		t.Fatalf("GetVideoInfo() error = %v, want a ClientTimeoutError attempt", err)
+	}
+}
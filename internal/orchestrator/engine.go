@@ -3,9 +3,7 @@ package orchestrator
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
-	"io"
 	"net/http"
 	neturl "net/url"
 	"strconv"
@@ -13,22 +11,28 @@ import (
 	"sync"
 	"time"
 
+	"github.com/famomatic/ytv1/internal/httpx"
 	"github.com/famomatic/ytv1/internal/innertube"
 	"github.com/famomatic/ytv1/internal/policy"
 	"github.com/famomatic/ytv1/internal/types"
 )
 
+// watchPageClient labels the watch-page fallback in attempts and events.
+const watchPageClient = "webpage"
+
 // Engine is the main orchestrator for video extraction.
 type Engine struct {
 	selector       policy.Selector
 	config         innertube.Config
 	apiKeyResolver *innertube.APIKeyResolver
+	breaker        *clientBreaker
 }
 
 func NewEngine(selector policy.Selector, config innertube.Config) *Engine {
 	engine := &Engine{
 		selector: selector,
 		config:   config,
+		breaker:  newClientBreaker(config.ClientCircuitBreaker),
 	}
 	if config.EnableDynamicAPIKeyResolution {
 		engine.apiKeyResolver = innertube.NewAPIKeyResolver(config.HTTPClient)
@@ -58,20 +62,88 @@ func (e *Engine) GetVideoInfo(ctx context.Context, videoID string) (*innertube.P
 	}
 
 	primary, fallback := splitClientPhases(clients)
+	primary = e.admitClients(primary)
+
+	var attempts []AttemptError
+	if e.config.ClassifyProbe && len(primary) > 1 {
+		probe := primary[:1]
+		resp, probeAttempts := e.tryPhase(ctx, videoID, probe)
+		if resp != nil {
+			return resp, nil
+		}
+		attempts = probeAttempts
+		class := classifyProbe(probeAttempts)
+		e.emitExtractionEvent("classification_probe", "success", profileIDOrName(probe[0]), "class="+string(class))
+		switch class {
+		case videoClassGone:
+			return nil, &AllClientsFailedError{Attempts: attempts}
+		case videoClassAgeRestricted:
+			if len(fallback) > 0 {
+				primary = e.ageGateClients(primary[1:])
+				break
+			}
+			primary = primary[1:]
+		default:
+			primary = primary[1:]
+		}
+	}
 
-	resp, attempts := e.tryPhase(ctx, videoID, primary)
+	resp, phaseAttempts := e.tryPhase(ctx, videoID, primary)
 	if resp != nil {
+		if len(attempts) > 0 {
+			resp.FailedAttempts = append(clientAttempts(attempts), resp.FailedAttempts...)
+		}
 		return resp, nil
 	}
+	attempts = append(attempts, phaseAttempts...)
 
 	if len(fallback) > 0 && shouldRunFallbackPhase(attempts) {
-		fallbackResp, fallbackAttempts := e.tryPhase(ctx, videoID, fallback)
+		fallbackResp, fallbackAttempts := e.tryPhase(ctx, videoID, e.admitClients(fallback))
 		if fallbackResp != nil {
+			fallbackResp.FailedAttempts = append(clientAttempts(attempts), fallbackResp.FailedAttempts...)
 			return fallbackResp, nil
 		}
 		attempts = append(attempts, fallbackAttempts...)
 	}
 
+	if !e.config.DisableWatchPageFallback && shouldRunWatchPageFallback(ctx, attempts) {
+		watchResp, err := e.fetchWatchPage(ctx, videoID)
+		if err == nil {
+			watchResp.FailedAttempts = clientAttempts(attempts)
+			return watchResp, nil
+		}
+		attempts = append(attempts, AttemptError{Client: watchPageClient, Err: err})
+	}
+
+	if len(attempts) > 0 {
+		return nil, &AllClientsFailedError{Attempts: attempts}
+	}
+	return nil, types.ErrNoClientsAvailable
+}
+
+// GetVideoInfoWithClients fetches video info using only the named registry
+// clients, bypassing policy order and the fallback phase. It is used to
+// re-extract stream URLs through a specific client.
+func (e *Engine) GetVideoInfoWithClients(ctx context.Context, videoID string, names []string) (*innertube.PlayerResponse, error) {
+	ctx, cancel := withRequestTimeout(ctx, e.config.RequestTimeout)
+	defer cancel()
+
+	var clients []innertube.ClientProfile
+	if registry := e.selector.Registry(); registry != nil {
+		for _, name := range names {
+			if p, ok := registry.Get(strings.ToLower(strings.TrimSpace(name))); ok {
+				clients = append(clients, p)
+			}
+		}
+	}
+	if len(clients) == 0 {
+		return nil, types.ErrNoClientsAvailable
+	}
+
+	resp, attempts := e.tryPhase(ctx, videoID, clients)
+	if resp != nil {
+		return resp, nil
+	}
 	if len(attempts) > 0 {
 		return nil, &AllClientsFailedError{Attempts: attempts}
 	}
@@ -93,7 +165,11 @@ func (e *Engine) tryPhase(ctx context.Context, videoID string, clients []innertu
 		wg.Add(1)
 		go func(order int, p innertube.ClientProfile) {
 			defer wg.Done()
+			p = p.WithUserAgent(e.config.MetadataUserAgent, e.config.MetadataUserAgents)
+			p = p.WithAPIHost(e.config.APIHost, e.config.APIHosts)
 			clientLabel := profileIDOrName(p)
+			outcome := outcomeAbandoned
+			defer func() { e.breaker.record(clientLabel, outcome) }()
 
 			if order > 0 && e.config.ClientHedgeDelay > 0 {
 				timer := time.NewTimer(time.Duration(order) * e.config.ClientHedgeDelay)
@@ -110,20 +186,45 @@ func (e *Engine) tryPhase(ctx context.Context, videoID string, clients []innertu
 			}
 			e.emitExtractionEvent("player_api_json", "start", clientLabel, "")
 
+			attemptCtx, cancelAttempt := withClientTimeout(ctx, e.config.ClientTimeout)
+			defer cancelAttempt()
+			if version := e.resolveClientVersion(attemptCtx, p, videoID); version != "" {
+				p.Version = version
+			}
+			sts := e.resolveSignatureTimestamp(attemptCtx, p, videoID)
 			req := innertube.NewPlayerRequest(p, videoID, innertube.PlayerRequestOptions{
-				VisitorData:        e.resolveVisitorData(ctx, p, videoID),
-				SignatureTimestamp: e.resolveSignatureTimestamp(ctx, p, videoID),
+				VisitorData:        e.resolveVisitorData(attemptCtx, p, videoID),
+				SignatureTimestamp: sts,
 				UseAdPlayback:      e.config.UseAdPlaybackContext && p.SupportsAdPlaybackContext,
 				PlayerParams:       strings.TrimSpace(p.PlayerParams),
+				Language:           e.config.MetadataLanguage,
 			})
-			if err := e.applyPoToken(ctx, req, p); err != nil {
+			if err := e.applyPoToken(attemptCtx, req, p); err != nil {
 				select {
 				case results <- extractionResult{response: nil, err: err, client: clientLabel, order: order}:
 				case <-ctx.Done():
 				}
 				return
 			}
-			resp, err := e.fetch(ctx, req, p, videoID)
+			resp, err := e.fetch(attemptCtx, req, p, videoID)
+			if rejectsVisitorData(err) {
+				if fresh := e.refreshVisitorData(attemptCtx, p, videoID); fresh != "" && fresh != req.Context.Client.VisitorData {
+					e.emitExtractionEvent("player_api_json", "retry", clientLabel, "refreshed visitor data: "+err.Error())
+					req.Context.Client.VisitorData = fresh
+					resp, err = e.fetch(attemptCtx, req, p, videoID)
+				}
+			}
+			if resp != nil {
+				resp.SignatureTimestamp = sts
+			}
+			switch {
+			case ctx.Err() != nil:
+			case errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && err != nil:
+				outcome = outcomeTimedOut
+				err = &ClientTimeoutError{Client: clientLabel, Timeout: e.config.ClientTimeout}
+			default:
+				outcome = outcomeResponded
+			}
 
 			select {
 			case results <- extractionResult{response: resp, err: err, client: clientLabel, order: order}:
@@ -167,6 +268,7 @@ func (e *Engine) tryPhase(ctx context.Context, videoID string, clients []innertu
 
 			if current.err == nil {
 				current.resp.SourceClient = current.client
+				current.resp.FailedAttempts = clientAttempts(attempts)
 				e.emitExtractionEvent("player_api_json", "success", current.client, "")
 				cancel()
 				return current.resp, attempts
@@ -181,6 +283,27 @@ func (e *Engine) tryPhase(ctx context.Context, videoID string, clients []innertu
 	return nil, attempts
 }
 
+// admitClients drops clients whose circuit is open, reporting each as a
+// "skip" extraction event.
+func (e *Engine) admitClients(clients []innertube.ClientProfile) []innertube.ClientProfile {
+	allowed, skipped := e.breaker.filter(clients)
+	for _, name := range skipped {
+		e.emitExtractionEvent("player_api_json", "skip", name, "circuit open after repeated timeouts")
+	}
+	return allowed
+}
+
+func clientAttempts(attempts []AttemptError) []innertube.ClientAttempt {
+	if len(attempts) == 0 {
+		return nil
+	}
+	out := make([]innertube.ClientAttempt, len(attempts))
+	for i, a := range attempts {
+		out[i] = innertube.ClientAttempt{Client: a.Client, Error: a.Err.Error()}
+	}
+	return out
+}
+
 func (e *Engine) withFallbackClients(clients []innertube.ClientProfile) []innertube.ClientProfile {
 	if len(clients) == 0 {
 		return clients
@@ -372,6 +495,58 @@ func profileIDOrName(c innertube.ClientProfile) string {
 	return c.Name
 }
 
+// videoClass is what a classification probe learned about a video from one
+// client's playability status.
+type videoClass string
+
+const (
+	// videoClassUnknown covers playable-elsewhere failures (PO tokens, HTTP
+	// errors, login walls): every remaining client is still worth a try.
+	videoClassUnknown videoClass = "unknown"
+	// videoClassAgeRestricted videos only play, without an account, through
+	// the embedded and TV clients.
+	videoClassAgeRestricted videoClass = "age_restricted"
+	// videoClassGone videos are deleted or blocked in this region; no other
+	// client can play them.
+	videoClassGone videoClass = "unavailable"
+)
+
+// classifyProbe classifies the video from the probe's failed attempt.
+func classifyProbe(attempts []AttemptError) videoClass {
+	for _, attempt := range attempts {
+		var pErr *PlayabilityError
+		if !errors.As(attempt.Err, &pErr) {
+			continue
+		}
+		status := strings.ToUpper(strings.TrimSpace(pErr.Status))
+		switch {
+		case status == "AGE_CHECK_REQUIRED" || pErr.IsAgeRestricted():
+			return videoClassAgeRestricted
+		case pErr.IsGeoRestricted():
+			return videoClassGone
+		case status == "ERROR" && pErr.IsUnavailable():
+			return videoClassGone
+		}
+	}
+	return videoClassUnknown
+}
+
+// ageGateClients keeps the primary clients that can get past an age gate:
+// with a cookie jar, signed-in clients that send cookies; without one, none,
+// leaving the embedded and TV fallback clients.
+func (e *Engine) ageGateClients(primary []innertube.ClientProfile) []innertube.ClientProfile {
+	if e.config.HTTPClient == nil || e.config.HTTPClient.Jar == nil {
+		return nil
+	}
+	var out []innertube.ClientProfile
+	for _, c := range primary {
+		if c.SupportsCookies {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
 func shouldRunFallbackPhase(attempts []AttemptError) bool {
 	for _, attempt := range attempts {
 		var pErr *PlayabilityError
@@ -390,10 +565,94 @@ func shouldRunFallbackPhase(attempts []AttemptError) bool {
 	return false
 }
 
+// shouldRunWatchPageFallback reports whether every client failed at the HTTP
+// level (error status or transport error), which points at API breakage
+// rather than at the video. Playability and PO-token failures would repeat on
+// the watch page.
+func shouldRunWatchPageFallback(ctx context.Context, attempts []AttemptError) bool {
+	if len(attempts) == 0 || ctx.Err() != nil {
+		return false
+	}
+	for _, attempt := range attempts {
+		var httpErr *HTTPStatusError
+		if errors.As(attempt.Err, &httpErr) {
+			continue
+		}
+		var timeoutErr *ClientTimeoutError
+		if errors.As(attempt.Err, &timeoutErr) {
+			continue
+		}
+		var urlErr *neturl.Error
+		if errors.As(attempt.Err, &urlErr) && !errors.Is(attempt.Err, context.Canceled) && !errors.Is(attempt.Err, context.DeadlineExceeded) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// fetchWatchPage scrapes the player response embedded in the desktop watch
+// page, the last resort when the player API is unreachable for every client.
+func (e *Engine) fetchWatchPage(ctx context.Context, videoID string) (*innertube.PlayerResponse, error) {
+	e.emitExtractionEvent("watch_page_fallback", "start", watchPageClient, "")
+	resp, err := e.fetchWatchPageOnce(ctx, videoID)
+	if err != nil {
+		e.emitExtractionEvent("watch_page_fallback", "failure", watchPageClient, err.Error())
+		return nil, err
+	}
+	e.emitExtractionEvent("watch_page_fallback", "success", watchPageClient, "")
+	return resp, nil
+}
+
+func (e *Engine) fetchWatchPageOnce(ctx context.Context, videoID string) (*innertube.PlayerResponse, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, innertube.WatchPageURL(videoID), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("User-Agent", firstNonEmpty(e.config.WatchPageUserAgent, innertube.WebClient.UserAgent))
+	httpReq.Header.Set("Accept-Language", innertube.AcceptLanguageHeader(e.config.MetadataLanguage))
+	httpx.AcceptCompressed(httpReq)
+	for k, values := range e.config.RequestHeaders {
+		for _, val := range values {
+			httpReq.Header.Add(k, val)
+		}
+	}
+
+	resp, err := e.config.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPStatusError{Client: watchPageClient, StatusCode: resp.StatusCode}
+	}
+	body, err := httpx.ReadBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := innertube.ExtractInitialPlayerResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	playerResp, drift, err := innertube.DecodePlayerResponse(raw)
+	if err != nil {
+		e.emitResponseAnomaly(watchPageClient, videoID, "player_response_parse: "+err.Error(), raw)
+		return nil, err
+	}
+	e.emitSchemaDrift(watchPageClient, videoID, drift)
+	if err := playabilityError(watchPageClient, playerResp); err != nil {
+		return nil, err
+	}
+	playerResp.SourceClient = watchPageClient
+	playerResp.PlayerURL = innertube.ExtractWatchPagePlayerURL(body)
+	return playerResp, nil
+}
+
 func (e *Engine) fetch(ctx context.Context, req *innertube.PlayerRequest, profile innertube.ClientProfile, videoID string) (*innertube.PlayerResponse, error) {
 	// Construct URL
 	apiKey := e.resolveAPIKey(ctx, profile, videoID)
-	url := "https://" + profile.Host + "/youtubei/v1/player"
+	url := profile.APIURL("/youtubei/v1/player")
 	if apiKey != "" {
 		url += "?key=" + neturl.QueryEscape(apiKey)
 	}
@@ -412,6 +671,7 @@ func (e *Engine) fetch(ctx context.Context, req *innertube.PlayerRequest, profil
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("User-Agent", profile.UserAgent)
+	httpx.AcceptCompressed(httpReq)
 	origin := "https://" + profile.Host
 	httpReq.Header.Set("Origin", origin)
 	httpReq.Header.Set("X-Origin", origin)
@@ -427,10 +687,10 @@ func (e *Engine) fetch(ctx context.Context, req *innertube.PlayerRequest, profil
 	}
 	if profile.SupportsCookies {
 		cookieAuth := innertube.BuildCookieAuthHeaders(e.config.HTTPClient, profile.Host, time.Now(), e.resolveCookieAuthContext(ctx, profile, videoID))
+		// Auth headers replace the defaults above: X-Origin must name the
+		// origin the SAPISIDHASH was computed for.
 		for k, values := range cookieAuth {
-			for _, val := range values {
-				httpReq.Header.Add(k, val)
-			}
+			httpReq.Header[k] = values
 		}
 	}
 	// Add other headers from profile
@@ -450,7 +710,10 @@ func (e *Engine) fetch(ctx context.Context, req *innertube.PlayerRequest, profil
 	metaCfg := normalizeMetadataTransportConfig(e.config.MetadataTransport)
 	var lastErr error
 	for attempt := 0; attempt <= metaCfg.MaxRetries; attempt++ {
-		playerResp, err := e.fetchOnce(ctx, httpReq, profile)
+		if err := e.config.QuotaGuard.Wait(ctx, profileIDOrName(profile)); err != nil {
+			return nil, err
+		}
+		playerResp, err := e.fetchOnce(ctx, httpReq, profile, videoID)
 		if err == nil {
 			return playerResp, nil
 		}
@@ -458,7 +721,11 @@ func (e *Engine) fetch(ctx context.Context, req *innertube.PlayerRequest, profil
 		if !isRetryableMetadataError(err, metaCfg) || attempt == metaCfg.MaxRetries {
 			return nil, err
 		}
-		if err := waitMetadataBackoff(ctx, metaCfg.backoffFor(attempt)); err != nil {
+		wait, ok := httpx.AllowRetry(ctx, metaCfg.retryWait(attempt, err))
+		if !ok {
+			return nil, err
+		}
+		if err := waitMetadataBackoff(ctx, wait); err != nil {
 			return nil, err
 		}
 	}
@@ -491,6 +758,15 @@ func (e *Engine) resolveVisitorData(ctx context.Context, profile innertube.Clien
 	return ""
 }
 
+// refreshVisitorData fetches the client's page again for new visitor
+// data, bypassing the configured value, the cookie jar and the cache.
+func (e *Engine) refreshVisitorData(ctx context.Context, profile innertube.ClientProfile, videoID string) string {
+	if e.apiKeyResolver == nil {
+		return ""
+	}
+	return e.apiKeyResolver.RefreshVisitorData(ctx, profile, videoID)
+}
+
 func (e *Engine) resolveCookieAuthContext(ctx context.Context, profile innertube.ClientProfile, videoID string) innertube.CookieAuthContext {
 	if e.apiKeyResolver == nil {
 		return innertube.CookieAuthContext{}
@@ -498,6 +774,13 @@ func (e *Engine) resolveCookieAuthContext(ctx context.Context, profile innertube
 	return e.apiKeyResolver.ResolveCookieAuthContext(ctx, profile, videoID)
 }
 
+func (e *Engine) resolveClientVersion(ctx context.Context, profile innertube.ClientProfile, videoID string) string {
+	if e.apiKeyResolver == nil {
+		return ""
+	}
+	return e.apiKeyResolver.ResolveClientVersion(ctx, profile, videoID)
+}
+
 func (e *Engine) resolveSignatureTimestamp(ctx context.Context, profile innertube.ClientProfile, videoID string) int {
 	if e.apiKeyResolver == nil {
 		return 0
@@ -505,7 +788,7 @@ func (e *Engine) resolveSignatureTimestamp(ctx context.Context, profile innertub
 	return e.apiKeyResolver.ResolveSignatureTimestamp(ctx, profile, videoID)
 }
 
-func (e *Engine) fetchOnce(ctx context.Context, template *http.Request, profile innertube.ClientProfile) (*innertube.PlayerResponse, error) {
+func (e *Engine) fetchOnce(ctx context.Context, template *http.Request, profile innertube.ClientProfile, videoID string) (*innertube.PlayerResponse, error) {
 	httpReq := template.Clone(ctx)
 	httpReq.Body, _ = template.GetBody()
 
@@ -519,29 +802,54 @@ func (e *Engine) fetchOnce(ctx context.Context, template *http.Request, profile
 		return nil, &HTTPStatusError{
 			Client:     profile.Name,
 			StatusCode: resp.StatusCode,
+			RetryAfter: httpx.ParseRetryAfter(resp.Header.Get("Retry-After")),
 		}
 	}
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := httpx.ReadBody(resp)
 	if err != nil {
 		return nil, err
 	}
 
-	var playerResp innertube.PlayerResponse
-	if err := json.Unmarshal(respBody, &playerResp); err != nil {
+	playerResp, drift, err := innertube.DecodePlayerResponse(respBody)
+	if err != nil {
+		e.emitResponseAnomaly(profileIDOrName(profile), videoID, "player_response_parse: "+err.Error(), respBody)
+		return nil, err
+	}
+	e.emitSchemaDrift(profileIDOrName(profile), videoID, drift)
+	if playerResp.PlayabilityStatus.IsOK() && !hasStreamingData(playerResp) {
+		e.emitResponseAnomaly(profileIDOrName(profile), videoID, "player_response_no_formats", respBody)
+	}
+	if err := playabilityError(profile.Name, playerResp); err != nil {
 		return nil, err
 	}
+	return playerResp, nil
+}
 
-	if !playerResp.PlayabilityStatus.IsOK() && !playerResp.PlayabilityStatus.IsLive() {
-		detail := extractPlayabilityDetail(&playerResp)
-		return nil, &PlayabilityError{
-			Client: profile.Name,
-			Status: playerResp.PlayabilityStatus.Status,
-			Reason: playerResp.PlayabilityStatus.Reason,
-			Detail: detail,
-		}
+// playabilityError returns a PlayabilityError for responses that cannot be
+// played. Upcoming streams and premieres are returned so callers see the
+// schedule and any trailer; they simply have no formats yet.
+func playabilityError(client string, playerResp *innertube.PlayerResponse) error {
+	if playerResp.PlayabilityStatus.IsOK() || playerResp.PlayabilityStatus.IsLive() || playerResp.PlayabilityStatus.IsUpcoming() {
+		return nil
+	}
+	return &PlayabilityError{
+		Client: client,
+		Status: playerResp.PlayabilityStatus.Status,
+		Reason: playerResp.PlayabilityStatus.Reason,
+		Detail: extractPlayabilityDetail(playerResp),
+	}
+}
+
+// rejectsVisitorData reports whether err is the playability status YouTube
+// returns when the visitor data does not fit the client ("This content
+// isn't available on this app"), which new visitor data usually fixes.
+func rejectsVisitorData(err error) bool {
+	var playErr *PlayabilityError
+	if !errors.As(err, &playErr) {
+		return false
 	}
-	return &playerResp, nil
+	return strings.Contains(strings.ToLower(playErr.Reason+" "+playErr.Detail.Subreason), "available on this app")
 }
 
 func extractPlayabilityDetail(resp *innertube.PlayerResponse) PlayabilityDetail {
@@ -648,22 +956,22 @@ func normalizeMetadataTransportConfig(cfg innertube.MetadataTransportConfig) eff
 	}
 }
 
-func (c effectiveMetadataTransportConfig) backoffFor(attempt int) time.Duration {
-	backoff := c.InitialBackoff
-	for i := 0; i < attempt; i++ {
-		backoff *= 2
-		if backoff > c.MaxBackoff {
-			return c.MaxBackoff
-		}
+// retryWait returns the jittered backoff before retry attempt, or the
+// server's Retry-After when err carries a longer one.
+func (c effectiveMetadataTransportConfig) retryWait(attempt int, err error) time.Duration {
+	wait := httpx.Backoff(c.InitialBackoff, c.MaxBackoff, attempt)
+	var httpErr *HTTPStatusError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > wait {
+		wait = httpErr.RetryAfter
 	}
-	return backoff
+	return wait
 }
 
 func isRetryableMetadataError(err error, cfg effectiveMetadataTransportConfig) bool {
 	if err == nil {
 		return false
 	}
-	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || httpx.IsPermanent(err) {
 		return false
 	}
 	var httpErr *HTTPStatusError
@@ -703,6 +1011,44 @@ func withRequestTimeout(ctx context.Context, timeout time.Duration) (context.Con
 	return context.WithTimeout(ctx, timeout)
 }
 
+// withClientTimeout bounds one client attempt. Unlike withRequestTimeout it
+// also applies under an existing, longer deadline.
+func withClientTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func (e *Engine) emitResponseAnomaly(client, videoID, reason string, body []byte) {
+	if e == nil || e.config.OnResponseAnomaly == nil {
+		return
+	}
+	e.config.OnResponseAnomaly(innertube.ResponseAnomaly{
+		Client:  client,
+		VideoID: videoID,
+		Reason:  reason,
+		Body:    body,
+	})
+}
+
+func (e *Engine) emitSchemaDrift(client, videoID string, drift []innertube.SchemaDrift) {
+	if e == nil || e.config.OnSchemaDrift == nil {
+		return
+	}
+	for _, d := range drift {
+		d.Client = client
+		d.VideoID = videoID
+		e.config.OnSchemaDrift(d)
+	}
+}
+
+// hasStreamingData reports whether resp lists any format or manifest.
+func hasStreamingData(resp *innertube.PlayerResponse) bool {
+	sd := resp.StreamingData
+	return len(sd.Formats) > 0 || len(sd.AdaptiveFormats) > 0 || sd.DashManifestURL != "" || sd.HlsManifestURL != ""
+}
+
 func (e *Engine) emitExtractionEvent(stage, phase, client, detail string) {
 	if e == nil || e.config.OnExtractionEvent == nil {
 		return
@@ -2,8 +2,11 @@ package orchestrator
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/cookiejar"
@@ -124,6 +127,161 @@ func TestEngineSkipsFallbackOnHTTPFailureOnly(t *testing.T) {
 	}
 }
 
+func TestEngineFallsBackToWatchPageOnHTTPFailures(t *testing.T) {
+	mweb := innertube.MWebClient
+	var watchURL string
+	tr := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/watch" {
+			watchURL = r.URL.String()
+			page := `<html><script>var ytcfg = {"PLAYER_JS_URL":"/s/player/abc/player_ias.vflset/en_US/base.js"};` +
+				`var ytInitialPlayerResponse = {"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"jNQXAC9IVRw","title":"from page"}};</script></html>`
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(page)), Header: make(http.Header)}, nil
+		}
+		return &http.Response{StatusCode: http.StatusForbidden, Body: io.NopCloser(strings.NewReader(`forbidden`)), Header: make(http.Header)}, nil
+	})
+
+	engine := NewEngine(
+		selectorStub{clients: []innertube.ClientProfile{mweb}},
+		innertube.Config{HTTPClient: &http.Client{Transport: tr}, DisableFallbackClients: true},
+	)
+	resp, err := engine.GetVideoInfo(context.Background(), "jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("GetVideoInfo() error = %v", err)
+	}
+	if !strings.Contains(watchURL, "v=jNQXAC9IVRw") {
+		t.Fatalf("watch page URL = %q", watchURL)
+	}
+	if resp.VideoDetails.Title != "from page" || resp.SourceClient != "webpage" {
+		t.Fatalf("response title=%q source=%q", resp.VideoDetails.Title, resp.SourceClient)
+	}
+	if resp.PlayerURL != "/s/player/abc/player_ias.vflset/en_US/base.js" {
+		t.Fatalf("PlayerURL = %q", resp.PlayerURL)
+	}
+	if len(resp.FailedAttempts) != 1 || resp.FailedAttempts[0].Client != "mweb" {
+		t.Fatalf("FailedAttempts = %+v", resp.FailedAttempts)
+	}
+
+	watchURL = ""
+	disabled := NewEngine(
+		selectorStub{clients: []innertube.ClientProfile{mweb}},
+		innertube.Config{HTTPClient: &http.Client{Transport: tr}, DisableFallbackClients: true, DisableWatchPageFallback: true},
+	)
+	if _, err := disabled.GetVideoInfo(context.Background(), "jNQXAC9IVRw"); err == nil || watchURL != "" {
+		t.Fatalf("DisableWatchPageFallback: error = %v, watch URL = %q", err, watchURL)
+	}
+}
+
+func TestEngineSkipsWatchPageOnPlayabilityFailure(t *testing.T) {
+	var watchCalls int32
+	tr := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/watch" {
+			atomic.AddInt32(&watchCalls, 1)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"playabilityStatus":{"status":"ERROR","reason":"Video unavailable"}}`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	engine := NewEngine(
+		selectorStub{clients: []innertube.ClientProfile{innertube.MWebClient}},
+		innertube.Config{HTTPClient: &http.Client{Transport: tr}, DisableFallbackClients: true},
+	)
+	var allFailed *AllClientsFailedError
+	if _, err := engine.GetVideoInfo(context.Background(), "jNQXAC9IVRw"); !errors.As(err, &allFailed) || len(allFailed.Attempts) != 1 {
+		t.Fatalf("GetVideoInfo() error = %v, want one failed attempt", err)
+	}
+	if atomic.LoadInt32(&watchCalls) != 0 {
+		t.Fatalf("watch page fetched %d times after a playability failure", watchCalls)
+	}
+}
+
+func TestEngineUsesClientVersionFromPage(t *testing.T) {
+	var payload, header string
+	tr := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method == http.MethodGet {
+			page := `<script>ytcfg.set({"INNERTUBE_API_KEY":"page_key","INNERTUBE_CONTEXT_CLIENT_NAME":1,"INNERTUBE_CLIENT_VERSION":"2.20991231.01.00"});</script>`
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(page)), Header: make(http.Header)}, nil
+		}
+		body, _ := io.ReadAll(r.Body)
+		payload, header = string(body), r.Header.Get("X-YouTube-Client-Version")
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"jNQXAC9IVRw"}}`)),
+			Header:     make(http.Header),
+		}, nil
+	})
+
+	engine := NewEngine(
+		selectorStub{clients: []innertube.ClientProfile{innertube.WebClient}},
+		innertube.Config{HTTPClient: &http.Client{Transport: tr}, EnableDynamicAPIKeyResolution: true, DisableFallbackClients: true},
+	)
+	if _, err := engine.GetVideoInfo(context.Background(), "jNQXAC9IVRw"); err != nil {
+		t.Fatalf("GetVideoInfo() error = %v", err)
+	}
+	if !strings.Contains(payload, `"clientVersion":"2.20991231.01.00"`) || header != "2.20991231.01.00" {
+		t.Fatalf("client version not taken from the watch page: header=%q payload=%s", header, payload)
+	}
+}
+
+func TestEngineRefreshesVisitorDataOnAppMismatch(t *testing.T) {
+	var pages, players int32
+	var sent []string
+	var mu sync.Mutex
+	tr := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method == http.MethodGet {
+			n := atomic.AddInt32(&pages, 1)
+			page := fmt.Sprintf(`<script>ytcfg.set({"INNERTUBE_API_KEY":"page_key","VISITOR_DATA":"visitor_%d"});</script>`, n)
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(page)), Header: make(http.Header)}, nil
+		}
+		atomic.AddInt32(&players, 1)
+		var req innertube.PlayerRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		sent = append(sent, req.Context.Client.VisitorData)
+		mu.Unlock()
+		body := `{"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"jNQXAC9IVRw"}}`
+		if req.Context.Client.VisitorData == "visitor_1" {
+			body = `{"playabilityStatus":{"status":"UNPLAYABLE","reason":"This content isn’t available on this app."}}`
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: make(http.Header)}, nil
+	})
+
+	var retries int32
+	engine := NewEngine(
+		selectorStub{clients: []innertube.ClientProfile{innertube.WebClient}},
+		innertube.Config{
+			HTTPClient:                    &http.Client{Transport: tr},
+			EnableDynamicAPIKeyResolution: true,
+			DisableFallbackClients:        true,
+			DisableWatchPageFallback:      true,
+			OnExtractionEvent: func(evt innertube.ExtractionEvent) {
+				if evt.Stage == "player_api_json" && evt.Phase == "retry" {
+					atomic.AddInt32(&retries, 1)
+				}
+			},
+		},
+	)
+	resp, err := engine.GetVideoInfo(context.Background(), "jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("GetVideoInfo() error = %v (visitor data sent %v, %d page fetches)", err, sent, pages)
+	}
+	if len(resp.FailedAttempts) != 0 {
+		t.Fatalf("FailedAttempts = %+v, want the retry to count as the same attempt", resp.FailedAttempts)
+	}
+	if !reflect.DeepEqual(sent, []string{"visitor_1", "visitor_2"}) || pages != 2 || retries != 1 {
+		t.Fatalf("visitor data sent = %v over %d page fetches, %d retry events", sent, pages, retries)
+	}
+
+	if rejectsVisitorData(&PlayabilityError{Reason: "Video unavailable"}) {
+		t.Fatal("rejectsVisitorData matched an unrelated reason, which would be retried")
+	}
+	if !rejectsVisitorData(&PlayabilityError{Reason: "The following content is not available on this app."}) {
+		t.Fatal("rejectsVisitorData missed the app mismatch reason")
+	}
+}
+
 func TestEngineInjectsPoTokenWhenProviderConfigured(t *testing.T) {
 	web := innertube.WebClient
 	provider := &poTokenProviderStub{token: "po-token-123"}
@@ -199,6 +357,38 @@ func TestEngineInjectsVisitorDataFromConfig(t *testing.T) {
 	}
 }
 
+func TestEngineDecodesGzipPlayerResponse(t *testing.T) {
+	web := innertube.WebClient
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	_, _ = gw.Write([]byte(`{"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"jNQXAC9IVRw","title":"ok","author":"yt"}}`))
+	_ = gw.Close()
+
+	var acceptEncoding string
+	engine := NewEngine(
+		selectorStub{clients: []innertube.ClientProfile{web}},
+		innertube.Config{HTTPClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			acceptEncoding = r.Header.Get("Accept-Encoding")
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(compressed.Bytes())),
+				Header:     http.Header{"Content-Encoding": {"gzip"}},
+			}, nil
+		})}},
+	)
+
+	resp, err := engine.GetVideoInfo(context.Background(), "jNQXAC9IVRw")
+	if err != nil {
+		t.Fatalf("GetVideoInfo() error = %v", err)
+	}
+	if resp.VideoDetails.Title != "ok" {
+		t.Fatalf("title = %q, want decoded response", resp.VideoDetails.Title)
+	}
+	if !strings.Contains(acceptEncoding, "gzip") || !strings.Contains(acceptEncoding, "br") {
+		t.Fatalf("Accept-Encoding = %q, want br and gzip", acceptEncoding)
+	}
+}
+
 func TestEngineProceedsWithoutPoTokenWhenProviderMissing(t *testing.T) {
 	web := innertube.WebClient
 	var calls int32
@@ -904,3 +1094,103 @@ func TestEngineEmitsPlayerAPIEventsInDeterministicStartOrder(t *testing.T) {
 		t.Fatalf("expected at least one success event")
 	}
 }
+
+func TestEngineClassifyProbeNarrowsClients(t *testing.T) {
+	const okResponse = `{"playabilityStatus":{"status":"OK"},"videoDetails":{"videoId":"jNQXAC9IVRw","title":"ok","author":"yt"}}`
+	tests := []struct {
+		name        string
+		probe       string
+		probeStatus int
+		wantErr     bool
+		wantClass   string
+		wantClients []string
+	}{
+		{
+			name:        "playable probe answers alone",
+			probe:       okResponse,
+			wantClients: []string{"ANDROID"},
+		},
+		{
+			name:        "age gate skips to embedded clients",
+			probe:       `{"playabilityStatus":{"status":"LOGIN_REQUIRED","reason":"Sign in to confirm your age"}}`,
+			wantClass:   "class=age_restricted",
+			wantClients: []string{"ANDROID", "WEB_EMBEDDED_PLAYER"},
+		},
+		{
+			name:        "deleted video fails at once",
+			probe:       `{"playabilityStatus":{"status":"ERROR","reason":"Video unavailable"}}`,
+			wantErr:     true,
+			wantClass:   "class=unavailable",
+			wantClients: []string{"ANDROID"},
+		},
+		{
+			name:        "http failure tries the rest",
+			probeStatus: http.StatusBadRequest,
+			wantClass:   "class=unknown",
+			wantClients: []string{"ANDROID", "MWEB"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var called []string
+			tr := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(r.Body)
+				var req struct {
+					Context struct {
+						Client struct {
+							ClientName string `json:"clientName"`
+						} `json:"client"`
+					} `json:"context"`
+				}
+				_ = json.Unmarshal(body, &req)
+				name := req.Context.Client.ClientName
+				mu.Lock()
+				called = append(called, name)
+				mu.Unlock()
+				status, response := http.StatusOK, okResponse
+				if name == "ANDROID" {
+					status, response = tt.probeStatus, tt.probe
+					if status == 0 {
+						status = http.StatusOK
+					}
+				}
+				return &http.Response{
+					StatusCode: status,
+					Body:       io.NopCloser(bytes.NewBufferString(response)),
+					Header:     make(http.Header),
+				}, nil
+			})
+
+			var events []innertube.ExtractionEvent
+			engine := NewEngine(
+				selectorStub{clients: []innertube.ClientProfile{innertube.AndroidClient, innertube.MWebClient, innertube.WebEmbeddedClient}},
+				innertube.Config{
+					HTTPClient:             &http.Client{Transport: tr},
+					ClassifyProbe:          true,
+					DisableFallbackClients: true,
+					OnExtractionEvent: func(evt innertube.ExtractionEvent) {
+						events = append(events, evt)
+					},
+				},
+			)
+
+			_, err := engine.GetVideoInfo(context.Background(), "jNQXAC9IVRw")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetVideoInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got, want := strings.Join(called, ","), strings.Join(tt.wantClients, ","); got != want {
+				t.Fatalf("clients called = %s, want %s", got, want)
+			}
+			var class string
+			for _, evt := range events {
+				if evt.Stage == "classification_probe" {
+					class = evt.Detail
+				}
+			}
+			if class != tt.wantClass {
+				t.Fatalf("classification_probe detail = %q, want %q", class, tt.wantClass)
+			}
+		})
+	}
+}
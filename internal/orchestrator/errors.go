@@ -3,6 +3,7 @@ package orchestrator
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/famomatic/ytv1/internal/innertube"
 )
@@ -29,12 +30,25 @@ func (e *AllClientsFailedError) Error() string {
 type HTTPStatusError struct {
 	Client     string
 	StatusCode int
+	// RetryAfter is the response's Retry-After delay, or 0.
+	RetryAfter time.Duration
 }
 
 func (e *HTTPStatusError) Error() string {
 	return fmt.Sprintf("innertube http status=%d client=%s", e.StatusCode, e.Client)
 }
 
+// ClientTimeoutError indicates a client's player attempt ran past
+// innertube.Config.ClientTimeout.
+type ClientTimeoutError struct {
+	Client  string
+	Timeout time.Duration
+}
+
+func (e *ClientTimeoutError) Error() string {
+	return fmt.Sprintf("innertube client timeout after %s client=%s", e.Timeout, e.Client)
+}
+
 // PlayabilityError indicates an unplayable player response.
 type PlayabilityError struct {
 	Client string
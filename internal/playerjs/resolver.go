@@ -4,11 +4,12 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
+
+	"github.com/famomatic/ytv1/internal/httpx"
 )
 
 type Variant string
@@ -31,8 +32,11 @@ type defaultResolver struct {
 
 // ResolverConfig contains externally tunable settings for player JS fetches.
 type ResolverConfig struct {
-	BaseURL         string
-	UserAgent       string
+	BaseURL   string
+	UserAgent string
+	// PageUserAgent is used for the watch page and iframe_api fetches that
+	// locate the player; empty falls back to UserAgent.
+	PageUserAgent   string
 	Headers         http.Header
 	PreferredLocale string
 }
@@ -75,7 +79,13 @@ func (r *defaultResolver) GetPlayerJS(ctx context.Context, playerURL string) (st
 	}
 
 	var lastErr error
-	for _, candidate := range candidates {
+	for i, candidate := range candidates {
+		if i > 0 {
+			// The fallback path is a retry of the same fetch.
+			if _, ok := httpx.AllowRetry(ctx, 0); !ok {
+				break
+			}
+		}
 		body, err := r.fetchPlayerJS(ctx, candidate)
 		if err != nil {
 			lastErr = err
@@ -114,6 +124,7 @@ func (r *defaultResolver) fetchPlayerJS(ctx context.Context, playerURL string) (
 			req.Header.Add(k, v)
 		}
 	}
+	httpx.AcceptCompressed(req)
 
 	resp, err := r.client.Do(req)
 	if err != nil {
@@ -125,7 +136,7 @@ func (r *defaultResolver) fetchPlayerJS(ctx context.Context, playerURL string) (
 		return "", fmt.Errorf("bad status code: %d", resp.StatusCode)
 	}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+	bodyBytes, err := httpx.ReadBody(resp)
 	if err != nil {
 		return "", fmt.Errorf("failed to read body: %w", err)
 	}
@@ -152,16 +163,14 @@ func (r *defaultResolver) GetPlayerURL(ctx context.Context, videoID string) (str
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
-	ua := r.config.UserAgent
-	if ua == "" {
-		ua = defaultPlayerJSUserAgent
-	}
+	ua := r.pageUserAgent()
 	req.Header.Set("User-Agent", ua)
 	for k, values := range r.config.Headers {
 		for _, v := range values {
 			req.Header.Add(k, v)
 		}
 	}
+	httpx.AcceptCompressed(req)
 
 	resp, err := r.client.Do(req)
 	if err != nil {
@@ -173,7 +182,7 @@ func (r *defaultResolver) GetPlayerURL(ctx context.Context, videoID string) (str
 		return "", fmt.Errorf("bad status code: %d", resp.StatusCode)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := httpx.ReadBody(resp)
 	if err != nil {
 		return "", fmt.Errorf("failed to read body: %w", err)
 	}
@@ -189,6 +198,16 @@ func (r *defaultResolver) GetPlayerURL(ctx context.Context, videoID string) (str
 	return "", fmt.Errorf("player url not found")
 }
 
+func (r *defaultResolver) pageUserAgent() string {
+	if r.config.PageUserAgent != "" {
+		return r.config.PageUserAgent
+	}
+	if r.config.UserAgent != "" {
+		return r.config.UserAgent
+	}
+	return defaultPlayerJSUserAgent
+}
+
 func extractPlayerURLFromWatchPage(body []byte) string {
 	for _, re := range []*regexp.Regexp{playerJSURLCfgPattern, webPlayerContextJSURLPattern, playerURLPattern} {
 		m := re.FindSubmatch(body)
@@ -214,16 +233,14 @@ func (r *defaultResolver) fetchIframeAPIPlayerURL(ctx context.Context, baseURL s
 	if err != nil {
 		return ""
 	}
-	ua := r.config.UserAgent
-	if ua == "" {
-		ua = defaultPlayerJSUserAgent
-	}
+	ua := r.pageUserAgent()
 	req.Header.Set("User-Agent", ua)
 	for k, values := range r.config.Headers {
 		for _, v := range values {
 			req.Header.Add(k, v)
 		}
 	}
+	httpx.AcceptCompressed(req)
 	resp, err := r.client.Do(req)
 	if err != nil {
 		return ""
@@ -232,7 +249,7 @@ func (r *defaultResolver) fetchIframeAPIPlayerURL(ctx context.Context, baseURL s
 	if resp.StatusCode != http.StatusOK {
 		return ""
 	}
-	body, err := io.ReadAll(resp.Body)
+	body, err := httpx.ReadBody(resp)
 	if err != nil {
 		return ""
 	}
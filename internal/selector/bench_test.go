@@ -0,0 +1,117 @@
+package selector
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/famomatic/ytv1/internal/types"
+)
+
+// benchSelectors are the expressions benchmarked against benchFormats(200).
+// selectAllocs is the allocation budget per Select call, about 25% above
+// the measured baseline in docs/benchmarks/baseline.txt.
+var benchSelectors = []struct {
+	name         string
+	expr         string
+	selectAllocs float64
+}{
+	{"best", "best", 20},
+	{"fallback_chain", "bestvideo[ext=mp4]+bestaudio[ext=m4a]/best[ext=mp4]/best", 800},
+	{"filtered_merge", "bv[height<=720][fps!=60]+wa[lang=en]/best[ext=mp4][width>=640]/audioonly", 32},
+}
+
+// benchFormats builds a format list shaped like a multi-client, multi-audio
+// response: n formats spread over video-only, audio-only and muxed streams.
+func benchFormats(n int) []types.FormatInfo {
+	heights := []int{144, 240, 360, 480, 720, 1080, 1440, 2160}
+	langs := []string{"en", "es", "fr", "de", "ja"}
+	clients := []string{"android", "ios", "web", "mweb"}
+	out := make([]types.FormatInfo, 0, n)
+	for i := 0; len(out) < n; i++ {
+		f := types.FormatInfo{
+			Itag:         100 + i,
+			URL:          fmt.Sprintf("https://rr1---sn-x.googlevideo.com/videoplayback?itag=%d", 100+i),
+			Protocol:     "https",
+			Bitrate:      50_000 + i*7_919,
+			SourceClient: clients[i%len(clients)],
+		}
+		switch i % 3 {
+		case 0:
+			h := heights[i%len(heights)]
+			f.HasVideo = true
+			f.Width, f.Height = h*16/9, h
+			f.FPS = 30 + 30*(i%2)
+			if i%2 == 0 {
+				f.MimeType = `video/mp4; codecs="avc1.640028"`
+			} else {
+				f.MimeType = `video/webm; codecs="vp9"`
+			}
+		case 1:
+			f.HasAudio = true
+			f.Language = langs[i%len(langs)]
+			if i%2 == 0 {
+				f.MimeType = `audio/mp4; codecs="mp4a.40.2"`
+			} else {
+				f.MimeType = `audio/webm; codecs="opus"`
+			}
+		default:
+			f.HasVideo, f.HasAudio = true, true
+			f.Width, f.Height = 640, 360
+			f.FPS = 30
+			f.MimeType = `video/mp4; codecs="avc1.42001E, mp4a.40.2"`
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func BenchmarkSelect(b *testing.B) {
+	formats := benchFormats(200)
+	for _, bc := range benchSelectors {
+		sel, err := Parse(bc.expr)
+		if err != nil {
+			b.Fatalf("Parse(%q) error = %v", bc.expr, err)
+		}
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Select(formats, sel); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, bc := range benchSelectors {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := Parse(bc.expr); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// TestSelectAllocBudget pins allocations per Select call on a 200-format
+// list, so a regression fails here rather than only in benchmark baselines.
+func TestSelectAllocBudget(t *testing.T) {
+	formats := benchFormats(200)
+	for _, bc := range benchSelectors {
+		sel, err := Parse(bc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", bc.expr, err)
+		}
+		allocs := testing.AllocsPerRun(20, func() {
+			if _, err := Select(formats, sel); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs > bc.selectAllocs {
+			t.Errorf("Select(%s) allocs = %v, budget %v", bc.name, allocs, bc.selectAllocs)
+		}
+	}
+}
@@ -30,9 +30,30 @@ type FormatFilter struct {
 	Op    string // =, <, >, <=, >= (for filters like res)
 }
 
+// String renders the filter as selector syntax, e.g. "bestvideo" or
+// "height<=720". Aliases come back in their canonical spelling.
+func (f FormatFilter) String() string {
+	switch f.Type {
+	case "builtin":
+		return f.Value
+	case "media":
+		if f.Op == "" {
+			return f.Value + "only"
+		}
+		return f.Op + f.Value
+	case "ext":
+		return "ext=" + f.Value
+	case "res":
+		return "height" + f.Op + f.Value
+	default:
+		return f.Type + f.Op + f.Value
+	}
+}
+
 // Parse parses a format selector string.
-// Syntax: seg1+seg2/seg3
-// Modifier syntax: bestvideo[ext=mp4]
+// Syntax: seg1+seg2/seg3 (any number of + segments per merge group)
+// Modifier syntax: bestvideo[ext=mp4], bestaudio[lang=en]
+// "mergeall[...]" expands to every format matching its modifiers.
 func Parse(s string) (*Selector, error) {
 	// Splits by / first (fallbacks)
 	fallbackStrs := strings.Split(s, "/")
@@ -118,6 +139,8 @@ func parseModifier(s string) (*FormatFilter, error) {
 				return &FormatFilter{Type: "width", Value: val, Op: op}, nil
 			case "fps":
 				return &FormatFilter{Type: "fps", Value: val, Op: op}, nil
+			case "lang", "language":
+				return &FormatFilter{Type: "lang", Value: val, Op: op}, nil
 			default:
 				// unknown key, maybe metadata? ignore or error?
 				// yt-dlp allows metadata matches.
@@ -131,7 +154,7 @@ func parseModifier(s string) (*FormatFilter, error) {
 func parseFilter(s string) (*FormatFilter, error) {
 	s = strings.ToLower(s)
 
-	if s == "best" || s == "worst" {
+	if s == "best" || s == "worst" || s == "mergeall" {
 		return &FormatFilter{Type: "builtin", Value: s}, nil
 	}
 	if s == "bestvideo" || s == "bv" {
@@ -179,3 +202,22 @@ func parseFilter(s string) (*FormatFilter, error) {
 
 	return nil, fmt.Errorf("unknown selector: %s", s)
 }
+
+// ConstrainHeight appends a height<=maxHeight modifier to every stream spec
+// of expr, e.g. "bv+ba/b" becomes "bv[height<=1080]+ba[height<=1080]/b[height<=1080]".
+// Audio-only formats have height 0 and still match.
+func ConstrainHeight(expr string, maxHeight int) string {
+	if maxHeight <= 0 || strings.TrimSpace(expr) == "" {
+		return expr
+	}
+	modifier := fmt.Sprintf("[height<=%d]", maxHeight)
+	fallbacks := strings.Split(expr, "/")
+	for i, fb := range fallbacks {
+		specs := strings.Split(fb, "+")
+		for j, spec := range specs {
+			specs[j] = strings.TrimSpace(spec) + modifier
+		}
+		fallbacks[i] = strings.Join(specs, "+")
+	}
+	return strings.Join(fallbacks, "/")
+}
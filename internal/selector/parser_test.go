@@ -108,3 +108,17 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestConstrainHeight(t *testing.T) {
+	got := ConstrainHeight("bestvideo[ext=mp4]+bestaudio/best", 1080)
+	want := "bestvideo[ext=mp4][height<=1080]+bestaudio[height<=1080]/best[height<=1080]"
+	if got != want {
+		t.Fatalf("ConstrainHeight() = %q, want %q", got, want)
+	}
+	if _, err := Parse(got); err != nil {
+		t.Fatalf("Parse(constrained) error = %v", err)
+	}
+	if got := ConstrainHeight("best", 0); got != "best" {
+		t.Fatalf("ConstrainHeight(max=0) = %q, want unchanged", got)
+	}
+}
@@ -14,27 +14,219 @@ func Select(formats []types.FormatInfo, selector *Selector) ([]types.FormatInfo,
 	if selector == nil || len(selector.Fallbacks) == 0 {
 		return SelectBest(formats), nil
 	}
+	selected, _ := evaluate(formats, selector, nil)
+	return selected, nil
+}
+
+// GroupTrace records how one fallback group of a selector was evaluated.
+type GroupTrace struct {
+	Group   string      `json:"group"` // e.g. "bestvideo+bestaudio"
+	Specs   []SpecTrace `json:"specs"`
+	Matched bool        `json:"matched"`
+}
+
+// SpecTrace records how one stream spec of a group was evaluated. Filters
+// shows which formats satisfy each clause on its own; Candidates are the
+// formats satisfying all of them, best ranked first. Every format not picked
+// appears in Rejected. Evaluation of a group stops at the first spec that
+// picks nothing.
+type SpecTrace struct {
+	Spec       string        `json:"spec"`
+	Filters    []FilterTrace `json:"filters"`
+	Candidates []int         `json:"candidates"`
+	Picked     []int         `json:"picked,omitempty"`
+	Rejected   []Rejection   `json:"rejected,omitempty"`
+}
+
+// FilterTrace lists the itags satisfying one filter clause, e.g. "ext=mp4".
+type FilterTrace struct {
+	Clause  string `json:"clause"`
+	Matched []int  `json:"matched"`
+}
+
+// Rejection explains why a format was not picked by a spec: the first clause
+// it fails ("fails ext=mp4"), or losing the ranking ("ranked below 137").
+type Rejection struct {
+	Itag   int    `json:"itag"`
+	Reason string `json:"reason"`
+}
+
+// Explain parses expr and selects from formats exactly like Select, also
+// returning a trace of every fallback group tried up to the first match.
+func Explain(formats []types.FormatInfo, expr string) ([]types.FormatInfo, []GroupTrace, error) {
+	selector, err := Parse(expr)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Same splitting as Parse, so texts line up with selector.Fallbacks.
+	var texts [][]string
+	for _, fbStr := range strings.Split(expr, "/") {
+		var group []string
+		for _, mStr := range strings.Split(fbStr, "+") {
+			group = append(group, strings.TrimSpace(mStr))
+		}
+		texts = append(texts, group)
+	}
+	selected, trace := evaluate(formats, selector, texts)
+	return selected, trace, nil
+}
+
+// PlanStep is one selection of a fallback plan.
+type PlanStep struct {
+	Group   int // index into Selector.Fallbacks
+	Formats []types.FormatInfo
+}
+
+// Plan evaluates every fallback group of selector against formats and
+// returns the distinct selections that match, in preference order. The first
+// step is what Select returns; later steps are what "/" alternatives would
+// yield if it cannot be fetched.
+func Plan(formats []types.FormatInfo, selector *Selector) []PlanStep {
+	if selector == nil || len(selector.Fallbacks) == 0 {
+		if best := SelectBest(formats); len(best) > 0 {
+			return []PlanStep{{Formats: best}}
+		}
+		return nil
+	}
+	var steps []PlanStep
+	seen := make(map[string]struct{})
+	for gi, group := range selector.Fallbacks {
+		selected, _, ok := evaluateGroup(formats, group, nil)
+		if !ok {
+			continue
+		}
+		key := selectionKey(selected)
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		steps = append(steps, PlanStep{Group: gi, Formats: selected})
+	}
+	return steps
+}
+
+func selectionKey(selected []types.FormatInfo) string {
+	parts := make([]string, 0, len(selected))
+	for _, f := range selected {
+		parts = append(parts, strconv.Itoa(f.Itag)+"|"+f.URL)
+	}
+	return strings.Join(parts, "+")
+}
+
+// evaluate runs the fallback groups in order, tracing each one tried when
+// texts (the spec sources per group) is non-nil.
+func evaluate(formats []types.FormatInfo, selector *Selector, texts [][]string) ([]types.FormatInfo, []GroupTrace) {
+	var trace []GroupTrace
+	for gi, group := range selector.Fallbacks {
+		var groupTexts []string
+		if texts != nil {
+			groupTexts = texts[gi]
+		}
+		selected, gt, ok := evaluateGroup(formats, group, groupTexts)
+		if texts != nil {
+			trace = append(trace, gt)
+		}
+		if ok {
+			return selected, trace
+		}
+	}
+
+	return nil, trace
+}
+
+// evaluateGroup picks formats for every spec of one fallback group. ok is
+// false when a spec picks nothing. The trace is filled when texts is non-nil.
+func evaluateGroup(formats []types.FormatInfo, group MergeGroup, texts []string) ([]types.FormatInfo, GroupTrace, bool) {
+	// A MergeGroup is a list of StreamSpecs (e.g. [video, audio])
+	var selected []types.FormatInfo
+	seen := make(map[string]struct{})
+	failed := false
+	var gt GroupTrace
+	if texts != nil {
+		gt.Group = strings.Join(texts, "+")
+	}
+
+	for si, spec := range group {
+		var picked []types.FormatInfo
+		if wantsAll(spec.Filters) {
+			picked = pickAll(formats, spec)
+		} else if candidate, ok := pickBest(formats, spec); ok {
+			picked = []types.FormatInfo{candidate}
+		}
+		if texts != nil {
+			gt.Specs = append(gt.Specs, traceSpec(formats, spec, texts[si], picked))
+		}
+		if len(picked) == 0 {
+			failed = true
+			break
+		}
+		// The same stream may satisfy several specs (e.g. "ba+ba[lang=en]");
+		// keep it once so it is not downloaded and muxed twice.
+		for _, f := range picked {
+			key := strconv.Itoa(f.Itag) + "|" + f.URL
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			selected = append(selected, f)
+		}
+	}
 
-	for _, group := range selector.Fallbacks {
-		// A MergeGroup is a list of StreamSpecs (e.g. [video, audio])
-		var selected []types.FormatInfo
-		failed := false
+	gt.Matched = !failed
+	if failed {
+		return nil, gt, false
+	}
+	return selected, gt, true
+}
 
-		for _, spec := range group {
-			candidate, ok := pickBest(formats, spec)
-			if !ok {
-				failed = true
-				break
+func traceSpec(formats []types.FormatInfo, spec *StreamSpec, text string, picked []types.FormatInfo) SpecTrace {
+	st := SpecTrace{Spec: text}
+	for i := range spec.Filters {
+		ft := FilterTrace{Clause: spec.Filters[i].String()}
+		for _, f := range formats {
+			if matches(f, &spec.Filters[i]) {
+				ft.Matched = append(ft.Matched, f.Itag)
 			}
-			selected = append(selected, candidate)
 		}
+		st.Filters = append(st.Filters, ft)
+	}
+
+	var candidates []types.FormatInfo
+	for _, f := range formats {
+		if failed := firstFailing(f, spec.Filters); failed != nil {
+			st.Rejected = append(st.Rejected, Rejection{Itag: f.Itag, Reason: "fails " + failed.String()})
+			continue
+		}
+		candidates = append(candidates, f)
+	}
+	sortFormats(candidates)
 
-		if !failed {
-			return selected, nil
+	pickedKeys := make(map[string]struct{}, len(picked))
+	for _, f := range picked {
+		pickedKeys[strconv.Itoa(f.Itag)+"|"+f.URL] = struct{}{}
+		st.Picked = append(st.Picked, f.Itag)
+	}
+	ranking := "ranked below "
+	if wantsWorst(spec.Filters) {
+		ranking = "ranked above "
+	}
+	for _, f := range candidates {
+		st.Candidates = append(st.Candidates, f.Itag)
+		if _, ok := pickedKeys[strconv.Itoa(f.Itag)+"|"+f.URL]; ok || len(picked) == 0 {
+			continue
 		}
+		st.Rejected = append(st.Rejected, Rejection{Itag: f.Itag, Reason: ranking + strconv.Itoa(picked[0].Itag)})
 	}
+	return st
+}
 
-	return nil, nil
+func firstFailing(f types.FormatInfo, filters []FormatFilter) *FormatFilter {
+	for i := range filters {
+		if !matches(f, &filters[i]) {
+			return &filters[i]
+		}
+	}
+	return nil
 }
 
 // SelectBest implements the default 'best' logic.
@@ -88,6 +280,27 @@ func pickBest(formats []types.FormatInfo, spec *StreamSpec) (types.FormatInfo, b
 	return candidates[0], true
 }
 
+// pickAll returns every format matching spec, ranked best first.
+func pickAll(formats []types.FormatInfo, spec *StreamSpec) []types.FormatInfo {
+	var candidates []types.FormatInfo
+	for _, f := range formats {
+		if matchesAll(f, spec.Filters) {
+			candidates = append(candidates, f)
+		}
+	}
+	sortFormats(candidates)
+	return candidates
+}
+
+func wantsAll(filters []FormatFilter) bool {
+	for _, flt := range filters {
+		if flt.Type == "builtin" && flt.Value == "mergeall" {
+			return true
+		}
+	}
+	return false
+}
+
 func wantsWorst(filters []FormatFilter) bool {
 	for _, flt := range filters {
 		if flt.Type == "builtin" && flt.Value == "worst" {
@@ -140,10 +353,27 @@ func matches(f types.FormatInfo, filter *FormatFilter) bool {
 			return false
 		}
 		return checkOp(f.FPS, val, filter.Op)
+	case "lang":
+		match := matchesLanguage(f.Language, filter.Value)
+		if filter.Op == "!=" {
+			return !match
+		}
+		return match
 	}
 	return false
 }
 
+// matchesLanguage compares audio track languages, treating "en" as a match
+// for regional variants such as "en-US".
+func matchesLanguage(lang, want string) bool {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	want = strings.ToLower(strings.TrimSpace(want))
+	if lang == "" || want == "" {
+		return false
+	}
+	return lang == want || strings.HasPrefix(lang, want+"-")
+}
+
 func checkOp(a, b int, op string) bool {
 	switch op {
 	case ":", "=":
@@ -1,6 +1,7 @@
 package selector
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/famomatic/ytv1/internal/types"
@@ -139,3 +140,149 @@ func TestSelect_FPSNotEqualFilter(t *testing.T) {
 		t.Fatalf("selected itag = %d, want 137", got[0].Itag)
 	}
 }
+
+func TestSelect_MultiAudioLanguageMerge(t *testing.T) {
+	formats := []types.FormatInfo{
+		{Itag: 137, MimeType: `video/mp4; codecs="avc1"`, HasVideo: true, Width: 1920, Height: 1080, FPS: 30, Bitrate: 4_000_000},
+		{Itag: 140, MimeType: `audio/mp4; codecs="mp4a"`, HasAudio: true, Bitrate: 128_000, Language: "en-US", URL: "https://x/en"},
+		{Itag: 140, MimeType: `audio/mp4; codecs="mp4a"`, HasAudio: true, Bitrate: 128_000, Language: "es", URL: "https://x/es"},
+		{Itag: 140, MimeType: `audio/mp4; codecs="mp4a"`, HasAudio: true, Bitrate: 128_000, Language: "de", URL: "https://x/de"},
+	}
+
+	sel, err := Parse("bv+ba[lang=en]+ba[lang=es]")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	got, err := Select(formats, sel)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("len(selected) = %d, want 3", len(got))
+	}
+	if got[0].Itag != 137 || got[1].Language != "en-US" || got[2].Language != "es" {
+		t.Fatalf("unexpected selection: %+v", got)
+	}
+}
+
+func TestSelect_MergeAllDeduplicatesAcrossSpecs(t *testing.T) {
+	formats := []types.FormatInfo{
+		{Itag: 137, MimeType: `video/mp4; codecs="avc1"`, HasVideo: true, Width: 1920, Height: 1080, FPS: 30, Bitrate: 4_000_000},
+		{Itag: 140, MimeType: `audio/mp4; codecs="mp4a"`, HasAudio: true, Bitrate: 128_000, Language: "en", URL: "https://x/en"},
+		{Itag: 140, MimeType: `audio/mp4; codecs="mp4a"`, HasAudio: true, Bitrate: 128_000, Language: "fr", URL: "https://x/fr"},
+		{Itag: 251, MimeType: `audio/webm; codecs="opus"`, HasAudio: true, Bitrate: 160_000, Language: "en", URL: "https://x/opus"},
+	}
+
+	sel, err := Parse("bv+mergeall[ext=m4a]+ba[lang=fr][ext=m4a]")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	got, err := Select(formats, sel)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("len(selected) = %d, want 3 (video + two m4a tracks): %+v", len(got), got)
+	}
+	for _, f := range got[1:] {
+		if f.Itag != 140 {
+			t.Fatalf("mergeall[ext=m4a] selected itag %d", f.Itag)
+		}
+	}
+}
+
+func TestExplain_TracesFallbackGroups(t *testing.T) {
+	formats := []types.FormatInfo{
+		{Itag: 137, MimeType: `video/mp4; codecs="avc1"`, HasVideo: true, Width: 1920, Height: 1080, Bitrate: 4_000_000},
+		{Itag: 251, MimeType: `audio/webm; codecs="opus"`, HasAudio: true, Bitrate: 160_000},
+		{Itag: 22, MimeType: `video/mp4; codecs="avc1,mp4a"`, HasVideo: true, HasAudio: true, Width: 1280, Height: 720, Bitrate: 2_000_000},
+	}
+
+	got, trace, err := Explain(formats, "bestvideo[ext=mp4] + bestaudio[ext=m4a]/best[ext=mp4]/best")
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Itag != 22 {
+		t.Fatalf("selected = %+v, want itag 22", got)
+	}
+	if len(trace) != 2 {
+		t.Fatalf("len(trace) = %d, want 2 (stops at first match): %+v", len(trace), trace)
+	}
+	first := trace[0]
+	if first.Matched || first.Group != "bestvideo[ext=mp4]+bestaudio[ext=m4a]" || len(first.Specs) != 2 {
+		t.Fatalf("trace[0] = %+v", first)
+	}
+	if first.Specs[0].Spec != "bestvideo[ext=mp4]" || len(first.Specs[0].Picked) != 1 || first.Specs[0].Picked[0] != 137 {
+		t.Fatalf("trace[0].Specs[0] = %+v", first.Specs[0])
+	}
+	audio := first.Specs[1]
+	if len(audio.Candidates) != 0 || len(audio.Picked) != 0 {
+		t.Fatalf("trace[0].Specs[1] = %+v, want no candidates", audio)
+	}
+	if len(audio.Filters) != 2 || audio.Filters[0].Clause != "bestaudio" || audio.Filters[1].Clause != "ext=m4a" {
+		t.Fatalf("trace[0].Specs[1].Filters = %+v", audio.Filters)
+	}
+	if len(audio.Filters[0].Matched) != 1 || audio.Filters[0].Matched[0] != 251 || len(audio.Filters[1].Matched) != 0 {
+		t.Fatalf("trace[0].Specs[1].Filters = %+v", audio.Filters)
+	}
+	if len(audio.Rejected) != 3 || audio.Rejected[1] != (Rejection{Itag: 251, Reason: "fails ext=m4a"}) {
+		t.Fatalf("trace[0].Specs[1].Rejected = %+v", audio.Rejected)
+	}
+	mp4 := trace[1].Specs[0]
+	if !trace[1].Matched || len(mp4.Candidates) != 2 || mp4.Candidates[0] != 22 || mp4.Picked[0] != 22 {
+		t.Fatalf("trace[1] = %+v", trace[1])
+	}
+	if last := mp4.Rejected[len(mp4.Rejected)-1]; last != (Rejection{Itag: 137, Reason: "ranked below 22"}) {
+		t.Fatalf("trace[1] rejected = %+v", mp4.Rejected)
+	}
+
+	if _, _, err := Explain(formats, "bestest"); err == nil {
+		t.Fatal("Explain() accepted an invalid selector")
+	}
+}
+
+func TestPlan_ListsDistinctMatchingGroups(t *testing.T) {
+	formats := []types.FormatInfo{
+		{Itag: 137, MimeType: `video/mp4; codecs="avc1"`, HasVideo: true, Width: 1920, Height: 1080, Bitrate: 4_000_000},
+		{Itag: 140, MimeType: `audio/mp4; codecs="mp4a"`, HasAudio: true, Bitrate: 128_000},
+		{Itag: 22, MimeType: `video/mp4; codecs="avc1,mp4a"`, HasVideo: true, HasAudio: true, Width: 1280, Height: 720, Bitrate: 2_000_000},
+	}
+	sel, err := Parse("bestvideo+bestaudio/bestvideo[ext=webm]+bestaudio/best[ext=mp4]/best")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := Plan(formats, sel)
+	if len(steps) != 2 {
+		t.Fatalf("len(Plan()) = %d, want 2: %+v", len(steps), steps)
+	}
+	if steps[0].Group != 0 || len(steps[0].Formats) != 2 || steps[0].Formats[0].Itag != 137 || steps[0].Formats[1].Itag != 140 {
+		t.Fatalf("steps[0] = %+v", steps[0])
+	}
+	// "best" selects 22 again and is folded into group 2.
+	if steps[1].Group != 2 || len(steps[1].Formats) != 1 || steps[1].Formats[0].Itag != 22 {
+		t.Fatalf("steps[1] = %+v", steps[1])
+	}
+	if first, _ := Select(formats, sel); len(first) != 2 || first[0].Itag != steps[0].Formats[0].Itag {
+		t.Fatalf("Select() = %+v, want the first plan step", first)
+	}
+}
+
+func TestFormatFilterString(t *testing.T) {
+	sel, err := Parse("bv[height<=720][fps!=60]+wa[lang=en]/best[ext=mp4][width>=640]/audioonly")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	var got []string
+	for _, group := range sel.Fallbacks {
+		for _, spec := range group {
+			for _, f := range spec.Filters {
+				got = append(got, f.String())
+			}
+		}
+	}
+	want := []string{"bestvideo", "height<=720", "fps!=60", "worstaudio", "lang=en", "best", "ext=mp4", "width>=640", "audioonly"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("filters = %q, want %q", got, want)
+	}
+}
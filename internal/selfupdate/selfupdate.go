@@ -0,0 +1,351 @@
+// Package selfupdate fetches signed release binaries and swaps them in for
+// the running executable.
+//
+// A release publishes one binary per platform (see AssetName), a sha256sum
+// style ChecksumsAsset, and SignatureAsset: an Ed25519 signature over the
+// checksums file, raw or base64-encoded. Only checksums signed by the
+// configured public key are trusted. The checksums file also names the
+// release version (see ReleaseVersion), so an old signed release cannot be
+// served under a newer tag.
+package selfupdate
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// DefaultEndpoint is the GitHub API URL of the latest ytv1 release.
+const DefaultEndpoint = "https://api.github.com/repos/famomatic/ytv1/releases/latest"
+
+const (
+	// ChecksumsAsset lists "<sha256 hex>  <asset name>" for every binary,
+	// plus a "# version: <tag>" line.
+	ChecksumsAsset = "checksums.txt"
+	// SignatureAsset is the Ed25519 signature of ChecksumsAsset.
+	SignatureAsset = "checksums.txt.sig"
+)
+
+// maxAssetBytes bounds downloads so a bad endpoint cannot exhaust memory.
+const maxAssetBytes = 256 << 20
+
+// ErrNoPublicKey is returned when no release signing key is configured, so
+// nothing downloaded could be verified.
+var ErrNoPublicKey = errors.New("selfupdate: no release signing key configured")
+
+// Release is the subset of the GitHub release API response used here.
+type Release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is one file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Asset returns the asset called name.
+func (r *Release) Asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// Updater checks for and downloads releases.
+type Updater struct {
+	// HTTPClient is used for all requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+	// Endpoint is the release API URL. Empty uses DefaultEndpoint.
+	Endpoint string
+	// PublicKey verifies SignatureAsset.
+	PublicKey ed25519.PublicKey
+	// GOOS and GOARCH pick the binary; empty uses the running platform.
+	GOOS, GOARCH string
+}
+
+// AssetName is the release binary name for a platform, e.g. "ytv1_linux_amd64"
+// or "ytv1_windows_amd64.exe".
+func AssetName(goos, goarch string) string {
+	name := "ytv1_" + goos + "_" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// ParsePublicKey decodes a base64 Ed25519 public key.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("selfupdate: decode public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("selfupdate: public key is %d bytes, want %d", len(raw), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// Latest fetches the latest release description.
+func (u *Updater) Latest(ctx context.Context) (*Release, error) {
+	endpoint := u.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	body, err := u.get(ctx, endpoint, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	var rel Release
+	if err := json.Unmarshal(body, &rel); err != nil {
+		return nil, fmt.Errorf("selfupdate: decode release: %w", err)
+	}
+	if rel.TagName == "" {
+		return nil, errors.New("selfupdate: release has no tag")
+	}
+	return &rel, nil
+}
+
+// Download fetches this platform's binary from rel and returns it only after
+// the checksums signature and the binary's checksum both verify, and the
+// signed version matches rel.TagName, so callers may trust the tag.
+func (u *Updater) Download(ctx context.Context, rel *Release) ([]byte, error) {
+	if len(u.PublicKey) != ed25519.PublicKeySize {
+		return nil, ErrNoPublicKey
+	}
+	name := AssetName(firstNonEmpty(u.GOOS, runtime.GOOS), firstNonEmpty(u.GOARCH, runtime.GOARCH))
+	binAsset, ok := rel.Asset(name)
+	if !ok {
+		return nil, fmt.Errorf("selfupdate: release %s has no %s asset", rel.TagName, name)
+	}
+	sumsAsset, ok := rel.Asset(ChecksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("selfupdate: release %s has no %s", rel.TagName, ChecksumsAsset)
+	}
+	sigAsset, ok := rel.Asset(SignatureAsset)
+	if !ok {
+		return nil, fmt.Errorf("selfupdate: release %s has no %s", rel.TagName, SignatureAsset)
+	}
+
+	sums, err := u.get(ctx, sumsAsset.URL, "")
+	if err != nil {
+		return nil, err
+	}
+	sig, err := u.get(ctx, sigAsset.URL, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifySignature(u.PublicKey, sums, sig); err != nil {
+		return nil, err
+	}
+	signed, err := ReleaseVersion(sums)
+	if err != nil {
+		return nil, err
+	}
+	if signed != rel.TagName {
+		return nil, fmt.Errorf("selfupdate: release %s carries checksums signed for %s", rel.TagName, signed)
+	}
+	want, err := LookupChecksum(sums, name)
+	if err != nil {
+		return nil, err
+	}
+	bin, err := u.get(ctx, binAsset.URL, "")
+	if err != nil {
+		return nil, err
+	}
+	if got := sha256.Sum256(bin); !bytes.Equal(got[:], want) {
+		return nil, fmt.Errorf("selfupdate: %s checksum mismatch", name)
+	}
+	return bin, nil
+}
+
+// VerifySignature checks sig (raw or base64) over message.
+func VerifySignature(pub ed25519.PublicKey, message, sig []byte) error {
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil {
+			return fmt.Errorf("selfupdate: decode signature: %w", err)
+		}
+		sig = decoded
+	}
+	if len(sig) != ed25519.SignatureSize || !ed25519.Verify(pub, message, sig) {
+		return errors.New("selfupdate: checksums signature does not verify")
+	}
+	return nil
+}
+
+// ReleaseVersion returns the version named by the "# version: <tag>" line of
+// a checksums file.
+func ReleaseVersion(sums []byte) (string, error) {
+	for _, line := range strings.Split(string(sums), "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "# version:"); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v), nil
+		}
+	}
+	return "", errors.New("selfupdate: checksums name no release version")
+}
+
+// LookupChecksum returns the sha256 listed for name in a sha256sum-style file.
+func LookupChecksum(sums []byte, name string) ([]byte, error) {
+	for _, line := range strings.Split(string(sums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum, err := hex.DecodeString(fields[0])
+		if err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("selfupdate: malformed checksum for %s", name)
+		}
+		return sum, nil
+	}
+	return nil, fmt.Errorf("selfupdate: no checksum for %s", name)
+}
+
+// ReplaceExecutable atomically replaces the file at path with data, keeping
+// its permissions. The new file is written next to path and renamed over it,
+// so a failure leaves the old executable in place. Windows cannot overwrite a
+// running executable, so there the old file is first moved to path+".old".
+func ReplaceExecutable(path string, data []byte) error {
+	st, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	cleanup := func() { _ = os.Remove(tmpPath) }
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		cleanup()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		cleanup()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		cleanup()
+		return err
+	}
+	if err := os.Chmod(tmpPath, st.Mode().Perm()); err != nil {
+		cleanup()
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			cleanup()
+			return err
+		}
+		if err := os.Rename(tmpPath, path); err != nil {
+			_ = os.Rename(old, path)
+			cleanup()
+			return err
+		}
+		return nil
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		cleanup()
+		return err
+	}
+	return nil
+}
+
+// CompareVersions compares "vMAJOR.MINOR.PATCH" style tags numerically,
+// ignoring a leading "v" and any pre-release or build suffix. It returns -1,
+// 0 or 1, and ok=false when either side does not parse.
+func CompareVersions(a, b string) (cmp int, ok bool) {
+	pa, okA := parseVersion(a)
+	pb, okB := parseVersion(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	for i := range pa {
+		switch {
+		case pa[i] < pb[i]:
+			return -1, true
+		case pa[i] > pb[i]:
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+func parseVersion(v string) ([3]int, bool) {
+	var out [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return out, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
+
+func (u *Updater) get(ctx context.Context, url, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	httpClient := u.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("selfupdate: GET %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxAssetBytes {
+		return nil, fmt.Errorf("selfupdate: %s exceeds %d bytes", url, maxAssetBytes)
+	}
+	return body, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,188 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRelease serves a release with a signed checksums file for linux/amd64.
+func testRelease(t *testing.T, priv ed25519.PrivateKey, bin []byte, mutate func(files map[string][]byte)) *httptest.Server {
+	t.Helper()
+	name := AssetName("linux", "amd64")
+	sum := sha256.Sum256(bin)
+	sums := []byte("# version: v1.4.0\n" + hex.EncodeToString(sum[:]) + "  " + name + "\n")
+	files := map[string][]byte{
+		name:           bin,
+		ChecksumsAsset: sums,
+		SignatureAsset: []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, sums))),
+	}
+	if mutate != nil {
+		mutate(files)
+	}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest" {
+			var assets []string
+			for n := range files {
+				assets = append(assets, `{"name":"`+n+`","browser_download_url":"`+srv.URL+`/dl/`+n+`"}`)
+			}
+			_, _ = w.Write([]byte(`{"tag_name":"v1.4.0","assets":[` + strings.Join(assets, ",") + `]}`))
+			return
+		}
+		data, ok := files[strings.TrimPrefix(r.URL.Path, "/dl/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUpdater_DownloadVerifiesSignatureAndChecksum(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	bin := []byte("new ytv1 binary")
+
+	cases := []struct {
+		name    string
+		key     ed25519.PublicKey
+		mutate  func(map[string][]byte)
+		wantErr string
+	}{
+		{name: "ok", key: pub},
+		{name: "no key", wantErr: ErrNoPublicKey.Error()},
+		{name: "wrong key", key: otherPub, wantErr: "signature does not verify"},
+		{name: "tampered binary", key: pub, mutate: func(f map[string][]byte) {
+			f[AssetName("linux", "amd64")] = []byte("evil")
+		}, wantErr: "checksum mismatch"},
+		{name: "tampered checksums", key: pub, mutate: func(f map[string][]byte) {
+			f[ChecksumsAsset] = append(f[ChecksumsAsset], '\n')
+		}, wantErr: "signature does not verify"},
+		{name: "missing platform", key: pub, mutate: func(f map[string][]byte) {
+			delete(f, AssetName("linux", "amd64"))
+		}, wantErr: "no ytv1_linux_amd64 asset"},
+		{name: "older release under a newer tag", key: pub, mutate: func(f map[string][]byte) {
+			// An old release, validly signed, served as v1.4.0.
+			sums := []byte(strings.Replace(string(f[ChecksumsAsset]), "v1.4.0", "v1.1.0", 1))
+			f[ChecksumsAsset] = sums
+			f[SignatureAsset] = ed25519.Sign(priv, sums)
+		}, wantErr: "signed for v1.1.0"},
+		{name: "unversioned checksums", key: pub, mutate: func(f map[string][]byte) {
+			sums := []byte(strings.SplitN(string(f[ChecksumsAsset]), "\n", 2)[1])
+			f[ChecksumsAsset] = sums
+			f[SignatureAsset] = ed25519.Sign(priv, sums)
+		}, wantErr: "name no release version"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := testRelease(t, priv, bin, tc.mutate)
+			u := &Updater{Endpoint: srv.URL + "/latest", PublicKey: tc.key, GOOS: "linux", GOARCH: "amd64"}
+			rel, err := u.Latest(context.Background())
+			if err != nil {
+				t.Fatalf("Latest() error = %v", err)
+			}
+			if rel.TagName != "v1.4.0" {
+				t.Fatalf("TagName=%q", rel.TagName)
+			}
+			got, err := u.Download(context.Background(), rel)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Download() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != string(bin) {
+				t.Fatalf("Download() = %q, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestVerifySignature_AcceptsRawSignature(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	msg := []byte("checksums")
+	if err := VerifySignature(pub, msg, ed25519.Sign(priv, msg)); err != nil {
+		t.Fatalf("VerifySignature(raw) error = %v", err)
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	got, err := ParsePublicKey(base64.StdEncoding.EncodeToString(pub))
+	if err != nil || !got.Equal(pub) {
+		t.Fatalf("ParsePublicKey() = %v, %v", got, err)
+	}
+	if _, err := ParsePublicKey(base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Fatalf("expected size error")
+	}
+}
+
+func TestLookupChecksum(t *testing.T) {
+	sums := []byte("" +
+		"0000000000000000000000000000000000000000000000000000000000000000  ytv1_darwin_arm64\n" +
+		"1111111111111111111111111111111111111111111111111111111111111111 *ytv1_windows_amd64.exe\n")
+	if _, err := LookupChecksum(sums, "ytv1_windows_amd64.exe"); err != nil {
+		t.Fatalf("LookupChecksum(binary mode) error = %v", err)
+	}
+	if _, err := LookupChecksum(sums, "ytv1_linux_amd64"); err == nil {
+		t.Fatalf("expected missing checksum error")
+	}
+}
+
+func TestReplaceExecutable_KeepsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ytv1")
+	if err := os.WriteFile(path, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReplaceExecutable(path, []byte("new")); err != nil {
+		t.Fatalf("ReplaceExecutable() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	st, _ := os.Stat(path)
+	if string(data) != "new" || st.Mode().Perm() != 0o755 {
+		t.Fatalf("content=%q mode=%v", data, st.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Fatalf("temp file left behind: %v", entries)
+	}
+	if err := ReplaceExecutable(filepath.Join(t.TempDir(), "missing"), nil); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("ReplaceExecutable(missing) error = %v", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		cmp  int
+		ok   bool
+	}{
+		{"v1.2.3", "v1.2.3", 0, true},
+		{"v1.2.3", "v1.10.0", -1, true},
+		{"1.3", "v1.2.9", 1, true},
+		{"v1.2.3-rc.1", "v1.2.3", 0, true},
+		{"(devel)", "v1.2.3", 0, false},
+	}
+	for _, tc := range cases {
+		cmp, ok := CompareVersions(tc.a, tc.b)
+		if cmp != tc.cmp || ok != tc.ok {
+			t.Fatalf("CompareVersions(%q, %q) = %d, %v", tc.a, tc.b, cmp, ok)
+		}
+	}
+}
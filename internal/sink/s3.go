@@ -0,0 +1,380 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPartSize is the S3 multipart part size. Each part is buffered in
+// memory so a failed part can be retried without re-reading the stream.
+const DefaultPartSize = 16 << 20
+
+// S3 uploads to an S3 bucket, or to any service speaking the S3 API with
+// Signature Version 4 (Google Cloud Storage, MinIO, R2). Streams shorter
+// than one part are stored with a single PUT; longer ones with a multipart
+// upload that is aborted on failure.
+type S3 struct {
+	Client *http.Client
+	// Endpoint is the service root, e.g. https://s3.us-east-1.amazonaws.com.
+	Endpoint string
+	Bucket   string
+	Region   string
+	// PathStyle addresses the bucket as Endpoint/Bucket instead of the
+	// virtual-hosted Bucket.<Endpoint host>.
+	PathStyle       bool
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	PartSize        int64
+	Retries         int
+
+	scheme string
+	now    func() time.Time
+}
+
+func newS3(bucket string, client *http.Client, getenv func(string) string) (*S3, error) {
+	s := &S3{
+		Client:          client,
+		Bucket:          bucket,
+		Region:          firstEnv(getenv, "AWS_REGION", "AWS_DEFAULT_REGION"),
+		AccessKeyID:     getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    getenv("AWS_SESSION_TOKEN"),
+		scheme:          SchemeS3,
+	}
+	if s.Region == "" {
+		s.Region = "us-east-1"
+	}
+	if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return nil, errors.New("s3 sink: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	if endpoint := firstEnv(getenv, "AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"); endpoint != "" {
+		s.Endpoint = strings.TrimSuffix(endpoint, "/")
+		s.PathStyle = true
+	} else {
+		s.Endpoint = "https://s3." + s.Region + ".amazonaws.com"
+		// Dotted bucket names do not match the wildcard certificate.
+		s.PathStyle = strings.Contains(bucket, ".")
+	}
+	return s, nil
+}
+
+func newGCS(bucket string, client *http.Client, getenv func(string) string) (*S3, error) {
+	s := &S3{
+		Client:          client,
+		Endpoint:        "https://storage.googleapis.com",
+		Bucket:          bucket,
+		Region:          "auto",
+		PathStyle:       true,
+		AccessKeyID:     getenv("GCS_HMAC_ACCESS_KEY_ID"),
+		SecretAccessKey: getenv("GCS_HMAC_SECRET"),
+		scheme:          SchemeGCS,
+	}
+	if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return nil, errors.New("gs sink: GCS_HMAC_ACCESS_KEY_ID and GCS_HMAC_SECRET must be set")
+	}
+	return s, nil
+}
+
+func firstEnv(getenv func(string) string, names ...string) string {
+	for _, name := range names {
+		if v := strings.TrimSpace(getenv(name)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// URL implements Sink.
+func (s *S3) URL(key string) string {
+	scheme := s.scheme
+	if scheme == "" {
+		scheme = SchemeS3
+	}
+	return scheme + "://" + s.Bucket + "/" + key
+}
+
+// Upload implements Sink.
+func (s *S3) Upload(ctx context.Context, key string, r io.Reader) error {
+	partSize := s.PartSize
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	buf := make([]byte, partSize)
+	n, err := io.ReadFull(r, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return s.putObject(ctx, key, buf[:n])
+	}
+	if err != nil {
+		return err
+	}
+
+	uploadID, err := s.createMultipartUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+	var parts []completedPart
+	for part := 1; ; part++ {
+		etag, err := s.uploadPart(ctx, key, uploadID, part, buf[:n])
+		if err != nil {
+			s.abortMultipartUpload(ctx, key, uploadID)
+			return err
+		}
+		parts = append(parts, completedPart{PartNumber: part, ETag: etag})
+		n, err = io.ReadFull(r, buf)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			s.abortMultipartUpload(ctx, key, uploadID)
+			return err
+		}
+		if err != nil {
+			// A short final part.
+			etag, err := s.uploadPart(ctx, key, uploadID, part+1, buf[:n])
+			if err != nil {
+				s.abortMultipartUpload(ctx, key, uploadID)
+				return err
+			}
+			parts = append(parts, completedPart{PartNumber: part + 1, ETag: etag})
+			break
+		}
+	}
+	if err := s.completeMultipartUpload(ctx, key, uploadID, parts); err != nil {
+		s.abortMultipartUpload(ctx, key, uploadID)
+		return err
+	}
+	return nil
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (s *S3) putObject(ctx context.Context, key string, body []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *S3) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var out struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("s3 create multipart upload: %w", err)
+	}
+	if out.UploadID == "" {
+		return "", errors.New("s3 create multipart upload: no UploadId")
+	}
+	return out.UploadID, nil
+}
+
+func (s *S3) uploadPart(ctx context.Context, key, uploadID string, part int, body []byte) (string, error) {
+	query := url.Values{"partNumber": {strconv.Itoa(part)}, "uploadId": {uploadID}}
+	resp, err := s.do(ctx, http.MethodPut, key, query, body)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+func (s *S3) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Completion can fail after a 200 status; the error is in the body.
+	var out struct {
+		XMLName xml.Name
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&out); err == nil && out.XMLName.Local == "Error" {
+		return fmt.Errorf("s3 complete multipart upload: %s: %s", out.Code, out.Message)
+	}
+	return nil
+}
+
+// abortMultipartUpload frees the parts of a failed upload, even when ctx
+// is already done.
+func (s *S3) abortMultipartUpload(ctx context.Context, key, uploadID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+	defer cancel()
+	if resp, err := s.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// do sends a signed request, retrying transport errors, 429 and 5xx. Any
+// other non-2xx status is returned as an error.
+func (s *S3) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	retries := s.Retries
+	if retries <= 0 {
+		retries = defaultRetries
+	}
+	op := "s3 " + method + " " + key
+	for attempt := 0; ; attempt++ {
+		req, err := s.newRequest(ctx, method, key, query, body)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.Client.Do(req)
+		status := 0
+		if err == nil {
+			if resp.StatusCode/100 == 2 {
+				return resp, nil
+			}
+			status = resp.StatusCode
+			err = statusError(op, resp)
+			resp.Body.Close()
+		}
+		if attempt >= retries || !retryable(status, err) {
+			return nil, err
+		}
+		if werr := wait(ctx, attempt); werr != nil {
+			return nil, errors.Join(err, werr)
+		}
+	}
+}
+
+func (s *S3) newRequest(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Request, error) {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3 endpoint: %w", err)
+	}
+	path := "/" + escapeKey(key)
+	if s.PathStyle {
+		path = "/" + escapeKey(s.Bucket) + path
+	} else {
+		endpoint.Host = s.Bucket + "." + endpoint.Host
+	}
+	target := endpoint.Scheme + "://" + endpoint.Host + strings.TrimSuffix(endpoint.Path, "/") + path
+	if len(query) > 0 {
+		target += "?" + canonicalQuery(query)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+	payload := sha256.Sum256(body)
+	s.sign(req, hex.EncodeToString(payload[:]))
+	return req, nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header covering Host,
+// Range, Content-Type, Content-MD5 and every X-Amz-* header.
+func (s *S3) sign(req *http.Request, payloadHash string) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	t := now().UTC()
+	amzDate := t.Format("20060102T150405Z")
+	day := t.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		switch {
+		case strings.HasPrefix(lower, "x-amz-"), lower == "range", lower == "content-type", lower == "content-md5":
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), day)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalQuery encodes query sorted by key with RFC 3986 escaping, as
+// Signature Version 4 requires.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
@@ -0,0 +1,137 @@
+// Package sink uploads finished downloads to remote storage: S3, Google
+// Cloud Storage (through its S3-compatible XML API) and WebDAV.
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/famomatic/ytv1/internal/httpx"
+)
+
+// Sink stores files under keys relative to its root.
+type Sink interface {
+	// Upload stores everything read from r under key. Implementations
+	// buffer at most one part of r, never the whole stream.
+	Upload(ctx context.Context, key string, r io.Reader) error
+	// URL returns the location of key, in the scheme it was opened with.
+	URL(key string) string
+}
+
+// Schemes accepted by Parse.
+const (
+	SchemeS3         = "s3"
+	SchemeGCS        = "gs"
+	SchemeWebDAV     = "webdav"      // WebDAV over HTTPS
+	SchemeWebDAVHTTP = "webdav+http" // WebDAV over plain HTTP
+)
+
+const (
+	defaultRetries      = 3
+	defaultRetryInitial = 500 * time.Millisecond
+	defaultRetryMax     = 10 * time.Second
+)
+
+// IsRemote reports whether output names a sink rather than a local path.
+func IsRemote(output string) bool {
+	scheme, _, ok := strings.Cut(strings.TrimSpace(output), "://")
+	if !ok {
+		return false
+	}
+	switch strings.ToLower(scheme) {
+	case SchemeS3, SchemeGCS, SchemeWebDAV, SchemeWebDAVHTTP:
+		return true
+	}
+	return false
+}
+
+// Parse opens the sink named by an output template such as
+// s3://bucket/prefix/%(id)s.%(ext)s and returns it with the template below
+// the sink root ("prefix/%(id)s.%(ext)s"). Credentials come from getenv:
+//
+//   - s3: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN,
+//     AWS_REGION (or AWS_DEFAULT_REGION) and AWS_ENDPOINT_URL_S3 (or
+//     AWS_ENDPOINT_URL) for S3-compatible services
+//   - gs: GCS_HMAC_ACCESS_KEY_ID and GCS_HMAC_SECRET, an HMAC key pair
+//   - webdav: user:password@ in the URL, or WEBDAV_USERNAME and
+//     WEBDAV_PASSWORD
+//
+// The template is split by hand: its %(...)s tokens are not valid URL
+// escapes.
+func Parse(output string, client *http.Client, getenv func(string) string) (Sink, string, error) {
+	output = strings.TrimSpace(output)
+	scheme, rest, ok := strings.Cut(output, "://")
+	if !ok || !IsRemote(output) {
+		return nil, "", fmt.Errorf("unsupported output sink %q: want s3://, gs://, webdav:// or webdav+http://", output)
+	}
+	scheme = strings.ToLower(scheme)
+	host, key, _ := strings.Cut(rest, "/")
+	if host == "" {
+		return nil, "", fmt.Errorf("output sink %q has no bucket or host", output)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	switch scheme {
+	case SchemeS3:
+		s, err := newS3(host, client, getenv)
+		return s, key, err
+	case SchemeGCS:
+		s, err := newGCS(host, client, getenv)
+		return s, key, err
+	default:
+		s, err := newWebDAV(scheme, host, client, getenv)
+		return s, key, err
+	}
+}
+
+// retryable reports whether a request that failed with err or answered with
+// status may succeed when repeated.
+func retryable(status int, err error) bool {
+	if err != nil {
+		return !httpx.IsPermanent(err)
+	}
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+func wait(ctx context.Context, attempt int) error {
+	t := time.NewTimer(httpx.Backoff(defaultRetryInitial, defaultRetryMax, attempt))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// escapeKey percent-encodes every byte of key outside the RFC 3986
+// unreserved set, keeping slashes. Signature Version 4 signs this exact
+// form, and titles in keys often hold characters url.PathEscape keeps.
+func escapeKey(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch c := key[i]; {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// statusError reports an unexpected response, with the start of its body.
+func statusError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	msg := strings.TrimSpace(string(body))
+	if msg == "" {
+		return fmt.Errorf("%s: status=%d", op, resp.StatusCode)
+	}
+	return fmt.Errorf("%s: status=%d: %s", op, resp.StatusCode, msg)
+}
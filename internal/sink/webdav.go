@@ -0,0 +1,133 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WebDAV uploads with PUT, creating missing parent collections with MKCOL.
+// The body is streamed, so an upload is retried only when the reader can
+// be rewound (an io.Seeker, such as a staged file).
+type WebDAV struct {
+	Client *http.Client
+	// BaseURL is the collection keys are stored under, without a trailing
+	// slash, e.g. https://dav.example.com/media.
+	BaseURL  string
+	Username string
+	Password string
+	Retries  int
+
+	scheme string
+	root   string // host and base path, as given to Parse
+}
+
+func newWebDAV(scheme, host string, client *http.Client, getenv func(string) string) (*WebDAV, error) {
+	d := &WebDAV{
+		Client:   client,
+		Username: getenv("WEBDAV_USERNAME"),
+		Password: getenv("WEBDAV_PASSWORD"),
+		scheme:   scheme,
+		root:     host,
+	}
+	if userinfo, rest, ok := strings.Cut(host, "@"); ok {
+		d.Username, d.Password, _ = strings.Cut(userinfo, ":")
+		host = rest
+		d.root = rest
+	}
+	transport := "https"
+	if scheme == SchemeWebDAVHTTP {
+		transport = "http"
+	}
+	d.BaseURL = transport + "://" + host
+	return d, nil
+}
+
+// URL implements Sink.
+func (d *WebDAV) URL(key string) string {
+	scheme := d.scheme
+	if scheme == "" {
+		scheme = SchemeWebDAV
+	}
+	return scheme + "://" + d.root + "/" + key
+}
+
+// Upload implements Sink.
+func (d *WebDAV) Upload(ctx context.Context, key string, r io.Reader) error {
+	if err := d.makeCollections(ctx, key); err != nil {
+		return err
+	}
+	retries := d.Retries
+	if retries <= 0 {
+		retries = defaultRetries
+	}
+	seeker, rewindable := r.(io.Seeker)
+	op := "webdav PUT " + key
+	for attempt := 0; ; attempt++ {
+		status, err := d.put(ctx, key, r, op)
+		if err == nil {
+			return nil
+		}
+		if !rewindable || attempt >= retries || !retryable(status, err) {
+			return err
+		}
+		if werr := wait(ctx, attempt); werr != nil {
+			return errors.Join(err, werr)
+		}
+		if _, serr := seeker.Seek(0, io.SeekStart); serr != nil {
+			return errors.Join(err, serr)
+		}
+	}
+}
+
+func (d *WebDAV) put(ctx context.Context, key string, r io.Reader, op string) (int, error) {
+	// Hide any Seeker or Len so the request streams with chunked encoding
+	// instead of the transport reading r ahead.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, d.BaseURL+"/"+escapeKey(key), io.NopCloser(r))
+	if err != nil {
+		return 0, err
+	}
+	d.authorize(req)
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, statusError(op, resp)
+	}
+	return resp.StatusCode, nil
+}
+
+// makeCollections creates the parent collections of key, outermost first.
+// Statuses are ignored: 405 means the collection exists, and servers
+// answer 403 or 409 for fixed path prefixes they will not create. A
+// collection that is really missing fails the PUT instead.
+func (d *WebDAV) makeCollections(ctx context.Context, key string) error {
+	segments := strings.Split(key, "/")
+	for i := 1; i < len(segments); i++ {
+		dir := strings.Join(segments[:i], "/")
+		if dir == "" {
+			continue
+		}
+		req, err := http.NewRequestWithContext(ctx, "MKCOL", d.BaseURL+"/"+escapeKey(dir)+"/", nil)
+		if err != nil {
+			return err
+		}
+		d.authorize(req)
+		resp, err := d.Client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+	}
+	return nil
+}
+
+func (d *WebDAV) authorize(req *http.Request) {
+	if d.Username != "" || d.Password != "" {
+		req.SetBasicAuth(d.Username, d.Password)
+	}
+}
@@ -19,3 +19,25 @@ func ClientNameFromContext(ctx context.Context) (string, bool) {
 	name, ok := ctx.Value(ClientNameKey).(string)
 	return name, ok
 }
+
+type metadataOnlyKey struct{}
+
+// WithMetadataOnly marks ctx as a metadata-only extraction: no stream URL
+// will be requested, so player JS work (signature timestamp lookup,
+// challenge solving) is deferred until one is.
+func WithMetadataOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, metadataOnlyKey{}, true)
+}
+
+// WithoutMetadataOnly clears the WithMetadataOnly mark, for work that
+// resolves stream URLs on a metadata-only context.
+func WithoutMetadataOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, metadataOnlyKey{}, false)
+}
+
+// MetadataOnlyFromContext reports whether ctx is a metadata-only
+// extraction.
+func MetadataOnlyFromContext(ctx context.Context) bool {
+	only, _ := ctx.Value(metadataOnlyKey{}).(bool)
+	return only
+}
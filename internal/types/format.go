@@ -1,5 +1,11 @@
 package types
 
+import (
+	"context"
+	"errors"
+	"time"
+)
+
 // FormatInfo is the normalized public format model.
 type FormatInfo struct {
 	Itag         int
@@ -18,4 +24,42 @@ type FormatInfo struct {
 	Quality      string
 	QualityLabel string
 	SourceClient string
+	// ContentLength is the advertised stream size in bytes (0 if unknown).
+	ContentLength int64
+	// ExpiresAt is when URL stops working, from its signed "expire"
+	// parameter (zero if unknown). Resolving the URL again after that
+	// requires a fresh player response.
+	ExpiresAt time.Time
+	// Language is the audio track language for multi-audio videos (e.g. "en").
+	Language       string
+	AudioTrackName string
+	// Hints flags known download blockers and caveats ("drm", "sabr_only",
+	// "premium", "deprecated"), from runtime fields and the itag table.
+	Hints []string
+
+	// resolve produces the playable URL on demand; see Resolve.
+	resolve func(context.Context) (string, error)
+}
+
+// ErrNoFormatURL is returned by FormatInfo.Resolve for a format with neither
+// a resolver nor a URL.
+var ErrNoFormatURL = errors.New("format has no URL")
+
+// Resolve returns the format's playable URL. Formats from the client solve
+// their n and signature challenges only here, so listing formats costs no
+// challenge work; formats built elsewhere return URL as given.
+func (f FormatInfo) Resolve(ctx context.Context) (string, error) {
+	if f.resolve != nil {
+		return f.resolve(ctx)
+	}
+	if f.URL == "" {
+		return "", ErrNoFormatURL
+	}
+	return f.URL, nil
+}
+
+// WithResolver returns a copy of f whose Resolve calls fn.
+func (f FormatInfo) WithResolver(fn func(context.Context) (string, error)) FormatInfo {
+	f.resolve = fn
+	return f
 }
@@ -1,5 +1,10 @@
 package types
 
+import (
+	"net/http"
+	"time"
+)
+
 // Metadata contains common media metadata for embedding.
 type Metadata struct {
 	Title       string
@@ -7,4 +12,36 @@ type Metadata struct {
 	Description string
 	Date        string // YYYY-MM-DD or YYYY
 	Duration    int    // Seconds
+	Language    string // language of Title and Description
+}
+
+// MuxTrack is one input stream for multi-track muxing.
+type MuxTrack struct {
+	Path     string
+	Kind     string // "video" or "audio"
+	Language string
+	Title    string
+}
+
+// ExternalDownload describes one direct media transfer handed to an
+// external downloader.
+type ExternalDownload struct {
+	URL        string
+	Headers    http.Header // full request headers, including User-Agent and Cookie
+	OutputPath string
+	Resume     bool // continue a partial OutputPath instead of overwriting it
+}
+
+// DownloadProgress is a transfer progress sample. Total is zero when unknown.
+type DownloadProgress struct {
+	Bytes int64
+	Total int64
+}
+
+// MediaProbe summarizes the streams of a media file.
+type MediaProbe struct {
+	Duration     time.Duration
+	VideoStreams int
+	AudioStreams int
+	VideoFrames  int64 // in the first video stream
 }
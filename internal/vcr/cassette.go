@@ -0,0 +1,65 @@
+// Package vcr records HTTP exchanges to JSON cassettes and replays them, so
+// tests can run the full extraction pipeline against real, recorded
+// traffic without network access.
+package vcr
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Cassette is the recorded traffic of one test.
+type Cassette struct {
+	// Note explains where the recording came from, e.g. how to refresh it.
+	Note         string        `json:"note,omitempty"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one request and the response it got.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is the recorded part of a request: enough to match it on replay.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// Match is the body fingerprint requests are matched on; see matchKey.
+	Match string `json:"match,omitempty"`
+}
+
+// Response is a recorded response. Only headers that affect parsing are
+// kept.
+type Response struct {
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Load reads the cassette at path.
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Save writes c to path, creating its directory.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
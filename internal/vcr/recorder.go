@@ -0,0 +1,203 @@
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/famomatic/ytv1/internal/httpx"
+)
+
+// Mode selects whether a Recorder replays a cassette or records a new one.
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette and never touches the
+	// network.
+	ModeReplay Mode = iota
+	// ModeRecord sends requests through the real transport and records
+	// them, replacing the cassette on Stop.
+	ModeRecord
+)
+
+// ErrNoInteraction is returned on replay for a request the cassette has no
+// recording of.
+var ErrNoInteraction = errors.New("vcr: no recorded interaction")
+
+// Recorder is an http.RoundTripper that records or replays a cassette.
+//
+// Requests match a recording on method, URL without volatile parameters
+// and, for Innertube JSON bodies, the client name and the video, browse or
+// continuation they ask for. Repeated identical requests replay their
+// recordings in order and then keep getting the last one.
+type Recorder struct {
+	mode Mode
+	path string
+	real http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     map[int]bool
+}
+
+// New returns a Recorder for the cassette at path. In ModeReplay the
+// cassette must exist; in ModeRecord requests go through real (nil means
+// http.DefaultTransport) and the cassette is written by Stop.
+func New(path string, mode Mode, real http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path, real: real, used: make(map[int]bool)}
+	if r.real == nil {
+		r.real = http.DefaultTransport
+	}
+	if mode == ModeRecord {
+		r.cassette = &Cassette{}
+		return r, nil
+	}
+	c, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	r.cassette = c
+	return r, nil
+}
+
+// SetNote sets the note saved with a recorded cassette.
+func (r *Recorder) SetNote(note string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Note = note
+}
+
+// Stop writes the recorded cassette. It does nothing on replay.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	key := Request{Method: req.Method, URL: sanitizeURL(req.URL.String()), Match: matchKey(body)}
+
+	if r.mode == ModeRecord {
+		return r.record(req, key)
+	}
+	resp, ok := r.replay(key)
+	if !ok {
+		return nil, fmt.Errorf("%w for %s %s %s", ErrNoInteraction, key.Method, key.URL, key.Match)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        resp.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) replay(key Request) (Response, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := -1
+	for i, it := range r.cassette.Interactions {
+		if it.Request != key {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return it.Response, true
+		}
+		last = i
+	}
+	if last < 0 {
+		return Response{}, false
+	}
+	return r.cassette.Interactions[last].Response, true
+}
+
+func (r *Recorder) record(req *http.Request, key Request) (*http.Response, error) {
+	resp, err := r.real.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := httpx.ReadBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	// The body is stored and replayed decoded, whatever the encoding.
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: key,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     sanitizeHeader(resp.Header),
+			Body:       SanitizeBody(string(data)),
+		},
+	})
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.ContentLength = int64(len(data))
+	return resp, nil
+}
+
+// matchKey fingerprints a JSON request body by the fields that pick the
+// answer: the Innertube client and the video, browse or continuation
+// requested. Other bodies (and their absence) match on method and URL
+// alone.
+func matchKey(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var req struct {
+		Context struct {
+			Client struct {
+				ClientName string `json:"clientName"`
+			} `json:"client"`
+		} `json:"context"`
+		VideoID      string `json:"videoId"`
+		BrowseID     string `json:"browseId"`
+		PlaylistID   string `json:"playlistId"`
+		Continuation string `json:"continuation"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, f := range []struct{ name, value string }{
+		{"client", req.Context.Client.ClientName},
+		{"video", req.VideoID},
+		{"browse", req.BrowseID},
+		{"playlist", req.PlaylistID},
+		{"continuation", req.Continuation},
+	} {
+		if f.value != "" {
+			parts = append(parts, f.name+"="+f.value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,233 @@
+package vcr
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func do(t *testing.T, rt http.RoundTripper, method, url, body string) (*http.Response, string) {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip(%s %s) error = %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(data)
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	calls := 0
+	real := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		io.WriteString(gz, `{"visitorData":"CgtXYZ","url":"https://rr1.googlevideo.com/videoplayback?id=1&ip=198.51.100.4&itag=18"}`)
+		gz.Close()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header: http.Header{
+				"Content-Type":     {"application/json"},
+				"Content-Encoding": {"gzip"},
+				"Set-Cookie":       {"YSC=abc; Path=/"},
+			},
+			Body: io.NopCloser(&buf),
+		}, nil
+	})
+
+	rec, err := New(path, ModeRecord, real)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.SetNote("test")
+	_, body := do(t, rec, http.MethodPost, "https://www.youtube.com/youtubei/v1/player?key=k&cpn=AAAA", `{"context":{"client":{"clientName":"WEB"}},"videoId":"abc"}`)
+	if !strings.Contains(body, "198.51.100.4") {
+		t.Fatalf("recording should pass the real body through, got %q", body)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Note != "test" || len(c.Interactions) != 1 {
+		t.Fatalf("cassette = %+v", c)
+	}
+	it := c.Interactions[0]
+	if it.Request.URL != "https://www.youtube.com/youtubei/v1/player?key=k" {
+		t.Fatalf("recorded URL = %q", it.Request.URL)
+	}
+	if it.Request.Match != "client=WEB video=abc" {
+		t.Fatalf("recorded match = %q", it.Request.Match)
+	}
+	if it.Response.Header.Get("Set-Cookie") != "" || it.Response.Header.Get("Content-Encoding") != "" {
+		t.Fatalf("recorded headers = %v", it.Response.Header)
+	}
+	if strings.Contains(it.Response.Body, "198.51.100.4") || strings.Contains(it.Response.Body, "CgtXYZ") {
+		t.Fatalf("recorded body not sanitized: %s", it.Response.Body)
+	}
+
+	replay, err := New(path, ModeReplay, real)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, body := do(t, replay, http.MethodPost, "https://www.youtube.com/youtubei/v1/player?cpn=BBBB&key=k", `{"videoId":"abc","context":{"client":{"clientName":"WEB","hl":"en"}}}`)
+	if resp.StatusCode != http.StatusOK || body != it.Response.Body {
+		t.Fatalf("replay = %d %q", resp.StatusCode, body)
+	}
+	if calls != 1 {
+		t.Fatalf("replay reached the real transport; calls = %d", calls)
+	}
+}
+
+func TestReplayUnknownRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	c := &Cassette{Interactions: []Interaction{{
+		Request:  Request{Method: http.MethodPost, URL: "https://www.youtube.com/youtubei/v1/player", Match: "client=WEB video=abc"},
+		Response: Response{StatusCode: http.StatusOK, Body: "{}"},
+	}}}
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := New(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, "https://www.youtube.com/youtubei/v1/player", strings.NewReader(`{"context":{"client":{"clientName":"MWEB"}},"videoId":"abc"}`))
+	if _, err := rec.RoundTrip(req); !errors.Is(err, ErrNoInteraction) {
+		t.Fatalf("RoundTrip() error = %v, want ErrNoInteraction", err)
+	}
+}
+
+func TestReplayRepeatedRequestsInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	const url = "https://www.youtube.com/watch?v=abc"
+	c := &Cassette{}
+	for _, body := range []string{"first", "second"} {
+		c.Interactions = append(c.Interactions, Interaction{
+			Request:  Request{Method: http.MethodGet, URL: url},
+			Response: Response{StatusCode: http.StatusOK, Body: body},
+		})
+	}
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := New(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"first", "second", "second"} {
+		if _, got := do(t, rec, http.MethodGet, url, ""); got != want {
+			t.Fatalf("replay body = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestRecordDecodesBrotli(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	real := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var buf bytes.Buffer
+		bw := brotli.NewWriter(&buf)
+		io.WriteString(bw, `<script>ytcfg.set({"VISITOR_DATA":"CgtXYZ"});</script>`)
+		bw.Close()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"text/html"}, "Content-Encoding": {"br"}},
+			Body:       io.NopCloser(&buf),
+		}, nil
+	})
+
+	rec, err := New(path, ModeRecord, real)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, body := do(t, rec, http.MethodGet, "https://www.youtube.com/watch?v=abc", "")
+	if !strings.Contains(body, "CgtXYZ") || resp.Header.Get("Content-Encoding") != "" {
+		t.Fatalf("recording should pass the decoded body through, got %q %v", body, resp.Header)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<script>ytcfg.set({"VISITOR_DATA":"REDACTED"});</script>`
+	if got := c.Interactions[0].Response.Body; got != want {
+		t.Fatalf("recorded body = %q, want %q", got, want)
+	}
+}
+
+func TestSanitizeBody(t *testing.T) {
+	in := `{"visitorData" : "Cgt123",` +
+		`"url":"https://x/videoplayback?ip=1.2.3.4&itag=18&ei=abc",` +
+		`"signatureCipher":"s=xyz&url=https%3A%2F%2Fx%2Fvideoplayback%3Fpot%3Dtok%26itag%3D18",` +
+		`"hlsManifestUrl":"https://m/api/manifest/hls_variant/ei/abc/ip/1.2.3.4/id/v/file/index.m3u8"}`
+	want := `{"visitorData":"REDACTED",` +
+		`"url":"https://x/videoplayback?ip=REDACTED&itag=18&ei=REDACTED",` +
+		`"signatureCipher":"s=xyz&url=https%3A%2F%2Fx%2Fvideoplayback%3Fpot%3DREDACTED%26itag%3D18",` +
+		`"hlsManifestUrl":"https://m/api/manifest/hls_variant/ei/REDACTED/ip/REDACTED/id/v/file/index.m3u8"}`
+	if got := SanitizeBody(in); got != want {
+		t.Fatalf("SanitizeBody() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSanitizeURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"https://x/y?b=2&a=1&cpn=zz&rn=3#frag", "https://x/y?a=1&b=2"},
+		{"https://x/y", "https://x/y"},
+		{"https://m/api/manifest/hls_playlist/ip/1.2.3.4/itag/95/file/index.m3u8", "https://m/api/manifest/hls_playlist/ip/REDACTED/itag/95/file/index.m3u8"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeURL(tt.in); got != tt.want {
+			t.Errorf("sanitizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMatchKey(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"", ""},
+		{"not json", ""},
+		{`{"context":{"client":{"clientName":"ANDROID"}},"videoId":"v"}`, "client=ANDROID video=v"},
+		{`{"context":{"client":{"clientName":"WEB"}},"continuation":"tok"}`, "client=WEB continuation=tok"},
+		{`{"context":{"client":{"clientName":"WEB"}},"browseId":"VLPL1","playlistId":"PL1"}`, "client=WEB browse=VLPL1 playlist=PL1"},
+	}
+	for _, tt := range tests {
+		if got := matchKey([]byte(tt.body)); got != tt.want {
+			t.Errorf("matchKey(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
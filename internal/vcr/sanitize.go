@@ -0,0 +1,88 @@
+package vcr
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// visitorData in API responses, VISITOR_DATA in a watch page's ytcfg.
+	visitorDataPattern = regexp.MustCompile(`"(visitorData|VISITOR_DATA)"\s*:\s*"[^"]*"`)
+	// Stream URL parameters tied to the recording machine or session, plain
+	// or JSON-escaped (&ip=) and percent-encoded inside signatureCipher.
+	queryParamPattern   = regexp.MustCompile(`([?&]|\\u0026)(ip|ei|pot|cpn)=[^&"\\]*`)
+	encodedParamPattern = regexp.MustCompile(`(%3F|%26)(ip|ei|pot|cpn)%3D[^%&"\\]*`)
+	// Manifest URLs carry the same parameters as /name/value path segments.
+	pathParamPattern = regexp.MustCompile(`/(ip|ei|pot|cpn)/[^/?&"\\\s]+`)
+)
+
+// volatileParams are dropped from recorded and replayed request URLs: they
+// differ per run or identify the recording session.
+var volatileParams = map[string]bool{
+	"cpn": true, "ei": true, "ip": true, "pot": true, "rn": true, "t": true,
+}
+
+// keptHeaders are the response headers worth recording.
+var keptHeaders = []string{"Content-Type", "Content-Range", "Location", "Retry-After"}
+
+// SanitizeBody redacts visitor data and session-bound stream URL parameters
+// from a recorded body.
+func SanitizeBody(body string) string {
+	body = visitorDataPattern.ReplaceAllString(body, `"${1}":"REDACTED"`)
+	body = queryParamPattern.ReplaceAllString(body, "${1}${2}=REDACTED")
+	body = pathParamPattern.ReplaceAllString(body, "/${1}/REDACTED")
+	return encodedParamPattern.ReplaceAllString(body, "${1}${2}%3DREDACTED")
+}
+
+// sanitizeURL drops volatile query parameters, redacts their path-segment
+// form and sorts the rest, so a request matches its recording regardless of
+// parameter order.
+func sanitizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	q := u.Query()
+	for name := range q {
+		if volatileParams[name] {
+			q.Del(name)
+		}
+	}
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		for _, v := range q[k] {
+			if b.Len() > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(url.QueryEscape(k) + "=" + url.QueryEscape(v))
+		}
+	}
+	u.RawQuery = b.String()
+	u.Fragment = ""
+	u.Path = pathParamPattern.ReplaceAllString(u.Path, "/${1}/REDACTED")
+	u.RawPath = ""
+	return u.String()
+}
+
+// sanitizeHeader keeps only keptHeaders; cookies and anything identifying
+// the recording session are never written.
+func sanitizeHeader(h http.Header) http.Header {
+	out := make(http.Header)
+	for _, name := range keptHeaders {
+		if v := h.Values(name); len(v) > 0 {
+			out[name] = append([]string(nil), v...)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
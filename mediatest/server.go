@@ -0,0 +1,269 @@
+// Package mediatest provides a fake googlevideo media server for tests of
+// code that downloads through ytv1: it serves one payload with byte-range
+// support and can reproduce the quirks real media hosts show, such as
+// ignored ranges, 403s part-way through a stream, throttling and expired
+// URLs.
+//
+// It is used by ytv1's own downloader tests and is importable by consumers
+// writing integration tests against the client.
+package mediatest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configures a Server. The zero value serves an empty payload with
+// well-behaved range support.
+type Options struct {
+	// Payload is the media body served at every path.
+	Payload []byte
+
+	// IgnoreRange answers every request with 200 and the whole payload, like
+	// hosts without range support.
+	IgnoreRange bool
+	// RequireRange rejects requests without a Range header with 400.
+	RequireRange bool
+	// UnknownLength sends Content-Range totals as "*", hiding the size.
+	UnknownLength bool
+
+	// ForbidAfterBytes, when positive, cuts the response that crosses this
+	// many bytes served in total and answers every later request with 403,
+	// like a URL googlevideo stops honoring mid-download.
+	ForbidAfterBytes int64
+	// FailFirst answers the first FailFirst requests with FailStatus
+	// (default 503).
+	FailFirst  int
+	FailStatus int
+
+	// BytesPerSecond, when positive, throttles each response body.
+	BytesPerSecond int64
+	// Latency delays every response.
+	Latency time.Duration
+
+	// Now is the clock checked against SignedURL expiries; nil means
+	// time.Now.
+	Now func() time.Time
+}
+
+// Request records one request the Server received.
+type Request struct {
+	Path   string
+	Range  string
+	Header http.Header
+	// Status is the status code the Server answered with.
+	Status int
+}
+
+// Server is a running fake media server. Close it when done.
+type Server struct {
+	*httptest.Server
+	opts Options
+
+	mu       sync.Mutex
+	requests []Request
+	served   int64
+}
+
+// NewServer starts a Server with opts.
+func NewServer(opts Options) *Server {
+	if opts.FailStatus == 0 {
+		opts.FailStatus = http.StatusServiceUnavailable
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	s := &Server{opts: opts}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// MediaURL returns a URL for the payload, shaped like a googlevideo
+// videoplayback URL.
+func (s *Server) MediaURL() string {
+	return s.URL + "/videoplayback"
+}
+
+// SignedURL returns a MediaURL carrying an expire parameter; requests to it
+// after expire get 403, as googlevideo answers for stale stream URLs.
+func (s *Server) SignedURL(expire time.Time) string {
+	return fmt.Sprintf("%s?expire=%d", s.MediaURL(), expire.Unix())
+}
+
+// Requests returns the requests received so far, in arrival order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Ranges returns the Range header of each request so far, "" when absent.
+func (s *Server) Ranges() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]string, len(s.requests))
+	for i, r := range s.requests {
+		out[i] = r.Range
+	}
+	return out
+}
+
+// BytesServed returns the payload bytes written so far.
+func (s *Server) BytesServed() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.served
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Latency > 0 {
+		select {
+		case <-time.After(s.opts.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	s.mu.Lock()
+	index := len(s.requests)
+	s.requests = append(s.requests, Request{Path: r.URL.Path, Range: r.Header.Get("Range"), Header: r.Header.Clone()})
+	exhausted := s.opts.ForbidAfterBytes > 0 && s.served >= s.opts.ForbidAfterBytes
+	s.mu.Unlock()
+	status := func(code int) {
+		s.mu.Lock()
+		s.requests[index].Status = code
+		s.mu.Unlock()
+	}
+	fail := func(code int) {
+		status(code)
+		http.Error(w, http.StatusText(code), code)
+	}
+
+	switch {
+	case index < s.opts.FailFirst:
+		fail(s.opts.FailStatus)
+		return
+	case exhausted || s.expired(r):
+		fail(http.StatusForbidden)
+		return
+	}
+
+	payload := s.opts.Payload
+	size := int64(len(payload))
+	rangeHeader := r.Header.Get("Range")
+	if rangeHeader == "" && s.opts.RequireRange {
+		fail(http.StatusBadRequest)
+		return
+	}
+	if rangeHeader == "" || s.opts.IgnoreRange {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		status(http.StatusOK)
+		w.WriteHeader(http.StatusOK)
+		s.write(w, r, payload)
+		return
+	}
+
+	start, end, ok := parseRange(rangeHeader, size)
+	if !ok {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		fail(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	total := strconv.FormatInt(size, 10)
+	if s.opts.UnknownLength {
+		total = "*"
+	}
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%s", start, end, total))
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	status(http.StatusPartialContent)
+	w.WriteHeader(http.StatusPartialContent)
+	s.write(w, r, payload[start:end+1])
+}
+
+func (s *Server) expired(r *http.Request) bool {
+	raw := r.URL.Query().Get("expire")
+	if raw == "" {
+		return false
+	}
+	expire, err := strconv.ParseInt(raw, 10, 64)
+	return err != nil || !s.opts.Now().Before(time.Unix(expire, 0))
+}
+
+// write sends body in pieces, honoring BytesPerSecond and ForbidAfterBytes.
+// A body cut short leaves the declared Content-Length unmet, so clients see
+// an unexpected EOF.
+func (s *Server) write(w http.ResponseWriter, r *http.Request, body []byte) {
+	const piece = 4 * 1024
+	flusher, _ := w.(http.Flusher)
+	for len(body) > 0 {
+		n := int64(len(body))
+		if n > piece {
+			n = piece
+		}
+		s.mu.Lock()
+		if limit := s.opts.ForbidAfterBytes; limit > 0 && s.served+n > limit {
+			n = limit - s.served
+		}
+		s.served += n
+		s.mu.Unlock()
+		if n <= 0 {
+			return
+		}
+		if s.opts.BytesPerSecond > 0 {
+			select {
+			case <-time.After(time.Duration(n) * time.Second / time.Duration(s.opts.BytesPerSecond)):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if _, err := w.Write(body[:n]); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		body = body[n:]
+	}
+}
+
+// parseRange parses a single "bytes=start-end", "bytes=start-" or
+// "bytes=-suffix" range against size.
+func parseRange(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	if first == "" {
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix <= 0 || size == 0 {
+			return 0, 0, false
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return size - suffix, size - 1, true
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+	return start, end, true
+}
@@ -0,0 +1,156 @@
+package mediatest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, s *Server, url, rangeHeader string) (*http.Response, []byte, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rangeHeader != "" {
+		req.Header.Set("Range", rangeHeader)
+	}
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp, body, err
+}
+
+func TestServer_Ranges(t *testing.T) {
+	s := NewServer(Options{Payload: []byte("0123456789")})
+	defer s.Close()
+
+	tests := []struct {
+		rangeHeader  string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{"", http.StatusOK, "0123456789", ""},
+		{"bytes=0-0", http.StatusPartialContent, "0", "bytes 0-0/10"},
+		{"bytes=4-", http.StatusPartialContent, "456789", "bytes 4-9/10"},
+		{"bytes=-3", http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{"bytes=8-20", http.StatusPartialContent, "89", "bytes 8-9/10"},
+		{"bytes=10-", http.StatusRequestedRangeNotSatisfiable, "", "bytes */10"},
+	}
+	for _, tt := range tests {
+		resp, body, err := get(t, s, s.MediaURL(), tt.rangeHeader)
+		if err != nil {
+			t.Fatalf("Range %q: read error = %v", tt.rangeHeader, err)
+		}
+		if resp.StatusCode != tt.status {
+			t.Fatalf("Range %q: status = %d, want %d", tt.rangeHeader, resp.StatusCode, tt.status)
+		}
+		if tt.status != http.StatusRequestedRangeNotSatisfiable && string(body) != tt.body {
+			t.Fatalf("Range %q: body = %q, want %q", tt.rangeHeader, body, tt.body)
+		}
+		if got := resp.Header.Get("Content-Range"); got != tt.contentRange {
+			t.Fatalf("Range %q: Content-Range = %q, want %q", tt.rangeHeader, got, tt.contentRange)
+		}
+	}
+	if got := len(s.Requests()); got != len(tests) {
+		t.Fatalf("Requests() = %d, want %d", got, len(tests))
+	}
+}
+
+func TestServer_Quirks(t *testing.T) {
+	t.Run("ignore range", func(t *testing.T) {
+		s := NewServer(Options{Payload: []byte("abc"), IgnoreRange: true})
+		defer s.Close()
+		resp, body, _ := get(t, s, s.MediaURL(), "bytes=1-")
+		if resp.StatusCode != http.StatusOK || string(body) != "abc" {
+			t.Fatalf("status=%d body=%q, want 200 abc", resp.StatusCode, body)
+		}
+	})
+	t.Run("unknown length", func(t *testing.T) {
+		s := NewServer(Options{Payload: []byte("abc"), UnknownLength: true})
+		defer s.Close()
+		resp, _, _ := get(t, s, s.MediaURL(), "bytes=0-0")
+		if got := resp.Header.Get("Content-Range"); got != "bytes 0-0/*" {
+			t.Fatalf("Content-Range = %q", got)
+		}
+	})
+	t.Run("require range", func(t *testing.T) {
+		s := NewServer(Options{Payload: []byte("abc"), RequireRange: true})
+		defer s.Close()
+		resp, _, _ := get(t, s, s.MediaURL(), "")
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400", resp.StatusCode)
+		}
+	})
+	t.Run("fail first", func(t *testing.T) {
+		s := NewServer(Options{Payload: []byte("abc"), FailFirst: 2, FailStatus: http.StatusTooManyRequests})
+		defer s.Close()
+		var statuses []int
+		for i := 0; i < 3; i++ {
+			resp, _, _ := get(t, s, s.MediaURL(), "")
+			statuses = append(statuses, resp.StatusCode)
+		}
+		if statuses[0] != 429 || statuses[1] != 429 || statuses[2] != 200 {
+			t.Fatalf("statuses = %v, want 429,429,200", statuses)
+		}
+		if got := s.Requests()[2].Status; got != 200 {
+			t.Fatalf("recorded status = %d", got)
+		}
+	})
+}
+
+func TestServer_ForbidAfterBytes(t *testing.T) {
+	payload := make([]byte, 10*1024)
+	s := NewServer(Options{Payload: payload, ForbidAfterBytes: 6 * 1024})
+	defer s.Close()
+
+	_, body, err := get(t, s, s.MediaURL(), "")
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("read error = %v, want unexpected EOF", err)
+	}
+	if len(body) != 6*1024 {
+		t.Fatalf("read %d bytes before the cut, want %d", len(body), 6*1024)
+	}
+	resp, _, _ := get(t, s, s.MediaURL(), "bytes=6144-")
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("status after limit = %d, want 403", resp.StatusCode)
+	}
+	if got := s.BytesServed(); got != 6*1024 {
+		t.Fatalf("BytesServed() = %d", got)
+	}
+}
+
+func TestServer_SignedURLExpires(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := NewServer(Options{Payload: []byte("abc"), Now: func() time.Time { return now }})
+	defer s.Close()
+
+	url := s.SignedURL(time.Unix(1060, 0))
+	if resp, _, _ := get(t, s, url, ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("fresh URL status = %d, want 200", resp.StatusCode)
+	}
+	now = time.Unix(1060, 0)
+	if resp, _, _ := get(t, s, url, ""); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expired URL status = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestServer_Throttles(t *testing.T) {
+	s := NewServer(Options{Payload: make([]byte, 8*1024), BytesPerSecond: 64 * 1024})
+	defer s.Close()
+
+	start := time.Now()
+	if _, _, err := get(t, s, s.MediaURL(), ""); err != nil {
+		t.Fatal(err)
+	}
+	// Two 4 KiB pieces at 64 KiB/s take at least ~125ms.
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("throttled transfer took %v", elapsed)
+	}
+}
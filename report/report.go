@@ -0,0 +1,198 @@
+// Package report defines the JSON documents written by the ytv1 CLI so
+// downstream tools can unmarshal them into typed structs.
+//
+// Every document carries SchemaVersion. Within one schema version, fields are
+// only added, never renamed, removed or retyped; any incompatible change bumps
+// SchemaVersion.
+package report
+
+import (
+	"time"
+
+	"github.com/famomatic/ytv1/client"
+)
+
+// SchemaVersion is the current version of every document in this package.
+const SchemaVersion = 1
+
+// Video is the --print-json / --dump-single-json payload. Apart from
+// SchemaVersion its fields follow yt-dlp's single-entry JSON names.
+type Video struct {
+	SchemaVersion int      `json:"schema_version"`
+	ID            string   `json:"id"`
+	Title         string   `json:"title,omitempty"`
+	WebpageURL    string   `json:"webpage_url,omitempty"`
+	OriginalURL   string   `json:"original_url,omitempty"`
+	Extractor     string   `json:"extractor,omitempty"`
+	ExtractorKey  string   `json:"extractor_key,omitempty"`
+	URL           string   `json:"url,omitempty"`
+	Ext           string   `json:"ext,omitempty"`
+	Formats       []Format `json:"formats,omitempty"`
+}
+
+// Format is one entry of Video.Formats.
+type Format struct {
+	FormatID string `json:"format_id,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Ext      string `json:"ext,omitempty"`
+	VCodec   string `json:"vcodec,omitempty"`
+	ACodec   string `json:"acodec,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	FPS      int    `json:"fps,omitempty"`
+	TBR      int    `json:"tbr,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+	// Hints mirrors client.FormatInfo.Hints (e.g. "drm", "sabr_only").
+	Hints []string `json:"hints,omitempty"`
+	// ExpiresAt is the Unix time URL stops working (0 if unknown).
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+}
+
+// Failure is the per-input error document printed in --print-json mode.
+type Failure struct {
+	SchemaVersion int         `json:"schema_version"`
+	OK            bool        `json:"ok"`
+	Input         string      `json:"input"`
+	ExitCode      int         `json:"exit_code"`
+	Error         ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes a failure. Category is a client.ErrorCategory value.
+type ErrorDetail struct {
+	Category    string                 `json:"category"`
+	Message     string                 `json:"message"`
+	Playability *Playability           `json:"playability,omitempty"`
+	Attempts    []client.AttemptDetail `json:"attempts,omitempty"`
+}
+
+// Playability is YouTube's playability verdict for a rejected video.
+type Playability struct {
+	Status             string   `json:"status,omitempty"`
+	Reason             string   `json:"reason,omitempty"`
+	Subreason          string   `json:"subreason,omitempty"`
+	AvailableCountries []string `json:"available_countries,omitempty"`
+}
+
+// Run is the end-of-run overview written by --write-report. BytesReceived
+// counts all HTTP traffic of the run, media and metadata included, against
+// the --max-total-bytes budget in MaxTotalBytes.
+type Run struct {
+	SchemaVersion int            `json:"schema_version"`
+	Total         int            `json:"total"`
+	Succeeded     int            `json:"succeeded"`
+	Failed        int            `json:"failed"`
+	Skipped       int            `json:"skipped"`
+	Aborted       bool           `json:"aborted"`
+	ExitCode      int            `json:"exit_code"`
+	Categories    map[string]int `json:"categories,omitempty"`
+	BytesReceived int64          `json:"bytes_received"`
+	MaxTotalBytes int64          `json:"max_total_bytes,omitempty"`
+	Items         []RunItem      `json:"items"`
+}
+
+// Capabilities is the --capabilities payload. Version is the CLI release
+// version when known, which may differ from the library's module version.
+type Capabilities struct {
+	SchemaVersion int `json:"schema_version"`
+	client.Capabilities
+}
+
+// NewCapabilities wraps client capabilities, overriding Version when version
+// is non-empty.
+func NewCapabilities(caps client.Capabilities, version string) Capabilities {
+	if version != "" {
+		caps.Version = version
+	}
+	return Capabilities{SchemaVersion: SchemaVersion, Capabilities: caps}
+}
+
+// DebugReport is the --write-debug-report payload for one video.
+type DebugReport struct {
+	SchemaVersion int `json:"schema_version"`
+	*client.DebugReport
+}
+
+// NewDebugReport wraps a client debug report.
+func NewDebugReport(r *client.DebugReport) DebugReport {
+	return DebugReport{SchemaVersion: SchemaVersion, DebugReport: r}
+}
+
+// RunItem statuses.
+const (
+	StatusOK      = "ok"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// RunItem is one input of a Run.
+type RunItem struct {
+	Input    string `json:"input"`
+	Status   string `json:"status"`
+	Category string `json:"category,omitempty"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Event kinds.
+const (
+	EventExtraction = "extraction"
+	EventDownload   = "download"
+	// EventSchemaDrift events have Stage "player_response", Phase set to the
+	// client.SchemaDrift kind and Detail "<json path>: <value>".
+	EventSchemaDrift = "schema_drift"
+)
+
+// Event is one line of the --events-ndjson stream: a client extraction or
+// download lifecycle event, or a player response schema drift finding.
+type Event struct {
+	SchemaVersion int       `json:"schema_version"`
+	Time          time.Time `json:"time"`
+	Kind          string    `json:"kind"`
+	Stage         string    `json:"stage"`
+	Phase         string    `json:"phase"`
+	Client        string    `json:"client,omitempty"`
+	VideoID       string    `json:"video_id,omitempty"`
+	Path          string    `json:"path,omitempty"`
+	Detail        string    `json:"detail,omitempty"`
+}
+
+// NewExtractionEvent converts a client extraction event.
+func NewExtractionEvent(at time.Time, evt client.ExtractionEvent) Event {
+	return Event{
+		SchemaVersion: SchemaVersion,
+		Time:          at,
+		Kind:          EventExtraction,
+		Stage:         evt.Stage,
+		Phase:         evt.Phase,
+		Client:        evt.Client,
+		Detail:        evt.Detail,
+	}
+}
+
+// NewDownloadEvent converts a client download event.
+func NewDownloadEvent(at time.Time, evt client.DownloadEvent) Event {
+	return Event{
+		SchemaVersion: SchemaVersion,
+		Time:          at,
+		Kind:          EventDownload,
+		Stage:         evt.Stage,
+		Phase:         evt.Phase,
+		VideoID:       evt.VideoID,
+		Path:          evt.Path,
+		Detail:        evt.Detail,
+	}
+}
+
+// NewSchemaDriftEvent converts a client schema drift finding.
+func NewSchemaDriftEvent(at time.Time, d client.SchemaDrift) Event {
+	return Event{
+		SchemaVersion: SchemaVersion,
+		Time:          at,
+		Kind:          EventSchemaDrift,
+		Stage:         "player_response",
+		Phase:         d.Kind,
+		Client:        d.Client,
+		VideoID:       d.VideoID,
+		Detail:        d.Path + ": " + d.Value,
+	}
+}